@@ -0,0 +1,128 @@
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/araddon/qlbridge/lex"
+)
+
+var (
+	// keywords for completion from the sql dialect plus the expression
+	// operators which are not statement clauses.
+	keywords = append(lex.SqlDialect.Keywords(), "and", "or", "not", "in", "like",
+		"between", "is", "null", "as", "distinct", "asc", "desc", "contains", "intersects")
+
+	metaCommands = []string{`\d`, `\c`, `\l`, `\format`, `\timing`, `\?`, `\q`}
+)
+
+// complete is the liner word completer, it completes the word at pos
+// with keywords, tables and columns of the current schema.
+func (m *shell) complete(line string, pos int) (head string, completions []string, tail string) {
+	start := pos
+	for start > 0 && isWordChar(line[start-1]) {
+		start--
+	}
+	head, word, tail := line[:start], line[start:pos], line[pos:]
+	return head, m.completions(head, word), tail
+}
+
+// completions for word, head is the line preceding it.
+func (m *shell) completions(head, word string) []string {
+	trimmed := strings.TrimSpace(head)
+	if trimmed == "" && strings.HasPrefix(word, `\`) {
+		return prefixed(metaCommands, word, false)
+	}
+	if trimmed == `\c` {
+		return prefixed(m.reg.Schemas(), word, false)
+	}
+
+	tables := m.tables()
+	lastWord := strings.ToLower(lastField(trimmed))
+	switch lastWord {
+	case "from", "join", "into", "update", "table", "describe", `\d`:
+		// only tables follow these
+		return prefixed(tables, word, false)
+	}
+
+	// table.col qualified
+	if idx := strings.LastIndex(word, "."); idx > 0 {
+		var out []string
+		for _, col := range m.columns(word[:idx]) {
+			out = append(out, word[:idx+1]+col)
+		}
+		return prefixed(out, word, false)
+	}
+
+	var out []string
+	out = append(out, prefixed(keywords, word, true)...)
+	out = append(out, prefixed(tables, word, false)...)
+	for _, tbl := range tables {
+		out = append(out, prefixed(m.columns(tbl), word, false)...)
+	}
+	return dedupe(out)
+}
+
+func (m *shell) tables() []string {
+	if m.schema == nil {
+		return nil
+	}
+	tables := append([]string(nil), m.schema.Tables()...)
+	sort.Strings(tables)
+	return tables
+}
+
+func (m *shell) columns(table string) []string {
+	if m.schema == nil {
+		return nil
+	}
+	tbl, err := m.schema.Table(table)
+	if err != nil || tbl == nil {
+		return nil
+	}
+	return tbl.Columns()
+}
+
+// prefixed filters list by case-insensitive prefix, keywords are returned
+// in the case the user is typing in.
+func prefixed(list []string, word string, keyword bool) []string {
+	lw := strings.ToLower(word)
+	upper := word != "" && word == strings.ToUpper(word) && word != lw
+	var out []string
+	for _, s := range list {
+		if !strings.HasPrefix(strings.ToLower(s), lw) {
+			continue
+		}
+		if keyword && upper {
+			s = strings.ToUpper(s)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func dedupe(list []string) []string {
+	seen := make(map[string]struct{}, len(list))
+	out := list[:0]
+	for _, s := range list {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		out = append(out, s)
+	}
+	return out
+}
+
+func lastField(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' || c == '\\' || c == '@' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,176 @@
+// qlsh is an interactive sql shell for querying qlbridge data sources.
+//
+// Sources are loaded from a json file containing a list of schema.ConfigSource
+//
+//    [
+//      {"name": "baseball", "type": "cloudstore", "settings": {"type": "localfs", "path": "baseball/"}}
+//    ]
+//
+//    qlsh --config=sources.json --schema=baseball
+//
+//    baseball> SELECT playerid, yearid FROM appearances
+//           -> LIMIT 10;
+//
+// Statements are terminated by a semi-colon and may span multiple lines.
+// Type \? for the list of shell commands.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	u "github.com/araddon/gou"
+	"github.com/peterh/liner"
+
+	// Side-Effect imports to register the source types
+	_ "github.com/araddon/qlbridge/datasource/elasticsearch"
+	_ "github.com/araddon/qlbridge/datasource/files"
+	_ "github.com/araddon/qlbridge/datasource/sqlite"
+	"github.com/araddon/qlbridge/expr/builtins"
+	// CREATE FUNCTION ... LANGUAGE wasm
+	"github.com/araddon/qlbridge/expr/wasm"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	configFile  string
+	schemaName  string
+	format      = "table"
+	historyFile string
+	execSql     string
+	timing      = true
+	logging     = "warn"
+	wasmDir     string
+)
+
+func init() {
+	flag.StringVar(&configFile, "config", "", "json file with list of source configs [{\"name\":...,\"type\":...}]")
+	flag.StringVar(&schemaName, "schema", "", "schema to use, defaults to first source in config")
+	flag.StringVar(&format, "format", "table", "output format [table,csv,json]")
+	flag.StringVar(&historyFile, "history", filepath.Join(os.Getenv("HOME"), ".qlsh_history"), "history file")
+	flag.StringVar(&execSql, "e", "", "execute statement(s) and exit")
+	flag.BoolVar(&timing, "timing", true, "print query timing")
+	flag.StringVar(&logging, "logging", "warn", "logging [debug,info,warn,error]")
+	flag.StringVar(&wasmDir, "wasmdir", "", "directory of wasm modules for CREATE FUNCTION ... LANGUAGE wasm FROM 'file.wasm'")
+}
+
+func main() {
+	flag.Parse()
+	u.SetupLogging(logging)
+	u.SetColorOutput()
+
+	builtins.LoadAllBuiltins()
+	wasm.ModuleDir = wasmDir
+
+	reg := schema.DefaultRegistry()
+	if configFile != "" {
+		confs, err := schema.LoadSourceConfigs(configFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not load config %q: %v\n", configFile, err)
+			os.Exit(1)
+		}
+		for _, conf := range confs {
+			if err := reg.SchemaAddFromConfig(conf); err != nil {
+				fmt.Fprintf(os.Stderr, "could not load source %q: %v\n", conf.Name, err)
+				os.Exit(1)
+			}
+		}
+		if schemaName == "" && len(confs) > 0 {
+			schemaName = confs[0].Name
+			if confs[0].Schema != "" {
+				schemaName = confs[0].Schema
+			}
+		}
+	}
+
+	sh := newShell(reg, os.Stdout)
+	sh.format = format
+	sh.timing = timing
+	if schemaName != "" {
+		if err := sh.use(schemaName); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if execSql != "" {
+		sb := &stmtBuffer{}
+		stmts := sb.add(execSql)
+		if rest := sb.flush(); rest != "" {
+			stmts = append(stmts, rest)
+		}
+		for _, stmt := range stmts {
+			if err := sh.exec(stmt); err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
+	repl(sh)
+}
+
+
+func repl(sh *shell) {
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	line.SetTabCompletionStyle(liner.TabPrints)
+	line.SetWordCompleter(sh.complete)
+
+	if f, err := os.Open(historyFile); err == nil {
+		line.ReadHistory(f)
+		f.Close()
+	}
+	defer func() {
+		if f, err := os.Create(historyFile); err == nil {
+			line.WriteHistory(f)
+			f.Close()
+		}
+	}()
+
+	fmt.Fprintln(sh.out, `qlsh: type \? for help, \q to quit`)
+	sb := &stmtBuffer{}
+	for {
+		prompt := sh.prompt()
+		if !sb.empty() {
+			prompt = strings.Repeat(" ", len(prompt)-3) + "-> "
+		}
+		in, err := line.Prompt(prompt)
+		if err == liner.ErrPromptAborted {
+			// ctrl-c clears the current statement
+			sb.reset()
+			continue
+		} else if err != nil {
+			// ctrl-d
+			fmt.Fprintln(sh.out)
+			return
+		}
+		if strings.TrimSpace(in) == "" {
+			continue
+		}
+
+		if sb.empty() && strings.HasPrefix(strings.TrimSpace(in), `\`) {
+			line.AppendHistory(in)
+			quit, err := sh.meta(strings.TrimSpace(in))
+			if err != nil {
+				fmt.Fprintf(sh.out, "ERROR: %v\n", err)
+			}
+			if quit {
+				return
+			}
+			continue
+		}
+
+		for _, stmt := range sb.add(in) {
+			line.AppendHistory(strings.Replace(stmt, "\n", " ", -1) + ";")
+			if err := sh.exec(stmt); err != nil {
+				fmt.Fprintf(sh.out, "ERROR: %v\n", err)
+			}
+		}
+	}
+}
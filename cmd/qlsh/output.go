@@ -0,0 +1,167 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/araddon/qlbridge/value"
+)
+
+// print the result in the shell's format followed by row count and timing.
+func (m *shell) print(res *result, took time.Duration) error {
+	if !res.isQuery {
+		fmt.Fprintf(m.out, "OK, %d row(s) affected", res.affected)
+		m.printTiming(took)
+		return nil
+	}
+	var err error
+	switch m.format {
+	case "csv":
+		err = writeCsv(m.out, res)
+	case "json":
+		err = writeJson(m.out, res)
+	default:
+		writeTable(m.out, res)
+		fmt.Fprintf(m.out, "%d row(s)", len(res.rows))
+		m.printTiming(took)
+	}
+	return err
+}
+
+func (m *shell) printTiming(took time.Duration) {
+	if m.timing && took > 0 {
+		fmt.Fprintf(m.out, " (%s)", took.Round(time.Microsecond))
+	}
+	fmt.Fprintln(m.out)
+}
+
+// formatValue renders a single field, NULL for nil.
+func formatValue(v driver.Value) string {
+	switch vt := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return vt
+	case []byte:
+		return string(vt)
+	case time.Time:
+		return vt.Format(time.RFC3339Nano)
+	case value.Value:
+		if vt.Nil() {
+			return "NULL"
+		}
+		return vt.ToString()
+	case []string:
+		return strings.Join(vt, ",")
+	case map[string]interface{}, []interface{}:
+		by, err := json.Marshal(vt)
+		if err == nil {
+			return string(by)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// writeTable writes an aligned, boxed table
+//
+//    +---------+-------+
+//    | user_id | email |
+//    +---------+-------+
+//    | 1       | a@b.c |
+//    +---------+-------+
+func writeTable(w io.Writer, res *result) {
+	widths := make([]int, len(res.cols))
+	for i, col := range res.cols {
+		widths[i] = utf8.RuneCountInString(col)
+	}
+	cells := make([][]string, len(res.rows))
+	for ri, row := range res.rows {
+		cells[ri] = make([]string, len(res.cols))
+		for i := range res.cols {
+			var v driver.Value
+			if i < len(row) {
+				v = row[i]
+			}
+			s := strings.Replace(formatValue(v), "\n", `\n`, -1)
+			cells[ri][i] = s
+			if n := utf8.RuneCountInString(s); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	sep := tableSeparator(widths)
+	io.WriteString(w, sep)
+	writeTableRow(w, widths, res.cols)
+	io.WriteString(w, sep)
+	for _, row := range cells {
+		writeTableRow(w, widths, row)
+	}
+	if len(cells) > 0 {
+		io.WriteString(w, sep)
+	}
+}
+
+func tableSeparator(widths []int) string {
+	parts := make([]string, len(widths))
+	for i, n := range widths {
+		parts[i] = strings.Repeat("-", n+2)
+	}
+	return "+" + strings.Join(parts, "+") + "+\n"
+}
+
+func writeTableRow(w io.Writer, widths []int, row []string) {
+	io.WriteString(w, "|")
+	for i, s := range row {
+		io.WriteString(w, " "+s+strings.Repeat(" ", widths[i]-utf8.RuneCountInString(s))+" |")
+	}
+	io.WriteString(w, "\n")
+}
+
+func writeCsv(w io.Writer, res *result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(res.cols); err != nil {
+		return err
+	}
+	rec := make([]string, len(res.cols))
+	for _, row := range res.rows {
+		for i := range rec {
+			rec[i] = ""
+			if i < len(row) && row[i] != nil {
+				rec[i] = formatValue(row[i])
+			}
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJson writes one json object per row (new-line delimited).
+func writeJson(w io.Writer, res *result) error {
+	enc := json.NewEncoder(w)
+	for _, row := range res.rows {
+		obj := make(map[string]interface{}, len(res.cols))
+		for i, col := range res.cols {
+			var v driver.Value
+			if i < len(row) {
+				v = row[i]
+			}
+			if vv, ok := v.(value.Value); ok {
+				v = vv.Value()
+			}
+			obj[col] = v
+		}
+		if err := enc.Encode(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
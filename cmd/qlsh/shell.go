@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+const helpText = `shell commands:
+  \d              list tables
+  \d <table>      describe table
+  \c <schema>     use schema
+  \l              list schemas
+  \format <fmt>   output format [table,csv,json]
+  \timing         toggle timing
+  \?              this help
+  \q              quit
+sql statements are terminated with a semi-colon and may span lines.
+`
+
+// shell holds the state of a session, the current schema and session
+// variables are kept across statements.
+type shell struct {
+	reg     *schema.Registry
+	schema  *schema.Schema
+	session expr.ContextReadWriter
+	out     io.Writer
+	format  string
+	timing  bool
+}
+
+// result of running a single statement.
+type result struct {
+	cols     []string
+	rows     [][]driver.Value
+	isQuery  bool
+	affected int64
+}
+
+func newShell(reg *schema.Registry, out io.Writer) *shell {
+	return &shell{
+		reg:     reg,
+		session: datasource.NewMySqlSessionVars(),
+		out:     out,
+		format:  "table",
+		timing:  true,
+	}
+}
+
+func (m *shell) prompt() string {
+	if m.schema == nil {
+		return "qlsh> "
+	}
+	return m.schema.Name + "> "
+}
+
+// use switches to the named schema.
+func (m *shell) use(name string) error {
+	s, ok := m.reg.Schema(name)
+	if !ok {
+		return fmt.Errorf("schema %q not found, have %v", name, m.reg.Schemas())
+	}
+	m.schema = s
+	return nil
+}
+
+// meta runs a backslash shell command, returns true to quit.
+func (m *shell) meta(line string) (bool, error) {
+	parts := strings.Fields(strings.TrimSuffix(line, ";"))
+	arg := ""
+	if len(parts) > 1 {
+		arg = parts[1]
+	}
+	switch parts[0] {
+	case `\q`, `\quit`:
+		return true, nil
+	case `\?`, `\h`, `\help`:
+		fmt.Fprint(m.out, helpText)
+	case `\c`, `\connect`:
+		if arg == "" {
+			return false, fmt.Errorf(`\c requires a schema name`)
+		}
+		return false, m.use(arg)
+	case `\l`:
+		schemas := m.reg.Schemas()
+		sort.Strings(schemas)
+		rows := make([][]driver.Value, len(schemas))
+		for i, s := range schemas {
+			rows[i] = []driver.Value{s}
+		}
+		return false, m.print(&result{cols: []string{"Schema"}, rows: rows, isQuery: true}, 0)
+	case `\d`:
+		if arg == "" {
+			return false, m.runTimed("show tables", func(ctx *plan.Context) (rel.SqlStatement, error) {
+				return plan.RewriteShowAsSelect(&rel.SqlShow{ShowType: "tables", Raw: "show tables"}, ctx)
+			})
+		}
+		raw := "describe " + arg
+		return false, m.runTimed(raw, func(ctx *plan.Context) (rel.SqlStatement, error) {
+			return plan.RewriteDescribeAsSelect(&rel.SqlDescribe{Identity: arg, Raw: raw}, ctx)
+		})
+	case `\format`:
+		switch arg {
+		case "table", "csv", "json":
+			m.format = arg
+		default:
+			return false, fmt.Errorf("unknown format %q, expected one of [table,csv,json]", arg)
+		}
+	case `\timing`:
+		m.timing = !m.timing
+		fmt.Fprintf(m.out, "timing is %v\n", map[bool]string{true: "on", false: "off"}[m.timing])
+	default:
+		return false, fmt.Errorf(`unknown command %q, try \?`, parts[0])
+	}
+	return false, nil
+}
+
+// exec parses, runs and prints a sql statement.
+func (m *shell) exec(sql string) error {
+	return m.runTimed(sql, nil)
+}
+
+// runTimed runs the statement and prints results.  If stmtFn is non-nil
+// it supplies the statement instead of parsing sql.
+func (m *shell) runTimed(sql string, stmtFn func(ctx *plan.Context) (rel.SqlStatement, error)) error {
+	start := time.Now()
+	res, err := m.run(sql, stmtFn)
+	if err != nil {
+		return err
+	}
+	return m.print(res, time.Since(start))
+}
+
+func (m *shell) newContext(sql string) (*plan.Context, error) {
+	if m.schema == nil {
+		return nil, fmt.Errorf(`no schema selected, use \c <schema>`)
+	}
+	ctx := plan.NewContext(sql)
+	ctx.Schema = m.schema
+	ctx.Session = m.session
+	return ctx, nil
+}
+
+func (m *shell) run(sql string, stmtFn func(ctx *plan.Context) (rel.SqlStatement, error)) (*result, error) {
+
+	ctx, err := m.newContext(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	var job *exec.JobExecutor
+	if stmtFn == nil {
+		job, err = exec.BuildSqlJob(ctx)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		stmt, err := stmtFn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ctx.Stmt = stmt
+		job = exec.NewExecutor(ctx, plan.NewPlanner(ctx))
+		pln, err := plan.WalkStmt(ctx, stmt, job.Planner)
+		if err != nil {
+			return nil, err
+		}
+		task, err := job.Executor.WalkPlan(pln)
+		if err != nil {
+			return nil, err
+		}
+		tr, ok := task.(exec.TaskRunner)
+		if !ok {
+			return nil, fmt.Errorf("expected TaskRunner but got %T", task)
+		}
+		job.RootTask = tr
+	}
+
+	sel, isSelect := ctx.Stmt.(*rel.SqlSelect)
+	if !isSelect {
+		rw := exec.NewResultExecWriter(ctx)
+		job.RootTask.Add(rw)
+		if err := job.Setup(); err != nil {
+			return nil, err
+		}
+		err := job.Run()
+		job.Close()
+		if err != nil {
+			return nil, err
+		}
+		affected, _ := rw.Result().RowsAffected()
+		return &result{affected: affected}, nil
+	}
+
+	res := &result{isQuery: true}
+	if ctx.Projection != nil && ctx.Projection.Proj != nil && len(ctx.Projection.Proj.Columns) > 0 {
+		for _, col := range ctx.Projection.Proj.Columns {
+			res.cols = append(res.cols, col.As)
+		}
+	} else {
+		res.cols = sel.Columns.AliasedFieldNames()
+	}
+
+	rw := exec.NewResultRows(ctx, res.cols)
+	job.RootTask.Add(rw)
+	if err := job.Setup(); err != nil {
+		return nil, err
+	}
+	go func() {
+		job.Run()
+		job.Close()
+	}()
+
+	for {
+		select {
+		case err := <-rw.ErrChan():
+			return nil, err
+		case msg, ok := <-rw.MessageIn():
+			if !ok || msg == nil {
+				return res, nil
+			}
+			mm, ok := msg.(*datasource.SqlDriverMessageMap)
+			if !ok {
+				return nil, fmt.Errorf("unexpected message type %T", msg)
+			}
+			// rows are positional, pad to column count
+			row := make([]driver.Value, len(res.cols))
+			copy(row, mm.Values())
+			res.rows = append(res.rows, row)
+		}
+	}
+}
+
+// stmtBuffer accumulates lines until a semi-colon outside of quotes
+// completes one or more statements.
+type stmtBuffer struct {
+	buf   bytes.Buffer
+	quote rune
+}
+
+func (m *stmtBuffer) empty() bool { return strings.TrimSpace(m.buf.String()) == "" }
+func (m *stmtBuffer) reset() {
+	m.buf.Reset()
+	m.quote = 0
+}
+
+// add a line, returning any completed statements.
+func (m *stmtBuffer) add(line string) []string {
+	var stmts []string
+	if m.buf.Len() > 0 {
+		m.buf.WriteByte('\n')
+	}
+	for _, r := range line {
+		switch {
+		case m.quote != 0:
+			if r == m.quote {
+				m.quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			m.quote = r
+		case r == ';':
+			if s := strings.TrimSpace(m.buf.String()); s != "" {
+				stmts = append(stmts, s)
+			}
+			m.buf.Reset()
+			continue
+		}
+		m.buf.WriteRune(r)
+	}
+	return stmts
+}
+
+// flush returns remaining un-terminated statement text.
+func (m *stmtBuffer) flush() string {
+	s := strings.TrimSpace(m.buf.String())
+	m.reset()
+	return s
+}
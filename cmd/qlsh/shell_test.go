@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/testutil"
+)
+
+func init() {
+	testutil.Setup()
+	td.LoadTestDataOnce()
+}
+
+func newTestShell(t *testing.T) (*shell, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	sh := newShell(schema.DefaultRegistry(), buf)
+	sh.timing = false
+	assert.Equal(t, nil, sh.use("mockcsv"))
+	return sh, buf
+}
+
+func TestStmtBuffer(t *testing.T) {
+	sb := &stmtBuffer{}
+	assert.Equal(t, 0, len(sb.add("SELECT a")))
+	assert.False(t, sb.empty())
+	assert.Equal(t, 0, len(sb.add("FROM b WHERE c = 'x;y'")))
+	stmts := sb.add("; select 1; select")
+	assert.Equal(t, []string{"SELECT a\nFROM b WHERE c = 'x;y'", "select 1"}, stmts)
+	assert.Equal(t, "select", sb.flush())
+	assert.True(t, sb.empty())
+}
+
+func TestShellQuery(t *testing.T) {
+	sh, buf := newTestShell(t)
+
+	assert.Equal(t, nil, sh.exec("SELECT user_id, email FROM users WHERE user_id = \"hT2impsOPUREcVPc\""))
+	out := buf.String()
+	assert.True(t, strings.Contains(out, "| user_id          | email         |"), out)
+	assert.True(t, strings.Contains(out, "| hT2impsOPUREcVPc | bob@email.com |"), out)
+	assert.True(t, strings.Contains(out, "1 row(s)"), out)
+
+	buf.Reset()
+	sh.format = "csv"
+	assert.Equal(t, nil, sh.exec("SELECT user_id, email FROM users WHERE user_id = \"hT2impsOPUREcVPc\""))
+	assert.Equal(t, "user_id,email\nhT2impsOPUREcVPc,bob@email.com\n", buf.String())
+
+	buf.Reset()
+	sh.format = "json"
+	assert.Equal(t, nil, sh.exec("SELECT user_id, email FROM users WHERE user_id = \"hT2impsOPUREcVPc\""))
+	assert.Equal(t, `{"email":"bob@email.com","user_id":"hT2impsOPUREcVPc"}`+"\n", buf.String())
+
+	assert.NotEqual(t, nil, sh.exec("SELECT email FROM users WHERE ("))
+}
+
+func TestShellMeta(t *testing.T) {
+	sh, buf := newTestShell(t)
+
+	quit, err := sh.meta(`\d users`)
+	assert.Equal(t, nil, err)
+	assert.False(t, quit)
+	out := buf.String()
+	assert.True(t, strings.Contains(out, "Field"), out)
+	assert.True(t, strings.Contains(out, "referral_count"), out)
+
+	buf.Reset()
+	_, err = sh.meta(`\d`)
+	assert.Equal(t, nil, err)
+	assert.True(t, strings.Contains(buf.String(), "orders"), buf.String())
+
+	_, err = sh.meta(`\format xml`)
+	assert.NotEqual(t, nil, err)
+	_, err = sh.meta(`\c not_a_schema`)
+	assert.NotEqual(t, nil, err)
+
+	quit, err = sh.meta(`\q`)
+	assert.Equal(t, nil, err)
+	assert.True(t, quit)
+}
+
+func TestShellComplete(t *testing.T) {
+	sh, _ := newTestShell(t)
+
+	_, c, _ := sh.complete("SEL", 3)
+	assert.Equal(t, []string{"SELECT"}, c)
+
+	_, c, _ = sh.complete("sel", 3)
+	assert.Equal(t, []string{"select"}, c)
+
+	head, c, tail := sh.complete("select * from us where", 16)
+	assert.Equal(t, "select * from ", head)
+	assert.Equal(t, " where", tail)
+	assert.Equal(t, []string{"users"}, c)
+
+	_, c, _ = sh.complete("select referr", 13)
+	assert.Contains(t, c, "referral_count")
+
+	_, c, _ = sh.complete("select users.em", 15)
+	assert.Equal(t, []string{"users.email"}, c)
+
+	_, c, _ = sh.complete(`\d or`, 5)
+	assert.Equal(t, []string{"orders"}, c)
+}
+
+func TestWriteTable(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeTable(buf, &result{cols: []string{"a", "bb"}, rows: [][]driver.Value{{int64(1), nil}, {"xyz", true}}})
+	assert.Equal(t, `+-----+------+
+| a   | bb   |
++-----+------+
+| 1   | NULL |
+| xyz | true |
++-----+------+
+`, buf.String())
+}
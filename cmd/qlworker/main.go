@@ -0,0 +1,91 @@
+// qlworker runs a worker that executes serialized plan fragments sent
+// to it by a coordinating worker.Executor.
+//
+// Sources are loaded from a json file containing a list of schema.ConfigSource,
+// the same as qlsh, every worker needs the sources of the fragments it runs.
+//
+//    [
+//      {"name": "orders", "type": "cloudstore", "partition_count": 4, "settings": {"type": "localfs", "path": "orders/"}}
+//    ]
+//
+//    QLWORKER_SECRET=... qlworker --config=sources.json --addr=:4100
+//
+// The secret is shared with the coordinator and sent in the clear, only
+// run workers on a trusted network.
+//
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	u "github.com/araddon/gou"
+
+	// Side-Effect imports to register the source types
+	_ "github.com/araddon/qlbridge/datasource/files"
+
+	"github.com/araddon/qlbridge/expr/builtins"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/worker"
+)
+
+var (
+	configFile string
+	addr       = ":4100"
+	logging    = "warn"
+	secret     string
+)
+
+func init() {
+	flag.StringVar(&configFile, "config", "", "json file with list of source configs [{\"name\":...,\"type\":...}]")
+	flag.StringVar(&addr, "addr", ":4100", "address to listen on for fragments")
+	flag.StringVar(&logging, "logging", "warn", "logging [debug,info,warn,error]")
+	flag.StringVar(&secret, "secret", os.Getenv("QLWORKER_SECRET"), "secret shared with the coordinator, defaults to $QLWORKER_SECRET")
+}
+
+func main() {
+	flag.Parse()
+	u.SetupLogging(logging)
+	u.SetColorOutput()
+
+	if secret == "" {
+		fmt.Fprintln(os.Stderr, "a --secret or $QLWORKER_SECRET shared with the coordinator is required")
+		os.Exit(1)
+	}
+
+	builtins.LoadAllBuiltins()
+
+	reg := schema.DefaultRegistry()
+	if configFile != "" {
+		confs, err := schema.LoadSourceConfigs(configFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "could not load config %q: %v\n", configFile, err)
+			os.Exit(1)
+		}
+		for _, conf := range confs {
+			if err := reg.SchemaAddFromConfig(conf); err != nil {
+				fmt.Fprintf(os.Stderr, "could not load source %q: %v\n", conf.Name, err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	loader := func(name string) (*schema.Schema, error) {
+		s, ok := reg.Schema(name)
+		if !ok {
+			return nil, fmt.Errorf("schema %q not found", name)
+		}
+		return s, nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(worker.FragmentPath, worker.NewServer(loader, secret))
+	u.Infof("qlworker listening on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
@@ -0,0 +1,136 @@
+package datasource
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	_ schema.Message     = (*Batch)(nil)
+	_ expr.ContextReader = (*BatchRow)(nil)
+)
+
+type (
+	// Batch is a column-oriented set of rows.  Exec tasks in batch mode
+	// exchange a Batch of rows instead of a message per row.
+	Batch struct {
+		IdVal    uint64
+		ColIndex map[string]int // Map of column names to position in Cols
+		// Cols are the column vectors Cols[col][row]
+		Cols [][]driver.Value
+		// Sel is the selection vector of rows still active after
+		// filtering, nil means every row is selected.
+		Sel []int
+		n   int
+	}
+	// BatchRow is a context reader of a single row of a batch, it can
+	// be re-positioned to read each row without allocating.
+	BatchRow struct {
+		B   *Batch
+		Pos int
+	}
+	// BatchScanner is a schema.ConnScanner that can also scan a batch of
+	// rows at a time.  Next batch returns nil when exhausted.
+	BatchScanner interface {
+		NextBatch(size int) *Batch
+	}
+)
+
+// NewBatch creates an empty batch with capacity for size rows.
+func NewBatch(id uint64, colIndex map[string]int, size int) *Batch {
+	cols := make([][]driver.Value, len(colIndex))
+	for i := range cols {
+		cols[i] = make([]driver.Value, 0, size)
+	}
+	return &Batch{IdVal: id, ColIndex: colIndex, Cols: cols}
+}
+
+func (m *Batch) Id() uint64        { return m.IdVal }
+func (m *Batch) Body() interface{} { return m }
+
+// Len the number of rows in the batch, ignoring selection.
+func (m *Batch) Len() int { return m.n }
+
+// Append a row to the batch, the row must be in ColIndex order.
+func (m *Batch) Append(row []driver.Value) {
+	for i := range m.Cols {
+		if i < len(row) {
+			m.Cols[i] = append(m.Cols[i], row[i])
+		} else {
+			m.Cols[i] = append(m.Cols[i], nil)
+		}
+	}
+	m.n++
+}
+
+// Selected the positions of the active rows.
+func (m *Batch) Selected() []int {
+	if m.Sel != nil {
+		return m.Sel
+	}
+	sel := make([]int, m.n)
+	for i := range sel {
+		sel[i] = i
+	}
+	return sel
+}
+
+// Col the column vector for key, also trying the right side of
+// a `table.column` key the same as SqlDriverMessageMap.Get().
+func (m *Batch) Col(key string) ([]driver.Value, bool) {
+	if idx, ok := m.ColIndex[key]; ok {
+		return m.Cols[idx], true
+	}
+	if _, right, hasLeft := expr.LeftRight(key); hasLeft {
+		if idx, ok := m.ColIndex[right]; ok {
+			return m.Cols[idx], true
+		}
+	}
+	return nil, false
+}
+
+// Row copies the values of row at position pos.
+func (m *Batch) Row(pos int) []driver.Value {
+	row := make([]driver.Value, len(m.Cols))
+	for i, col := range m.Cols {
+		row[i] = col[pos]
+	}
+	return row
+}
+
+// Message the row at position pos as a single row message.
+func (m *Batch) Message(pos int) *SqlDriverMessageMap {
+	return NewSqlDriverMessageMap(m.IdVal+uint64(pos), m.Row(pos), m.ColIndex)
+}
+
+// Messages the selected rows as single row messages.
+func (m *Batch) Messages() []*SqlDriverMessageMap {
+	sel := m.Selected()
+	msgs := make([]*SqlDriverMessageMap, len(sel))
+	for i, pos := range sel {
+		msgs[i] = m.Message(pos)
+	}
+	return msgs
+}
+
+// NewBatchRow creates a reader positioned on first row of batch.
+func NewBatchRow(b *Batch) *BatchRow { return &BatchRow{B: b} }
+
+func (m *BatchRow) Get(key string) (value.Value, bool) {
+	if col, ok := m.B.Col(key); ok {
+		return value.NewValue(col[m.Pos]), true
+	}
+	return nil, false
+}
+func (m *BatchRow) Row() map[string]value.Value {
+	row := make(map[string]value.Value, len(m.B.ColIndex))
+	for k, idx := range m.B.ColIndex {
+		row[k] = value.NewValue(m.B.Cols[idx][m.Pos])
+	}
+	return row
+}
+func (m *BatchRow) Ts() time.Time { return time.Time{} }
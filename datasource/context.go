@@ -121,6 +121,10 @@ func (m *SqlDriverMessageMap) Get(key string) (value.Value, bool) {
 	}
 	_, right, hasLeft := expr.LeftRight(key)
 	//u.Debugf("could not find: %q  right=%q hasLeftRight?%v", key, right, hasLeft)
+	if !hasLeft && right != key {
+		// quoted as a whole `users.user_id`
+		_, right, hasLeft = expr.LeftRight(right)
+	}
 	if hasLeft {
 		if idx, ok := m.ColIndex[right]; ok {
 			return value.NewValue(m.Vals[idx]), true
@@ -198,6 +202,9 @@ func (m ContextSimple) Get(key string) (value.Value, bool) {
 		}
 		return nil, false
 	}
+	if ok && val == nil {
+		return value.NilValueVal, true
+	}
 	return val, ok
 }
 
@@ -0,0 +1,424 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/araddon/dateparse"
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/generators/elasticsearch/esgen"
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	// ensure our conn implements connection features
+	_ schema.Conn        = (*conn)(nil)
+	_ schema.ConnColumns = (*conn)(nil)
+	_ schema.ConnScanner = (*conn)(nil)
+
+	// SourcePlanner allows us to push down where, projection, aggregates
+	_ plan.SourcePlanner = (*conn)(nil)
+	// group by and order by are done in elasticsearch when pushed down
+	_ plan.SourceSelectComplete = (*conn)(nil)
+)
+
+type (
+	// conn is a single query against an index, paging through the hits
+	// (or aggregation buckets) of the search.
+	conn struct {
+		source   *Source
+		tbl      *Table
+		sg       *esgen.SelectGenerator
+		payload  *gentypes.Payload
+		cols     []string
+		colidx   map[string]int
+		fields   []*gentypes.FieldType // es field of each column for hits
+		isAgg    bool
+		complete bool // the whole statement was pushed down
+		sorted   bool // page with search_after instead of scroll
+		size     int
+		limit    int
+		offset   int
+		skipped  int
+		fetched  int
+		scrollID string
+		rows     [][]driver.Value
+		pos      int
+		ct       uint64
+		done     bool
+		err      error
+	}
+	searchResponse struct {
+		ScrollID string `json:"_scroll_id"`
+		Hits     struct {
+			Hits []struct {
+				ID     string                 `json:"_id"`
+				Source map[string]interface{} `json:"_source"`
+				Sort   []interface{}          `json:"sort"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+)
+
+func newConn(tbl *Table, source *Source) *conn {
+	return &conn{
+		tbl:    tbl,
+		source: source,
+		cols:   tbl.Columns(),
+		sg:     esgen.NewSelectGenerator(time.Now(), nil, tbl),
+	}
+}
+
+// Columns gets the columns used in this query.
+func (m *conn) Columns() []string { return m.cols }
+
+// Close the conn, clearing the scroll context if one is open.
+func (m *conn) Close() error {
+	if m.scrollID == "" {
+		return nil
+	}
+	body := map[string][]string{"scroll_id": {m.scrollID}}
+	m.scrollID = ""
+	if _, err := m.source.request("DELETE", "/_search/scroll", body); err != nil {
+		u.Warnf("could not clear scroll err=%v", err)
+	}
+	return nil
+}
+
+// WalkSourceSelect An interface implemented by this connection allowing the planner
+// to push down as much sql logic as possible to elasticsearch. If this is the only
+// source and the whole statement translates, the source plan is Complete, otherwise
+// only the where filter is pushed down and the planner does the rest.
+func (m *conn) WalkSourceSelect(planner plan.Planner, p *plan.Source) (plan.Task, error) {
+
+	p.SourceExec = true
+
+	sqlSelect := p.Stmt.Source
+	if p.Final && !sqlSelect.Distinct {
+		payload, err := m.sg.Walk(sqlSelect)
+		if err == nil {
+			if err = m.pushDown(sqlSelect, payload); err == nil {
+				p.Complete = true
+				m.complete = true
+				return nil, m.fetch()
+			}
+		}
+		u.Debugf("could not push down full statement to elasticsearch %v: %s", err, sqlSelect)
+	}
+
+	p.Stmt.Source = nil
+	p.Stmt.Rewrite(sqlSelect)
+	sqlSelect = p.Stmt.Source
+	sqlSelect.RewriteAsRawSelect()
+
+	if sqlSelect.Star {
+		m.cols = m.tbl.Columns()
+		m.colidx = make(map[string]int, len(m.cols))
+		for i, col := range m.cols {
+			m.colidx[col] = i
+		}
+	} else {
+		m.cols = sqlSelect.Columns.UnAliasedFieldNames()
+		m.colidx = sqlSelect.ColIndexes()
+	}
+	if err := m.loadFields(m.cols); err != nil {
+		return nil, err
+	}
+
+	// Filter only, if the where clause doesn't translate then the
+	// planner's where task evaluates it against every hit.
+	payload, err := m.sg.Walk(&rel.SqlSelect{Where: sqlSelect.Where})
+	if err != nil {
+		u.Debugf("could not push down where to elasticsearch %v", err)
+		payload = &gentypes.Payload{}
+	}
+	m.setPaging(payload, 0, 0)
+	return nil, m.fetch()
+}
+
+// SelectComplete the group by, having and order by of a Complete plan
+// were all done by elasticsearch.
+func (m *conn) SelectComplete() bool { return m.complete }
+
+// pushDown the fully translated statement.
+func (m *conn) pushDown(stmt *rel.SqlSelect, payload *gentypes.Payload) error {
+	if payload.Aggs != nil || stmt.IsAggQuery() {
+		m.isAgg = true
+		m.payload = payload
+		m.cols = m.sg.Columns()
+	} else {
+		m.cols = nil
+		var names []string
+		for _, col := range stmt.Columns {
+			if col.Star {
+				m.cols = append(m.cols, m.tbl.Columns()...)
+				names = append(names, m.tbl.Columns()...)
+				continue
+			}
+			in, ok := col.Expr.(*expr.IdentityNode)
+			if !ok {
+				return fmt.Errorf("elasticsearch: expected identity column but got %s", col)
+			}
+			m.cols = append(m.cols, col.As)
+			names = append(names, in.Text)
+		}
+		if len(m.cols) == 0 {
+			m.cols = m.tbl.Columns()
+			names = m.cols
+		}
+		if err := m.loadFields(names); err != nil {
+			return err
+		}
+		m.setPaging(payload, stmt.Limit, stmt.Offset)
+	}
+	m.colidx = make(map[string]int, len(m.cols))
+	for i, col := range m.cols {
+		m.colidx[col] = i
+	}
+	return nil
+}
+
+// loadFields finds the es field of each named column.
+func (m *conn) loadFields(names []string) error {
+	m.fields = make([]*gentypes.FieldType, len(names))
+	for i, name := range names {
+		ft, ok := m.tbl.ColumnInfo(name)
+		if !ok {
+			_, right, _ := expr.LeftRight(name)
+			if ft, ok = m.tbl.ColumnInfo(right); !ok {
+				return gentypes.MissingField(name)
+			}
+		}
+		m.fields[i] = ft
+	}
+	return nil
+}
+
+// setPaging sets up the payload for paging through hits, sorted requests page
+// with search_after, everything else with a scroll. Offset and limit are applied
+// as the hits are read.
+func (m *conn) setPaging(payload *gentypes.Payload, limit, offset int) {
+	m.payload = payload
+	m.limit, m.offset = limit, offset
+	m.size = m.source.pageSize
+	if limit > 0 && offset+limit < m.size {
+		m.size = offset + limit
+	}
+	payload.Size = &m.size
+	payload.From = nil
+	payload.Source = payload.Source[:0]
+	for _, ft := range m.fields {
+		if ft.Nested() {
+			payload.Source = append(payload.Source, ft.Path)
+		} else {
+			payload.Source = append(payload.Source, ft.Field)
+		}
+	}
+	if len(payload.Sort) > 0 {
+		// a unique tie-breaker so search_after doesn't skip equal sort values
+		m.sorted = true
+		payload.SortAsc("_id")
+	}
+}
+
+// Next the next row of the search.
+func (m *conn) Next() schema.Message {
+	for m.pos >= len(m.rows) {
+		if m.done || m.err != nil {
+			return nil
+		}
+		if m.err = m.fetch(); m.err != nil {
+			u.Errorf("could not read from elasticsearch err=%v", m.err)
+			return nil
+		}
+	}
+	msg := datasource.NewSqlDriverMessageMap(m.ct, m.rows[m.pos], m.colidx)
+	m.pos++
+	m.ct++
+	return msg
+}
+
+// fetch the next page of results.
+func (m *conn) fetch() error {
+	m.rows, m.pos = m.rows[:0], 0
+	searchPath := "/" + m.tbl.Name + "/_search"
+
+	if m.isAgg {
+		m.done = true
+		resp, err := m.source.request("POST", searchPath, m.payload)
+		if err != nil {
+			return err
+		}
+		m.rows, err = m.sg.AggRows(resp)
+		return err
+	}
+
+	var resp []byte
+	var err error
+	switch {
+	case m.sorted:
+		resp, err = m.source.request("POST", searchPath, m.payload)
+	case m.scrollID != "":
+		resp, err = m.source.request("POST", "/_search/scroll", map[string]string{
+			"scroll":    m.source.scroll,
+			"scroll_id": m.scrollID,
+		})
+	default:
+		resp, err = m.source.request("POST", searchPath+"?scroll="+m.source.scroll, m.payload)
+	}
+	if err != nil {
+		return err
+	}
+
+	sr := searchResponse{}
+	dec := json.NewDecoder(bytes.NewReader(resp))
+	dec.UseNumber()
+	if err = dec.Decode(&sr); err != nil {
+		return fmt.Errorf("elasticsearch: could not read search response: %v", err)
+	}
+	if sr.ScrollID != "" {
+		m.scrollID = sr.ScrollID
+	}
+
+	hits := sr.Hits.Hits
+	if len(hits) < m.size {
+		m.done = true
+	}
+	if m.sorted && len(hits) > 0 {
+		m.payload.SearchAfter = hits[len(hits)-1].Sort
+	}
+	for _, hit := range hits {
+		if m.skipped < m.offset {
+			m.skipped++
+			continue
+		}
+		row := make([]driver.Value, len(m.fields))
+		for i, ft := range m.fields {
+			row[i] = fieldValue(hit.Source, ft)
+		}
+		m.rows = append(m.rows, row)
+		m.fetched++
+		if m.limit > 0 && m.fetched >= m.limit {
+			m.done = true
+			break
+		}
+	}
+	if m.done {
+		m.Close()
+	}
+	return nil
+}
+
+// fieldValue reads the value of field from the _source of a hit.
+func fieldValue(src map[string]interface{}, ft *gentypes.FieldType) driver.Value {
+	if !ft.Nested() {
+		return convertValue(lookup(src, ft.Field), ft.Type)
+	}
+	kvs, _ := lookup(src, ft.Path).([]interface{})
+	out := make(map[string]interface{}, len(kvs))
+	for _, kv := range kvs {
+		obj, ok := kv.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key, _ := obj["k"].(string)
+		for pfx, vt := range nestedValuePrefixes {
+			if v, ok := obj[pfx]; ok {
+				out[key] = convertValue(v, mapValueType(vt))
+				break
+			}
+		}
+	}
+	if ft.Field != "" {
+		if v, ok := out[ft.Field]; ok {
+			return v
+		}
+		return nil
+	}
+	return out
+}
+
+// lookup a possibly dotted field name in a _source document.
+func lookup(src map[string]interface{}, field string) interface{} {
+	if v, ok := src[field]; ok {
+		return v
+	}
+	left, right, hasLeft := expr.LeftRight(field)
+	if !hasLeft {
+		return nil
+	}
+	if obj, ok := src[left].(map[string]interface{}); ok {
+		return lookup(obj, right)
+	}
+	return nil
+}
+
+func mapValueType(vt value.ValueType) value.ValueType {
+	switch vt {
+	case value.MapIntType:
+		return value.IntType
+	case value.MapNumberType:
+		return value.NumberType
+	case value.MapBoolType:
+		return value.BoolType
+	case value.MapTimeType:
+		return value.TimeType
+	}
+	return value.StringType
+}
+
+// convertValue converts a json decoded value to the driver value of
+// the column type.
+func convertValue(v interface{}, vt value.ValueType) driver.Value {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case json.Number:
+		switch vt {
+		case value.TimeType:
+			// epoch millis
+			if ms, err := val.Int64(); err == nil {
+				return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+			}
+		case value.NumberType:
+			if f, err := val.Float64(); err == nil {
+				return f
+			}
+		}
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return val.String()
+	case string:
+		if vt == value.TimeType {
+			if t, err := dateparse.ParseAny(val); err == nil {
+				return t
+			}
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = convertValue(item, vt)
+		}
+		return val
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = convertValue(item, value.UnknownType)
+		}
+		return val
+	}
+	return v
+}
@@ -0,0 +1,238 @@
+package elasticsearch_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource/elasticsearch"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/testutil"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	loadData sync.Once
+	esServer *fakeES
+)
+
+type esRequest struct {
+	method, path, body string
+}
+
+// fakeES replays recorded elasticsearch responses from testdata.
+type fakeES struct {
+	*httptest.Server
+	mu   sync.Mutex
+	reqs []esRequest
+}
+
+func newFakeES() *fakeES {
+	m := &fakeES{}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serve))
+	return m
+}
+
+func (m *fakeES) serve(w http.ResponseWriter, r *http.Request) {
+	by, _ := ioutil.ReadAll(r.Body)
+	body := string(by)
+	m.mu.Lock()
+	m.reqs = append(m.reqs, esRequest{r.Method, r.URL.RequestURI(), body})
+	m.mu.Unlock()
+
+	file := ""
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/_mapping":
+		file = "mapping.json"
+	case r.Method == "DELETE" && r.URL.Path == "/_search/scroll":
+		w.Write([]byte(`{"succeeded": true, "num_freed": 1}`))
+		return
+	case r.URL.Path == "/_search/scroll":
+		file = "scroll_2.json"
+	case r.URL.Path == "/users/_search":
+		file = "scroll_1.json"
+	case r.URL.Path == "/orders/_search" && strings.Contains(body, "aggregations"):
+		file = "aggs.json"
+	case r.URL.Path == "/orders/_search" && strings.Contains(body, "search_after"):
+		file = "search_sorted_2.json"
+	case r.URL.Path == "/orders/_search":
+		file = "search_sorted_1.json"
+	default:
+		http.Error(w, `{"error": "not found"}`, http.StatusNotFound)
+		return
+	}
+	resp, err := ioutil.ReadFile("testdata/" + file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+func (m *fakeES) requests() []esRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	reqs := m.reqs
+	m.reqs = nil
+	return reqs
+}
+
+func loadTestSchema(t *testing.T) {
+	loadData.Do(func() {
+		testutil.Setup()
+		exec.RegisterSqlDriver()
+		exec.DisableRecover()
+
+		esServer = newFakeES()
+		conf := &schema.ConfigSource{
+			Name:       "es_test",
+			SourceType: elasticsearch.SourceType,
+			Hosts:      []string{esServer.URL},
+			Settings:   map[string]interface{}{"page_size": 2},
+		}
+		err := schema.DefaultRegistry().SchemaAddFromConfig(conf)
+		assert.Equal(t, nil, err)
+	})
+	esServer.requests()
+}
+
+func queryRows(t *testing.T, sqlText string) [][]interface{} {
+	db, err := sql.Open("qlbridge", "es_test")
+	assert.Equal(t, nil, err)
+	defer db.Close()
+
+	rows, err := db.Query(sqlText)
+	assert.Equal(t, nil, err, sqlText)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	cols, _ := rows.Columns()
+	var out [][]interface{}
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+		assert.Equal(t, nil, rows.Scan(dest...))
+		out = append(out, vals)
+	}
+	assert.Equal(t, nil, rows.Err())
+	return out
+}
+
+func TestMapping(t *testing.T) {
+	loadTestSchema(t)
+
+	s, ok := schema.DefaultRegistry().Schema("es_test")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"orders", "users"}, s.Tables())
+
+	tbl, err := s.Table("orders")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"attrs", "item_count", "order_date", "price", "shipping.city", "shipping.zip", "user_id"}, tbl.Columns())
+	vt, _ := tbl.Column("order_date")
+	assert.Equal(t, value.TimeType, vt)
+	vt, _ = tbl.Column("attrs")
+	assert.Equal(t, value.MapStringType, vt)
+
+	// typed (es 6) mapping
+	tbl, err = s.Table("users")
+	assert.Equal(t, nil, err)
+	vt, _ = tbl.Column("active")
+	assert.Equal(t, value.BoolType, vt)
+}
+
+func TestSelectSearchAfter(t *testing.T) {
+	loadTestSchema(t)
+
+	rows := queryRows(t, `SELECT user_id, price FROM orders WHERE price > 10 ORDER BY price DESC`)
+	assert.Equal(t, [][]interface{}{
+		{"abc", 42.5},
+		{"xyz", 22.5},
+		{"abc", 18.0},
+	}, rows)
+
+	reqs := esServer.requests()
+	assert.Equal(t, 2, len(reqs))
+	assert.JSONEq(t, `{
+		"size": 2,
+		"query": {"bool": {"filter": [{"range": {"price": {"gt": 10}}}]}},
+		"_source": ["user_id", "price"],
+		"sort": [{"price": {"order": "desc"}}, {"_id": {"order": "asc"}}]
+	}`, reqs[0].body)
+	var second map[string]interface{}
+	assert.Equal(t, nil, json.Unmarshal([]byte(reqs[1].body), &second))
+	assert.Equal(t, []interface{}{22.5, "3"}, second["search_after"])
+
+	// limit is applied across pages
+	rows = queryRows(t, `SELECT user_id FROM orders ORDER BY price DESC LIMIT 1`)
+	assert.Equal(t, [][]interface{}{{"abc"}}, rows)
+	reqs = esServer.requests()
+	assert.Equal(t, 1, len(reqs))
+	assert.True(t, strings.Contains(reqs[0].body, `"size":1`), reqs[0].body)
+}
+
+func TestSelectScroll(t *testing.T) {
+	loadTestSchema(t)
+
+	rows := queryRows(t, `SELECT user_id, name, active, created FROM users`)
+	assert.Equal(t, 3, len(rows))
+	assert.Equal(t, []interface{}{"abc", "Aaron", true, time.Date(2012, 12, 24, 17, 29, 39, 738000000, time.UTC)}, rows[0])
+	// epoch millis dates
+	assert.Equal(t, []interface{}{"def", "Dee", true, time.Date(2013, 1, 1, 10, 0, 0, 0, time.UTC)}, rows[2])
+
+	reqs := esServer.requests()
+	assert.Equal(t, 3, len(reqs))
+	assert.Equal(t, "/users/_search?scroll=1m", reqs[0].path)
+	assert.Equal(t, "/_search/scroll", reqs[1].path)
+	assert.JSONEq(t, `{"scroll": "1m", "scroll_id": "scroll-abc-1"}`, reqs[1].body)
+	// scroll is cleared once done
+	assert.Equal(t, "DELETE", reqs[2].method)
+	assert.JSONEq(t, `{"scroll_id": ["scroll-abc-2"]}`, reqs[2].body)
+}
+
+func TestSelectNotPushedDown(t *testing.T) {
+	loadTestSchema(t)
+
+	// tolower() can't be translated so the planner evaluates the where
+	rows := queryRows(t, `SELECT name FROM users WHERE tolower(name) = "dee"`)
+	assert.Equal(t, [][]interface{}{{"Dee"}}, rows)
+
+	reqs := esServer.requests()
+	assert.True(t, len(reqs) > 0)
+	assert.False(t, strings.Contains(reqs[0].body, "query"), reqs[0].body)
+}
+
+func TestSelectAggregate(t *testing.T) {
+	loadTestSchema(t)
+
+	rows := queryRows(t, `SELECT user_id, count(*) AS ct, sum(price) AS total FROM orders GROUP BY user_id ORDER BY total DESC`)
+	assert.Equal(t, [][]interface{}{
+		{"abc", int64(2), 60.5},
+		{"xyz", int64(1), 22.5},
+	}, rows)
+
+	reqs := esServer.requests()
+	assert.Equal(t, 1, len(reqs))
+	assert.JSONEq(t, `{
+		"size": 0,
+		"aggregations": {
+			"user_id": {
+				"terms": {"field": "user_id", "size": 1000, "order": {"total": "desc"}},
+				"aggregations": {"total": {"sum": {"field": "price"}}}
+			}
+		}
+	}`, reqs[0].body)
+}
@@ -0,0 +1,189 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	// Ensure our table provides the column info the generators need
+	_ gentypes.SchemaColumns = (*Table)(nil)
+)
+
+type (
+	// Table is the schema of a single elasticsearch index along with the
+	// field info (nested paths, prefixes) needed by the esgen generators.
+	Table struct {
+		*schema.Table
+		fields map[string]*gentypes.FieldType
+	}
+	// property is a single field of an index mapping.
+	property struct {
+		Type       string               `json:"type"`
+		Properties map[string]*property `json:"properties"`
+	}
+)
+
+// nestedValuePrefixes are the value fields of the nested key/value
+// convention for maps, ie {"k":"name","i":22}.
+var nestedValuePrefixes = map[string]value.ValueType{
+	"i": value.MapIntType,
+	"f": value.MapNumberType,
+	"s": value.MapStringType,
+	"b": value.MapBoolType,
+	"t": value.MapTimeType,
+}
+
+// newTable creates a table from the "properties" of an index mapping.
+func newTable(name string, props map[string]*property) *Table {
+	t := &Table{
+		Table:  schema.NewTable(name),
+		fields: make(map[string]*gentypes.FieldType),
+	}
+	t.addProperties("", props)
+	t.SetColumnsFromFields()
+	return t
+}
+
+// Column the underlying value type of a column.
+func (m *Table) Column(col string) (value.ValueType, bool) {
+	if ft, ok := m.ColumnInfo(col); ok {
+		return ft.Type, true
+	}
+	return value.UnknownType, false
+}
+
+// ColumnInfo describes how a column maps to an elasticsearch field. Keys of
+// nested map fields may be addressed as "field.key".
+func (m *Table) ColumnInfo(col string) (*gentypes.FieldType, bool) {
+	if ft, ok := m.fields[col]; ok {
+		return ft, true
+	}
+	parts := strings.SplitN(col, ".", 2)
+	if len(parts) != 2 {
+		return nil, false
+	}
+	ft, ok := m.fields[parts[0]]
+	if !ok || !ft.Nested() {
+		return nil, false
+	}
+	return &gentypes.FieldType{
+		Field:    parts[1],
+		Prefix:   ft.Prefix,
+		Path:     ft.Path,
+		Type:     ft.Type,
+		TypeName: ft.TypeName,
+	}, true
+}
+
+func (m *Table) addProperties(prefix string, props map[string]*property) {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := props[name]
+		field := prefix + name
+		switch {
+		case p.Type == "nested":
+			m.addField(field, nestedFieldType(field, p))
+		case len(p.Properties) > 0:
+			// plain objects are flattened to dotted fields
+			m.addProperties(field+".", p.Properties)
+		default:
+			m.addField(field, &gentypes.FieldType{Field: field, Type: esValueType(p.Type), TypeName: p.Type})
+		}
+	}
+}
+
+func (m *Table) addField(name string, ft *gentypes.FieldType) {
+	m.fields[name] = ft
+	m.AddField(schema.NewFieldBase(name, ft.Type, 255, ft.TypeName))
+}
+
+// nestedFieldType a nested field following the {"k":key, "<prefix>":val}
+// convention is a map, anything else is opaque json.
+func nestedFieldType(name string, p *property) *gentypes.FieldType {
+	if _, hasKey := p.Properties["k"]; !hasKey {
+		return &gentypes.FieldType{Field: name, Type: value.JsonType, TypeName: p.Type}
+	}
+	ft := &gentypes.FieldType{Path: name, Type: value.MapValueType, TypeName: p.Type}
+	for pfx, vt := range nestedValuePrefixes {
+		if _, ok := p.Properties[pfx]; !ok {
+			continue
+		}
+		if ft.Prefix != "" {
+			// more than one value type, ie map[string]value
+			return &gentypes.FieldType{Path: name, Type: value.MapValueType, TypeName: p.Type}
+		}
+		ft.Prefix, ft.Type = pfx, vt
+	}
+	return ft
+}
+
+// esValueType converts an elasticsearch mapping type to a value type.
+func esValueType(esType string) value.ValueType {
+	switch esType {
+	case "text", "keyword", "string", "ip", "constant_keyword", "wildcard":
+		return value.StringType
+	case "long", "integer", "short", "byte", "unsigned_long":
+		return value.IntType
+	case "double", "float", "half_float", "scaled_float":
+		return value.NumberType
+	case "boolean":
+		return value.BoolType
+	case "date", "date_nanos":
+		return value.TimeType
+	}
+	return value.JsonType
+}
+
+// tablesFromMapping reads the response of GET /_mapping which is keyed by
+// index name. Both typed (es <= 6) and typeless mappings are supported.
+func tablesFromMapping(resp []byte) (map[string]*Table, error) {
+	indices := make(map[string]struct {
+		Mappings map[string]json.RawMessage `json:"mappings"`
+	})
+	if err := json.Unmarshal(resp, &indices); err != nil {
+		return nil, fmt.Errorf("elasticsearch: could not read mapping: %v", err)
+	}
+	tables := make(map[string]*Table, len(indices))
+	for index, idx := range indices {
+		if strings.HasPrefix(index, ".") {
+			// system indices
+			continue
+		}
+		raw, ok := idx.Mappings["properties"]
+		if !ok {
+			// typed mapping, use the first type with properties
+			for _, tm := range idx.Mappings {
+				var typed struct {
+					Properties json.RawMessage `json:"properties"`
+				}
+				if err := json.Unmarshal(tm, &typed); err == nil && len(typed.Properties) > 0 {
+					raw = typed.Properties
+					break
+				}
+			}
+		}
+		props := make(map[string]*property)
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &props); err != nil {
+				u.Warnf("could not read mapping for index %q err=%v", index, err)
+				continue
+			}
+		}
+		name := strings.ToLower(index)
+		tables[name] = newTable(name, props)
+	}
+	return tables, nil
+}
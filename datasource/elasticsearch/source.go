@@ -0,0 +1,214 @@
+// Package elasticsearch implements a Qlbridge Datasource over elasticsearch
+// indexes, pushing sql WHERE, projections and aggregates down to elasticsearch
+// through the esgen generator.
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/schema"
+)
+
+const (
+	// SourceType "elasticsearch" is the registered Source name in the qlbridge source registry
+	SourceType = "elasticsearch"
+)
+
+var (
+	// DefaultHost used if no hosts are configured.
+	DefaultHost = "http://localhost:9200"
+	// DefaultPageSize number of hits per request when paging through results.
+	DefaultPageSize = 500
+	// DefaultScroll how long a scroll context is kept alive between pages.
+	DefaultScroll = "1m"
+
+	// Ensure our source implements Source interface
+	_ schema.Source = (*Source)(nil)
+)
+
+func init() {
+	// We need to register our DataSource provider here
+	schema.RegisterSourceType(SourceType, newSourceEmpty())
+}
+
+// Source implements qlbridge DataSource to an elasticsearch cluster, each
+// index is a table.
+//
+// Settings
+// - "index" index name or pattern to load tables from, defaults to all
+// - "page_size" number of hits per request
+// - "scroll" scroll keep-alive for un-sorted scans
+type Source struct {
+	schema    *schema.Schema
+	client    *http.Client
+	hosts     []string
+	next      int
+	pageSize  int
+	scroll    string
+	mu        sync.Mutex
+	tables    map[string]*Table
+	tableList []string
+}
+
+func newSourceEmpty() schema.Source {
+	return &Source{
+		client: &http.Client{Timeout: 60 * time.Second},
+		tables: make(map[string]*Table),
+	}
+}
+
+// Setup this source with schema from parent, loading the index mappings.
+func (m *Source) Setup(s *schema.Schema) error {
+
+	m.schema = s
+	conf := s.Conf
+	if conf == nil {
+		conf = &schema.ConfigSource{}
+	}
+	m.hosts = m.hosts[:0]
+	for _, host := range conf.Hosts {
+		m.hosts = append(m.hosts, strings.TrimRight(host, "/"))
+	}
+	for _, node := range conf.Nodes {
+		if node.Address != "" {
+			m.hosts = append(m.hosts, strings.TrimRight(node.Address, "/"))
+		}
+	}
+	if len(m.hosts) == 0 {
+		m.hosts = append(m.hosts, DefaultHost)
+	}
+	for i, host := range m.hosts {
+		if !strings.Contains(host, "://") {
+			m.hosts[i] = "http://" + host
+		}
+	}
+
+	m.pageSize = DefaultPageSize
+	if ps, ok := conf.Settings.IntSafe("page_size"); ok && ps > 0 {
+		m.pageSize = ps
+	}
+	m.scroll = DefaultScroll
+	if scroll := conf.Settings.String("scroll"); scroll != "" {
+		m.scroll = scroll
+	}
+
+	path := "/_mapping"
+	if index := conf.Settings.String("index"); index != "" {
+		path = "/" + index + "/_mapping"
+	}
+	resp, err := m.request("GET", path, nil)
+	if err != nil {
+		u.Errorf("could not load elasticsearch mapping err=%v", err)
+		return err
+	}
+	tables, err := tablesFromMapping(resp)
+	if err != nil {
+		return err
+	}
+	tableList := make([]string, 0, len(tables))
+	for name := range tables {
+		if len(conf.TablesToLoad) > 0 && !contains(conf.TablesToLoad, name) {
+			delete(tables, name)
+			continue
+		}
+		tableList = append(tableList, name)
+	}
+	sort.Strings(tableList)
+
+	m.mu.Lock()
+	m.tables, m.tableList = tables, tableList
+	m.mu.Unlock()
+	return nil
+}
+
+// Init the source
+func (m *Source) Init() {}
+
+// Open a connection to query a single index.
+func (m *Source) Open(table string) (schema.Conn, error) {
+	m.mu.Lock()
+	t, ok := m.tables[table]
+	m.mu.Unlock()
+	if !ok {
+		return nil, schema.ErrNotFound
+	}
+	return newConn(t, m), nil
+}
+
+// Table gets table schema for given index
+func (m *Source) Table(table string) (*schema.Table, error) {
+	m.mu.Lock()
+	t, ok := m.tables[table]
+	m.mu.Unlock()
+	if !ok {
+		return nil, schema.ErrNotFound
+	}
+	return t.Table, nil
+}
+
+// Tables gets list of tables (indexes)
+func (m *Source) Tables() []string { return m.tableList }
+
+// Close this source
+func (m *Source) Close() error { return nil }
+
+func (m *Source) host() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	host := m.hosts[m.next%len(m.hosts)]
+	m.next++
+	return host
+}
+
+// request makes a json http request to elasticsearch, returning the raw
+// response body.
+func (m *Source) request(method, path string, body interface{}) ([]byte, error) {
+	var rdr io.Reader
+	if body != nil {
+		by, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		rdr = bytes.NewReader(by)
+	}
+	req, err := http.NewRequest(method, m.host()+path, rdr)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	by, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("elasticsearch: %s %s returned %d: %s", method, path, resp.StatusCode, by)
+	}
+	return by, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,10 @@
 package files
 
 import (
+	"fmt"
 	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 
 	u "github.com/araddon/gou"
@@ -20,6 +23,8 @@ var (
 	_ schema.ConnScanner  = (*FilePager)(nil)
 	_ exec.ExecutorSource = (*FilePager)(nil)
 
+	_ schema.SourcePartitionable = (*FilePager)(nil)
+
 	// Default file queue size to buffer by pager
 	FileBufferSize = 5
 )
@@ -32,6 +37,7 @@ type FilePager struct {
 	rowct           int64
 	table           string
 	exit            chan bool
+	exitOnce        sync.Once
 	err             error
 	closed          bool
 	fs              *FileSource
@@ -58,6 +64,29 @@ func NewFilePager(tableName string, fs *FileSource) *FilePager {
 	return fp
 }
 
+// Partitions of this table's files, one per configured partition_count.
+func (m *FilePager) Partitions() []*schema.Partition {
+	parts := make([]*schema.Partition, m.fs.partitionCt)
+	for i := range parts {
+		parts[i] = &schema.Partition{Id: strconv.Itoa(i)}
+	}
+	return parts
+}
+
+// PartitionSource creates a pager for only the files in given partition.
+func (m *FilePager) PartitionSource(p *schema.Partition) (schema.Conn, error) {
+	partid, err := strconv.Atoi(p.Id)
+	if err != nil || partid < 0 || uint64(partid) >= m.fs.partitionCt {
+		return nil, fmt.Errorf("invalid partition %q for %q", p.Id, m.table)
+	}
+	pg, err := m.fs.createPager(m.table, partid, 0)
+	if err != nil {
+		return nil, err
+	}
+	pg.partition = p
+	return pg, nil
+}
+
 // WalkExecSource Provide ability to implement a source plan for execution
 func (m *FilePager) WalkExecSource(p *plan.Source) (exec.Task, error) {
 
@@ -202,7 +231,7 @@ func (m *FilePager) fetcher() {
 					continue
 				}
 				ctxCancel()
-				close(m.exit)
+				m.stop()
 				u.Errorf("could not read %q err=%v", fi.Name, err)
 				return
 			} else {
@@ -228,7 +257,12 @@ func (m *FilePager) fetcher() {
 			}
 
 			// This will back-pressure after we reach our queue size
-			m.readers <- fr
+			select {
+			case m.readers <- fr:
+			case <-m.exit:
+				f.Close()
+				return
+			}
 
 			if m.Limit > 0 && fetchCt >= m.Limit {
 				return
@@ -280,6 +314,11 @@ func (m *FilePager) Next() schema.Message {
 // Close this connection/pager
 func (m *FilePager) Close() error {
 	m.closed = true
-	//close(m.exit)
+	m.stop()
 	return nil
 }
+
+// stop the fetcher
+func (m *FilePager) stop() {
+	m.exitOnce.Do(func() { close(m.exit) })
+}
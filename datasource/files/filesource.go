@@ -5,8 +5,12 @@ package files
 
 import (
 	"fmt"
+	"hash"
+	"hash/fnv"
 	"path"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	u "github.com/araddon/gou"
@@ -21,9 +25,14 @@ import (
 
 var (
 	// ensure we implement interfaces
-	_ schema.Source = (*FileSource)(nil)
+	_ schema.Source             = (*FileSource)(nil)
+	_ schema.SourceTableVersion = (*FileSource)(nil)
 
 	schemaRefreshInterval = time.Minute * 5
+
+	// TableVersionInterval is how long the file listing behind TableVersion
+	// is reused before the store is listed again.
+	TableVersionInterval = schemaRefreshInterval
 )
 
 const (
@@ -78,6 +87,9 @@ type FileSource struct {
 	Partitioner    string // random, ??  (date, keyed?)
 	partitionFunc  Partitioner
 	partitionCt    uint64
+	versionMu      sync.Mutex
+	versionListed  time.Time
+	versions       map[string]string // table version of each table, see TableVersion
 }
 
 // NewFileSource provides a singleton manager for a particular
@@ -116,7 +128,7 @@ func (m *FileSource) Open(tableName string) (schema.Conn, error) {
 	if tableName == m.filesTable {
 		return m.fdb.Open(tableName)
 	}
-	pg, err := m.createPager(tableName, 0, 0)
+	pg, err := m.createPager(tableName, -1, 0)
 	if err != nil {
 		u.Errorf("could not get pager: %v", err)
 		return nil, err
@@ -329,11 +341,67 @@ func (m *FileSource) Table(tableName string) (*schema.Table, error) {
 	return t, nil
 }
 
+// TableVersion a hash of the names and update times of the files of the
+// table, so adding, removing or re-writing a file changes it.  The files
+// are listed once for all tables per TableVersionInterval.
+func (m *FileSource) TableVersion(ctx context.Context, tableName string) (string, error) {
+	m.versionMu.Lock()
+	defer m.versionMu.Unlock()
+	if m.versions == nil || time.Since(m.versionListed) >= TableVersionInterval {
+		versions, err := m.listVersions(ctx)
+		if err != nil {
+			return "", err
+		}
+		m.versions, m.versionListed = versions, time.Now()
+	}
+	if v, ok := m.versions[tableName]; ok {
+		return v, nil
+	}
+	// no files
+	return strconv.FormatUint(fnv.New64a().Sum64(), 16), nil
+}
+
+// listVersions the table version of each table with files.
+func (m *FileSource) listVersions(ctx context.Context) (map[string]string, error) {
+	q := cloudstorage.Query{Delimiter: "", Prefix: m.path}
+	q.Sorted()
+	iter, err := m.store.Objects(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]hash.Hash64)
+	add := func(table string, o cloudstorage.Object) {
+		h, ok := hashes[table]
+		if !ok {
+			h = fnv.New64a()
+			hashes[table] = h
+		}
+		fmt.Fprintf(h, "%s:%d\n", o.Name(), o.Updated().UnixNano())
+	}
+	for {
+		o, err := iter.Next()
+		if err == iterator.Done {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		add(m.filesTable, o)
+		if fi := m.File(o); fi != nil && fi.Table != m.filesTable {
+			add(fi.Table, o)
+		}
+	}
+	versions := make(map[string]string, len(hashes))
+	for table, h := range hashes {
+		versions[table] = strconv.FormatUint(h.Sum64(), 16)
+	}
+	return versions, nil
+}
+
 func (m *FileSource) buildTable(tableName string) (*schema.Table, error) {
 
 	// Since we don't have a table schema, lets create one via introspection
 	//u.Debugf("introspecting file-table %q for schema type=%q path=%s", tableName, m.fileType, m.path)
-	pager, err := m.createPager(tableName, 0, 1)
+	pager, err := m.createPager(tableName, -1, 1)
 	if err != nil {
 		u.Errorf("could not find scanner for table %q table err:%v", tableName, err)
 		return nil, err
@@ -365,6 +433,7 @@ func (m *FileSource) buildTable(tableName string) (*schema.Table, error) {
 func (m *FileSource) createPager(tableName string, partition, limit int) (*FilePager, error) {
 
 	pg := NewFilePager(tableName, m)
+	pg.partid = partition
 	pg.Limit = limit
 	pg.RunFetcher()
 	return pg, nil
@@ -2,11 +2,13 @@ package files_test
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"os"
 	"testing"
+	"time"
 
 	u "github.com/araddon/gou"
 	"github.com/stretchr/testify/assert"
@@ -129,6 +131,41 @@ func TestJsonSelectSimple(t *testing.T) {
 	assert.Equal(t, "open", i1.State)
 }
 
+func TestJsonTableVersion(t *testing.T) {
+
+	s, ok := schema.DefaultRegistry().Schema("testjson")
+	assert.True(t, ok)
+	ts, err := s.SchemaForTable("issues")
+	assert.Equal(t, nil, err)
+	source, ok := ts.DS.(schema.SourceTableVersion)
+	assert.True(t, ok, "%T", ts.DS)
+
+	ctx := context.Background()
+	v1, err := source.TableVersion(ctx, "issues")
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, "", v1)
+	v2, _ := source.TableVersion(ctx, "issues")
+	assert.Equal(t, v1, v2)
+
+	// re-writing a file of the table changes its version, once listed again
+	fi, err := os.Stat("tables/github/issues.json")
+	assert.Equal(t, nil, err)
+	updated := fi.ModTime()
+	defer os.Chtimes("tables/github/issues.json", updated, updated)
+	later := updated.Add(time.Hour)
+	assert.Equal(t, nil, os.Chtimes("tables/github/issues.json", later, later))
+	v2, err = source.TableVersion(ctx, "issues")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, v1, v2)
+
+	interval := files.TableVersionInterval
+	files.TableVersionInterval = 0
+	defer func() { files.TableVersionInterval = interval }()
+	v2, err = source.TableVersion(ctx, "issues")
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, v1, v2)
+}
+
 /*
 
 3/2/2017
@@ -32,6 +32,8 @@ var (
 	_ schema.ConnSeeker   = (*StaticDataSource)(nil)
 	_ schema.ConnUpsert   = (*StaticDataSource)(nil)
 	_ schema.ConnDeletion = (*StaticDataSource)(nil)
+
+	_ datasource.BatchScanner = (*StaticDataSource)(nil)
 )
 
 // Key implements Key and Sort interfaces.
@@ -213,6 +215,38 @@ func (m *StaticDataSource) Next() schema.Message {
 	}
 }
 
+// NextBatch scan up to size rows at a time for batch execution.
+func (m *StaticDataSource) NextBatch(size int) *datasource.Batch {
+	select {
+	case <-m.exit:
+		return nil
+	default:
+	}
+	var b *datasource.Batch
+	collect := func(a btree.Item) bool {
+		if m.cursor == a {
+			// already returned in previous batch
+			return true
+		}
+		item := a.(*DriverItem)
+		if b == nil {
+			b = datasource.NewBatch(item.IdVal, item.ColIndex, size)
+		}
+		b.Append(item.Vals)
+		m.cursor = a
+		return b.Len() < size
+	}
+	if m.cursor == nil {
+		m.bt.Ascend(collect)
+	} else {
+		m.bt.AscendGreaterOrEqual(m.cursor, collect)
+	}
+	if b == nil {
+		m.cursor = nil
+	}
+	return b
+}
+
 // interface for Upsert.Put()
 func (m *StaticDataSource) Put(ctx context.Context, key schema.Key, row interface{}) (schema.Key, error) {
 
@@ -6,6 +6,9 @@ package memdb
 import (
 	"database/sql/driver"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
 
 	u "github.com/araddon/gou"
 	"github.com/hashicorp/go-memdb"
@@ -24,7 +27,9 @@ const (
 
 var (
 	// Ensure our MemDB implements schema.Source
-	_ schema.Source = (*MemDb)(nil)
+	_ schema.Source             = (*MemDb)(nil)
+	_ schema.SourceTableVersion = (*MemDb)(nil)
+	_ schema.SourceTableCreator = (*MemDb)(nil)
 
 	// Ensure our dbConn implements variety of Connection interfaces.
 	_ schema.Conn         = (*dbConn)(nil)
@@ -33,11 +38,17 @@ var (
 	_ schema.ConnUpsert   = (*dbConn)(nil)
 	_ schema.ConnDeletion = (*dbConn)(nil)
 	_ schema.ConnSeeker   = (*dbConn)(nil)
+
+	_ schema.SourcePartitionable = (*dbConn)(nil)
+
+	_ datasource.BatchScanner = (*dbConn)(nil)
 )
 
 // MemDb implements qlbridge `Source` to allow in-memory native go data
 // to have a Schema and implement and be operated on by Sql Statements.
 type MemDb struct {
+	writes         uint64 // count of committed writes, first for atomic alignment
+	lastID         uint64 // id of last row put if ordered
 	exit           chan bool
 	*schema.Schema                 // schema
 	tbl            *schema.Table   // schema table
@@ -45,12 +56,15 @@ type MemDb struct {
 	primaryIndex   string
 	db             *memdb.MemDB
 	max            int
+	partitionCt    uint64 // rows are partitioned on id % partitionCt
+	ordered        bool   // rows are kept in order put, not keyed by first column
 }
 type dbConn struct {
 	md     *MemDb
 	db     *memdb.MemDB
 	txn    *memdb.Txn
 	result memdb.ResultIterator
+	part   int // partition this conn scans, -1 for all
 }
 
 // NewMemDbData creates a MemDb with given indexes, columns, and values
@@ -101,7 +115,12 @@ func NewMemDbForSchema(name string, cols []string) (*MemDb, error) {
 func (m *MemDb) Init() {}
 
 // Setup this db with parent schema.
-func (m *MemDb) Setup(*schema.Schema) error { return nil }
+func (m *MemDb) Setup(s *schema.Schema) error {
+	if s != nil && s.Conf != nil {
+		m.partitionCt = uint64(s.Conf.PartitionCt)
+	}
+	return nil
+}
 
 // Open a Conn for this source @table name
 func (m *MemDb) Open(table string) (schema.Conn, error) { return newDbConn(m), nil }
@@ -116,9 +135,38 @@ func (m *MemDb) Close() error {
 	return nil
 }
 
+// CreateTable replaces the table of this db with tbl, dropping its rows.
+// A db holds a single table, so tbl must have its name.  Rows put
+// afterwards are kept in the order they are put instead of keyed by their
+// first column, so may repeat its values.
+func (m *MemDb) CreateTable(tbl *schema.Table) error {
+	if len(tbl.Columns()) < 1 {
+		return fmt.Errorf("must have columns provided")
+	}
+	if !strings.EqualFold(tbl.Name, m.tbl.Name) {
+		return fmt.Errorf("memdb holds table %q can not create %q", m.tbl.Name, tbl.Name)
+	}
+	m.tbl = tbl
+	m.indexes = nil
+	m.ordered = true
+	m.buildDefaultIndexes()
+	db, err := memdb.NewMemDB(makeMemDbSchema(m))
+	if err != nil {
+		return err
+	}
+	m.db = db
+	atomic.AddUint64(&m.writes, 1)
+	return nil
+}
+
 // Tables list, should be single table
 func (m *MemDb) Tables() []string { return []string{m.tbl.Name} }
 
+// TableVersion the count of writes committed to this db.
+func (m *MemDb) TableVersion(ctx context.Context, table string) (string, error) {
+	return strconv.FormatUint(atomic.LoadUint64(&m.writes), 10), nil
+}
+
 func (m *MemDb) buildDefaultIndexes() {
 	if len(m.indexes) == 0 {
 		//u.Debugf("no index provided creating on %q", m.tbl.Columns()[0])
@@ -146,9 +194,34 @@ func (m *MemDb) buildDefaultIndexes() {
 //func (m *MemDb) SetColumns(cols []string)                  { m.tbl.SetColumns(cols) }
 
 func newDbConn(mdb *MemDb) *dbConn {
-	c := &dbConn{md: mdb, db: mdb.db}
+	c := &dbConn{md: mdb, db: mdb.db, part: -1}
 	return c
 }
+
+// Partitions of this table, one per configured partition_count.
+func (m *dbConn) Partitions() []*schema.Partition {
+	parts := make([]*schema.Partition, m.md.partitionCt)
+	for i := range parts {
+		parts[i] = &schema.Partition{Id: strconv.Itoa(i)}
+	}
+	return parts
+}
+
+// PartitionSource opens a conn scanning only rows of given partition.
+func (m *dbConn) PartitionSource(p *schema.Partition) (schema.Conn, error) {
+	part, err := strconv.Atoi(p.Id)
+	if err != nil || part < 0 || uint64(part) >= m.md.partitionCt {
+		return nil, fmt.Errorf("invalid partition %q for %q", p.Id, m.md.tbl.Name)
+	}
+	c := newDbConn(m.md)
+	c.part = part
+	return c, nil
+}
+
+// inPartition is this row part of the partition this conn scans.
+func (m *dbConn) inPartition(msg *datasource.SqlDriverMessage) bool {
+	return m.part < 0 || msg.IdVal%m.md.partitionCt == uint64(m.part)
+}
 func (m *dbConn) Columns() []string { return m.md.tbl.Columns() }
 func (m *dbConn) Close() error      { return nil }
 func (m *dbConn) Next() schema.Message {
@@ -174,6 +247,9 @@ func (m *dbConn) Next() schema.Message {
 				return nil
 			}
 			if msg, ok := raw.(*datasource.SqlDriverMessage); ok {
+				if !m.inPartition(msg) {
+					continue
+				}
 				return msg.ToMsgMap(m.md.tbl.FieldPositions)
 			}
 			u.Warnf("error, not correct type: %#v", raw)
@@ -182,6 +258,47 @@ func (m *dbConn) Next() schema.Message {
 	}
 }
 
+// NextBatch scan up to size rows at a time for batch execution.
+func (m *dbConn) NextBatch(size int) *datasource.Batch {
+
+	if m.txn == nil {
+		m.txn = m.db.Txn(false)
+	}
+	select {
+	case <-m.md.exit:
+		return nil
+	default:
+	}
+	if m.result == nil {
+		result, err := m.txn.Get(m.md.tbl.Name, m.md.primaryIndex)
+		if err != nil {
+			u.Errorf("error %v", err)
+			return nil
+		}
+		m.result = result
+	}
+	var b *datasource.Batch
+	for b == nil || b.Len() < size {
+		raw := m.result.Next()
+		if raw == nil {
+			break
+		}
+		msg, ok := raw.(*datasource.SqlDriverMessage)
+		if !ok {
+			u.Warnf("error, not correct type: %#v", raw)
+			break
+		}
+		if !m.inPartition(msg) {
+			continue
+		}
+		if b == nil {
+			b = datasource.NewBatch(msg.IdVal, m.md.tbl.FieldPositions, size)
+		}
+		b.Append(msg.Vals)
+	}
+	return b
+}
+
 // Put interface for allowing this to accept writes via ConnUpsert.Put()
 func (m *dbConn) Put(ctx context.Context, key schema.Key, row interface{}) (schema.Key, error) {
 
@@ -194,6 +311,7 @@ func (m *dbConn) Put(ctx context.Context, key schema.Key, row interface{}) (sche
 			return nil, err
 		}
 		txn.Commit()
+		atomic.AddUint64(&m.md.writes, 1)
 		return key, nil
 	default:
 		return nil, fmt.Errorf("Expected []driver.Value but got %T", row)
@@ -206,6 +324,9 @@ func (m *dbConn) putValues(txn *memdb.Txn, row []driver.Value) (schema.Key, erro
 		return nil, fmt.Errorf("Wrong number of columns, expected %v got %v", len(m.Columns()), len(row))
 	}
 	id := makeId(row[0])
+	if m.md.ordered {
+		id = atomic.AddUint64(&m.md.lastID, 1)
+	}
 	msg := &datasource.SqlDriverMessage{Vals: row, IdVal: id}
 	if err := txn.Insert(m.md.tbl.Name, msg); err != nil {
 		return nil, err
@@ -228,6 +349,7 @@ func (m *dbConn) PutMulti(ctx context.Context, keys []schema.Key, objs interface
 			keys = append(keys, key)
 		}
 		txn.Commit()
+		atomic.AddUint64(&m.md.writes, 1)
 		return keys, nil
 	}
 	return nil, fmt.Errorf("unrecognized put object type: %T", objs)
@@ -262,6 +384,7 @@ func (m *dbConn) Delete(key driver.Value) (int, error) {
 		return 0, err
 	}
 	txn.Commit()
+	atomic.AddUint64(&m.md.writes, 1)
 	return 1, nil
 }
 
@@ -322,5 +445,6 @@ deleteLoop:
 		return 0, err
 	}
 	txn.Commit()
+	atomic.AddUint64(&m.md.writes, 1)
 	return len(deletedKeys), nil
 }
@@ -1,21 +1,28 @@
 package memdb
 
 import (
+	"context"
 	"database/sql/driver"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/araddon/dateparse"
+	u "github.com/araddon/gou"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/schema"
-	"github.com/araddon/qlbridge/testutil"
+	"github.com/araddon/qlbridge/value"
 )
 
+// testutil isn't used as it imports exec, which imports memdb
 func init() {
-	testutil.Setup()
+	if os.Getenv("VERBOSELOGS") != "" {
+		u.SetupLogging("debug")
+		u.SetColorOutput()
+	}
 }
 
 func TestMemDb(t *testing.T) {
@@ -111,3 +118,103 @@ func TestMemDb(t *testing.T) {
 	}
 	assert.Equal(t, 0, ct)
 }
+
+func TestMemDbPartitions(t *testing.T) {
+
+	rows := make([][]driver.Value, 0, 100)
+	for i := 0; i < 100; i++ {
+		rows = append(rows, []driver.Value{i, "name"})
+	}
+	db, err := NewMemDbData("parts", rows, []string{"id", "name"})
+	assert.Equal(t, nil, err)
+	s := schema.NewSchemaSource("parts", db)
+	s.Conf = &schema.ConfigSource{Name: "parts", PartitionCt: 4}
+	assert.Equal(t, nil, db.Setup(s))
+
+	c, err := db.Open("parts")
+	assert.Equal(t, nil, err)
+	pc, ok := c.(schema.SourcePartitionable)
+	assert.True(t, ok)
+	parts := pc.Partitions()
+	assert.Equal(t, 4, len(parts))
+
+	// each row is scanned by exactly one partition
+	seen := make(map[int]bool)
+	for _, part := range parts {
+		pconn, err := pc.PartitionSource(part)
+		assert.Equal(t, nil, err)
+		scanner := pconn.(schema.ConnScanner)
+		ct := 0
+		for msg := scanner.Next(); msg != nil; msg = scanner.Next() {
+			id := msg.Body().(*datasource.SqlDriverMessageMap).Vals[0].(int)
+			assert.False(t, seen[id], "row %d in more than one partition", id)
+			seen[id] = true
+			ct++
+		}
+		assert.True(t, ct > 0 && ct < 100, "partition %s has %d rows", part.Id, ct)
+	}
+	assert.Equal(t, 100, len(seen))
+
+	// batches are partitioned the same
+	pconn, _ := pc.PartitionSource(parts[0])
+	bct := 0
+	for b := pconn.(datasource.BatchScanner).NextBatch(10); b != nil; b = pconn.(datasource.BatchScanner).NextBatch(10) {
+		bct += b.Len()
+	}
+	pconn, _ = pc.PartitionSource(parts[0])
+	ct := 0
+	for msg := pconn.(schema.ConnScanner).Next(); msg != nil; msg = pconn.(schema.ConnScanner).Next() {
+		ct++
+	}
+	assert.Equal(t, ct, bct)
+
+	_, err = pc.PartitionSource(&schema.Partition{Id: "4"})
+	assert.NotEqual(t, nil, err)
+}
+
+func TestMemDbCreateTable(t *testing.T) {
+	db, err := NewMemDbData("hits", [][]driver.Value{{"a", int64(1)}}, []string{"name", "ct"})
+	assert.Equal(t, nil, err)
+	v1, _ := db.TableVersion(context.Background(), "hits")
+
+	// a db holds a single table, others are refused
+	other := schema.NewTable("hits_by_user")
+	other.AddFieldType("user", value.StringType)
+	other.SetColumnsFromFields()
+	assert.NotEqual(t, nil, db.CreateTable(other))
+	assert.Equal(t, []string{"hits"}, db.Tables())
+	v2, _ := db.TableVersion(context.Background(), "hits")
+	assert.Equal(t, v1, v2)
+
+	tbl := schema.NewTable("hits")
+	tbl.AddFieldType("user", value.StringType)
+	tbl.AddFieldType("ct", value.IntType)
+	tbl.SetColumnsFromFields()
+	assert.Equal(t, nil, db.CreateTable(tbl))
+	assert.Equal(t, []string{"hits"}, db.Tables())
+	v2, _ = db.TableVersion(context.Background(), "hits")
+	assert.NotEqual(t, v1, v2)
+
+	c, err := db.Open("hits")
+	assert.Equal(t, nil, err)
+	dc := c.(schema.ConnAll)
+	assert.Equal(t, nil, dc.Next(), "rows were dropped")
+
+	// rows are kept in order put, repeating the first column
+	rows := [][]driver.Value{{"bob", int64(3)}, {"ann", int64(1)}, {"bob", int64(2)}}
+	_, err = dc.PutMulti(nil, nil, rows)
+	assert.Equal(t, nil, err)
+	dc.Close()
+
+	c, _ = db.Open("hits")
+	dc = c.(schema.ConnAll)
+	got := make([][]driver.Value, 0)
+	for msg := dc.Next(); msg != nil; msg = dc.Next() {
+		got = append(got, msg.(*datasource.SqlDriverMessageMap).Values())
+	}
+	assert.Equal(t, rows, got)
+	row, err := dc.Get(uint64(3))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, rows[2], row.Body())
+	dc.Close()
+}
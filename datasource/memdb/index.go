@@ -39,6 +39,7 @@ func makeId(dv driver.Value) uint64 {
 type indexWrapper struct {
 	t *schema.Table
 	*schema.Index
+	ordered bool // index on id of row instead of first column
 }
 
 func (s *indexWrapper) FromObject(obj interface{}) (bool, []byte, error) {
@@ -47,6 +48,10 @@ func (s *indexWrapper) FromObject(obj interface{}) (bool, []byte, error) {
 		if len(row.Vals) < 0 {
 			return false, nil, u.LogErrorf("No values in row?")
 		}
+		if s.ordered {
+			// zero padded so ids sort in the order they were put
+			return true, []byte(fmt.Sprintf("%020d\x00", row.IdVal)), nil
+		}
 		// Add the null character as a terminator
 		val := fmt.Sprintf("%v", row.Vals[0])
 		val += "\x00"
@@ -65,6 +70,9 @@ func (s *indexWrapper) FromArgs(args ...interface{}) ([]byte, error) {
 		return nil, fmt.Errorf("must provide only a single argument")
 	}
 	arg := fmt.Sprintf("%v", args[0])
+	if s.ordered {
+		arg = fmt.Sprintf("%020v", args[0])
+	}
 	// Add the null character as a terminator
 	arg += "\x00"
 	return []byte(arg), nil
@@ -77,7 +85,7 @@ func makeMemDbSchema(m *MemDb) *memdb.DBSchema {
 	for _, idx := range m.indexes {
 		sidx := &memdb.IndexSchema{
 			Name:    idx.Name,
-			Indexer: &indexWrapper{Index: idx},
+			Indexer: &indexWrapper{Index: idx, ordered: m.ordered},
 		}
 		if idx.PrimaryKey {
 			sidx.Unique = true
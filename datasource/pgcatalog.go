@@ -0,0 +1,203 @@
+package datasource
+
+import (
+	"database/sql/driver"
+	"sort"
+
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+const (
+	// PgCatalogSchemaName is the name postgres clients use to query the catalog,
+	// ie `SELECT relname FROM pg_catalog.pg_class`
+	PgCatalogSchemaName = "pg_catalog"
+
+	// Postgres OIDs for the types we map value.ValueType's onto.
+	PgOidBool        uint32 = 16
+	PgOidBytea       uint32 = 17
+	PgOidInt8        uint32 = 20
+	PgOidText        uint32 = 25
+	PgOidJson        uint32 = 114
+	PgOidFloat8      uint32 = 701
+	PgOidTextArray   uint32 = 1009
+	PgOidVarchar     uint32 = 1043
+	PgOidTimestamp   uint32 = 1114
+	pgNamespaceCat   int64  = 11
+	pgNamespacePub   int64  = 2200
+	pgFirstNormalOid int64  = 16384
+)
+
+var (
+	// pg_catalog emulation tables, these live in the same info-schema
+	// as the mysql style tables above.
+	pgCatalogTables = []string{"pg_namespace", "pg_class", "pg_attribute", "pg_type",
+		"pg_database", "pg_tables"}
+
+	// pgTypes are the rows of pg_type, only the types we ever emit.
+	pgTypes = []struct {
+		oid  uint32
+		name string
+		len  int64
+	}{
+		{PgOidBool, "bool", 1},
+		{PgOidBytea, "bytea", -1},
+		{PgOidInt8, "int8", 8},
+		{PgOidText, "text", -1},
+		{PgOidJson, "json", -1},
+		{PgOidFloat8, "float8", 8},
+		{PgOidTextArray, "_text", -1},
+		{PgOidVarchar, "varchar", -1},
+		{PgOidTimestamp, "timestamp", 8},
+	}
+)
+
+// PgTypeOid maps a value type to the postgres type OID used to describe
+// it in RowDescription and pg_attribute.
+func PgTypeOid(t value.ValueType) uint32 {
+	switch t {
+	case value.BoolType:
+		return PgOidBool
+	case value.IntType:
+		return PgOidInt8
+	case value.NumberType:
+		return PgOidFloat8
+	case value.TimeType:
+		return PgOidTimestamp
+	case value.ByteSliceType:
+		return PgOidBytea
+	case value.StringType:
+		return PgOidVarchar
+	case value.StringsType:
+		return PgOidTextArray
+	case value.JsonType, value.MapValueType, value.MapIntType, value.MapStringType,
+		value.MapNumberType, value.MapBoolType, value.MapTimeType, value.SliceValueType,
+		value.StructType:
+		return PgOidJson
+	default:
+		return PgOidText
+	}
+}
+
+// PgTypeLen is the pg_type.typlen of the type OID, -1 for variable length.
+func PgTypeLen(oid uint32) int16 {
+	for _, pt := range pgTypes {
+		if pt.oid == oid {
+			return int16(pt.len)
+		}
+	}
+	return -1
+}
+
+// isPgCatalogTable is this one of the pg_catalog emulation tables?
+func isPgCatalogTable(table string) bool {
+	for _, t := range pgCatalogTables {
+		if t == table {
+			return true
+		}
+	}
+	return false
+}
+
+// tableForPgCatalog builds the pg_catalog tables.  These are never cached
+// as they reflect the current tables of the schema.
+func (m *SchemaDb) tableForPgCatalog(table string) (*schema.Table, error) {
+	t, _, err := m.pgCatalogTable(table)
+	return t, err
+}
+
+// pgCatalogTable builds table and its rows, rows are returned separately as
+// Table.AsRows() would describe the fields if there are no rows.
+func (m *SchemaDb) pgCatalogTable(table string) (*schema.Table, [][]driver.Value, error) {
+
+	t := schema.NewTable(table)
+	var rows [][]driver.Value
+
+	switch table {
+	case "pg_namespace":
+		t.AddField(schema.NewFieldBase("oid", value.IntType, 8, "oid"))
+		t.AddField(schema.NewFieldBase("nspname", value.StringType, 64, "name"))
+		rows = [][]driver.Value{
+			{pgNamespaceCat, PgCatalogSchemaName},
+			{pgNamespacePub, "public"},
+		}
+	case "pg_class":
+		t.AddField(schema.NewFieldBase("oid", value.IntType, 8, "oid"))
+		t.AddField(schema.NewFieldBase("relname", value.StringType, 64, "name"))
+		t.AddField(schema.NewFieldBase("relnamespace", value.IntType, 8, "oid"))
+		t.AddField(schema.NewFieldBase("relkind", value.StringType, 1, "char"))
+		t.AddField(schema.NewFieldBase("relnatts", value.IntType, 2, "int2"))
+		for i, tbl := range m.pgTables() {
+			rows = append(rows, []driver.Value{pgFirstNormalOid + int64(i), tbl.Name,
+				pgNamespacePub, "r", int64(len(tbl.Fields))})
+		}
+	case "pg_attribute":
+		t.AddField(schema.NewFieldBase("attrelid", value.IntType, 8, "oid"))
+		t.AddField(schema.NewFieldBase("attname", value.StringType, 64, "name"))
+		t.AddField(schema.NewFieldBase("atttypid", value.IntType, 8, "oid"))
+		t.AddField(schema.NewFieldBase("attlen", value.IntType, 2, "int2"))
+		t.AddField(schema.NewFieldBase("attnum", value.IntType, 2, "int2"))
+		t.AddField(schema.NewFieldBase("attnotnull", value.BoolType, 1, "bool"))
+		for i, tbl := range m.pgTables() {
+			for fi, fld := range tbl.Fields {
+				oid := PgTypeOid(fld.ValueType())
+				rows = append(rows, []driver.Value{pgFirstNormalOid + int64(i), fld.Name,
+					int64(oid), int64(PgTypeLen(oid)), int64(fi + 1), false})
+			}
+		}
+	case "pg_type":
+		t.AddField(schema.NewFieldBase("oid", value.IntType, 8, "oid"))
+		t.AddField(schema.NewFieldBase("typname", value.StringType, 64, "name"))
+		t.AddField(schema.NewFieldBase("typnamespace", value.IntType, 8, "oid"))
+		t.AddField(schema.NewFieldBase("typlen", value.IntType, 2, "int2"))
+		t.AddField(schema.NewFieldBase("typtype", value.StringType, 1, "char"))
+		for _, pt := range pgTypes {
+			rows = append(rows, []driver.Value{int64(pt.oid), pt.name, pgNamespaceCat, pt.len, "b"})
+		}
+	case "pg_database":
+		t.AddField(schema.NewFieldBase("oid", value.IntType, 8, "oid"))
+		t.AddField(schema.NewFieldBase("datname", value.StringType, 64, "name"))
+		schemas := registry.Schemas()
+		sort.Strings(schemas)
+		for i, name := range schemas {
+			rows = append(rows, []driver.Value{pgFirstNormalOid + int64(i), name})
+		}
+	case "pg_tables":
+		t.AddField(schema.NewFieldBase("schemaname", value.StringType, 64, "name"))
+		t.AddField(schema.NewFieldBase("tablename", value.StringType, 64, "name"))
+		t.AddField(schema.NewFieldBase("tableowner", value.StringType, 64, "name"))
+		for _, tbl := range m.pgTables() {
+			rows = append(rows, []driver.Value{"public", tbl.Name, "qlbridge"})
+		}
+	default:
+		return nil, nil, schema.ErrNotFound
+	}
+	t.SetColumnsFromFields()
+	t.SetRows(rows)
+	return t, rows, nil
+}
+
+// pgTables the user tables of our schema in stable (sorted) order so
+// the oids we hand out are the same across catalog tables.
+func (m *SchemaDb) pgTables() []*schema.Table {
+	names := make([]string, 0, len(m.s.Tables()))
+	for _, name := range m.s.Tables() {
+		if isPgCatalogTable(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tables := make([]*schema.Table, 0, len(names))
+	for _, name := range names {
+		tbl, err := m.s.Table(name)
+		if err != nil || tbl == nil {
+			continue
+		}
+		if len(tbl.Columns()) > 0 && len(tbl.Fields) == 0 {
+			m.inspect(tbl.Name)
+		}
+		tables = append(tables, tbl)
+	}
+	return tables
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"sort"
+	"time"
 
 	u "github.com/araddon/gou"
 
@@ -27,8 +28,8 @@ var (
 	_ schema.ConnScanner = (*SchemaSource)(nil)
 
 	// normal tables
-	defaultSchemaTables = []string{"tables", "databases", "columns", "global_variables", "session_variables",
-		"functions", "procedures", "engines", "status", "indexes"}
+	defaultSchemaTables = append([]string{"tables", "databases", "columns", "global_variables", "session_variables",
+		"functions", "procedures", "engines", "status", "indexes", "processlist"}, pgCatalogTables...)
 	// DialectWriterCols list of columns for dialectwriter.
 	DialectWriterCols = []string{"mysql"}
 	// DialectWriters list of differnt writers.
@@ -59,6 +60,7 @@ type (
 		tbl     *schema.Table
 		ctx     *plan.Context
 		session bool
+		procs   bool
 		cursor  int
 		rows    [][]driver.Value
 	}
@@ -113,8 +115,12 @@ func (m *SchemaDb) Table(table string) (*schema.Table, error) {
 		return m.tableForIndexes()
 	case "status":
 		return m.tableForVariables(table)
+	case "processlist":
+		return m.tableForProcessList()
 	case "columns":
 		return m.tableForTable(table)
+	case "pg_namespace", "pg_class", "pg_attribute", "pg_type", "pg_database", "pg_tables":
+		return m.tableForPgCatalog(table)
 	default:
 		return m.tableForTable(table)
 	}
@@ -123,13 +129,25 @@ func (m *SchemaDb) Table(table string) (*schema.Table, error) {
 // Open Create a SchemaSource specific to schema object (table, database)
 func (m *SchemaDb) Open(schemaObjectName string) (schema.Conn, error) {
 
+	if isPgCatalogTable(schemaObjectName) {
+		tbl, rows, err := m.pgCatalogTable(schemaObjectName)
+		if err != nil {
+			return nil, err
+		}
+		return &SchemaSource{db: m, tbl: tbl, rows: rows}, nil
+	}
+
 	tbl, err := m.Table(schemaObjectName)
 	if err == nil && tbl != nil {
 
 		switch schemaObjectName {
 		case "session_variables", "global_variables":
 			return &SchemaSource{db: m, tbl: tbl, session: true}, nil
-		case "engines", "procedures", "functions", "indexes":
+		case "functions":
+			return &SchemaSource{db: m, tbl: tbl, rows: m.functionRows()}, nil
+		case "processlist":
+			return &SchemaSource{db: m, tbl: tbl, procs: true}, nil
+		case "engines", "procedures", "indexes":
 			return &SchemaSource{db: m, tbl: tbl, rows: nil}, nil
 		default:
 			return &SchemaSource{db: m, tbl: tbl, rows: tbl.AsRows()}, nil
@@ -145,6 +163,9 @@ func (m *SchemaSource) SetContext(ctx *plan.Context) {
 	if m.session {
 		m.rows = RowsForSession(ctx)
 	}
+	if m.procs {
+		m.rows = processRows(ctx)
+	}
 }
 
 func (m *SchemaSource) Close() error                  { return nil }
@@ -265,6 +286,18 @@ func (m *SchemaDb) tableForProcedures(table string) (*schema.Table, error) {
 	return t, nil
 }
 
+// functionRows the user defined functions of the schema, as rows of
+// the functions table.
+func (m *SchemaDb) functionRows() [][]driver.Value {
+	fns := m.s.Functions()
+	rows := make([][]driver.Value, 0, len(fns))
+	for _, fn := range fns {
+		rows = append(rows, []driver.Value{m.s.Name, fn.Name, "FUNCTION", "", nil, nil,
+			"DEFINER", fn.String(), "utf8", "utf8_general_ci", "utf8_general_ci"})
+	}
+	return rows
+}
+
 func (m *SchemaDb) tableForEngines() (*schema.Table, error) {
 
 	table := "engines"
@@ -289,6 +322,45 @@ func (m *SchemaDb) tableForEngines() (*schema.Table, error) {
 	return t, nil
 }
 
+func (m *SchemaDb) tableForProcessList() (*schema.Table, error) {
+
+	table := "processlist"
+
+	tbl, hasTable := m.tableMap[table]
+	if hasTable {
+		return tbl, nil
+	}
+
+	t := schema.NewTable(table)
+	t.AddField(schema.NewFieldBase("Id", value.IntType, 8, "integer"))
+	t.AddField(schema.NewFieldBase("User", value.StringType, 64, "string"))
+	t.AddField(schema.NewFieldBase("Host", value.StringType, 64, "string"))
+	t.AddField(schema.NewFieldBase("db", value.StringType, 64, "string"))
+	t.AddField(schema.NewFieldBase("Command", value.StringType, 16, "string"))
+	t.AddField(schema.NewFieldBase("Time", value.IntType, 8, "integer"))
+	t.AddField(schema.NewFieldBase("State", value.StringType, 64, "string"))
+	t.AddField(schema.NewFieldBase("Info", value.StringType, 1024, "string"))
+	t.SetColumns(schema.ShowProcessListCols)
+	m.tableMap[table] = t
+	return t, nil
+}
+
+// processRows the running jobs of the process table the user of ctx
+// may see, see plan.ProcessAllowed.
+func processRows(ctx *plan.Context) [][]driver.Value {
+	procs := plan.Processes.List()
+	rows := make([][]driver.Value, 0, len(procs))
+	for i := range procs {
+		p := &procs[i]
+		if !plan.ProcessAllowed(ctx, p) {
+			continue
+		}
+		rows = append(rows, []driver.Value{int64(p.Id), p.User, "", p.Schema, "Query",
+			int64(time.Since(p.Started).Seconds()), p.State(), p.Info})
+	}
+	return rows
+}
+
 func (m *SchemaDb) tableForVariables(table string) (*schema.Table, error) {
 
 	t := schema.NewTable(table)
@@ -435,8 +507,15 @@ func mysqlWriteField(w *bytes.Buffer, fld *schema.Field) {
 		fmt.Fprintf(w, "varchar(%d) DEFAULT NULL", deflen)
 	case value.NumberType:
 		fmt.Fprint(w, "float DEFAULT NULL")
+	case value.DecimalType:
+		if deflen == 0 {
+			deflen = 10
+		}
+		fmt.Fprintf(w, "decimal(%d,%d) DEFAULT NULL", deflen, fld.Scale)
 	case value.TimeType:
 		fmt.Fprint(w, "datetime DEFAULT NULL")
+	case value.DateType:
+		fmt.Fprint(w, "date DEFAULT NULL")
 	case value.JsonType:
 		fmt.Fprintf(w, "JSON")
 	default:
@@ -458,12 +537,16 @@ func MysqlValueString(t value.ValueType) string {
 		return "text"
 	case value.NumberType:
 		return "float"
+	case value.DecimalType:
+		return "decimal"
 	case value.IntType:
 		return "long"
 	case value.BoolType:
 		return "boolean"
 	case value.TimeType:
 		return "datetime"
+	case value.DateType:
+		return "date"
 	case value.ByteSliceType:
 		return "text"
 	case value.StringType:
@@ -104,7 +104,7 @@ func TestSchemaShowStatements(t *testing.T) {
 		},
 	)
 	// VARIABLES
-	testutil.TestSelect(t, `show global variables like 'max_allowed*';`,
+	testutil.TestSelect(t, `show global variables like 'max_allowed%';`,
 		[][]driver.Value{
 			{"max_allowed_packet", int64(datasource.MaxAllowedPacket)},
 		},
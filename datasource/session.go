@@ -55,6 +55,7 @@ func NewMySqlGlobalVars() *ContextSimple {
 	ctx.Data["@@license"] = value.NewStringValue("MIT")
 	ctx.Data["@@lower_case_table_names"] = value.NewIntValue(0)
 	ctx.Data["max_allowed_packet"] = value.NewIntValue(MaxAllowedPacket)
+	ctx.Data["@@max_execution_time"] = value.NewIntValue(0)
 	ctx.Data["@@max_allowed_packet"] = value.NewIntValue(MaxAllowedPacket)
 	ctx.Data["@@max_allowed_packets"] = value.NewIntValue(MaxAllowedPacket)
 	ctx.Data["@@net_buffer_length"] = value.NewIntValue(16384)
@@ -208,8 +208,33 @@ func (m *qryconn) Put(ctx context.Context, key schema.Key, row interface{}) (sch
 	}
 }
 
+// PutMulti inserts the rows in a single transaction.
 func (m *qryconn) PutMulti(ctx context.Context, keys []schema.Key, src interface{}) ([]schema.Key, error) {
-	return nil, fmt.Errorf("not implemented")
+	rows, ok := src.([][]driver.Value)
+	if !ok {
+		return nil, fmt.Errorf("Expected [][]driver.Value but got %T", src)
+	}
+	tx, err := m.source.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	keys = make([]schema.Key, 0, len(rows))
+	for _, row := range rows {
+		if len(row) != len(m.cols) {
+			tx.Rollback()
+			return nil, fmt.Errorf("Wrong number of columns, got %v expected %v", len(row), len(m.cols))
+		}
+		ivals := make([]interface{}, len(row))
+		for i, v := range row {
+			ivals[i] = v
+		}
+		if _, err = tx.Exec(m.sqlInsert, ivals...); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		keys = append(keys, NewKey(MakeId(row[m.indexCol])))
+	}
+	return keys, tx.Commit()
 }
 
 // Get a single row by key.
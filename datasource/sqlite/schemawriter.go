@@ -81,6 +81,8 @@ func WriteField(w *bytes.Buffer, fld *schema.Field) {
 		fmt.Fprintf(w, "text")
 	case value.NumberType:
 		fmt.Fprint(w, "REAL")
+	case value.DecimalType:
+		fmt.Fprint(w, "NUMERIC")
 	case value.TimeType:
 		fmt.Fprint(w, "text")
 	case value.JsonType:
@@ -95,12 +97,18 @@ func WriteField(w *bytes.Buffer, fld *schema.Field) {
 
 // TypeFromString given a string, return data type
 func TypeFromString(t string) value.ValueType {
+	// numeric(10,2) => numeric
+	if pos := strings.IndexByte(t, '('); pos > 0 {
+		t = t[:pos]
+	}
 	switch strings.ToLower(t) {
 	case "integer":
 		// This isn't necessarily true, as integer could be bool
 		return value.IntType
 	case "real":
 		return value.NumberType
+	case "numeric", "decimal":
+		return value.DecimalType
 	default:
 		return value.StringType
 	}
@@ -119,6 +127,8 @@ func ValueString(t value.ValueType) string {
 		return "text"
 	case value.NumberType:
 		return "real"
+	case value.DecimalType:
+		return "numeric"
 	case value.IntType:
 		return "integer"
 	case value.BoolType:
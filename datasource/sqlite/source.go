@@ -4,12 +4,12 @@ package sqlite
 import (
 	"database/sql"
 	"fmt"
+	"regexp"
 	"strings"
 	"sync"
 
 	u "github.com/araddon/gou"
-	// Import Sqlite driver
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/schema"
@@ -18,16 +18,50 @@ import (
 const (
 	// SourceType "sqlite" is the registered Source name in the qlbridge source registry
 	SourceType = "sqlite"
+	// DriverName the sqlite3 driver with a regexp() function for REGEXP
+	DriverName = "sqlite3_qlbridge"
 )
 
 func init() {
 	// We need to register our DataSource provider here
 	schema.RegisterSourceType(SourceType, newSourceEmtpy())
+	sql.Register(DriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("regexp", regexpMatch, true)
+		},
+	})
+}
+
+var regexps = struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}{m: make(map[string]*regexp.Regexp)}
+
+// regexpMatch the sqlite regexp(pattern, value) function behind
+// value REGEXP pattern, patterns are compiled once.
+func regexpMatch(pattern, val string) (bool, error) {
+	regexps.Lock()
+	re, ok := regexps.m[pattern]
+	if !ok {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			regexps.Unlock()
+			return false, err
+		}
+		if len(regexps.m) > 1000 {
+			regexps.m = make(map[string]*regexp.Regexp)
+		}
+		regexps.m[pattern] = re
+	}
+	regexps.Unlock()
+	return re.MatchString(val), nil
 }
 
 var (
 	// Ensure our source implements Source interface
-	_ schema.Source = (*Source)(nil)
+	_ schema.Source             = (*Source)(nil)
+	_ schema.SourceTableCreator = (*Source)(nil)
+	_ schema.Alter              = (*Source)(nil)
 	// ensure our Source implements connection features
 	_ schema.Conn = (*Source)(nil)
 )
@@ -37,6 +71,10 @@ var (
 // Features
 // - Support full predicate push down to SqlLite.
 // - Support Thread-Safe wrapper around sqlite file.
+//
+// Not partitioned: partition_count is ignored and tables are scanned by a
+// single conn, as the select is pushed down whole and sqlite serializes
+// access to the file anyway.
 type Source struct {
 	exit      <-chan bool
 	schema    *schema.Schema
@@ -65,6 +103,9 @@ func (m *Source) Setup(s *schema.Schema) error {
 
 	u.Debugf("got new sqlite schema %s", s.Name)
 	m.schema = s
+	if s.Conf != nil && (s.Conf.PartitionCt > 0 || len(s.Conf.Partitions) > 0) {
+		u.Warnf("sqlite source %q is not partitioned, ignoring its partitions", s.Name)
+	}
 	if m.db != nil {
 		return nil
 	}
@@ -77,7 +118,7 @@ func (m *Source) Setup(s *schema.Schema) error {
 
 	// It will be created if it doesn't exist.
 	//   "./source.enriched.db"
-	db, err := sql.Open("sqlite3", m.file)
+	db, err := sql.Open(DriverName, m.file)
 	if err != nil {
 		u.Errorf("could not open %q err=%v", m.file, err)
 		return err
@@ -146,6 +187,45 @@ func (m *Source) Table(table string) (*schema.Table, error) {
 // Tables gets list of tables
 func (m *Source) Tables() []string { return m.tableList }
 
+// CreateTable creates tbl in the sqlite db, dropping any table of the same name.
+func (m *Source) CreateTable(tbl *schema.Table) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS `%s`;", tbl.Name)); err != nil {
+		return err
+	}
+	if _, err := m.db.Exec(TableToString(tbl)); err != nil {
+		return err
+	}
+	m.tblmu.Lock()
+	if _, exists := m.tables[tbl.Name]; !exists {
+		m.tableList = append(m.tableList, tbl.Name)
+	}
+	m.tables[tbl.Name] = tbl
+	m.tblmu.Unlock()
+	return nil
+}
+
+// DropTable drops table from the sqlite db.
+func (m *Source) DropTable(table string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS `%s`;", table)); err != nil {
+		return err
+	}
+	m.tblmu.Lock()
+	delete(m.tables, table)
+	tl := make([]string, 0, len(m.tableList))
+	for _, name := range m.tableList {
+		if name != table {
+			tl = append(tl, name)
+		}
+	}
+	m.tableList = tl
+	m.tblmu.Unlock()
+	return nil
+}
+
 // Close this source, closing the underlying sqlite db file
 func (m *Source) Close() error {
 	if m.db != nil {
@@ -3,6 +3,7 @@ package sqlite_test
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"os"
 	"sync"
@@ -16,9 +17,11 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/exec"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/schema"
 	"github.com/araddon/qlbridge/testutil"
+	"github.com/araddon/qlbridge/value"
 )
 
 /*
@@ -145,3 +148,70 @@ func TestSuite(t *testing.T) {
 	LoadTestDataOnce(t)
 	testutil.RunSimpleSuite(t)
 }
+
+func TestPatternMatch(t *testing.T) {
+	defer func() {
+		td.SetContextToMockCsv()
+	}()
+	LoadTestDataOnce(t)
+	// LIKE is case-sensitive and _ a single character, unlike sqlite's LIKE
+	testutil.TestSelect(t, `SELECT email FROM users WHERE email LIKE "AARON%"`,
+		[][]driver.Value{},
+	)
+	testutil.TestSelect(t, `SELECT email FROM users WHERE email LIKE "b_b@%"`,
+		[][]driver.Value{{"bob@email.com"}},
+	)
+	testutil.TestSelect(t, `SELECT email FROM users WHERE email ILIKE "AARON%"`,
+		[][]driver.Value{{"aaron@email.com"}},
+	)
+	testutil.TestSelect(t, `SELECT email FROM users WHERE email REGEXP "^b.b@"`,
+		[][]driver.Value{{"bob@email.com"}},
+	)
+}
+
+func TestCreateTable(t *testing.T) {
+	defer func() {
+		td.SetContextToMockCsv()
+	}()
+	LoadTestDataOnce(t)
+	td.TestContext = planContext
+
+	creator, ok := sch.DS.(schema.SourceTableCreator)
+	assert.True(t, ok)
+	tbl := schema.NewTable("user_counts")
+	tbl.AddFieldType("user_id", value.StringType)
+	tbl.AddFieldType("ct", value.IntType)
+	tbl.SetColumnsFromFields()
+	assert.Equal(t, nil, creator.CreateTable(tbl))
+	// created again is empty
+	assert.Equal(t, nil, creator.CreateTable(tbl))
+
+	conn, err := sch.DS.Open("user_counts")
+	assert.Equal(t, nil, err)
+	keys, err := conn.(schema.ConnUpsert).PutMulti(context.Background(), nil, [][]driver.Value{
+		{"9Ip1aKbeZe2njCDM", int64(2)},
+		{"hT2impsOPUREcVPc", int64(1)},
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(keys))
+	assert.Equal(t, nil, conn.Close())
+
+	db, err := sql.Open("sqlite3", testFile)
+	assert.Equal(t, nil, err)
+	var ct int64
+	assert.Equal(t, nil, db.QueryRow(`SELECT ct FROM user_counts WHERE user_id = "9Ip1aKbeZe2njCDM"`).Scan(&ct))
+	assert.Equal(t, int64(2), ct)
+	db.Close()
+	assert.Equal(t, nil, sch.DS.(schema.Alter).DropTable("user_counts"))
+
+	// a materialized view stored in sqlite
+	ctx := planContext(`CREATE MATERIALIZED VIEW user_orders AS
+		SELECT user_id, count(*) AS ct FROM orders GROUP BY user_id WITH source = "sqlite_test"`)
+	job, err := exec.BuildSqlJob(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, job.Setup())
+	assert.Equal(t, nil, job.Run())
+	testutil.TestSelect(t, `SELECT user_id, ct FROM user_orders WHERE ct > 1`,
+		[][]driver.Value{{"9Ip1aKbeZe2njCDM", int64(2)}},
+	)
+}
@@ -123,6 +123,13 @@ func (m *rewrite) walkSelectList() error {
 			// 	return nil, value.NewStringValue(curNode.Text), nil
 			case *expr.IdentityNode:
 				//u.Debugf("likely a projection, not agg T:%T  %v", curNode, curNode)
+			case *expr.CaseNode:
+				newNode, err := m.walkCase(curNode)
+				if err != nil {
+					u.Error(err)
+					return err
+				}
+				col.Expr = newNode
 			default:
 				u.Warnf("unrecognized not agg T:%T  %v", curNode, curNode)
 				//panic("Unrecognized node type")
@@ -203,6 +210,8 @@ func (m *rewrite) walkNode(cur expr.Node) (expr.Node, error) {
 		return m.walkFilterBinary(curNode)
 	case *expr.TriNode: // Between
 		return m.walkFilterTri(curNode)
+	case *expr.CaseNode:
+		return m.walkCase(curNode)
 	case *expr.UnaryNode:
 		//return m.walkUnary(curNode)
 		u.Warnf("not implemented: %#v", curNode)
@@ -224,9 +233,19 @@ func (m *rewrite) walkNode(cur expr.Node) (expr.Node, error) {
 // Tri Nodes expressions:
 //
 //     <expression> [NOT] BETWEEN <expression> AND <expression>
+//     <expression> [NOT] LIKE <expression> ESCAPE <expression>
 //
 func (m *rewrite) walkFilterTri(node *expr.TriNode) (expr.Node, error) {
 
+	switch node.Operator.T {
+	case lex.TokenLike, lex.TokenILike:
+		escape, ok := node.Args[2].(*expr.StringNode)
+		if !ok {
+			return nil, fmt.Errorf("unsupported ESCAPE argument: %v", node.Args[2])
+		}
+		return rewriteLike(node, node.Operator.T, node.Args, escape.Text)
+	}
+
 	/*
 		arg1val, aok, _ := m.eval(node.Args[0])
 		if !aok {
@@ -246,6 +265,34 @@ func (m *rewrite) walkFilterTri(node *expr.TriNode) (expr.Node, error) {
 	return node, nil
 }
 
+// Case Nodes, sqlite evaluates CASE natively so only the children
+// need rewriting
+//
+//    CASE WHEN x IS NOT NULL THEN 1 END   =>   CASE WHEN x IS NOT NULL THEN 1 END
+//
+func (m *rewrite) walkCase(node *expr.CaseNode) (expr.Node, error) {
+	var err error
+	if node.Operand != nil {
+		if node.Operand, err = m.walkNode(node.Operand); err != nil {
+			return nil, err
+		}
+	}
+	for i := range node.Whens {
+		if node.Whens[i], err = m.walkNode(node.Whens[i]); err != nil {
+			return nil, err
+		}
+		if node.Thens[i], err = m.walkNode(node.Thens[i]); err != nil {
+			return nil, err
+		}
+	}
+	if node.Else != nil {
+		if node.Else, err = m.walkNode(node.Else); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
 // Array Nodes expressions:
 //
 //    year IN (1990,1992)  =>
@@ -268,14 +315,23 @@ func (m *rewrite) walkFilterBinary(node *expr.BinaryNode) (expr.Node, error) {
 
 	// If we have to recurse deeper for AND, OR operators
 	switch node.Operator.T {
-	case lex.TokenNE:
-		if node.Args[1].String() == "NULL" {
-			//u.Errorf("we found something wrong werener")
-			//return expr.NewIdentityNodeVal(fmt.Sprintf("%s IS NOT NULL", node.Args[0])), nil
-			node.Operator.V = "IS NOT NULL"
-			node.Args[1] = expr.NewIdentityNodeVal("")
-			//u.Warnf("rh %#v", node.Args[1])
+	case lex.TokenIsDistinctFrom:
+		// sqlite null-safe comparisons are IS NOT and IS, x IS [NOT] NULL
+		// writes itself as such
+		node.Operator.V = "IS NOT"
+		return node, nil
+	case lex.TokenIsNotDistinctFrom:
+		node.Operator.V = "IS"
+		return node, nil
+	case lex.TokenLike, lex.TokenILike:
+		args := node.Args
+		if sn, ok := args[1].(*expr.StringNode); ok && node.Glob {
+			args = []expr.Node{args[0], expr.NewStringNode(node.LikePattern(sn.Text))}
 		}
+		return rewriteLike(node, node.Operator.T, args, vm.DefaultLikeEscape)
+	case lex.TokenRegexp:
+		// evaluated by the regexp() function registered on our connections
+		node.Operator.V = "REGEXP"
 		return node, nil
 		// case lex.TokenLogicOr:
 		// 	lh, err := m.walkNode(node.Args[0])
@@ -308,10 +364,6 @@ func (m *rewrite) walkFilterBinary(node *expr.BinaryNode) (expr.Node, error) {
 	case lex.TokenGT:
 		// db.inventory.find( { qty: { $gt: 20 } } )
 
-	case lex.TokenLike:
-		// { $text: { $search: <string>, $language: <string> } }
-		// { <field>: { $regex: /pattern/, $options: '<options>' } }
-
 	case lex.TokenIN:
 		// switch vt := node.Args[1].(type) {
 		// case value.SliceValue:
@@ -326,6 +378,52 @@ func (m *rewrite) walkFilterBinary(node *expr.BinaryNode) (expr.Node, error) {
 	return node, nil
 }
 
+// rewriteLike sqlite LIKE ignores case and has no default escape character
+// so LIKE becomes the case-sensitive GLOB and ILIKE a LIKE with ESCAPE.
+//
+//    x LIKE "a\_b%"             =>   x GLOB "a_b*"
+//    x LIKE "a!%" ESCAPE "!"     =>   x GLOB "a%"
+//    x ILIKE "ab%"               =>   x LIKE "ab%" ESCAPE "\"
+//
+func rewriteLike(node expr.Node, op lex.TokenType, args []expr.Node, escape string) (expr.Node, error) {
+	pattern, ok := args[1].(*expr.StringNode)
+	if !ok {
+		return nil, fmt.Errorf("unsupported non-string %s pattern: %v", op, args[1])
+	}
+	negated := false
+	if nn, ok := node.(expr.NegateableNode); ok {
+		negated = nn.Negated()
+	}
+	if op == lex.TokenILike {
+		// validate the pattern, sqlite would ignore a bad escape
+		if _, err := vm.TranslateLike(pattern.Text, escape, "%", "_", func(s string) string { return s }); err != nil {
+			return nil, err
+		}
+		tri := expr.NewTriNode(lex.Token{T: lex.TokenLike, V: "LIKE"}, args[0], pattern, expr.NewStringNode(escape))
+		if negated {
+			tri.ReverseNegation()
+		}
+		return tri, nil
+	}
+	glob, err := vm.TranslateLike(pattern.Text, escape, "*", "?", globQuote)
+	if err != nil {
+		return nil, err
+	}
+	bn := expr.NewBinaryNode(lex.Token{T: lex.TokenLike, V: "GLOB"}, args[0], expr.NewStringNode(glob))
+	if negated {
+		return expr.NewUnary(lex.Token{T: lex.TokenNegate, V: "NOT"}, bn), nil
+	}
+	return bn, nil
+}
+
+// globQuote escape the GLOB wildcards of literal text as single character
+// classes, GLOB has no escape character.
+func globQuote(s string) string {
+	return globEscaper.Replace(s)
+}
+
+var globEscaper = strings.NewReplacer("*", "[*]", "?", "[?]", "[", "[[]")
+
 // Take an expression func, ensure we don't do runtime-checking (as the function)
 // doesn't really exist, then map that function to a mongo operation
 //
@@ -0,0 +1,294 @@
+package exec
+
+import (
+	"database/sql/driver"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
+)
+
+// BatchSize is the number of rows per batch when tasks exchange
+// column-oriented batches instead of single row messages.  Only sources
+// implementing datasource.BatchScanner produce batches, set to 0 to
+// disable batch execution.
+var BatchSize = 1024
+
+// batchKernel filters the selected rows of a batch returning the positions
+// that are still selected.  It may re-use sel for its result.
+type batchKernel func(b *datasource.Batch, sel []int, row *datasource.BatchRow) []int
+
+// keepValue is the where clause rule for keeping a row given the evaluated
+// filter value, non-bool values are kept unless nil.
+func keepValue(v value.Value, ok bool) bool {
+	if !ok || v == nil {
+		return false
+	}
+	switch vt := v.(type) {
+	case value.BoolValue:
+		return vt.Val()
+	default:
+		return !vt.Nil()
+	}
+}
+
+// isTrue is the rule for arguments of an AND, which must be a true bool.
+func isTrue(v value.Value, ok bool) bool {
+	bv, isBool := v.(value.BoolValue)
+	return ok && isBool && bv.Val()
+}
+
+// newBatchFilter creates the vectorized filter for a where clause.  Each
+// argument of a top level AND is evaluated as its own kernel over the
+// column vectors narrowing the selection vector, comparisons of a column
+// to a literal run as typed loops and anything else evaluates the compiled
+// expression against each selected row.
+func newBatchFilter(filter expr.Node) (batchKernel, error) {
+	args := andArgs(filter)
+	if len(args) == 1 {
+		if k := compareKernel(filter, keepValue); k != nil {
+			return k, nil
+		}
+		prog, err := vm.Compile(filter)
+		if err != nil {
+			return nil, err
+		}
+		return rowKernel(func(ctx expr.EvalContext) bool {
+			return keepValue(prog.Eval(ctx))
+		}), nil
+	}
+	kernels := make([]batchKernel, 0, len(args))
+	for _, arg := range args {
+		if k := compareKernel(arg, isTrue); k != nil {
+			kernels = append(kernels, k)
+			continue
+		}
+		prog, err := vm.Compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		kernels = append(kernels, rowKernel(func(ctx expr.EvalContext) bool {
+			return isTrue(prog.Eval(ctx))
+		}))
+	}
+	return func(b *datasource.Batch, sel []int, row *datasource.BatchRow) []int {
+		for _, k := range kernels {
+			if len(sel) == 0 {
+				break
+			}
+			sel = k(b, sel, row)
+		}
+		return sel
+	}, nil
+}
+
+// andArgs flattens an AND into its arguments, NOT AND is left as is.
+func andArgs(n expr.Node) []expr.Node {
+	switch nt := n.(type) {
+	case *expr.BooleanNode:
+		if !nt.Negated() && (nt.Operator.T == lex.TokenAnd || nt.Operator.T == lex.TokenLogicAnd) {
+			args := make([]expr.Node, 0, len(nt.Args))
+			for _, arg := range nt.Args {
+				args = append(args, andArgs(arg)...)
+			}
+			return args
+		}
+	case *expr.BinaryNode:
+		if len(nt.Args) == 2 && (nt.Operator.T == lex.TokenAnd || nt.Operator.T == lex.TokenLogicAnd) {
+			return append(andArgs(nt.Args[0]), andArgs(nt.Args[1])...)
+		}
+	}
+	return []expr.Node{n}
+}
+
+// rowKernel evaluates keep for each selected row.
+func rowKernel(keep func(ctx expr.EvalContext) bool) batchKernel {
+	return func(b *datasource.Batch, sel []int, row *datasource.BatchRow) []int {
+		out := sel[:0]
+		for _, pos := range sel {
+			row.Pos = pos
+			if keep(row) {
+				out = append(out, pos)
+			}
+		}
+		return out
+	}
+}
+
+// compareKernel creates a typed kernel for `column op literal` and
+// `column IN (literals)`, or nil if the node is some other expression.
+// Values of a type the kernel doesn't handle are evaluated by the vm.
+func compareKernel(n expr.Node, keep func(value.Value, bool) bool) batchKernel {
+	bn, ok := n.(*expr.BinaryNode)
+	if !ok || len(bn.Args) != 2 {
+		return nil
+	}
+	in, ok := bn.Args[0].(*expr.IdentityNode)
+	if !ok || in.IsBooleanIdentity() {
+		return nil
+	}
+	key := in.Text
+	if in.HasLeftRight() {
+		key = in.OriginalText()
+	}
+
+	var test func(v driver.Value) (matches, handled bool)
+
+	op := bn.Operator.T
+	switch op {
+	case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenNE,
+		lex.TokenGT, lex.TokenGE, lex.TokenLT, lex.TokenLE:
+
+		switch lit := bn.Args[1].(type) {
+		case *expr.NumberNode:
+			if lit.IsInt {
+				b, fb := lit.Int64, float64(lit.Int64)
+				test = func(v driver.Value) (bool, bool) {
+					switch vt := v.(type) {
+					case int64:
+						return compareInts(op, vt, b), true
+					case int:
+						return compareInts(op, int64(vt), b), true
+					case float64:
+						return compareFloats(op, vt, fb), true
+					}
+					return false, false
+				}
+			} else {
+				b := lit.Float64
+				test = func(v driver.Value) (bool, bool) {
+					switch vt := v.(type) {
+					case int64:
+						return compareFloats(op, float64(vt), b), true
+					case int:
+						return compareFloats(op, float64(vt), b), true
+					case float64:
+						return compareFloats(op, vt, b), true
+					}
+					return false, false
+				}
+			}
+		case *expr.StringNode:
+			if op != lex.TokenEqual && op != lex.TokenEqualEqual && op != lex.TokenNE {
+				return nil
+			}
+			b, ne := lit.Text, op == lex.TokenNE
+			test = func(v driver.Value) (bool, bool) {
+				if vt, isStr := v.(string); isStr {
+					return (vt == b) != ne, true
+				}
+				return false, false
+			}
+		default:
+			return nil
+		}
+
+	case lex.TokenIN:
+		arr, ok := bn.Args[1].(*expr.ArrayNode)
+		if !ok || len(arr.Args) == 0 {
+			return nil
+		}
+		strs := make(map[string]struct{})
+		ints := make(map[int64]struct{})
+		for _, arg := range arr.Args {
+			switch lit := arg.(type) {
+			case *expr.StringNode:
+				strs[lit.Text] = struct{}{}
+			case *expr.NumberNode:
+				if !lit.IsInt {
+					return nil
+				}
+				ints[lit.Int64] = struct{}{}
+			default:
+				return nil
+			}
+		}
+		// mixed type lists are left to the vm
+		if len(strs) > 0 && len(ints) > 0 {
+			return nil
+		}
+		test = func(v driver.Value) (bool, bool) {
+			switch vt := v.(type) {
+			case string:
+				if len(strs) > 0 {
+					_, found := strs[vt]
+					return found, true
+				}
+			case int64:
+				if len(ints) > 0 {
+					_, found := ints[vt]
+					return found, true
+				}
+			}
+			return false, false
+		}
+	default:
+		return nil
+	}
+
+	prog, err := vm.Compile(n)
+	if err != nil {
+		u.Debugf("could not compile %s err=%v", n, err)
+		return nil
+	}
+	return func(b *datasource.Batch, sel []int, row *datasource.BatchRow) []int {
+		col, ok := b.Col(key)
+		if !ok {
+			return rowKernel(func(ctx expr.EvalContext) bool {
+				return keep(prog.Eval(ctx))
+			})(b, sel, row)
+		}
+		out := sel[:0]
+		for _, pos := range sel {
+			matches, handled := test(col[pos])
+			if !handled {
+				row.Pos = pos
+				matches = keep(prog.Eval(row))
+			}
+			if matches {
+				out = append(out, pos)
+			}
+		}
+		return out
+	}
+}
+
+func compareInts(op lex.TokenType, a, b int64) bool {
+	switch op {
+	case lex.TokenEqual, lex.TokenEqualEqual:
+		return a == b
+	case lex.TokenNE:
+		return a != b
+	case lex.TokenGT:
+		return a > b
+	case lex.TokenGE:
+		return a >= b
+	case lex.TokenLT:
+		return a < b
+	case lex.TokenLE:
+		return a <= b
+	}
+	return false
+}
+
+func compareFloats(op lex.TokenType, a, b float64) bool {
+	switch op {
+	case lex.TokenEqual, lex.TokenEqualEqual:
+		return a == b
+	case lex.TokenNE:
+		return a != b
+	case lex.TokenGT:
+		return a > b
+	case lex.TokenGE:
+		return a >= b
+	case lex.TokenLT:
+		return a < b
+	case lex.TokenLE:
+		return a <= b
+	}
+	return false
+}
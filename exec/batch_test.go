@@ -0,0 +1,110 @@
+package exec_test
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/memdb"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var batchEventsOnce sync.Once
+
+// batchContext a context for the batch_events table, held in a memdb
+// so columns are typed unlike the csv mock tables.
+func batchContext(sqlText string) *plan.Context {
+	batchEventsOnce.Do(func() {
+		events := []string{"signup", "view", "purchase", "refund"}
+		rows := make([][]driver.Value, 5000)
+		for i := range rows {
+			rows[i] = []driver.Value{int64(i), fmt.Sprintf("user%d", i%17), events[i%len(events)], float64(i%100) + 0.5, int64(i % 7)}
+		}
+		db, err := memdb.NewMemDbData("batch_events", rows, []string{"event_id", "user_id", "event", "price", "item_count"})
+		if err != nil {
+			panic(err.Error())
+		}
+		if err = schema.RegisterSourceAsSchema("batch_test", db); err != nil {
+			panic(err.Error())
+		}
+	})
+	ctx := plan.NewContext(sqlText)
+	ctx.DisableRecover = true
+	ctx.Schema, _ = schema.DefaultRegistry().Schema("batch_test")
+	ctx.Session = datasource.NewMySqlSessionVars()
+	return ctx
+}
+
+func runRows(t testing.TB, sqlText string) []string {
+	ctx := batchContext(sqlText)
+	job, err := exec.BuildSqlJob(ctx)
+	assert.Equal(t, nil, err, sqlText)
+
+	msgs := make([]schema.Message, 0)
+	job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+
+	assert.Equal(t, nil, job.Setup(), sqlText)
+	assert.Equal(t, nil, job.Run(), sqlText)
+
+	rows := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		// limit sends a nil message for shutdown
+		if msg != nil {
+			rows = append(rows, fmt.Sprintf("%v", msg.(*datasource.SqlDriverMessageMap).Values()))
+		}
+	}
+	sort.Strings(rows)
+	return rows
+}
+
+// Batch execution must return the same rows as the row at a time path.
+func TestExecBatchMatchesRows(t *testing.T) {
+	defer func(size int) { exec.BatchSize = size }(exec.BatchSize)
+
+	for _, sqlText := range []string{
+		`SELECT event_id, user_id, price FROM batch_events`,
+		`SELECT event_id, user_id FROM batch_events WHERE item_count > 3`,
+		`SELECT event_id, price FROM batch_events WHERE price >= 50 AND event = "purchase" AND user_id IN ("user1", "user2")`,
+		`SELECT event_id FROM batch_events WHERE event != "view" AND item_count IN (1, 2) AND price < 20.5`,
+		`SELECT event_id, item_count * 2 FROM batch_events WHERE user_id LIKE "user1%" OR item_count == 0`,
+		`SELECT event_id FROM batch_events WHERE NOT (event = "refund" AND item_count > 2)`,
+		`SELECT event_id, user_id FROM batch_events WHERE item_count > 3 LIMIT 10`,
+		`SELECT user_id, count(*), sum(price), avg(item_count) FROM batch_events GROUP BY user_id`,
+		`SELECT event, count(event_id) FROM batch_events WHERE price > 10 GROUP BY event`,
+		`SELECT user_id, count(*) AS ct FROM batch_events GROUP BY user_id HAVING ct > 294`,
+	} {
+		exec.BatchSize = 0
+		expected := runRows(t, sqlText)
+		assert.NotEqual(t, 0, len(expected), sqlText)
+		for _, size := range []int{1024, 7} {
+			exec.BatchSize = size
+			assert.Equal(t, expected, runRows(t, sqlText), "batch=%d %s", size, sqlText)
+		}
+	}
+}
+
+func benchmarkBatch(b *testing.B, size int) {
+	defer func(size int) { exec.BatchSize = size }(exec.BatchSize)
+	exec.BatchSize = size
+
+	sqlText := `SELECT user_id, count(*), sum(price) FROM batch_events WHERE item_count > 1 AND event != "view" GROUP BY user_id`
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runRows(b, sqlText)
+	}
+}
+
+/*
+BenchmarkExecRows     	     354	   9157206 ns/op	  867408 B/op	   34990 allocs/op
+BenchmarkExecBatch    	    1012	   2201678 ns/op	  810732 B/op	   19607 allocs/op
+*/
+func BenchmarkExecRows(b *testing.B)  { benchmarkBatch(b, 0) }
+func BenchmarkExecBatch(b *testing.B) { benchmarkBatch(b, exec.BatchSize) }
@@ -10,6 +10,7 @@ import (
 	"github.com/araddon/qlbridge/lex"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
 	"github.com/araddon/qlbridge/vm"
 )
 
@@ -48,6 +49,13 @@ func (m *Command) Run() error {
 	//defer m.Ctx.Recover()
 	defer close(m.msgOutCh)
 
+	if m.p.Stmt.Keyword() == lex.TokenKill {
+		return m.runKill()
+	}
+	if m.p.Stmt.Keyword() == lex.TokenRefresh {
+		return m.runRefresh()
+	}
+
 	if m.Ctx.Session == nil {
 		u.Warnf("no Context.Session?")
 		return fmt.Errorf("no Context.Session?")
@@ -87,6 +95,34 @@ func (m *Command) runSet() error {
 	return nil
 }
 
+// runKill stops the job with the process id.
+//
+//    KILL [CONNECTION | QUERY] <id>
+//
+func (m *Command) runKill() error {
+	id, ok := m.p.Stmt.Value.(*expr.NumberNode)
+	if !ok || !id.IsInt || id.Int64 <= 0 {
+		return fmt.Errorf("Expected process id for %s", m.p.Stmt)
+	}
+	if p, ok := plan.Processes.Get(uint64(id.Int64)); ok && !plan.ProcessAllowed(m.Ctx, &p) {
+		return fmt.Errorf("You are not owner of thread %d", p.Id)
+	}
+	return plan.Processes.Kill(uint64(id.Int64))
+}
+
+// runRefresh REFRESH MATERIALIZED VIEW, folding in only the rows appended
+// since the last refresh if the view has an append column.
+func (m *Command) runRefresh() error {
+	if m.Ctx.Schema == nil {
+		return fmt.Errorf("must have schema")
+	}
+	v, ok := m.Ctx.Schema.View(m.p.Stmt.Identity)
+	if !ok {
+		return fmt.Errorf("view %q not found", m.p.Stmt.Identity)
+	}
+	return refreshView(m.Ctx, v, false)
+}
+
 func evalSetExpression(col *rel.CommandColumn, ctx expr.ContextReadWriter, arg expr.Node) error {
 
 	switch bn := arg.(type) {
@@ -101,6 +137,12 @@ func evalSetExpression(col *rel.CommandColumn, ctx expr.ContextReadWriter, arg e
 			u.Warnf("expected right side value but got %T in %s", bn.Args[1], arg.String())
 			return fmt.Errorf("Expected value but got %T", bn.Args[1])
 		}
+		if isTimeZoneVar(col.Name) {
+			return setTimeZone(col, ctx, rhv)
+		}
+		if name := sessionVarName(col.Name); intSessionVars[name] {
+			return setIntSessionVar(col, ctx, name, rhv)
+		}
 		//u.Infof(`writeContext.Put("%v",%v)`, col.Key(), rhv.Value())
 		ctx.Put(col, ctx, rhv)
 	case nil:
@@ -122,3 +164,65 @@ func evalSetExpression(col *rel.CommandColumn, ctx expr.ContextReadWriter, arg e
 	}
 	return nil
 }
+
+// isTimeZoneVar is this the session time zone variable, any of
+// time_zone, @@time_zone, @@session.time_zone
+func isTimeZoneVar(name string) bool {
+	return sessionVarName(name) == "time_zone"
+}
+
+// sessionVarName the lower-case name of a session variable without
+// its @@ or @@session. prefix.
+func sessionVarName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.TrimPrefix(name, "@@")
+	return strings.TrimPrefix(name, "session.")
+}
+
+// intSessionVars the session variables of the executor that hold
+// a non-negative integer.
+var intSessionVars = map[string]bool{
+	"max_execution_time": true, // milliseconds
+	"query_memory_limit": true, // bytes
+	"query_memory_spill": true, // bytes
+}
+
+// setIntSessionVar validate and store an integer session variable under
+// each of its aliases.
+//
+//    SET max_execution_time = 1000
+//    SET @@session.query_memory_limit = 1073741824
+//
+func setIntSessionVar(col *rel.CommandColumn, ctx expr.ContextReadWriter, name string, v value.Value) error {
+	iv, ok := value.ValueToInt64(v)
+	if !ok || iv < 0 {
+		return fmt.Errorf("Incorrect argument type to variable '%s': %v", name, v.Value())
+	}
+	for _, key := range []string{col.Name, "@@" + name, "@@session." + name} {
+		if err := ctx.Put(&rel.CommandColumn{Name: key}, ctx, value.NewIntValue(iv)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setTimeZone validate and store the session time zone under each of its
+// aliases so all of them read the same value back.
+//
+//    SET time_zone = 'America/Denver'
+//    SET @@session.time_zone = '+05:30'
+//
+func setTimeZone(col *rel.CommandColumn, ctx expr.ContextReadWriter, tz value.Value) error {
+	name := tz.ToString()
+	if !strings.EqualFold(name, "SYSTEM") {
+		if _, ok := value.LoadLocation(name); !ok {
+			return fmt.Errorf("Unknown or incorrect time zone: %q", name)
+		}
+	}
+	for _, key := range []string{col.Name, "@@time_zone", "@@session.time_zone"} {
+		if err := ctx.Put(&rel.CommandColumn{Name: key}, ctx, value.NewStringValue(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
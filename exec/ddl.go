@@ -16,6 +16,7 @@ var (
 	_ TaskRunner = (*Create)(nil)
 	_ TaskRunner = (*Drop)(nil)
 	_ TaskRunner = (*Alter)(nil)
+	_ TaskRunner = (*Grant)(nil)
 )
 
 type (
@@ -34,6 +35,11 @@ type (
 		*TaskBase
 		p *plan.Alter
 	}
+	// Grant is executeable task for SQL GRANT and REVOKE.
+	Grant struct {
+		*TaskBase
+		p *plan.Grant
+	}
 )
 
 // NewCreate creates new create exec task
@@ -90,6 +96,73 @@ func (m *Create) Run() error {
 		reg := schema.DefaultRegistry()
 
 		return reg.SchemaAddFromConfig(sourceConf)
+	case lex.TokenFunction:
+		// CREATE [OR REPLACE] FUNCTION domain_of(url) AS lower(domain(url))
+		// CREATE [OR REPLACE] FUNCTION clean_sku(string) RETURNS string LANGUAGE wasm FROM 'sku.wasm'
+		s := m.Ctx.Schema
+		if s == nil {
+			return fmt.Errorf("must have schema")
+		}
+		if _, exists := s.Function(cs.Identity); exists && !cs.OrReplace {
+			return fmt.Errorf("function %q already exists", cs.Identity)
+		}
+		if cs.Func.IsExternal() {
+			if err := cs.Func.Load(); err != nil {
+				return err
+			}
+		}
+		reg := schema.DefaultRegistry()
+		if err := reg.FunctionAdd(s.Name, cs.Func); err != nil {
+			cs.Func.Close()
+			return err
+		}
+		return nil
+	case lex.TokenPolicy:
+		// CREATE [OR REPLACE] POLICY tenant_only ON events USING tenant_id = session.tenant_id
+		// CREATE [OR REPLACE] POLICY hide_email ON users MASK email USING hash.sha1(email)
+		s := m.Ctx.Schema
+		if s == nil {
+			return fmt.Errorf("must have schema")
+		}
+		if _, exists := s.Policy(cs.Identity); exists && !cs.OrReplace {
+			return fmt.Errorf("policy %q already exists", cs.Identity)
+		}
+		tbl, err := s.Table(cs.Table)
+		if err != nil {
+			return fmt.Errorf("policy %q table %q not found", cs.Identity, cs.Table)
+		}
+		if cs.Column != "" && len(tbl.Fields) > 0 && !tbl.HasField(cs.Column) {
+			return fmt.Errorf("policy %q column %q not found in %q", cs.Identity, cs.Column, cs.Table)
+		}
+		reg := schema.DefaultRegistry()
+		return reg.PolicyAdd(s.Name, schema.NewPolicy(cs.Identity, cs.Table, cs.Column, cs.Using))
+	case lex.TokenView:
+		// CREATE [OR REPLACE] MATERIALIZED VIEW hits_by_user AS SELECT ... WITH append = "ts"
+		if !cs.Materialized {
+			break
+		}
+		return createView(m.Ctx, cs)
+	case lex.TokenUser:
+		reg := schema.DefaultRegistry()
+		if _, exists := reg.User(cs.Identity); exists {
+			return fmt.Errorf("user %q already exists", cs.Identity)
+		}
+		// CREATE USER alice WITH password = "secret"
+		user := schema.NewUser(cs.Identity)
+		if password := cs.With.String("password"); password != "" {
+			cred, err := schema.NewCredential(password)
+			if err != nil {
+				return err
+			}
+			user.Credential = cred
+		}
+		return reg.UserAdd(user)
+	case lex.TokenRole:
+		reg := schema.DefaultRegistry()
+		if _, exists := reg.Role(cs.Identity); exists {
+			return fmt.Errorf("role %q already exists", cs.Identity)
+		}
+		return reg.RoleAdd(schema.NewRole(cs.Identity))
 	default:
 		u.Warnf("unrecognized create/alter: kw=%v   stmt:%s", cs.Tok, m.p.Stmt)
 	}
@@ -115,16 +188,33 @@ func (m *Drop) Run() error {
 	defer close(m.msgOutCh)
 
 	cs := m.p.Stmt
-	s := m.Ctx.Schema
-	if s == nil {
-		return fmt.Errorf("must have schema")
-	}
+	reg := schema.DefaultRegistry()
 
 	switch cs.Tok.T {
-	case lex.TokenSource, lex.TokenSchema, lex.TokenTable:
+	case lex.TokenUser, lex.TokenRole:
+		// users and roles belong to the registry, not a schema
+		return reg.SchemaDrop("", cs.Identity, cs.Tok.T)
+	case lex.TokenSource, lex.TokenSchema, lex.TokenTable, lex.TokenFunction, lex.TokenPolicy:
 
-		reg := schema.DefaultRegistry()
+		s := m.Ctx.Schema
+		if s == nil {
+			return fmt.Errorf("must have schema")
+		}
 		return reg.SchemaDrop(s.Name, cs.Identity, cs.Tok.T)
+	case lex.TokenView:
+		s := m.Ctx.Schema
+		if s == nil {
+			return fmt.Errorf("must have schema")
+		}
+		v, ok := s.View(cs.Identity)
+		if !ok {
+			return fmt.Errorf("view %q not found", cs.Identity)
+		}
+		if err := reg.SchemaDrop(s.Name, cs.Identity, cs.Tok.T); err != nil {
+			return err
+		}
+		dropViewState(v)
+		return nil
 
 	default:
 		u.Warnf("unrecognized DROP: kw=%v   stmt:%s", cs.Tok, m.p.Stmt)
@@ -158,3 +248,56 @@ func (m *Alter) Run() error {
 	}
 	return ErrNotImplemented
 }
+
+// NewGrant creates new GRANT or REVOKE exec task.
+func NewGrant(ctx *plan.Context, p *plan.Grant) *Grant {
+	m := &Grant{
+		TaskBase: NewTaskBase(ctx),
+		p:        p,
+	}
+	return m
+}
+
+// Close Grant
+func (m *Grant) Close() error {
+	return m.TaskBase.Close()
+}
+
+// Run Grant
+func (m *Grant) Run() error {
+	defer close(m.msgOutCh)
+
+	gs := m.p.Stmt
+	reg := schema.DefaultRegistry()
+	revoke := gs.Tok.T == lex.TokenRevoke
+
+	// GRANT analyst TO alice
+	if gs.Role != "" {
+		if revoke {
+			return reg.RoleRevoke(gs.Role, gs.Grantee)
+		}
+		return reg.RoleGrant(gs.Role, gs.Grantee)
+	}
+
+	// GRANT SELECT, INSERT ON mydb.users TO analyst
+	schemaName := gs.Schema
+	if schemaName == "" {
+		if m.Ctx.Schema == nil {
+			return fmt.Errorf("must have schema")
+		}
+		schemaName = m.Ctx.Schema.Name
+	}
+	for _, privilege := range gs.Privileges {
+		g := schema.NewGrant(gs.Grantee, privilege, schemaName, gs.Table)
+		var err error
+		if revoke {
+			err = reg.GrantDrop(g)
+		} else {
+			err = reg.GrantAdd(g)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ package exec
 import (
 	"fmt"
 
+	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/schema"
 )
@@ -26,6 +27,27 @@ var (
 	ErrInternalError = fmt.Errorf("QLBridge: Internal Error")
 	// ErrNoSchemaSelected no schema was selected when performing statement.
 	ErrNoSchemaSelected = fmt.Errorf("No Schema Selected")
+	// ErrQueryKilled the job was stopped by KILL.
+	ErrQueryKilled = fmt.Errorf("Query execution was interrupted")
+	// ErrQueryTimeout the job ran past the session max_execution_time.
+	ErrQueryTimeout = fmt.Errorf("Query execution was interrupted, maximum statement execution time exceeded")
+
+	// MemoryLimit default limit in bytes of the rows a query may buffer,
+	// SET query_memory_limit overrides it per session.  0 is no limit.
+	MemoryLimit int64
+	// MemorySpillLimit default bytes of buffered rows after which a task
+	// that can spill to disk does, SET query_memory_spill overrides it.
+	MemorySpillLimit int64
+
+	// PlanCache if set caches the plans of select statements by their
+	// fingerprint, so statements differing only by literals skip parse
+	// and plan.  Only used with the default planner, and not for schemas
+	// with policies as those bind identity values into the plan.
+	PlanCache *plan.PlanCache
+	// Results if set caches the rows of select statements whose sources
+	// expose table versions, see schema.SourceTableVersion.  Same
+	// restrictions as PlanCache.
+	Results *ResultCache
 )
 
 type (
@@ -37,6 +59,8 @@ type (
 	MessageChan chan schema.Message
 	// MessageHandler Handle/Forward a message for this Task
 	MessageHandler func(ctx *plan.Context, msg schema.Message) bool
+	// BatchHandler Handle/Forward a batch of rows for this Task
+	BatchHandler func(ctx *plan.Context, b *datasource.Batch) bool
 )
 
 type (
@@ -74,6 +98,16 @@ type (
 		SigChan() SigChan
 		Quit()
 	}
+	// BatchConsumer is a TaskRunner that accepts column-oriented batches
+	// on its input as well as single row messages.
+	BatchConsumer interface {
+		BatchIn() bool
+	}
+	// BatchProducer is a TaskRunner that can send batches on its output,
+	// only enabled when the downstream task is a BatchConsumer.
+	BatchProducer interface {
+		SetBatchOut(enabled bool)
+	}
 	// TaskPrinter a debug printer for dag-shape.
 	TaskPrinter interface {
 		PrintDag(depth int)
@@ -115,6 +149,19 @@ type (
 		WalkAlter(p *plan.Alter) (Task, error)
 	}
 
+	// ExecutorGrant is optionally implemented by an Executor to run GRANT
+	// and REVOKE its own way, else the JobExecutor runs them.
+	ExecutorGrant interface {
+		WalkGrant(p *plan.Grant) (Task, error)
+	}
+
+	// ExecutorPartitions is optionally implemented by an Executor to run the
+	// parallel partition scans of a plan.PartitionMerge its own way, else the
+	// JobExecutor runs them.
+	ExecutorPartitions interface {
+		WalkPartitionMerge(p *plan.PartitionMerge) (Task, error)
+	}
+
 	// ExecutorSource Sources can often do their own execution-plan for sub-select statements
 	// ie mysql can do its own (select, projection) mongo, es can as well
 	// - provide interface to allow passing down select planning to source
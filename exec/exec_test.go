@@ -3,6 +3,8 @@ package exec_test
 import (
 	"database/sql"
 	"database/sql/driver"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,11 +12,17 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/memdb"
 	"github.com/araddon/qlbridge/datasource/mockcsv"
 	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
 	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
 	"github.com/araddon/qlbridge/schema"
 	"github.com/araddon/qlbridge/testutil"
+	"github.com/araddon/qlbridge/value"
 )
 
 func init() {
@@ -96,6 +104,214 @@ func TestExecSqlCommands(t *testing.T) {
 	}
 }
 
+func TestExecSetTimeZone(t *testing.T) {
+
+	runSql := func(sqlText string, session expr.ContextReadWriter) ([]schema.Message, expr.ContextReadWriter, error) {
+		ctx := td.TestContext(sqlText)
+		if session != nil {
+			ctx.Session = session
+		}
+		job, err := exec.BuildSqlJob(ctx)
+		assert.True(t, err == nil, "no error %v", err)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		err = job.Run()
+		time.Sleep(time.Millisecond * 10)
+		return msgs, ctx.Session, err
+	}
+
+	_, session, err := runSql(`SET time_zone = 'America/Denver'`, nil)
+	assert.Equal(t, nil, err)
+	for _, key := range []string{"time_zone", "@@time_zone", "@@session.time_zone"} {
+		tz, ok := session.Get(key)
+		assert.True(t, ok, key)
+		assert.Equal(t, "America/Denver", tz.ToString(), key)
+	}
+
+	// 10:30 utc is 03:30 in denver
+	msgs, _, err := runSql(`SELECT hourofday("2017-01-31T10:30:00Z"), hourofday("2017-01-31T10:30:00Z" AT TIME ZONE "UTC"), @@time_zone`, session)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(msgs))
+	msg := msgs[0].Body().(*datasource.SqlDriverMessageMap)
+	assert.Equal(t, []driver.Value{int64(3), int64(10), "America/Denver"}, msg.Vals)
+
+	// aaron registered 17:29 utc, 11:29 in denver
+	msgs, _, err = runSql(`SELECT email FROM users WHERE hourofday(reg_date) = 11`, session)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(msgs))
+	msg = msgs[0].Body().(*datasource.SqlDriverMessageMap)
+	assert.Equal(t, []driver.Value{"aaron@email.com"}, msg.Vals)
+
+	_, _, err = runSql(`SET @@session.time_zone = 'Mars/Olympus_Mons'`, session)
+	assert.NotEqual(t, nil, err)
+	tz, _ := session.Get("@@time_zone")
+	assert.Equal(t, "America/Denver", tz.ToString())
+}
+
+func TestExecKill(t *testing.T) {
+
+	runSql := func(sqlText string, session expr.ContextReadWriter) ([]schema.Message, expr.ContextReadWriter, error) {
+		ctx := td.TestContext(sqlText)
+		if session != nil {
+			ctx.Session = session
+		}
+		job, err := exec.BuildSqlJob(ctx)
+		assert.True(t, err == nil, "no error %v", err)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		err = job.Run()
+		return msgs, ctx.Session, err
+	}
+	// a select whose results are never read only stops if killed
+	runBlocked := func(sqlText string, session expr.ContextReadWriter) (chan bool, chan error) {
+		ctx := td.TestContext(sqlText)
+		if session != nil {
+			ctx.Session = session
+		}
+		job, err := exec.BuildSqlJob(ctx)
+		assert.True(t, err == nil, "no error %v", err)
+		started := make(chan bool)
+		var once sync.Once
+		sink := exec.NewTaskBase(ctx)
+		sink.Handler = func(ctx *plan.Context, msg schema.Message) bool {
+			once.Do(func() { close(started) })
+			<-sink.SigChan()
+			return false
+		}
+		job.RootTask.Add(sink)
+		assert.Equal(t, nil, job.Setup())
+		errCh := make(chan error, 1)
+		go func() { errCh <- job.Run() }()
+		return started, errCh
+	}
+	processId := func(info string) uint64 {
+		msgs, _, err := runSql(`SHOW FULL PROCESSLIST`, nil)
+		assert.Equal(t, nil, err)
+		for _, msg := range msgs {
+			vals := msg.Body().(*datasource.SqlDriverMessageMap).Vals
+			if vals[7] == info {
+				assert.Equal(t, "executing", vals[6])
+				return uint64(vals[0].(int64))
+			}
+		}
+		return 0
+	}
+
+	started, errCh := runBlocked(`SELECT email FROM users`, nil)
+	<-started
+	id := processId(`SELECT email FROM users`)
+	assert.NotEqual(t, uint64(0), id)
+
+	_, _, err := runSql(fmt.Sprintf("KILL QUERY %d", id), nil)
+	assert.Equal(t, nil, err)
+	select {
+	case err = <-errCh:
+		assert.Equal(t, exec.ErrQueryKilled, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("killed query did not stop")
+	}
+	assert.Equal(t, uint64(0), processId(`SELECT email FROM users`))
+
+	// it is gone from the process table
+	_, _, err = runSql(fmt.Sprintf("KILL %d", id), nil)
+	assert.NotEqual(t, nil, err)
+
+	_, session, err := runSql(`SET max_execution_time = 50`, nil)
+	assert.Equal(t, nil, err)
+	started, errCh = runBlocked(`SELECT email FROM users`, session)
+	<-started
+	select {
+	case err = <-errCh:
+		assert.Equal(t, exec.ErrQueryTimeout, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("query did not time out")
+	}
+}
+
+func TestExecKillOwner(t *testing.T) {
+
+	reg := schema.DefaultRegistry()
+	assert.Equal(t, nil, reg.RoleAdd(schema.NewRole("kill_admin")))
+	assert.Equal(t, nil, reg.GrantAdd(schema.NewGrant("kill_admin", lex.TokenAll, "*", "*")))
+	assert.Equal(t, nil, reg.RoleAdd(schema.NewRole("kill_reader")))
+	assert.Equal(t, nil, reg.GrantAdd(schema.NewGrant("kill_reader", lex.TokenSelect, "*", "*")))
+	for user, role := range map[string]string{"kill_root": "kill_admin", "kill_alice": "kill_reader", "kill_bob": "kill_reader"} {
+		assert.Equal(t, nil, reg.UserAdd(&schema.User{Name: user, Roles: []string{role}}))
+		defer reg.SchemaDrop("", user, lex.TokenUser)
+	}
+	defer reg.SchemaDrop("", "kill_admin", lex.TokenRole)
+	defer reg.SchemaDrop("", "kill_reader", lex.TokenRole)
+
+	runSql := func(sqlText, user string) ([][]driver.Value, error) {
+		ctx := td.TestContext(sqlText)
+		ctx.User = user
+		job, err := exec.BuildSqlJob(ctx)
+		if err != nil {
+			return nil, err
+		}
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		err = job.Run()
+		rows := make([][]driver.Value, 0, len(msgs))
+		for _, msg := range msgs {
+			rows = append(rows, msg.Body().(*datasource.SqlDriverMessageMap).Vals)
+		}
+		return rows, err
+	}
+	processes := func(user string) map[string]uint64 {
+		rows, err := runSql(`SHOW FULL PROCESSLIST`, user)
+		assert.Equal(t, nil, err)
+		procs := make(map[string]uint64)
+		for _, row := range rows {
+			procs[row[1].(string)] = uint64(row[0].(int64))
+		}
+		return procs
+	}
+
+	// once there are accounts a statement must have a user
+	_, err := runSql(`SELECT email FROM users`, "")
+	assert.Equal(t, plan.ErrNoUser, err)
+
+	ctx := td.TestContext(`SELECT email FROM users`)
+	ctx.User = "kill_alice"
+	job, err := exec.BuildSqlJob(ctx)
+	assert.Equal(t, nil, err)
+	started := make(chan bool)
+	var once sync.Once
+	sink := exec.NewTaskBase(ctx)
+	sink.Handler = func(ctx *plan.Context, msg schema.Message) bool {
+		once.Do(func() { close(started) })
+		<-sink.SigChan()
+		return false
+	}
+	job.RootTask.Add(sink)
+	assert.Equal(t, nil, job.Setup())
+	errCh := make(chan error, 1)
+	go func() { errCh <- job.Run() }()
+	<-started
+
+	// others only see and kill their own queries
+	id, ok := processes("kill_alice")["kill_alice"]
+	assert.True(t, ok)
+	_, ok = processes("kill_bob")["kill_alice"]
+	assert.False(t, ok)
+	_, err = runSql(fmt.Sprintf("KILL %d", id), "kill_bob")
+	assert.NotEqual(t, nil, err)
+
+	assert.Equal(t, id, processes("kill_root")["kill_alice"])
+	_, err = runSql(fmt.Sprintf("KILL %d", id), "kill_root")
+	assert.Equal(t, nil, err)
+	select {
+	case err = <-errCh:
+		assert.Equal(t, exec.ErrQueryKilled, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("killed query did not stop")
+	}
+}
+
 func TestExecSelectWhere(t *testing.T) {
 	sqlText := `
 		select 
@@ -192,6 +408,44 @@ func TestExecGroupBy(t *testing.T) {
 	assert.True(t, int(row[0].(float64)) == 14, "expected avg(len(email))=14 but got %v", int(row[0].(float64)))
 }
 
+func TestExecGroupByDecimal(t *testing.T) {
+
+	sqlText := `
+		select 
+	        user_id, sum(cast(price AS decimal)), avg(cast(price AS decimal))
+	    FROM orders
+	    GROUP BY user_id
+	`
+	ctx := td.TestContext(sqlText)
+	job, err := exec.BuildSqlJob(ctx)
+	assert.Equal(t, nil, err)
+
+	msgs := make([]schema.Message, 0)
+	resultWriter := exec.NewResultBuffer(ctx, &msgs)
+	job.RootTask.Add(resultWriter)
+
+	assert.Equal(t, nil, job.Setup())
+	assert.Equal(t, nil, job.Run())
+	assert.Equal(t, 2, len(msgs))
+	for _, msg := range msgs {
+		row := msg.(*datasource.SqlDriverMessageMap).Values()
+		if row[0].(string) == "9Ip1aKbeZe2njCDM" {
+			// decimals are summed exactly, returned as strings like sql drivers
+			assert.Equal(t, []driver.Value{"9Ip1aKbeZe2njCDM", "60.00", "30.000000"}, row)
+		}
+	}
+
+	// ints above 2^53 summed with decimals stay exact
+	sum := exec.NewSum(nil, false)
+	sum.Do(value.NewIntValue(1 << 53))
+	sum.Do(value.NewIntValue(1))
+	sum.Do(value.NewDecimalNil())
+	sum.Do(value.NewIntValue(1))
+	dv, _ := value.ParseDecimal("0.5")
+	sum.Do(dv)
+	assert.Equal(t, "9007199254740994.5", sum.Result())
+}
+
 func TestExecHaving(t *testing.T) {
 	sqlText := `
 		select 
@@ -511,3 +765,753 @@ func testSubselect(t *testing.T) {
 	assert.True(t, err == nil, "no error %v", err)
 	assert.True(t, len(msgs) == 1, "should have filtered out 2 messages")
 }
+
+func TestCreateFunction(t *testing.T) {
+	run := func(sql string) error {
+		ctx := td.TestContext(sql)
+		job, err := exec.BuildSqlJob(ctx)
+		if err != nil {
+			return err
+		}
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		if err = job.Setup(); err != nil {
+			return err
+		}
+		return job.Run()
+	}
+	assert.Equal(t, nil, run(`CREATE FUNCTION is_aaron(addr) AS addr LIKE "aaron%"`))
+	assert.NotEqual(t, nil, run(`CREATE FUNCTION is_aaron(addr) AS addr = "aaron"`), "exists without OR REPLACE")
+	fn, ok := td.MockSchema.Function("is_aaron")
+	assert.True(t, ok)
+	assert.Equal(t, value.BoolType, fn.Type())
+	assert.Equal(t, nil, run(`DROP FUNCTION is_aaron`))
+	assert.NotEqual(t, nil, run(`DROP FUNCTION is_aaron`))
+	_, ok = td.MockSchema.Function("is_aaron")
+	assert.False(t, ok)
+
+	// external functions are loaded on create, there is no loader for python
+	assert.NotEqual(t, nil, run(`CREATE FUNCTION clean_sku(string) RETURNS string LANGUAGE python FROM 'sku.py'`))
+	_, ok = td.MockSchema.Function("clean_sku")
+	assert.False(t, ok)
+
+	// functions called from another function are looked up when it runs
+	query := func(sql string) []driver.Value {
+		ctx := td.TestContext(sql)
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err, sql)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		assert.Equal(t, nil, job.Run())
+		vals := make([]driver.Value, 0, len(msgs))
+		for _, msg := range msgs {
+			if sdm, ok := msg.(*datasource.SqlDriverMessageMap); ok {
+				vals = append(vals, sdm.Values()[0])
+			}
+		}
+		return vals
+	}
+	assert.Equal(t, nil, run(`CREATE FUNCTION tag_of(addr) AS "a"`))
+	assert.Equal(t, nil, run(`CREATE FUNCTION label_of(addr) AS join(tag_of(addr), addr, ":")`))
+	sel := `SELECT label_of(email) FROM users WHERE label_of(email) LIKE "b:%"`
+	assert.Equal(t, []driver.Value{}, query(sel))
+	assert.Equal(t, nil, run(`CREATE OR REPLACE FUNCTION tag_of(addr) AS "b"`))
+	assert.Equal(t, []driver.Value{"b:aaron@email.com"}, query(sel+` AND email = "aaron@email.com"`))
+	assert.Equal(t, nil, run(`DROP FUNCTION tag_of`))
+	assert.Equal(t, []driver.Value{}, query(sel))
+	assert.Equal(t, nil, run(`DROP FUNCTION label_of`))
+}
+
+func TestExecPartitioned(t *testing.T) {
+
+	// 3 users with 100 orders each, id's hash across partitions
+	rows := make([][]driver.Value, 0, 300)
+	for i := 0; i < 300; i++ {
+		rows = append(rows, []driver.Value{int64(i), fmt.Sprintf("user%d", i%3), int64(i % 10)})
+	}
+	db, err := memdb.NewMemDbData("part_orders", rows, []string{"order_id", "user_id", "price"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, schema.RegisterSourceAsSchema("partdb", db))
+	s, ok := schema.DefaultRegistry().Schema("partdb")
+	assert.True(t, ok)
+	s.Conf = &schema.ConfigSource{Name: "partdb", PartitionCt: 4}
+	assert.Equal(t, nil, db.Setup(s))
+
+	var hasPartitions func(tasks []exec.Task) bool
+	hasPartitions = func(tasks []exec.Task) bool {
+		for _, task := range tasks {
+			if _, ok := task.(*exec.PartitionMerge); ok {
+				return true
+			}
+			if hasPartitions(task.Children()) {
+				return true
+			}
+		}
+		return false
+	}
+	run := func(sql string) [][]driver.Value {
+		ctx := plan.NewContext(sql)
+		ctx.DisableRecover = true
+		ctx.Schema = s
+		ctx.Session = datasource.NewMySqlSessionVars()
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err)
+		assert.True(t, hasPartitions(job.RootTask.Children()), "expected partitioned scan %s", sql)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		assert.Equal(t, nil, job.Run())
+		vals := make([][]driver.Value, 0, len(msgs))
+		for _, msg := range msgs {
+			// a nil message is the shutdown signal after a limit
+			if msg != nil {
+				vals = append(vals, msg.(*datasource.SqlDriverMessageMap).Values())
+			}
+		}
+		return vals
+	}
+
+	vals := run(`SELECT count(*) FROM part_orders`)
+	assert.Equal(t, [][]driver.Value{{int64(300)}}, vals)
+
+	vals = run(`SELECT user_id, count(*), sum(price), avg(price) FROM part_orders
+		WHERE price > 4 GROUP BY user_id ORDER BY user_id ASC`)
+	assert.Equal(t, [][]driver.Value{
+		{"user0", int64(50), float64(350), float64(7)},
+		{"user1", int64(50), float64(350), float64(7)},
+		{"user2", int64(50), float64(350), float64(7)},
+	}, vals)
+
+	// group by values that are not strings
+	vals = run(`SELECT price, count(*) FROM part_orders WHERE price < 2 GROUP BY price ORDER BY price ASC`)
+	assert.Equal(t, [][]driver.Value{{int64(0), int64(30)}, {int64(1), int64(30)}}, vals)
+
+	vals = run(`SELECT order_id FROM part_orders WHERE order_id < 5 ORDER BY order_id ASC`)
+	assert.Equal(t, [][]driver.Value{{int64(0)}, {int64(1)}, {int64(2)}, {int64(3)}, {int64(4)}}, vals)
+
+	vals = run(`SELECT order_id FROM part_orders`)
+	assert.Equal(t, 300, len(vals))
+
+	vals = run(`SELECT order_id FROM part_orders LIMIT 10`)
+	assert.Equal(t, 10, len(vals))
+}
+
+func TestExecMemoryLimit(t *testing.T) {
+
+	rows := make([][]driver.Value, 0, 200)
+	for i := 0; i < 200; i++ {
+		rows = append(rows, []driver.Value{int64(i), fmt.Sprintf("name%03d", i), int64(i % 5)})
+	}
+	db, err := memdb.NewMemDbData("mem_rows", rows, []string{"id", "name", "grp"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, schema.RegisterSourceAsSchema("memlimitdb", db))
+	s, ok := schema.DefaultRegistry().Schema("memlimitdb")
+	assert.True(t, ok)
+
+	run := func(sql string, session expr.ContextReadWriter) ([][]driver.Value, error) {
+		ctx := plan.NewContext(sql)
+		ctx.DisableRecover = true
+		ctx.Schema = s
+		ctx.Session = session
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		err = job.Run()
+		vals := make([][]driver.Value, 0, len(msgs))
+		for _, msg := range msgs {
+			if msg != nil {
+				vals = append(vals, msg.(*datasource.SqlDriverMessageMap).Values())
+			}
+		}
+		return vals, err
+	}
+
+	orderBy := `SELECT name FROM mem_rows ORDER BY name ASC`
+	expected, err := run(orderBy, datasource.NewMySqlSessionVars())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 200, len(expected))
+	assert.Equal(t, []driver.Value{"name000"}, expected[0])
+
+	// past the spill limit order by sorts on disk with the same results
+	session := datasource.NewMySqlSessionVars()
+	_, err = run(`SET query_memory_spill = 1000`, session)
+	assert.Equal(t, nil, err)
+	vals, err := run(orderBy, session)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, expected, vals)
+
+	// a spilled order by whose rows aren't read still stops when canceled
+	_, err = run(`SET max_execution_time = 50`, session)
+	assert.Equal(t, nil, err)
+	ctx := plan.NewContext(orderBy)
+	ctx.DisableRecover = true
+	ctx.Schema = s
+	ctx.Session = session
+	job, err := exec.BuildSqlJob(ctx)
+	assert.Equal(t, nil, err)
+	sink := exec.NewTaskBase(ctx)
+	sink.Handler = func(ctx *plan.Context, msg schema.Message) bool {
+		<-sink.SigChan()
+		return false
+	}
+	job.RootTask.Add(sink)
+	assert.Equal(t, nil, job.Setup())
+	errCh := make(chan error, 1)
+	go func() { errCh <- job.Run() }()
+	select {
+	case err = <-errCh:
+		assert.Equal(t, exec.ErrQueryTimeout, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("spilled order by did not stop")
+	}
+
+	// past the hard limit the query fails
+	session = datasource.NewMySqlSessionVars()
+	_, err = run(`SET query_memory_limit = 1000`, session)
+	assert.Equal(t, nil, err)
+	for _, sql := range []string{
+		`SELECT name FROM mem_rows ORDER BY name ASC`,
+		`SELECT name, count(*) FROM mem_rows GROUP BY name`,
+	} {
+		_, err = run(sql, session)
+		assert.Equal(t, plan.ErrMemoryLimit, err, sql)
+	}
+	vals, err = run(`SELECT grp, count(*) FROM mem_rows WHERE id < 10 GROUP BY grp`, session)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 5, len(vals))
+
+	_, err = run(`SET query_memory_limit = -1`, session)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestExecPlanCache(t *testing.T) {
+
+	rows := make([][]driver.Value, 0, 100)
+	for i := 0; i < 100; i++ {
+		rows = append(rows, []driver.Value{int64(i), fmt.Sprintf("name%d", i), fmt.Sprintf("g%d", i%3)})
+	}
+	db, err := memdb.NewMemDbData("cache_rows", rows, []string{"id", "name", "grp"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, schema.RegisterSourceAsSchema("plancachedb", db))
+	s, ok := schema.DefaultRegistry().Schema("plancachedb")
+	assert.True(t, ok)
+
+	cache := plan.NewPlanCache(10)
+	exec.PlanCache = cache
+	defer func() { exec.PlanCache = nil }()
+
+	run := func(sql string) [][]driver.Value {
+		ctx := plan.NewContext(sql)
+		ctx.DisableRecover = true
+		ctx.Schema = s
+		ctx.Session = datasource.NewMySqlSessionVars()
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err, sql)
+		assert.NotEqual(t, nil, ctx.Projection, sql)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		assert.Equal(t, nil, job.Run())
+		vals := make([][]driver.Value, 0, len(msgs))
+		for _, msg := range msgs {
+			if msg != nil {
+				vals = append(vals, msg.(*datasource.SqlDriverMessageMap).Values())
+			}
+		}
+		return vals
+	}
+
+	vals := run(`SELECT id, name FROM cache_rows WHERE id < 6 AND grp = "g0" ORDER BY id ASC`)
+	assert.Equal(t, [][]driver.Value{{int64(0), "name0"}, {int64(3), "name3"}}, vals)
+	assert.Equal(t, int64(0), cache.Hits())
+	assert.Equal(t, 1, cache.Len())
+
+	// same statement with other literals binds them into the cached plan
+	vals = run(`SELECT id, name FROM cache_rows WHERE id < 10 AND grp = "g1" ORDER BY id ASC`)
+	assert.Equal(t, [][]driver.Value{{int64(1), "name1"}, {int64(4), "name4"}, {int64(7), "name7"}}, vals)
+	assert.Equal(t, int64(1), cache.Hits())
+
+	vals = run(`SELECT id, "a" AS tag FROM cache_rows WHERE id = 5`)
+	assert.Equal(t, [][]driver.Value{{int64(5), "a"}}, vals)
+	vals = run(`SELECT id, "b" AS tag FROM cache_rows WHERE id = 8`)
+	assert.Equal(t, [][]driver.Value{{int64(8), "b"}}, vals)
+	assert.Equal(t, int64(2), cache.Hits())
+
+	vals = run(`SELECT count(*) FROM cache_rows WHERE id >= 90`)
+	assert.Equal(t, [][]driver.Value{{int64(10)}}, vals)
+	vals = run(`SELECT count(*) FROM cache_rows WHERE id >= 50.5`)
+	assert.Equal(t, [][]driver.Value{{int64(49)}}, vals)
+	vals = run(`SELECT count(*) FROM cache_rows WHERE id >= 50`)
+	assert.Equal(t, [][]driver.Value{{int64(50)}}, vals)
+	assert.Equal(t, int64(3), cache.Hits())
+
+	// limits are part of the fingerprint
+	vals = run(`SELECT id FROM cache_rows WHERE id > 10 ORDER BY id ASC LIMIT 2`)
+	assert.Equal(t, [][]driver.Value{{int64(11)}, {int64(12)}}, vals)
+	vals = run(`SELECT id FROM cache_rows WHERE id > 20 ORDER BY id ASC LIMIT 3`)
+	assert.Equal(t, [][]driver.Value{{int64(21)}, {int64(22)}, {int64(23)}}, vals)
+	assert.Equal(t, int64(3), cache.Hits())
+
+	// refreshing the schema invalidates the plans made against it
+	assert.Equal(t, nil, schema.DefaultRegistry().SchemaRefresh("plancachedb"))
+	vals = run(`SELECT id, name FROM cache_rows WHERE id < 3 AND grp = "g2" ORDER BY id ASC`)
+	assert.Equal(t, [][]driver.Value{{int64(2), "name2"}}, vals)
+	assert.Equal(t, int64(3), cache.Hits())
+	vals = run(`SELECT id, name FROM cache_rows WHERE id < 6 AND grp = "g2" ORDER BY id ASC`)
+	assert.Equal(t, [][]driver.Value{{int64(2), "name2"}, {int64(5), "name5"}}, vals)
+	assert.Equal(t, int64(4), cache.Hits())
+}
+
+func TestExecResultCache(t *testing.T) {
+
+	rows := make([][]driver.Value, 0, 20)
+	for i := 0; i < 20; i++ {
+		rows = append(rows, []driver.Value{int64(i), fmt.Sprintf("name%d", i), fmt.Sprintf("g%d", i%3)})
+	}
+	db, err := memdb.NewMemDbData("result_rows", rows, []string{"id", "name", "grp"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, schema.RegisterSourceAsSchema("resultcachedb", db))
+	s, ok := schema.DefaultRegistry().Schema("resultcachedb")
+	assert.True(t, ok)
+
+	cache := exec.NewResultCache(10, 0, 0)
+	exec.Results = cache
+	defer func() { exec.Results = nil }()
+
+	run := func(sql string) [][]driver.Value {
+		ctx := plan.NewContext(sql)
+		ctx.DisableRecover = true
+		ctx.Schema = s
+		ctx.Session = datasource.NewMySqlSessionVars()
+		job, err := exec.BuildSqlJob(ctx)
+		assert.Equal(t, nil, err, sql)
+		assert.NotEqual(t, nil, ctx.Stmt, sql)
+		assert.NotEqual(t, nil, ctx.Projection, sql)
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		assert.Equal(t, nil, job.Setup())
+		assert.Equal(t, nil, job.Run())
+		vals := make([][]driver.Value, 0, len(msgs))
+		for _, msg := range msgs {
+			if msg != nil {
+				vals = append(vals, msg.(*datasource.SqlDriverMessageMap).Values())
+			}
+		}
+		return vals
+	}
+
+	sql := `SELECT id, name FROM result_rows WHERE grp = "g0" AND id < 7 ORDER BY id ASC`
+	want := [][]driver.Value{{int64(0), "name0"}, {int64(3), "name3"}, {int64(6), "name6"}}
+	assert.Equal(t, want, run(sql))
+	assert.Equal(t, int64(0), cache.Hits())
+	assert.Equal(t, 1, cache.Len())
+	assert.Equal(t, want, run(sql))
+	assert.Equal(t, int64(1), cache.Hits())
+
+	// other params are another entry
+	vals := run(`SELECT id, name FROM result_rows WHERE grp = "g1" AND id < 7 ORDER BY id ASC`)
+	assert.Equal(t, [][]driver.Value{{int64(1), "name1"}, {int64(4), "name4"}}, vals)
+	assert.Equal(t, int64(1), cache.Hits())
+	assert.Equal(t, 2, cache.Len())
+
+	// a write changes the version of the table
+	conn, err := db.Open("result_rows")
+	assert.Equal(t, nil, err)
+	_, err = conn.(schema.ConnUpsert).Put(nil, nil, []driver.Value{int64(5), "five", "g0"})
+	assert.Equal(t, nil, err)
+	want = [][]driver.Value{{int64(0), "name0"}, {int64(3), "name3"}, {int64(5), "five"}, {int64(6), "name6"}}
+	assert.Equal(t, want, run(sql))
+	assert.Equal(t, int64(1), cache.Hits())
+	// readers of cached rows each get their own copy
+	vals = run(sql)
+	assert.Equal(t, want, vals)
+	assert.Equal(t, int64(2), cache.Hits())
+	vals[0][1] = "changed"
+	assert.Equal(t, want, run(sql))
+	assert.Equal(t, int64(3), cache.Hits())
+
+	// statements without tables aren't cached
+	run(`SELECT 1 + 1 AS two`)
+	assert.Equal(t, 2, cache.Len())
+
+	// nor shared by sessions with other variables, ie time_zone
+	ctx := plan.NewContext(sql)
+	ctx.Schema = s
+	ctx.Session = datasource.NewMySqlSessionVars()
+	ctx.Session.Put(&rel.CommandColumn{Name: "@@session.time_zone"}, nil, value.NewStringValue("America/Denver"))
+	job, err := exec.BuildSqlJob(ctx)
+	assert.Equal(t, nil, err)
+	msgs := make([]schema.Message, 0)
+	job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+	assert.Equal(t, nil, job.Setup())
+	assert.Equal(t, nil, job.Run())
+	assert.Equal(t, 4, len(msgs))
+	assert.Equal(t, int64(3), cache.Hits())
+	assert.Equal(t, 3, cache.Len())
+
+	cache.Purge()
+	assert.Equal(t, 0, cache.Len())
+	assert.Equal(t, int64(0), cache.Bytes())
+
+	// the rows are bounded by bytes
+	cache = exec.NewResultCache(10, 600, 0)
+	exec.Results = cache
+	run(sql)
+	assert.Equal(t, 1, cache.Len())
+	held := cache.Bytes()
+	assert.True(t, held > 0 && held <= 600, "held %d", held)
+	run(`SELECT id, name FROM result_rows WHERE grp = "g1" AND id < 7 ORDER BY id ASC`)
+	assert.True(t, cache.Bytes() <= 600, "held %d", cache.Bytes())
+	// too large to cache at all
+	run(`SELECT id, name FROM result_rows`)
+	assert.True(t, cache.Bytes() <= 600, "held %d", cache.Bytes())
+	run(`SELECT id, name FROM result_rows`)
+	assert.Equal(t, int64(0), cache.Hits())
+
+	// or are cached for at most the ttl
+	cache = exec.NewResultCache(10, 0, time.Millisecond*20)
+	exec.Results = cache
+	assert.Equal(t, want, run(sql))
+	assert.Equal(t, want, run(sql))
+	assert.Equal(t, int64(1), cache.Hits())
+	time.Sleep(time.Millisecond * 30)
+	assert.Equal(t, want, run(sql))
+	assert.Equal(t, int64(1), cache.Hits())
+}
+
+func TestExecPolicies(t *testing.T) {
+
+	rows := [][]driver.Value{
+		{int64(1), int64(7), "click", "a@x.com"},
+		{int64(2), int64(7), "view", "b@x.com"},
+		{int64(3), int64(8), "click", "c@y.com"},
+		{int64(4), int64(8), "view", "d@y.com"},
+	}
+	db, err := memdb.NewMemDbData("tenant_events", rows, []string{"id", "tenant_id", "action", "email"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, schema.RegisterSourceAsSchema("policydb", db))
+	s, ok := schema.DefaultRegistry().Schema("policydb")
+	assert.True(t, ok)
+
+	// the application binds the tenant of the connection, not SET
+	type conn struct {
+		session  expr.ContextReadWriter
+		identity expr.ContextReader
+	}
+	tenant := func(id interface{}) *conn {
+		c := &conn{session: datasource.NewMySqlSessionVars()}
+		if id != nil {
+			c.identity = datasource.NewContextSimpleNative(map[string]interface{}{"tenant_id": id})
+		}
+		return c
+	}
+	run := func(sql string, c *conn) ([][]driver.Value, error) {
+		ctx := plan.NewContext(sql)
+		ctx.DisableRecover = true
+		ctx.Schema = s
+		ctx.Session = c.session
+		ctx.Identity = c.identity
+		job, err := exec.BuildSqlJob(ctx)
+		if err != nil {
+			return nil, err
+		}
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		if err = job.Setup(); err != nil {
+			return nil, err
+		}
+		if err = job.Run(); err != nil {
+			return nil, err
+		}
+		vals := make([][]driver.Value, 0, len(msgs))
+		for _, msg := range msgs {
+			switch mt := msg.(type) {
+			case *datasource.SqlDriverMessageMap:
+				vals = append(vals, mt.Values())
+			case *datasource.SqlDriverMessage:
+				vals = append(vals, mt.Vals)
+			}
+		}
+		return vals, nil
+	}
+	ids := func(sql string, c *conn) []int64 {
+		vals, err := run(sql, c)
+		assert.Equal(t, nil, err, sql)
+		found := make([]int64, 0, len(vals))
+		for _, row := range vals {
+			found = append(found, row[0].(int64))
+		}
+		return found
+	}
+
+	_, err = run(`CREATE POLICY tenant_only ON tenant_events USING tenant_id = session.tenant_id`, tenant(nil))
+	assert.Equal(t, nil, err)
+	_, err = run(`CREATE POLICY tenant_only ON tenant_events USING tenant_id = 1`, tenant(nil))
+	assert.NotEqual(t, nil, err, "exists without OR REPLACE")
+	_, err = run(`CREATE POLICY other ON not_a_table USING tenant_id = 1`, tenant(nil))
+	assert.NotEqual(t, nil, err)
+
+	sql := `SELECT id FROM tenant_events ORDER BY id ASC`
+	assert.Equal(t, []int64{1, 2}, ids(sql, tenant(7)))
+	assert.Equal(t, []int64{3, 4}, ids(sql, tenant(8)))
+	assert.Equal(t, []int64{}, ids(sql, tenant(nil)), "no tenant sees nothing")
+	assert.Equal(t, []int64{}, ids(sql, tenant(`7" OR "1" = "1`)))
+
+	// hand written sql can't escape the filter
+	assert.Equal(t, []int64{1}, ids(`SELECT id FROM tenant_events WHERE action = "click" OR tenant_id = 8 ORDER BY id ASC`, tenant(7)))
+	assert.Equal(t, []int64{}, ids(`SELECT id FROM tenant_events WHERE tenant_id = 8`, tenant(7)))
+	vals, err := run(`SELECT count(*) AS ct FROM tenant_events`, tenant(8))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]driver.Value{{int64(2)}}, vals)
+
+	// nor can SET change the tenant, session variables are not the identity
+	session := tenant(7)
+	_, err = run(`SET tenant_id = 8`, session)
+	assert.Equal(t, nil, err)
+	_, err = run(`SET @@session.tenant_id = 8`, session)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int64{1, 2}, ids(sql, session))
+	session = tenant(nil)
+	_, err = run(`SET tenant_id = 8`, session)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int64{}, ids(sql, session))
+
+	// rows copied by INSERT ... SELECT are filtered too
+	ctx := plan.NewContext(`INSERT INTO tenant_events (id, tenant_id, action, email)
+		SELECT id, tenant_id, action, email FROM tenant_events WHERE action = "click"`)
+	ctx.Schema = s
+	ctx.Session = datasource.NewMySqlSessionVars()
+	ctx.Identity = tenant(7).identity
+	_, err = exec.BuildSqlJob(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `(action = "click") AND (tenant_id = 7)`, ctx.Stmt.(*rel.SqlInsert).Select.Where.String())
+
+	// masked columns keep their name
+	_, err = run(`CREATE POLICY hide_email ON tenant_events MASK email USING hash.md5(email)`, tenant(nil))
+	assert.Equal(t, nil, err)
+	vals, err = run(`SELECT id, email, len(email) AS ln FROM tenant_events WHERE id = 1`, tenant(7))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]driver.Value{{int64(1), "743173788aa9166801df2e18f0e7ff24", int64(32)}}, vals)
+	vals, err = run(`SELECT * FROM tenant_events WHERE id = 2`, tenant(7))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]driver.Value{{int64(2), int64(7), "view", "553e03dbb031473e7922e6c4254b0403"}}, vals)
+	_, err = run(`CREATE OR REPLACE POLICY hide_email ON tenant_events MASK email USING NULL`, tenant(nil))
+	assert.Equal(t, nil, err)
+	vals, err = run(`SELECT id, email FROM tenant_events WHERE id = 2`, tenant(7))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]driver.Value{{int64(2), nil}}, vals)
+
+	// changes are scoped to the rows of the tenant too
+	_, err = run(`UPDATE tenant_events SET action = "x" WHERE id = 3`, tenant(7))
+	assert.NotEqual(t, nil, err, "memdb can't patch by where")
+	_, err = run(`DELETE FROM tenant_events WHERE action = "click"`, tenant(8))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int64{1, 2}, ids(sql, tenant(7)))
+	assert.Equal(t, []int64{4}, ids(sql, tenant(8)))
+
+	_, err = run(`DROP POLICY tenant_only`, tenant(nil))
+	assert.Equal(t, nil, err)
+	_, err = run(`DROP POLICY tenant_only`, tenant(nil))
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, []int64{1, 2, 4}, ids(sql, tenant(7)))
+	_, err = run(`SET tenant_id = 8`, session)
+	assert.Equal(t, nil, err)
+}
+
+func TestExecPoliciesJoin(t *testing.T) {
+	run := func(sql string, uid interface{}) ([][]driver.Value, error) {
+		ctx := td.TestContext(sql)
+		if uid != nil {
+			ctx.Identity = datasource.NewContextSimpleNative(map[string]interface{}{"uid": uid})
+		}
+		job, err := exec.BuildSqlJob(ctx)
+		if err != nil {
+			return nil, err
+		}
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		if err = job.Setup(); err != nil {
+			return nil, err
+		}
+		if err = job.Run(); err != nil {
+			return nil, err
+		}
+		vals := make([][]driver.Value, 0, len(msgs))
+		for _, msg := range msgs {
+			vals = append(vals, msg.(*datasource.SqlDriverMessageMap).Values())
+		}
+		return vals, nil
+	}
+	_, err := run(`CREATE POLICY own_orders ON orders USING user_id = session.uid`, nil)
+	assert.Equal(t, nil, err)
+	defer run(`DROP POLICY own_orders`, nil)
+	_, err = run(`CREATE POLICY hide_user_email ON users MASK email USING "hidden"`, nil)
+	assert.Equal(t, nil, err)
+	defer run(`DROP POLICY hide_user_email`, nil)
+
+	sql := `
+		SELECT u.user_id, u.email, o.item_id
+		FROM users AS u
+		INNER JOIN orders AS o ON u.user_id = o.user_id
+		WHERE o.price > 10`
+	vals, err := run(sql, "9Ip1aKbeZe2njCDM")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(vals), "%v", vals)
+	for _, row := range vals {
+		assert.Equal(t, "9Ip1aKbeZe2njCDM", row[0])
+		assert.Equal(t, "hidden", row[1])
+	}
+	vals, err = run(sql, "hT2impsOPUREcVPc")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(vals), "%v", vals)
+
+	vals, err = run(`SELECT order_id FROM orders`, "abcabcabc")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]driver.Value{{"3"}}, vals)
+}
+
+func TestExecMaterializedView(t *testing.T) {
+
+	rows := [][]driver.Value{
+		{int64(1), int64(7), int64(100), int64(10)},
+		{int64(2), int64(7), int64(50), int64(11)},
+		{int64(3), int64(8), int64(10), int64(12)},
+	}
+	db, err := memdb.NewMemDbData("hits", rows, []string{"id", "user_id", "bytes", "ts"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, schema.RegisterSourceAsSchema("viewdb", db))
+	s, ok := schema.DefaultRegistry().Schema("viewdb")
+	assert.True(t, ok)
+
+	run := func(sql string) ([][]driver.Value, error) {
+		ctx := plan.NewContext(sql)
+		ctx.DisableRecover = true
+		ctx.Schema = s
+		ctx.Session = datasource.NewMySqlSessionVars()
+		job, err := exec.BuildSqlJob(ctx)
+		if err != nil {
+			return nil, err
+		}
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		if err = job.Setup(); err != nil {
+			return nil, err
+		}
+		if err = job.Run(); err != nil {
+			return nil, err
+		}
+		vals := make([][]driver.Value, 0, len(msgs))
+		for _, msg := range msgs {
+			if sdm, ok := msg.(*datasource.SqlDriverMessageMap); ok {
+				vals = append(vals, sdm.Values())
+			}
+		}
+		return vals, nil
+	}
+	query := func(sql string) [][]driver.Value {
+		vals, err := run(sql)
+		assert.Equal(t, nil, err, sql)
+		return vals
+	}
+
+	_, err = run(`CREATE MATERIALIZED VIEW hits_by_user AS
+		SELECT user_id, count(*) AS ct, sum(bytes) AS bytes FROM hits GROUP BY user_id
+		WITH append = "ts"`)
+	assert.Equal(t, nil, err)
+	_, err = run(`CREATE MATERIALIZED VIEW hits_by_user AS SELECT user_id FROM hits`)
+	assert.NotEqual(t, nil, err, "exists without OR REPLACE")
+	_, err = run(`CREATE MATERIALIZED VIEW hits AS SELECT user_id FROM hits`)
+	assert.NotEqual(t, nil, err, "table exists")
+	_, err = run(`CREATE MATERIALIZED VIEW top_users AS
+		SELECT user_id, count(*) AS ct FROM hits GROUP BY user_id LIMIT 1 WITH append = "ts"`)
+	assert.NotEqual(t, nil, err, "can't append a limit")
+	_, err = run(`CREATE MATERIALIZED VIEW recent AS SELECT id FROM hits WITH source = "not_a_source"`)
+	assert.NotEqual(t, nil, err)
+	// the memdb of viewdb holds hits, its rows are kept
+	_, err = run(`CREATE MATERIALIZED VIEW recent AS SELECT id FROM hits WITH source = "viewdb"`)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, 3, len(query(`SELECT id FROM hits`)))
+
+	byUser := `SELECT user_id, ct, bytes FROM hits_by_user ORDER BY user_id ASC`
+	assert.Equal(t, [][]driver.Value{
+		{int64(7), int64(2), float64(150)},
+		{int64(8), int64(1), float64(10)},
+	}, query(byUser))
+
+	_, err = run(`CREATE MATERIALIZED VIEW big_hits AS SELECT id, user_id, ts FROM hits WHERE bytes >= 50 WITH append = "ts"`)
+	assert.Equal(t, nil, err)
+	_, err = run(`CREATE MATERIALIZED VIEW user_hits AS SELECT user_id, count(*) AS ct FROM hits GROUP BY user_id`)
+	assert.Equal(t, nil, err)
+
+	// rows appended to the source show up after a refresh
+	_, err = run(`INSERT INTO hits (id, user_id, bytes, ts) VALUES (4, 8, 70, 13), (5, 9, 5, 14)`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(query(byUser)))
+	_, err = run(`REFRESH MATERIALIZED VIEW hits_by_user`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]driver.Value{
+		{int64(7), int64(2), float64(150)},
+		{int64(8), int64(2), float64(80)},
+		{int64(9), int64(1), float64(5)},
+	}, query(byUser))
+
+	// nothing new is a no-op
+	_, err = run(`REFRESH MATERIALIZED VIEW hits_by_user`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(query(byUser)))
+
+	_, err = run(`REFRESH MATERIALIZED VIEW big_hits`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]driver.Value{{int64(1)}, {int64(2)}, {int64(4)}},
+		query(`SELECT id FROM big_hits ORDER BY id ASC`))
+
+	// rows appended at the last value of the append column are read once
+	_, err = run(`INSERT INTO hits (id, user_id, bytes, ts) VALUES (6, 9, 60, 14)`)
+	assert.Equal(t, nil, err)
+	for i := 0; i < 2; i++ {
+		_, err = run(`REFRESH MATERIALIZED VIEW hits_by_user`)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, [][]driver.Value{
+			{int64(7), int64(2), float64(150)},
+			{int64(8), int64(2), float64(80)},
+			{int64(9), int64(2), float64(65)},
+		}, query(byUser))
+		_, err = run(`REFRESH MATERIALIZED VIEW big_hits`)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, [][]driver.Value{{int64(1)}, {int64(2)}, {int64(4)}, {int64(6)}},
+			query(`SELECT id FROM big_hits ORDER BY id ASC`))
+	}
+
+	// without an append column the view is recomputed
+	assert.Equal(t, 2, len(query(`SELECT user_id FROM user_hits`)))
+	_, err = run(`REFRESH MATERIALIZED VIEW user_hits`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(query(`SELECT user_id FROM user_hits`)))
+
+	_, err = run(`REFRESH MATERIALIZED VIEW not_a_view`)
+	assert.NotEqual(t, nil, err)
+
+	_, err = run(`CREATE OR REPLACE MATERIALIZED VIEW hits_by_user AS
+		SELECT user_id, sum(bytes) AS bytes FROM hits WHERE bytes > 20 GROUP BY user_id`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]driver.Value{{int64(7), float64(150)}, {int64(8), float64(70)}, {int64(9), float64(60)}},
+		query(`SELECT user_id, bytes FROM hits_by_user ORDER BY user_id ASC`))
+
+	_, err = run(`DROP MATERIALIZED VIEW hits_by_user`)
+	assert.Equal(t, nil, err)
+	_, err = run(`SELECT user_id FROM hits_by_user`)
+	assert.NotEqual(t, nil, err)
+	_, err = run(`DROP VIEW hits_by_user`)
+	assert.NotEqual(t, nil, err)
+
+	// the rows of a view are shared by all readers, so policies can't apply
+	_, err = run(`CREATE POLICY own_hits ON hits USING user_id = 7`)
+	assert.Equal(t, nil, err)
+	_, err = run(`CREATE MATERIALIZED VIEW own AS SELECT id FROM hits`)
+	assert.NotEqual(t, nil, err)
+	_, err = run(`REFRESH MATERIALIZED VIEW big_hits`)
+	assert.NotEqual(t, nil, err)
+}
@@ -1,13 +1,17 @@
 package exec
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	u "github.com/araddon/gou"
 
 	"github.com/araddon/qlbridge/datasource/membtree"
+	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
 )
 
 var (
@@ -15,7 +19,9 @@ var (
 	_ JobRunner = (*JobExecutor)(nil)
 
 	// Ensure that we implement the plan.Planner interface for our job
-	_ Executor = (*JobExecutor)(nil)
+	_ Executor           = (*JobExecutor)(nil)
+	_ ExecutorPartitions = (*JobExecutor)(nil)
+	_ ExecutorGrant      = (*JobExecutor)(nil)
 	//_ plan.SourcePlanner = (*SourceBuilder)(nil)
 )
 
@@ -42,7 +48,8 @@ func NewExecutor(ctx *plan.Context, planner plan.Planner) *JobExecutor {
 }
 
 // BuildSqlJob given a plan context (query statement, +context) create
-// a JobExecutor and error if we can't.
+// a JobExecutor and error if we can't.  If ctx.Stmt is set it is run
+// instead of parsing ctx.Raw, ie with params bound into it.
 func BuildSqlJob(ctx *plan.Context) (*JobExecutor, error) {
 	job := NewExecutor(ctx, plan.NewPlanner(ctx))
 	task, err := BuildSqlJobPlanned(job.Planner, job.Executor, ctx)
@@ -65,7 +72,78 @@ func BuildSqlJobPlanned(planner plan.Planner, executor Executor, ctx *plan.Conte
 	if ctx.Raw == "" {
 		return nil, fmt.Errorf("no sql provided")
 	}
-	stmt, err := rel.ParseSql(ctx.Raw)
+	// user defined functions of the schema resolve at parse time
+	fr := ctx.Funcs
+	if fr == nil && ctx.Schema != nil {
+		fr = ctx.Schema
+	}
+	observing := ctx.Observing()
+	started := time.Now()
+
+	cache, results := PlanCache, Results
+	if ctx.Stmt != nil {
+		// the caches are keyed by the literals of Raw
+		cache, results = nil, nil
+	} else if pd, ok := planner.(*plan.PlannerDefault); !ok || pd.Planner != planner || ctx.Schema == nil || ctx.Funcs != nil {
+		cache, results = nil, nil
+	} else if len(ctx.Schema.Policies()) > 0 {
+		cache, results = nil, nil
+	}
+	if _, ok := executor.(*JobExecutor); !ok {
+		// the rows are added to the cache when the JobExecutor finishes
+		results = nil
+	}
+	if results != nil {
+		if re := results.get(ctx); re != nil {
+			// the cache lookup by fingerprint stands in for the parse
+			if observing {
+				ctx.Observe(&plan.Event{Type: plan.EventParse, Start: started, Duration: time.Since(started)})
+			}
+			started = time.Now()
+			// the rows may be cached by another user
+			var root *TaskSequential
+			err := plan.Authorize(ctx, re.stmt)
+			if err == nil {
+				ctx.Stmt = re.stmt
+				ctx.Projection = re.proj
+				root = NewTaskSequential(ctx)
+				err = root.Add(newResultCacheScan(ctx, re.rows))
+			}
+			if observing {
+				ctx.Observe(&plan.Event{Type: plan.EventPlan, Start: started, Duration: time.Since(started), Err: err})
+			}
+			if err != nil {
+				return nil, err
+			}
+			return root, nil
+		}
+	}
+	if cache != nil {
+		if p := cache.Get(ctx); p != nil {
+			if observing {
+				ctx.Observe(&plan.Event{Type: plan.EventParse, Start: started, Duration: time.Since(started)})
+			}
+			started = time.Now()
+			// the plan may be cached by another user
+			var execRoot Task
+			err := plan.Authorize(ctx, p.Stmt)
+			if err == nil {
+				execRoot, err = executor.WalkPlan(p)
+			}
+			if observing {
+				ctx.Observe(&plan.Event{Type: plan.EventPlan, Start: started, Duration: time.Since(started), Err: err})
+			}
+			return execRoot, err
+		}
+	}
+
+	stmt, err := ctx.Stmt, error(nil)
+	if stmt == nil {
+		stmt, err = rel.ParseSqlResolver(ctx.Raw, fr)
+		if observing {
+			ctx.Observe(&plan.Event{Type: plan.EventParse, Start: started, Duration: time.Since(started), Err: err})
+		}
+	}
 	if err != nil {
 		u.Debugf("could not parse sql : %v", err)
 		return nil, err
@@ -75,6 +153,20 @@ func BuildSqlJobPlanned(planner plan.Planner, executor Executor, ctx *plan.Conte
 	}
 	ctx.Stmt = stmt
 
+	started = time.Now()
+	execRoot, err := buildPlanned(planner, executor, ctx, stmt, cache, results)
+	if observing {
+		ctx.Observe(&plan.Event{Type: plan.EventPlan, Start: started, Duration: time.Since(started), Err: err})
+	}
+	return execRoot, err
+}
+
+// buildPlanned plan the statement and walk the plan into the exec dag,
+// adding select plans to cache, and recording their rows for results,
+// if not nil.
+func buildPlanned(planner plan.Planner, executor Executor, ctx *plan.Context, stmt rel.SqlStatement,
+	cache *plan.PlanCache, results *ResultCache) (Task, error) {
+
 	pln, err := plan.WalkStmt(ctx, stmt, planner)
 
 	if err != nil {
@@ -84,6 +176,9 @@ func BuildSqlJobPlanned(planner plan.Planner, executor Executor, ctx *plan.Conte
 		u.Warnf("error, no plan task, should not be possible?  %v", err)
 		return nil, fmt.Errorf("No plan root task found? %v", ctx.Raw)
 	}
+	if sel, ok := pln.(*plan.Select); ok && cache != nil {
+		cache.Add(ctx, sel)
+	}
 
 	execRoot, err := executor.WalkPlan(pln)
 
@@ -93,6 +188,13 @@ func BuildSqlJobPlanned(planner plan.Planner, executor Executor, ctx *plan.Conte
 	if execRoot == nil {
 		return nil, fmt.Errorf("No plan root task found? %v", ctx.Raw)
 	}
+	if sel, ok := pln.(*plan.Select); ok && results != nil {
+		if seq, ok := execRoot.(*TaskSequential); ok {
+			if re := results.entry(ctx, sel); re != nil {
+				err = seq.Add(newResultCacheRecorder(ctx, results, re))
+			}
+		}
+	}
 
 	return execRoot, err
 }
@@ -136,6 +238,11 @@ func (m *JobExecutor) WalkPlan(p plan.Task) (Task, error) {
 		return m.Executor.WalkDrop(p)
 	case *plan.Alter:
 		return m.Executor.WalkAlter(p)
+	case *plan.Grant:
+		if eg, ok := m.Executor.(ExecutorGrant); ok {
+			return eg.WalkGrant(p)
+		}
+		return m.WalkGrant(p)
 	}
 	panic(fmt.Sprintf("Not implemented for %T", p))
 }
@@ -225,6 +332,9 @@ func (m *JobExecutor) WalkHaving(p *plan.Having) (Task, error) {
 	return NewHaving(m.Ctx, p), nil
 }
 func (m *JobExecutor) WalkGroupBy(p *plan.GroupBy) (Task, error) {
+	if p.Final {
+		return NewGroupByFinal(m.Ctx, p), nil
+	}
 	return NewGroupBy(m.Ctx, p), nil
 }
 func (m *JobExecutor) WalkOrder(p *plan.Order) (Task, error) {
@@ -265,6 +375,35 @@ func (m *JobExecutor) WalkJoin(p *plan.JoinMerge) (Task, error) {
 func (m *JobExecutor) WalkJoinKey(p *plan.JoinKey) (Task, error) {
 	return NewJoinKey(m.Ctx, p), nil
 }
+func (m *JobExecutor) WalkPartitionMerge(p *plan.PartitionMerge) (Task, error) {
+	execTask := NewTaskParallel(m.Ctx)
+	parts := make([]TaskRunner, 0, len(p.Parts))
+	for _, pp := range p.Parts {
+		t, err := m.WalkPlanAll(pp)
+		if err != nil {
+			return nil, err
+		}
+		// each partition needs its own output channel for the merge to read,
+		// so a lone source scan is still wrapped in a sequence.
+		part, ok := t.(*TaskSequential)
+		if !ok {
+			part = NewTaskSequential(m.Ctx)
+			if err = part.Add(t); err != nil {
+				return nil, err
+			}
+		}
+		if err = execTask.Add(part); err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+
+	pm := NewPartitionMerge(m.Ctx, parts)
+	if err := execTask.Add(pm); err != nil {
+		return nil, err
+	}
+	return execTask, nil
+}
 func (m *JobExecutor) WalkPlanAll(p plan.Task) (Task, error) {
 	root, err := m.WalkPlanTask(p)
 	if err != nil {
@@ -302,6 +441,11 @@ func (m *JobExecutor) WalkPlanTask(p plan.Task) (Task, error) {
 		return m.Executor.WalkJoin(p)
 	case *plan.JoinKey:
 		return m.Executor.WalkJoinKey(p)
+	case *plan.PartitionMerge:
+		if ep, ok := m.Executor.(ExecutorPartitions); ok {
+			return ep.WalkPartitionMerge(p)
+		}
+		return m.WalkPartitionMerge(p)
 	}
 	panic(fmt.Sprintf("Task plan-exec Not implemented for %T", p))
 }
@@ -334,6 +478,12 @@ func (m *JobExecutor) WalkAlter(p *plan.Alter) (Task, error) {
 	return root, root.Add(NewAlter(m.Ctx, p))
 }
 
+// WalkGrant walks the Grant plan.
+func (m *JobExecutor) WalkGrant(p *plan.Grant) (Task, error) {
+	root := m.NewTask(p)
+	return root, root.Add(NewGrant(m.Ctx, p))
+}
+
 // WalkChildren walk dag of plan tasks creating execution tasks
 func (m *JobExecutor) WalkChildren(p plan.Task, root Task) error {
 	for _, t := range p.Children() {
@@ -384,9 +534,77 @@ func (m *JobExecutor) Setup() error {
 	return m.RootTask.Setup(0)
 }
 
-// Run this task
-func (m *JobExecutor) Run() error {
-	return m.RootTask.Run()
+// Run this task.  The job is listed in the process table while running
+// and stops when killed or after the session max_execution_time.  The rows
+// its tasks buffer are charged to the memory account of its context, the
+// Observers of the context are sent its start and stop.
+func (m *JobExecutor) Run() (err error) {
+
+	ms, _ := sessionInt(m.Ctx.Session, "max_execution_time")
+	m.Ctx.WithTimeout(time.Duration(ms) * time.Millisecond)
+	defer m.Ctx.Cancel()
+	if m.Ctx.Memory == nil {
+		m.Ctx.Memory = plan.NewMemoryAccount(MemorySpillLimit, MemoryLimit)
+		if soft, ok := sessionInt(m.Ctx.Session, "query_memory_spill"); ok {
+			m.Ctx.Memory.SoftLimit = soft
+		}
+		if hard, ok := sessionInt(m.Ctx.Session, "query_memory_limit"); ok {
+			m.Ctx.Memory.HardLimit = hard
+		}
+	}
+	proc := plan.Processes.Add(m.Ctx)
+	defer plan.Processes.Remove(proc.Id)
+
+	if m.Ctx.Observing() {
+		started := time.Now()
+		m.Ctx.Observe(&plan.Event{Type: plan.EventJobStart, Start: started})
+		defer func() {
+			m.Ctx.Observe(&plan.Event{Type: plan.EventJobStop, Start: started, Duration: time.Since(started), Err: err})
+		}()
+	}
+
+	// closing the tasks closes their SigChan and source conns
+	finished := make(chan bool)
+	watcherDone := make(chan bool)
+	go func() {
+		defer close(watcherDone)
+		select {
+		case <-m.Ctx.Done():
+			m.RootTask.Close()
+		case <-finished:
+		}
+	}()
+
+	err = m.RootTask.Run()
+	close(finished)
+	<-watcherDone
+
+	switch m.Ctx.Err() {
+	case context.DeadlineExceeded:
+		return ErrQueryTimeout
+	case context.Canceled:
+		return ErrQueryKilled
+	}
+	if err == nil {
+		for _, task := range m.RootTask.Children() {
+			if rec, ok := task.(*resultCacheRecorder); ok {
+				rec.store()
+			}
+		}
+	}
+	return err
+}
+
+// sessionInt the value of an integer session variable set with
+// SET, see intSessionVars.
+func sessionInt(session expr.ContextReader, name string) (int64, bool) {
+	if session == nil {
+		return 0, false
+	}
+	if v, ok := session.Get("@@" + name); ok && v != nil {
+		return value.ValueToInt64(v)
+	}
+	return 0, false
 }
 
 // Close the normal close of root task
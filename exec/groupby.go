@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/gob"
 	"fmt"
+	"math/big"
 	"strings"
 	"time"
 
@@ -89,6 +90,25 @@ func (m *GroupBy) Run() error {
 	//  so obviously not scalable.
 	gb := make(map[string][]*datasource.SqlDriverMessageMap)
 
+	// the buffered rows are charged to the query memory account
+	mem := m.Ctx.Memory
+	var charged int64
+	defer func() { mem.Shrink(charged) }()
+	charge := func(key string, sdm *datasource.SqlDriverMessageMap) error {
+		size := plan.RowSize(sdm.Vals) + int64(len(key))
+		if err := mem.Grow(size); err != nil {
+			return err
+		}
+		charged += size
+		return nil
+	}
+
+	// batches are aggregated as they arrive instead of held in memory
+	gbb, err := newGroupByBatch(m.p)
+	if err != nil {
+		u.Debugf("group by can not aggregate batches, using rows %v", err)
+	}
+
 msgReadLoop:
 	for {
 
@@ -102,6 +122,23 @@ msgReadLoop:
 				var sdm *datasource.SqlDriverMessageMap
 
 				switch mt := msg.(type) {
+				case *datasource.Batch:
+					if gbb != nil {
+						size, err := gbb.add(mt, mem)
+						charged += size
+						if err != nil {
+							return err
+						}
+						continue
+					}
+					for _, bm := range mt.Messages() {
+						key := m.groupKey(bm)
+						if err := charge(key, bm); err != nil {
+							return err
+						}
+						gb[key] = append(gb[key], bm)
+					}
+					continue
 				case *datasource.SqlDriverMessageMap:
 					sdm = mt
 				default:
@@ -117,24 +154,46 @@ msgReadLoop:
 					sdm = datasource.NewSqlDriverMessageMapCtx(msg.Id(), msgReader, colIndex)
 				}
 
-				// We are going to use VM Engine to create a value for each statement in group by
-				// then join each value together to create a unique key.
-				keys := make([]string, len(m.p.Stmt.GroupBy))
-				for i, col := range m.p.Stmt.GroupBy {
-					if key, ok := vm.Eval(sdm, col.Expr); ok {
-						keys[i] = key.ToString()
-					}
+				key := m.groupKey(sdm)
+				if err := charge(key, sdm); err != nil {
+					return err
 				}
-				key := strings.Join(keys, ",")
 				gb[key] = append(gb[key], sdm)
 			}
 		}
 	}
 
 	i := uint64(0)
+	emit := func(key string, aggs []Aggregator) {
+		row := make([]driver.Value, len(columns))
+		for i, agg := range aggs {
+			row[i] = driver.Value(agg.Result())
+			agg.Reset()
+			//u.Debugf("agg result: %#v  %v", row[i], row[i])
+		}
+
+		if m.p.Partial {
+			// Partial results, append key at end?  shouldn't be able to be fit in message itself?
+			row = append(row, key)
+			//u.Debugf("GroupBy output row? key:%s %#v", key, row)
+		}
+		//u.Debugf("row: %v  cols:%v", row, colIndex)
+		outCh <- datasource.NewSqlDriverMessageMap(i, row, colIndex)
+		i++
+	}
+
 	for key, v := range gb {
 		//u.Debugf("got %s:%v msgs", k, len(v))
 
+		// keys also seen in batches continue from the batch aggregates
+		keyAggs := aggs
+		if gbb != nil {
+			if baggs, ok := gbb.aggs[key]; ok {
+				keyAggs = baggs
+				delete(gbb.aggs, key)
+			}
+		}
+
 		for _, mm := range v {
 			for i, col := range columns {
 				//u.Debugf("col: idx:%v sidx: %v pidx:%v key:%v   %s", col.Index, col.SourceIndex, col.ParentIndex, col.Key(), col.Expr)
@@ -147,33 +206,113 @@ msgReadLoop:
 					if !ok || v == nil {
 						//u.Debugf("evaled nil? key=%v  val=%v expr:%s", col.Key(), v, col.Expr.String())
 						//u.Infof("mt: %T  mm %#v", mm, mm)
-						aggs[i].Do(value.NewNilValue())
+						keyAggs[i].Do(value.NewNilValue())
 					} else {
 						//u.Debugf("evaled: key=%v  val=%v", col.Key(), v.Value())
-						aggs[i].Do(v)
+						keyAggs[i].Do(v)
 					}
 				}
 			}
 		}
+		emit(key, keyAggs)
+	}
 
-		row := make([]driver.Value, len(columns))
-		for i, agg := range aggs {
-			row[i] = driver.Value(agg.Result())
-			agg.Reset()
-			//u.Debugf("agg result: %#v  %v", row[i], row[i])
+	if gbb != nil {
+		for key, aggs := range gbb.aggs {
+			emit(key, aggs)
 		}
+	}
 
-		if m.p.Partial {
-			// Partial results, append key at end?  shouldn't be able to be fit in message itself?
-			row = append(row, key)
-			//u.Debugf("GroupBy output row? key:%s %#v", key, row)
+	return nil
+}
+
+// groupKey use VM Engine to create a value for each statement in group by
+// then join each value together to create a unique key.
+func (m *GroupBy) groupKey(sdm *datasource.SqlDriverMessageMap) string {
+	keys := make([]string, len(m.p.Stmt.GroupBy))
+	for i, col := range m.p.Stmt.GroupBy {
+		if key, ok := vm.Eval(sdm, col.Expr); ok {
+			keys[i] = key.ToString()
 		}
-		//u.Debugf("row: %v  cols:%v", row, colIndex)
-		outCh <- datasource.NewSqlDriverMessageMap(i, row, colIndex)
-		i++
 	}
+	return strings.Join(keys, ",")
+}
 
-	return nil
+// BatchIn group by accepts batches.
+func (m *GroupBy) BatchIn() bool { return true }
+
+// groupByBatch aggregates batches of rows, keeping the running aggregates
+// per group key instead of the rows.
+type groupByBatch struct {
+	p    *plan.GroupBy
+	keys []vm.Program
+	cols []vm.Program
+	aggs map[string][]Aggregator
+}
+
+func newGroupByBatch(p *plan.GroupBy) (*groupByBatch, error) {
+	m := &groupByBatch{
+		p:    p,
+		keys: make([]vm.Program, len(p.Stmt.GroupBy)),
+		cols: make([]vm.Program, len(p.Stmt.Columns)),
+		aggs: make(map[string][]Aggregator),
+	}
+	var err error
+	for i, col := range p.Stmt.GroupBy {
+		if m.keys[i], err = vm.Compile(col.Expr); err != nil {
+			return nil, err
+		}
+	}
+	for i, col := range p.Stmt.Columns {
+		if col.Expr == nil {
+			continue
+		}
+		if m.cols[i], err = vm.Compile(col.Expr); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// add aggregates the rows of the batch, the memory of new group keys
+// is charged to the query memory account and returned.
+func (m *groupByBatch) add(b *datasource.Batch, mem *plan.MemoryAccount) (int64, error) {
+	var charged int64
+	row := datasource.NewBatchRow(b)
+	keys := make([]string, len(m.keys))
+	for _, pos := range b.Selected() {
+		row.Pos = pos
+		for i, prog := range m.keys {
+			keys[i] = ""
+			if key, ok := prog.Eval(row); ok {
+				keys[i] = key.ToString()
+			}
+		}
+		key := strings.Join(keys, ",")
+		aggs, ok := m.aggs[key]
+		if !ok {
+			size := int64(len(key) + 64*len(m.cols))
+			if err := mem.Grow(size); err != nil {
+				return charged, err
+			}
+			charged += size
+			// the aggregators were already validated on the row path
+			aggs, _ = buildAggs(m.p)
+			m.aggs[key] = aggs
+		}
+		for i, prog := range m.cols {
+			if prog == nil {
+				continue
+			}
+			v, ok := prog.Eval(row)
+			if !ok || v == nil {
+				aggs[i].Do(value.NewNilValue())
+			} else {
+				aggs[i].Do(v)
+			}
+		}
+	}
+	return charged, nil
 }
 
 // Run group-by-final Runs standard task interface.
@@ -187,14 +326,25 @@ func (m *GroupByFinal) Run() error {
 	columns := m.p.Stmt.Columns
 	colIndex := m.p.Stmt.ColIndexes()
 
-	m.p.Partial = false
-	aggs, err := buildAggs(m.p)
+	defer func() {
+		m.isComplete = true
+		close(m.complete)
+	}()
+
+	// the partials are merged into final results
+	final := *m.p
+	final.Partial = false
+	aggs, err := buildAggs(&final)
 	if err != nil {
 		return err
 	}
 
 	gb := make(map[string][][]driver.Value)
 
+	mem := m.Ctx.Memory
+	var charged int64
+	defer func() { mem.Shrink(charged) }()
+
 msgReadLoop:
 	for {
 
@@ -219,6 +369,11 @@ msgReadLoop:
 					}
 					vals := mt.Vals[0 : len(mt.Vals)-1]
 					//u.Infof("found key:%s for %#v", key, mt.Vals)
+					size := plan.RowSize(vals) + int64(len(key))
+					if err := mem.Grow(size); err != nil {
+						return err
+					}
+					charged += size
 					gb[key] = append(gb[key], vals)
 				default:
 					err := fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
@@ -235,30 +390,7 @@ msgReadLoop:
 		//u.Debugf("got %s:%v msgs", key, vals)
 
 		for _, dv := range vals {
-			for i, col := range columns {
-				//u.Debugf("col: idx:%v sidx: %v pidx:%v key:%v   %s", col.Index, col.SourceIndex, col.ParentIndex, col.Key(), col.Expr)
-				if i-1 >= len(dv) {
-					u.Errorf("what??? %v  dv: %d   %#v", i, len(dv), dv)
-				}
-				if col.Expr == nil {
-					u.Warnf("wat?   nil col expr? %#v", col)
-				} else {
-					v := dv[i]
-					switch vt := v.(type) {
-					case *AggPartial:
-						//u.Debugf("evaled: key=%v  val=%v", col.Key(), v.Value())
-						aggs[i].Merge(vt)
-					case AggPartial:
-						aggs[i].Merge(&vt)
-					case int64:
-						aggs[i].Merge(&AggPartial{Ct: vt})
-					case string:
-						aggs[i] = &groupByFunc{vt}
-					default:
-						u.Warnf("unhandled type: %#v", v)
-					}
-				}
-			}
+			mergePartial(columns, aggs, dv)
 		}
 
 		row := make([]driver.Value, len(columns))
@@ -272,11 +404,40 @@ msgReadLoop:
 		i++
 	}
 
-	m.isComplete = true
-	close(m.complete)
 	return nil
 }
 
+// mergePartial merges the values of a row of a partial group by into the
+// aggregates of its group.
+func mergePartial(columns rel.Columns, aggs []Aggregator, dv []driver.Value) {
+	for i, col := range columns {
+		//u.Debugf("col: idx:%v sidx: %v pidx:%v key:%v   %s", col.Index, col.SourceIndex, col.ParentIndex, col.Key(), col.Expr)
+		if i >= len(dv) {
+			u.Errorf("what??? %v  dv: %d   %#v", i, len(dv), dv)
+			return
+		}
+		if col.Expr == nil {
+			u.Warnf("wat?   nil col expr? %#v", col)
+		} else if gbv, isGroupBy := aggs[i].(*groupByFunc); isGroupBy {
+			// group by values of any type are passed through
+			gbv.last = dv[i]
+		} else {
+			v := dv[i]
+			switch vt := v.(type) {
+			case *AggPartial:
+				//u.Debugf("evaled: key=%v  val=%v", col.Key(), v.Value())
+				aggs[i].Merge(vt)
+			case AggPartial:
+				aggs[i].Merge(&vt)
+			case int64:
+				aggs[i].Merge(&AggPartial{Ct: vt})
+			default:
+				u.Warnf("unhandled type: %#v", v)
+			}
+		}
+	}
+}
+
 // Close the task, channels, cleanup.
 func (m *GroupBy) Close() error {
 	m.Lock()
@@ -319,8 +480,9 @@ func (m *GroupByFinal) Close() error {
 // group-bys calculated across multiple nodes this holds info that
 // needs to be further calculated it only represents this hash.
 type AggPartial struct {
-	Ct int64
-	N  float64
+	Ct  int64
+	N   float64
+	Dec string // exact total if the values are decimals
 }
 
 type AggFunc func(v value.Value)
@@ -347,34 +509,97 @@ func NewGroupByValue(col *rel.Column) Aggregator {
 	return &groupByFunc{}
 }
 
-type sum struct {
-	partial bool
-	ct      int64
-	n       float64
+// total is the running total of sum and avg, float unless decimal values
+// are seen in which case the total is kept exact until a float is seen.
+type total struct {
+	n      float64
+	ints   *big.Int // exact total of the int values
+	dec    value.DecimalValue
+	floats bool
 }
 
-func (m *sum) Do(v value.Value) {
-	m.ct++
+func (m *total) add(v value.Value) {
 	switch vt := v.(type) {
 	case value.IntValue:
 		m.n += vt.Float()
+		if m.ints == nil {
+			m.ints = new(big.Int)
+		}
+		m.ints.Add(m.ints, big.NewInt(vt.Val()))
 	case value.NumberValue:
 		m.n += vt.Val()
+		m.floats = true
+	case value.DecimalValue:
+		if vt.Nil() {
+			return
+		}
+		m.addDecimal(vt)
+		m.n += vt.Float()
+	}
+}
+func (m *total) addDecimal(dv value.DecimalValue) {
+	if m.dec.Nil() {
+		m.dec = dv
+		return
+	}
+	m.dec = m.dec.Add(dv)
+}
+func (m *total) decimal() (value.DecimalValue, bool) {
+	if m.dec.Nil() || m.floats {
+		return m.dec, false
+	}
+	if m.ints == nil {
+		return m.dec, true
+	}
+	return m.dec.Add(value.NewDecimalValue(new(big.Int).Set(m.ints), 0)), true
+}
+func (m *total) aggPartial(ct int64) *AggPartial {
+	p := &AggPartial{Ct: ct, N: m.n}
+	if dv, ok := m.decimal(); ok {
+		p.Dec = dv.ToString()
+	}
+	return p
+}
+func (m *total) merge(a *AggPartial) {
+	if a.Dec != "" {
+		if dv, err := value.ParseDecimal(a.Dec); err == nil {
+			m.addDecimal(dv)
+			m.n += a.N
+			return
+		}
 	}
+	m.add(value.NewNumberValue(a.N))
+}
+func (m *total) reset() {
+	m.n = 0
+	m.ints = nil
+	m.dec = value.NewDecimalNil()
+	m.floats = false
+}
+
+type sum struct {
+	partial bool
+	ct      int64
+	total
+}
+
+func (m *sum) Do(v value.Value) {
+	m.ct++
+	m.add(v)
 }
 func (m *sum) Result() interface{} {
 	if !m.partial {
+		if dv, ok := m.decimal(); ok {
+			return dv.Value()
+		}
 		return m.n
 	}
-	return &AggPartial{
-		m.ct,
-		m.n,
-	}
+	return m.aggPartial(m.ct)
 }
-func (m *sum) Reset() { m.n = 0 }
+func (m *sum) Reset() { m.reset() }
 func (m *sum) Merge(a *AggPartial) {
 	m.ct += a.Ct
-	m.n += a.N
+	m.merge(a)
 }
 func NewSum(col *rel.Column, partial bool) Aggregator {
 	return &sum{partial: partial}
@@ -383,31 +608,27 @@ func NewSum(col *rel.Column, partial bool) Aggregator {
 type avg struct {
 	partial bool
 	ct      int64
-	n       float64
+	total
 }
 
 func (m *avg) Do(v value.Value) {
 	m.ct++
-	switch vt := v.(type) {
-	case value.IntValue:
-		m.n += vt.Float()
-	case value.NumberValue:
-		m.n += vt.Val()
-	}
+	m.add(v)
 }
 func (m *avg) Result() interface{} {
 	if !m.partial {
+		if dv, ok := m.decimal(); ok && m.ct > 0 {
+			q, _ := dv.Quo(value.NewDecimalValueInt(m.ct))
+			return q.Value()
+		}
 		return m.n / float64(m.ct)
 	}
-	return &AggPartial{
-		m.ct,
-		m.n,
-	}
+	return m.aggPartial(m.ct)
 }
-func (m *avg) Reset() { m.n = 0; m.ct = 0 }
+func (m *avg) Reset() { m.reset(); m.ct = 0 }
 func (m *avg) Merge(a *AggPartial) {
 	m.ct += a.Ct
-	m.n += a.N
+	m.merge(a)
 }
 func NewAvg(col *rel.Column, partial bool) Aggregator {
 	return &avg{partial: partial}
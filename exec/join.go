@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	u "github.com/araddon/gou"
 
@@ -154,84 +155,73 @@ func (m *JoinMerge) Run() error {
 	lh := make(map[driver.Value][]*datasource.SqlDriverMessageMap)
 	rh := make(map[driver.Value][]*datasource.SqlDriverMessageMap)
 
-	wg := new(sync.WaitGroup)
-	wg.Add(1)
+	// the buffered rows of both sides are charged to the query memory account
+	mem := m.Ctx.Memory
+	var charged int64
+	defer func() { mem.Shrink(atomic.LoadInt64(&charged)) }()
+
 	var fatalErr error
-	go func() {
+	var failOnce sync.Once
+	fail := func(err error) {
+		failOnce.Do(func() {
+			fatalErr = err
+			close(m.TaskBase.sigCh)
+		})
+	}
+
+	scan := func(in MessageChan, h map[driver.Value][]*datasource.SqlDriverMessageMap) {
 		for {
 			//u.Infof("In source Scanner msg %#v", msg)
 			select {
 			case <-m.SigChan():
 				u.Debugf("got signal quit")
-				wg.Done()
-				wg.Done()
 				return
-			case msg, ok := <-leftIn:
+			case msg, ok := <-in:
 				if !ok {
-					//u.Debugf("NICE, got left shutdown")
-					wg.Done()
+					//u.Debugf("NICE, got shutdown")
 					return
 				} else {
 					switch mt := msg.(type) {
 					case *datasource.SqlDriverMessageMap:
 						key := mt.Key()
 						if key == "" {
-							fatalErr = fmt.Errorf(`To use Join msgs must have keys but got "" for %+v`, mt)
-							u.Errorf("no key? %#v  %v", mt, fatalErr)
-							close(m.TaskBase.sigCh)
+							err := fmt.Errorf(`To use Join msgs must have keys but got "" for %+v`, mt)
+							u.Errorf("no key? %#v  %v", mt, err)
+							fail(err)
 							return
 						}
-						lh[key] = append(lh[key], mt)
-					default:
-						fatalErr = fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
-						u.Errorf("unrecognized msg %T", msg)
-						close(m.TaskBase.sigCh)
-						return
-					}
-				}
-			}
-
-		}
-	}()
-	wg.Add(1)
-	go func() {
-		for {
-
-			//u.Infof("In source Scanner iter %#v", item)
-			select {
-			case <-m.SigChan():
-				u.Debugf("got quit signal join source 1")
-				wg.Done()
-				wg.Done()
-				return
-			case msg, ok := <-rightIn:
-				if !ok {
-					//u.Debugf("NICE, got right shutdown")
-					wg.Done()
-					return
-				} else {
-					switch mt := msg.(type) {
-					case *datasource.SqlDriverMessageMap:
-						key := mt.Key()
-						if key == "" {
-							fatalErr = fmt.Errorf(`To use Join msgs must have keys but got "" for %+v`, mt)
-							u.Errorf("no key? %#v  %v", mt, fatalErr)
-							close(m.TaskBase.sigCh)
+						size := plan.RowSize(mt.Vals) + plan.ValueSize(key)
+						if err := mem.Grow(size); err != nil {
+							fail(err)
 							return
 						}
-						rh[key] = append(rh[key], mt)
+						atomic.AddInt64(&charged, size)
+						h[key] = append(h[key], mt)
 					default:
-						fatalErr = fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg)
+						fail(fmt.Errorf("To use Join must use SqlDriverMessageMap but got %T", msg))
 						u.Errorf("unrecognized msg %T", msg)
-						close(m.TaskBase.sigCh)
 						return
 					}
 				}
 			}
 
 		}
+	}
+
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		scan(leftIn, lh)
+	}()
+	go func() {
+		defer wg.Done()
+		scan(rightIn, rh)
 	}()
 	wg.Wait()
+	if fatalErr != nil {
+		return fatalErr
+	}
 	//u.Info("leaving source scanner")
 	i := uint64(0)
 	for keyLeft, valLeft := range lh {
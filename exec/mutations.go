@@ -168,6 +168,15 @@ func (m *Upsert) updateValues() (int64, error) {
 		return updated, nil
 	}
 
+	// the row filter of a policy is part of the where, a key can't express it
+	if m.Ctx.Schema != nil {
+		for _, p := range m.Ctx.Schema.TablePolicies(m.update.Table) {
+			if !p.IsMask() {
+				return 0, fmt.Errorf("UPDATE of %q with policy %q requires a source that patches by WHERE", m.update.Table, p.Name)
+			}
+		}
+	}
+
 	// TODO:   If it does not implement Where Patch then we need to do a poly fill
 	//      Do we have to recognize if the Where is on a primary key?
 	// - for sources/queries that can't do partial updates we need to do a read first
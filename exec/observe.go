@@ -0,0 +1,120 @@
+package exec
+
+import (
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// lastTaskId the id of the last task sent to observers, they are
+// unique across jobs.
+var lastTaskId uint64
+
+// observedTask tasks that know their own id, so their children
+// can refer to it as their parent.
+type observedTask interface {
+	setObserveId(id uint64)
+}
+
+func (m *TaskBase) setObserveId(id uint64) { m.observeId = id }
+
+// startTask sends the start of a task to the observers of the context
+// and returns its stop event, sent once it is done.
+func startTask(ctx *plan.Context, parentId uint64, task TaskRunner) *plan.Event {
+	e := &plan.Event{
+		Type:     plan.EventTaskStart,
+		Task:     taskName(task),
+		Id:       atomic.AddUint64(&lastTaskId, 1),
+		ParentId: parentId,
+		Start:    time.Now(),
+	}
+	if ot, ok := task.(observedTask); ok {
+		ot.setObserveId(e.Id)
+	}
+	ctx.Observe(e)
+	stop := *e
+	stop.Type = plan.EventTaskStop
+	return &stop
+}
+
+func taskName(task TaskRunner) string {
+	t := reflect.TypeOf(task)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// countedTask tasks holding others (sequence, parallel) that count the
+// rows in and out of their children.  Their stops are sent by the root
+// task once the whole dag is done, as the rows a task sends are only
+// counted once read by a task downstream, possibly outside its parent.
+type countedTask interface {
+	rowsIn() int64
+	rowsOut() int64
+	taskStops() []*plan.Event
+}
+
+// rowCounter relays the messages of a channel between tasks counting
+// the rows the downstream task took.
+type rowCounter struct {
+	in   MessageChan
+	out  MessageChan
+	rows int64
+}
+
+func newRowCounter(in MessageChan) *rowCounter {
+	// unbuffered so only rows actually read are counted
+	return &rowCounter{in: in, out: make(MessageChan)}
+}
+
+func (m *rowCounter) run(ctx *plan.Context, sigCh SigChan) {
+	defer close(m.out)
+	done := ctx.Context.Done()
+	for {
+		var msg schema.Message
+		select {
+		case in, ok := <-m.in:
+			if !ok {
+				return
+			}
+			msg = in
+		case <-sigCh:
+			return
+		case <-done:
+			return
+		}
+		// counted before sending, the receiver may filter a batch
+		n := rowCount(msg)
+		select {
+		case m.out <- msg:
+			atomic.AddInt64(&m.rows, n)
+		case <-sigCh:
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+// count is 0 for a nil counter, ie not observed.
+func (m *rowCounter) count() int64 {
+	if m == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&m.rows)
+}
+
+func rowCount(msg schema.Message) int64 {
+	switch mt := msg.(type) {
+	case nil:
+		return 0
+	case *datasource.Batch:
+		return int64(len(mt.Selected()))
+	}
+	return 1
+}
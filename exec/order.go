@@ -1,7 +1,13 @@
 package exec
 
 import (
+	"bufio"
+	"database/sql/driver"
+	"encoding/gob"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
 	"sort"
 	"time"
 
@@ -51,6 +57,8 @@ func (m *Order) Close() error {
 		u.Warnf("order by timeout???? ")
 	case <-m.complete:
 		//u.Warnf("%p got groupbyfinal complete", m)
+	case <-m.Ctx.Done():
+		// canceled, Run stops sending once the sig chan is closed
 	}
 
 	return m.TaskBase.Close()
@@ -60,6 +68,11 @@ func (m *Order) Run() error {
 	defer m.Ctx.Recover()
 	defer close(m.msgOutCh)
 
+	defer func() {
+		m.isComplete = true
+		close(m.complete)
+	}()
+
 	outCh := m.MessageOut()
 	inCh := m.MessageIn()
 
@@ -67,8 +80,18 @@ func (m *Order) Run() error {
 	orderCt := len(m.p.Stmt.OrderBy)
 
 	// are are going to hold entire row in memory while we are calculating
-	//  so obviously not scalable.
+	//  so obviously not scalable, past the memory spill limit the sorted
+	//  rows are written to disk and merged at the end.
 	sl := NewOrderMessages(m.p)
+	mem := m.Ctx.Memory
+	var buffered int64
+	var runs []*orderRun
+	defer func() {
+		mem.Shrink(buffered)
+		for _, run := range runs {
+			run.close()
+		}
+	}()
 
 msgReadLoop:
 	for {
@@ -118,28 +141,94 @@ msgReadLoop:
 				}
 
 				//u.Infof("found key:%s for %+v", key, sdm)
-				sl.l = append(sl.l, &msgkey{keys, sdm})
+				mk := &msgkey{keys, sdm}
+				size := mk.size()
+				if err := mem.Grow(size); err != nil {
+					return err
+				}
+				buffered += size
+				sl.l = append(sl.l, mk)
+
+				if mem.Spill(buffered) {
+					sort.Sort(sl)
+					run, err := spillOrderRun(sl.l)
+					if err != nil {
+						return err
+					}
+					runs = append(runs, run)
+					sl.l = sl.l[:0]
+					mem.Shrink(buffered)
+					buffered = 0
+				}
 			}
 		}
 	}
 
 	sort.Sort(sl)
 
-	for _, m := range sl.l {
-		//u.Debugf("got %s:%v msgs", key, vals)
-		outCh <- m.msg
+	if len(runs) == 0 {
+		for _, mk := range sl.l {
+			//u.Debugf("got %s:%v msgs", key, vals)
+			select {
+			case outCh <- mk.msg:
+			case <-m.SigChan():
+				return nil
+			}
+		}
+	} else if err := m.merge(append(runs, newOrderRunMem(sl.l))); err != nil {
+		return err
 	}
 
-	m.isComplete = true
-	close(m.complete)
-
 	return nil
 }
 
+// merge the sorted runs, some spilled to disk, sending the rows in order.
+func (m *Order) merge(runs []*orderRun) error {
+	sl := NewOrderMessages(m.p)
+	outCh := m.MessageOut()
+	for _, run := range runs {
+		if err := run.advance(); err != nil {
+			return err
+		}
+	}
+	for {
+		next := -1
+		for i, run := range runs {
+			if run.head == nil {
+				continue
+			}
+			if next < 0 || sl.less(run.head.keys, runs[next].head.keys) {
+				next = i
+			}
+		}
+		if next < 0 {
+			return nil
+		}
+		select {
+		case outCh <- runs[next].head.msg:
+		case <-m.SigChan():
+			return nil
+		}
+		if err := runs[next].advance(); err != nil {
+			return err
+		}
+	}
+}
+
 type msgkey struct {
 	keys []string
 	msg  *datasource.SqlDriverMessageMap
 }
+
+// size approximate bytes held by the buffered row.
+func (m *msgkey) size() int64 {
+	n := plan.RowSize(m.msg.Vals) + 64
+	for _, key := range m.keys {
+		n += 16 + int64(len(key))
+	}
+	return n
+}
+
 type OrderMessages struct {
 	l      []*msgkey
 	invert []bool
@@ -164,8 +253,11 @@ func (m *OrderMessages) Len() int {
 	return len(m.l)
 }
 func (m *OrderMessages) Less(i, j int) bool {
-	for ki, key := range m.l[i].keys {
-		if key < m.l[j].keys[ki] {
+	return m.less(m.l[i].keys, m.l[j].keys)
+}
+func (m *OrderMessages) less(ikeys, jkeys []string) bool {
+	for ki, key := range ikeys {
+		if key < jkeys[ki] {
 			if m.invert[ki] {
 				return false
 			}
@@ -181,3 +273,101 @@ func (m *OrderMessages) Less(i, j int) bool {
 func (m *OrderMessages) Swap(i, j int) {
 	m.l[i], m.l[j] = m.l[j], m.l[i]
 }
+
+// orderRun a sorted run of rows, either held in memory or spilled
+// to a temp file, that the Order task merges.
+type orderRun struct {
+	head  *msgkey
+	next  func() (*msgkey, error)
+	close func()
+}
+
+// advance to the next row of the run, head is nil once exhausted.
+func (m *orderRun) advance() (err error) {
+	m.head, err = m.next()
+	return err
+}
+
+func newOrderRunMem(l []*msgkey) *orderRun {
+	pos := 0
+	return &orderRun{
+		next: func() (*msgkey, error) {
+			if pos >= len(l) {
+				return nil, nil
+			}
+			pos++
+			return l[pos-1], nil
+		},
+		close: func() {},
+	}
+}
+
+func init() {
+	// value types a row may hold in its []driver.Value that gob
+	// does not know about already.
+	gob.Register(time.Time{})
+	gob.Register(map[string]interface{}{})
+	gob.Register(map[string]string{})
+	gob.Register(map[string]int64{})
+	gob.Register(map[string]float64{})
+	gob.Register(map[string]bool{})
+	gob.Register(map[string]time.Time{})
+	gob.Register([]interface{}{})
+}
+
+// spilledRow a row as written to an order by spill file.
+type spilledRow struct {
+	Keys []string
+	Id   uint64
+	Vals []driver.Value
+}
+
+// spillOrderRun write the sorted rows to a temp file, read back
+// by the returned run.
+func spillOrderRun(l []*msgkey) (*orderRun, error) {
+	f, err := ioutil.TempFile("", "qlbridge-order-")
+	if err != nil {
+		return nil, err
+	}
+	run := &orderRun{close: func() {
+		f.Close()
+		os.Remove(f.Name())
+	}}
+
+	var colIndex map[string]int
+	if len(l) > 0 {
+		colIndex = l[0].msg.ColIndex
+	}
+	w := bufio.NewWriter(f)
+	enc := gob.NewEncoder(w)
+	for _, mk := range l {
+		row := spilledRow{Keys: mk.keys, Id: mk.msg.Id(), Vals: mk.msg.Vals}
+		if err = enc.Encode(&row); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		_, err = f.Seek(0, 0)
+	}
+	if err != nil {
+		run.close()
+		u.Errorf("could not spill order by rows: %v", err)
+		return nil, err
+	}
+
+	dec := gob.NewDecoder(bufio.NewReader(f))
+	run.next = func() (*msgkey, error) {
+		row := spilledRow{}
+		if err := dec.Decode(&row); err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return &msgkey{row.Keys, datasource.NewSqlDriverMessageMap(row.Id, row.Vals, colIndex)}, nil
+	}
+	return run, nil
+}
@@ -0,0 +1,68 @@
+package exec
+
+import (
+	"sync"
+
+	"github.com/araddon/qlbridge/plan"
+)
+
+var (
+	// Ensure that we implement the Task Runner interface
+	_ TaskRunner = (*PartitionMerge)(nil)
+)
+
+// PartitionMerge merges the output of the parallel scans of each
+// partition of a single source into one channel.
+//
+//   partition 1  ->
+//                  \
+//                    --  merge  -->
+//                  /
+//   partition n  ->
+//
+type PartitionMerge struct {
+	*TaskBase
+	parts []TaskRunner
+}
+
+// NewPartitionMerge creates the merge task reading from each of the
+// partition tasks.
+func NewPartitionMerge(ctx *plan.Context, parts []TaskRunner) *PartitionMerge {
+	return &PartitionMerge{
+		TaskBase: NewTaskBase(ctx),
+		parts:    parts,
+	}
+}
+
+// Run the merge, standard task interface.
+func (m *PartitionMerge) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+
+	outCh := m.MessageOut()
+
+	wg := new(sync.WaitGroup)
+	for _, part := range m.parts {
+		wg.Add(1)
+		go func(in MessageChan) {
+			defer wg.Done()
+			for {
+				select {
+				case <-m.SigChan():
+					return
+				case msg, ok := <-in:
+					if !ok {
+						return
+					}
+					select {
+					case outCh <- msg:
+					case <-m.SigChan():
+						return
+					}
+				}
+			}
+		}(part.MessageOut())
+	}
+	wg.Wait()
+	return nil
+}
@@ -126,6 +126,25 @@ func (m *Projection) projectionEvaluator(isFinal bool) MessageHandler {
 	}
 
 	rowCt := 0
+	emit := func(outMsg schema.Message) bool {
+		if rowCt >= limit {
+			//u.Debugf("%p Projection reaching Limit!!! rowct:%v  limit:%v", m, rowCt, limit)
+			out <- nil // Sending nil message is a message to downstream to shutdown
+			m.Quit()   // should close rest of dag as well
+			return false
+		}
+		rowCt++
+
+		//u.Debugf("row:%d  completed projection for: %p %#v", rowCt, out, outMsg)
+		select {
+		case out <- outMsg:
+			return true
+		case <-m.SigChan():
+			return false
+		}
+	}
+	m.BatchHandler = m.projectionBatch(isFinal, colCt, emit)
+
 	return func(ctx *plan.Context, msg schema.Message) bool {
 
 		select {
@@ -141,10 +160,14 @@ func (m *Projection) projectionEvaluator(isFinal bool) MessageHandler {
 		case *datasource.SqlDriverMessageMap:
 			// use our custom write context for example purposes
 			row := make([]driver.Value, colCt)
-			rdr := datasource.NewNestedContextReader([]expr.ContextReader{
-				mt,
-				ctx.Session,
-			}, mt.Ts())
+			var rdr expr.ContextReader = mt
+			if ctx.Session != nil {
+				// session variables, and time zone for time functions
+				rdr = datasource.NewNestedContextReader([]expr.ContextReader{
+					mt,
+					ctx.Session,
+				}, mt.Ts())
+			}
 			//u.Debugf("about to project: %#v", mt)
 			colIdx := -1
 			for _, col := range columns {
@@ -293,24 +316,70 @@ func (m *Projection) projectionEvaluator(isFinal bool) MessageHandler {
 			u.Errorf("could not project msg:  %T", msg)
 		}
 
-		if rowCt >= limit {
-			//u.Debugf("%p Projection reaching Limit!!! rowct:%v  limit:%v", m, rowCt, limit)
-			out <- nil // Sending nil message is a message to downstream to shutdown
-			m.Quit()   // should close rest of dag as well
-			return false
+		return emit(outMsg)
+	}
+}
+
+// projectionBatch creates the batch handler for projections of plain columns,
+// copying values straight from the column vectors.  Returns nil for other
+// projections, which project each row of the batch as a message.
+func (m *Projection) projectionBatch(isFinal bool, colCt int, emit func(schema.Message) bool) BatchHandler {
+
+	columns := m.p.Stmt.Columns
+	colIndex := m.p.Stmt.ColIndexes()
+	if colCt < len(columns) {
+		return nil
+	}
+	keys := make([]string, len(columns))
+	for i, col := range columns {
+		in, isIdent := col.Expr.(*expr.IdentityNode)
+		if col.Star || col.Guard != nil || !isIdent || in.IsBooleanIdentity() {
+			return nil
 		}
-		rowCt++
+		keys[i] = in.Text
+		if in.HasLeftRight() {
+			keys[i] = in.OriginalText()
+		}
+	}
 
-		//u.Debugf("row:%d  completed projection for: %p %#v", rowCt, out, outMsg)
-		select {
-		case out <- outMsg:
-			return true
-		case <-m.SigChan():
-			return false
+	return func(ctx *plan.Context, b *datasource.Batch) bool {
+
+		vals := make([][]driver.Value, len(columns))
+		for i, col := range columns {
+			if isFinal && col.ParentIndex < 0 {
+				continue
+			}
+			cv, ok := b.Col(keys[i])
+			if !ok {
+				// may be a session variable, needs the full evaluator
+				for _, msg := range b.Messages() {
+					if !m.Handler(ctx, msg) {
+						return false
+					}
+				}
+				return true
+			}
+			vals[i] = cv
 		}
+
+		for _, pos := range b.Selected() {
+			row := make([]driver.Value, colCt)
+			for i, cv := range vals {
+				if cv != nil {
+					row[i] = value.NewValue(cv[pos]).Value()
+				}
+			}
+			if !emit(datasource.NewSqlDriverMessageMap(0, row, colIndex)) {
+				return false
+			}
+		}
+		return true
 	}
 }
 
+// BatchIn projection accepts batches, its output is always rows.
+func (m *Projection) BatchIn() bool { return true }
+
 // Limit only evaluator
 func (m *Projection) limitEvaluator() MessageHandler {
 
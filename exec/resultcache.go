@@ -0,0 +1,419 @@
+package exec
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// Ensure the result cache tasks implement TaskRunner
+	_ TaskRunner = (*resultCacheScan)(nil)
+	_ TaskRunner = (*resultCacheRecorder)(nil)
+)
+
+// ResultCache is an LRU cache of the result rows of select statements keyed
+// by the fingerprint of the statement, the values of its literals, ie its
+// bound params, and the session variables, as time_zone and @@session
+// variables change the results.
+//
+//    SELECT name FROM users WHERE state = "ca"
+//
+// is answered from the cache, without opening the source of users, until
+// the TableVersion() of users changes or ttl passes.  Only statements whose
+// tables all come from sources implementing schema.SourceTableVersion are
+// cached.  Functions such as now() aren't part of the version, use a ttl
+// if statements depend on them.
+type ResultCache struct {
+	size     int
+	maxBytes int64
+	ttl      time.Duration
+	mu       sync.Mutex
+	lru      *list.List
+	items    map[string]*list.Element
+	bytes    int64
+	hits     int64
+	misses   int64
+}
+
+// resultEntry the rows of a statement and the versions of the tables
+// they were read from.
+type resultEntry struct {
+	key      string
+	schema   *schema.Schema
+	version  uint64 // schema version
+	stmt     *rel.SqlSelect
+	proj     *plan.Projection
+	tables   []string
+	versions []string
+	rows     []schema.Message
+	bytes    int64 // approximate bytes held by rows
+	created  time.Time
+}
+
+// NewResultCache creates a result cache holding the rows of at most size
+// statements and maxBytes bytes of rows, 0 being no byte limit, each for
+// at most ttl, 0 being until their tables change.  Statements whose rows
+// alone are over maxBytes aren't cached.
+func NewResultCache(size int, maxBytes int64, ttl time.Duration) *ResultCache {
+	if size < 1 {
+		size = 1
+	}
+	return &ResultCache{size: size, maxBytes: maxBytes, ttl: ttl, lru: list.New(), items: make(map[string]*list.Element)}
+}
+
+// get the cached rows of the statement of ctx, nil if there are none or
+// a table changed since they were cached.
+func (m *ResultCache) get(ctx *plan.Context) *resultEntry {
+	key, err := resultKey(ctx)
+	if err != nil {
+		atomic.AddInt64(&m.misses, 1)
+		return nil
+	}
+
+	m.mu.Lock()
+	var re *resultEntry
+	if el, ok := m.items[key]; ok {
+		re = el.Value.(*resultEntry)
+		m.lru.MoveToFront(el)
+	}
+	m.mu.Unlock()
+
+	if re == nil {
+		atomic.AddInt64(&m.misses, 1)
+		return nil
+	}
+	if !re.valid(ctx, m.ttl) {
+		m.remove(re)
+		atomic.AddInt64(&m.misses, 1)
+		return nil
+	}
+	atomic.AddInt64(&m.hits, 1)
+	return re
+}
+
+// entry a new entry for the statement of ctx planned as p, nil if its rows
+// can't be cached.  The versions of its tables are read before it runs, so
+// rows written while it runs make the entry stale.
+func (m *ResultCache) entry(ctx *plan.Context, p *plan.Select) *resultEntry {
+	if p.Stmt == nil || p.IsSchemaQuery() || len(p.Stmt.From) == 0 {
+		return nil
+	}
+	key, err := resultKey(ctx)
+	if err != nil {
+		return nil
+	}
+	tables := make(map[string]bool)
+	if !statementTables(p.Stmt, tables) {
+		return nil
+	}
+	re := &resultEntry{
+		key:     key,
+		schema:  ctx.Schema,
+		version: ctx.Schema.Version(),
+		stmt:    p.Stmt,
+		proj:    ctx.Projection,
+		tables:  make([]string, 0, len(tables)),
+	}
+	for table := range tables {
+		re.tables = append(re.tables, table)
+	}
+	sort.Strings(re.tables)
+	if re.versions, err = tableVersions(ctx, re.tables); err != nil {
+		u.Debugf("not caching results of %q: %v", ctx.Raw, err)
+		return nil
+	}
+	return re
+}
+
+func (m *ResultCache) add(re *resultEntry) {
+	if m.tooLarge(re.bytes) {
+		return
+	}
+	re.created = time.Now()
+	m.mu.Lock()
+	if el, ok := m.items[re.key]; ok {
+		m.removeElement(el)
+	}
+	m.items[re.key] = m.lru.PushFront(re)
+	m.bytes += re.bytes
+	for m.lru.Len() > m.size || (m.maxBytes > 0 && m.bytes > m.maxBytes) {
+		m.removeElement(m.lru.Back())
+	}
+	m.mu.Unlock()
+}
+
+func (m *ResultCache) remove(re *resultEntry) {
+	m.mu.Lock()
+	if el, ok := m.items[re.key]; ok && el.Value == re {
+		m.removeElement(el)
+	}
+	m.mu.Unlock()
+}
+
+// removeElement drop an entry, the caller holds mu.
+func (m *ResultCache) removeElement(el *list.Element) {
+	re := el.Value.(*resultEntry)
+	m.lru.Remove(el)
+	delete(m.items, re.key)
+	m.bytes -= re.bytes
+}
+
+// tooLarge are rows of n bytes over the limit of the whole cache?
+func (m *ResultCache) tooLarge(n int64) bool {
+	return m.maxBytes > 0 && n > m.maxBytes
+}
+
+// Bytes approximate bytes of rows in the cache.
+func (m *ResultCache) Bytes() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bytes
+}
+
+// Len number of statements in the cache.
+func (m *ResultCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+// Hits number of statements answered from the cache.
+func (m *ResultCache) Hits() int64 { return atomic.LoadInt64(&m.hits) }
+
+// Misses number of statements that weren't.
+func (m *ResultCache) Misses() int64 { return atomic.LoadInt64(&m.misses) }
+
+// Purge drops all results.
+func (m *ResultCache) Purge() {
+	m.mu.Lock()
+	m.lru.Init()
+	m.items = make(map[string]*list.Element)
+	m.bytes = 0
+	m.mu.Unlock()
+}
+
+// valid are the rows within ttl and their tables unchanged?
+func (m *resultEntry) valid(ctx *plan.Context, ttl time.Duration) bool {
+	if s := ctx.Schema; m.schema != s || m.version != s.Version() {
+		return false
+	}
+	if ttl > 0 && time.Since(m.created) > ttl {
+		return false
+	}
+	versions, err := tableVersions(ctx, m.tables)
+	if err != nil {
+		return false
+	}
+	for i, v := range versions {
+		if v != m.versions[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// resultKey the schema, fingerprint and literal values of the statement,
+// and the session variables it may read, ie time_zone or @@session values.
+func resultKey(ctx *plan.Context) (string, error) {
+	fp, slots, err := plan.Fingerprint(ctx.Raw)
+	if err != nil {
+		return "", err
+	}
+	var key bytes.Buffer
+	fmt.Fprintf(&key, "%s\x00%x", ctx.Schema.Name, fp)
+	for _, t := range slots {
+		key.WriteByte(0)
+		key.WriteByte(t.Quote)
+		key.WriteString(t.V)
+	}
+	if ctx.Session != nil {
+		row := ctx.Session.Row()
+		names := make([]string, 0, len(row))
+		for name := range row {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			key.WriteByte(1)
+			key.WriteString(name)
+			key.WriteByte(0)
+			if v := row[name]; v != nil {
+				key.WriteString(v.ToString())
+			}
+		}
+	}
+	return key.String(), nil
+}
+
+// messageSize approximate bytes held by a cached row.
+func messageSize(msg schema.Message) int64 {
+	if vals, ok := msg.(interface {
+		Values() []driver.Value
+	}); ok {
+		return plan.RowSize(vals.Values()) + 64
+	}
+	return 256
+}
+
+// copyMessage copy the row of msg, so readers of cached rows don't share
+// them with each other or the statement that was cached.
+func copyMessage(msg schema.Message) schema.Message {
+	switch mt := msg.(type) {
+	case *datasource.SqlDriverMessageMap:
+		nm := mt.Copy()
+		nm.Vals = make([]driver.Value, len(mt.Vals))
+		copy(nm.Vals, mt.Vals)
+		return nm
+	case *datasource.SqlDriverMessage:
+		vals := make([]driver.Value, len(mt.Vals))
+		copy(vals, mt.Vals)
+		return datasource.NewSqlDriverMessage(mt.IdVal, vals)
+	}
+	return msg
+}
+
+// statementTables adds the tables read by sel, its sub-queries and joins,
+// false if one isn't a table of this schema.
+func statementTables(sel *rel.SqlSelect, tables map[string]bool) bool {
+	for _, from := range sel.From {
+		if from.SubQuery != nil {
+			if !statementTables(from.SubQuery, tables) {
+				return false
+			}
+			continue
+		}
+		if from.Schema != "" {
+			return false
+		}
+		if _, _, hasLeft := expr.LeftRight(from.Name); hasLeft {
+			return false
+		}
+		tables[strings.ToLower(from.Name)] = true
+	}
+	if sel.Where != nil && sel.Where.Source != nil {
+		return statementTables(sel.Where.Source, tables)
+	}
+	return true
+}
+
+// tableVersions the current version of each table from its source.
+func tableVersions(ctx *plan.Context, tables []string) ([]string, error) {
+	goCtx := ctx.Context
+	if goCtx == nil {
+		goCtx = context.Background()
+	}
+	versions := make([]string, len(tables))
+	for i, table := range tables {
+		ts, err := ctx.Schema.SchemaForTable(table)
+		if err != nil {
+			return nil, err
+		}
+		source, ok := ts.DS.(schema.SourceTableVersion)
+		if !ok {
+			return nil, fmt.Errorf("source %T of %q has no table version", ts.DS, table)
+		}
+		if versions[i], err = source.TableVersion(goCtx, table); err != nil {
+			return nil, err
+		}
+	}
+	return versions, nil
+}
+
+// resultCacheScan sends the cached rows of a statement.
+type resultCacheScan struct {
+	*TaskBase
+	rows []schema.Message
+}
+
+func newResultCacheScan(ctx *plan.Context, rows []schema.Message) *resultCacheScan {
+	return &resultCacheScan{TaskBase: NewTaskBase(ctx), rows: rows}
+}
+
+func (m *resultCacheScan) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+	for _, msg := range m.rows {
+		select {
+		case m.msgOutCh <- copyMessage(msg):
+		case <-m.SigChan():
+			return nil
+		}
+	}
+	return nil
+}
+
+// resultCacheRecorder passes through the rows of a statement keeping them,
+// to be added to the cache once the job finished without error.
+type resultCacheRecorder struct {
+	*TaskBase
+	cache    *ResultCache
+	entry    *resultEntry
+	complete bool
+}
+
+func newResultCacheRecorder(ctx *plan.Context, cache *ResultCache, re *resultEntry) *resultCacheRecorder {
+	return &resultCacheRecorder{TaskBase: NewTaskBase(ctx), cache: cache, entry: re}
+}
+
+func (m *resultCacheRecorder) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.msgOutCh)
+	rows := make([]schema.Message, 0)
+	var size int64
+	tooLarge := false
+	for {
+		select {
+		case <-m.SigChan():
+			return nil
+		case msg, ok := <-m.msgInCh:
+			if !ok {
+				m.Lock()
+				m.entry.rows = rows
+				m.entry.bytes = size
+				m.complete = !tooLarge
+				m.Unlock()
+				return nil
+			}
+			select {
+			case m.msgOutCh <- msg:
+				if tooLarge {
+					continue
+				}
+				// stop keeping rows once they can't fit the cache
+				size += messageSize(msg)
+				if m.cache.tooLarge(size) {
+					tooLarge, rows = true, nil
+					continue
+				}
+				rows = append(rows, copyMessage(msg))
+			case <-m.SigChan():
+				return nil
+			}
+		}
+	}
+}
+
+// store the rows in the cache if all were read.
+func (m *resultCacheRecorder) store() {
+	m.Lock()
+	defer m.Unlock()
+	if m.complete {
+		m.cache.add(m.entry)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	u "github.com/araddon/gou"
 
+	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/schema"
 )
@@ -15,6 +16,9 @@ var (
 	// Ensure that we implement the Task Runner interface
 	// to ensure this can run in exec engine
 	_ TaskRunner = (*Source)(nil)
+
+	// Sources scanning batches can send them downstream
+	_ BatchProducer = (*Source)(nil)
 )
 
 // RequiresContext defines a Source which requires context.
@@ -120,6 +124,9 @@ func (m *Source) Close() error {
 	return m.TaskBase.Close()
 }
 
+// SetBatchOut send batches downstream if the scanner supports it.
+func (m *Source) SetBatchOut(enabled bool) { m.batchOut = enabled }
+
 func (m *Source) Run() error {
 	defer m.Ctx.Recover()
 	defer close(m.msgOutCh)
@@ -131,6 +138,18 @@ func (m *Source) Run() error {
 
 	sigChan := m.SigChan()
 
+	if bs, ok := m.Scanner.(datasource.BatchScanner); ok && m.batchOut && BatchSize > 0 {
+		for b := bs.NextBatch(BatchSize); b != nil; b = bs.NextBatch(BatchSize) {
+			select {
+			case <-sigChan:
+				return nil
+			case m.msgOutCh <- b:
+				// continue
+			}
+		}
+		return nil
+	}
+
 	for item := m.Scanner.Next(); item != nil; item = m.Scanner.Next() {
 
 		select {
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -82,12 +83,27 @@ type qlbdriver struct{}
 // @connInfo = database/Schema name
 // @connInfo = driver-connection-info
 // @connInfo = sourceType://source
+// @connInfo = database/Schema name?user=alice   statements run as user
 func (m *qlbdriver) Open(connInfo string) (driver.Conn, error) {
-	s, ok := registry.Schema(connInfo)
+	schemaName, user := connInfo, ""
+	if idx := strings.Index(connInfo, "?"); idx >= 0 {
+		schemaName = connInfo[:idx]
+		args, err := url.ParseQuery(connInfo[idx+1:])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid connection info %q: %v", connInfo, err)
+		}
+		user = strings.ToLower(args.Get("user"))
+	}
+	s, ok := registry.Schema(schemaName)
 	if !ok || s == nil {
-		return nil, fmt.Errorf("No schema was found for %q", connInfo)
+		return nil, fmt.Errorf("No schema was found for %q", schemaName)
+	}
+	if user != "" {
+		if _, ok := registry.User(user); !ok {
+			return nil, fmt.Errorf("No user was found for %q", user)
+		}
 	}
-	return &qlbConn{schema: s}, nil
+	return &qlbConn{schema: s, user: user}, nil
 }
 
 // A stateful connection to database/source
@@ -106,6 +122,7 @@ type qlbConn struct {
 	parallel bool   // Do we Run In Background Mode?  Default = true
 	connInfo string //
 	schema   *schema.Schema
+	user     string // user statements are authorized for, empty is unchecked
 }
 
 // Exec may return ErrSkip.
@@ -203,6 +220,7 @@ func (m *qlbStmt) Exec(args []driver.Value) (driver.Result, error) {
 	// Create a Job, which is Dag of Tasks that Run()
 	ctx := plan.NewContext(m.query)
 	ctx.Schema = m.conn.schema
+	ctx.User = m.conn.user
 	job, err := BuildSqlJob(ctx)
 	if err != nil {
 		return nil, err
@@ -238,6 +256,7 @@ func (m *qlbStmt) Query(args []driver.Value) (driver.Rows, error) {
 	// Create a Job, which is Dag of Tasks that Run()
 	ctx := plan.NewContext(m.query)
 	ctx.Schema = m.conn.schema
+	ctx.User = m.conn.user
 	job, err := BuildSqlJob(ctx)
 	if err != nil {
 		u.Warnf("return error? %v", err)
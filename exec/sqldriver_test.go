@@ -10,6 +10,8 @@ import (
 
 	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/schema"
 )
 
 var _ = u.EMPTY
@@ -374,3 +376,95 @@ func TestSqlDbConnFailure(t *testing.T) {
 	assert.True(t, uo1.Price == 22.5, "? %#v", uo1)
 	rows2.Close()
 }
+
+func TestSqlDriverGrants(t *testing.T) {
+
+	// the first account is created through the registry, after which a
+	// connection without user is refused
+	reg := schema.DefaultRegistry()
+	assert.Equal(t, nil, reg.RoleAdd(schema.NewRole("drv_admin")))
+	assert.Equal(t, nil, reg.GrantAdd(schema.NewGrant("drv_admin", lex.TokenAll, "*", "*")))
+	assert.Equal(t, nil, reg.UserAdd(&schema.User{Name: "drv_root", Roles: []string{"drv_admin"}}))
+	defer reg.SchemaDrop("", "drv_admin", lex.TokenRole)
+	defer reg.SchemaDrop("", "drv_root", lex.TokenUser)
+
+	anonymous, err := sql.Open("qlbridge", "mockcsv")
+	assert.Equal(t, nil, err)
+	defer anonymous.Close()
+	_, err = anonymous.Query(`SELECT email FROM users`)
+	assert.NotEqual(t, nil, err)
+	_, err = anonymous.Exec(`CREATE USER drv_eve`)
+	assert.NotEqual(t, nil, err)
+
+	admin, err := sql.Open("qlbridge", "mockcsv?user=drv_root")
+	assert.Equal(t, nil, err)
+	defer admin.Close()
+
+	for _, ddl := range []string{
+		`CREATE USER drv_alice`,
+		`CREATE ROLE drv_analyst`,
+		`GRANT SELECT ON mockcsv.users TO drv_analyst`,
+		`GRANT drv_analyst TO drv_alice`,
+	} {
+		_, err = admin.Exec(ddl)
+		assert.Equal(t, nil, err, ddl)
+	}
+	defer admin.Exec(`DROP USER drv_alice`)
+	defer admin.Exec(`DROP ROLE drv_analyst`)
+
+	nobody, err := sql.Open("qlbridge", "mockcsv?user=drv_nobody")
+	assert.Equal(t, nil, err)
+	defer nobody.Close()
+	_, err = nobody.Query(`SELECT email FROM users`)
+	assert.NotEqual(t, nil, err)
+
+	alice, err := sql.Open("qlbridge", "mockcsv?user=drv_alice")
+	assert.Equal(t, nil, err)
+	defer alice.Close()
+
+	countRows := func(sqlText string) (int, error) {
+		rows, err := alice.Query(sqlText)
+		if err != nil {
+			return 0, err
+		}
+		defer rows.Close()
+		ct := 0
+		for rows.Next() {
+			ct++
+		}
+		return ct, rows.Err()
+	}
+
+	ct, err := countRows(`SELECT email FROM users`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, ct)
+
+	for _, denied := range []string{
+		`SELECT order_id FROM orders`,
+		`SELECT u.email FROM users AS u INNER JOIN orders AS o ON u.user_id = o.user_id`,
+	} {
+		_, err = countRows(denied)
+		assert.NotEqual(t, nil, err, denied)
+	}
+	for _, denied := range []string{
+		`DELETE FROM users WHERE user_id = "abc"`,
+		`DROP TABLE users`,
+		`GRANT ALL ON *.* TO drv_analyst`,
+		`CREATE USER drv_bob`,
+	} {
+		_, err = alice.Exec(denied)
+		assert.NotEqual(t, nil, err, denied)
+	}
+
+	// revoking applies to the next statement of the connection
+	_, err = admin.Exec(`REVOKE SELECT ON mockcsv.users FROM drv_analyst`)
+	assert.Equal(t, nil, err)
+	_, err = countRows(`SELECT email FROM users`)
+	assert.NotEqual(t, nil, err)
+
+	_, err = admin.Exec(`GRANT ALL ON mockcsv.* TO drv_analyst`)
+	assert.Equal(t, nil, err)
+	ct, err = countRows(`SELECT order_id FROM orders`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, ct)
+}
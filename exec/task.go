@@ -7,6 +7,7 @@ import (
 
 	u "github.com/araddon/gou"
 
+	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/plan"
 	"github.com/araddon/qlbridge/schema"
 )
@@ -32,6 +33,13 @@ type TaskBase struct {
 	errCh    ErrChan
 	sigCh    SigChan // notify of quit/stop
 	errors   []error
+
+	// BatchHandler optional handler for batches, if nil batches
+	// are split into rows for Handler
+	BatchHandler BatchHandler
+	batchOut     bool
+
+	observeId uint64 // id of this task sent to observers
 }
 
 func NewTaskBase(ctx *plan.Context) *TaskBase {
@@ -144,7 +152,11 @@ msgLoop:
 		case msg, ok = <-m.msgInCh:
 			if ok {
 				//u.Debugf("sending to handler: %T  %+v", msg, msg)
-				m.Handler(m.Ctx, msg)
+				if b, isBatch := msg.(*datasource.Batch); isBatch {
+					m.handleBatch(b)
+				} else {
+					m.Handler(m.Ctx, msg)
+				}
 			} else {
 				//u.Debugf("msg in closed shutting down")
 				break msgLoop
@@ -158,6 +170,23 @@ msgLoop:
 	return err
 }
 
+func (m *TaskBase) handleBatch(b *datasource.Batch) bool {
+	if m.BatchHandler != nil {
+		return m.BatchHandler(m.Ctx, b)
+	}
+	// handlers return false for filtered rows too, so only a
+	// closed task stops splitting the batch
+	for _, msg := range b.Messages() {
+		select {
+		case <-m.sigCh:
+			return false
+		default:
+		}
+		m.Handler(m.Ctx, msg)
+	}
+	return true
+}
+
 // On Task stepper we don't Run it, rather use a
 //   Next() explicit call from end user
 type TaskStepper struct {
@@ -3,6 +3,7 @@ package exec
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	u "github.com/araddon/gou"
 
@@ -13,7 +14,8 @@ var (
 	_ = u.EMPTY
 
 	// Ensure that we implement the Tasks
-	_ Task = (*TaskParallel)(nil)
+	_ Task        = (*TaskParallel)(nil)
+	_ countedTask = (*TaskParallel)(nil)
 )
 
 // A parallel set of tasks, this starts each child task and offers up
@@ -27,6 +29,11 @@ type TaskParallel struct {
 	in      TaskRunner
 	runners []TaskRunner
 	tasks   []Task
+
+	merged   MessageChan   // the channel the runners send to
+	observed bool          // tasks are sent to the context Observers
+	out      *rowCounter   // rows out of the merged runners, if observed
+	stops    []*plan.Event // stops of the runners, once run
 }
 
 func NewTaskParallel(ctx *plan.Context) *TaskParallel {
@@ -68,6 +75,7 @@ func (m *TaskParallel) Close() error {
 }
 
 func (m *TaskParallel) Setup(depth int) error {
+	m.depth = depth
 	m.setup = true
 	if m.in != nil {
 		for _, task := range m.runners {
@@ -84,6 +92,12 @@ func (m *TaskParallel) Setup(depth int) error {
 			return err
 		}
 	}
+	m.merged = m.msgOutCh
+	m.observed = m.Ctx.Observing()
+	if m.observed && depth > 0 {
+		m.out = newRowCounter(m.merged)
+		m.TaskBase.MessageOutSet(m.out.out)
+	}
 	return nil
 }
 
@@ -112,7 +126,7 @@ func (m *TaskParallel) Run() error {
 			}
 		}()
 		//u.WarnT(8)
-		close(m.msgOutCh) // closing output channels is the signal to stop
+		close(m.merged) // closing output channels is the signal to stop
 	}()
 
 	// Either of the SigQuit, or error channel will
@@ -127,6 +141,14 @@ func (m *TaskParallel) Run() error {
 	}
 
 	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var err error
+	if m.observed {
+		m.stops = make([]*plan.Event, len(m.runners))
+		if m.out != nil {
+			go m.out.run(m.Ctx, m.SigChan())
+		}
+	}
 
 	// start tasks in reverse order, so that by time
 	// source starts up all downstreams have started
@@ -134,10 +156,25 @@ func (m *TaskParallel) Run() error {
 		wg.Add(1)
 		go func(taskId int) {
 			task := m.runners[taskId]
+			var stop *plan.Event
+			if m.observed {
+				stop = startTask(m.Ctx, m.observeId, task)
+				m.stops[taskId] = stop
+			}
 			//u.Infof("starting task %d-%d %T in:%p  out:%p", m.depth, taskId, task, task.MessageIn(), task.MessageOut())
-			if err := task.Run(); err != nil {
-				u.Errorf("%T.Run() errored %v", task, err)
-				// TODO:  what do we do with this error?   send to error channel?
+			taskErr := task.Run()
+			if stop != nil {
+				stop.Duration = time.Since(stop.Start)
+				stop.Err = taskErr
+			}
+			if taskErr != nil {
+				u.Errorf("%T.Run() errored %v", task, taskErr)
+				// the first error is returned once all tasks are done
+				errMu.Lock()
+				if err == nil {
+					err = taskErr
+				}
+				errMu.Unlock()
 			}
 			//u.Debugf("exiting taskId: %v %T", taskId, task)
 			wg.Done()
@@ -145,6 +182,59 @@ func (m *TaskParallel) Run() error {
 	}
 
 	wg.Wait()
+	if m.depth == 0 {
+		for _, stop := range m.taskStops() {
+			m.Ctx.Observe(stop)
+		}
+	}
 
-	return nil
+	return err
+}
+
+// taskRows the rows read by the task at i from its input, and read
+// from its output downstream.  A runner that is not a sequence is the
+// merge (join, partitions) of the outputs of the others.
+func (m *TaskParallel) taskRows(i int) (in, out int64) {
+	if ct, ok := m.runners[i].(countedTask); ok {
+		return ct.rowsIn(), ct.rowsOut()
+	}
+	for _, task := range m.runners {
+		if ct, ok := task.(countedTask); ok {
+			in += ct.rowsOut()
+		}
+	}
+	if m.out == nil {
+		return in, in
+	}
+	return in, m.out.count()
+}
+
+// rowsIn the rows read by the sources of the runners.
+func (m *TaskParallel) rowsIn() int64 {
+	var in int64
+	for _, task := range m.runners {
+		if ct, ok := task.(countedTask); ok {
+			in += ct.rowsIn()
+		}
+	}
+	return in
+}
+
+func (m *TaskParallel) rowsOut() int64 { return m.out.count() }
+
+// taskStops the stops of the runners and of the tasks they hold, with
+// their rows counted.
+func (m *TaskParallel) taskStops() []*plan.Event {
+	var stops []*plan.Event
+	for i, stop := range m.stops {
+		if stop == nil {
+			continue
+		}
+		stop.RowsIn, stop.RowsOut = m.taskRows(i)
+		stops = append(stops, stop)
+		if ct, ok := m.runners[i].(countedTask); ok {
+			stops = append(stops, ct.taskStops()...)
+		}
+	}
+	return stops
 }
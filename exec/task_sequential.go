@@ -3,6 +3,7 @@ package exec
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	u "github.com/araddon/gou"
 
@@ -13,7 +14,8 @@ var (
 	_ = u.EMPTY
 
 	// Ensure that we implement the plan.Tasks
-	_ Task = (*TaskSequential)(nil)
+	_ Task        = (*TaskSequential)(nil)
+	_ countedTask = (*TaskSequential)(nil)
 )
 
 type TaskSequential struct {
@@ -21,6 +23,11 @@ type TaskSequential struct {
 	closed  bool
 	tasks   []Task
 	runners []TaskRunner
+
+	observed bool          // tasks are sent to the context Observers
+	counters []*rowCounter // rows between the runners, if observed
+	out      *rowCounter   // rows out of the last runner, if not the root
+	stops    []*plan.Event // stops of the runners, once run
 }
 
 func NewTaskSequential(ctx *plan.Context) *TaskSequential {
@@ -88,18 +95,58 @@ func (m *TaskSequential) Setup(depth int) error {
 		}
 	}
 	//u.Infof("%d  TaskSequential Setup  tasks len=%d", depth, len(m.tasks))
+	m.observed = m.Ctx.Observing()
 	for i := 1; i < len(m.runners); i++ {
-		m.runners[i].MessageInSet(m.runners[i-1].MessageOut())
+		if m.observed {
+			rc := newRowCounter(m.runners[i-1].MessageOut())
+			m.counters = append(m.counters, rc)
+			m.runners[i].MessageInSet(rc.out)
+		} else {
+			m.runners[i].MessageInSet(m.runners[i-1].MessageOut())
+		}
 		//u.Infof("%d-%d setup msgin: %T  %p", depth, i, m.runners[i], m.runners[i].MessageIn())
+
+		// Tasks exchange batches only if both ends support them
+		if BatchSize > 0 {
+			producer, isProducer := m.runners[i-1].(BatchProducer)
+			consumer, isConsumer := m.runners[i].(BatchConsumer)
+			if isProducer && isConsumer && consumer.BatchIn() {
+				producer.SetBatchOut(true)
+			}
+		}
 	}
 	if depth > 0 {
-		m.TaskBase.MessageOutSet(m.runners[len(m.tasks)-1].MessageOut())
+		if m.observed {
+			m.out = newRowCounter(m.runners[len(m.tasks)-1].MessageOut())
+			m.TaskBase.MessageOutSet(m.out.out)
+		} else {
+			m.TaskBase.MessageOutSet(m.runners[len(m.tasks)-1].MessageOut())
+		}
 		m.runners[0].MessageInSet(m.TaskBase.MessageIn())
 	}
 	//u.Debugf("setup() %T in:%p  out:%p", m, m.msgInCh, m.msgOutCh)
 	return nil
 }
 
+// BatchIn a sequence accepts batches if its first task does.
+func (m *TaskSequential) BatchIn() bool {
+	if len(m.runners) == 0 {
+		return false
+	}
+	consumer, ok := m.runners[0].(BatchConsumer)
+	return ok && consumer.BatchIn()
+}
+
+// SetBatchOut passes through to the last task of the sequence.
+func (m *TaskSequential) SetBatchOut(enabled bool) {
+	if len(m.runners) == 0 {
+		return
+	}
+	if producer, ok := m.runners[len(m.runners)-1].(BatchProducer); ok {
+		producer.SetBatchOut(enabled)
+	}
+}
+
 func (m *TaskSequential) Add(task Task) error {
 	if m.setup {
 		return fmt.Errorf("Cannot add task after Setup() called")
@@ -141,14 +188,38 @@ func (m *TaskSequential) Run() (err error) {
 	// 	}
 	// }()
 
+	// the stop of each task is sent once the dag is done, so the
+	// rows it sent on are counted
+	var stops []*plan.Event
+	if m.observed {
+		stops = make([]*plan.Event, len(m.runners))
+		m.stops = stops
+		for _, rc := range m.counters {
+			go rc.run(m.Ctx, m.SigChan())
+		}
+		if m.out != nil {
+			go m.out.run(m.Ctx, m.SigChan())
+		}
+	}
+
 	// start tasks in reverse order, so that by time
 	// source starts up all downstreams have started
 	for i := len(m.runners) - 1; i >= 0; i-- {
 		wg.Add(1)
 		go func(taskId int) {
 			task := m.runners[taskId]
+			var stop *plan.Event
+			if stops != nil {
+				stop = startTask(m.Ctx, m.observeId, task)
+				stops[taskId] = stop
+			}
 			//u.Infof("starting task %d-%d %T in:%p  out:%p", m.depth, taskId, task, task.MessageIn(), task.MessageOut())
-			if taskErr := task.Run(); taskErr != nil {
+			taskErr := task.Run()
+			if stop != nil {
+				stop.Duration = time.Since(stop.Start)
+				stop.Err = taskErr
+			}
+			if taskErr != nil {
 				u.Errorf("%T.Run() errored %v", task, taskErr)
 				// TODO:  what do we do with this error?   send to error channel?
 				err = taskErr
@@ -170,6 +241,74 @@ func (m *TaskSequential) Run() (err error) {
 	}
 
 	wg.Wait() // block until all tasks have finished
+	if m.depth == 0 {
+		for _, stop := range m.taskStops() {
+			m.Ctx.Observe(stop)
+		}
+	}
 	//u.Debugf("%p exit TaskSequential Run():  %q", m, m.Name)
 	return
 }
+
+// taskRows the rows read by the task at i from its input, and read
+// from its output by the task downstream.
+func (m *TaskSequential) taskRows(i int) (in, out int64) {
+	ct, isCounted := m.runners[i].(countedTask)
+	if isCounted {
+		in, out = ct.rowsIn(), ct.rowsOut()
+	}
+	if i > 0 {
+		in = m.counters[i-1].count()
+	}
+	if i < len(m.counters) {
+		out = m.counters[i].count()
+	} else if m.out != nil {
+		out = m.out.count()
+	}
+	if isCounted {
+		return in, out
+	}
+	// Nothing in the dag feeds the first task of a sequence, it is a
+	// source that sends on the rows it reads.  The last task of the
+	// root hands the rows it reads to the caller.
+	if i == 0 {
+		in = out
+	}
+	if i == len(m.runners)-1 && m.out == nil {
+		out = in
+	}
+	return in, out
+}
+
+func (m *TaskSequential) rowsIn() int64 {
+	if len(m.runners) == 0 {
+		return 0
+	}
+	in, _ := m.taskRows(0)
+	return in
+}
+
+func (m *TaskSequential) rowsOut() int64 {
+	if len(m.runners) == 0 {
+		return 0
+	}
+	_, out := m.taskRows(len(m.runners) - 1)
+	return out
+}
+
+// taskStops the stops of the tasks of this sequence and of the
+// tasks they hold, with their rows counted.
+func (m *TaskSequential) taskStops() []*plan.Event {
+	var stops []*plan.Event
+	for i, stop := range m.stops {
+		if stop == nil {
+			continue
+		}
+		stop.RowsIn, stop.RowsOut = m.taskRows(i)
+		stops = append(stops, stop)
+		if ct, ok := m.runners[i].(countedTask); ok {
+			stops = append(stops, ct.taskStops()...)
+		}
+	}
+	return stops
+}
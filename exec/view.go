@@ -0,0 +1,571 @@
+package exec
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/memdb"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+// views the refresh state of materialized views, kept in memory so the
+// first refresh after a restart is a full one.
+var views = struct {
+	sync.Mutex
+	m map[*schema.View]*viewState
+}{m: make(map[*schema.View]*viewState)}
+
+// viewState the last value of the append column of a view read into its
+// rows.  Rows appended later may repeat that value, so the next refresh
+// reads from it again: views with aggregates keep the partial aggregates of
+// each group of the rows before it, and recompute the rows at it, other
+// views keep how many of each row at it were written, to skip them.
+type viewState struct {
+	mu        sync.Mutex
+	refreshed bool
+	watermark driver.Value
+	keys      []string // group keys in the order first seen
+	groups    map[string][]Aggregator
+	atMark    map[string]int // rows at the watermark written
+}
+
+func viewStateFor(v *schema.View) *viewState {
+	views.Lock()
+	defer views.Unlock()
+	st, ok := views.m[v]
+	if !ok {
+		st = &viewState{}
+		views.m[v] = st
+	}
+	return st
+}
+
+func dropViewState(v *schema.View) {
+	views.Lock()
+	delete(views.m, v)
+	views.Unlock()
+}
+
+// createView creates the materialized view of cs and runs its first
+// refresh.  The rows are stored in a new memdb unless the WITH source names
+// a schema whose source is a schema.SourceTableCreator.
+//
+//    CREATE MATERIALIZED VIEW hits_by_user AS
+//       SELECT user_id, count(*) AS ct, sum(bytes) AS bytes FROM hits GROUP BY user_id
+//       WITH source = "viewstore", append = "ts"
+//
+func createView(ctx *plan.Context, cs *rel.SqlCreate) error {
+	s := ctx.Schema
+	if s == nil {
+		return fmt.Errorf("must have schema")
+	}
+	existing, exists := s.View(cs.Identity)
+	if exists && !cs.OrReplace {
+		return fmt.Errorf("view %q already exists", cs.Identity)
+	} else if !exists {
+		if _, err := s.Table(cs.Identity); err == nil {
+			return fmt.Errorf("table %q already exists", cs.Identity)
+		}
+	}
+	appendCol := cs.With.String("append")
+	if appendCol != "" {
+		if err := checkAppendView(cs.Select); err != nil {
+			return fmt.Errorf("view %q can not be refreshed incrementally: %v", cs.Identity, err)
+		}
+	}
+
+	var source schema.Source
+	if name := cs.With.String("source"); name != "" && name != "memdb" {
+		ss, ok := schema.DefaultRegistry().Schema(name)
+		if !ok || ss.DS == nil {
+			return fmt.Errorf("view %q source %q not found", cs.Identity, name)
+		}
+		if _, ok := ss.DS.(schema.SourceTableCreator); !ok {
+			return fmt.Errorf("view %q source %q of type %T can not create tables", cs.Identity, name, ss.DS)
+		}
+		if !exists || existing.Source != ss.DS {
+			// creating the table would replace the rows of another
+			for _, table := range ss.DS.Tables() {
+				if strings.EqualFold(table, cs.Identity) {
+					return fmt.Errorf("view %q source %q already has table %q", cs.Identity, name, table)
+				}
+			}
+		}
+		source = ss.DS
+	}
+
+	v := schema.NewView(cs.Identity, cs.Select.String(), appendCol, source)
+	if err := refreshView(ctx, v, true); err != nil {
+		dropViewState(v)
+		return err
+	}
+	if exists {
+		dropViewState(existing)
+	}
+	return nil
+}
+
+// checkAppendView can the rows of sel be folded in as they are appended
+// to its source, ie it reads a single table and its aggregates can merge.
+func checkAppendView(sel *rel.SqlSelect) error {
+	if len(sel.From) != 1 || sel.From[0].SubQuery != nil {
+		return fmt.Errorf("must select from a single table")
+	}
+	if sel.Where != nil && sel.Where.Source != nil {
+		return fmt.Errorf("sub-query not supported")
+	}
+	if sel.Having != nil || sel.Limit > 0 || sel.Distinct {
+		return fmt.Errorf("HAVING, LIMIT and DISTINCT not supported")
+	}
+	if sel.IsAggQuery() {
+		if _, err := buildAggs(plan.NewGroupBy(sel)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshView recompute the rows of the view, or if it has an append
+// column and full is false fold in only the rows appended since the last
+// refresh.  The selects run as the user of ctx.
+//
+//    REFRESH MATERIALIZED VIEW hits_by_user
+//
+func refreshView(ctx *plan.Context, v *schema.View, full bool) error {
+	st := viewStateFor(v)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	stmt, err := rel.ParseSqlResolver(v.Sql, ctx.Schema)
+	if err != nil {
+		return err
+	}
+	sel, ok := stmt.(*rel.SqlSelect)
+	if !ok {
+		return fmt.Errorf("view %q is not a select %T", v.Name, stmt)
+	}
+	if err := checkViewPolicies(ctx.Schema, sel); err != nil {
+		return fmt.Errorf("view %q %v", v.Name, err)
+	}
+
+	if v.Append == "" {
+		rows, proj, err := viewQuery(ctx, v.Sql, false)
+		if err != nil {
+			return err
+		}
+		return writeView(ctx, v, viewTable(v.Name, proj, 0), rows, true)
+	}
+
+	if full || !st.refreshed {
+		st.refreshed, st.watermark, st.keys, st.groups, st.atMark = false, nil, nil, nil, nil
+		full = true
+	}
+
+	to, err := viewWatermark(ctx, v, sel, st.watermark)
+	if err != nil {
+		return err
+	}
+	if to == nil && !full {
+		// nothing appended
+		return nil
+	}
+	if sel.IsAggQuery() {
+		err = refreshViewGroups(ctx, v, sel, st, to)
+	} else {
+		err = refreshViewRows(ctx, v, sel, st, to, full)
+	}
+	if err != nil {
+		// the state no longer matches the rows, next refresh is full
+		st.refreshed = false
+		return err
+	}
+	if to != nil {
+		st.watermark = to
+	}
+	st.refreshed = true
+	return nil
+}
+
+// refreshViewGroups fold the rows from the watermark up to, not including,
+// to into the partial aggregates of each group, then rewrite the table with
+// those merged with the aggregates of the rows at to.
+func refreshViewGroups(ctx *plan.Context, v *schema.View, sel *rel.SqlSelect, st *viewState, to driver.Value) error {
+	col := expr.IdentityMaybeQuote('`', v.Append)
+	gb := plan.NewGroupBy(sel)
+	partial := *gb
+	partial.Partial = true
+
+	if st.groups == nil {
+		st.groups = make(map[string][]Aggregator)
+	}
+	var proj *plan.Projection
+	if to != nil && (st.watermark == nil || viewLiteral(st.watermark) != viewLiteral(to)) {
+		cond := fmt.Sprintf("%s < %s", col, viewLiteral(to))
+		if st.watermark != nil {
+			cond = fmt.Sprintf("%s >= %s AND %s", col, viewLiteral(st.watermark), cond)
+		}
+		sql, err := viewDelta(sel, cond)
+		if err != nil {
+			return err
+		}
+		rows, p, err := viewQuery(ctx, sql, true)
+		if err != nil {
+			return err
+		}
+		proj = p
+		for _, row := range rows {
+			key, ok := row[len(row)-1].(string)
+			if !ok {
+				return fmt.Errorf("expected group key but got %T", row[len(row)-1])
+			}
+			groupAggs, ok := st.groups[key]
+			if !ok {
+				if groupAggs, err = buildAggs(&partial); err != nil {
+					return err
+				}
+				st.groups[key] = groupAggs
+				st.keys = append(st.keys, key)
+			}
+			mergePartial(sel.Columns, groupAggs, row[:len(row)-1])
+		}
+	}
+
+	// the rows at to are recomputed each refresh
+	cond := "1 = 0"
+	if to != nil {
+		cond = fmt.Sprintf("%s = %s", col, viewLiteral(to))
+	}
+	sql, err := viewDelta(sel, cond)
+	if err != nil {
+		return err
+	}
+	tail, p, err := viewQuery(ctx, sql, true)
+	if err != nil {
+		return err
+	}
+	if proj == nil {
+		proj = p
+	}
+	keys := append([]string(nil), st.keys...)
+	tailRows := make(map[string][]driver.Value, len(tail))
+	for _, row := range tail {
+		key, ok := row[len(row)-1].(string)
+		if !ok {
+			return fmt.Errorf("expected group key but got %T", row[len(row)-1])
+		}
+		if _, ok := st.groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		tailRows[key] = row[:len(row)-1]
+	}
+
+	// the groups changed anywhere so the table is rewritten
+	rows := make([][]driver.Value, 0, len(keys))
+	for _, key := range keys {
+		groupAggs, err := buildAggs(gb)
+		if err != nil {
+			return err
+		}
+		if partials, ok := st.groups[key]; ok {
+			row := make([]driver.Value, len(partials))
+			for i, agg := range partials {
+				row[i] = agg.Result()
+			}
+			mergePartial(sel.Columns, groupAggs, row)
+		}
+		if row, ok := tailRows[key]; ok {
+			mergePartial(sel.Columns, groupAggs, row)
+		}
+		row := make([]driver.Value, len(groupAggs))
+		for i, agg := range groupAggs {
+			row[i] = agg.Result()
+		}
+		rows = append(rows, row)
+	}
+	return writeView(ctx, v, viewTable(v.Name, proj, 0), rows, true)
+}
+
+// refreshViewRows append the rows from the watermark up to and including
+// to, skipping those at the watermark already written.  The append column
+// is selected last to tell which rows are at the watermark.
+func refreshViewRows(ctx *plan.Context, v *schema.View, sel *rel.SqlSelect, st *viewState, to driver.Value, full bool) error {
+	col := expr.IdentityMaybeQuote('`', v.Append)
+	mark := rel.NewColumn(v.Append)
+	mark.As = "__view_append"
+	if err := sel.AddColumn(*mark); err != nil {
+		return err
+	}
+	cond := "1 = 0"
+	if to != nil {
+		cond = fmt.Sprintf("%s <= %s", col, viewLiteral(to))
+		if st.watermark != nil {
+			cond = fmt.Sprintf("%s >= %s AND %s", col, viewLiteral(st.watermark), cond)
+		}
+	}
+	sql, err := viewDelta(sel, cond)
+	if err != nil {
+		return err
+	}
+	rows, proj, err := viewQuery(ctx, sql, false)
+	if err != nil {
+		return err
+	}
+
+	atMark := make(map[string]int)
+	out := rows[:0]
+	for _, row := range rows {
+		at, vals := viewLiteral(row[len(row)-1]), row[:len(row)-1]
+		key := viewRowKey(vals)
+		if to != nil && at == viewLiteral(to) {
+			atMark[key]++
+		}
+		if st.watermark != nil && at == viewLiteral(st.watermark) && st.atMark[key] > 0 {
+			// written by the last refresh
+			st.atMark[key]--
+			continue
+		}
+		out = append(out, vals)
+	}
+	if err := writeView(ctx, v, viewTable(v.Name, proj, 1), out, full); err != nil {
+		return err
+	}
+	st.atMark = atMark
+	return nil
+}
+
+// viewRowKey the values of a row as a string, to compare rows.
+func viewRowKey(vals []driver.Value) string {
+	var key bytes.Buffer
+	for _, v := range vals {
+		key.WriteString(viewLiteral(v))
+		key.WriteByte(0)
+	}
+	return key.String()
+}
+
+// checkViewPolicies refuse views over tables with row filters or masks, as
+// the rows of the view are shared by all its readers.
+func checkViewPolicies(s *schema.Schema, sel *rel.SqlSelect) error {
+	policies := s.Policies()
+	if len(policies) == 0 {
+		return nil
+	}
+	tables := make(map[string]bool)
+	if !statementTables(sel, tables) {
+		return fmt.Errorf("can not read tables of other schemas when there are policies")
+	}
+	for _, p := range policies {
+		if tables[p.Table] {
+			return fmt.Errorf("can not read table %q as it has policy %q", p.Table, p.Name)
+		}
+	}
+	return nil
+}
+
+// viewWatermark the last value of the append column of the source of the
+// view at or past from, nil if there is none.
+func viewWatermark(ctx *plan.Context, v *schema.View, sel *rel.SqlSelect, from driver.Value) (driver.Value, error) {
+	col := expr.IdentityMaybeQuote('`', v.Append)
+	sql := fmt.Sprintf("SELECT %s FROM %s", col, expr.IdentityMaybeQuote('`', sel.From[0].Name))
+	if from != nil {
+		sql += fmt.Sprintf(" WHERE %s >= %s", col, viewLiteral(from))
+	}
+	sql += fmt.Sprintf(" ORDER BY %s DESC LIMIT 1", col)
+	rows, _, err := viewQuery(ctx, sql, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, nil
+	}
+	return rows[0][0], nil
+}
+
+// viewDelta the select of the view limited to the rows matching cond on
+// its append column, sel is left unchanged.
+//
+//    SELECT user_id, count(*) AS ct FROM hits WHERE ts >= 10 AND ts < 20 GROUP BY user_id
+//
+func viewDelta(sel *rel.SqlSelect, cond string) (string, error) {
+	orig := sel.Where
+	defer func() { sel.Where = orig }()
+	if orig != nil && orig.Expr != nil {
+		cond = fmt.Sprintf("(%s) AND %s", orig.Expr, cond)
+	}
+	where, err := expr.ParseExpression(cond)
+	if err != nil {
+		// the literals and where of the view always parse
+		u.Errorf("could not parse %q err=%v", cond, err)
+		return "", err
+	}
+	sel.Where = &rel.SqlWhere{Expr: where}
+	return sel.String(), nil
+}
+
+func viewLiteral(v driver.Value) string {
+	switch vt := v.(type) {
+	case int, int32, int64, uint32, uint64, float32, float64:
+		return fmt.Sprintf("%v", vt)
+	case time.Time:
+		return strconv.Quote(vt.Format(time.RFC3339Nano))
+	}
+	return strconv.Quote(fmt.Sprintf("%v", v))
+}
+
+// viewQuery runs sql with the schema, session and user of ctx returning
+// its rows.  If partial the plan is cut after its group by, each row being
+// the partial aggregates of a group followed by its key.
+func viewQuery(ctx *plan.Context, sql string, partial bool) ([][]driver.Value, *plan.Projection, error) {
+
+	qctx := plan.NewContext(sql)
+	qctx.Schema = ctx.Schema
+	qctx.Session = ctx.Session
+	qctx.User = ctx.User
+	qctx.Observer = ctx.Observer
+
+	stmt, err := rel.ParseSqlResolver(sql, ctx.Schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	qctx.Stmt = stmt
+	planner := plan.NewPlanner(qctx)
+	pln, err := plan.WalkStmt(qctx, stmt, planner)
+	if err != nil {
+		return nil, nil, err
+	}
+	sel, ok := pln.(*plan.Select)
+	if !ok {
+		return nil, nil, fmt.Errorf("expected select plan but got %T", pln)
+	}
+	if partial {
+		sel = partialSelect(sel)
+	}
+
+	job := NewExecutor(qctx, planner)
+	root, err := job.WalkPlan(sel)
+	if err != nil {
+		return nil, nil, err
+	}
+	runner, ok := root.(TaskRunner)
+	if !ok {
+		return nil, nil, fmt.Errorf("Expected TaskRunner but was %T", root)
+	}
+	job.RootTask = runner
+
+	msgs := make([]schema.Message, 0)
+	if err = runner.Add(NewResultBuffer(qctx, &msgs)); err != nil {
+		return nil, nil, err
+	}
+	if err = job.Setup(); err != nil {
+		return nil, nil, err
+	}
+	err = job.Run()
+	job.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([][]driver.Value, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg == nil {
+			continue
+		}
+		sdm, ok := msg.(*datasource.SqlDriverMessageMap)
+		if !ok {
+			return nil, nil, fmt.Errorf("expected SqlDriverMessageMap but got %T", msg)
+		}
+		rows = append(rows, sdm.Values())
+	}
+	return rows, qctx.Projection, nil
+}
+
+// partialSelect the tasks of the select up to its group by, made partial.
+// A partitioned select already has partial group bys merged by its final
+// group by, which is dropped.
+func partialSelect(sel *plan.Select) *plan.Select {
+	cut := *sel
+	cut.PlanBase = plan.NewPlanBase(sel.IsParallel())
+	for _, t := range sel.Children() {
+		if gb, ok := t.(*plan.GroupBy); ok {
+			if !gb.Final {
+				partial := *gb
+				partial.Partial = true
+				cut.Add(&partial)
+			}
+			break
+		}
+		cut.Add(t)
+	}
+	return &cut
+}
+
+// viewTable the table of the view with the columns of its projection but
+// the last skip.
+func viewTable(name string, proj *plan.Projection, skip int) *schema.Table {
+	tbl := schema.NewTable(name)
+	if proj != nil && proj.Proj != nil && len(proj.Proj.Columns) >= skip {
+		for _, col := range proj.Proj.Columns[:len(proj.Proj.Columns)-skip] {
+			vt := col.Type
+			if vt == value.NilType {
+				vt = value.UnknownType
+			}
+			tbl.AddFieldType(col.As, vt)
+		}
+	}
+	tbl.SetColumnsFromFields()
+	return tbl
+}
+
+// writeView puts the rows in the table of the view, replacing the table
+// and its rows first if replace.  A replaced table is added to the schema
+// again as its columns may have changed.
+func writeView(ctx *plan.Context, v *schema.View, tbl *schema.Table, rows [][]driver.Value, replace bool) error {
+	if replace {
+		if v.Source == nil {
+			db, err := memdb.NewMemDb(v.Name, tbl.Columns())
+			if err != nil {
+				return err
+			}
+			v.Source = db
+		}
+		creator, ok := v.Source.(schema.SourceTableCreator)
+		if !ok {
+			return fmt.Errorf("source %T of view %q can not create tables", v.Source, v.Name)
+		}
+		if err := creator.CreateTable(tbl); err != nil {
+			return err
+		}
+	}
+	if len(rows) > 0 {
+		conn, err := v.Source.Open(v.Name)
+		if err != nil {
+			return err
+		}
+		upsert, ok := conn.(schema.ConnUpsert)
+		if !ok {
+			conn.Close()
+			return fmt.Errorf("source %T of view %q does not accept writes", v.Source, v.Name)
+		}
+		_, err = upsert.PutMulti(ctx.Context, nil, rows)
+		conn.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if replace {
+		return schema.DefaultRegistry().ViewAdd(ctx.Schema.Name, v)
+	}
+	return nil
+}
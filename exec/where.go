@@ -1,6 +1,8 @@
 package exec
 
 import (
+	"time"
+
 	u "github.com/araddon/gou"
 
 	"github.com/araddon/qlbridge/datasource"
@@ -12,6 +14,12 @@ import (
 	"github.com/araddon/qlbridge/vm"
 )
 
+var (
+	// Where filters batches of rows
+	_ BatchConsumer = (*Where)(nil)
+	_ BatchProducer = (*Where)(nil)
+)
+
 // Where execution of A filter to implement where clause
 type Where struct {
 	*TaskBase
@@ -59,7 +67,8 @@ func NewWhereFinal(ctx *plan.Context, p *plan.Where) *Where {
 
 	//u.Debugf("found where columns: %d", len(cols))
 
-	s.Handler = whereFilter(s.filter, s, cols)
+	s.Handler = whereFilter(ctx, s.filter, s, cols)
+	s.BatchHandler = whereBatchFilter(s.filter, s)
 	return s
 }
 
@@ -70,7 +79,8 @@ func NewWhereFilter(ctx *plan.Context, sql *rel.SqlSelect) *Where {
 		filter:   sql.Where.Expr,
 	}
 	cols := sql.ColIndexes()
-	s.Handler = whereFilter(s.filter, s, cols)
+	s.Handler = whereFilter(ctx, s.filter, s, cols)
+	s.BatchHandler = whereBatchFilter(s.filter, s)
 	return s
 }
 
@@ -80,13 +90,38 @@ func NewHaving(ctx *plan.Context, p *plan.Having) *Where {
 		TaskBase: NewTaskBase(ctx),
 		filter:   p.Stmt.Having,
 	}
-	s.Handler = whereFilter(p.Stmt.Having, s, p.Stmt.ColIndexes())
+	s.Handler = whereFilter(ctx, p.Stmt.Having, s, p.Stmt.ColIndexes())
+	s.BatchHandler = whereBatchFilter(p.Stmt.Having, s)
 	return s
 }
 
-func whereFilter(filter expr.Node, task TaskRunner, cols map[string]int) MessageHandler {
+// BatchIn where accepts batches.
+func (m *Where) BatchIn() bool { return m.BatchHandler != nil }
+
+// SetBatchOut send the filtered batches downstream instead of rows.
+func (m *Where) SetBatchOut(enabled bool) { m.batchOut = enabled }
+
+func whereFilter(pctx *plan.Context, filter expr.Node, task TaskRunner, cols map[string]int) MessageHandler {
 	out := task.MessageOut()
 
+	// compile the filter once instead of walking it for every message
+	eval := func(ctx expr.EvalContext) (value.Value, bool) {
+		return vm.Eval(ctx, filter)
+	}
+	if prog, err := vm.Compile(filter); err == nil {
+		eval = prog.Eval
+	} else {
+		u.Debugf("could not compile filter, using eval %s err=%v", filter, err)
+	}
+	if session := zonedSession(pctx); session != nil {
+		// time functions and string to time comparisons read the session
+		// time zone so the session is nested under each message
+		msgEval := eval
+		eval = func(ctx expr.EvalContext) (value.Value, bool) {
+			return msgEval(datasource.NewNestedContextReader([]expr.ContextReader{ctx, session}, ctx.Ts()))
+		}
+	}
+
 	//u.Debugf("prepare filter %s", filter)
 	return func(ctx *plan.Context, msg schema.Message) bool {
 
@@ -98,9 +133,9 @@ func whereFilter(filter expr.Node, task TaskRunner, cols map[string]int) Message
 			//u.Debugf("WHERE:  T:%T  vals:%#v", msg, mt.Vals)
 			//u.Debugf("cols:  %#v", cols)
 			msgReader := mt.ToMsgMap(cols)
-			filterValue, ok = vm.Eval(msgReader, filter)
+			filterValue, ok = eval(msgReader)
 		case *datasource.SqlDriverMessageMap:
-			filterValue, ok = vm.Eval(mt, filter)
+			filterValue, ok = eval(mt)
 			if !ok {
 				u.Warnf("wtf %s    %#v", filter, mt)
 			}
@@ -108,7 +143,7 @@ func whereFilter(filter expr.Node, task TaskRunner, cols map[string]int) Message
 			//u.Debugf("cols:  %#v", cols)
 		default:
 			if msgReader, isContextReader := msg.(expr.ContextReader); isContextReader {
-				filterValue, ok = vm.Eval(msgReader, filter)
+				filterValue, ok = eval(msgReader)
 				if !ok {
 					u.Warnf("wat? %v  filterval:%#v expr: %s", filter.String(), filterValue, filter)
 				}
@@ -145,3 +180,55 @@ func whereFilter(filter expr.Node, task TaskRunner, cols map[string]int) Message
 		}
 	}
 }
+
+// whereBatchFilter filters a batch with vectorized kernels, narrowing the
+// selection vector.  Returns nil if the filter can't be run on batches.
+func whereBatchFilter(filter expr.Node, task *Where) BatchHandler {
+	if zonedSession(task.Ctx) != nil {
+		// batch kernels don't read the session time zone
+		return nil
+	}
+	kernel, err := newBatchFilter(filter)
+	if err != nil {
+		u.Debugf("could not create batch filter %s err=%v", filter, err)
+		return nil
+	}
+	out := task.MessageOut()
+	return func(ctx *plan.Context, b *datasource.Batch) bool {
+
+		sel := kernel(b, b.Selected(), datasource.NewBatchRow(b))
+		if len(sel) == 0 {
+			return true
+		}
+		b.Sel = sel
+
+		if task.batchOut {
+			select {
+			case out <- b:
+				return true
+			case <-task.SigChan():
+				return false
+			}
+		}
+		for _, msg := range b.Messages() {
+			select {
+			case out <- msg:
+			case <-task.SigChan():
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// zonedSession the session if it has a time zone other than UTC, which
+// strings without a zone are already read as.
+func zonedSession(ctx *plan.Context) expr.ContextReader {
+	if ctx == nil || ctx.Session == nil {
+		return nil
+	}
+	if loc := expr.SessionLocation(ctx.Session); loc == nil || loc == time.UTC {
+		return nil
+	}
+	return ctx.Session
+}
@@ -14,6 +14,8 @@ import (
 //    avg(1,2,3) => 2.0, true
 //    avg("hello") => math.NaN, false
 //
+// Decimal args (mixed with ints) are averaged exactly.
+//
 type Avg struct{}
 
 // Type is NumberType
@@ -27,6 +29,13 @@ func (m *Avg) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
 func (m *Avg) IsAgg() bool { return true }
 
 func avgEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	if dv, ok := sumDecimals(vals); ok {
+		if len(vals) == 1 {
+			return dv, true
+		}
+		q, err := dv.Quo(value.NewDecimalValueInt(int64(len(vals))))
+		return q, err == nil
+	}
 	avg := float64(0)
 	ct := 0
 	for _, val := range vals {
@@ -71,6 +80,8 @@ func avgEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
 //   sum(1, 2, 3) => 6
 //   sum(1, "horse", 3) => nan, false
 //
+// Decimal args (mixed with ints) are summed exactly.
+//
 type Sum struct{}
 
 // Type is number
@@ -87,6 +98,9 @@ func (m *Sum) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
 }
 
 func sumEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+	if dv, ok := sumDecimals(vals); ok {
+		return dv, true
+	}
 
 	sumval := float64(0)
 	for _, val := range vals {
@@ -155,3 +169,25 @@ func incrementEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool)
 	}
 	return value.NewIntValue(1), true
 }
+
+// sumDecimals exact sum of decimal args, only if there is at least one
+// decimal and the rest are ints.
+func sumDecimals(vals []value.Value) (value.DecimalValue, bool) {
+	sum := value.NewDecimalValueInt(0)
+	hasDecimal := false
+	for _, val := range vals {
+		switch v := val.(type) {
+		case value.DecimalValue:
+			if v.Nil() {
+				return sum, false
+			}
+			sum = sum.Add(v)
+			hasDecimal = true
+		case value.IntValue:
+			sum = sum.Add(value.NewDecimalValueInt(v.Val()))
+		default:
+			return sum, false
+		}
+	}
+	return sum, hasDecimal
+}
@@ -62,6 +62,9 @@ func LoadAllBuiltins() {
 		expr.FuncAdd("extract", &StrFromTime{})
 		expr.FuncAdd("strftime", &StrFromTime{})
 		expr.FuncAdd("unixtrunc", &TimeTrunc{})
+		expr.FuncAdd("date_trunc", &DateTrunc{})
+		expr.FuncAdd("date_add", &DateAdd{})
+		expr.FuncAdd("date_diff", &DateDiff{})
 
 		// Casting and Type Coercion
 		expr.FuncAdd("tostring", &ToString{})
@@ -89,6 +92,8 @@ func LoadAllBuiltins() {
 
 		// selection
 		expr.FuncAdd("oneof", &OneOf{})
+		expr.FuncAdd("coalesce", &Coalesce{})
+		expr.FuncAdd("nullif", &NullIf{})
 		expr.FuncAdd("match", &Match{})
 		expr.FuncAdd("mapkeys", &MapKeys{})
 		expr.FuncAdd("mapvalues", &MapValues{})
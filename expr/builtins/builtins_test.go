@@ -722,6 +722,14 @@ var builtinTests = []testBuiltins{
 	{`unixtrunc(reg_date,Address)`, value.ErrValue},
 	{`unixtrunc(reg_date,"not-valid")`, value.ErrValue},
 
+	{`date_trunc("month", reg_date)`, value.NewTimeValue(time.Date(2014, 10, 1, 0, 0, 0, 0, time.UTC))},
+	{`date_trunc("month", "hello")`, value.ErrValue},
+	{`date_trunc(Address, reg_date)`, value.ErrValue},
+	{`date_add(reg_date, "hello")`, value.ErrValue},
+	{`date_add(reg_date, 3, "fortnights")`, value.ErrValue},
+	{`date_diff("day", reg_date, "2014-10-20")`, value.NewIntValue(7)},
+	{`date_diff("day", reg_date, "hello")`, value.ErrValue},
+
 	// Math
 	{`pow(5,2)`, value.NewNumberValue(25)},
 	{`pow(2,2)`, value.NewNumberValue(4)},
@@ -790,6 +798,7 @@ var testValidation = []string{
 	`tostring()`, `tostring(a,b)`, // must be 1 arg
 	`tonumber()`, `tonumber(a,b)`, // must be 1 arg
 	`todate()`, `tonumber(a,b,c)`, // must be 1,2 args
+	`date_trunc("fortnight", reg_date)`, `date_add(reg_date)`, `date_diff("day", reg_date)`,
 	`todatein("now-3d","hello")`, `todatein()`, `todatein(date_field)`, // must be 2 args
 	`todatein("now-3days","America/Los_Angeles")`, // can't parse now-3days
 
@@ -944,6 +953,72 @@ func TestValidation(t *testing.T) {
 	}
 }
 
+func TestDateFuncs(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	assert.Equal(t, nil, err)
+	// 03:30 utc on the 13th is 23:30 on the 12th in new york
+	ts := time.Date(2017, 3, 13, 3, 30, 0, 0, time.UTC)
+	session := datasource.NewContextSimpleNative(map[string]interface{}{
+		"@@time_zone": "America/New_York",
+	})
+	utc := datasource.NewContextSimple()
+	row := datasource.NewContextSimpleNative(map[string]interface{}{
+		"ts":  ts,
+		"day": value.NewDateValue(ts),
+	})
+	for _, tc := range []struct {
+		expr    string
+		session expr.ContextReader
+		out     value.Value
+	}{
+		{`date_trunc("day", ts)`, utc, value.NewTimeValue(time.Date(2017, 3, 13, 0, 0, 0, 0, time.UTC))},
+		{`date_trunc("day", ts)`, session, value.NewTimeValue(time.Date(2017, 3, 12, 0, 0, 0, 0, ny))},
+		{`date_trunc("week", ts)`, session, value.NewTimeValue(time.Date(2017, 3, 6, 0, 0, 0, 0, ny))},
+		{`date_trunc("quarter", ts, "UTC")`, session, value.NewTimeValue(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC))},
+		{`date_trunc("hour", ts, "+05:30")`, utc, value.NewTimeValue(time.Date(2017, 3, 13, 9, 0, 0, 0, time.FixedZone("+05:30", 19800)))},
+		{`date_trunc("month", day)`, session, value.NewDateValue(time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC))},
+		// month ends are clamped, days keep the wall clock across the dst change (mar 12)
+		{`date_add("2017-01-31", INTERVAL 1 MONTH)`, utc, value.NewTimeValue(time.Date(2017, 2, 28, 0, 0, 0, 0, time.UTC))},
+		{`date_add(day, 13, "months")`, utc, value.NewDateValue(time.Date(2018, 4, 13, 0, 0, 0, 0, time.UTC))},
+		{`date_add(ts, INTERVAL '-2' DAY)`, session, value.NewTimeValue(time.Date(2017, 3, 10, 23, 30, 0, 0, ny))},
+		{`date_diff("day", "2017-03-01 23:00", "2017-03-02 01:00")`, utc, value.NewIntValue(1)},
+		{`date_diff("day", ts, "2017-03-13 12:00")`, session, value.NewIntValue(1)},
+		{`date_diff("day", ts, "2017-03-13 12:00")`, utc, value.NewIntValue(0)},
+		{`date_diff("month", "2017-01-31", "2017-02-01")`, utc, value.NewIntValue(1)},
+		{`date_diff("hour", ts, "2017-03-12 03:30")`, utc, value.NewIntValue(-24)},
+		{`date_diff("year", "2016-12-31", ts)`, utc, value.NewIntValue(1)},
+		{`date_diff("week", "2017-03-05", ts)`, utc, value.NewIntValue(2)},
+		// extraction, formatting and parsing in the session time zone
+		{`hourofday(ts)`, utc, value.NewIntValue(3)},
+		{`hourofday(ts)`, session, value.NewIntValue(23)},
+		{`dayofweek(ts)`, session, value.NewIntValue(0)},
+		{`yymm(ts)`, session, value.NewStringValue("1703")},
+		{`mm("2017-04-01 02:00:00")`, session, value.NewIntValue(4)},
+		{`strftime(ts, "%d %H:%M")`, session, value.NewStringValue("12 23:30")},
+		{`totimestamp("2017-03-12 23:30:00")`, session, value.NewIntValue(ts.Unix())},
+		{`todate("2017-03-12 23:30")`, session, value.NewTimeValue(ts.In(ny))},
+		{`todate("2006-01-02 15:04", "2017-03-12 23:30")`, session, value.NewTimeValue(ts.In(ny))},
+		// AT TIME ZONE overrides the session time zone
+		{`hourofday(ts AT TIME ZONE "UTC")`, session, value.NewIntValue(3)},
+		{`hourofday(ts AT TIME ZONE "+05:30")`, session, value.NewIntValue(9)},
+		{`strftime("2017-03-12 23:30" AT TIME ZONE "America/New_York", "%H")`, utc, value.NewStringValue("23")},
+		{`"2017-03-12 23:30" AT TIME ZONE "America/New_York"`, utc, value.NewTimeValue(ts.In(ny))},
+		{`ts AT TIME ZONE "America/New_York"`, utc, value.NewTimeValue(ts.In(ny))},
+	} {
+		n, err := expr.ParseExpression(tc.expr)
+		assert.Equal(t, nil, err, tc.expr)
+		ctx := datasource.NewNestedContextReader([]expr.ContextReader{row, tc.session}, ts)
+		val, ok := vm.Eval(ctx, n)
+		assert.True(t, ok, tc.expr)
+		if tv, isTime := tc.out.(value.TimeValue); isTime {
+			assert.True(t, tv.Val().Equal(val.(value.TimeValue).Val()), "%s: %v != %v", tc.expr, tv.Val(), val.Value())
+			assert.Equal(t, tv.Val().Format(time.RFC3339), val.(value.TimeValue).Val().Format(time.RFC3339), tc.expr)
+		} else {
+			assert.Equal(t, tc.out, val, tc.expr)
+		}
+	}
+}
+
 func TestBuiltins(t *testing.T) {
 
 	t1 := dateparse.MustParse("12/18/2015")
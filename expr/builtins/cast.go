@@ -36,7 +36,7 @@ func toStringEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool)
 //    cast(identity AS <type>) => 5.0
 //    cast(reg_date AS string) => "2014/01/12"
 //
-// Types:  [char, string, int, float]
+// Types:  [char, string, int, float, decimal, date, interval]
 //
 type Cast struct{}
 
@@ -62,6 +62,8 @@ func castEvalNoAs(ctx expr.EvalContext, vals []value.Value) (value.Value, bool)
 		switch strings.ToLower(vals[1].ToString()) {
 		case "char":
 			vt = value.ByteSliceType
+		case "numeric":
+			vt = value.DecimalType
 		default:
 			return nil, false
 		}
@@ -90,6 +92,8 @@ func castEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
 		switch strings.ToLower(vals[2].ToString()) {
 		case "char":
 			vt = value.ByteSliceType
+		case "numeric":
+			vt = value.DecimalType
 		default:
 			return nil, false
 		}
@@ -33,6 +33,59 @@ func oneOfEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
 	return value.NilValueVal, false
 }
 
+// Coalesce choose the first non-NULL argument, unlike oneof empty
+// values such as "" or 0 are not NULL
+//
+//    coalesce(not_a_field, "", "hello") => ""
+//    coalesce(not_a_field, null)        => NULL
+//
+type Coalesce struct{}
+
+// Type unknown
+func (m *Coalesce) Type() value.ValueType { return value.UnknownType }
+func (m *Coalesce) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 1 {
+		return nil, fmt.Errorf("Expected 1 or more args for Coalesce(arg, arg, ...) but got %s", n)
+	}
+	return coalesceEval, nil
+}
+func coalesceEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	for _, v := range args {
+		if v != nil && v.Type() != value.NilType {
+			return v, true
+		}
+	}
+	return value.NilValueVal, true
+}
+
+// NullIf returns NULL if both arguments are equal, else the first
+//
+//    nullif("a", "a") => NULL
+//    nullif("a", "b") => "a"
+//
+type NullIf struct{}
+
+// Type unknown
+func (m *NullIf) Type() value.ValueType { return value.UnknownType }
+func (m *NullIf) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("Expected exactly 2 args for NullIf(arg, arg) but got %s", n)
+	}
+	return nullIfEval, nil
+}
+func nullIfEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	a, b := args[0], args[1]
+	if a == nil || a.Type() == value.NilType {
+		return value.NilValueVal, true
+	}
+	if b != nil && b.Type() != value.NilType {
+		if eq, err := value.Equal(a, b); err == nil && eq {
+			return value.NilValueVal, true
+		}
+	}
+	return a, true
+}
+
 // FilterFromArgs given set of values
 func FiltersFromArgs(filterVals []value.Value) []string {
 	filters := make([]string, 0, len(filterVals))
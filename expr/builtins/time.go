@@ -57,21 +57,13 @@ func yearEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
 
 	yy := 0
 	if len(vals) == 0 {
-		if ctx != nil && !ctx.Ts().IsZero() {
-			yy = ctx.Ts().Year()
-		} else {
-			yy = time.Now().Year()
-		}
+		yy = contextTime(ctx).Year()
 	} else if len(vals) == 1 {
-		dateStr, ok := value.ValueToString(vals[0])
+		t, ok := sessionTime(ctx, vals[0])
 		if !ok {
 			return value.NewIntValue(0), false
 		}
-		if t, err := dateparse.ParseAny(dateStr); err != nil {
-			return value.NewIntValue(0), false
-		} else {
-			yy = t.Year()
-		}
+		yy = t.Year()
 	}
 
 	if yy >= 2000 {
@@ -103,18 +95,10 @@ func (m *Mm) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
 func monthEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
 
 	if len(vals) == 0 {
-		if ctx != nil && !ctx.Ts().IsZero() {
-			t := ctx.Ts()
-			return value.NewIntValue(int64(t.Month())), true
-		}
-		return value.NewIntValue(int64(time.Now().Month())), true
+		return value.NewIntValue(int64(contextTime(ctx).Month())), true
 	}
 
-	dateStr, ok := value.ValueToString(vals[0])
-	if !ok {
-		return value.NewIntValue(0), false
-	}
-	if t, err := dateparse.ParseAny(dateStr); err == nil {
+	if t, ok := sessionTime(ctx, vals[0]); ok {
 		return value.NewIntValue(int64(t.Month())), true
 	}
 
@@ -139,25 +123,17 @@ func (m *YyMm) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
 func yymmEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
 
 	if len(vals) == 0 {
-		if ctx != nil && !ctx.Ts().IsZero() {
-			t := ctx.Ts()
-			return value.NewStringValue(t.Format(yymmTimeLayout)), true
-		}
-		return value.NewStringValue(time.Now().Format(yymmTimeLayout)), true
+		return value.NewStringValue(contextTime(ctx).Format(yymmTimeLayout)), true
 	}
 
-	dateStr, ok := value.ValueToString(vals[0])
-	if !ok {
-		return value.EmptyStringValue, false
-	}
-	if t, err := dateparse.ParseAny(dateStr); err == nil {
+	if t, ok := sessionTime(ctx, vals[0]); ok {
 		return value.NewStringValue(t.Format(yymmTimeLayout)), true
 	}
 
 	return value.EmptyStringValue, false
 }
 
-// DayOfWeek day of week [0-6]
+// DayOfWeek day of week [0-6] in the session time zone
 //
 //   dayofweek() => 3, true
 //   dayofweek("2016/07/04") => 5, true
@@ -175,21 +151,10 @@ func (m *DayOfWeek) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
 
 func dayOfWeekEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
 
-	var t time.Time
-	if len(vals) == 0 {
-		if ctx != nil && !ctx.Ts().IsZero() {
-			t = ctx.Ts()
-		} else {
-			t = time.Now()
-		}
-	} else {
-		dateStr, ok := value.ValueToString(vals[0])
-		if !ok {
-			return value.NewIntNil(), false
-		}
-		var err error
-		t, err = dateparse.ParseAny(dateStr)
-		if err != nil {
+	t := contextTime(ctx)
+	if len(vals) > 0 {
+		var ok bool
+		if t, ok = sessionTime(ctx, vals[0]); !ok {
 			return value.NewIntNil(), false
 		}
 	}
@@ -212,19 +177,9 @@ func hourOfWeekEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool
 
 	var t time.Time
 	if len(vals) == 0 {
-		if ctx != nil && !ctx.Ts().IsZero() {
-			t = ctx.Ts()
-		} else {
-			t = time.Now()
-		}
-	} else {
-		dateStr, ok := value.ValueToString(vals[0])
-		if !ok {
-			return value.NewIntValue(0), false
-		}
-		if t2, err := dateparse.ParseAny(dateStr); err == nil {
-			t = t2
-		}
+		t = contextTime(ctx)
+	} else if t2, ok := sessionTime(ctx, vals[0]); ok {
+		t = t2
 	}
 	if !t.IsZero() {
 		return value.NewIntValue(int64(t.Weekday()*24) + int64(t.Hour())), true
@@ -233,7 +188,7 @@ func hourOfWeekEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool
 	return value.NewIntValue(0), false
 }
 
-// hourofday hour of day [0-23]
+// hourofday hour of day [0-23] in the session time zone
 //
 //    hourofday(field)
 //    hourofday()  // Uses message time
@@ -251,16 +206,9 @@ func (m *HourOfDay) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
 func hourOfDayEval(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
 
 	if len(vals) == 0 {
-		if ctx != nil && !ctx.Ts().IsZero() {
-			return value.NewIntValue(int64(ctx.Ts().Hour())), true
-		}
-		return value.NewIntValue(int64(time.Now().Hour())), true
-	}
-	dateStr, ok := value.ValueToString(vals[0])
-	if !ok {
-		return value.NewIntValue(0), false
+		return value.NewIntValue(int64(contextTime(ctx).Hour())), true
 	}
-	if t, err := dateparse.ParseAny(dateStr); err == nil {
+	if t, ok := sessionTime(ctx, vals[0]); ok {
 		return value.NewIntValue(int64(t.Hour())), true
 	}
 
@@ -283,11 +231,7 @@ func (m *ToTimestamp) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
 	return toTimestampEval, nil
 }
 func toTimestampEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
-	dateStr, ok := value.ValueToString(args[0])
-	if !ok {
-		return value.NewIntValue(0), false
-	}
-	if t, err := dateparse.ParseAny(dateStr); err == nil {
+	if t, ok := sessionTime(ctx, args[0]); ok {
 		return value.NewIntValue(int64(t.Unix())), true
 	}
 	return value.NewIntValue(0), false
@@ -316,6 +260,7 @@ func (m *ToDate) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
 }
 func toDateEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
 
+	loc := expr.SessionLocation(ctx)
 	if len(args) == 1 {
 		dateStr, ok := value.ValueToString(args[0])
 		if !ok {
@@ -325,10 +270,10 @@ func toDateEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
 		if len(dateStr) > 3 && strings.ToLower(dateStr[:3]) == "now" {
 			// Is date math
 			if t, err := datemath.Eval(dateStr[3:]); err == nil {
-				return value.NewTimeValue(t), true
+				return value.NewTimeValue(value.TimeIn(t, loc)), true
 			}
 		} else {
-			if t, err := dateparse.ParseAny(dateStr); err == nil {
+			if t, ok := value.StringToTimeIn(dateStr, time.Now(), loc); ok {
 				return value.NewTimeValue(t), true
 			}
 		}
@@ -345,8 +290,11 @@ func toDateEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
 			return value.TimeZeroValue, false
 		}
 
+		if loc == nil {
+			loc = time.UTC
+		}
 		//u.Infof("hello  layout=%v  time=%v", formatStr, dateStr)
-		if t, err := time.Parse(formatStr, dateStr); err == nil {
+		if t, err := time.ParseInLocation(formatStr, dateStr, loc); err == nil {
 			return value.NewTimeValue(t), true
 		}
 	}
@@ -518,9 +466,9 @@ func timeTruncEvalOne(ctx expr.EvalContext, args []value.Value) (value.Value, bo
 		// If the value is of type "TimeValue", return the Unix representation.
 		return value.NewStringValue(fmt.Sprintf("%d", v.Time().Unix())), true
 	default:
-		// Otherwise use date parse any
-		t, err := dateparse.ParseAny(v.ToString())
-		if err != nil {
+		// Otherwise use date parse, naive strings in session time zone
+		t, ok := sessionTime(ctx, value.NewStringValue(v.ToString()))
+		if !ok {
 			return value.NewStringValue(""), false
 		}
 		return value.NewStringValue(fmt.Sprintf("%d", t.Unix())), true
@@ -535,7 +483,7 @@ func timeTruncEvalTwo(ctx expr.EvalContext, args []value.Value) (value.Value, bo
 		valTs = itemT.Int()
 	default:
 		// If not a TimeValue, convert to a TimeValue and get Unix w/ milliseconds.
-		t, ok := value.ValueToTime(args[0])
+		t, ok := sessionTime(ctx, args[0])
 		if !ok || t.IsZero() {
 			return value.NewStringValue(""), false
 		}
@@ -571,6 +519,8 @@ func timeTruncEvalTwo(ctx expr.EvalContext, args []value.Value) (value.Value, bo
 //    strftime("2015/07/04", "%B")      => "July"
 //    strftime("2015/07/04", "%B:%d")   => "July:4"
 //    strftime("1257894000", "%p")      => "PM"
+//    strftime(ts AT TIME ZONE "America/Denver", "%H")
+//
 type StrFromTime struct{}
 
 // Type string
@@ -587,7 +537,7 @@ func strFromTimeEval(ctx expr.EvalContext, args []value.Value) (value.Value, boo
 	// if we have 2 items, the first is the time string
 	// and the second is the format string.
 	// Use leekchan/timeutil package
-	dateStr, ok := value.ValueToString(args[0])
+	t, ok := sessionTime(ctx, args[0])
 	if !ok {
 		return value.EmptyStringValue, false
 	}
@@ -597,11 +547,231 @@ func strFromTimeEval(ctx expr.EvalContext, args []value.Value) (value.Value, boo
 		return value.EmptyStringValue, false
 	}
 
-	t, err := dateparse.ParseAny(dateStr)
-	if err != nil {
-		return value.EmptyStringValue, false
-	}
-
 	formatted := timeutil.Strftime(&t, formatStr)
 	return value.NewStringValue(formatted), true
 }
+
+// DateTrunc truncate a time to the start of the unit (second, minute, hour,
+// day, week, month, quarter, year) in the session time zone, or the optional
+// time zone argument. Weeks start on monday.
+//
+//    date_trunc("month", "2017-03-17 10:30:00")                    => 2017-03-01 00:00:00
+//    date_trunc("day", "2017-03-17 03:30:00", "America/New_York")  => 2017-03-16 00:00:00 -0400
+//
+type DateTrunc struct{}
+
+// Type time
+func (m *DateTrunc) Type() value.ValueType { return value.TimeType }
+func (m *DateTrunc) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 2 || len(n.Args) > 3 {
+		return nil, fmt.Errorf(`Expected 2 or 3 args for date_trunc(unit, field [, timezone]) but got %s`, n)
+	}
+	if sn, ok := n.Args[0].(*expr.StringNode); ok && !isTruncUnit(sn.Text) {
+		return nil, fmt.Errorf("Invalid unit %q for date_trunc", sn.Text)
+	}
+	return dateTruncEval, nil
+}
+func dateTruncEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	unit, ok := value.ValueToString(args[0])
+	if !ok || !isTruncUnit(unit) {
+		return value.TimeZeroValue, false
+	}
+	if _, isDate := args[1].(value.DateValue); isDate {
+		t, _ := value.ValueToTime(args[1])
+		return value.NewDateValue(truncTime(t, unit)), true
+	}
+	if len(args) == 3 {
+		loc, ok := value.LoadLocation(args[2].ToString())
+		if !ok {
+			return value.TimeZeroValue, false
+		}
+		t, ok := value.ValueToTime(args[1])
+		if !ok || t.IsZero() {
+			return value.TimeZeroValue, false
+		}
+		return value.NewTimeValue(truncTime(t.In(loc), unit)), true
+	}
+	t, ok := sessionTime(ctx, args[1])
+	if !ok || t.IsZero() {
+		return value.TimeZeroValue, false
+	}
+	return value.NewTimeValue(truncTime(t, unit)), true
+}
+
+// DateAdd add an interval to a time, months and days are added to the
+// calendar date in the session time zone and month ends are clamped.
+//
+//    date_add("2017-01-31", INTERVAL 1 MONTH)  => 2017-02-28
+//    date_add(ts, 3, "day")
+//
+type DateAdd struct{}
+
+// Type time
+func (m *DateAdd) Type() value.ValueType { return value.TimeType }
+func (m *DateAdd) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) < 2 || len(n.Args) > 3 {
+		return nil, fmt.Errorf(`Expected 2 or 3 args for date_add(field, interval) or date_add(field, n, unit) but got %s`, n)
+	}
+	return dateAddEval, nil
+}
+func dateAddEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	_, isDate := args[0].(value.DateValue)
+	var t time.Time
+	var ok bool
+	if isDate {
+		t, ok = value.ValueToTime(args[0])
+	} else {
+		t, ok = sessionTime(ctx, args[0])
+	}
+	if !ok || t.IsZero() {
+		return value.TimeZeroValue, false
+	}
+	var iv value.IntervalValue
+	if len(args) == 3 {
+		n, ok := value.ValueToInt64(args[1])
+		if !ok {
+			return value.TimeZeroValue, false
+		}
+		unit, ok := value.IntervalUnit(args[2].ToString())
+		if !ok {
+			return value.TimeZeroValue, false
+		}
+		iv = unit.Mul(n)
+	} else if iv, ok = value.ValueToInterval(args[1]); !ok {
+		return value.TimeZeroValue, false
+	}
+	if isDate && iv.Fixed() == 0 {
+		return value.NewDateValue(iv.AddTo(t)), true
+	}
+	return value.NewTimeValue(iv.AddTo(value.TimeIn(t, sessionLocation(ctx)))), true
+}
+
+// DateDiff number of unit boundaries crossed going from start to end in
+// the session time zone, negative if end is before start.
+//
+//    date_diff("month", "2017-01-31", "2017-02-01")  => 1
+//    date_diff("day", "2017-03-01 23:00", "2017-03-02 01:00")  => 1
+//
+type DateDiff struct{}
+
+// Type int
+func (m *DateDiff) Type() value.ValueType { return value.IntType }
+func (m *DateDiff) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != 3 {
+		return nil, fmt.Errorf(`Expected 3 args for date_diff(unit, start, end) but got %s`, n)
+	}
+	if sn, ok := n.Args[0].(*expr.StringNode); ok && !isTruncUnit(sn.Text) {
+		return nil, fmt.Errorf("Invalid unit %q for date_diff", sn.Text)
+	}
+	return dateDiffEval, nil
+}
+func dateDiffEval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	unit, ok := value.ValueToString(args[0])
+	if !ok || !isTruncUnit(unit) {
+		return value.NewIntValue(0), false
+	}
+	loc := sessionLocation(ctx)
+	start, ok := value.ValueToTimeIn(args[1], time.Now(), loc)
+	if !ok || start.IsZero() {
+		return value.NewIntValue(0), false
+	}
+	end, ok := value.ValueToTimeIn(args[2], time.Now(), loc)
+	if !ok || end.IsZero() {
+		return value.NewIntValue(0), false
+	}
+	a, b := truncTime(start, unit), truncTime(end, unit)
+	switch truncUnit(unit) {
+	case "second":
+		return value.NewIntValue(int64(b.Sub(a) / time.Second)), true
+	case "minute":
+		return value.NewIntValue(int64(b.Sub(a) / time.Minute)), true
+	case "hour":
+		return value.NewIntValue(int64(b.Sub(a) / time.Hour)), true
+	case "day":
+		return value.NewIntValue(int64(civilDays(a, b))), true
+	case "week":
+		return value.NewIntValue(int64(civilDays(a, b) / 7)), true
+	case "month":
+		return value.NewIntValue(int64(monthsOf(b) - monthsOf(a))), true
+	case "quarter":
+		return value.NewIntValue(int64((monthsOf(b) - monthsOf(a)) / 3)), true
+	case "year":
+		return value.NewIntValue(int64(b.Year() - a.Year())), true
+	}
+	return value.NewIntValue(0), false
+}
+
+func truncUnit(unit string) string {
+	return strings.TrimSuffix(strings.ToLower(unit), "s")
+}
+
+func isTruncUnit(unit string) bool {
+	switch truncUnit(unit) {
+	case "second", "minute", "hour", "day", "week", "month", "quarter", "year":
+		return true
+	}
+	return false
+}
+
+// truncTime truncate t to start of unit in t's location
+func truncTime(t time.Time, unit string) time.Time {
+	y, mo, d := t.Date()
+	switch truncUnit(unit) {
+	case "second":
+		return time.Date(y, mo, d, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	case "minute":
+		return time.Date(y, mo, d, t.Hour(), t.Minute(), 0, 0, t.Location())
+	case "hour":
+		return time.Date(y, mo, d, t.Hour(), 0, 0, 0, t.Location())
+	case "day":
+		return time.Date(y, mo, d, 0, 0, 0, 0, t.Location())
+	case "week":
+		return time.Date(y, mo, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	case "month":
+		return time.Date(y, mo, 1, 0, 0, 0, 0, t.Location())
+	case "quarter":
+		return time.Date(y, mo-(mo-1)%3, 1, 0, 0, 0, 0, t.Location())
+	case "year":
+		return time.Date(y, 1, 1, 0, 0, 0, 0, t.Location())
+	}
+	return t
+}
+
+// civilDays days between the calendar dates of a and b, ignoring
+// daylight savings changes in between
+func civilDays(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	days := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC).Sub(time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC))
+	return int(days / (24 * time.Hour))
+}
+
+func monthsOf(t time.Time) int {
+	return t.Year()*12 + int(t.Month()) - 1
+}
+
+// sessionLocation the session time zone of the context, defaults to UTC.
+func sessionLocation(ctx expr.EvalContext) *time.Location {
+	if loc := expr.SessionLocation(ctx); loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// sessionTime convert a value to time in the session time zone, if the
+// context has one.  Strings without a zone are read as session wall clock.
+func sessionTime(ctx expr.EvalContext, v value.Value) (time.Time, bool) {
+	return value.ValueToTimeIn(v, time.Now(), expr.SessionLocation(ctx))
+}
+
+// contextTime the message time, or now, in the session time zone.
+func contextTime(ctx expr.EvalContext) time.Time {
+	if ctx == nil {
+		return time.Now()
+	}
+	t := ctx.Ts()
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return value.TimeIn(t, expr.SessionLocation(ctx))
+}
@@ -1,15 +1,24 @@
 package expr
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"strings"
 	"sync"
 
+	u "github.com/araddon/gou"
+
 	"github.com/araddon/qlbridge/value"
 )
 
 var (
 	// The global function registry
 	funcReg = NewFuncRegistry()
+
+	// loaders of external functions by language
+	funcLoaderMu sync.RWMutex
+	funcLoaders  = make(map[string]FuncLoader)
 )
 
 type (
@@ -33,6 +42,42 @@ type (
 	FuncResolver interface {
 		FuncGet(name string) (Func, bool)
 	}
+	// FuncChecker is optionally implemented by a FuncResolver to say if
+	// functions it can't resolve are parse errors, by default they are.
+	FuncChecker interface {
+		FuncCheck() bool
+	}
+
+	// UserFunc is a function declared in SQL instead of go, its body is an
+	// expression over the named parameters.  It is evaluated by the vm with
+	// the parameters bound to the argument values.
+	//
+	//    CREATE FUNCTION domain_of(url) AS lower(domain(url))
+	//
+	//
+	// A function declared with a LANGUAGE instead of a body is external, it
+	// has a declared signature and its implementation is provided by the
+	// FuncLoader registered for the language.
+	//
+	//    CREATE FUNCTION clean_sku(string) RETURNS string LANGUAGE wasm FROM 'sku.wasm'
+	//
+	UserFunc struct {
+		Name   string
+		Params []string
+		Body   Node
+
+		Args     []value.ValueType // declared argument types of external function
+		Returns  value.ValueType   // declared return type of external function
+		Language string            // language of external function, ie wasm
+		From     string            // FROM 'path' of external function
+		With     u.JsonHelper      // WITH options of external function
+		Impl     CustomFunc        // implementation once loaded
+
+		scope FuncResolver // where the function is declared, see Current()
+	}
+
+	// FuncLoader loads the implementation of an external UserFunc.
+	FuncLoader func(fn *UserFunc) (CustomFunc, error)
 
 	// FuncRegistry contains lists of functions for different scope/run-time evaluation contexts.
 	FuncRegistry struct {
@@ -84,3 +129,150 @@ func (m *FuncRegistry) FuncGet(name string) (Func, bool) {
 func FuncAdd(name string, fn CustomFunc) {
 	funcReg.Add(name, fn)
 }
+
+// NewUserFunc create a function from its parameter names and body.
+func NewUserFunc(name string, params []string, body Node) *UserFunc {
+	return &UserFunc{Name: strings.ToLower(name), Params: params, Body: body}
+}
+
+// NewExternalFunc create a function from its signature whose implementation
+// is loaded from source by the FuncLoader for language.
+func NewExternalFunc(name string, args []value.ValueType, returns value.ValueType, language, from string) *UserFunc {
+	return &UserFunc{
+		Name:     strings.ToLower(name),
+		Args:     args,
+		Returns:  returns,
+		Language: strings.ToLower(language),
+		From:     from,
+	}
+}
+
+// FuncLoaderRegister makes a loader of external functions available for
+// a LANGUAGE, packages providing languages register themselves in init.
+func FuncLoaderRegister(language string, fl FuncLoader) {
+	funcLoaderMu.Lock()
+	defer funcLoaderMu.Unlock()
+	funcLoaders[strings.ToLower(language)] = fl
+}
+
+// IsExternal is this function implemented outside of SQL, it has no body.
+func (m *UserFunc) IsExternal() bool { return m.Body == nil }
+
+// Load the implementation of an external function using the loader
+// registered for its language.
+func (m *UserFunc) Load() error {
+	funcLoaderMu.RLock()
+	fl, ok := funcLoaders[m.Language]
+	funcLoaderMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown function language %q", m.Language)
+	}
+	impl, err := fl(m)
+	if err != nil {
+		return err
+	}
+	m.Impl = impl
+	return nil
+}
+
+// Type of the function is its declared return type, or inferred from its body.
+func (m *UserFunc) Type() value.ValueType {
+	if m.IsExternal() {
+		return m.Returns
+	}
+	return ValueTypeFromNode(m.Body)
+}
+
+// Validate the call has an argument for each parameter.
+func (m *UserFunc) Validate(n *FuncNode) (EvaluatorFunc, error) {
+	if !m.IsExternal() {
+		if len(n.Args) != len(m.Params) {
+			return nil, fmt.Errorf("Expected %d args for %s(%s) but got %s", len(m.Params), m.Name, strings.Join(m.Params, ", "), n)
+		}
+		// the vm evaluates the body, there is no go implementation
+		return EmptyEvalFunc, nil
+	}
+	if len(n.Args) != len(m.Args) {
+		return nil, fmt.Errorf("Expected %d args for %s but got %s", len(m.Args), m.signature(), n)
+	}
+	if m.Impl == nil {
+		return nil, fmt.Errorf("function %s LANGUAGE %s is not loaded", m.Name, m.Language)
+	}
+	return m.Impl.Validate(n)
+}
+
+// SetScope the resolver the function is declared in, calls to it resolve
+// its name there when evaluated so they see OR REPLACE and DROP.
+func (m *UserFunc) SetScope(fr FuncResolver) { m.scope = fr }
+
+// Current the declaration of this function in its scope when evaluated,
+// false if it was dropped.  Functions without a scope are always current.
+func (m *UserFunc) Current() (*UserFunc, bool) {
+	if m.scope == nil {
+		return m, true
+	}
+	f, ok := m.scope.FuncGet(m.Name)
+	if !ok {
+		return nil, false
+	}
+	uf, ok := f.CustomFunc.(*UserFunc)
+	if !ok {
+		return nil, false
+	}
+	return uf, true
+}
+
+// Close the implementation of an external function if it holds resources.
+func (m *UserFunc) Close() error {
+	if c, ok := m.Impl.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (m *UserFunc) signature() string {
+	args := make([]string, len(m.Args))
+	for i, vt := range m.Args {
+		args[i] = vt.String()
+	}
+	return fmt.Sprintf("%s(%s)", m.Name, strings.Join(args, ", "))
+}
+
+// String the declaration of the function, name(params) AS body
+func (m *UserFunc) String() string {
+	w := NewDefaultWriter()
+	m.WriteDialect(w)
+	return w.String()
+}
+
+// WriteDialect write the declaration of the function, name(params) AS body
+// or name(types) RETURNS type LANGUAGE language FROM 'source'
+func (m *UserFunc) WriteDialect(w DialectWriter) {
+	if m.IsExternal() {
+		io.WriteString(w, m.signature())
+		io.WriteString(w, " RETURNS ")
+		io.WriteString(w, m.Returns.String())
+		io.WriteString(w, " LANGUAGE ")
+		io.WriteString(w, m.Language)
+		if m.From != "" {
+			io.WriteString(w, " FROM ")
+			w.WriteLiteral(m.From)
+		}
+		if len(m.With) > 0 {
+			by, _ := json.Marshal(m.With)
+			io.WriteString(w, " WITH ")
+			w.Write(by)
+		}
+		return
+	}
+	io.WriteString(w, m.Name)
+	io.WriteString(w, "(")
+	for i, p := range m.Params {
+		if i > 0 {
+			io.WriteString(w, ", ")
+		}
+		w.WriteIdentity(p)
+	}
+	io.WriteString(w, ") AS ")
+	m.Body.WriteDialect(w)
+}
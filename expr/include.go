@@ -40,6 +40,20 @@ func inlineIncludesDepth(ctx Includer, arg Node, depth int) (Node, error) {
 	}
 
 	switch n := arg.(type) {
+	case *CaseNode:
+		// case children aren't a single slice, so set them back
+		args := n.ChildrenArgs()
+		for i, narg := range args {
+			newNode, err := inlineIncludesDepth(ctx, narg, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if newNode != nil {
+				args[i] = newNode
+			}
+		}
+		n.setChildrenArgs(args)
+		return arg, nil
 	// FuncNode, BinaryNode, BooleanNode, TriNode, UnaryNode, ArrayNode
 	case NodeArgs:
 		args := n.ChildrenArgs()
@@ -110,6 +124,10 @@ func findAllIncludes(node Node, current []string) []string {
 		for _, arg := range n.Args {
 			current = findAllIncludes(arg, current)
 		}
+	case *CaseNode:
+		for _, arg := range n.ChildrenArgs() {
+			current = findAllIncludes(arg, current)
+		}
 	case *ArrayNode:
 		for _, arg := range n.Args {
 			current = findAllIncludes(arg, current)
@@ -57,6 +57,7 @@ var (
 	_ NodeArgs = (*FuncNode)(nil)
 	_ NodeArgs = (*UnaryNode)(nil)
 	_ NodeArgs = (*ArrayNode)(nil)
+	_ NodeArgs = (*CaseNode)(nil)
 )
 
 type (
@@ -138,7 +139,9 @@ type (
 	}
 
 	// ContextReader is a key-value interface to read the context of message/row
-	// using a  Get("key") interface.  Used by vm to evaluate messages
+	// using a  Get("key") interface.  Used by vm to evaluate messages.  Get of a
+	// missing key returns (nil, false) while an explicit NULL is
+	// (value.NilValue, true).
 	ContextReader interface {
 		Get(key string) (value.Value, bool)
 		Row() map[string]value.Value
@@ -259,6 +262,9 @@ type (
 		Paren    bool
 		Args     []Node
 		Operator lex.Token
+		// Glob a FilterQL LIKE whose pattern is a glob not ANSI, see
+		// LikePattern.  It is not kept by NodePb or Expr.
+		Glob bool
 	}
 
 	// BooleanNode is   n nodes and an operator
@@ -277,6 +283,17 @@ type (
 		Operator lex.Token
 	}
 
+	// CaseNode is a searched, or simple (with Operand) CASE expression
+	//
+	//    CASE WHEN <expr> THEN <expr> [WHEN ...] [ELSE <expr>] END
+	//    CASE <expr> WHEN <expr> THEN <expr> [WHEN ...] [ELSE <expr>] END
+	CaseNode struct {
+		Operand Node   // optional, compared to each when value
+		Whens   []Node // conditions, or values if Operand
+		Thens   []Node // results, one per when
+		Else    Node   // optional result if no when matches
+	}
+
 	// UnaryNode negates a single node argument
 	//
 	//    (  not <expression>  |   !<expression> )
@@ -331,6 +348,27 @@ func NewIncludeContext(cr ContextReader) *IncludeContext {
 // Include interface not implemented.
 func (*IncludeContext) Include(name string) (Node, error) { return nil, ErrNoIncluder }
 
+// SessionLocation the time zone of the session the context reads from, as
+// set by SET time_zone = 'America/Denver'.  SYSTEM uses @@system_time_zone,
+// nil if the context has no (valid) time zone.
+func SessionLocation(ctx ContextReader) *time.Location {
+	if ctx == nil {
+		return nil
+	}
+	tz, ok := ctx.Get("@@session.time_zone")
+	if !ok {
+		tz, ok = ctx.Get("@@time_zone")
+	}
+	if ok && strings.EqualFold(tz.ToString(), "SYSTEM") {
+		tz, ok = ctx.Get("@@system_time_zone")
+	}
+	if !ok || tz == nil {
+		return nil
+	}
+	loc, _ := value.LoadLocation(tz.ToString())
+	return loc
+}
+
 // FindFirstIdentity Recursively descend down a node looking for first Identity Field
 //
 //     min(year)                 == year
@@ -387,6 +425,10 @@ func findIdentities(node Node, l IdentityNodes) IdentityNodes {
 		for _, arg := range n.Args {
 			l = findIdentities(arg, l)
 		}
+	case *CaseNode:
+		for _, arg := range n.ChildrenArgs() {
+			l = findIdentities(arg, l)
+		}
 	case *ArrayNode:
 		for _, arg := range n.Args {
 			l = findIdentities(arg, l)
@@ -508,7 +550,15 @@ func ValueTypeFromNode(n Node) value.ValueType {
 			return value.NumberType
 		case lex.TokenModulus:
 			return value.IntType
-		case lex.TokenLT, lex.TokenLE, lex.TokenGT, lex.TokenGE:
+		case lex.TokenLT, lex.TokenLE, lex.TokenGT, lex.TokenGE, lex.TokenNE,
+			lex.TokenLike, lex.TokenILike, lex.TokenRegexp, lex.TokenIN,
+			lex.TokenContains, lex.TokenIntersects, lex.TokenIsDistinctFrom, lex.TokenIsNotDistinctFrom:
+			return value.BoolType
+		}
+	case *TriNode:
+		return value.BoolType
+	case *UnaryNode:
+		if nt.Operator.T == lex.TokenNegate {
 			return value.BoolType
 		}
 	}
@@ -875,6 +925,8 @@ func (m *ValueNode) String() string {
 			vals[i] = fmt.Sprintf("%q", v.ToString())
 		}
 		return fmt.Sprintf("[%s]", strings.Join(vals, ", "))
+	case value.IntervalValue:
+		return fmt.Sprintf("INTERVAL %q", vt.ToString())
 	}
 	return m.Value.ToString()
 }
@@ -906,6 +958,9 @@ func (m *ValueNode) WriteDialect(w DialectWriter) {
 		w.WriteNumber(vt.ToString())
 	case value.BoolValue:
 		w.WriteLiteral(vt.ToString())
+	case value.IntervalValue:
+		io.WriteString(w, "INTERVAL ")
+		w.WriteLiteral(vt.ToString())
 	default:
 		u.Warnf("unsupported value-node writer: %T", vt)
 		io.WriteString(w, vt.ToString())
@@ -921,9 +976,20 @@ func (m *ValueNode) FromPB(n *NodePb) Node {
 	return &ValueNode{}
 }
 func (m *ValueNode) Expr() *Expr {
+	if iv, ok := m.Value.(value.IntervalValue); ok {
+		return &Expr{Op: "interval", Args: []*Expr{{Value: iv.ToString()}}}
+	}
 	return &Expr{Value: m.Value.ToString()}
 }
 func (m *ValueNode) FromExpr(e *Expr) error {
+	if strings.ToLower(e.Op) == "interval" && len(e.Args) == 1 {
+		iv, err := value.ParseInterval(e.Args[0].Value)
+		if err != nil {
+			return err
+		}
+		m.Value = iv
+		return nil
+	}
 	if len(e.Value) > 0 {
 		m.Value = value.NewStringValue(e.Value)
 		return nil
@@ -1231,6 +1297,18 @@ func (m *BinaryNode) writeToString(w DialectWriter, negate string) {
 	}
 	m.Args[0].WriteDialect(w)
 	io.WriteString(w, " ")
+	if m.IsNullTest() {
+		// x IS NOT DISTINCT FROM NULL is written as x IS NULL
+		if (m.Operator.T == lex.TokenIsNotDistinctFrom) == (len(negate) == 0) {
+			io.WriteString(w, "IS NULL")
+		} else {
+			io.WriteString(w, "IS NOT NULL")
+		}
+		if m.Paren {
+			io.WriteString(w, ")")
+		}
+		return
+	}
 	if len(negate) > 0 {
 		switch m.Operator.T {
 		case lex.TokenEqual, lex.TokenEqualEqual:
@@ -1245,6 +1323,10 @@ func (m *BinaryNode) writeToString(w DialectWriter, negate string) {
 			io.WriteString(w, lex.TokenGT.String())
 		case lex.TokenLT:
 			io.WriteString(w, lex.TokenGE.String())
+		case lex.TokenIsDistinctFrom:
+			io.WriteString(w, strings.ToUpper(lex.TokenIsNotDistinctFrom.String()))
+		case lex.TokenIsNotDistinctFrom:
+			io.WriteString(w, strings.ToUpper(lex.TokenIsDistinctFrom.String()))
 		default:
 			io.WriteString(w, negate)
 			io.WriteString(w, m.Operator.V)
@@ -1260,6 +1342,57 @@ func (m *BinaryNode) writeToString(w DialectWriter, negate string) {
 	}
 }
 
+// IsNullTest is this the NULL test x IS [NOT] NULL, which is parsed as the
+// null-safe x IS [NOT] DISTINCT FROM NULL.
+func (m *BinaryNode) IsNullTest() bool {
+	switch m.Operator.T {
+	case lex.TokenIsDistinctFrom, lex.TokenIsNotDistinctFrom:
+		_, isNull := m.Args[1].(*NullNode)
+		return isNull
+	}
+	return false
+}
+
+// LikePattern the ANSI LIKE pattern of pattern, the text of the right hand
+// argument of this LIKE, translated if it is a FilterQL glob.
+func (m *BinaryNode) LikePattern(pattern string) string {
+	if m.Glob {
+		return GlobToLike(pattern)
+	}
+	return pattern
+}
+
+// GlobToLike rewrite a glob pattern, * many and ? one character with \
+// as escape character, as an ANSI LIKE pattern with \ as escape.
+func GlobToLike(pattern string) string {
+	var out []rune
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+			if r == '%' || r == '_' || r == '\\' {
+				out = append(out, '\\')
+			}
+			out = append(out, r)
+		case r == '\\':
+			escaped = true
+		case r == '*', r == '%':
+			out = append(out, '%')
+		case r == '?':
+			out = append(out, '_')
+		case r == '_':
+			out = append(out, '\\', '_')
+		default:
+			out = append(out, r)
+		}
+	}
+	if escaped {
+		out = append(out, '\\', '\\')
+	}
+	return string(out)
+}
+
 /*
 Negation
 I wanted to do negation on Binaries, but ended up not doing for now
@@ -1291,6 +1424,12 @@ func (m *BinaryNode) ReverseNegation() bool {
 	case lex.TokenGE:
 		m.Operator.T = lex.TokenLT
 		m.Operator.V = m.Operator.T.String()
+	case lex.TokenIsDistinctFrom:
+		m.Operator.T = lex.TokenIsNotDistinctFrom
+		m.Operator.V = strings.ToUpper(m.Operator.T.String())
+	case lex.TokenIsNotDistinctFrom:
+		m.Operator.T = lex.TokenIsDistinctFrom
+		m.Operator.V = strings.ToUpper(m.Operator.T.String())
 	default:
 		//u.Warnf("What, what is this?   %s", m)
 		m.negated = !m.negated
@@ -1386,7 +1525,7 @@ func (m *BinaryNode) Equal(n Node) bool {
 				return false
 			}
 		}
-		if nt.Paren != m.Paren {
+		if nt.Paren != m.Paren || nt.Glob != m.Glob {
 			return false
 		}
 		if len(m.Args) != len(nt.Args) {
@@ -1550,6 +1689,7 @@ func (m *BooleanNode) Equal(n Node) bool {
 // Create a Tri node
 //
 //  @arg1 [NOT] BETWEEN @arg2 AND @arg3
+//  @arg1 [NOT] LIKE @arg2 ESCAPE @arg3
 //
 func NewTriNode(operator lex.Token, arg1, arg2, arg3 Node) *TriNode {
 	return &TriNode{Args: []Node{arg1, arg2, arg3}, Operator: operator}
@@ -1561,19 +1701,19 @@ func (m *TriNode) ReverseNegation() bool {
 }
 func (m *TriNode) String() string {
 	w := NewDefaultWriter()
-	m.writeToString(w, false)
+	m.writeToString(w, m.negated)
 	return w.String()
 }
 func (m *TriNode) StringNegate() string {
 	w := NewDefaultWriter()
-	m.writeToString(w, true)
+	m.writeToString(w, !m.negated)
 	return w.String()
 }
 func (m *TriNode) WriteNegate(w DialectWriter) {
-	m.writeToString(w, true)
+	m.writeToString(w, !m.negated)
 }
 func (m *TriNode) WriteDialect(w DialectWriter) {
-	m.writeToString(w, false)
+	m.writeToString(w, m.negated)
 }
 func (m *TriNode) writeToString(w DialectWriter, negate bool) {
 	m.Args[0].WriteDialect(w)
@@ -1584,9 +1724,14 @@ func (m *TriNode) writeToString(w DialectWriter, negate bool) {
 	switch m.Operator.T {
 	case lex.TokenBetween:
 		io.WriteString(w, "BETWEEN ")
+		m.Args[1].WriteDialect(w)
+		io.WriteString(w, " AND ")
+	case lex.TokenLike, lex.TokenILike:
+		io.WriteString(w, strings.ToUpper(m.Operator.T.String()))
+		io.WriteString(w, " ")
+		m.Args[1].WriteDialect(w)
+		io.WriteString(w, " ESCAPE ")
 	}
-	m.Args[1].WriteDialect(w)
-	io.WriteString(w, " AND ")
 	m.Args[2].WriteDialect(w)
 }
 func (m *TriNode) Collapse() Node { return m }
@@ -1672,6 +1817,203 @@ func (m *TriNode) Equal(n Node) bool {
 	return false
 }
 
+// NewCaseNode Create a CASE node, operand is nil for a searched case
+//
+//    CASE [@operand] WHEN @whens[0] THEN @thens[0] ... [ELSE @elseNode] END
+//
+func NewCaseNode(operand Node, whens, thens []Node, elseNode Node) *CaseNode {
+	return &CaseNode{Operand: operand, Whens: whens, Thens: thens, Else: elseNode}
+}
+func (m *CaseNode) NodeType() string { return "Case" }
+func (m *CaseNode) String() string {
+	w := NewDefaultWriter()
+	m.WriteDialect(w)
+	return w.String()
+}
+func (m *CaseNode) WriteDialect(w DialectWriter) {
+	io.WriteString(w, "CASE ")
+	if m.Operand != nil {
+		m.Operand.WriteDialect(w)
+		io.WriteString(w, " ")
+	}
+	for i, when := range m.Whens {
+		io.WriteString(w, "WHEN ")
+		when.WriteDialect(w)
+		io.WriteString(w, " THEN ")
+		m.Thens[i].WriteDialect(w)
+		io.WriteString(w, " ")
+	}
+	if m.Else != nil {
+		io.WriteString(w, "ELSE ")
+		m.Else.WriteDialect(w)
+		io.WriteString(w, " ")
+	}
+	io.WriteString(w, "END")
+}
+func (m *CaseNode) Validate() error {
+	if len(m.Whens) == 0 {
+		return fmt.Errorf("CASE requires at least one WHEN")
+	}
+	if len(m.Whens) != len(m.Thens) {
+		return fmt.Errorf("CASE requires a THEN for each WHEN")
+	}
+	for _, n := range m.ChildrenArgs() {
+		if err := n.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChildrenArgs the operand, each when and then pair, and else
+func (m *CaseNode) ChildrenArgs() []Node {
+	args := make([]Node, 0, len(m.Whens)*2+2)
+	if m.Operand != nil {
+		args = append(args, m.Operand)
+	}
+	for i, when := range m.Whens {
+		args = append(args, when, m.Thens[i])
+	}
+	if m.Else != nil {
+		args = append(args, m.Else)
+	}
+	return args
+}
+
+// setChildrenArgs is the reverse of ChildrenArgs
+func (m *CaseNode) setChildrenArgs(args []Node) {
+	if m.Operand != nil {
+		m.Operand, args = args[0], args[1:]
+	}
+	for i := range m.Whens {
+		m.Whens[i], m.Thens[i], args = args[0], args[1], args[2:]
+	}
+	if m.Else != nil {
+		m.Else = args[0]
+	}
+}
+func (m *CaseNode) NodePb() *NodePb {
+	n := &CaseNodePb{
+		Whens: make([]NodePb, len(m.Whens)),
+		Thens: make([]NodePb, len(m.Thens)),
+	}
+	if m.Operand != nil {
+		n.Operand = m.Operand.NodePb()
+	}
+	for i, arg := range m.Whens {
+		n.Whens[i] = *arg.NodePb()
+	}
+	for i, arg := range m.Thens {
+		n.Thens[i] = *arg.NodePb()
+	}
+	if m.Else != nil {
+		n.Else = m.Else.NodePb()
+	}
+	return &NodePb{Cn: n}
+}
+func (m *CaseNode) FromPB(n *NodePb) Node {
+	return &CaseNode{
+		Operand: NodeFromNodePb(n.Cn.Operand),
+		Whens:   NodesFromNodesPb(n.Cn.Whens),
+		Thens:   NodesFromNodesPb(n.Cn.Thens),
+		Else:    NodeFromNodePb(n.Cn.Else),
+	}
+}
+
+// Expr of a case has args of the optional operand, then a `when`
+// expression per when/then pair, and an optional `else`
+func (m *CaseNode) Expr() *Expr {
+	fe := &Expr{Op: "case", Args: make([]*Expr, 0, len(m.Whens)+2)}
+	if m.Operand != nil {
+		fe.Args = append(fe.Args, m.Operand.Expr())
+	}
+	for i, when := range m.Whens {
+		fe.Args = append(fe.Args, &Expr{Op: "when", Args: []*Expr{when.Expr(), m.Thens[i].Expr()}})
+	}
+	if m.Else != nil {
+		fe.Args = append(fe.Args, &Expr{Op: "else", Args: []*Expr{m.Else.Expr()}})
+	}
+	return fe
+}
+func (m *CaseNode) FromExpr(e *Expr) error {
+	if len(e.Args) == 0 {
+		return fmt.Errorf("Invalid CaseNode, expected args %+v", e)
+	}
+	for i, arg := range e.Args {
+		switch strings.ToLower(arg.Op) {
+		case "when":
+			if len(arg.Args) != 2 {
+				return fmt.Errorf("Invalid CASE when, expected 2 args %+v", arg)
+			}
+			args, err := NodesFromExprs(arg.Args)
+			if err != nil {
+				return err
+			}
+			m.Whens = append(m.Whens, args[0])
+			m.Thens = append(m.Thens, args[1])
+		case "else":
+			if len(arg.Args) != 1 {
+				return fmt.Errorf("Invalid CASE else, expected 1 arg %+v", arg)
+			}
+			n, err := NodeFromExpr(arg.Args[0])
+			if err != nil {
+				return err
+			}
+			m.Else = n
+		default:
+			if i != 0 {
+				return fmt.Errorf("Invalid CASE, operand must be first %+v", arg)
+			}
+			n, err := NodeFromExpr(arg)
+			if err != nil {
+				return err
+			}
+			m.Operand = n
+		}
+	}
+	if len(m.Whens) == 0 {
+		return fmt.Errorf("Invalid CASE, expected when %+v", e)
+	}
+	return nil
+}
+func (m *CaseNode) Equal(n Node) bool {
+	if m == nil && n == nil {
+		return true
+	}
+	if m == nil && n != nil {
+		return false
+	}
+	if m != nil && n == nil {
+		return false
+	}
+	if nt, ok := n.(*CaseNode); ok {
+		if !nodeEqualMaybeNil(m.Operand, nt.Operand) || !nodeEqualMaybeNil(m.Else, nt.Else) {
+			return false
+		}
+		if len(m.Whens) != len(nt.Whens) || len(m.Thens) != len(nt.Thens) {
+			return false
+		}
+		for i, arg := range nt.Whens {
+			if !arg.Equal(m.Whens[i]) {
+				return false
+			}
+		}
+		for i, arg := range nt.Thens {
+			if !arg.Equal(m.Thens[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+func nodeEqualMaybeNil(n1, n2 Node) bool {
+	if n1 == nil || n2 == nil {
+		return n1 == nil && n2 == nil
+	}
+	return n1.Equal(n2)
+}
+
 // Unary nodes
 //
 //    NOT <expression>
@@ -2077,6 +2419,9 @@ func NodeFromNodePb(n *NodePb) Node {
 		return in.FromPB(n)
 	case n.Niln != nil:
 		return &NullNode{}
+	case n.Cn != nil:
+		var cn *CaseNode
+		return cn.FromPB(n)
 	}
 	return nil
 }
@@ -2201,8 +2546,20 @@ func NodeFromExpr(e *Expr) (Node, error) {
 			n = &UnaryNode{}
 		case "BETWEEN":
 			n = &TriNode{}
+		case "LIKE", "ILIKE":
+			// LIKE with an ESCAPE character is ternary
+			if len(e.Args) == 3 {
+				n = &TriNode{}
+			} else {
+				n = &BinaryNode{}
+			}
+		case "CASE":
+			n = &CaseNode{}
+		case "INTERVAL":
+			n = &ValueNode{}
 		case "=", "-", "+", "++", "+=", "/", "%", "==", "<=", "!=", ">=", ">", "<", "*",
-			"LIKE", "CONTAINS", "INTERSECTS", "IN":
+			"CONTAINS", "INTERSECTS", "IN", "IS DISTINCT FROM", "IS NOT DISTINCT FROM",
+			"AT TIME ZONE", "REGEXP":
 
 			// very weird special case for FILTER * where the * is an ident not op
 			if e.Op == "*" && len(e.Args) == 0 {
@@ -22,6 +22,13 @@ var pbTests = []string{
 	`AND ( EXISTS x, INCLUDE ref_name )`,
 	`company = "Toys R"" Us"`,
 	`providers.id != NULL`,
+	`CASE WHEN x > 5 THEN "big" WHEN x > 1 THEN "small" ELSE "none" END`,
+	`CASE status WHEN 1 THEN "one" WHEN 2 THEN toint(y) END`,
+	`x IS DISTINCT FROM y`,
+	`x IS NOT DISTINCT FROM NULL`,
+	`ts AT TIME ZONE "America/Denver" > "2017-03-13"`,
+	`name LIKE "a!_%" ESCAPE "!"`,
+	`name ILIKE "bob%" AND name REGEXP "^b.*"`,
 }
 
 func TestNodePb(t *testing.T) {
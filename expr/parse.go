@@ -164,6 +164,9 @@ func newTree(pager TokenPager) *tree {
 }
 func newTreeFuncs(pager TokenPager, fr FuncResolver) *tree {
 	t := tree{TokenPager: pager, fr: fr, funcCheck: fr != nil}
+	if fc, ok := fr.(FuncChecker); ok {
+		t.funcCheck = fc.FuncCheck()
+	}
 	return &t
 }
 
@@ -375,13 +378,26 @@ func (t *tree) C(depth int) Node {
 			return NewUnary(cur, t.cInner(n, depth+1))
 		case lex.TokenIs:
 			t.Next()
-			if t.Cur().T == lex.TokenNegate {
-				cur = t.Next()
+			negate := t.Cur().T == lex.TokenNegate
+			if negate {
+				t.Next()
+			}
+			if t.Cur().T == lex.TokenNull {
+				// x IS [NOT] NULL is the null-safe x IS [NOT] DISTINCT FROM NULL
+				// as x = NULL is itself NULL
+				op := lex.Token{T: lex.TokenIsNotDistinctFrom}
+				if negate {
+					op.T = lex.TokenIsDistinctFrom
+				}
+				op.V = strings.ToUpper(op.T.String())
+				return NewBinaryNode(op, n, t.P(depth+1))
+			}
+			if negate {
 				ne := lex.Token{T: lex.TokenNE, V: "!="}
 				return NewBinaryNode(ne, n, t.P(depth+1))
 			}
-			u.Warnf("TokenIS?  is this supported?")
-			return NewUnary(cur, t.cInner(n, depth+1))
+			eq := lex.Token{T: lex.TokenEqual, V: "="}
+			return NewBinaryNode(eq, n, t.P(depth+1))
 		default:
 			return t.cInner(n, depth)
 		}
@@ -393,7 +409,27 @@ func (t *tree) cInner(n Node, depth int) Node {
 		debugf(depth, "cInner:  tok:  cur=%v peek=%v n=%v", t.Cur(), t.Peek(), n)
 		switch cur := t.Cur(); cur.T {
 		case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenNE, lex.TokenGT, lex.TokenGE,
-			lex.TokenLE, lex.TokenLT, lex.TokenLike, lex.TokenContains:
+			lex.TokenLE, lex.TokenLT, lex.TokenContains:
+			t.Next()
+			n = NewBinaryNode(cur, n, t.P(depth+1))
+		case lex.TokenLike, lex.TokenILike:
+			//  x LIKE 'a!%' ESCAPE '!'  is ternary
+			t.Next()
+			pattern := t.P(depth + 1)
+			if t.Cur().T == lex.TokenEscape {
+				t.Next()
+				n = NewTriNode(cur, n, pattern, t.P(depth+1))
+			} else {
+				n = NewBinaryNode(cur, n, pattern)
+			}
+		case lex.TokenRegexp:
+			// RLIKE and ~ are synonyms
+			cur.V = "REGEXP"
+			t.Next()
+			n = NewBinaryNode(cur, n, t.P(depth+1))
+		case lex.TokenIsDistinctFrom, lex.TokenIsNotDistinctFrom:
+			// the lexed multi-word operator may have any whitespace
+			cur.V = strings.ToUpper(cur.T.String())
 			t.Next()
 			n = NewBinaryNode(cur, n, t.P(depth+1))
 		case lex.TokenBetween:
@@ -485,6 +521,12 @@ func (t *tree) M(depth int) Node {
 		case lex.TokenStar, lex.TokenMultiply, lex.TokenDivide, lex.TokenModulus:
 			t.Next()
 			n = NewBinaryNode(cur, n, t.F(depth+1))
+		case lex.TokenAtTimeZone:
+			// binds tighter than arithmetic and comparison
+			//   ts AT TIME ZONE 'America/Denver' > "2017-01-01"
+			cur.V = strings.ToUpper(cur.T.String())
+			t.Next()
+			n = NewBinaryNode(cur, n, t.F(depth+1))
 		default:
 			return n
 		}
@@ -505,8 +547,8 @@ func (t *tree) F(depth int) Node {
 		var arg Node
 
 		switch t.Peek().T {
-		case lex.TokenIN, lex.TokenLike, lex.TokenContains, lex.TokenBetween,
-			lex.TokenIntersects:
+		case lex.TokenIN, lex.TokenLike, lex.TokenILike, lex.TokenRegexp,
+			lex.TokenContains, lex.TokenBetween, lex.TokenIntersects:
 			// TODO:  this is a bug.  An old version of generator was saving these
 			//  NOT news INTERSECTS ("a")    which is invalid it should be
 			//  news NOT INTERSECTS ("a")  OR NOT (news INTERSECTS ("a"))
@@ -633,6 +675,12 @@ func (t *tree) v(depth int) Node {
 	case lex.TokenUdfExpr:
 		t.Next() // consume Function Name
 		return t.Func(depth, cur)
+	case lex.TokenCase:
+		t.Next() // consume CASE
+		return t.Case(depth)
+	case lex.TokenInterval:
+		t.Next() // consume INTERVAL
+		return t.Interval(depth)
 	case lex.TokenLeftParenthesis:
 		t.Next() // Consume  (
 		n := t.O(depth + 1)
@@ -772,6 +820,63 @@ func (t *tree) Func(depth int, funcTok lex.Token) (fn *FuncNode) {
 	}
 }
 
+// Case parses a CASE expression, the CASE has already been consumed
+//
+//    CASE [<expr>] WHEN <expr> THEN <expr> [WHEN ...] [ELSE <expr>] END
+//
+func (t *tree) Case(depth int) Node {
+	debugf(depth, "Case: cur:%v peek:%v", t.Cur(), t.Peek())
+	var operand, elseNode Node
+	if t.Cur().T != lex.TokenWhen {
+		operand = t.O(depth + 1)
+	}
+	whens := make([]Node, 0)
+	thens := make([]Node, 0)
+	for t.Cur().T == lex.TokenWhen {
+		t.Next() // consume WHEN
+		whens = append(whens, t.O(depth+1))
+		t.expect(lex.TokenThen, "Expected THEN in CASE")
+		t.Next() // consume THEN
+		thens = append(thens, t.O(depth+1))
+	}
+	if len(whens) == 0 {
+		t.unexpected(t.Cur(), "Expected WHEN in CASE")
+	}
+	if t.Cur().T == lex.TokenElse {
+		t.Next() // consume ELSE
+		elseNode = t.O(depth + 1)
+	}
+	t.expect(lex.TokenEnd, "Expected END of CASE")
+	t.Next() // consume END
+	return NewCaseNode(operand, whens, thens, elseNode)
+}
+
+// Interval parses an INTERVAL literal, the INTERVAL has already been consumed
+//
+//    INTERVAL '3' DAY
+//    INTERVAL '1 month 3 days'
+//
+func (t *tree) Interval(depth int) Node {
+	debugf(depth, "Interval: cur:%v peek:%v", t.Cur(), t.Peek())
+	cur := t.Cur()
+	switch cur.T {
+	case lex.TokenValue, lex.TokenInteger:
+	default:
+		t.unexpected(cur, "Expected quantity for INTERVAL")
+	}
+	t.Next() // consume quantity
+	qty := cur.V
+	if t.Cur().T == lex.TokenIdentity {
+		qty += " " + t.Cur().V
+		t.Next() // consume unit
+	}
+	iv, err := value.ParseInterval(qty)
+	if err != nil {
+		t.unexpected(cur, err.Error())
+	}
+	return NewValueNode(iv)
+}
+
 // get Function from Global function registry.
 func (t *tree) getFunction(name string) (fn Func, ok bool) {
 	if t.fr != nil {
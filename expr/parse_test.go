@@ -113,6 +113,16 @@ var exprTestsx = []exprTest{
 }
 
 var exprTests = []exprTest{
+	{
+		`name NOT LIKE "a!_%" ESCAPE "!"`,
+		`name NOT LIKE "a!_%" ESCAPE "!"`,
+		true,
+	},
+	{
+		`name ILIKE "bob%" OR name RLIKE "^b" OR name ~ "o+"`,
+		`name ILIKE "bob%" OR name REGEXP "^b" OR name REGEXP "o+"`,
+		true,
+	},
 	{
 		"`content table`.`Ford Motor Company` >= \"0.58\"",
 		"`content table`.`Ford Motor Company` >= \"0.58\"",
@@ -292,7 +302,62 @@ var exprTests = []exprTest{
 		`version == 4 AND (NOT(exists(@@content_whitelist_domains)) OR len(@@content_whitelist_domains) == 0 OR host(url) IN hosts(@@content_whitelist_domains))`,
 		true,
 	},
+	{
+		`case when x > 5 then "big" when x > 1 then "small" else "none" end`,
+		`CASE WHEN x > 5 THEN "big" WHEN x > 1 THEN "small" ELSE "none" END`,
+		true,
+	},
+	{
+		`CASE status WHEN 1 THEN toint(x) WHEN 2 THEN y + 1 END == 7`,
+		`CASE status WHEN 1 THEN toint(x) WHEN 2 THEN y + 1 END == 7`,
+		true,
+	},
+	{
+		`AND ( exists x, CASE WHEN x IN ("a","b") THEN true ELSE false END )`,
+		`AND ( EXISTS x, CASE WHEN x IN ("a", "b") THEN true ELSE false END )`,
+		true,
+	},
+	{
+		`x IS NULL AND y IS NOT NULL`,
+		`x IS NULL AND y IS NOT NULL`,
+		true,
+	},
+	{
+		`x is  distinct from y OR z IS NOT DISTINCT FROM NULL`,
+		`x IS DISTINCT FROM y OR z IS NULL`,
+		true,
+	},
+	{
+		`NOT (x IS DISTINCT FROM y)`,
+		`NOT (x IS DISTINCT FROM y)`,
+		true,
+	},
+	{
+		`ts + INTERVAL '3' DAY > created - interval 1 month`,
+		`ts + INTERVAL "3 days" > created - INTERVAL "1 months"`,
+		true,
+	},
+	{
+		`date_add(ts, INTERVAL '1 year 2 weeks')`,
+		`date_add(ts, INTERVAL "1 years 14 days")`,
+		true,
+	},
 	// Invalid Statements
+	{
+		`ts + INTERVAL '3' FORTNIGHT`, // unknown unit
+		"",
+		false,
+	},
+	{
+		`CASE ELSE "none" END`, // requires a WHEN
+		"",
+		false,
+	},
+	{
+		`CASE WHEN x > 5 THEN "big"`, // requires END
+		"",
+		false,
+	},
 	{
 		"`fieldname` INTERSECTS \"hello\"", // Right Side only allows (identity|array|func)
 		"",
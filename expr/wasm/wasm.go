@@ -0,0 +1,536 @@
+// Package wasm loads scalar functions from WebAssembly modules so they can be
+// added to the expression vm without recompiling.  Modules run in the pure go
+// wazero runtime, sandboxed with no host imports and with limits on memory
+// and time per call.
+//
+// Importing this package registers the wasm language for
+//
+//    CREATE FUNCTION clean_sku(string) RETURNS string LANGUAGE wasm FROM 'sku.wasm'
+//        WITH export = "clean", memory_pages = 32, timeout = "50ms", max_instances = 4
+//
+// Modules are read from ModuleDir, FROM is a path relative to it.  No modules
+// can be loaded until it is set.
+//
+// Each concurrent call uses its own module instance, at most max_instances of
+// them so a function uses at most max_instances * memory_pages of memory.
+//
+// The exported function is called with the declared arguments mapped to wasm types
+//
+//    int    i64
+//    number f64
+//    bool   i32, 0 or 1
+//    string i32 pointer and i32 length of the utf8 bytes
+//
+// Strings are passed in memory, the module must export its memory as "memory"
+// and an "alloc(len i32) i32" function returning a pointer to len bytes.  A
+// string result is returned as i64 of pointer<<32 | length.  If the module
+// exports "dealloc(ptr i32, len i32)" it is called to free string arguments and
+// results after the call.
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	u "github.com/araddon/gou"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/value"
+)
+
+const (
+	// DefaultMemoryPages is the memory limit of a module, 64KiB pages so 16MiB.
+	DefaultMemoryPages = 256
+	// DefaultTimeout is the limit on time of a single call.
+	DefaultTimeout = 100 * time.Millisecond
+)
+
+var (
+	// Ensure we implement the CustomFunc interface
+	_ expr.CustomFunc = (*Func)(nil)
+
+	// ModuleDir is the directory modules are loaded from, paths given to
+	// Load are relative to it.  Empty refuses to load any module.
+	ModuleDir = ""
+)
+
+func init() {
+	expr.FuncLoaderRegister("wasm", loadUserFunc)
+}
+
+type (
+	// Config of a wasm function, the export to call and its limits.
+	Config struct {
+		Export       string        // name of exported function, defaults to function name
+		MemoryPages  uint32        // max memory in 64KiB pages
+		Timeout      time.Duration // max time of a single call
+		MaxInstances int           // max module instances, defaults to GOMAXPROCS
+	}
+
+	// Func is a scalar function implemented by an exported function of a wasm
+	// module.  Module instances are not safe for concurrent use so each call
+	// takes an idle instance, instantiating a new one if there are none and
+	// there are fewer than MaxInstances, else waiting for one.
+	Func struct {
+		Name    string
+		Args    []value.ValueType
+		Returns value.ValueType
+
+		conf     Config
+		rt       wazero.Runtime
+		compiled wazero.CompiledModule
+		strings  bool // passes strings in memory
+
+		idle   chan api.Module // released instances
+		mu     sync.Mutex
+		live   int           // instances, idle or in use
+		freed  chan struct{} // closed, and replaced, when live goes down
+		closed bool
+	}
+)
+
+// ConfigFromWith reads the options of a CREATE FUNCTION WITH clause
+//
+//    WITH export = "clean", memory_pages = 32, timeout = "50ms", max_instances = 4
+//
+func ConfigFromWith(with u.JsonHelper) (Config, error) {
+	conf := Config{Export: with.String("export")}
+	if with.Has("memory_pages") {
+		pages, ok := with.Int64Safe("memory_pages")
+		if !ok || pages <= 0 || pages > 65536 {
+			return conf, fmt.Errorf("invalid memory_pages %v", with.Get("memory_pages"))
+		}
+		conf.MemoryPages = uint32(pages)
+	}
+	if with.Has("timeout") {
+		dur, err := time.ParseDuration(with.String("timeout"))
+		if err != nil || dur <= 0 {
+			return conf, fmt.Errorf("invalid timeout %v", with.Get("timeout"))
+		}
+		conf.Timeout = dur
+	}
+	if with.Has("max_instances") {
+		n, ok := with.Int64Safe("max_instances")
+		if !ok || n <= 0 || n > 1024 {
+			return conf, fmt.Errorf("invalid max_instances %v", with.Get("max_instances"))
+		}
+		conf.MaxInstances = int(n)
+	}
+	return conf, nil
+}
+
+func loadUserFunc(fn *expr.UserFunc) (expr.CustomFunc, error) {
+	if fn.From == "" {
+		return nil, fmt.Errorf("wasm function %s requires FROM 'path'", fn.Name)
+	}
+	conf, err := ConfigFromWith(fn.With)
+	if err != nil {
+		return nil, err
+	}
+	return Load(fn.Name, fn.From, fn.Args, fn.Returns, conf)
+}
+
+// Load a function from the wasm module file at path, relative to ModuleDir.
+func Load(name, path string, args []value.ValueType, returns value.ValueType, conf Config) (*Func, error) {
+	file, err := modulePath(path)
+	if err != nil {
+		return nil, fmt.Errorf("wasm function %s: %v", name, err)
+	}
+	by, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return New(name, by, args, returns, conf)
+}
+
+// modulePath the file of path in ModuleDir, absolute paths and paths
+// leaving ModuleDir, including through symlinks, are refused.
+func modulePath(path string) (string, error) {
+	if ModuleDir == "" {
+		return "", fmt.Errorf("no wasm module directory configured")
+	}
+	if path == "" || filepath.IsAbs(path) {
+		return "", fmt.Errorf("module path %q must be relative to the module directory", path)
+	}
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == ".." {
+			return "", fmt.Errorf("module path %q may not contain ..", path)
+		}
+	}
+	dir, err := filepath.EvalSymlinks(ModuleDir)
+	if err != nil {
+		return "", err
+	}
+	file, err := filepath.EvalSymlinks(filepath.Join(dir, path))
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(dir, file); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("module path %q is outside the module directory", path)
+	}
+	return file, nil
+}
+
+// New compiles the wasm module and checks its export matches the signature.
+func New(name string, wasm []byte, args []value.ValueType, returns value.ValueType, conf Config) (*Func, error) {
+	if conf.Export == "" {
+		conf.Export = name
+	}
+	if conf.MemoryPages == 0 {
+		conf.MemoryPages = DefaultMemoryPages
+	}
+	if conf.Timeout == 0 {
+		conf.Timeout = DefaultTimeout
+	}
+	if conf.MaxInstances == 0 {
+		conf.MaxInstances = runtime.GOMAXPROCS(0)
+	}
+	m := &Func{Name: strings.ToLower(name), Args: args, Returns: returns, conf: conf}
+	m.idle = make(chan api.Module, conf.MaxInstances)
+	m.freed = make(chan struct{})
+
+	params := make([]api.ValueType, 0, len(args))
+	for _, vt := range args {
+		switch vt {
+		case value.StringType:
+			m.strings = true
+			params = append(params, api.ValueTypeI32, api.ValueTypeI32)
+		default:
+			wt, err := wasmType(vt)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, wt)
+		}
+	}
+	result := api.ValueTypeI64
+	if returns == value.StringType {
+		m.strings = true
+	} else {
+		wt, err := wasmType(returns)
+		if err != nil {
+			return nil, err
+		}
+		result = wt
+	}
+
+	ctx := context.Background()
+	rc := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(conf.MemoryPages).
+		WithCloseOnContextDone(true)
+	m.rt = wazero.NewRuntimeWithConfig(ctx, rc)
+	compiled, err := m.rt.CompileModule(ctx, wasm)
+	if err != nil {
+		m.rt.Close(ctx)
+		return nil, fmt.Errorf("could not compile wasm for %s: %v", m.Name, err)
+	}
+	m.compiled = compiled
+	if err := m.check(params, result); err != nil {
+		m.rt.Close(ctx)
+		return nil, err
+	}
+	// instantiate one up front so start up errors are found at load
+	inst, err := m.acquire(ctx)
+	if err != nil {
+		m.rt.Close(ctx)
+		return nil, err
+	}
+	m.release(inst)
+	return m, nil
+}
+
+func wasmType(vt value.ValueType) (api.ValueType, error) {
+	switch vt {
+	case value.IntType:
+		return api.ValueTypeI64, nil
+	case value.NumberType:
+		return api.ValueTypeF64, nil
+	case value.BoolType:
+		return api.ValueTypeI32, nil
+	}
+	return 0, fmt.Errorf("type %s is not supported by wasm functions", vt)
+}
+
+// check the module is self contained and exports what the signature needs.
+func (m *Func) check(params []api.ValueType, result api.ValueType) error {
+	for _, def := range m.compiled.ImportedFunctions() {
+		mod, name, _ := def.Import()
+		return fmt.Errorf("wasm module for %s imports %s.%s, imports are not allowed", m.Name, mod, name)
+	}
+	exports := m.compiled.ExportedFunctions()
+	if err := checkExport(exports, m.conf.Export, params, []api.ValueType{result}); err != nil {
+		return err
+	}
+	if !m.strings {
+		return nil
+	}
+	if _, ok := m.compiled.ExportedMemories()["memory"]; !ok {
+		return fmt.Errorf("wasm module for %s must export memory to pass strings", m.Name)
+	}
+	i32 := []api.ValueType{api.ValueTypeI32}
+	if err := checkExport(exports, "alloc", i32, i32); err != nil {
+		return err
+	}
+	if _, ok := exports["dealloc"]; ok {
+		return checkExport(exports, "dealloc", []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, nil)
+	}
+	return nil
+}
+
+func checkExport(exports map[string]api.FunctionDefinition, name string, params, results []api.ValueType) error {
+	def, ok := exports[name]
+	if !ok {
+		return fmt.Errorf("wasm module does not export function %q", name)
+	}
+	if !sameTypes(def.ParamTypes(), params) || !sameTypes(def.ResultTypes(), results) {
+		return fmt.Errorf("wasm export %q has signature %s but expected %s",
+			name, signature(def.ParamTypes(), def.ResultTypes()), signature(params, results))
+	}
+	return nil
+}
+
+func sameTypes(a, b []api.ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func signature(params, results []api.ValueType) string {
+	names := func(vts []api.ValueType) string {
+		s := make([]string, len(vts))
+		for i, vt := range vts {
+			s[i] = api.ValueTypeName(vt)
+		}
+		return strings.Join(s, ", ")
+	}
+	return fmt.Sprintf("(%s) -> (%s)", names(params), names(results))
+}
+
+// Type is the declared return type.
+func (m *Func) Type() value.ValueType { return m.Returns }
+
+// Validate the call has an argument for each declared argument.
+func (m *Func) Validate(n *expr.FuncNode) (expr.EvaluatorFunc, error) {
+	if len(n.Args) != len(m.Args) {
+		return nil, fmt.Errorf("Expected %d args for %s but got %s", len(m.Args), m.Name, n)
+	}
+	return m.eval, nil
+}
+
+func (m *Func) eval(ctx expr.EvalContext, args []value.Value) (value.Value, bool) {
+	v, err := m.Call(args)
+	if err != nil {
+		u.LogThrottle(u.WARN, 10, "%v", err)
+		return nil, false
+	}
+	if v == nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// Call the wasm function, a nil argument returns nil without calling it.
+func (m *Func) Call(args []value.Value) (value.Value, error) {
+	if len(args) != len(m.Args) {
+		return nil, fmt.Errorf("Expected %d args for %s but got %d", len(m.Args), m.Name, len(args))
+	}
+	for _, arg := range args {
+		if arg == nil || arg.Type() == value.NilType {
+			return nil, nil
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.conf.Timeout)
+	defer cancel()
+	inst, err := m.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := m.call(ctx, inst, args)
+	if err != nil {
+		// a trap or timeout leaves the instance in unknown state, or closed
+		m.discard(inst)
+		return nil, fmt.Errorf("wasm function %s: %v", m.Name, err)
+	}
+	m.release(inst)
+	return v, nil
+}
+
+func (m *Func) call(ctx context.Context, inst api.Module, args []value.Value) (value.Value, error) {
+	params := make([]uint64, 0, len(args))
+	var allocs []uint64 // ptr, len pairs to dealloc
+	for i, vt := range m.Args {
+		switch vt {
+		case value.IntType:
+			iv, ok := value.ValueToInt64(args[i])
+			if !ok {
+				return nil, fmt.Errorf("could not convert %v to int", args[i])
+			}
+			params = append(params, api.EncodeI64(iv))
+		case value.NumberType:
+			fv, ok := value.ValueToFloat64(args[i])
+			if !ok {
+				return nil, fmt.Errorf("could not convert %v to number", args[i])
+			}
+			params = append(params, api.EncodeF64(fv))
+		case value.BoolType:
+			bv, ok := value.ValueToBool(args[i])
+			if !ok {
+				return nil, fmt.Errorf("could not convert %v to bool", args[i])
+			}
+			var b uint64
+			if bv {
+				b = 1
+			}
+			params = append(params, b)
+		case value.StringType:
+			s := args[i].ToString()
+			ptr, err := writeString(ctx, inst, s)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, ptr, uint64(len(s)))
+			allocs = append(allocs, ptr, uint64(len(s)))
+		}
+	}
+
+	results, err := inst.ExportedFunction(m.conf.Export).Call(ctx, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	var v value.Value
+	switch m.Returns {
+	case value.IntType:
+		v = value.NewIntValue(int64(results[0]))
+	case value.NumberType:
+		v = value.NewNumberValue(api.DecodeF64(results[0]))
+	case value.BoolType:
+		v = value.NewBoolValue(api.DecodeI32(results[0]) != 0)
+	case value.StringType:
+		ptr, n := uint32(results[0]>>32), uint32(results[0])
+		by, ok := inst.Memory().Read(ptr, n)
+		if !ok {
+			return nil, fmt.Errorf("result string [%d:%d] out of memory range", ptr, ptr+n)
+		}
+		v = value.NewStringValue(string(by))
+		allocs = append(allocs, uint64(ptr), uint64(n))
+	}
+
+	if dealloc := inst.ExportedFunction("dealloc"); dealloc != nil {
+		for i := 0; i < len(allocs); i += 2 {
+			if _, err := dealloc.Call(ctx, allocs[i], allocs[i+1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+func writeString(ctx context.Context, inst api.Module, s string) (uint64, error) {
+	res, err := inst.ExportedFunction("alloc").Call(ctx, uint64(len(s)))
+	if err != nil {
+		return 0, err
+	}
+	ptr := uint32(res[0])
+	if !inst.Memory().Write(ptr, []byte(s)) {
+		return 0, fmt.Errorf("alloc(%d) returned %d out of memory range", len(s), ptr)
+	}
+	return uint64(ptr), nil
+}
+
+// acquire an idle instance, or a new one if there are fewer than
+// MaxInstances, else wait for one to be released or discarded until ctx
+// is done.
+func (m *Func) acquire(ctx context.Context) (api.Module, error) {
+	for {
+		select {
+		case inst := <-m.idle:
+			return inst, nil
+		default:
+		}
+		m.mu.Lock()
+		if m.closed {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("wasm function %s is closed", m.Name)
+		}
+		if m.live < m.conf.MaxInstances {
+			m.live++
+			m.mu.Unlock()
+			break
+		}
+		freed := m.freed
+		m.mu.Unlock()
+		select {
+		case inst := <-m.idle:
+			return inst, nil
+		case <-freed:
+			// a discarded instance may be replaced
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wasm function %s: no instance free within %v", m.Name, m.conf.Timeout)
+		}
+	}
+
+	// anonymous so the module may be instantiated many times, reactor
+	// modules are initialized but commands are not run, which are limited
+	// in time as a call is
+	ictx, cancel := context.WithTimeout(context.Background(), m.conf.Timeout)
+	defer cancel()
+	mc := wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize")
+	inst, err := m.rt.InstantiateModule(ictx, m.compiled, mc)
+	if err != nil {
+		m.lower()
+		return nil, fmt.Errorf("could not instantiate wasm for %s: %v", m.Name, err)
+	}
+	return inst, nil
+}
+
+// lower the live instances and wake up acquires waiting for one.
+func (m *Func) lower() {
+	m.mu.Lock()
+	m.live--
+	close(m.freed)
+	m.freed = make(chan struct{})
+	m.mu.Unlock()
+}
+
+func (m *Func) release(inst api.Module) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		inst.Close(context.Background())
+		m.live--
+		return
+	}
+	// never blocks, there are at most MaxInstances
+	m.idle <- inst
+}
+
+// discard an instance that is no longer usable.
+func (m *Func) discard(inst api.Module) {
+	inst.Close(context.Background())
+	m.lower()
+}
+
+// Close the runtime and all module instances.
+func (m *Func) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	// closing the runtime closes the idle instances
+	return m.rt.Close(context.Background())
+}
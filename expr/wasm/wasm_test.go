@@ -0,0 +1,382 @@
+package wasm
+
+import (
+	"context"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
+)
+
+// wasm value types and opcodes used by the hand assembled test modules
+const (
+	i32 = 0x7f
+	i64 = 0x7e
+	f64 = 0x7c
+
+	opEnd           = 0x0b
+	opLoop          = 0x03
+	opBr            = 0x0c
+	opLocalGet      = 0x20
+	opGlobalGet     = 0x23
+	opGlobalSet     = 0x24
+	opMemGrow       = 0x40
+	opI32Const      = 0x41
+	opI64Const      = 0x42
+	opF64Const      = 0x44
+	opI32Eqz        = 0x45
+	opI32Add        = 0x6a
+	opI64Add        = 0x7c
+	opI64Or         = 0x84
+	opI64Shl        = 0x86
+	opF64Mul        = 0xa2
+	opI32WrapI64    = 0xa7
+	opI64ExtendI32S = 0xac
+	opI64ExtendI32U = 0xad
+)
+
+type wasmFunc struct {
+	name    string
+	params  []byte
+	results []byte
+	body    []byte
+}
+
+// wasmModule assembles a module of exported funcs, memory of pages and a
+// bump allocator global when memory is used.
+type wasmModule struct {
+	funcs   []wasmFunc
+	pages   uint32
+	imports bool
+}
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func vec(items ...[]byte) []byte {
+	out := uleb(uint64(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func name(s string) []byte { return append(uleb(uint64(len(s))), s...) }
+
+func section(id byte, payload []byte) []byte {
+	return append(append([]byte{id}, uleb(uint64(len(payload)))...), payload...)
+}
+
+func (m *wasmModule) bytes() []byte {
+	out := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	var types, funcs, exports, codes [][]byte
+	for i, fn := range m.funcs {
+		types = append(types, append(append([]byte{0x60}, vec(split(fn.params)...)...), vec(split(fn.results)...)...))
+		funcs = append(funcs, uleb(uint64(i)))
+		exports = append(exports, append(name(fn.name), append([]byte{0x00}, uleb(uint64(i))...)...))
+		code := append(vec(), fn.body...)
+		codes = append(codes, append(uleb(uint64(len(code))), code...))
+	}
+	out = append(out, section(1, vec(types...))...)
+	if m.imports {
+		out = append(out, section(2, vec(append(append(name("env"), name("log")...), 0x00, 0x00)))...)
+	}
+	out = append(out, section(3, vec(funcs...))...)
+	if m.pages > 0 {
+		out = append(out, section(5, vec(append([]byte{0x00}, uleb(uint64(m.pages))...)))...)
+		heap := append([]byte{i32, 0x01, opI32Const}, sleb(1024)...)
+		out = append(out, section(6, vec(append(heap, opEnd)))...)
+		exports = append(exports, append(name("memory"), 0x02, 0x00))
+	}
+	out = append(out, section(7, vec(exports...))...)
+	out = append(out, section(10, vec(codes...))...)
+	return out
+}
+
+func split(b []byte) [][]byte {
+	out := make([][]byte, len(b))
+	for i := range b {
+		out[i] = b[i : i+1]
+	}
+	return out
+}
+
+var (
+	addFunc = wasmFunc{"add", []byte{i64, i64}, []byte{i64},
+		[]byte{opLocalGet, 0, opLocalGet, 1, opI64Add, opEnd}}
+	halfFunc = wasmFunc{"half", []byte{f64}, []byte{f64},
+		append(append([]byte{opLocalGet, 0, opF64Const}, f64Bytes(0.5)...), opF64Mul, opEnd)}
+	notFunc = wasmFunc{"not", []byte{i32}, []byte{i32},
+		[]byte{opLocalGet, 0, opI32Eqz, opEnd}}
+	spinFunc = wasmFunc{"spin", []byte{i64}, []byte{i64},
+		[]byte{opLoop, 0x40, opBr, 0, opEnd, opI64Const, 0, opEnd}}
+	growFunc = wasmFunc{"grow", []byte{i64}, []byte{i64},
+		[]byte{opLocalGet, 0, opI32WrapI64, opMemGrow, 0, opI64ExtendI32S, opEnd}}
+	// alloc(n) returns the heap pointer and bumps it by n
+	allocFunc = wasmFunc{"alloc", []byte{i32}, []byte{i32},
+		[]byte{opGlobalGet, 0, opGlobalGet, 0, opLocalGet, 0, opI32Add, opGlobalSet, 0, opEnd}}
+	strlenFunc = wasmFunc{"strlen", []byte{i32, i32}, []byte{i64},
+		[]byte{opLocalGet, 1, opI64ExtendI32U, opEnd}}
+	// echo(ptr, len) returns its argument, ptr<<32 | len
+	echoFunc = wasmFunc{"echo", []byte{i32, i32}, []byte{i64},
+		[]byte{opLocalGet, 0, opI64ExtendI32U, opI64Const, 32, opI64Shl, opLocalGet, 1, opI64ExtendI32U, opI64Or, opEnd}}
+)
+
+func f64Bytes(f float64) []byte {
+	bits := math.Float64bits(f)
+	out := make([]byte, 8)
+	for i := range out {
+		out[i] = byte(bits >> (8 * uint(i)))
+	}
+	return out
+}
+
+func newFunc(t *testing.T, mod *wasmModule, export string, args []value.ValueType, returns value.ValueType, conf Config) *Func {
+	conf.Export = export
+	fn, err := New(export, mod.bytes(), args, returns, conf)
+	if !assert.Nil(t, err, "%s: %v", export, err) {
+		t.FailNow()
+	}
+	return fn
+}
+
+func TestScalarTypes(t *testing.T) {
+	mod := &wasmModule{funcs: []wasmFunc{addFunc, halfFunc, notFunc}}
+
+	add := newFunc(t, mod, "add", []value.ValueType{value.IntType, value.IntType}, value.IntType, Config{})
+	defer add.Close()
+	v, err := add.Call([]value.Value{value.NewIntValue(40), value.NewStringValue("2")})
+	assert.Nil(t, err)
+	assert.Equal(t, value.NewIntValue(42), v)
+
+	half := newFunc(t, mod, "half", []value.ValueType{value.NumberType}, value.NumberType, Config{})
+	defer half.Close()
+	v, err = half.Call([]value.Value{value.NewNumberValue(3)})
+	assert.Nil(t, err)
+	assert.Equal(t, value.NewNumberValue(1.5), v)
+
+	not := newFunc(t, mod, "not", []value.ValueType{value.BoolType}, value.BoolType, Config{})
+	defer not.Close()
+	v, err = not.Call([]value.Value{value.NewBoolValue(false)})
+	assert.Nil(t, err)
+	assert.Equal(t, value.NewBoolValue(true), v)
+
+	// nil arguments are not passed to wasm
+	v, err = add.Call([]value.Value{value.NewIntValue(1), value.NilValueVal})
+	assert.Nil(t, err)
+	assert.Nil(t, v)
+
+	_, err = add.Call([]value.Value{value.NewIntValue(1), value.NewStringValue("abc")})
+	assert.NotNil(t, err)
+}
+
+func TestStrings(t *testing.T) {
+	mod := &wasmModule{funcs: []wasmFunc{allocFunc, strlenFunc, echoFunc}, pages: 1}
+
+	strlen := newFunc(t, mod, "strlen", []value.ValueType{value.StringType}, value.IntType, Config{})
+	defer strlen.Close()
+	v, err := strlen.Call([]value.Value{value.NewStringValue("héllo")})
+	assert.Nil(t, err)
+	assert.Equal(t, value.NewIntValue(6), v)
+
+	echo := newFunc(t, mod, "echo", []value.ValueType{value.StringType}, value.StringType, Config{})
+	defer echo.Close()
+	for _, s := range []string{"héllo", "", "world"} {
+		v, err = echo.Call([]value.Value{value.NewStringValue(s)})
+		assert.Nil(t, err)
+		assert.Equal(t, value.NewStringValue(s), v)
+	}
+
+	// strings require memory and alloc
+	_, err = New("strlen", (&wasmModule{funcs: []wasmFunc{strlenFunc}}).bytes(),
+		[]value.ValueType{value.StringType}, value.IntType, Config{})
+	assert.NotNil(t, err)
+}
+
+func TestLimits(t *testing.T) {
+	mod := &wasmModule{funcs: []wasmFunc{spinFunc, growFunc}, pages: 1}
+
+	spin := newFunc(t, mod, "spin", []value.ValueType{value.IntType}, value.IntType, Config{Timeout: 20 * time.Millisecond})
+	defer spin.Close()
+	start := time.Now()
+	_, err := spin.Call([]value.Value{value.NewIntValue(1)})
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(start) < time.Second, "took %v", time.Since(start))
+	// the timed out instance was discarded, a new one times out again
+	_, err = spin.Call([]value.Value{value.NewIntValue(1)})
+	assert.NotNil(t, err)
+
+	grow := newFunc(t, mod, "grow", []value.ValueType{value.IntType}, value.IntType, Config{MemoryPages: 2})
+	defer grow.Close()
+	v, err := grow.Call([]value.Value{value.NewIntValue(1)})
+	assert.Nil(t, err)
+	assert.Equal(t, value.NewIntValue(1), v)
+	// memory.grow fails past the limit
+	v, err = grow.Call([]value.Value{value.NewIntValue(8)})
+	assert.Nil(t, err)
+	assert.Equal(t, value.NewIntValue(-1), v)
+
+	// module memory larger than the limit can't be loaded
+	_, err = New("grow", (&wasmModule{funcs: []wasmFunc{growFunc}, pages: 4}).bytes(),
+		[]value.ValueType{value.IntType}, value.IntType, Config{MemoryPages: 2})
+	assert.NotNil(t, err)
+}
+
+func TestMaxInstances(t *testing.T) {
+	mod := &wasmModule{funcs: []wasmFunc{addFunc}}
+	add := newFunc(t, mod, "add", []value.ValueType{value.IntType, value.IntType}, value.IntType, Config{MaxInstances: 2})
+	defer add.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := add.Call([]value.Value{value.NewIntValue(int64(i)), value.NewIntValue(1)})
+			assert.Nil(t, err)
+			assert.Equal(t, value.NewIntValue(int64(i+1)), v)
+		}(i)
+	}
+	wg.Wait()
+	add.mu.Lock()
+	assert.True(t, add.live <= 2, "live instances %d", add.live)
+	add.mu.Unlock()
+
+	_, err := ConfigFromWith(map[string]interface{}{"max_instances": 0})
+	assert.NotNil(t, err)
+	conf, err := ConfigFromWith(map[string]interface{}{"max_instances": 3})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, conf.MaxInstances)
+
+	// a waiter gets a new instance when one in use is discarded
+	one := newFunc(t, mod, "add", []value.ValueType{value.IntType, value.IntType}, value.IntType, Config{MaxInstances: 1, Timeout: time.Second})
+	defer one.Close()
+	inst, err := one.acquire(context.Background())
+	assert.Nil(t, err)
+	got := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		waited, err := one.acquire(ctx)
+		if err == nil {
+			one.release(waited)
+		}
+		got <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	started := time.Now()
+	one.discard(inst)
+	assert.Nil(t, <-got)
+	assert.True(t, time.Since(started) < 500*time.Millisecond, "waited %v", time.Since(started))
+
+	// a closed function errors instead of calling a closed runtime
+	add.Close()
+	_, err = add.Call([]value.Value{value.NewIntValue(1), value.NewIntValue(1)})
+	assert.NotNil(t, err)
+}
+
+func TestSignatureErrors(t *testing.T) {
+	by := (&wasmModule{funcs: []wasmFunc{addFunc}}).bytes()
+	for _, test := range []struct {
+		export  string
+		args    []value.ValueType
+		returns value.ValueType
+	}{
+		{"missing", []value.ValueType{value.IntType, value.IntType}, value.IntType},
+		{"add", []value.ValueType{value.IntType}, value.IntType},
+		{"add", []value.ValueType{value.IntType, value.IntType}, value.NumberType},
+		{"add", []value.ValueType{value.TimeType, value.IntType}, value.IntType},
+	} {
+		_, err := New(test.export, by, test.args, test.returns, Config{})
+		assert.NotNil(t, err, "%s%v %s", test.export, test.args, test.returns)
+	}
+	_, err := New("add", []byte("not wasm"), []value.ValueType{value.IntType, value.IntType}, value.IntType, Config{})
+	assert.NotNil(t, err)
+
+	// modules can't import host functions
+	_, err = New("add", (&wasmModule{funcs: []wasmFunc{addFunc}, imports: true}).bytes(),
+		[]value.ValueType{value.IntType, value.IntType}, value.IntType, Config{})
+	assert.NotNil(t, err)
+}
+
+func TestUserFuncLanguage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "qlbridge_wasm")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "add.wasm")
+	assert.Nil(t, ioutil.WriteFile(path, (&wasmModule{funcs: []wasmFunc{addFunc}}).bytes(), 0644))
+
+	// only modules in the module directory may be loaded
+	args := []value.ValueType{value.IntType, value.IntType}
+	ModuleDir = ""
+	_, err = Load("add", "add.wasm", args, value.IntType, Config{})
+	assert.NotNil(t, err)
+	ModuleDir = filepath.Join(dir, "udfs")
+	defer func() { ModuleDir = "" }()
+	assert.Nil(t, os.Mkdir(ModuleDir, 0755))
+	assert.Nil(t, os.Rename(path, filepath.Join(ModuleDir, "add.wasm")))
+	assert.Nil(t, os.Symlink(filepath.Join(dir, "secret"), filepath.Join(ModuleDir, "escape.wasm")))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "secret"), []byte("x"), 0644))
+	for _, bad := range []string{filepath.Join(ModuleDir, "add.wasm"), "../udfs/add.wasm", "sub/../../secret", "escape.wasm", ""} {
+		_, err = Load("add", bad, args, value.IntType, Config{})
+		assert.NotNil(t, err, bad)
+	}
+	add, err := Load("add", "add.wasm", args, value.IntType, Config{})
+	assert.Nil(t, err)
+	add.Close()
+
+	uf := expr.NewExternalFunc("plus", []value.ValueType{value.IntType, value.IntType}, value.IntType, "wasm", "add.wasm")
+	uf.With = map[string]interface{}{"export": "add", "timeout": "50ms"}
+	assert.Nil(t, uf.Load())
+	defer uf.Close()
+	assert.Equal(t, value.IntType, uf.Type())
+
+	fr := expr.NewFuncRegistry()
+	fr.Add(uf.Name, uf)
+	n, err := expr.ParseExprWithFuncs(expr.NewLexTokenPager(lex.NewLexer(`plus(n, 2)`, lex.LogicalExpressionDialect)), fr)
+	assert.Nil(t, err)
+	ctx := datasource.NewContextSimpleNative(map[string]interface{}{"n": 5})
+	v, ok := vm.Eval(ctx, n)
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), v.Value())
+
+	_, err = expr.ParseExprWithFuncs(expr.NewLexTokenPager(lex.NewLexer(`plus(n)`, lex.LogicalExpressionDialect)), fr)
+	assert.NotNil(t, err)
+
+	uf = expr.NewExternalFunc("plus", []value.ValueType{value.IntType, value.IntType}, value.IntType, "wasm", "add.wasm")
+	uf.With = map[string]interface{}{"timeout": "soon"}
+	assert.NotNil(t, uf.Load())
+}
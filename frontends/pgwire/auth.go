@@ -0,0 +1,173 @@
+package pgwire
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/araddon/qlbridge/schema"
+)
+
+const (
+	// authentication request codes of the Authentication message
+	authOk           = 0
+	authSASL         = 10
+	authSASLContinue = 11
+	authSASLFinal    = 12
+
+	scramSHA256 = "SCRAM-SHA-256"
+)
+
+// authenticate user with SCRAM-SHA-256 (RFC 5802, 7677) against the
+// credential of its account.  Unknown users and users without a password
+// run the exchange against a throw away credential so they fail the same
+// way as a wrong password.
+func (c *conn) authenticate(user string) (err error) {
+	defer func() {
+		// short/malformed messages panic in readBuf
+		if r := recover(); r != nil {
+			if pe, ok := r.(*Error); ok {
+				err = pe
+				return
+			}
+			panic(r)
+		}
+	}()
+	failed := NewError(CodeInvalidPassword, "password authentication failed for user %q", user)
+
+	var cred *schema.Credential
+	if acct, ok := c.srv.reg.User(user); ok {
+		cred = acct.Credential
+	}
+	known := cred != nil
+	if !known {
+		if cred, err = schema.NewCredential(randomNonce()); err != nil {
+			return err
+		}
+	}
+
+	c.wr.begin(msgAuthentication)
+	c.wr.int32(authSASL)
+	c.wr.string(scramSHA256)
+	c.wr.byte(0)
+	if err := c.wr.end(); err != nil {
+		return err
+	}
+	if err := c.wr.flush(); err != nil {
+		return err
+	}
+
+	// SASLInitialResponse:  mechanism, then client-first-message
+	//    n,,n=,r=<client nonce>
+	body, err := c.readPassword()
+	if err != nil {
+		return err
+	}
+	if mech := body.string(); mech != scramSHA256 {
+		return NewError(CodeInvalidAuthSpec, "unsupported SASL mechanism %q", mech)
+	}
+	clientFirst := string(body.next(body.int32()))
+	parts := strings.SplitN(clientFirst, ",", 3)
+	if len(parts) != 3 {
+		return NewError(CodeProtocolViolation, "malformed SCRAM message")
+	}
+	switch parts[0] {
+	case "n", "y":
+	default:
+		// p=<cb-name> is channel binding, there is no TLS to bind to
+		return NewError(CodeFeatureNotSupported, "SCRAM channel binding is not supported")
+	}
+	gs2Header, clientFirstBare := parts[0]+","+parts[1]+",", parts[2]
+	clientNonce := scramAttr(clientFirstBare, 'r')
+	if clientNonce == "" {
+		return NewError(CodeProtocolViolation, "malformed SCRAM message")
+	}
+
+	nonce := clientNonce + randomNonce()
+	serverFirst := fmt.Sprintf("r=%s,s=%s,i=%d", nonce,
+		base64.StdEncoding.EncodeToString(cred.Salt), cred.Iterations)
+	c.wr.begin(msgAuthentication)
+	c.wr.int32(authSASLContinue)
+	c.wr.bytes([]byte(serverFirst))
+	if err := c.wr.end(); err != nil {
+		return err
+	}
+	if err := c.wr.flush(); err != nil {
+		return err
+	}
+
+	// SASLResponse:  client-final-message
+	//    c=biws,r=<nonce>,p=<proof>
+	body, err = c.readPassword()
+	if err != nil {
+		return err
+	}
+	clientFinal := string(body)
+	i := strings.LastIndex(clientFinal, ",p=")
+	if i < 0 {
+		return NewError(CodeProtocolViolation, "malformed SCRAM message")
+	}
+	withoutProof := clientFinal[:i]
+	if scramAttr(withoutProof, 'c') != base64.StdEncoding.EncodeToString([]byte(gs2Header)) ||
+		scramAttr(withoutProof, 'r') != nonce {
+		return failed
+	}
+	proof, err := base64.StdEncoding.DecodeString(clientFinal[i+3:])
+	if err != nil || len(proof) != sha256.Size {
+		return failed
+	}
+
+	// ClientKey = ClientProof XOR HMAC(StoredKey, AuthMessage)
+	authMessage := []byte(clientFirstBare + "," + serverFirst + "," + withoutProof)
+	clientKey := hmacSHA256(cred.StoredKey, authMessage)
+	for j := range clientKey {
+		clientKey[j] ^= proof[j]
+	}
+	storedKey := sha256.Sum256(clientKey)
+	if !hmac.Equal(storedKey[:], cred.StoredKey) || !known {
+		return failed
+	}
+
+	c.wr.begin(msgAuthentication)
+	c.wr.int32(authSASLFinal)
+	c.wr.bytes([]byte("v=" + base64.StdEncoding.EncodeToString(hmacSHA256(cred.ServerKey, authMessage))))
+	return c.wr.end()
+}
+
+// readPassword reads the next message, which must be a password message
+// ie a SASL response.
+func (c *conn) readPassword() (readBuf, error) {
+	typ, body, err := c.rd.readMsg()
+	if err != nil {
+		return nil, err
+	}
+	if typ != msgPassword {
+		return nil, NewError(CodeProtocolViolation, "expected password response, got %q", typ)
+	}
+	return body, nil
+}
+
+// scramAttr the value of attribute name of a SCRAM message, "" if missing.
+func scramAttr(msg string, name byte) string {
+	for _, attr := range strings.Split(msg, ",") {
+		if len(attr) > 1 && attr[0] == name && attr[1] == '=' {
+			return attr[2:]
+		}
+	}
+	return ""
+}
+
+func randomNonce() string {
+	b := make([]byte, 18)
+	rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func hmacSHA256(key, msg []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(msg)
+	return h.Sum(nil)
+}
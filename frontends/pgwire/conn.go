@@ -0,0 +1,760 @@
+package pgwire
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// errTerminate is client asked us to close connection.
+	errTerminate = fmt.Errorf("pgwire: terminate")
+)
+
+type (
+	// conn is a single client connection, messages are handled serially.
+	conn struct {
+		srv      *Server
+		nc       net.Conn
+		rd       *msgReader
+		wr       *msgWriter
+		startup  map[string]string
+		user     string // account statements run as, empty if there are none
+		schema   *schema.Schema
+		session  expr.ContextReadWriter
+		stmts    map[string]*prepared
+		portals  map[string]*portal
+		pid      uint32
+		secret   uint32
+		pidValid bool
+		// after an error in extended protocol, skip messages till Sync
+		ignoreTillSync bool
+
+		mu      sync.Mutex
+		running *resultSet
+	}
+	// prepared is a statement from a Parse message.
+	prepared struct {
+		query     string
+		paramOids []uint32
+	}
+	// portal is a bound statement from a Bind message.
+	portal struct {
+		query   string
+		params  []param
+		formats []int
+		rs      *resultSet
+	}
+	// resultSet is a planned job whose results are being read.
+	resultSet struct {
+		ctx     *plan.Context
+		job     *exec.JobExecutor
+		cols    []*column
+		rows    *exec.ResultWriter
+		execw   *exec.ResultExecWriter
+		ct      int
+		started bool
+		done    bool
+	}
+)
+
+func (c *conn) serve() error {
+	if err := c.handleStartup(); err != nil {
+		if pe, ok := err.(*Error); ok {
+			pe.Severity = "FATAL"
+			c.wr.writeError(pe)
+			c.wr.flush()
+		}
+		return err
+	}
+	defer c.closeAll()
+
+	for {
+		typ, body, err := c.rd.readMsg()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if c.ignoreTillSync && typ != msgSync && typ != msgTerminate {
+			continue
+		}
+		err = c.handleMsg(typ, body)
+		switch err := err.(type) {
+		case nil:
+		case *Error:
+			if werr := c.wr.writeError(err); werr != nil {
+				return werr
+			}
+			if typ == msgQuery {
+				if werr := c.readyForQuery(); werr != nil {
+					return werr
+				}
+			} else {
+				c.ignoreTillSync = true
+			}
+		default:
+			if err == errTerminate {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (c *conn) handleMsg(typ byte, body readBuf) (err error) {
+	defer func() {
+		// short/malformed messages panic in readBuf
+		if r := recover(); r != nil {
+			if pe, ok := r.(*Error); ok {
+				err = pe
+				return
+			}
+			panic(r)
+		}
+	}()
+	switch typ {
+	case msgQuery:
+		return c.handleQuery(body.string())
+	case msgParse:
+		return c.handleParse(body)
+	case msgBind:
+		return c.handleBind(body)
+	case msgDescribe:
+		return c.handleDescribe(body)
+	case msgExecute:
+		return c.handleExecute(body)
+	case msgClose:
+		return c.handleClose(body)
+	case msgSync:
+		c.ignoreTillSync = false
+		c.closePortals()
+		return c.readyForQuery()
+	case msgFlush:
+		return c.wr.flush()
+	case msgTerminate:
+		return errTerminate
+	}
+	return NewError(CodeProtocolViolation, "unsupported message type %q", typ)
+}
+
+func (c *conn) handleStartup() error {
+	for {
+		body, err := c.rd.readStartup()
+		if err != nil {
+			return err
+		}
+		switch code := body.int32(); code {
+		case sslRequestCode, gssEncRequest:
+			// We don't do encryption, client may continue un-encrypted
+			if err := c.wr.w.WriteByte('N'); err != nil {
+				return err
+			}
+			if err := c.wr.flush(); err != nil {
+				return err
+			}
+			continue
+		case cancelRequest:
+			pid := uint32(body.int32())
+			secret := uint32(body.int32())
+			c.srv.cancel(pid, secret)
+			return errTerminate
+		case protocolVersion3:
+			for len(body) > 0 {
+				k := body.string()
+				if k == "" {
+					break
+				}
+				c.startup[k] = body.string()
+			}
+		default:
+			return NewError(CodeProtocolViolation, "unsupported frontend protocol %d.%d", code>>16, code&0xffff)
+		}
+		break
+	}
+
+	dbName := c.startup["database"]
+	if dbName == "" {
+		dbName = c.startup["user"]
+	}
+	s, ok := c.srv.reg.Schema(dbName)
+	if !ok || s == nil {
+		return NewError(CodeInvalidCatalogName, "database %q does not exist", dbName)
+	}
+	// Once there are accounts the startup user is the account privileges
+	// are checked for, it must know its password unless the server trusts
+	// its clients.
+	if len(c.srv.reg.Users()) > 0 {
+		user := strings.ToLower(c.startup["user"])
+		if c.srv.Trust {
+			if _, ok := c.srv.reg.User(user); !ok {
+				return NewError(CodeInvalidAuthSpec, "role %q does not exist", c.startup["user"])
+			}
+		} else if err := c.authenticate(user); err != nil {
+			return err
+		}
+		c.user = user
+	}
+	c.schema = s
+	c.session = datasource.NewMySqlSessionVars()
+	c.srv.register(c)
+
+	c.wr.begin(msgAuthentication)
+	c.wr.int32(authOk)
+	if err := c.wr.end(); err != nil {
+		return err
+	}
+	params := [][2]string{
+		{"server_version", c.srv.ServerVersion},
+		{"server_encoding", "UTF8"},
+		{"client_encoding", "UTF8"},
+		{"DateStyle", "ISO, MDY"},
+		{"TimeZone", "UTC"},
+		{"integer_datetimes", "on"},
+		{"standard_conforming_strings", "on"},
+	}
+	for _, kv := range params {
+		c.wr.begin(msgParameterStatus)
+		c.wr.string(kv[0])
+		c.wr.string(kv[1])
+		if err := c.wr.end(); err != nil {
+			return err
+		}
+	}
+	c.wr.begin(msgBackendKeyData)
+	c.wr.int32(int(c.pid))
+	c.wr.int32(int(c.secret))
+	if err := c.wr.end(); err != nil {
+		return err
+	}
+	return c.readyForQuery()
+}
+
+func (c *conn) readyForQuery() error {
+	c.wr.begin(msgReadyForQuery)
+	c.wr.byte('I')
+	if err := c.wr.end(); err != nil {
+		return err
+	}
+	return c.wr.flush()
+}
+
+// handleQuery simple query protocol, may contain multiple statements.
+func (c *conn) handleQuery(query string) error {
+	stmts := splitStatements(query)
+	if len(stmts) == 0 {
+		c.wr.begin(msgEmptyQueryResponse)
+		if err := c.wr.end(); err != nil {
+			return err
+		}
+		return c.readyForQuery()
+	}
+	for _, sql := range stmts {
+		rs, err := c.plan(sql, nil, nil)
+		if err != nil {
+			return err
+		}
+		if rs.cols != nil {
+			if err := c.writeRowDescription(rs.cols); err != nil {
+				rs.close()
+				return err
+			}
+		}
+		if _, err := c.execute(rs, 0); err != nil {
+			rs.close()
+			return err
+		}
+	}
+	return c.readyForQuery()
+}
+
+func (c *conn) handleParse(body readBuf) error {
+	name := body.string()
+	query := body.string()
+	n := body.int16()
+	ps := &prepared{query: strings.TrimSpace(query)}
+	for i := 0; i < n; i++ {
+		ps.paramOids = append(ps.paramOids, uint32(body.int32()))
+	}
+	// un-specified param types are unknown
+	for i := len(ps.paramOids); i < countParams(ps.query); i++ {
+		ps.paramOids = append(ps.paramOids, pgOidUnknown)
+	}
+	if name != "" {
+		if _, exists := c.stmts[name]; exists {
+			return NewError("42P05", "prepared statement %q already exists", name)
+		}
+	}
+	c.stmts[name] = ps
+	c.wr.begin(msgParseComplete)
+	return c.wr.end()
+}
+
+func (c *conn) handleBind(body readBuf) error {
+	portalName := body.string()
+	stmtName := body.string()
+	ps, ok := c.stmts[stmtName]
+	if !ok {
+		return NewError(CodeInvalidPrepared, "prepared statement %q does not exist", stmtName)
+	}
+	paramFormats := make([]int, body.int16())
+	for i := range paramFormats {
+		paramFormats[i] = body.int16()
+	}
+	n := body.int16()
+	if n != len(ps.paramOids) {
+		return NewError(CodeProtocolViolation, "bind message supplies %d parameters, but prepared statement %q requires %d",
+			n, stmtName, len(ps.paramOids))
+	}
+	params := make([]param, n)
+	for i := 0; i < n; i++ {
+		var raw []byte
+		if l := body.int32(); l >= 0 {
+			raw = body.next(l)
+		}
+		p, err := paramLiteral(ps.paramOids[i], formatCode(paramFormats, i), raw)
+		if err != nil {
+			return err
+		}
+		params[i] = p
+	}
+	resultFormats := make([]int, body.int16())
+	for i := range resultFormats {
+		resultFormats[i] = body.int16()
+	}
+	query, err := bindParams(ps.query, params)
+	if err != nil {
+		return err
+	}
+	if existing, ok := c.portals[portalName]; ok {
+		if portalName != "" {
+			return NewError("42P03", "portal %q already exists", portalName)
+		}
+		existing.close()
+	}
+	c.portals[portalName] = &portal{query: query, params: params, formats: resultFormats}
+	c.wr.begin(msgBindComplete)
+	return c.wr.end()
+}
+
+func (c *conn) handleDescribe(body readBuf) error {
+	typ := body.byte()
+	name := body.string()
+	switch typ {
+	case 'S':
+		ps, ok := c.stmts[name]
+		if !ok {
+			return NewError(CodeInvalidPrepared, "prepared statement %q does not exist", name)
+		}
+		c.wr.begin(msgParameterDescription)
+		c.wr.int16(len(ps.paramOids))
+		for _, oid := range ps.paramOids {
+			if oid == pgOidUnknown {
+				oid = datasource.PgOidText
+			}
+			c.wr.int32(int(oid))
+		}
+		if err := c.wr.end(); err != nil {
+			return err
+		}
+		if ps.query == "" {
+			return c.noData()
+		}
+		// we need to plan to find the result columns, placeholders are planned
+		// with a literal that is valid most everywhere
+		placeholders := make([]param, len(ps.paramOids))
+		for i := range placeholders {
+			placeholders[i] = param{lit: "0"}
+		}
+		query, err := bindParams(ps.query, placeholders)
+		if err != nil {
+			return err
+		}
+		rs, err := c.plan(query, nil, nil)
+		if err != nil {
+			return err
+		}
+		rs.close()
+		if rs.cols == nil {
+			return c.noData()
+		}
+		return c.writeRowDescription(rs.cols)
+	case 'P':
+		p, ok := c.portals[name]
+		if !ok {
+			return NewError(CodeInvalidCursor, "portal %q does not exist", name)
+		}
+		if p.query == "" {
+			return c.noData()
+		}
+		if err := c.planPortal(p); err != nil {
+			return err
+		}
+		if p.rs.cols == nil {
+			return c.noData()
+		}
+		return c.writeRowDescription(p.rs.cols)
+	}
+	return NewError(CodeProtocolViolation, "invalid describe type %q", typ)
+}
+
+func (c *conn) handleExecute(body readBuf) error {
+	name := body.string()
+	maxRows := body.int32()
+	p, ok := c.portals[name]
+	if !ok {
+		return NewError(CodeInvalidCursor, "portal %q does not exist", name)
+	}
+	if p.query == "" {
+		c.wr.begin(msgEmptyQueryResponse)
+		return c.wr.end()
+	}
+	if err := c.planPortal(p); err != nil {
+		return err
+	}
+	if p.rs.done {
+		return NewError(CodeInvalidCursor, "portal %q cannot be run", name)
+	}
+	suspended, err := c.execute(p.rs, maxRows)
+	if err != nil {
+		p.close()
+		return err
+	}
+	if suspended {
+		c.wr.begin(msgPortalSuspended)
+		return c.wr.end()
+	}
+	return nil
+}
+
+func (c *conn) handleClose(body readBuf) error {
+	typ := body.byte()
+	name := body.string()
+	switch typ {
+	case 'S':
+		delete(c.stmts, name)
+	case 'P':
+		if p, ok := c.portals[name]; ok {
+			p.close()
+			delete(c.portals, name)
+		}
+	default:
+		return NewError(CodeProtocolViolation, "invalid close type %q", typ)
+	}
+	c.wr.begin(msgCloseComplete)
+	return c.wr.end()
+}
+
+func (c *conn) noData() error {
+	c.wr.begin(msgNoData)
+	return c.wr.end()
+}
+
+func (c *conn) writeRowDescription(cols []*column) error {
+	c.wr.begin(msgRowDescription)
+	c.wr.int16(len(cols))
+	for _, col := range cols {
+		c.wr.string(col.name)
+		c.wr.int32(0) // table oid
+		c.wr.int16(0) // column attribute number
+		c.wr.int32(int(col.oid))
+		c.wr.int16(int(datasource.PgTypeLen(col.oid)))
+		c.wr.int32(-1) // type modifier
+		c.wr.int16(col.format)
+	}
+	return c.wr.end()
+}
+
+// planPortal plans the portal's query once.
+func (c *conn) planPortal(p *portal) error {
+	if p.rs != nil {
+		return nil
+	}
+	rs, err := c.plan(p.query, p.params, p.formats)
+	if err != nil {
+		return err
+	}
+	p.rs = rs
+	return nil
+}
+
+// plan parses and plans a statement into a job ready to run, binding the
+// text of its string params into the parsed statement.
+func (c *conn) plan(query string, params []param, formats []int) (*resultSet, error) {
+
+	ctx := plan.NewContext(query)
+	ctx.Schema = c.schema
+	ctx.Session = c.session
+	ctx.User = c.user
+
+	for _, p := range params {
+		if !p.isText {
+			continue
+		}
+		var fr expr.FuncResolver
+		if c.schema != nil {
+			fr = c.schema
+		}
+		stmt, err := rel.ParseSqlResolver(query, fr)
+		if err != nil {
+			return nil, pgErrorFromPlan(ctx, err)
+		}
+		bindText(stmt, params)
+		ctx.Stmt = stmt
+		break
+	}
+
+	job, err := exec.BuildSqlJob(ctx)
+	if err != nil {
+		return nil, pgErrorFromPlan(ctx, err)
+	}
+	rs := &resultSet{ctx: ctx, job: job}
+
+	sel, isSelect := job.Ctx.Stmt.(*rel.SqlSelect)
+	if !isSelect {
+		return rs, nil
+	}
+	if ctx.Projection != nil && ctx.Projection.Proj != nil && len(ctx.Projection.Proj.Columns) > 0 {
+		for i, rc := range ctx.Projection.Proj.Columns {
+			rs.cols = append(rs.cols, &column{name: rc.As, oid: datasource.PgTypeOid(rc.Type),
+				format: formatCode(formats, i)})
+		}
+	} else {
+		for i, name := range sel.Columns.AliasedFieldNames() {
+			rs.cols = append(rs.cols, &column{name: name, oid: datasource.PgOidText,
+				format: formatCode(formats, i)})
+		}
+	}
+	return rs, nil
+}
+
+// execute runs the result set if not already running and writes up to
+// maxRows (0 for all) DataRows, then CommandComplete if exhausted.
+func (c *conn) execute(rs *resultSet, maxRows int) (bool, error) {
+
+	if !rs.started {
+		rs.started = true
+		if rs.cols == nil {
+			rs.execw = exec.NewResultExecWriter(rs.ctx)
+			rs.job.RootTask.Add(rs.execw)
+		} else {
+			rs.rows = exec.NewResultRows(rs.ctx, columnNames(rs.cols))
+			rs.job.RootTask.Add(rs.rows)
+		}
+		if err := rs.job.Setup(); err != nil {
+			return false, pgError(err)
+		}
+		c.setRunning(rs)
+		if rs.execw != nil {
+			err := rs.job.Run()
+			c.setRunning(nil)
+			rs.close()
+			if err != nil {
+				return false, pgError(err)
+			}
+			return false, c.commandComplete(commandTag(rs.ctx.Stmt, rs.execw.Result()))
+		}
+		go func() {
+			if err := rs.job.Run(); err != nil {
+				u.Warnf("pgwire job error: %v", err)
+			}
+			rs.job.Close()
+		}()
+	}
+
+	for {
+		row, err := rs.next()
+		if err == io.EOF {
+			c.setRunning(nil)
+			rs.close()
+			return false, c.commandComplete(fmt.Sprintf("SELECT %d", rs.ct))
+		} else if err != nil {
+			c.setRunning(nil)
+			rs.close()
+			return false, pgError(err)
+		}
+		if err := c.writeDataRow(rs.cols, row); err != nil {
+			return false, err
+		}
+		rs.ct++
+		if maxRows > 0 && rs.ct%maxRows == 0 {
+			return true, nil
+		}
+	}
+}
+
+func (c *conn) writeDataRow(cols []*column, row []driver.Value) error {
+	c.wr.begin(msgDataRow)
+	c.wr.int16(len(cols))
+	for i, col := range cols {
+		var v driver.Value
+		if i < len(row) {
+			v = row[i]
+		}
+		by, err := encodeValue(col, v)
+		if err != nil {
+			return err
+		}
+		if by == nil {
+			c.wr.int32(-1)
+			continue
+		}
+		c.wr.int32(len(by))
+		c.wr.bytes(by)
+	}
+	return c.wr.end()
+}
+
+func (c *conn) commandComplete(tag string) error {
+	c.wr.begin(msgCommandComplete)
+	c.wr.string(tag)
+	return c.wr.end()
+}
+
+func (c *conn) setRunning(rs *resultSet) {
+	c.mu.Lock()
+	c.running = rs
+	c.mu.Unlock()
+}
+
+// cancelRunning from a CancelRequest on another connection.
+func (c *conn) cancelRunning() {
+	c.mu.Lock()
+	rs := c.running
+	c.mu.Unlock()
+	if rs != nil && rs.job != nil {
+		rs.job.Close()
+	}
+}
+
+func (c *conn) closePortals() {
+	for name, p := range c.portals {
+		p.close()
+		delete(c.portals, name)
+	}
+}
+
+func (c *conn) closeAll() {
+	c.closePortals()
+	c.stmts = make(map[string]*prepared)
+}
+
+func (m *portal) close() {
+	if m.rs != nil {
+		m.rs.close()
+	}
+}
+
+// next positional row of results.
+func (m *resultSet) next() ([]driver.Value, error) {
+	select {
+	case <-m.rows.SigChan():
+		return nil, NewError(CodeQueryCanceled, "canceling statement due to user request")
+	case err := <-m.rows.ErrChan():
+		return nil, err
+	case msg, ok := <-m.rows.MessageIn():
+		if !ok || msg == nil {
+			return nil, io.EOF
+		}
+		switch mt := msg.(type) {
+		case *datasource.SqlDriverMessageMap:
+			return mt.Values(), nil
+		}
+		return nil, fmt.Errorf("unexpected message type %T", msg)
+	}
+}
+
+func (m *resultSet) close() {
+	if m.done {
+		return
+	}
+	m.done = true
+	if m.job != nil {
+		m.job.Close()
+	}
+}
+
+func columnNames(cols []*column) []string {
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = col.name
+	}
+	return names
+}
+
+func formatCode(formats []int, i int) int {
+	switch len(formats) {
+	case 0:
+		return formatText
+	case 1:
+		return formats[0]
+	}
+	if i < len(formats) {
+		return formats[i]
+	}
+	return formatText
+}
+
+// commandTag is the CommandComplete tag for non-row returning statements.
+func commandTag(stmt rel.SqlStatement, res driver.Result) string {
+	var affected int64
+	if res != nil {
+		affected, _ = res.RowsAffected()
+	}
+	switch stmt.(type) {
+	case *rel.SqlInsert, *rel.SqlUpsert:
+		return fmt.Sprintf("INSERT 0 %d", affected)
+	case *rel.SqlUpdate:
+		return fmt.Sprintf("UPDATE %d", affected)
+	case *rel.SqlDelete:
+		return fmt.Sprintf("DELETE %d", affected)
+	}
+	if stmt == nil {
+		return ""
+	}
+	switch kw := stmt.Keyword(); kw {
+	case lex.TokenSet:
+		return "SET"
+	default:
+		return strings.ToUpper(kw.String())
+	}
+}
+
+// pgErrorFromPlan classifies errors from parsing and planning.
+func pgErrorFromPlan(ctx *plan.Context, err error) *Error {
+	if ctx.Stmt == nil {
+		// never made it past parse
+		return NewError(CodeSyntaxError, "%v", err)
+	}
+	return pgError(err)
+}
+
+// pgError converts an error to one with a SQLSTATE code.
+func pgError(err error) *Error {
+	switch err {
+	case schema.ErrNotFound:
+		return NewError(CodeUndefinedTable, "%v", err)
+	case exec.ErrNotSupported, exec.ErrNotImplemented, expr.ErrNotSupported, expr.ErrNotImplemented:
+		return NewError(CodeFeatureNotSupported, "%v", err)
+	case exec.ErrShuttingDown:
+		return NewError(CodeQueryCanceled, "canceling statement due to user request")
+	}
+	if pe, ok := err.(*Error); ok {
+		return pe
+	}
+	return NewError(CodeInternalError, "%v", err)
+}
@@ -0,0 +1,432 @@
+package pgwire
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+const (
+	formatText   = 0
+	formatBinary = 1
+
+	// timestamp text format, we always send UTC
+	pgTimeFormat = "2006-01-02 15:04:05.999999"
+)
+
+var (
+	// postgres binary timestamps are microseconds since 2000-01-01
+	pgEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// column is a single column in a RowDescription.
+type column struct {
+	name   string
+	oid    uint32
+	format int
+}
+
+// encodeValue converts a driver value into its wire representation for
+// the given column type and format.  A nil return is a NULL.
+func encodeValue(col *column, v driver.Value) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if col.format == formatBinary {
+		return encodeBinary(col.oid, v)
+	}
+	return encodeText(col.oid, v), nil
+}
+
+func encodeText(oid uint32, v driver.Value) []byte {
+	switch vt := v.(type) {
+	case string:
+		return []byte(vt)
+	case []byte:
+		if oid == datasource.PgOidBytea {
+			b := make([]byte, 2+hex.EncodedLen(len(vt)))
+			b[0], b[1] = '\\', 'x'
+			hex.Encode(b[2:], vt)
+			return b
+		}
+		return vt
+	case bool:
+		if vt {
+			return []byte("t")
+		}
+		return []byte("f")
+	case int64:
+		return strconv.AppendInt(nil, vt, 10)
+	case int:
+		return strconv.AppendInt(nil, int64(vt), 10)
+	case float64:
+		return strconv.AppendFloat(nil, vt, 'g', -1, 64)
+	case time.Time:
+		return []byte(vt.UTC().Format(pgTimeFormat))
+	case []string:
+		return encodeTextArray(vt)
+	case value.Value:
+		if vt.Nil() {
+			return nil
+		}
+		return encodeText(oid, vt.Value())
+	case json.RawMessage:
+		return vt
+	}
+	switch oid {
+	case datasource.PgOidJson:
+		if by, err := json.Marshal(v); err == nil {
+			return by
+		}
+	}
+	return []byte(fmt.Sprintf("%v", v))
+}
+
+// encodeTextArray writes a text[] in postgres array literal format, quoting
+// every element.
+func encodeTextArray(vals []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, s := range vals {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('"')
+		for _, r := range s {
+			if r == '"' || r == '\\' {
+				buf.WriteByte('\\')
+			}
+			buf.WriteRune(r)
+		}
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+// encodeBinary supports binary format for the fixed width types, everything
+// else has the same binary as text representation.
+func encodeBinary(oid uint32, v driver.Value) ([]byte, error) {
+	switch oid {
+	case datasource.PgOidBool:
+		bv, ok := value.ValueToBool(value.NewValue(v))
+		if !ok {
+			return nil, NewError(CodeInvalidParameter, "could not convert %v to bool", v)
+		}
+		if bv {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case datasource.PgOidInt8:
+		iv, ok := value.ValueToInt64(value.NewValue(v))
+		if !ok {
+			return nil, NewError(CodeInvalidParameter, "could not convert %v to int8", v)
+		}
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, uint64(iv))
+		return b, nil
+	case datasource.PgOidFloat8:
+		fv, ok := value.ValueToFloat64(value.NewValue(v))
+		if !ok {
+			return nil, NewError(CodeInvalidParameter, "could not convert %v to float8", v)
+		}
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, math.Float64bits(fv))
+		return b, nil
+	case datasource.PgOidTimestamp:
+		t, ok := value.ValueToTime(value.NewValue(v))
+		if !ok {
+			return nil, NewError(CodeInvalidParameter, "could not convert %v to timestamp", v)
+		}
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, uint64(t.Sub(pgEpoch)/time.Microsecond))
+		return b, nil
+	case datasource.PgOidBytea:
+		if by, ok := v.([]byte); ok {
+			return by, nil
+		}
+	case datasource.PgOidTextArray, datasource.PgOidJson:
+		return nil, NewError(CodeFeatureNotSupported, "binary format not supported for type oid %d", oid)
+	}
+	return encodeText(oid, v), nil
+}
+
+// Parameter type OIDs clients commonly declare in Parse, beyond the ones
+// we send back ourselves.
+const (
+	pgOidInt2    uint32 = 21
+	pgOidInt4    uint32 = 23
+	pgOidOid     uint32 = 26
+	pgOidFloat4  uint32 = 700
+	pgOidNumeric uint32 = 1700
+	pgOidUnknown uint32 = 0
+)
+
+// paramLiteral converts a bound parameter into a sql literal that is
+// substituted for the $n placeholder, or for strings a param whose text
+// is bound into the parsed statement.
+func paramLiteral(oid uint32, format int, raw []byte) (param, error) {
+	if raw == nil {
+		return param{lit: "NULL"}, nil
+	}
+	if format == formatBinary {
+		switch oid {
+		case datasource.PgOidBool:
+			if len(raw) != 1 {
+				return param{}, NewError(CodeProtocolViolation, "invalid binary bool parameter")
+			}
+			if raw[0] != 0 {
+				return param{lit: "true"}, nil
+			}
+			return param{lit: "false"}, nil
+		case pgOidInt2:
+			if len(raw) != 2 {
+				return param{}, NewError(CodeProtocolViolation, "invalid binary int2 parameter")
+			}
+			return param{lit: strconv.FormatInt(int64(int16(binary.BigEndian.Uint16(raw))), 10)}, nil
+		case pgOidInt4, pgOidOid:
+			if len(raw) != 4 {
+				return param{}, NewError(CodeProtocolViolation, "invalid binary int4 parameter")
+			}
+			return param{lit: strconv.FormatInt(int64(int32(binary.BigEndian.Uint32(raw))), 10)}, nil
+		case datasource.PgOidInt8:
+			if len(raw) != 8 {
+				return param{}, NewError(CodeProtocolViolation, "invalid binary int8 parameter")
+			}
+			return param{lit: strconv.FormatInt(int64(binary.BigEndian.Uint64(raw)), 10)}, nil
+		case pgOidFloat4:
+			if len(raw) != 4 {
+				return param{}, NewError(CodeProtocolViolation, "invalid binary float4 parameter")
+			}
+			return param{lit: strconv.FormatFloat(float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), 'g', -1, 32)}, nil
+		case datasource.PgOidFloat8:
+			if len(raw) != 8 {
+				return param{}, NewError(CodeProtocolViolation, "invalid binary float8 parameter")
+			}
+			return param{lit: strconv.FormatFloat(math.Float64frombits(binary.BigEndian.Uint64(raw)), 'g', -1, 64)}, nil
+		case datasource.PgOidTimestamp:
+			if len(raw) != 8 {
+				return param{}, NewError(CodeProtocolViolation, "invalid binary timestamp parameter")
+			}
+			t := pgEpoch.Add(time.Duration(int64(binary.BigEndian.Uint64(raw))) * time.Microsecond)
+			return param{text: t.Format(pgTimeFormat), isText: true}, nil
+		case datasource.PgOidText, datasource.PgOidVarchar, pgOidUnknown, datasource.PgOidJson:
+			return param{text: string(raw), isText: true}, nil
+		}
+		return param{}, NewError(CodeFeatureNotSupported, "binary format not supported for parameter type oid %d", oid)
+	}
+
+	s := string(raw)
+	switch oid {
+	case pgOidInt2, pgOidInt4, pgOidOid, datasource.PgOidInt8:
+		if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+			return param{}, NewError(CodeInvalidParameter, "invalid input syntax for integer: %q", s)
+		}
+		return param{lit: s}, nil
+	case pgOidFloat4, datasource.PgOidFloat8, pgOidNumeric:
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return param{}, NewError(CodeInvalidParameter, "invalid input syntax for number: %q", s)
+		}
+		return param{lit: s}, nil
+	case datasource.PgOidBool:
+		switch strings.ToLower(s) {
+		case "t", "true", "1", "y", "yes", "on":
+			return param{lit: "true"}, nil
+		case "f", "false", "0", "n", "no", "off":
+			return param{lit: "false"}, nil
+		}
+		return param{}, NewError(CodeInvalidParameter, "invalid input syntax for boolean: %q", s)
+	case pgOidUnknown:
+		// un-typed, send numbers as numbers so they can be used in LIMIT etc
+		if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return param{lit: s}, nil
+		}
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return param{lit: s}, nil
+		}
+	}
+	return param{text: s, isText: true}, nil
+}
+
+// param a bound parameter, numbers, booleans and NULL are substituted as
+// sql literals.  Strings are not, the lexer treats a \ before a quote as
+// an escape so no quoting of arbitrary text is safe, instead a marker
+// literal is substituted and replaced by the text once parsed.
+type param struct {
+	lit    string
+	text   string
+	isText bool
+}
+
+// paramMarker the literal text standing in for string parameter n.
+func paramMarker(n int) string {
+	return "\x01$" + strconv.Itoa(n)
+}
+
+// bindParams replaces $n placeholders outside of quoted strings and
+// identifiers with the literals of params, or for strings their marker.
+func bindParams(query string, params []param) (string, error) {
+	if strings.IndexByte(query, '\x01') >= 0 {
+		return "", NewError(CodeSyntaxError, "invalid character in query")
+	}
+	var buf bytes.Buffer
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			j := i + 1
+			for j < len(query) && isDigit(query[j]) {
+				j++
+			}
+			n, _ := strconv.Atoi(query[i+1 : j])
+			if n < 1 || n > len(params) {
+				return "", NewError(CodeProtocolViolation, "there is no parameter $%d", n)
+			}
+			if params[n-1].isText {
+				buf.WriteString("'" + paramMarker(n) + "'")
+			} else {
+				buf.WriteString(params[n-1].lit)
+			}
+			i = j - 1
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String(), nil
+}
+
+// bindText replaces the marker literals of the string params in the
+// parsed statement with their text.
+func bindText(stmt rel.SqlStatement, params []param) {
+	markers := make(map[string]string)
+	for i, p := range params {
+		if p.isText {
+			markers[paramMarker(i+1)] = p.text
+		}
+	}
+	if len(markers) > 0 {
+		bindTextValue(reflect.ValueOf(stmt), markers, make(map[uintptr]bool))
+	}
+}
+
+// bindTextValue walks the exported fields of the statement, its nodes and
+// values, for marker literals.
+func bindTextValue(v reflect.Value, markers map[string]string, seen map[uintptr]bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+		if sn, ok := v.Interface().(*expr.StringNode); ok {
+			if text, ok := markers[sn.Text]; ok {
+				sn.Text = text
+			}
+			return
+		}
+		bindTextValue(v.Elem(), markers, seen)
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if sv, ok := v.Interface().(value.StringValue); ok {
+			if text, ok := markers[sv.Val()]; ok && v.CanSet() {
+				v.Set(reflect.ValueOf(value.NewStringValue(text)))
+			}
+			return
+		}
+		bindTextValue(v.Elem(), markers, seen)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath == "" {
+				bindTextValue(v.Field(i), markers, seen)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			bindTextValue(v.Index(i), markers, seen)
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			bindTextValue(v.MapIndex(k), markers, seen)
+		}
+	}
+}
+
+// countParams finds the highest $n placeholder.
+func countParams(query string) int {
+	max := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			j := i + 1
+			for j < len(query) && isDigit(query[j]) {
+				j++
+			}
+			if n, _ := strconv.Atoi(query[i+1 : j]); n > max {
+				max = n
+			}
+			i = j - 1
+		}
+	}
+	return max
+}
+
+// splitStatements splits a simple-query string on semi-colons that are
+// not inside of quotes.
+func splitStatements(query string) []string {
+	var stmts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == ';':
+			if s := strings.TrimSpace(query[start:i]); s != "" {
+				stmts = append(stmts, s)
+			}
+			start = i + 1
+		}
+	}
+	if s := strings.TrimSpace(query[start:]); s != "" {
+		stmts = append(stmts, s)
+	}
+	return stmts
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
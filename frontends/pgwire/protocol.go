@@ -0,0 +1,216 @@
+package pgwire
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	// Protocol version numbers sent in the startup packet.
+	protocolVersion3 = 196608
+	sslRequestCode   = 80877103
+	cancelRequest    = 80877102
+	gssEncRequest    = 80877104
+
+	// maxMessageSize guard against garbage or hostile message lengths.
+	maxMessageSize = 1 << 26
+)
+
+// Frontend (client) message types.
+const (
+	msgBind      byte = 'B'
+	msgClose     byte = 'C'
+	msgDescribe  byte = 'D'
+	msgExecute   byte = 'E'
+	msgFlush     byte = 'H'
+	msgParse     byte = 'P'
+	msgPassword  byte = 'p'
+	msgQuery     byte = 'Q'
+	msgSync      byte = 'S'
+	msgTerminate byte = 'X'
+)
+
+// Backend (server) message types.
+const (
+	msgAuthentication       byte = 'R'
+	msgBackendKeyData       byte = 'K'
+	msgBindComplete         byte = '2'
+	msgCloseComplete        byte = '3'
+	msgCommandComplete      byte = 'C'
+	msgDataRow              byte = 'D'
+	msgEmptyQueryResponse   byte = 'I'
+	msgErrorResponse        byte = 'E'
+	msgNoData               byte = 'n'
+	msgNoticeResponse       byte = 'N'
+	msgParameterDescription byte = 't'
+	msgParameterStatus      byte = 'S'
+	msgParseComplete        byte = '1'
+	msgPortalSuspended      byte = 's'
+	msgReadyForQuery        byte = 'Z'
+	msgRowDescription       byte = 'T'
+)
+
+// SQLSTATE error codes we send back in ErrorResponse.
+const (
+	CodeSyntaxError         = "42601"
+	CodeUndefinedTable      = "42P01"
+	CodeUndefinedColumn     = "42703"
+	CodeFeatureNotSupported = "0A000"
+	CodeProtocolViolation   = "08P01"
+	CodeInvalidCatalogName  = "3D000"
+	CodeInvalidAuthSpec     = "28000"
+	CodeInvalidPassword     = "28P01"
+	CodeInvalidParameter    = "22023"
+	CodeInvalidPrepared     = "26000"
+	CodeInvalidCursor       = "34000"
+	CodeQueryCanceled       = "57014"
+	CodeInternalError       = "XX000"
+)
+
+// Error is an error with a postgres SQLSTATE code, written to the
+// client as an ErrorResponse.
+type Error struct {
+	Severity string
+	Code     string
+	Message  string
+}
+
+// NewError creates a new postgres error with severity ERROR.
+func NewError(code, format string, args ...interface{}) *Error {
+	return &Error{Severity: "ERROR", Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (m *Error) Error() string { return fmt.Sprintf("%s %s: %s", m.Severity, m.Code, m.Message) }
+
+// readBuf is the body of a single message, read sequentially.
+type readBuf []byte
+
+func (b *readBuf) int16() int {
+	if len(*b) < 2 {
+		panic(errShortMessage)
+	}
+	n := int(int16(binary.BigEndian.Uint16(*b)))
+	*b = (*b)[2:]
+	return n
+}
+func (b *readBuf) int32() int {
+	if len(*b) < 4 {
+		panic(errShortMessage)
+	}
+	n := int(int32(binary.BigEndian.Uint32(*b)))
+	*b = (*b)[4:]
+	return n
+}
+func (b *readBuf) string() string {
+	for i, c := range *b {
+		if c == 0 {
+			s := string((*b)[:i])
+			*b = (*b)[i+1:]
+			return s
+		}
+	}
+	panic(errShortMessage)
+}
+func (b *readBuf) next(n int) []byte {
+	if n < 0 || len(*b) < n {
+		panic(errShortMessage)
+	}
+	v := (*b)[:n]
+	*b = (*b)[n:]
+	return v
+}
+func (b *readBuf) byte() byte {
+	return b.next(1)[0]
+}
+
+var errShortMessage = NewError(CodeProtocolViolation, "invalid message format")
+
+// msgReader reads messages off of the client connection.
+type msgReader struct {
+	r   *bufio.Reader
+	hdr [5]byte
+}
+
+// readStartup reads the un-typed startup message.
+func (m *msgReader) readStartup() (readBuf, error) {
+	if _, err := io.ReadFull(m.r, m.hdr[:4]); err != nil {
+		return nil, err
+	}
+	n := int(binary.BigEndian.Uint32(m.hdr[:4])) - 4
+	if n < 4 || n > maxMessageSize {
+		return nil, NewError(CodeProtocolViolation, "invalid startup packet length %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(m.r, b); err != nil {
+		return nil, err
+	}
+	return readBuf(b), nil
+}
+
+// readMsg reads a typed message.
+func (m *msgReader) readMsg() (byte, readBuf, error) {
+	if _, err := io.ReadFull(m.r, m.hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	n := int(binary.BigEndian.Uint32(m.hdr[1:])) - 4
+	if n < 0 || n > maxMessageSize {
+		return 0, nil, NewError(CodeProtocolViolation, "invalid message length %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(m.r, b); err != nil {
+		return 0, nil, err
+	}
+	return m.hdr[0], readBuf(b), nil
+}
+
+// msgWriter buffers backend messages, one at a time is started with
+// begin() and finished by end() which fills in the length.
+type msgWriter struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+func (m *msgWriter) begin(typ byte) {
+	m.buf = append(m.buf[:0], typ, 0, 0, 0, 0)
+}
+func (m *msgWriter) int16(n int) {
+	m.buf = append(m.buf, byte(n>>8), byte(n))
+}
+func (m *msgWriter) int32(n int) {
+	m.buf = append(m.buf, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+func (m *msgWriter) string(s string) {
+	m.buf = append(m.buf, s...)
+	m.buf = append(m.buf, 0)
+}
+func (m *msgWriter) bytes(b []byte) {
+	m.buf = append(m.buf, b...)
+}
+func (m *msgWriter) byte(c byte) {
+	m.buf = append(m.buf, c)
+}
+func (m *msgWriter) end() error {
+	binary.BigEndian.PutUint32(m.buf[1:5], uint32(len(m.buf)-1))
+	_, err := m.w.Write(m.buf)
+	return err
+}
+func (m *msgWriter) flush() error {
+	return m.w.Flush()
+}
+
+// writeError writes an ErrorResponse.
+func (m *msgWriter) writeError(e *Error) error {
+	m.begin(msgErrorResponse)
+	m.byte('S')
+	m.string(e.Severity)
+	m.byte('V')
+	m.string(e.Severity)
+	m.byte('C')
+	m.string(e.Code)
+	m.byte('M')
+	m.string(e.Message)
+	m.byte(0)
+	return m.end()
+}
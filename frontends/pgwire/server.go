@@ -0,0 +1,196 @@
+// Package pgwire is a PostgreSQL v3 wire-protocol front-end for qlbridge.
+//
+// It allows postgres clients (psql, JDBC, lib/pq, etc) to connect and run
+// queries against the schemas in a schema.Registry.  The postgres database
+// name of the connection is the qlbridge schema name.
+//
+//	srv := pgwire.NewServer(":5432", schema.DefaultRegistry())
+//	go srv.ListenAndServe()
+//
+//	psql -h localhost -p 5432 -d mockcsv -c "SELECT * FROM users"
+//
+// Both the simple and extended query protocols are supported, $n parameters
+// are bound as values into the parsed statement.
+//
+// Once the registry has users, clients authenticate with SCRAM-SHA-256 as
+// one of them against its password (CREATE USER alice WITH password = "..").
+// Set Server.Trust to accept the startup user without a password.  There
+// is no TLS.
+package pgwire
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	_ = u.EMPTY
+
+	// ErrServerClosed returned by Serve after Close is called.
+	ErrServerClosed = fmt.Errorf("pgwire: Server closed")
+)
+
+// Server accepts postgres wire-protocol connections.
+type Server struct {
+	addr     string
+	reg      *schema.Registry
+	listener net.Listener
+	// ServerVersion is reported to clients as server_version, some
+	// clients change behavior based on it.
+	ServerVersion string
+	// Trust accepts the startup user as is without its password, only
+	// for clients that are trusted ie on a private network.
+	Trust bool
+
+	mu      sync.Mutex
+	closed  bool
+	nextPid uint32
+	conns   map[uint32]*conn
+}
+
+// NewServer creates a new postgres wire-protocol server listening on addr
+// serving the schemas of the registry.
+func NewServer(addr string, reg *schema.Registry) *Server {
+	return &Server{
+		addr:          addr,
+		reg:           reg,
+		ServerVersion: "9.6.0",
+		conns:         make(map[uint32]*conn),
+	}
+}
+
+// Addr of the listener if listening, else the configured address.
+func (m *Server) Addr() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.listener != nil {
+		return m.listener.Addr().String()
+	}
+	return m.addr
+}
+
+// Listen opens the listener without serving yet.
+func (m *Server) Listen() error {
+	l, err := net.Listen("tcp", m.addr)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.listener = l
+	m.mu.Unlock()
+	return nil
+}
+
+// ListenAndServe listen and serve until Close.
+func (m *Server) ListenAndServe() error {
+	if err := m.Listen(); err != nil {
+		return err
+	}
+	return m.Serve()
+}
+
+// Serve accept connections on the listener, each connection is served
+// in its own go-routine.
+func (m *Server) Serve() error {
+	m.mu.Lock()
+	l := m.listener
+	m.mu.Unlock()
+	if l == nil {
+		return fmt.Errorf("pgwire: must Listen() before Serve()")
+	}
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			m.mu.Lock()
+			closed := m.closed
+			m.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				u.Warnf("pgwire accept error: %v", err)
+				continue
+			}
+			return err
+		}
+		go m.serveConn(nc)
+	}
+}
+
+// Close the listener and all open connections.
+func (m *Server) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	conns := make([]*conn, 0, len(m.conns))
+	for _, c := range m.conns {
+		conns = append(conns, c)
+	}
+	l := m.listener
+	m.mu.Unlock()
+
+	var err error
+	if l != nil {
+		err = l.Close()
+	}
+	for _, c := range conns {
+		c.nc.Close()
+	}
+	return err
+}
+
+func (m *Server) serveConn(nc net.Conn) {
+	c := &conn{
+		srv:      m,
+		nc:       nc,
+		rd:       &msgReader{r: bufio.NewReader(nc)},
+		wr:       &msgWriter{w: bufio.NewWriter(nc)},
+		stmts:    make(map[string]*prepared),
+		portals:  make(map[string]*portal),
+		startup:  make(map[string]string),
+		pidValid: false,
+	}
+	defer nc.Close()
+	if err := c.serve(); err != nil {
+		u.Debugf("pgwire conn closed: %v", err)
+	}
+	if c.pidValid {
+		m.mu.Lock()
+		delete(m.conns, c.pid)
+		m.mu.Unlock()
+	}
+}
+
+// register a connection, assigning the pid and secret used for cancel.
+func (m *Server) register(c *conn) {
+	var b [4]byte
+	rand.Read(b[:])
+	m.mu.Lock()
+	m.nextPid++
+	c.pid = m.nextPid
+	c.secret = binary.BigEndian.Uint32(b[:])
+	c.pidValid = true
+	m.conns[c.pid] = c
+	m.mu.Unlock()
+}
+
+// cancel the running query of connection with pid if the secret matches.
+func (m *Server) cancel(pid, secret uint32) {
+	m.mu.Lock()
+	c, ok := m.conns[pid]
+	m.mu.Unlock()
+	if ok && c.secret == secret {
+		c.cancelRunning()
+	}
+}
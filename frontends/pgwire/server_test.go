@@ -0,0 +1,194 @@
+package pgwire_test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/frontends/pgwire"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/testutil"
+)
+
+func init() {
+	testutil.Setup()
+	td.LoadTestDataOnce()
+}
+
+func startServer(t *testing.T) (*pgwire.Server, *sql.DB) {
+	srv := pgwire.NewServer("127.0.0.1:0", schema.DefaultRegistry())
+	assert.Equal(t, nil, srv.Listen())
+	go srv.Serve()
+
+	dsn := fmt.Sprintf("postgres://qlb@%s/mockcsv?sslmode=disable", srv.Addr())
+	db, err := sql.Open("postgres", dsn)
+	assert.Equal(t, nil, err)
+	return srv, db
+}
+
+func TestSimpleQuery(t *testing.T) {
+	srv, db := startServer(t)
+	defer srv.Close()
+	defer db.Close()
+
+	// no args uses the simple query protocol
+	rows, err := db.Query("SELECT user_id, email, referral_count FROM users ORDER BY user_id")
+	assert.Equal(t, nil, err)
+	cols, err := rows.Columns()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"user_id", "email", "referral_count"}, cols)
+
+	ct := 0
+	for rows.Next() {
+		var id, email string
+		var referrals int64
+		assert.Equal(t, nil, rows.Scan(&id, &email, &referrals))
+		assert.NotEqual(t, "", id)
+		assert.True(t, referrals > 0)
+		ct++
+	}
+	assert.Equal(t, nil, rows.Err())
+	assert.Equal(t, 3, ct)
+}
+
+func TestExtendedQuery(t *testing.T) {
+	srv, db := startServer(t)
+	defer srv.Close()
+	defer db.Close()
+
+	var email string
+	err := db.QueryRow("SELECT email FROM users WHERE user_id = $1", "hT2impsOPUREcVPc").Scan(&email)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "bob@email.com", email)
+
+	ct := 0
+	rows, err := db.Query("SELECT order_id FROM orders WHERE price > $1", 30)
+	assert.Equal(t, nil, err)
+	for rows.Next() {
+		ct++
+	}
+	assert.Equal(t, nil, rows.Err())
+	assert.Equal(t, 1, ct)
+
+	// string params are bound into the parsed statement, not the query
+	// text, so quotes and escapes in them are only ever data
+	for _, payload := range []string{`x\' OR 1=1 -- `, `x' OR 1=1 -- `, `x\`, `'`} {
+		ct = 0
+		rows, err = db.Query("SELECT email FROM users WHERE user_id = $1", payload)
+		assert.Equal(t, nil, err, payload)
+		for rows.Next() {
+			ct++
+		}
+		assert.Equal(t, nil, rows.Err())
+		assert.Equal(t, 0, ct, payload)
+	}
+	var echo string
+	err = db.QueryRow("SELECT email FROM users WHERE user_id = $1 AND $2 = $2", "hT2impsOPUREcVPc", `a\'b`).Scan(&echo)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "bob@email.com", echo)
+}
+
+func TestPgCatalog(t *testing.T) {
+	srv, db := startServer(t)
+	defer srv.Close()
+	defer db.Close()
+
+	rows, err := db.Query("SELECT relname FROM pg_catalog.pg_class")
+	assert.Equal(t, nil, err)
+	tables := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		assert.Equal(t, nil, rows.Scan(&name))
+		tables[name] = true
+	}
+	assert.Equal(t, nil, rows.Err())
+	assert.True(t, tables["users"], "%v", tables)
+	assert.True(t, tables["orders"], "%v", tables)
+}
+
+func TestErrors(t *testing.T) {
+	srv, db := startServer(t)
+	defer srv.Close()
+	defer db.Close()
+
+	_, err := db.Query("SELECT email FROM users WHERE (")
+	assert.NotEqual(t, nil, err)
+	pe, ok := err.(*pq.Error)
+	assert.True(t, ok, "expected *pq.Error got %T", err)
+	if ok {
+		assert.Equal(t, pgwire.CodeSyntaxError, string(pe.Code))
+	}
+
+	// connection is still usable after an error
+	var ct int64
+	err = db.QueryRow("SELECT count(*) AS ct FROM users").Scan(&ct)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(3), ct)
+
+	// unknown database
+	bad, err := sql.Open("postgres", fmt.Sprintf("postgres://qlb@%s/nope?sslmode=disable", srv.Addr()))
+	assert.Equal(t, nil, err)
+	defer bad.Close()
+	err = bad.Ping()
+	pe, ok = err.(*pq.Error)
+	assert.True(t, ok, "expected *pq.Error got %T %v", err, err)
+	if ok {
+		assert.Equal(t, pgwire.CodeInvalidCatalogName, string(pe.Code))
+	}
+}
+
+func TestStartupUser(t *testing.T) {
+	reg := schema.DefaultRegistry()
+	assert.Equal(t, nil, reg.RoleAdd(schema.NewRole("pg_reader")))
+	assert.Equal(t, nil, reg.GrantAdd(schema.NewGrant("pg_reader", lex.TokenSelect, "mockcsv", "users")))
+	assert.Equal(t, nil, reg.UserAdd(&schema.User{Name: "qlb", Roles: []string{"pg_reader"}}))
+	assert.Equal(t, nil, reg.UserPassword("qlb", "s3cret"))
+	assert.Equal(t, nil, reg.UserAdd(schema.NewUser("nopass")))
+	defer reg.SchemaDrop("", "pg_reader", lex.TokenRole)
+	defer reg.SchemaDrop("", "qlb", lex.TokenUser)
+	defer reg.SchemaDrop("", "nopass", lex.TokenUser)
+
+	srv, db := startServer(t)
+	defer srv.Close()
+	defer db.Close()
+
+	// without, or with the wrong, password nobody gets in
+	for _, userinfo := range []string{"qlb", "qlb:wrong", "nobody:s3cret", "nopass", "nopass:x"} {
+		other, err := sql.Open("postgres", fmt.Sprintf("postgres://%s@%s/mockcsv?sslmode=disable", userinfo, srv.Addr()))
+		assert.Equal(t, nil, err)
+		err = other.Ping()
+		other.Close()
+		assert.NotEqual(t, nil, err, userinfo)
+		if pqErr, ok := err.(*pq.Error); ok {
+			assert.Equal(t, pgwire.CodeInvalidPassword, string(pqErr.Code), userinfo)
+		}
+	}
+
+	// statements run as the authenticated user
+	authed, err := sql.Open("postgres", fmt.Sprintf("postgres://qlb:s3cret@%s/mockcsv?sslmode=disable", srv.Addr()))
+	assert.Equal(t, nil, err)
+	defer authed.Close()
+	var ct int
+	assert.Equal(t, nil, authed.QueryRow("SELECT count(*) AS ct FROM users").Scan(&ct))
+	assert.Equal(t, 3, ct)
+	_, err = authed.Query("SELECT * FROM orders")
+	assert.NotEqual(t, nil, err)
+
+	// trust is opt-in, the startup user must still exist
+	srv.Trust = true
+	assert.Equal(t, nil, db.QueryRow("SELECT count(*) AS ct FROM users").Scan(&ct))
+	assert.Equal(t, 3, ct)
+	other, err := sql.Open("postgres", fmt.Sprintf("postgres://nobody@%s/mockcsv?sslmode=disable", srv.Addr()))
+	assert.Equal(t, nil, err)
+	defer other.Close()
+	err = other.Ping()
+	assert.NotEqual(t, nil, err)
+	if pqErr, ok := err.(*pq.Error); ok {
+		assert.Equal(t, pgwire.CodeInvalidAuthSpec, string(pqErr.Code))
+	}
+}
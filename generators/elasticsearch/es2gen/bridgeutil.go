@@ -3,6 +3,7 @@ package es2gen
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	u "github.com/araddon/gou"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
 	"github.com/araddon/qlbridge/lex"
 	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
 )
 
 var _ = u.EMPTY
@@ -204,9 +206,11 @@ func makeBetween(lhs *gentypes.FieldType, lower, upper interface{}) (interface{}
 	return &and{fl}, nil
 }
 
-// makeWildcard returns a wildcard/like query
+// makeWildcard returns a wildcard/like query, CONTAINS matches value
+// anywhere while LIKE patterns are translated from % and _ and must
+// match the whole value.
 //  {"query": {"wildcard": {field: value}}}
-func makeWildcard(lhs *gentypes.FieldType, value string) (interface{}, error) {
+func makeWildcard(lhs *gentypes.FieldType, op lex.TokenType, value, escape string) (interface{}, error) {
 	/*
 		"nested": {
 			"filter": {
@@ -228,7 +232,20 @@ func makeWildcard(lhs *gentypes.FieldType, value string) (interface{}, error) {
 	if lhs.Nested() {
 		fieldName = lhs.PathAndPrefix(value)
 	}
-	wc := Wildcard(fieldName, value)
+	var wc interface{}
+	switch op {
+	case lex.TokenContains:
+		wc = Wildcard(fieldName, value)
+	case lex.TokenLike:
+		pattern, err := vm.TranslateLike(value, escape, "*", "?", wildcardQuote)
+		if err != nil {
+			return nil, err
+		}
+		wc = Like(fieldName, pattern)
+	default:
+		// no case-insensitive wildcard before es 7.10
+		return nil, fmt.Errorf("qlindex: unsupported pattern operator %s", op)
+	}
 	if lhs.Nested() {
 		fl := []interface{}{wc, Term(fmt.Sprintf("%s.k", lhs.Path), lhs.Field)}
 		return &nested{&NestedFilter{
@@ -236,7 +253,25 @@ func makeWildcard(lhs *gentypes.FieldType, value string) (interface{}, error) {
 			Path:   lhs.Path,
 		}}, nil
 	}
-	return &wc, nil
+	return wc, nil
+}
+
+// makeRegexp returns a regexp filter for a REGEXP b, see luceneRegexp
+//  {"regexp": {field: value}}
+func makeRegexp(lhs *gentypes.FieldType, value string) (interface{}, error) {
+	fieldName := lhs.Field
+	if lhs.Nested() {
+		fieldName = lhs.PathAndPrefix(value)
+	}
+	re := Regexp(fieldName, luceneRegexp(value))
+	if lhs.Nested() {
+		fl := []interface{}{re, Term(fmt.Sprintf("%s.k", lhs.Path), lhs.Field)}
+		return &nested{&NestedFilter{
+			Filter: &and{fl},
+			Path:   lhs.Path,
+		}}, nil
+	}
+	return re, nil
 }
 
 // makeTimeWindowQuery maps the provided threshold and window arguments to the indexed time buckets
@@ -279,3 +314,29 @@ func makeTimeWindowQuery(lhs *gentypes.FieldType, threshold, window, ts int64) (
 		Path:   lhs.Field,
 	}}, nil
 }
+
+// wildcardQuote escape the wildcard characters of literal text.
+func wildcardQuote(s string) string {
+	return wildcardEscaper.Replace(s)
+}
+
+var wildcardEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`)
+
+// luceneRegexp an unanchored regular expression as the anchored lucene
+// regexp, ^ and $ are dropped and otherwise .* added in their place.
+//
+//    luceneRegexp("^ab+")  => "ab+.*"
+//
+func luceneRegexp(re string) string {
+	if strings.HasPrefix(re, "^") {
+		re = re[1:]
+	} else {
+		re = ".*" + re
+	}
+	if strings.HasSuffix(re, "$") && !strings.HasSuffix(re, `\$`) {
+		re = re[:len(re)-1]
+	} else {
+		re = re + ".*"
+	}
+	return re
+}
@@ -233,21 +233,17 @@ func (fg *FilterGenerator) binaryExpr(node *expr.BinaryNode, depth int) (interfa
 		default:
 			return nil, fmt.Errorf("qlindex: unsupported non-string argument for CONTAINS pattern: %T", node.Args[1])
 		}
-		return makeWildcard(lhs, rhsstr)
+		return makeWildcard(lhs, op, rhsstr, "")
 
-	case lex.TokenLike: // ident LIKE literal
-		rhsstr := ""
-		switch rhst := node.Args[1].(type) {
-		case *expr.StringNode:
-			rhsstr = rhst.Text
-		case *expr.IdentityNode:
-			rhsstr = rhst.Text
-		case *expr.NumberNode:
-			rhsstr = rhst.Text
-		default:
-			return nil, fmt.Errorf("qlindex: unsupported non-string argument for LIKE pattern: %T", node.Args[1])
+	case lex.TokenLike, lex.TokenILike, lex.TokenRegexp: // ident LIKE literal
+		rhsstr, ok := patternText(node.Args[1])
+		if !ok {
+			return nil, fmt.Errorf("qlindex: unsupported non-string argument for %s pattern: %T", op, node.Args[1])
+		}
+		if op == lex.TokenRegexp {
+			return makeRegexp(lhs, rhsstr)
 		}
-		return makeWildcard(lhs, rhsstr)
+		return makeWildcard(lhs, op, node.LikePattern(rhsstr), vm.DefaultLikeEscape)
 
 	case lex.TokenIN, lex.TokenIntersects:
 		// Build up list of arguments
@@ -289,10 +285,37 @@ func (fg *FilterGenerator) triExpr(node *expr.TriNode, depth int) (interface{},
 			return nil, fmt.Errorf("qlindex: unsupported type for second argument of BETWEEN expression: %T", node.Args[1])
 		}
 		return makeBetween(lhs, lower, upper)
+	case lex.TokenLike, lex.TokenILike: // a LIKE b ESCAPE c
+		lhs, err := fg.fieldType(node.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		pattern, ok := patternText(node.Args[1])
+		if !ok {
+			return nil, fmt.Errorf("qlindex: unsupported non-string argument for %s pattern: %T", op, node.Args[1])
+		}
+		escape, ok := patternText(node.Args[2])
+		if !ok {
+			return nil, fmt.Errorf("qlindex: unsupported non-string argument for ESCAPE: %T", node.Args[2])
+		}
+		return makeWildcard(lhs, op, pattern, escape)
 	}
 	return nil, fmt.Errorf("qlindex: unsupported ternary expression: %s", node.Operator.T)
 }
 
+// patternText the text of a LIKE pattern argument.
+func patternText(node expr.Node) (string, bool) {
+	switch n := node.(type) {
+	case *expr.StringNode:
+		return n.Text, true
+	case *expr.IdentityNode:
+		return n.Text, true
+	case *expr.NumberNode:
+		return n.Text, true
+	}
+	return "", false
+}
+
 func (fg *FilterGenerator) funcExpr(node *expr.FuncNode, depth int) (interface{}, error) {
 	switch node.Name {
 	case "timewindow":
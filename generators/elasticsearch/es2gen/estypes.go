@@ -182,3 +182,25 @@ func wcFunc(val string) string {
 func Wildcard(field, value string) *wildcardquery {
 	return &wildcardquery{Query: wildcard{Wildcard: map[string]string{field: wcFunc(value)}}}
 }
+
+// Like creates a wildcard query matching the whole value, unlike Wildcard
+// value is used as is.
+//
+//   {"query": {"wildcard": {field: value}}}
+//
+func Like(field, value string) *wildcardquery {
+	return &wildcardquery{Query: wildcard{Wildcard: map[string]string{field: value}}}
+}
+
+type regexpfilter struct {
+	Regexp map[string]string `json:"regexp"`
+}
+
+// Regexp creates a new Elasticsearch regexp filter, the lucene regular
+// expression must match the whole value.
+//
+//   {"regexp": {field: value}}
+//
+func Regexp(field, value string) *regexpfilter {
+	return &regexpfilter{Regexp: map[string]string{field: value}}
+}
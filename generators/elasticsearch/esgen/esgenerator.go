@@ -92,6 +92,14 @@ func (fg *FilterGenerator) walkExpr(node expr.Node, depth int) (interface{}, err
 		filter, err = fg.walkExpr(n.ExprNode, depth+1)
 	case *expr.FuncNode:
 		filter, err = fg.funcExpr(n, depth+1)
+	case *expr.CaseNode:
+		var bn expr.Node
+		if bn, err = caseAsBoolean(n); err == nil {
+			if bn == nil {
+				return MatchNone, nil
+			}
+			filter, err = fg.walkExpr(bn, depth+1)
+		}
 	default:
 		u.Warnf("not handled %v", node)
 		return nil, fmt.Errorf("qlindex: unsupported node in expression: %T (%s)", node, node)
@@ -185,6 +193,64 @@ func (fg *FilterGenerator) booleanExpr(bn *expr.BooleanNode, depth int) (interfa
 	return bf, nil
 }
 
+// caseAsBoolean re-writes a CASE used as a filter into boolean logic, each
+// branch matches if none of the prior WHEN's did
+//
+//    CASE WHEN a > 1 THEN true WHEN b = 2 THEN c = 3 END
+//    => a > 1 OR (NOT (a > 1) AND b = 2 AND c = 3)
+//
+// Only branches resulting in boolean expressions are translatable, a nil
+// node is returned if no branch can match.
+func caseAsBoolean(n *expr.CaseNode) (expr.Node, error) {
+	or := make([]expr.Node, 0, len(n.Whens)+1)
+	prior := make([]expr.Node, 0, len(n.Whens))
+	branch := func(result expr.Node, conds ...expr.Node) error {
+		switch rn := result.(type) {
+		case nil, *expr.NullNode:
+			return nil
+		case *expr.IdentityNode:
+			if rn.IsBooleanIdentity() {
+				if !rn.Bool() {
+					return nil
+				}
+				result = nil
+			}
+		case *expr.StringNode, *expr.NumberNode, *expr.ValueNode:
+			return fmt.Errorf("qlindex: unsupported non-boolean CASE result: %s", result)
+		}
+		args := append(append(make([]expr.Node, 0, len(prior)+2), prior...), conds...)
+		if result != nil {
+			args = append(args, result)
+		}
+		if len(args) == 1 {
+			or = append(or, args[0])
+		} else {
+			or = append(or, expr.NewBooleanNode(lex.Token{T: lex.TokenLogicAnd, V: "AND"}, args...))
+		}
+		return nil
+	}
+	for i, when := range n.Whens {
+		if n.Operand != nil {
+			when = expr.NewBinaryNode(lex.Token{T: lex.TokenEqual, V: "="}, n.Operand, when)
+		}
+		if err := branch(n.Thens[i], when); err != nil {
+			return nil, err
+		}
+		// not NewUnary which would reverse the negation of the when itself
+		prior = append(prior, &expr.UnaryNode{Operator: lex.Token{T: lex.TokenNegate, V: "NOT"}, Arg: when})
+	}
+	if err := branch(n.Else); err != nil {
+		return nil, err
+	}
+	switch len(or) {
+	case 0:
+		return nil, nil
+	case 1:
+		return or[0], nil
+	}
+	return expr.NewBooleanNode(lex.Token{T: lex.TokenLogicOr, V: "OR"}, or...), nil
+}
+
 func (fg *FilterGenerator) binaryExpr(node *expr.BinaryNode, depth int) (interface{}, error) {
 	// Type check binary expression arguments as they must be:
 	// Identifier-Operator-Literal
@@ -232,21 +298,17 @@ func (fg *FilterGenerator) binaryExpr(node *expr.BinaryNode, depth int) (interfa
 		default:
 			return nil, fmt.Errorf("qlindex: unsupported non-string argument for CONTAINS pattern: %T", node.Args[1])
 		}
-		return makeWildcard(lhs, rhsstr)
+		return makeWildcard(lhs, op, rhsstr, "")
 
-	case lex.TokenLike: // ident LIKE literal
-		rhsstr := ""
-		switch rhst := node.Args[1].(type) {
-		case *expr.StringNode:
-			rhsstr = rhst.Text
-		case *expr.IdentityNode:
-			rhsstr = rhst.Text
-		case *expr.NumberNode:
-			rhsstr = rhst.Text
-		default:
-			return nil, fmt.Errorf("qlindex: unsupported non-string argument for LIKE pattern: %T", node.Args[1])
+	case lex.TokenLike, lex.TokenILike, lex.TokenRegexp: // ident LIKE literal
+		rhsstr, ok := patternText(node.Args[1])
+		if !ok {
+			return nil, fmt.Errorf("qlindex: unsupported non-string argument for %s pattern: %T", op, node.Args[1])
+		}
+		if op == lex.TokenRegexp {
+			return makeRegexp(lhs, rhsstr)
 		}
-		return makeWildcard(lhs, rhsstr)
+		return makeWildcard(lhs, op, node.LikePattern(rhsstr), vm.DefaultLikeEscape)
 
 	case lex.TokenIN, lex.TokenIntersects:
 		// Build up list of arguments
@@ -288,10 +350,37 @@ func (fg *FilterGenerator) triExpr(node *expr.TriNode, depth int) (interface{},
 			return nil, fmt.Errorf("qlindex: unsupported type for second argument of BETWEEN expression: %T", node.Args[1])
 		}
 		return makeBetween(lhs, lower, upper)
+	case lex.TokenLike, lex.TokenILike: // a LIKE b ESCAPE c
+		lhs, err := fg.fieldType(node.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		pattern, ok := patternText(node.Args[1])
+		if !ok {
+			return nil, fmt.Errorf("qlindex: unsupported non-string argument for %s pattern: %T", op, node.Args[1])
+		}
+		escape, ok := patternText(node.Args[2])
+		if !ok {
+			return nil, fmt.Errorf("qlindex: unsupported non-string argument for ESCAPE: %T", node.Args[2])
+		}
+		return makeWildcard(lhs, op, pattern, escape)
 	}
 	return nil, fmt.Errorf("qlindex: unsupported ternary expression: %s", node.Operator.T)
 }
 
+// patternText the text of a LIKE pattern argument.
+func patternText(node expr.Node) (string, bool) {
+	switch n := node.(type) {
+	case *expr.StringNode:
+		return n.Text, true
+	case *expr.IdentityNode:
+		return n.Text, true
+	case *expr.NumberNode:
+		return n.Text, true
+	}
+	return "", false
+}
+
 func (fg *FilterGenerator) funcExpr(node *expr.FuncNode, depth int) (interface{}, error) {
 	switch node.Name {
 	case "timewindow":
@@ -1 +1,179 @@
 package esgen_test
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/esgen"
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+type testSchema map[string]value.ValueType
+
+func (m testSchema) Column(col string) (value.ValueType, bool) {
+	vt, ok := m[col]
+	return vt, ok
+}
+func (m testSchema) ColumnInfo(col string) (*gentypes.FieldType, bool) {
+	vt, ok := m[col]
+	if !ok {
+		return nil, false
+	}
+	return &gentypes.FieldType{Field: col, Type: vt}, true
+}
+
+var ordersSchema = testSchema{
+	"user_id":    value.StringType,
+	"price":      value.NumberType,
+	"item_count": value.IntType,
+	"order_date": value.TimeType,
+}
+
+func selectJson(t *testing.T, sql string) (*esgen.SelectGenerator, string) {
+	stmt, err := rel.ParseSqlSelect(sql)
+	assert.Equal(t, nil, err, sql)
+	sg := esgen.NewSelectGenerator(time.Now(), nil, ordersSchema)
+	p, err := sg.Walk(stmt)
+	assert.Equal(t, nil, err, sql)
+	by, err := json.Marshal(p)
+	assert.Equal(t, nil, err)
+	return sg, string(by)
+}
+
+func TestSelectGenerator(t *testing.T) {
+	_, body := selectJson(t, `SELECT user_id, price FROM orders WHERE price > 10 ORDER BY order_date DESC LIMIT 20 OFFSET 40`)
+	assert.JSONEq(t, `{
+		"size": 20, "from": 40,
+		"query": {"bool": {"filter": [{"range": {"price": {"gt": 10}}}]}},
+		"_source": ["user_id", "price"],
+		"sort": [{"order_date": {"order": "desc"}}]
+	}`, body)
+
+	_, body = selectJson(t, `SELECT * FROM orders`)
+	assert.JSONEq(t, `{}`, body)
+
+	stmt, _ := rel.ParseSqlSelect(`SELECT user_id FROM orders WHERE not_a_field > 10 HAVING count(*) > 1`)
+	_, err := esgen.NewSelectGenerator(time.Now(), nil, ordersSchema).Walk(stmt)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestSelectGeneratorAggs(t *testing.T) {
+	sg, body := selectJson(t, `
+		SELECT user_id, count(*) AS ct, sum(price) AS total, avg(item_count) AS avg_items, cardinality(order_date) AS days
+		FROM orders
+		WHERE price > 10
+		GROUP BY user_id
+		ORDER BY total DESC
+		LIMIT 5`)
+	assert.JSONEq(t, `{
+		"size": 0,
+		"query": {"bool": {"filter": [{"range": {"price": {"gt": 10}}}]}},
+		"aggregations": {
+			"user_id": {
+				"terms": {"field": "user_id", "size": 5, "order": {"total": "desc"}},
+				"aggregations": {
+					"total": {"sum": {"field": "price"}},
+					"avg_items": {"avg": {"field": "item_count"}},
+					"days": {"cardinality": {"field": "order_date"}}
+				}
+			}
+		}
+	}`, body)
+	assert.Equal(t, []string{"user_id", "ct", "total", "avg_items", "days"}, sg.Columns())
+
+	rows, err := sg.AggRows([]byte(`{
+		"hits": {"total": 3, "hits": []},
+		"aggregations": {"user_id": {"buckets": [
+			{"key": "abc", "doc_count": 2, "total": {"value": 60.5}, "avg_items": {"value": 2}, "days": {"value": 2}},
+			{"key": "xyz", "doc_count": 1, "total": {"value": 22.5}, "avg_items": {"value": 1.5}, "days": {"value": 1}}
+		]}}
+	}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]driver.Value{
+		{"abc", int64(2), 60.5, int64(2), int64(2)},
+		{"xyz", int64(1), 22.5, 1.5, int64(1)},
+	}, rows)
+}
+
+func TestSelectGeneratorDateHistogram(t *testing.T) {
+	sg, body := selectJson(t, `
+		SELECT date_histogram(order_date, "1d") AS day, user_id, count(*)
+		FROM orders
+		GROUP BY date_histogram(order_date, "1d"), user_id`)
+	assert.JSONEq(t, `{
+		"size": 0,
+		"aggregations": {
+			"day": {
+				"date_histogram": {"field": "order_date", "interval": "1d"},
+				"aggregations": {
+					"user_id": {"terms": {"field": "user_id", "size": 1000}}
+				}
+			}
+		}
+	}`, body)
+
+	rows, err := sg.AggRows([]byte(`{
+		"hits": {"total": {"value": 3, "relation": "eq"}},
+		"aggregations": {"day": {"buckets": [
+			{"key": 1356307200000, "key_as_string": "2012-12-24", "doc_count": 2, "user_id": {"buckets": [
+				{"key": "abc", "doc_count": 1},
+				{"key": "xyz", "doc_count": 1}
+			]}}
+		]}}
+	}`))
+	assert.Equal(t, nil, err)
+	day := time.Date(2012, 12, 24, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, [][]driver.Value{
+		{day, "abc", int64(1)},
+		{day, "xyz", int64(1)},
+	}, rows)
+
+	// no group by is a single row of metrics
+	sg, body = selectJson(t, `SELECT count(*) AS ct, max(price) AS mx FROM orders`)
+	assert.JSONEq(t, `{"size": 0, "aggregations": {"mx": {"max": {"field": "price"}}}}`, body)
+	rows, err = sg.AggRows([]byte(`{"hits": {"total": {"value": 3}}, "aggregations": {"mx": {"value": 37.5}}}`))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, [][]driver.Value{{int64(3), 37.5}}, rows)
+}
+
+func TestSelectGeneratorCase(t *testing.T) {
+	_, body := selectJson(t, `SELECT user_id FROM orders WHERE CASE WHEN price > 10 THEN true WHEN item_count = 2 THEN user_id = "abc" END`)
+	assert.JSONEq(t, `{
+		"query": {"bool": {"filter": [{"bool": {"should": [
+			{"range": {"price": {"gt": 10}}},
+			{"bool": {"filter": [
+				{"bool": {"must_not": {"range": {"price": {"gt": 10}}}}},
+				{"term": {"item_count": 2}},
+				{"term": {"user_id": "abc"}}
+			]}}
+		]}}]}},
+		"_source": ["user_id"]
+	}`, body)
+
+	// results which are not boolean can't be used as a filter
+	stmt, _ := rel.ParseSqlSelect(`SELECT user_id FROM orders WHERE CASE user_id WHEN "abc" THEN 1 ELSE 0 END`)
+	_, err := esgen.NewSelectGenerator(time.Now(), nil, ordersSchema).Walk(stmt)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestSelectGeneratorLike(t *testing.T) {
+	for _, tc := range []struct {
+		where, filter string
+	}{
+		{`user_id LIKE "ab_%"`, `{"wildcard": {"user_id": "ab?*"}}`},
+		{`user_id LIKE "a*b\%"`, `{"wildcard": {"user_id": "a\\*b%"}}`},
+		{`user_id LIKE "a!_c" ESCAPE "!"`, `{"wildcard": {"user_id": "a_c"}}`},
+		{`user_id ILIKE "AB%"`, `{"wildcard": {"user_id": {"value": "AB*", "case_insensitive": true}}}`},
+		{`user_id REGEXP "^ab+"`, `{"regexp": {"user_id": "ab+.*"}}`},
+		{`user_id ~ "x$"`, `{"regexp": {"user_id": ".*x"}}`},
+	} {
+		_, body := selectJson(t, `SELECT user_id FROM orders WHERE `+tc.where)
+		assert.JSONEq(t, `{"query": {"bool": {"filter": [`+tc.filter+`]}}, "_source": ["user_id"]}`, body, tc.where)
+	}
+}
@@ -207,3 +207,38 @@ func wcFunc(val string) string {
 func Wildcard(field, value string) *wildcard {
 	return &wildcard{Wildcard: map[string]string{field: wcFunc(value)}}
 }
+
+type wildcardValue struct {
+	Value           string `json:"value"`
+	CaseInsensitive bool   `json:"case_insensitive"`
+}
+
+type wildcardFold struct {
+	Wildcard map[string]wildcardValue `json:"wildcard"`
+}
+
+// Like creates a wildcard query matching the whole value, unlike Wildcard
+// value is used as is. Fold matches case-insensitive.
+//
+//    {"wildcard": {field: value}}
+//    {"wildcard": {field: {"value": value, "case_insensitive": true}}}
+//
+func Like(field, value string, fold bool) interface{} {
+	if fold {
+		return &wildcardFold{Wildcard: map[string]wildcardValue{field: {Value: value, CaseInsensitive: true}}}
+	}
+	return &wildcard{Wildcard: map[string]string{field: value}}
+}
+
+type regexpq struct {
+	Regexp map[string]string `json:"regexp"`
+}
+
+// Regexp creates a new Elasticsearch regexp query, the lucene regular
+// expression must match the whole value.
+//
+//    {"regexp": {field: value}}
+//
+func Regexp(field, value string) *regexpq {
+	return &regexpq{Regexp: map[string]string{field: value}}
+}
@@ -0,0 +1,461 @@
+package esgen
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+	"github.com/araddon/qlbridge/rel"
+)
+
+var (
+	// MaxAggSize is the terms aggregation size used for GROUP BY when
+	// the statement has no LIMIT.
+	MaxAggSize = 1000
+)
+
+const (
+	colGroup  = iota // group-by key
+	colCount         // count(*) is doc_count of bucket
+	colMetric        // single value metric aggregation
+)
+
+// SelectGenerator generates a full Elasticsearch search request from a
+// sql select statement, including aggregations for GROUP BY.  After Walk
+// it retains the aggregation layout so responses can be read back as rows.
+type SelectGenerator struct {
+	fg      *FilterGenerator
+	schema  gentypes.SchemaColumns
+	isAgg   bool
+	groups  []*groupCol
+	cols    []*aggCol
+	metrics gentypes.Aggregations
+}
+
+// groupCol is a single GROUP BY column, one bucket aggregation per level.
+type groupCol struct {
+	name     string // aggregation name, the projected column name if projected
+	expr     string
+	field    string
+	interval string // date_histogram interval, empty for terms
+	agg      *gentypes.BucketAgg
+}
+
+// aggCol is a single projected column of an aggregate query.
+type aggCol struct {
+	name  string
+	kind  int
+	group int
+}
+
+// NewSelectGenerator create a generator for sql select statements.
+func NewSelectGenerator(ts time.Time, inc expr.Includer, s gentypes.SchemaColumns) *SelectGenerator {
+	return &SelectGenerator{fg: NewGenerator(ts, inc, s), schema: s}
+}
+
+// Walk the select statement to create the search request.
+//
+//    SELECT user_id, count(*), sum(price) AS total FROM orders
+//    WHERE price > 10 GROUP BY user_id ORDER BY total DESC LIMIT 10
+func (m *SelectGenerator) Walk(stmt *rel.SqlSelect) (*gentypes.Payload, error) {
+
+	m.isAgg, m.groups, m.cols, m.metrics = false, nil, nil, nil
+
+	if stmt.Having != nil {
+		return nil, fmt.Errorf("esgen: HAVING is not supported: %s", stmt.Having)
+	}
+
+	p := &gentypes.Payload{}
+	if stmt.Where != nil && stmt.Where.Expr != nil {
+		f, err := m.fg.walkExpr(stmt.Where.Expr, 0)
+		if err != nil {
+			return nil, err
+		}
+		p.Query = AndFilter([]interface{}{f})
+	}
+
+	if stmt.IsAggQuery() || hasAggColumn(stmt.Columns) {
+		return p, m.walkAggs(stmt, p)
+	}
+
+	if !stmt.Star {
+		for _, col := range stmt.Columns {
+			if col.Star {
+				p.Source = nil
+				break
+			}
+			ft, err := fieldType(m.schema, col.Expr)
+			if err != nil {
+				return nil, err
+			}
+			p.Source = append(p.Source, ft.Field)
+		}
+	}
+	for _, col := range stmt.OrderBy {
+		ft, err := fieldType(m.schema, col.Expr)
+		if err != nil {
+			return nil, err
+		}
+		if col.Asc() {
+			p.SortAsc(ft.Field)
+		} else {
+			p.SortDesc(ft.Field)
+		}
+	}
+	if stmt.Limit > 0 {
+		size := stmt.Limit
+		p.Size = &size
+	}
+	if stmt.Offset > 0 {
+		from := stmt.Offset
+		p.From = &from
+	}
+	return p, nil
+}
+
+// Columns the output column names of an aggregate query in projection order.
+func (m *SelectGenerator) Columns() []string {
+	names := make([]string, len(m.cols))
+	for i, c := range m.cols {
+		names[i] = c.name
+	}
+	return names
+}
+
+func (m *SelectGenerator) walkAggs(stmt *rel.SqlSelect, p *gentypes.Payload) error {
+
+	m.isAgg = true
+	size := 0
+	p.Size = &size
+
+	for _, col := range stmt.GroupBy {
+		g, err := m.groupBy(col)
+		if err != nil {
+			return err
+		}
+		m.groups = append(m.groups, g)
+	}
+
+	m.metrics = make(gentypes.Aggregations)
+	for _, col := range stmt.Columns {
+		if err := m.projectAgg(col); err != nil {
+			return err
+		}
+	}
+
+	aggSize := MaxAggSize
+	if stmt.Limit > 0 {
+		aggSize = stmt.Limit
+	}
+	for _, g := range m.groups {
+		if g.interval == "" {
+			sz := aggSize
+			g.agg.Size = &sz
+		}
+	}
+
+	for _, col := range stmt.OrderBy {
+		if err := m.orderAgg(col); err != nil {
+			return err
+		}
+	}
+
+	// nest the bucket aggregations, innermost has the metrics
+	aggs := m.metrics
+	for i := len(m.groups) - 1; i >= 0; i-- {
+		g := m.groups[i]
+		agg := &gentypes.Aggregation{}
+		if g.interval != "" {
+			agg.DateHistogram = g.agg
+		} else {
+			agg.Terms = g.agg
+		}
+		if len(aggs) > 0 {
+			agg.Aggs = aggs
+		}
+		aggs = gentypes.Aggregations{g.name: agg}
+	}
+	if len(aggs) > 0 {
+		p.Aggs = aggs
+	}
+	return nil
+}
+
+// groupBy converts a GROUP BY column into terms or date_histogram
+//
+//    GROUP BY user_id
+//    GROUP BY date_histogram(order_date, "1d")
+func (m *SelectGenerator) groupBy(col *rel.Column) (*groupCol, error) {
+	g := &groupCol{name: colName(col), expr: col.Expr.String()}
+	switch n := col.Expr.(type) {
+	case *expr.IdentityNode:
+		ft, err := fieldType(m.schema, n)
+		if err != nil {
+			return nil, err
+		}
+		if ft.Nested() {
+			return nil, fmt.Errorf("esgen: GROUP BY not supported on nested field %s", ft.String())
+		}
+		g.field = ft.Field
+	case *expr.FuncNode:
+		if strings.ToLower(n.Name) != "date_histogram" || len(n.Args) != 2 {
+			return nil, fmt.Errorf("esgen: unsupported GROUP BY expression %s", n)
+		}
+		ft, err := fieldType(m.schema, n.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		g.field = ft.Field
+		interval, ok := scalar(n.Args[1])
+		if !ok {
+			return nil, fmt.Errorf("esgen: date_histogram interval must be a literal %s", n)
+		}
+		g.interval = fmt.Sprintf("%v", interval)
+	default:
+		return nil, fmt.Errorf("esgen: unsupported GROUP BY expression %s", col.Expr)
+	}
+	g.agg = &gentypes.BucketAgg{Field: g.field, Interval: g.interval}
+	return g, nil
+}
+
+// projectAgg maps a projected column onto group key or metric aggregation.
+func (m *SelectGenerator) projectAgg(col *rel.Column) error {
+	name := colName(col)
+	if col.CountStar() {
+		m.cols = append(m.cols, &aggCol{name: name, kind: colCount})
+		return nil
+	}
+	if gi := m.groupIndex(col); gi >= 0 {
+		m.groups[gi].name = name
+		m.cols = append(m.cols, &aggCol{name: name, kind: colGroup, group: gi})
+		return nil
+	}
+	fn, ok := col.Expr.(*expr.FuncNode)
+	if !ok || len(fn.Args) != 1 {
+		return fmt.Errorf("esgen: column %s must be in GROUP BY or an aggregate", col.Expr)
+	}
+	ft, err := fieldType(m.schema, fn.Args[0])
+	if err != nil {
+		return err
+	}
+	metric := &gentypes.MetricAgg{Field: ft.Field}
+	agg := &gentypes.Aggregation{}
+	switch strings.ToLower(fn.Name) {
+	case "count":
+		agg.ValueCount = metric
+	case "sum":
+		agg.Sum = metric
+	case "avg":
+		agg.Avg = metric
+	case "min":
+		agg.Min = metric
+	case "max":
+		agg.Max = metric
+	case "cardinality":
+		agg.Cardinality = metric
+	default:
+		return fmt.Errorf("esgen: unsupported aggregate function %s", fn)
+	}
+	m.metrics[name] = agg
+	m.cols = append(m.cols, &aggCol{name: name, kind: colMetric})
+	return nil
+}
+
+// orderAgg ORDER BY for an aggregate query is bucket order, by key for
+// group columns or by metric on the innermost bucket.
+func (m *SelectGenerator) orderAgg(col *rel.Column) error {
+	if len(m.groups) == 0 {
+		return nil
+	}
+	dir := "desc"
+	if col.Asc() {
+		dir = "asc"
+	}
+	if gi := m.groupIndex(col); gi >= 0 {
+		m.groups[gi].agg.Order = map[string]string{"_key": dir}
+		return nil
+	}
+	inner := m.groups[len(m.groups)-1]
+	if col.CountStar() {
+		inner.agg.Order = map[string]string{"_count": dir}
+		return nil
+	}
+	name := col.Expr.String()
+	for _, c := range m.cols {
+		if c.kind == colMetric && (c.name == name || c.name == col.As) {
+			inner.agg.Order = map[string]string{c.name: dir}
+			return nil
+		}
+		if c.kind == colCount && c.name == name {
+			inner.agg.Order = map[string]string{"_count": dir}
+			return nil
+		}
+	}
+	return fmt.Errorf("esgen: unsupported ORDER BY %s in aggregate query", col.Expr)
+}
+
+func (m *SelectGenerator) groupIndex(col *rel.Column) int {
+	if col.Expr == nil {
+		return -1
+	}
+	s := col.Expr.String()
+	for i, g := range m.groups {
+		if g.expr == s || g.name == col.As {
+			return i
+		}
+	}
+	for i, g := range m.groups {
+		if ident, ok := col.Expr.(*expr.IdentityNode); ok {
+			if ft, err := fieldType(m.schema, ident); err == nil && ft.Field == g.field && g.interval == "" {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// AggRows converts an Elasticsearch search response for an aggregate
+// query generated by Walk into rows of Columns().
+func (m *SelectGenerator) AggRows(resp []byte) ([][]driver.Value, error) {
+	if !m.isAgg {
+		return nil, fmt.Errorf("esgen: not an aggregate query")
+	}
+	var r struct {
+		Hits struct {
+			Total json.RawMessage `json:"total"`
+		} `json:"hits"`
+		Aggregations map[string]interface{} `json:"aggregations"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(resp))
+	dec.UseNumber()
+	if err := dec.Decode(&r); err != nil {
+		return nil, err
+	}
+	if len(m.groups) == 0 {
+		return [][]driver.Value{m.row(nil, r.Aggregations, hitsTotal(r.Hits.Total))}, nil
+	}
+	var rows [][]driver.Value
+	if err := m.walkBuckets(0, r.Aggregations, nil, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (m *SelectGenerator) walkBuckets(level int, aggs map[string]interface{}, keys []driver.Value, rows *[][]driver.Value) error {
+	g := m.groups[level]
+	ga, ok := aggs[g.name].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("esgen: missing aggregation %q in response", g.name)
+	}
+	buckets, ok := ga["buckets"].([]interface{})
+	if !ok {
+		return fmt.Errorf("esgen: missing buckets for aggregation %q", g.name)
+	}
+	for _, b := range buckets {
+		bucket, ok := b.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("esgen: invalid bucket %T for aggregation %q", b, g.name)
+		}
+		bkeys := make([]driver.Value, len(keys), len(keys)+1)
+		copy(bkeys, keys)
+		bkeys = append(bkeys, g.key(bucket["key"]))
+		if level == len(m.groups)-1 {
+			*rows = append(*rows, m.row(bkeys, bucket, jsonValue(bucket["doc_count"])))
+			continue
+		}
+		if err := m.walkBuckets(level+1, bucket, bkeys, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *SelectGenerator) row(keys []driver.Value, aggs map[string]interface{}, count driver.Value) []driver.Value {
+	row := make([]driver.Value, len(m.cols))
+	for i, c := range m.cols {
+		switch c.kind {
+		case colGroup:
+			row[i] = keys[c.group]
+		case colCount:
+			row[i] = count
+		case colMetric:
+			if mv, ok := aggs[c.name].(map[string]interface{}); ok {
+				row[i] = jsonValue(mv["value"])
+			}
+		}
+	}
+	return row
+}
+
+// key of a bucket, date_histogram keys are epoch millis.
+func (g *groupCol) key(k interface{}) driver.Value {
+	v := jsonValue(k)
+	if g.interval != "" {
+		switch ms := v.(type) {
+		case int64:
+			return time.Unix(0, ms*int64(time.Millisecond)).UTC()
+		case float64:
+			return time.Unix(0, int64(ms)*int64(time.Millisecond)).UTC()
+		}
+	}
+	return v
+}
+
+// hitsTotal is a number prior to es 7, an object after.
+func hitsTotal(raw json.RawMessage) driver.Value {
+	if len(raw) == 0 {
+		return nil
+	}
+	var total struct {
+		Value int64 `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &total.Value); err == nil {
+		return total.Value
+	}
+	if err := json.Unmarshal(raw, &total); err == nil {
+		return total.Value
+	}
+	return nil
+}
+
+// jsonValue converts a json.Number to int64 or float64.
+func jsonValue(v interface{}) driver.Value {
+	switch vt := v.(type) {
+	case json.Number:
+		if iv, err := vt.Int64(); err == nil {
+			return iv
+		}
+		if fv, err := vt.Float64(); err == nil {
+			return fv
+		}
+		return vt.String()
+	}
+	return v
+}
+
+func colName(col *rel.Column) string {
+	if col.As != "" {
+		return col.As
+	}
+	return col.Expr.String()
+}
+
+func hasAggColumn(cols rel.Columns) bool {
+	for _, col := range cols {
+		if col.Agg || col.CountStar() {
+			return true
+		}
+		if fn, ok := col.Expr.(*expr.FuncNode); ok {
+			switch strings.ToLower(fn.Name) {
+			case "count", "sum", "avg", "min", "max", "cardinality":
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -55,6 +55,12 @@ func (m *TypeValidator) walkNode(node expr.Node) error {
 		return nil
 	case *expr.FuncNode:
 		return m.funcExpr(n)
+	case *expr.CaseNode:
+		bn, err := caseAsBoolean(n)
+		if err != nil || bn == nil {
+			return err
+		}
+		return m.walkNode(bn)
 	default:
 		u.Warnf("not handled type validation %v %T", node, node)
 		return fmt.Errorf("esgen: unsupported node in expression: %T (%s)", node, node)
@@ -121,7 +127,7 @@ func (m *TypeValidator) binaryNode(node *expr.BinaryNode) error {
 		// ident(0) != literal(1)
 	case lex.TokenContains:
 		// ident CONTAINS literal
-	case lex.TokenLike:
+	case lex.TokenLike, lex.TokenILike, lex.TokenRegexp:
 		// ident LIKE literal
 	case lex.TokenIN, lex.TokenIntersects:
 		// Build up list of arguments
@@ -48,10 +48,44 @@ type (
 	}
 	// Payload is the top Level Request to Elasticsearch
 	Payload struct {
-		Size   *int                   `json:"size,omitempty"`
-		Filter interface{}            `json:"filter,omitempty"`
-		Fields []string               `json:"fields,omitempty"`
-		Sort   []map[string]SortOrder `json:"sort,omitempty"`
+		Size        *int                   `json:"size,omitempty"`
+		From        *int                   `json:"from,omitempty"`
+		Query       interface{}            `json:"query,omitempty"`
+		Filter      interface{}            `json:"filter,omitempty"`
+		Fields      []string               `json:"fields,omitempty"`
+		Source      []string               `json:"_source,omitempty"`
+		Sort        []map[string]SortOrder `json:"sort,omitempty"`
+		SearchAfter []interface{}          `json:"search_after,omitempty"`
+		Aggs        Aggregations           `json:"aggregations,omitempty"`
+	}
+	// Aggregations are the named aggregations of a request, each
+	// is a single aggregation type such as "terms" with optional
+	// sub-aggregations.
+	Aggregations map[string]*Aggregation
+	// Aggregation is a single named aggregation
+	//
+	//    {"terms": {"field": "user_id", "size": 10}, "aggregations": {...}}
+	Aggregation struct {
+		Terms         *BucketAgg   `json:"terms,omitempty"`
+		DateHistogram *BucketAgg   `json:"date_histogram,omitempty"`
+		Sum           *MetricAgg   `json:"sum,omitempty"`
+		Avg           *MetricAgg   `json:"avg,omitempty"`
+		Min           *MetricAgg   `json:"min,omitempty"`
+		Max           *MetricAgg   `json:"max,omitempty"`
+		ValueCount    *MetricAgg   `json:"value_count,omitempty"`
+		Cardinality   *MetricAgg   `json:"cardinality,omitempty"`
+		Aggs          Aggregations `json:"aggregations,omitempty"`
+	}
+	// BucketAgg is a terms, or date_histogram bucketing aggregation.
+	BucketAgg struct {
+		Field    string            `json:"field"`
+		Size     *int              `json:"size,omitempty"`
+		Interval string            `json:"interval,omitempty"`
+		Order    map[string]string `json:"order,omitempty"`
+	}
+	// MetricAgg is a single value metric aggregation
+	MetricAgg struct {
+		Field string `json:"field"`
 	}
 	// SortOder of the es query request
 	SortOrder struct {
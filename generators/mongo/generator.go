@@ -0,0 +1,426 @@
+// Package mongo generates MongoDB find filters and aggregation pipelines
+// from FilterQL and SQL statements.
+package mongo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
+)
+
+var (
+	// MaxDepth specifies the depth at which we are certain the filter generator is in an endless loop
+	// This *shouldn't* happen, but is better than a stack overflow
+	MaxDepth = 1000
+
+	// MatchAll a filter matching every document
+	MatchAll = M{}
+	// MatchNone a filter matching no documents, every document has an _id
+	MatchNone = M{"_id": M{"$exists": false}}
+
+	_ = u.EMPTY
+)
+
+type (
+	// M is a mongo document, it marshals the same as bson.M in
+	// either of the go mongo drivers.
+	M map[string]interface{}
+
+	// D is an ordered mongo document such as a $sort.
+	D []DocElem
+
+	// DocElem a single ordered key/value of a D.
+	DocElem struct {
+		Name  string
+		Value interface{}
+	}
+
+	// Query is the generated find filter or aggregation pipeline.
+	Query struct {
+		// Filter for find() or the $match of an aggregation
+		Filter M
+		// Projection of a find()
+		Projection M
+		// Sort of a find()
+		Sort D
+		// Skip and Limit of a find()
+		Skip, Limit int
+		// Pipeline is non-nil for aggregate queries, and includes the filter
+		Pipeline []M
+		// HasDateMath is true if the filter uses relative date-math ("now-3d")
+		// so is only valid for the generator's time.
+		HasDateMath bool
+	}
+)
+
+// MarshalJSON keeps the order of the document.
+func (d D) MarshalJSON() ([]byte, error) {
+	buf := []byte{'{'}
+	for i, e := range d {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		k, err := json.Marshal(e.Name)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(append(append(buf, k...), ':'), v...)
+	}
+	return append(buf, '}'), nil
+}
+
+// FilterGenerator converts FilterQL/sql where expressions into mongo filters.
+type FilterGenerator struct {
+	ts      time.Time
+	inc     expr.Includer
+	schema  gentypes.SchemaColumns
+	field   func(n expr.Node) (*gentypes.FieldType, error)
+	hasMath bool
+}
+
+// NewGenerator creates a filter generator, ts is the anchor time
+// for date-math such as "now-3d".
+func NewGenerator(ts time.Time, inc expr.Includer, s gentypes.SchemaColumns) *FilterGenerator {
+	fg := &FilterGenerator{ts: ts, inc: inc, schema: s}
+	fg.field = fg.schemaField
+	return fg
+}
+
+func (fg *FilterGenerator) schemaField(n expr.Node) (*gentypes.FieldType, error) {
+	return fieldType(fg.schema, n)
+}
+
+// Walk the filter statement creating the find filter.
+func (fg *FilterGenerator) Walk(stmt *rel.FilterStatement) (*Query, error) {
+	f, err := fg.WalkExpr(stmt.Filter)
+	if err != nil {
+		return nil, err
+	}
+	return &Query{Filter: f, HasDateMath: fg.hasMath}, nil
+}
+
+// WalkExpr converts a single boolean expression to a filter.
+func (fg *FilterGenerator) WalkExpr(node expr.Node) (M, error) {
+	fg.hasMath = false
+	if node == nil {
+		return MatchAll, nil
+	}
+	f, err := fg.walkExpr(node, 0)
+	if err != nil {
+		// Convert MissingField errors to a logical `false`
+		if _, ok := err.(*gentypes.MissingFieldError); !ok {
+			return nil, err
+		}
+		f = MatchNone
+	}
+	dc, err := vm.NewDateConverter(&dateMathContext{ts: fg.ts, Includer: fg.inc}, node)
+	if err != nil {
+		return nil, err
+	}
+	// the converter only finds date-math in binary comparisons, not BETWEEN
+	fg.hasMath = fg.hasMath || dc.HasDateMath
+	return f, nil
+}
+
+// expr dispatches to node-type-specific methods
+func (fg *FilterGenerator) walkExpr(node expr.Node, depth int) (M, error) {
+	if depth > MaxDepth {
+		return nil, fmt.Errorf("hit max depth on filter generation. bad query?")
+	}
+	var err error
+	var filter M
+	switch n := node.(type) {
+	case *expr.UnaryNode:
+		// Urnaries do their own negation
+		filter, err = fg.unaryExpr(n, depth+1)
+	case *expr.BooleanNode:
+		// Also do their own negation
+		filter, err = fg.booleanExpr(n, depth+1)
+	case *expr.BinaryNode:
+		filter, err = fg.binaryExpr(n, depth+1)
+	case *expr.TriNode:
+		filter, err = fg.triExpr(n, depth+1)
+	case *expr.IdentityNode:
+		switch strings.ToLower(n.Text) {
+		case "match_all", "*":
+			return MatchAll, nil
+		}
+		if n.Bool() {
+			return MatchAll, nil
+		}
+		return nil, fmt.Errorf("mongo: unsupported identity in expression: %s", n)
+	case *expr.IncludeNode:
+		if incErr := vm.ResolveIncludes(fg.inc, n); incErr != nil {
+			return nil, incErr
+		}
+		filter, err = fg.walkExpr(n.ExprNode, depth+1)
+	default:
+		return nil, fmt.Errorf("mongo: unsupported node in expression: %T (%s)", node, node)
+	}
+	if err != nil {
+		// A negated missing field is a logical `true`, otherwise the
+		// error is returned for the enclosing boolean to handle.
+		if _, ok := err.(*gentypes.MissingFieldError); ok && isNegated(node) {
+			return MatchAll, nil
+		}
+		return nil, err
+	}
+
+	if nn, isNegateable := node.(expr.NegateableNode); isNegateable && nn.Negated() {
+		return Not(filter), nil
+	}
+	return filter, nil
+}
+
+func (fg *FilterGenerator) unaryExpr(node *expr.UnaryNode, depth int) (M, error) {
+	switch node.Operator.T {
+	case lex.TokenExists:
+		ft, err := fg.field(node.Arg)
+		if err != nil {
+			return nil, err
+		}
+		return M{fieldName(ft): M{"$exists": true}}, nil
+
+	case lex.TokenNegate:
+		inner, err := fg.walkExpr(node.Arg, depth+1)
+		if err != nil {
+			if _, ok := err.(*gentypes.MissingFieldError); ok {
+				return MatchAll, nil
+			}
+			return nil, err
+		}
+		return Not(inner), nil
+	default:
+		return nil, fmt.Errorf("mongo: unsupported unary operator: %s", node.Operator.T)
+	}
+}
+
+func (fg *FilterGenerator) booleanExpr(bn *expr.BooleanNode, depth int) (M, error) {
+	and := true
+	switch bn.Operator.T {
+	case lex.TokenAnd, lex.TokenLogicAnd:
+	case lex.TokenOr, lex.TokenLogicOr:
+		and = false
+	default:
+		return nil, fmt.Errorf("mongo: unexpected op %v", bn.Operator)
+	}
+
+	items := make([]interface{}, 0, len(bn.Args))
+	for _, fe := range bn.Args {
+		it, err := fg.walkExpr(fe, depth+1)
+		if err != nil {
+			if _, ok := err.(*gentypes.MissingFieldError); ok {
+				if !and {
+					// Simply skip missing fields in ORs
+					continue
+				}
+				// Convert ANDs to false
+				return MatchNone, nil
+			}
+			return nil, err
+		}
+		items = append(items, it)
+	}
+
+	switch len(items) {
+	case 0:
+		return MatchNone, nil
+	case 1:
+		// omit the useless boolean since there's only 1 item
+		return items[0].(M), nil
+	}
+	if and {
+		return M{"$and": items}, nil
+	}
+	return M{"$or": items}, nil
+}
+
+func (fg *FilterGenerator) binaryExpr(node *expr.BinaryNode, depth int) (M, error) {
+	// Type check binary expression arguments as they must be:
+	// Identifier-Operator-Literal
+	lhs, err := fg.field(node.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	field := fieldName(lhs)
+
+	switch op := node.Operator.T; op {
+	case lex.TokenGE, lex.TokenLE, lex.TokenGT, lex.TokenLT:
+		rhs, err := fg.scalarFor(lhs, node.Args[1])
+		if err != nil {
+			return nil, err
+		}
+		return M{field: M{rangeOps[op]: rhs}}, nil
+
+	case lex.TokenEqual, lex.TokenEqualEqual: // the VM supports both = and ==
+		rhs, err := fg.scalarFor(lhs, node.Args[1])
+		if err != nil {
+			return nil, err
+		}
+		return M{field: rhs}, nil
+
+	case lex.TokenNE: // ident(0) != literal(1)
+		rhs, err := fg.scalarFor(lhs, node.Args[1])
+		if err != nil {
+			return nil, err
+		}
+		return M{field: M{"$ne": rhs}}, nil
+
+	case lex.TokenContains, lex.TokenLike, lex.TokenILike, lex.TokenRegexp: // ident LIKE literal
+		pattern, ok := patternText(node.Args[1])
+		if !ok {
+			return nil, fmt.Errorf("mongo: unsupported non-string argument for %s pattern: %T", op, node.Args[1])
+		}
+		switch op {
+		case lex.TokenContains:
+			return M{field: M{"$regex": regexQuote(pattern)}}, nil
+		case lex.TokenRegexp:
+			return M{field: M{"$regex": pattern}}, nil
+		}
+		return likeRegex(field, op, node.LikePattern(pattern), vm.DefaultLikeEscape)
+
+	case lex.TokenIN, lex.TokenIntersects:
+		array, ok := node.Args[1].(*expr.ArrayNode)
+		if !ok {
+			return nil, fmt.Errorf("mongo: second argument to %s must be an array, found: %T", op, node.Args[1])
+		}
+		args := make([]interface{}, 0, len(array.Args))
+		for _, nodearg := range array.Args {
+			arg, err := fg.scalarFor(lhs, nodearg)
+			if err != nil {
+				return nil, fmt.Errorf("mongo: non-scalar argument in %s clause: %T", op, nodearg)
+			}
+			args = append(args, arg)
+		}
+		return M{field: M{"$in": args}}, nil
+
+	default:
+		return nil, fmt.Errorf("mongo: unsupported binary expression: %s", op)
+	}
+}
+
+func (fg *FilterGenerator) triExpr(node *expr.TriNode, depth int) (M, error) {
+	switch op := node.Operator.T; op {
+	case lex.TokenBetween: // a BETWEEN b AND c
+		lhs, err := fg.field(node.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		lower, err := fg.scalarFor(lhs, node.Args[1])
+		if err != nil {
+			return nil, err
+		}
+		upper, err := fg.scalarFor(lhs, node.Args[2])
+		if err != nil {
+			return nil, err
+		}
+		// the vm's between is exclusive
+		return M{fieldName(lhs): M{"$gt": lower, "$lt": upper}}, nil
+	case lex.TokenLike, lex.TokenILike: // a LIKE b ESCAPE c
+		lhs, err := fg.field(node.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		pattern, ok := patternText(node.Args[1])
+		if !ok {
+			return nil, fmt.Errorf("mongo: unsupported non-string argument for %s pattern: %T", op, node.Args[1])
+		}
+		escape, ok := patternText(node.Args[2])
+		if !ok {
+			return nil, fmt.Errorf("mongo: unsupported non-string argument for ESCAPE: %T", node.Args[2])
+		}
+		return likeRegex(fieldName(lhs), op, pattern, escape)
+	}
+	return nil, fmt.Errorf("mongo: unsupported ternary expression: %s", node.Operator.T)
+}
+
+// scalarFor converts a literal to the type of the field it is compared to,
+// date-math strings are anchored at the generator time.
+func (fg *FilterGenerator) scalarFor(lhs *gentypes.FieldType, n expr.Node) (interface{}, error) {
+	val, ok := scalar(n)
+	if !ok {
+		return nil, fmt.Errorf("mongo: unsupported type for comparison: %T (%s)", n, n)
+	}
+	rhv := value.NewValue(val)
+	switch lhs.Type {
+	case value.IntType, value.MapIntType:
+		if iv, ok := value.ValueToInt64(rhv); ok {
+			return iv, nil
+		}
+		return nil, fmt.Errorf("mongo: could not convert %v to int for %s", val, lhs.Field)
+	case value.NumberType, value.MapNumberType:
+		if fv, ok := value.ValueToFloat64(rhv); ok {
+			return fv, nil
+		}
+		return nil, fmt.Errorf("mongo: could not convert %v to number for %s", val, lhs.Field)
+	case value.TimeType, value.MapTimeType:
+		if s, ok := val.(string); ok {
+			if len(s) > 3 && strings.ToLower(s[:3]) == "now" {
+				fg.hasMath = true
+			}
+			if t, ok := value.StringToTimeAnchor(s, fg.ts); ok {
+				return t, nil
+			}
+			return nil, fmt.Errorf("mongo: could not convert %q to time for %s", s, lhs.Field)
+		}
+	case value.BoolType, value.MapBoolType:
+		if bv, ok := value.ValueToBool(rhv); ok {
+			return bv, nil
+		}
+	}
+	return val, nil
+}
+
+func isNegated(node expr.Node) bool {
+	nn, ok := node.(expr.NegateableNode)
+	return ok && nn.Negated()
+}
+
+// likeRegex the $regex filter for field LIKE pattern, ILIKE is case-insensitive.
+func likeRegex(field string, op lex.TokenType, pattern, escape string) (M, error) {
+	re, err := likeToRegex(pattern, escape)
+	if err != nil {
+		return nil, fmt.Errorf("mongo: %v", err)
+	}
+	if op == lex.TokenILike {
+		return M{field: M{"$regex": re, "$options": "i"}}, nil
+	}
+	return M{field: M{"$regex": re}}, nil
+}
+
+// Not negates a filter, mongo has no top level $not so use $nor.
+func Not(f M) M {
+	return M{"$nor": []interface{}{f}}
+}
+
+var rangeOps = map[lex.TokenType]string{
+	lex.TokenGT: "$gt",
+	lex.TokenGE: "$gte",
+	lex.TokenLT: "$lt",
+	lex.TokenLE: "$lte",
+}
+
+// dateMathContext is the context for finding date-math in a filter, there
+// is no document so only the date-math strings are found.
+type dateMathContext struct {
+	expr.Includer
+	ts time.Time
+}
+
+func (m *dateMathContext) Get(key string) (value.Value, bool) { return nil, false }
+func (m *dateMathContext) Row() map[string]value.Value        { return nil }
+func (m *dateMathContext) Ts() time.Time                      { return m.ts }
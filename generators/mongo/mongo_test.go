@@ -0,0 +1,208 @@
+package mongo_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+	"github.com/araddon/qlbridge/generators/mongo"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+type testSchema map[string]value.ValueType
+
+func (m testSchema) Column(col string) (value.ValueType, bool) {
+	vt, ok := m[col]
+	return vt, ok
+}
+func (m testSchema) ColumnInfo(col string) (*gentypes.FieldType, bool) {
+	if vt, ok := m[col]; ok {
+		return &gentypes.FieldType{Field: col, Type: vt}, true
+	}
+	// map fields, "events.open"
+	parts := strings.SplitN(col, ".", 2)
+	if vt, ok := m[parts[0]]; ok && len(parts) == 2 && vt == value.MapIntType {
+		return &gentypes.FieldType{Field: parts[1], Path: parts[0], Prefix: "i", Type: vt}, true
+	}
+	return nil, false
+}
+
+var (
+	ts = time.Date(2017, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	usersSchema = testSchema{
+		"user_id":    value.StringType,
+		"email":      value.StringType,
+		"name":       value.StringType,
+		"visits":     value.IntType,
+		"score":      value.NumberType,
+		"verified":   value.BoolType,
+		"last_visit": value.TimeType,
+		"events":     value.MapIntType,
+	}
+)
+
+func asJson(t *testing.T, v interface{}) string {
+	by, err := json.Marshal(v)
+	assert.Equal(t, nil, err)
+	return string(by)
+}
+
+func filterJson(t *testing.T, ql string) (*mongo.Query, string) {
+	fs, err := rel.ParseFilterQL(ql)
+	assert.Equal(t, nil, err, ql)
+	q, err := mongo.NewGenerator(ts, nil, usersSchema).Walk(fs)
+	assert.Equal(t, nil, err, ql)
+	if q == nil {
+		return nil, ""
+	}
+	return q, asJson(t, q.Filter)
+}
+
+func TestFilterGenerator(t *testing.T) {
+	tests := []struct {
+		ql, filter string
+	}{
+		{`FILTER visits > 5`, `{"visits": {"$gt": 5}}`},
+		{`FILTER score >= "5.5"`, `{"score": {"$gte": 5.5}}`},
+		{`FILTER email = "bob@email.com"`, `{"email": "bob@email.com"}`},
+		{`FILTER email != "bob@email.com"`, `{"email": {"$ne": "bob@email.com"}}`},
+		{`FILTER verified == true`, `{"verified": true}`},
+		{`FILTER AND (visits > 5, email LIKE "*@gmail.com")`,
+			`{"$and": [{"visits": {"$gt": 5}}, {"email": {"$regex": "@gmail\\.com$"}}]}`},
+		{`FILTER AND (visits > 5, email LIKE "%@gmail.com")`,
+			`{"$and": [{"visits": {"$gt": 5}}, {"email": {"$regex": "@gmail\\.com$"}}]}`},
+		{`FILTER OR (name LIKE "bo?", name LIKE "al%")`,
+			`{"$or": [{"name": {"$regex": "^bo.$"}}, {"name": {"$regex": "^al"}}]}`},
+		{`FILTER name LIKE "a_b*"`, `{"name": {"$regex": "^a_b"}}`},
+		{`FILTER name LIKE "a*b!%%" ESCAPE "!"`, `{"name": {"$regex": "^a\\*b%"}}`},
+		{`FILTER name ILIKE "al%"`, `{"name": {"$options": "i", "$regex": "^al"}}`},
+		{`FILTER name REGEXP "^al+"`, `{"name": {"$regex": "^al+"}}`},
+		{`FILTER name CONTAINS "a.b"`, `{"name": {"$regex": "a\\.b"}}`},
+		{`FILTER user_id IN ("abc", "def")`, `{"user_id": {"$in": ["abc", "def"]}}`},
+		{`FILTER NOT user_id IN ("abc")`, `{"$nor": [{"user_id": {"$in": ["abc"]}}]}`},
+		{`FILTER EXISTS email`, `{"email": {"$exists": true}}`},
+		{`FILTER visits BETWEEN 1 AND 10`, `{"visits": {"$gt": 1, "$lt": 10}}`},
+		{`FILTER events.open > 2`, `{"events.open": {"$gt": 2}}`},
+		{`FILTER *`, `{}`},
+		// missing fields are false in AND, skipped in OR
+		{`FILTER AND (visits > 5, not_a_field = 1)`, `{"_id": {"$exists": false}}`},
+		{`FILTER OR (visits > 5, not_a_field = 1)`, `{"visits": {"$gt": 5}}`},
+		{`FILTER not_a_field = 1`, `{"_id": {"$exists": false}}`},
+		{`FILTER AND (visits > 5, NOT not_a_field = 1)`, `{"$and": [{"visits": {"$gt": 5}}, {}]}`},
+	}
+	for _, tc := range tests {
+		_, body := filterJson(t, tc.ql)
+		assert.JSONEq(t, tc.filter, body, tc.ql)
+	}
+
+	fs, _ := rel.ParseFilterQL(`FILTER visits > "not a number"`)
+	_, err := mongo.NewGenerator(ts, nil, usersSchema).Walk(fs)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestFilterDateMath(t *testing.T) {
+	q, body := filterJson(t, `FILTER last_visit > "now-3d"`)
+	assert.True(t, q.HasDateMath)
+	gt := q.Filter["last_visit"].(mongo.M)["$gt"]
+	assert.Equal(t, ts.Add(-72*time.Hour), gt, body)
+
+	q, _ = filterJson(t, `FILTER last_visit BETWEEN "2017-01-01" AND "now-1h"`)
+	assert.True(t, q.HasDateMath)
+	rng := q.Filter["last_visit"].(mongo.M)
+	assert.Equal(t, time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), rng["$gt"])
+	assert.Equal(t, ts.Add(-time.Hour), rng["$lt"])
+
+	q, _ = filterJson(t, `FILTER last_visit > "2017-01-01"`)
+	assert.False(t, q.HasDateMath)
+}
+
+func TestSelectGenerator(t *testing.T) {
+	stmt, err := rel.ParseSqlSelect(`SELECT user_id, email FROM users WHERE visits > 5 ORDER BY last_visit DESC, user_id ASC LIMIT 10 OFFSET 20`)
+	assert.Equal(t, nil, err)
+	q, err := mongo.NewSelectGenerator(ts, nil, usersSchema).Walk(stmt)
+	assert.Equal(t, nil, err)
+	assert.JSONEq(t, `{"visits": {"$gt": 5}}`, asJson(t, q.Filter))
+	assert.JSONEq(t, `{"user_id": 1, "email": 1, "_id": 0}`, asJson(t, q.Projection))
+	assert.Equal(t, `{"last_visit":-1,"user_id":1}`, asJson(t, q.Sort))
+	assert.Equal(t, 20, q.Skip)
+	assert.Equal(t, 10, q.Limit)
+	assert.Equal(t, 0, len(q.Pipeline))
+
+	stmt, _ = rel.ParseSqlSelect(`SELECT * FROM users`)
+	q, err = mongo.NewSelectGenerator(ts, nil, usersSchema).Walk(stmt)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(q.Filter))
+	assert.Equal(t, 0, len(q.Projection))
+}
+
+func TestSelectGeneratorAggs(t *testing.T) {
+	stmt, err := rel.ParseSqlSelect(`
+		SELECT user_id, count(*) AS ct, sum(score) AS total, cardinality(email) AS emails
+		FROM users
+		WHERE verified = true
+		GROUP BY user_id
+		HAVING ct > 1
+		ORDER BY total DESC
+		LIMIT 5`)
+	assert.Equal(t, nil, err)
+	sg := mongo.NewSelectGenerator(ts, nil, usersSchema)
+	q, err := sg.Walk(stmt)
+	assert.Equal(t, nil, err)
+	assert.JSONEq(t, `[
+		{"$match": {"verified": true}},
+		{"$group": {
+			"_id": {"user_id": "$user_id"},
+			"ct": {"$sum": 1},
+			"total": {"$sum": "$score"},
+			"emails": {"$addToSet": "$email"}
+		}},
+		{"$project": {"_id": 0, "user_id": "$_id.user_id", "ct": 1, "total": 1, "emails": {"$size": "$emails"}}},
+		{"$match": {"ct": {"$gt": 1}}},
+		{"$sort": {"total": -1}},
+		{"$limit": 5}
+	]`, asJson(t, q.Pipeline))
+	assert.Equal(t, []string{"user_id", "ct", "total", "emails"}, sg.Columns())
+
+	// no group by is a single group
+	stmt, _ = rel.ParseSqlSelect(`SELECT count(*) AS ct, max(visits) AS mx FROM users`)
+	q, err = sg.Walk(stmt)
+	assert.Equal(t, nil, err)
+	assert.JSONEq(t, `[
+		{"$group": {"_id": null, "ct": {"$sum": 1}, "mx": {"$max": "$visits"}}},
+		{"$project": {"_id": 0, "ct": 1, "mx": 1}}
+	]`, asJson(t, q.Pipeline))
+
+	stmt, _ = rel.ParseSqlSelect(`SELECT email, count(*) FROM users GROUP BY user_id`)
+	_, err = sg.Walk(stmt)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestValidator(t *testing.T) {
+	v := mongo.NewValidator(usersSchema)
+	for _, ql := range []string{
+		`FILTER AND (visits > 5, email LIKE "*@gmail.com", last_visit > "now-2d")`,
+		`FILTER verified = true`,
+		`FILTER user_id IN ("a", "b")`,
+	} {
+		fs, err := rel.ParseFilterQL(ql)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, v.FilterValidate(fs), ql)
+	}
+	for _, ql := range []string{
+		`FILTER not_a_field = 5`,
+		`FILTER visits > "abc"`,
+		`FILTER visits LIKE "a*"`,
+		`FILTER verified > true`,
+		`FILTER last_visit > "not a date"`,
+	} {
+		fs, err := rel.ParseFilterQL(ql)
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, nil, v.FilterValidate(fs), ql)
+	}
+}
@@ -0,0 +1,100 @@
+package mongo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
+)
+
+// fieldType finds the schema info of an identity.
+func fieldType(s gentypes.SchemaColumns, n expr.Node) (*gentypes.FieldType, error) {
+
+	ident, ok := n.(*expr.IdentityNode)
+	if !ok {
+		return nil, fmt.Errorf("expected an identity but found %T (%s)", n, n)
+	}
+
+	ft, ok := s.ColumnInfo(ident.Text)
+	if ok {
+		return ft, nil
+	}
+	if ident.HasLeftRight() {
+		ft, ok := s.ColumnInfo(ident.OriginalText())
+		if ok {
+			return ft, nil
+		}
+	}
+	return nil, gentypes.MissingField(ident.OriginalText())
+}
+
+// fieldName the mongo document path of a field, keys of map fields
+// are sub-documents.
+func fieldName(ft *gentypes.FieldType) string {
+	if ft.Nested() {
+		if ft.Field == "" {
+			return ft.Path
+		}
+		return ft.Path + "." + ft.Field
+	}
+	return ft.Field
+}
+
+// scalar returns the literal value of a scalar node.
+//
+// Does not support Null.
+func scalar(node expr.Node) (interface{}, bool) {
+	switch n := node.(type) {
+	case *expr.StringNode:
+		return n.Text, true
+	case *expr.NumberNode:
+		if n.IsInt {
+			return n.Int64, true
+		}
+		return n.Float64, true
+	case *expr.ValueNode:
+		switch n.Value.Type() {
+		case value.BoolType, value.IntType, value.NumberType, value.StringType, value.TimeType:
+			return n.Value.Value(), true
+		}
+	case *expr.IdentityNode:
+		if b, err := strconv.ParseBool(n.Text); err == nil {
+			return b, true
+		}
+	}
+	return nil, false
+}
+
+func patternText(node expr.Node) (string, bool) {
+	switch n := node.(type) {
+	case *expr.StringNode:
+		return n.Text, true
+	case *expr.IdentityNode:
+		return n.Text, true
+	case *expr.NumberNode:
+		return n.Text, true
+	}
+	return "", false
+}
+
+// likeToRegex converts an ANSI LIKE pattern to an anchored regex, % for
+// any characters and _ for one.
+func likeToRegex(pattern, escape string) (string, error) {
+	re, err := vm.TranslateLike(pattern, escape, ".*", ".", regexp.QuoteMeta)
+	if err != nil {
+		return "", err
+	}
+	// leading or trailing any-characters don't need anchors
+	re = strings.TrimPrefix("^"+re+"$", "^.*")
+	return strings.TrimSuffix(re, ".*$"), nil
+}
+
+// regexQuote a contains-substring regex.
+func regexQuote(s string) string {
+	return regexp.QuoteMeta(s)
+}
@@ -0,0 +1,267 @@
+package mongo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+// SelectGenerator converts a sql select into a mongo find() or, for
+// GROUP BY and aggregate functions, an aggregation pipeline.
+type SelectGenerator struct {
+	fg     *FilterGenerator
+	schema gentypes.SchemaColumns
+	cols   []*outCol
+}
+
+// outCol is an output column of an aggregate pipeline.
+type outCol struct {
+	name  string // sanitized output field name
+	expr  string // original expression, to match HAVING/ORDER BY
+	as    string
+	group bool
+	ft    *gentypes.FieldType
+}
+
+// NewSelectGenerator creates a select generator, ts is the anchor time
+// for date-math.
+func NewSelectGenerator(ts time.Time, inc expr.Includer, s gentypes.SchemaColumns) *SelectGenerator {
+	return &SelectGenerator{fg: NewGenerator(ts, inc, s), schema: s}
+}
+
+// Walk the select statement to create the query.
+//
+//    SELECT user_id, count(*) AS ct, sum(price) AS total FROM orders
+//    WHERE price > 10 GROUP BY user_id HAVING ct > 1 ORDER BY total DESC LIMIT 10
+func (m *SelectGenerator) Walk(stmt *rel.SqlSelect) (*Query, error) {
+
+	m.cols = nil
+	q := &Query{Filter: MatchAll}
+	if stmt.Where != nil && stmt.Where.Expr != nil {
+		f, err := m.fg.WalkExpr(stmt.Where.Expr)
+		if err != nil {
+			return nil, err
+		}
+		q.Filter = f
+		q.HasDateMath = m.fg.hasMath
+	}
+
+	if stmt.IsAggQuery() || hasAggColumn(stmt.Columns) {
+		return q, m.walkAggs(stmt, q)
+	}
+	if stmt.Having != nil {
+		return nil, fmt.Errorf("mongo: HAVING requires an aggregate query: %s", stmt.Having)
+	}
+
+	if !stmt.Star {
+		q.Projection = M{}
+		hasID := false
+		for _, col := range stmt.Columns {
+			if col.Star {
+				q.Projection = nil
+				break
+			}
+			ft, err := fieldType(m.schema, col.Expr)
+			if err != nil {
+				return nil, err
+			}
+			name := fieldName(ft)
+			hasID = hasID || name == "_id"
+			q.Projection[name] = 1
+		}
+		if q.Projection != nil && !hasID {
+			q.Projection["_id"] = 0
+		}
+	}
+	for _, col := range stmt.OrderBy {
+		ft, err := fieldType(m.schema, col.Expr)
+		if err != nil {
+			return nil, err
+		}
+		q.Sort = append(q.Sort, DocElem{fieldName(ft), sortDir(col)})
+	}
+	q.Skip, q.Limit = stmt.Offset, stmt.Limit
+	return q, nil
+}
+
+// Columns the output column names of an aggregate query in projection order.
+func (m *SelectGenerator) Columns() []string {
+	names := make([]string, len(m.cols))
+	for i, c := range m.cols {
+		names[i] = c.name
+	}
+	return names
+}
+
+// walkAggs builds the pipeline
+//
+//    $match -> $group -> $project -> $match (having) -> $sort -> $skip -> $limit
+func (m *SelectGenerator) walkAggs(stmt *rel.SqlSelect, q *Query) error {
+
+	groupID := M{}
+	groupKeys := make(map[string]string, len(stmt.GroupBy))
+	for _, col := range stmt.GroupBy {
+		ft, err := fieldType(m.schema, col.Expr)
+		if err != nil {
+			return fmt.Errorf("mongo: unsupported GROUP BY expression %s: %v", col.Expr, err)
+		}
+		key := outName(col.Expr.String())
+		groupID[key] = "$" + fieldName(ft)
+		groupKeys[col.Expr.String()] = key
+	}
+
+	group := M{"_id": nil}
+	if len(groupID) > 0 {
+		group["_id"] = groupID
+	}
+	project := M{"_id": 0}
+	for _, col := range stmt.Columns {
+		oc := &outCol{name: outName(colName(col)), as: col.As}
+		if col.Expr != nil {
+			oc.expr = col.Expr.String()
+		}
+		if key, ok := groupKeys[oc.expr]; ok {
+			oc.group = true
+			oc.ft, _ = fieldType(m.schema, col.Expr)
+			project[oc.name] = "$_id." + key
+			m.cols = append(m.cols, oc)
+			continue
+		}
+		acc, proj, vt, err := m.accumulator(col)
+		if err != nil {
+			return err
+		}
+		oc.ft = &gentypes.FieldType{Field: oc.name, Type: vt}
+		group[oc.name] = acc
+		project[oc.name] = proj
+		m.cols = append(m.cols, oc)
+	}
+
+	if !q.isMatchAll() {
+		q.Pipeline = append(q.Pipeline, M{"$match": q.Filter})
+	}
+	q.Pipeline = append(q.Pipeline, M{"$group": group}, M{"$project": project})
+
+	if stmt.Having != nil {
+		hg := NewGenerator(m.fg.ts, m.fg.inc, m.schema)
+		hg.field = m.outputField
+		f, err := hg.WalkExpr(stmt.Having)
+		if err != nil {
+			return err
+		}
+		q.Pipeline = append(q.Pipeline, M{"$match": f})
+	}
+	if len(stmt.OrderBy) > 0 {
+		sort := D{}
+		for _, col := range stmt.OrderBy {
+			ft, err := m.outputField(col.Expr)
+			if err != nil {
+				return fmt.Errorf("mongo: unsupported ORDER BY %s in aggregate query", col.Expr)
+			}
+			sort = append(sort, DocElem{ft.Field, sortDir(col)})
+		}
+		q.Pipeline = append(q.Pipeline, M{"$sort": sort})
+	}
+	if stmt.Offset > 0 {
+		q.Pipeline = append(q.Pipeline, M{"$skip": stmt.Offset})
+	}
+	if stmt.Limit > 0 {
+		q.Pipeline = append(q.Pipeline, M{"$limit": stmt.Limit})
+	}
+	return nil
+}
+
+// accumulator the $group accumulator and $project expression of an
+// aggregate column.
+func (m *SelectGenerator) accumulator(col *rel.Column) (interface{}, interface{}, value.ValueType, error) {
+	if col.CountStar() {
+		return M{"$sum": 1}, 1, value.IntType, nil
+	}
+	fn, ok := col.Expr.(*expr.FuncNode)
+	if !ok || len(fn.Args) != 1 {
+		return nil, nil, value.UnknownType, fmt.Errorf("mongo: column %s must be in GROUP BY or an aggregate", col.Expr)
+	}
+	ft, err := fieldType(m.schema, fn.Args[0])
+	if err != nil {
+		return nil, nil, value.UnknownType, err
+	}
+	field := "$" + fieldName(ft)
+	switch strings.ToLower(fn.Name) {
+	case "count":
+		// non-null values
+		return M{"$sum": M{"$cond": []interface{}{M{"$gt": []interface{}{field, nil}}, 1, 0}}}, 1, value.IntType, nil
+	case "sum":
+		return M{"$sum": field}, 1, value.NumberType, nil
+	case "avg":
+		return M{"$avg": field}, 1, value.NumberType, nil
+	case "min":
+		return M{"$min": field}, 1, ft.Type, nil
+	case "max":
+		return M{"$max": field}, 1, ft.Type, nil
+	case "cardinality":
+		name := outName(colName(col))
+		return M{"$addToSet": field}, M{"$size": "$" + name}, value.IntType, nil
+	}
+	return nil, nil, value.UnknownType, fmt.Errorf("mongo: unsupported aggregate function %s", fn)
+}
+
+// outputField resolves HAVING and ORDER BY expressions of an aggregate query
+// against the output columns, by alias or by expression.
+func (m *SelectGenerator) outputField(n expr.Node) (*gentypes.FieldType, error) {
+	s := n.String()
+	text := s
+	if in, ok := n.(*expr.IdentityNode); ok {
+		text = in.Text
+	}
+	for _, c := range m.cols {
+		if c.expr == s || c.as == text || c.name == text {
+			if c.ft == nil {
+				return &gentypes.FieldType{Field: c.name, Type: value.UnknownType}, nil
+			}
+			return &gentypes.FieldType{Field: c.name, Type: c.ft.Type}, nil
+		}
+	}
+	return nil, gentypes.MissingField(s)
+}
+
+func (q *Query) isMatchAll() bool { return len(q.Filter) == 0 }
+
+func sortDir(col *rel.Column) int {
+	if col.Asc() {
+		return 1
+	}
+	return -1
+}
+
+// outName mongo field names may not contain dots or start with $.
+func outName(name string) string {
+	name = strings.Replace(name, ".", "_", -1)
+	return strings.TrimLeft(name, "$")
+}
+
+func colName(col *rel.Column) string {
+	if col.As != "" {
+		return col.As
+	}
+	return col.Expr.String()
+}
+
+func hasAggColumn(cols rel.Columns) bool {
+	for _, col := range cols {
+		if col.Agg || col.CountStar() {
+			return true
+		}
+		if fn, ok := col.Expr.(*expr.FuncNode); ok {
+			switch strings.ToLower(fn.Name) {
+			case "count", "sum", "avg", "min", "max", "cardinality":
+				return true
+			}
+		}
+	}
+	return false
+}
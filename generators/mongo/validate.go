@@ -0,0 +1,155 @@
+package mongo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/generators/elasticsearch/gentypes"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+)
+
+var (
+	// Ensure our validator implements filter validation
+	_ gentypes.FilterValidate = (&TypeValidator{}).FilterValidate
+)
+
+// TypeValidator walks a filter validating the columns exist in the schema
+// and that literals can be compared to the column types.
+type TypeValidator struct {
+	schema gentypes.SchemaColumns
+	fg     *FilterGenerator
+}
+
+// NewValidator creates a validator for the schema.
+func NewValidator(s gentypes.SchemaColumns) *TypeValidator {
+	return &TypeValidator{schema: s, fg: NewGenerator(time.Now(), nil, s)}
+}
+
+// FilterValidate validate the filter statement
+func (m *TypeValidator) FilterValidate(stmt *rel.FilterStatement) error {
+	return m.walkNode(stmt.Filter)
+}
+
+func (m *TypeValidator) walkNode(node expr.Node) error {
+	switch n := node.(type) {
+	case *expr.UnaryNode:
+		return m.unaryNode(n)
+	case *expr.BooleanNode:
+		for _, arg := range n.Args {
+			if err := m.walkNode(arg); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *expr.BinaryNode:
+		return m.binaryNode(n)
+	case *expr.TriNode:
+		return m.triNode(n)
+	case *expr.IdentityNode:
+		if n.Bool() {
+			return nil
+		}
+		_, err := m.identityNode(n)
+		return err
+	case *expr.IncludeNode:
+		// We assume included statement has its own validation
+		return nil
+	}
+	return fmt.Errorf("mongo: unsupported node in expression: %T (%s)", node, node)
+}
+
+func (m *TypeValidator) identityNode(n *expr.IdentityNode) (*gentypes.FieldType, error) {
+	ft, err := fieldType(m.schema, n)
+	if err != nil {
+		return nil, err
+	}
+	if ft.Type == value.UnknownType {
+		return nil, fmt.Errorf("Unknown Field Type %s", n)
+	}
+	return ft, nil
+}
+
+func (m *TypeValidator) field(n expr.Node) (*gentypes.FieldType, error) {
+	in, ok := n.(*expr.IdentityNode)
+	if !ok {
+		return nil, fmt.Errorf("Expected Identity field got %T (%s)", n, n)
+	}
+	return m.identityNode(in)
+}
+
+func (m *TypeValidator) unaryNode(n *expr.UnaryNode) error {
+	switch n.Operator.T {
+	case lex.TokenExists:
+		_, err := m.field(n.Arg)
+		return err
+	case lex.TokenNegate:
+		return m.walkNode(n.Arg)
+	}
+	return fmt.Errorf("mongo: unsupported unary operator: %s", n.Operator.T)
+}
+
+func (m *TypeValidator) binaryNode(node *expr.BinaryNode) error {
+
+	// Identifier-Operator-Literal
+	lhs, err := m.field(node.Args[0])
+	if err != nil {
+		return err
+	}
+
+	switch op := node.Operator.T; op {
+	case lex.TokenGE, lex.TokenLE, lex.TokenGT, lex.TokenLT:
+		switch lhs.Type {
+		case value.BoolType, value.MapBoolType:
+			return fmt.Errorf("mongo: %s comparison not supported for bool field %s", op, lhs.Field)
+		}
+		_, err = m.fg.scalarFor(lhs, node.Args[1])
+	case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenNE:
+		_, err = m.fg.scalarFor(lhs, node.Args[1])
+	case lex.TokenContains, lex.TokenLike, lex.TokenILike, lex.TokenRegexp:
+		switch lhs.Type {
+		case value.StringType, value.StringsType, value.MapStringType, value.MapValueType:
+		default:
+			return fmt.Errorf("mongo: %s requires a string field but %s is %s", op, lhs.Field, lhs.Type)
+		}
+		if _, ok := patternText(node.Args[1]); !ok {
+			return fmt.Errorf("mongo: unsupported non-string argument for %s pattern: %T", op, node.Args[1])
+		}
+	case lex.TokenIN, lex.TokenIntersects:
+		array, ok := node.Args[1].(*expr.ArrayNode)
+		if !ok {
+			return fmt.Errorf("mongo: second argument to %s must be an array, found: %T", op, node.Args[1])
+		}
+		for _, arg := range array.Args {
+			if _, err = m.fg.scalarFor(lhs, arg); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("mongo: unsupported binary expression: %s", op)
+	}
+	return err
+}
+
+func (m *TypeValidator) triNode(node *expr.TriNode) error {
+	switch node.Operator.T {
+	case lex.TokenLike, lex.TokenILike:
+		// a LIKE b ESCAPE c validates as a LIKE b
+		return m.binaryNode(expr.NewBinaryNode(node.Operator, node.Args[0], node.Args[1]))
+	case lex.TokenBetween:
+	default:
+		return fmt.Errorf("mongo: unsupported ternary expression: %s", node.Operator.T)
+	}
+	lhs, err := m.field(node.Args[0])
+	if err != nil {
+		return err
+	}
+	for _, arg := range node.Args[1:] {
+		if _, err := m.fg.scalarFor(lhs, arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package lex
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -46,6 +47,22 @@ func (m *Dialect) Init() {
 	}
 }
 
+// Keywords of all the clauses of this dialect, lower-cased, sorted and
+// de-duplicated.  Multi-word keywords such as "group by" are a single entry.
+func (m *Dialect) Keywords() []string {
+	m.Init()
+	seen := make(map[string]struct{})
+	for _, s := range m.Statements {
+		s.keywords(seen)
+	}
+	kws := make([]string, 0, len(seen))
+	for kw := range seen {
+		kws = append(kws, kw)
+	}
+	sort.Strings(kws)
+	return kws
+}
+
 // MatchesKeyword
 func (c *Clause) MatchesKeyword(peekWord string, l *Lexer) bool {
 	if c.KeywordMatcher != nil {
@@ -77,6 +94,17 @@ func (c *Clause) init() {
 		}
 	}
 }
+func (c *Clause) keywords(seen map[string]struct{}) {
+	if c.KeywordMatcher == nil && c.Token != TokenEOF {
+		// skip punctuation such as "(" which are clause tokens too
+		if _, ok := TokenNameMap[c.Token]; ok && c.fullWord != "" && isAlpha(rune(c.fullWord[0])) {
+			seen[strings.ToLower(c.fullWord)] = struct{}{}
+		}
+	}
+	for _, clause := range c.Clauses {
+		clause.keywords(seen)
+	}
+}
 func (c *Clause) String() string {
 	if c.parent != nil {
 		return fmt.Sprintf(`<clause %p %q kw=%q fullword=%q multiword?%v clausesct=%d parentKw=%q />`, c, c.Name, c.keyword, c.fullWord, c.multiWord, len(c.Clauses), c.parent.keyword)
@@ -131,3 +131,121 @@ func TestLexLogicalDialect(t *testing.T) {
 			tv(TokenInteger, "2"),
 		})
 }
+
+func TestLexCaseExpr(t *testing.T) {
+	verifyExpr2Tokens(t, `CASE WHEN x > 5 THEN "big" ELSE "small" END`,
+		[]Token{
+			tv(TokenCase, "CASE"),
+			tv(TokenWhen, "WHEN"),
+			tv(TokenIdentity, "x"),
+			tv(TokenGT, ">"),
+			tv(TokenInteger, "5"),
+			tv(TokenThen, "THEN"),
+			tv(TokenValue, "big"),
+			tv(TokenElse, "ELSE"),
+			tv(TokenValue, "small"),
+			tv(TokenEnd, "END"),
+		})
+
+	verifyExpr2Tokens(t, `case status when 1 then toint(x) when 2 then y end`,
+		[]Token{
+			tv(TokenCase, "case"),
+			tv(TokenIdentity, "status"),
+			tv(TokenWhen, "when"),
+			tv(TokenInteger, "1"),
+			tv(TokenThen, "then"),
+			tv(TokenUdfExpr, "toint"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "x"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenWhen, "when"),
+			tv(TokenInteger, "2"),
+			tv(TokenThen, "then"),
+			tv(TokenIdentity, "y"),
+			tv(TokenEnd, "end"),
+		})
+}
+
+func TestLexDistinctFrom(t *testing.T) {
+	verifyExpr2Tokens(t, `x IS DISTINCT FROM y AND z is  not distinct from NULL AND a IS NOT NULL`,
+		[]Token{
+			tv(TokenIdentity, "x"),
+			tv(TokenIsDistinctFrom, "IS DISTINCT FROM"),
+			tv(TokenIdentity, "y"),
+			tv(TokenLogicAnd, "AND"),
+			tv(TokenIdentity, "z"),
+			tv(TokenIsNotDistinctFrom, "is  not distinct from"),
+			tv(TokenNull, "NULL"),
+			tv(TokenLogicAnd, "AND"),
+			tv(TokenIdentity, "a"),
+			tv(TokenIs, "IS"),
+			tv(TokenNegate, "NOT"),
+			tv(TokenNull, "NULL"),
+		})
+}
+
+func TestLexInterval(t *testing.T) {
+	verifyExpr2Tokens(t, `ts + INTERVAL '3' DAY > now() - interval 1 month`,
+		[]Token{
+			tv(TokenIdentity, "ts"),
+			tv(TokenPlus, "+"),
+			tv(TokenInterval, "INTERVAL"),
+			tv(TokenValue, "3"),
+			tv(TokenIdentity, "DAY"),
+			tv(TokenGT, ">"),
+			tv(TokenUdfExpr, "now"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenMinus, "-"),
+			tv(TokenInterval, "interval"),
+			tv(TokenInteger, "1"),
+			tv(TokenIdentity, "month"),
+		})
+
+	// a column named interval is still an identity
+	verifyExpr2Tokens(t, `interval > 2`,
+		[]Token{
+			tv(TokenIdentity, "interval"),
+			tv(TokenGT, ">"),
+			tv(TokenInteger, "2"),
+		})
+}
+
+func TestLexAtTimeZone(t *testing.T) {
+	verifyExpr2Tokens(t, `hourofday(ts AT  TIME ZONE 'America/Denver') > ts at time zone "UTC"`,
+		[]Token{
+			tv(TokenUdfExpr, "hourofday"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "ts"),
+			tv(TokenAtTimeZone, "AT  TIME ZONE"),
+			tv(TokenValue, "America/Denver"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenGT, ">"),
+			tv(TokenIdentity, "ts"),
+			tv(TokenAtTimeZone, "at time zone"),
+			tv(TokenValue, "UTC"),
+		})
+}
+
+func TestLexPatternMatch(t *testing.T) {
+	verifyExpr2Tokens(t, `name ILIKE 'a!_%' ESCAPE '!' AND name REGEXP "^b" OR name RLIKE "c$" OR name ~ "d+"`,
+		[]Token{
+			tv(TokenIdentity, "name"),
+			tv(TokenILike, "ILIKE"),
+			tv(TokenValue, "a!_%"),
+			tv(TokenEscape, "ESCAPE"),
+			tv(TokenValue, "!"),
+			tv(TokenLogicAnd, "AND"),
+			tv(TokenIdentity, "name"),
+			tv(TokenRegexp, "REGEXP"),
+			tv(TokenValue, "^b"),
+			tv(TokenLogicOr, "OR"),
+			tv(TokenIdentity, "name"),
+			tv(TokenRegexp, "RLIKE"),
+			tv(TokenValue, "c$"),
+			tv(TokenLogicOr, "OR"),
+			tv(TokenIdentity, "name"),
+			tv(TokenRegexp, "~"),
+			tv(TokenValue, "d+"),
+		})
+}
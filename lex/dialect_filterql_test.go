@@ -239,3 +239,22 @@ func TestFilterQLIntersects(t *testing.T) {
 			tv(TokenRightParenthesis, ")"),
 		})
 }
+
+func TestFilterQLLikeEscape(t *testing.T) {
+	verifyFilterQLTokens(t, `FILTER AND (name LIKE "a!%" ESCAPE "!", name ILIKE "b%")`,
+		[]Token{
+			tv(TokenFilter, "FILTER"),
+			tv(TokenLogicAnd, "AND"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "name"),
+			tv(TokenLike, "LIKE"),
+			tv(TokenValue, "a!%"),
+			tv(TokenEscape, "ESCAPE"),
+			tv(TokenValue, "!"),
+			tv(TokenComma, ","),
+			tv(TokenIdentity, "name"),
+			tv(TokenILike, "ILIKE"),
+			tv(TokenValue, "b%"),
+			tv(TokenRightParenthesis, ")"),
+		})
+}
@@ -2,6 +2,7 @@ package lex
 
 import (
 	"strings"
+	"unicode"
 
 	u "github.com/araddon/gou"
 )
@@ -19,10 +20,12 @@ var (
 	//    SHOW idenity;
 	//    DESCRIBE identity;
 	//    PREPARE
+	//    KILL id;
 	//
 	// ddl
 	//    ALTER
 	//    CREATE (TABLE|VIEW|CONTINUOUSVIEW|SOURCE)
+	//    GRANT, REVOKE
 	//
 	//  TODO:
 	//      CREATE
@@ -46,6 +49,10 @@ var (
 			{Token: TokenUse, Clauses: SqlUse},
 			{Token: TokenRollback, Clauses: SqlRollback},
 			{Token: TokenCommit, Clauses: SqlCommit},
+			{Token: TokenKill, Clauses: SqlKill},
+			{Token: TokenGrant, Clauses: SqlGrant},
+			{Token: TokenRevoke, Clauses: SqlRevoke},
+			{Token: TokenRefresh, Clauses: SqlRefresh},
 		},
 	}
 	// SqlSelect Select statement.
@@ -159,14 +166,19 @@ var (
 		{Token: TokenChange, Lexer: LexDdlAlterColumn},
 		{Token: TokenWith, Lexer: LexJsonOrKeyValue, Optional: true},
 	}
-	// SqlCreate CREATE {SCHEMA | DATABASE | SOURCE | TABLE | VIEW | CONTINUOUSVIEW}
+	// SqlCreate CREATE {SCHEMA | DATABASE | SOURCE | TABLE | VIEW | CONTINUOUSVIEW | FUNCTION | POLICY | USER | ROLE}
 	SqlCreate = []*Clause{
 		{Token: TokenCreate, Lexer: LexCreate},
+		{Token: TokenAs, Lexer: LexExpression, Optional: true},
+		{Token: TokenUsing, Lexer: LexExpression, Optional: true},
+		{Token: TokenReturns, Lexer: LexIdentifier, Optional: true},
+		{Token: TokenLanguage, Lexer: LexIdentifier, Optional: true},
+		{Token: TokenFrom, Lexer: LexValue, Optional: true},
 		{Token: TokenEngine, Lexer: LexDdlTableStorage, Optional: true},
 		{Token: TokenSelect, Clauses: SqlSelect, Optional: true},
 		{Token: TokenWith, Lexer: LexJsonOrKeyValue, Optional: true},
 	}
-	// SqlDrop DROP {SCHEMA | DATABASE | SOURCE | TABLE}
+	// SqlDrop DROP {SCHEMA | DATABASE | SOURCE | TABLE | [MATERIALIZED] VIEW | FUNCTION | POLICY | USER | ROLE}
 	SqlDrop = []*Clause{
 		{Token: TokenDrop, Lexer: LexDrop},
 	}
@@ -208,6 +220,22 @@ var (
 	SqlCommit = []*Clause{
 		{Token: TokenCommit, Lexer: LexEmpty},
 	}
+	// SqlKill KILL [CONNECTION | QUERY] <id>
+	SqlKill = []*Clause{
+		{Token: TokenKill, Lexer: LexKill},
+	}
+	// SqlGrant GRANT <privileges> ON <schema>.<table> TO <role>
+	SqlGrant = []*Clause{
+		{Token: TokenGrant, Lexer: LexGrant},
+	}
+	// SqlRevoke REVOKE <privileges> ON <schema>.<table> FROM <role>
+	SqlRevoke = []*Clause{
+		{Token: TokenRevoke, Lexer: LexGrant},
+	}
+	// SqlRefresh REFRESH MATERIALIZED VIEW <identity>
+	SqlRefresh = []*Clause{
+		{Token: TokenRefresh, Lexer: LexRefresh},
+	}
 )
 
 // NewSqlLexer creates a new lexer for the input string using SqlDialect
@@ -338,6 +366,108 @@ func LexShowClause(l *Lexer) StateFn {
 	return LexIdentifier
 }
 
+// LexKill the process id after KILL
+//
+//    KILL [CONNECTION | QUERY] <id>
+//
+func LexKill(l *Lexer) StateFn {
+
+	l.SkipWhiteSpaces()
+	keyWord := strings.ToLower(l.PeekWord())
+
+	switch keyWord {
+	case "connection", "query":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenIdentity)
+		return LexKill
+	case "", ";":
+		return nil
+	}
+	return LexNumber
+}
+
+// LexRefresh the view of a REFRESH.
+//
+//    REFRESH MATERIALIZED VIEW <identity>
+//
+func LexRefresh(l *Lexer) StateFn {
+
+	l.SkipWhiteSpaces()
+	keyWord := strings.ToLower(l.PeekWord())
+
+	switch keyWord {
+	case "materialized":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenMaterialized)
+		return LexRefresh
+	case "view":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenView)
+	case "", ";":
+		return nil
+	}
+	return LexIdentifier
+}
+
+// LexGrant the privileges, object and grantee of GRANT and REVOKE.  The
+// object is a single identity, * for all schemas or tables.
+//
+//    GRANT {SELECT|INSERT|UPDATE|DELETE|DROP|ALL} [, ...] ON <schema>.<table> TO <role>
+//    GRANT <role> TO <user>
+//    REVOKE {SELECT|INSERT|UPDATE|DELETE|DROP|ALL} [, ...] ON <schema>.<table> FROM <role>
+//    REVOKE <role> FROM <user>
+//
+func LexGrant(l *Lexer) StateFn {
+
+	l.SkipWhiteSpaces()
+	if l.IsEnd() {
+		return nil
+	}
+	switch l.Peek() {
+	case ',':
+		l.Next()
+		l.Emit(TokenComma)
+		return LexGrant
+	case ';':
+		return nil
+	}
+
+	keyWord := strings.ToLower(l.PeekWord())
+	switch keyWord {
+	case "on":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenOn)
+		return lexGrantObject
+	case "to":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenTo)
+		return LexGrant
+	case "from":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenFrom)
+		return LexGrant
+	case "":
+		return nil
+	}
+	l.Push("LexGrant", LexGrant)
+	return LexIdentifier
+}
+
+// lexGrantObject the <schema>.<table> of a grant up to the next white space,
+// either part may be *.
+func lexGrantObject(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	for {
+		r := l.Peek()
+		if r == eof || r == ';' || unicode.IsSpace(r) {
+			break
+		}
+		l.Next()
+	}
+	l.Emit(TokenIdentity)
+	return LexGrant
+}
+
 // LexInto clause
 func LexInto(l *Lexer) StateFn {
 
@@ -392,6 +522,10 @@ func LexLimit(l *Lexer) StateFn {
 //    CREATE {SCHEMA|DATABASE|SOURCE} [IF NOT EXISTS] <identity>  <WITH>
 //    CREATE {TABLE} <identity> [IF NOT EXISTS] <table_spec> [WITH]
 //    CREATE [OR REPLACE] {VIEW|CONTINUOUSVIEW} <identity> AS <select_statement> [WITH]
+//    CREATE [OR REPLACE] MATERIALIZED VIEW <identity> AS <select_statement> [WITH]
+//    CREATE [OR REPLACE] FUNCTION <identity>(<arg>, ...) AS <expression>
+//    CREATE [OR REPLACE] POLICY <identity> ON <table> [MASK <column>] USING <expression>
+//    CREATE {USER|ROLE} <identity>
 //
 func LexCreate(l *Lexer) StateFn {
 
@@ -429,6 +563,10 @@ func LexCreate(l *Lexer) StateFn {
 		l.Emit(TokenDatabase)
 		l.Push("LexIdentifier", LexIdentifier)
 		return LexCreate
+	case "materialized":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenMaterialized)
+		return LexCreate
 	case "view":
 		l.ConsumeWord(keyWord)
 		l.Emit(TokenView)
@@ -439,6 +577,24 @@ func LexCreate(l *Lexer) StateFn {
 		l.Emit(TokenContinuousView)
 		l.Push("lexAs", lexAs)
 		return LexIdentifier
+	case "function":
+		// name(args) lexes as a function call, AS <expression> is its own clause
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenFunction)
+		return lexExpressionIdentifier
+	case "policy":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenPolicy)
+		l.Push("lexPolicyOn", lexPolicyOn)
+		return LexIdentifier
+	case "user":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenUser)
+		return LexIdentifier
+	case "role":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenRole)
+		return LexIdentifier
 	case "if":
 		l.Push("LexCreate", LexCreate)
 		return lexNotExists
@@ -447,6 +603,27 @@ func LexCreate(l *Lexer) StateFn {
 	}
 	return nil
 }
+
+// lexPolicyOn the table of a policy and the column it masks.
+//
+//    ON <table> [MASK <column>]
+//
+func lexPolicyOn(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	keyWord := strings.ToLower(l.PeekWord())
+	switch keyWord {
+	case "on":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenOn)
+		l.Push("lexPolicyOn", lexPolicyOn)
+		return LexIdentifier
+	case "mask":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenMask)
+		return LexIdentifier
+	}
+	return nil
+}
 func lexAs(l *Lexer) StateFn {
 	l.SkipWhiteSpaces()
 	keyWord := strings.ToLower(l.PeekWord())
@@ -527,6 +704,10 @@ func LexDrop(l *Lexer) StateFn {
 		l.ConsumeWord(keyWord)
 		l.Emit(TokenTemp)
 		return LexDrop
+	case "materialized":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenMaterialized)
+		return LexDrop
 	case "table":
 		l.ConsumeWord(keyWord)
 		l.Emit(TokenTable)
@@ -545,6 +726,18 @@ func LexDrop(l *Lexer) StateFn {
 	case "continuousview":
 		l.ConsumeWord(keyWord)
 		l.Emit(TokenContinuousView)
+	case "function":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenFunction)
+	case "policy":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenPolicy)
+	case "user":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenUser)
+	case "role":
+		l.ConsumeWord(keyWord)
+		l.Emit(TokenRole)
 	default:
 		return nil
 	}
@@ -728,6 +921,15 @@ func LexDdlAlterColumn(l *Lexer) StateFn {
 		l.Push("LexDdlAlterColumn", l.clauseState())
 		l.Push("LexParenRight", LexParenRight)
 		return LexListOfArgs
+	case "decimal", "numeric":
+		l.ConsumeWord(word)
+		l.Emit(TokenTypeDecimal)
+		if l.Peek() == '(' {
+			l.Push("LexDdlAlterColumn", l.clauseState())
+			l.Push("LexParenRight", LexParenRight)
+			return LexListOfArgs
+		}
+		return l.clauseState()
 
 	default:
 		r = l.Peek()
@@ -916,6 +1118,16 @@ func LexDdlTableColumn(l *Lexer) StateFn {
 		l.Push("LexDdlTableColumn", LexDdlTableColumn)
 		l.Push("LexParenRight", LexParenRight)
 		return LexListOfArgs
+	case "decimal", "numeric":
+		l.ConsumeWord(word)
+		l.Emit(TokenTypeDecimal)
+		p := l.Peek()
+		if p == '(' {
+			l.Push("LexDdlTableColumn", LexDdlTableColumn)
+			l.Push("LexParenRight", LexParenRight)
+			return LexListOfArgs
+		}
+		return LexDdlTableColumn
 	default:
 		if l.isIdentity() {
 			l.ConsumeWord(word)
@@ -14,6 +14,18 @@ func TestSqlDialectInit(t *testing.T) {
 	}
 }
 
+func TestSqlDialectKeywords(t *testing.T) {
+	kws := SqlDialect.Keywords()
+	assert.True(t, len(kws) > 10)
+	for _, kw := range []string{"select", "from", "where", "group by", "order by", "limit", "describe", "show"} {
+		assert.Contains(t, kws, kw)
+	}
+	for _, kw := range kws {
+		assert.NotEqual(t, ")", kw)
+		assert.NotEqual(t, "", kw)
+	}
+}
+
 func TestLexSqlDescribe(t *testing.T) {
 	/*
 		describe myidentity
@@ -237,6 +249,218 @@ func TestLexSqlCreate(t *testing.T) {
 			tv(TokenValue, "hello"),
 		})
 }
+func TestLexSqlCreateDecimal(t *testing.T) {
+	verifyTokens(t, `CREATE TABLE invoices (amount decimal(10,2) NOT NULL, total NUMERIC);`,
+		[]Token{
+			tv(TokenCreate, "CREATE"),
+			tv(TokenTable, "TABLE"),
+			tv(TokenIdentity, "invoices"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "amount"),
+			tv(TokenTypeDecimal, "decimal"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenInteger, "10"),
+			tv(TokenComma, ","),
+			tv(TokenInteger, "2"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenNegate, "NOT"),
+			tv(TokenNull, "NULL"),
+			tv(TokenComma, ","),
+			tv(TokenIdentity, "total"),
+			tv(TokenTypeDecimal, "NUMERIC"),
+			tv(TokenRightParenthesis, ")"),
+		})
+}
+func TestLexSqlCreateFunction(t *testing.T) {
+	verifyTokens(t, `CREATE OR REPLACE FUNCTION domain_of(url) AS (lower(domain(url)));`,
+		[]Token{
+			tv(TokenCreate, "CREATE"),
+			tv(TokenOr, "OR"),
+			tv(TokenReplace, "REPLACE"),
+			tv(TokenFunction, "FUNCTION"),
+			tv(TokenUdfExpr, "domain_of"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "url"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenAs, "AS"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenUdfExpr, "lower"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenUdfExpr, "domain"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "url"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenRightParenthesis, ")"),
+		})
+	verifyTokens(t, `CREATE FUNCTION fullname(first, last) AS concat(first, " ", last)`,
+		[]Token{
+			tv(TokenCreate, "CREATE"),
+			tv(TokenFunction, "FUNCTION"),
+			tv(TokenUdfExpr, "fullname"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "first"),
+			tv(TokenComma, ","),
+			tv(TokenIdentity, "last"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenAs, "AS"),
+			tv(TokenUdfExpr, "concat"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "first"),
+			tv(TokenComma, ","),
+			tv(TokenValue, " "),
+			tv(TokenComma, ","),
+			tv(TokenIdentity, "last"),
+			tv(TokenRightParenthesis, ")"),
+		})
+	verifyTokens(t, `CREATE FUNCTION clean_sku(string, int) RETURNS string LANGUAGE wasm FROM '/udfs/sku.wasm' WITH timeout = "50ms"`,
+		[]Token{
+			tv(TokenCreate, "CREATE"),
+			tv(TokenFunction, "FUNCTION"),
+			tv(TokenUdfExpr, "clean_sku"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "string"),
+			tv(TokenComma, ","),
+			tv(TokenIdentity, "int"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenReturns, "RETURNS"),
+			tv(TokenIdentity, "string"),
+			tv(TokenLanguage, "LANGUAGE"),
+			tv(TokenIdentity, "wasm"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenValue, "/udfs/sku.wasm"),
+			tv(TokenWith, "WITH"),
+			tv(TokenIdentity, "timeout"),
+			tv(TokenEqual, "="),
+			tv(TokenValue, "50ms"),
+		})
+}
+func TestLexSqlCreatePolicy(t *testing.T) {
+	verifyTokens(t, `CREATE POLICY tenant_only ON events USING (tenant_id = session.tenant_id);`,
+		[]Token{
+			tv(TokenCreate, "CREATE"),
+			tv(TokenPolicy, "POLICY"),
+			tv(TokenIdentity, "tenant_only"),
+			tv(TokenOn, "ON"),
+			tv(TokenIdentity, "events"),
+			tv(TokenUsing, "USING"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "tenant_id"),
+			tv(TokenEqual, "="),
+			tv(TokenIdentity, "session.tenant_id"),
+			tv(TokenRightParenthesis, ")"),
+		})
+	verifyTokens(t, `CREATE OR REPLACE POLICY hide_email ON users MASK email USING hash.sha1(email)`,
+		[]Token{
+			tv(TokenCreate, "CREATE"),
+			tv(TokenOr, "OR"),
+			tv(TokenReplace, "REPLACE"),
+			tv(TokenPolicy, "POLICY"),
+			tv(TokenIdentity, "hide_email"),
+			tv(TokenOn, "ON"),
+			tv(TokenIdentity, "users"),
+			tv(TokenMask, "MASK"),
+			tv(TokenIdentity, "email"),
+			tv(TokenUsing, "USING"),
+			tv(TokenUdfExpr, "hash.sha1"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenIdentity, "email"),
+			tv(TokenRightParenthesis, ")"),
+		})
+}
+func TestLexSqlCreateUser(t *testing.T) {
+	verifyTokens(t, `CREATE USER alice;`,
+		[]Token{
+			tv(TokenCreate, "CREATE"),
+			tv(TokenUser, "USER"),
+			tv(TokenIdentity, "alice"),
+		})
+	verifyTokens(t, `CREATE ROLE analyst`,
+		[]Token{
+			tv(TokenCreate, "CREATE"),
+			tv(TokenRole, "ROLE"),
+			tv(TokenIdentity, "analyst"),
+		})
+}
+func TestLexSqlMaterializedView(t *testing.T) {
+	verifyTokens(t, `CREATE MATERIALIZED VIEW hits_by_user AS SELECT user_id, count(*) AS ct FROM hits GROUP BY user_id WITH append = "ts";`,
+		[]Token{
+			tv(TokenCreate, "CREATE"),
+			tv(TokenMaterialized, "MATERIALIZED"),
+			tv(TokenView, "VIEW"),
+			tv(TokenIdentity, "hits_by_user"),
+			tv(TokenAs, "AS"),
+			tv(TokenSelect, "SELECT"),
+			tv(TokenIdentity, "user_id"),
+			tv(TokenComma, ","),
+			tv(TokenUdfExpr, "count"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenStar, "*"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenAs, "AS"),
+			tv(TokenIdentity, "ct"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "hits"),
+			tv(TokenGroupBy, "GROUP BY"),
+			tv(TokenIdentity, "user_id"),
+			tv(TokenWith, "WITH"),
+			tv(TokenIdentity, "append"),
+			tv(TokenEqual, "="),
+			tv(TokenValue, "ts"),
+		})
+	verifyTokens(t, `REFRESH MATERIALIZED VIEW hits_by_user;`,
+		[]Token{
+			tv(TokenRefresh, "REFRESH"),
+			tv(TokenMaterialized, "MATERIALIZED"),
+			tv(TokenView, "VIEW"),
+			tv(TokenIdentity, "hits_by_user"),
+		})
+	verifyTokens(t, `DROP MATERIALIZED VIEW hits_by_user;`,
+		[]Token{
+			tv(TokenDrop, "DROP"),
+			tv(TokenMaterialized, "MATERIALIZED"),
+			tv(TokenView, "VIEW"),
+			tv(TokenIdentity, "hits_by_user"),
+		})
+}
+func TestLexSqlGrant(t *testing.T) {
+	verifyTokens(t, `GRANT SELECT, INSERT ON mydb.users TO analyst;`,
+		[]Token{
+			tv(TokenGrant, "GRANT"),
+			tv(TokenIdentity, "SELECT"),
+			tv(TokenComma, ","),
+			tv(TokenIdentity, "INSERT"),
+			tv(TokenOn, "ON"),
+			tv(TokenIdentity, "mydb.users"),
+			tv(TokenTo, "TO"),
+			tv(TokenIdentity, "analyst"),
+		})
+	verifyTokens(t, `GRANT ALL ON *.* TO admin`,
+		[]Token{
+			tv(TokenGrant, "GRANT"),
+			tv(TokenIdentity, "ALL"),
+			tv(TokenOn, "ON"),
+			tv(TokenIdentity, "*.*"),
+			tv(TokenTo, "TO"),
+			tv(TokenIdentity, "admin"),
+		})
+	verifyTokens(t, `GRANT analyst TO alice`,
+		[]Token{
+			tv(TokenGrant, "GRANT"),
+			tv(TokenIdentity, "analyst"),
+			tv(TokenTo, "TO"),
+			tv(TokenIdentity, "alice"),
+		})
+	verifyTokens(t, `REVOKE DELETE ON mydb.* FROM analyst`,
+		[]Token{
+			tv(TokenRevoke, "REVOKE"),
+			tv(TokenIdentity, "DELETE"),
+			tv(TokenOn, "ON"),
+			tv(TokenIdentity, "mydb.*"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "analyst"),
+		})
+}
 func TestLexSqlDrop(t *testing.T) {
 	// DROP {DATABASE | SCHEMA | SOURCE | TABLE} [IF EXISTS] db_name
 	verifyTokens(t, `DROP SCHEMA IF EXISTS myschema;`,
@@ -284,6 +508,24 @@ func TestLexSqlDrop(t *testing.T) {
 			tv(TokenDatabase, "DATABASE"),
 			tv(TokenIdentity, "mydb"),
 		})
+	verifyTokens(t, `DROP FUNCTION domain_of;`,
+		[]Token{
+			tv(TokenDrop, "DROP"),
+			tv(TokenFunction, "FUNCTION"),
+			tv(TokenIdentity, "domain_of"),
+		})
+	verifyTokens(t, `DROP POLICY tenant_only;`,
+		[]Token{
+			tv(TokenDrop, "DROP"),
+			tv(TokenPolicy, "POLICY"),
+			tv(TokenIdentity, "tenant_only"),
+		})
+	verifyTokens(t, `DROP USER alice;`,
+		[]Token{
+			tv(TokenDrop, "DROP"),
+			tv(TokenUser, "USER"),
+			tv(TokenIdentity, "alice"),
+		})
 	verifyTokens(t, `DROP VIEW myv;`,
 		[]Token{
 			tv(TokenDrop, "DROP"),
@@ -325,6 +567,34 @@ func TestLexSqlSelect(t *testing.T) {
 			tv(TokenNE, "!="),
 			tv(TokenValue, "hello"),
 		})
+	verifyTokens(t, `SELECT CASE WHEN a > 1 THEN "x" END AS b, tolower(CASE a WHEN 1 THEN c END) FROM tbl`,
+		[]Token{
+			tv(TokenSelect, "SELECT"),
+			tv(TokenCase, "CASE"),
+			tv(TokenWhen, "WHEN"),
+			tv(TokenIdentity, "a"),
+			tv(TokenGT, ">"),
+			tv(TokenInteger, "1"),
+			tv(TokenThen, "THEN"),
+			tv(TokenValue, "x"),
+			tv(TokenEnd, "END"),
+			tv(TokenAs, "AS"),
+			tv(TokenIdentity, "b"),
+			tv(TokenComma, ","),
+			tv(TokenUdfExpr, "tolower"),
+			tv(TokenLeftParenthesis, "("),
+			tv(TokenCase, "CASE"),
+			tv(TokenIdentity, "a"),
+			tv(TokenWhen, "WHEN"),
+			tv(TokenInteger, "1"),
+			tv(TokenThen, "THEN"),
+			tv(TokenIdentity, "c"),
+			tv(TokenEnd, "END"),
+			tv(TokenRightParenthesis, ")"),
+			tv(TokenFrom, "FROM"),
+			tv(TokenIdentity, "tbl"),
+		})
+
 	verifyTokens(t, `SELECT a FROM tbl LIMIT 1";`,
 		[]Token{
 			tv(TokenSelect, "SELECT"),
@@ -263,6 +263,31 @@ func (l *Lexer) peekRunePast(skip int) rune {
 	return rune(0)
 }
 
+// peekWords returns the length of input if the next words are the given
+// lower case words separated by whitespace, or 0 if they are not.
+func (l *Lexer) peekWords(words ...string) int {
+	pos := l.pos
+	for i, word := range words {
+		if i > 0 {
+			start := pos
+			for pos < len(l.input) && isWhiteSpace(rune(l.input[pos])) {
+				pos++
+			}
+			if pos == start {
+				return 0
+			}
+		}
+		if len(l.input)-pos < len(word) || strings.ToLower(l.input[pos:pos+len(word)]) != word {
+			return 0
+		}
+		pos += len(word)
+	}
+	if pos < len(l.input) && isIdentCh(rune(l.input[pos])) {
+		return 0
+	}
+	return pos - l.pos
+}
+
 // PeekWord grab the next word (till whitespace, without consuming)
 func (l *Lexer) PeekWord() string {
 
@@ -1163,6 +1188,14 @@ func LexExpressionOrIdentity(l *Lexer) StateFn {
 		l.Push("LexParenRight", LexParenRight)
 		return LexExpressionOrIdentity
 	}
+	switch word := strings.ToLower(l.PeekWord()); word {
+	case "case":
+		return LexExpression
+	case "interval":
+		if l.isIntervalLiteral(word) {
+			return LexExpression
+		}
+	}
 	// u.Debugf("LexExpressionOrIdentity identity?%v expr?%v %v peek5='%v'", l.isIdentity(), l.isExpr(), string(l.Peek()), string(l.PeekX(5)))
 	// Expressions end in Parens:     LOWER(item)
 	if l.isExpr() {
@@ -1341,7 +1374,7 @@ func LexListOfArgs(l *Lexer) StateFn {
 			l.backup()
 			return LexExpression
 		}
-	case '!', '=', '>', '<', '-', '+', '%', '&', '/', '|':
+	case '!', '=', '>', '<', '-', '+', '%', '&', '/', '|', '~':
 		l.backup()
 		return LexExpression
 	case ';':
@@ -1359,8 +1392,19 @@ func LexListOfArgs(l *Lexer) StateFn {
 			l.Next()
 			l.Next()
 			l.Emit(TokenAs)
+			l.SkipWhiteSpaces()
+			if strings.ToLower(l.PeekWord()) == "interval" {
+				// cast(x AS interval) is the type not an interval literal
+				return LexIdentifier
+			}
 			return LexExpressionOrIdentity
 		}
+		if n := l.peekWords("at", "time", "zone"); n > 0 {
+			//  hourofday(ts AT TIME ZONE 'America/Denver')
+			l.pos += n
+			l.Emit(TokenAtTimeZone)
+			return LexListOfArgs
+		}
 		if l.isNextKeyword(peekWord) {
 			//u.Warnf("found keyword while looking for arg? %v", string(r))
 			return nil
@@ -1372,6 +1416,20 @@ func LexListOfArgs(l *Lexer) StateFn {
 	}
 }
 
+// lexLikeEscape the optional ESCAPE character after a LIKE pattern
+//
+//    x LIKE 'a!%' ESCAPE '!'
+//
+func lexLikeEscape(l *Lexer) StateFn {
+	if strings.ToLower(l.PeekWord()) == "escape" {
+		l.SkipWhiteSpaces()
+		l.ConsumeWord("escape")
+		l.Emit(TokenEscape)
+		return LexValue
+	}
+	return nil
+}
+
 // LexIdentifier scans and finds named things (tables, columns)
 //  and specifies them as TokenIdentity, uses LexIdentifierType
 //
@@ -2371,7 +2429,7 @@ func LexExpression(l *Lexer) StateFn {
 		//l.Emit(TokenRightParenthesis)
 		l.backup() // don't consume )
 		return nil
-	case '!', '=', '>', '<', ',', ';', '-', '*', '+', '%', '&', '/', '|':
+	case '!', '=', '>', '<', ',', ';', '-', '*', '+', '%', '&', '/', '|', '~':
 		foundLogical := false
 		foundOperator := false
 		switch r {
@@ -2465,6 +2523,9 @@ func LexExpression(l *Lexer) StateFn {
 		case '/':
 			l.Emit(TokenDivide)
 			foundOperator = true
+		case '~':
+			l.Emit(TokenRegexp)
+			foundOperator = true
 		}
 		if foundLogical == true {
 			return LexExpression
@@ -2479,7 +2540,7 @@ func LexExpression(l *Lexer) StateFn {
 	switch word {
 	case "as":
 		return nil
-	case "in", "intersects", "like", "between", "contains": // what is complete list here?
+	case "in", "intersects", "like", "ilike", "regexp", "rlike", "between", "contains": // what is complete list here?
 		switch word {
 		case "in":
 			l.ConsumeWord(word)
@@ -2509,6 +2570,16 @@ func LexExpression(l *Lexer) StateFn {
 		case "like":
 			l.ConsumeWord(word)
 			l.Emit(TokenLike)
+			l.Push("lexLikeEscape", lexLikeEscape)
+			return LexExpressionOrIdentity
+		case "ilike":
+			l.ConsumeWord(word)
+			l.Emit(TokenILike)
+			l.Push("lexLikeEscape", lexLikeEscape)
+			return LexExpressionOrIdentity
+		case "regexp", "rlike":
+			l.ConsumeWord(word)
+			l.Emit(TokenRegexp)
 			return LexExpressionOrIdentity
 		case "contains":
 			l.ConsumeWord(word)
@@ -2544,13 +2615,57 @@ func LexExpression(l *Lexer) StateFn {
 		l.Emit(TokenExists)
 		return LexExpression
 	case "is":
+		//  x IS [NOT] DISTINCT FROM y
+		if n := l.peekWords("is", "not", "distinct", "from"); n > 0 {
+			l.pos += n
+			l.Emit(TokenIsNotDistinctFrom)
+			return LexExpression
+		} else if n := l.peekWords("is", "distinct", "from"); n > 0 {
+			l.pos += n
+			l.Emit(TokenIsDistinctFrom)
+			return LexExpression
+		}
 		l.ConsumeWord(word)
 		l.Emit(TokenIs)
 		return LexExpression
+	case "at":
+		//  ts AT TIME ZONE 'America/Denver'
+		if n := l.peekWords("at", "time", "zone"); n > 0 {
+			l.pos += n
+			l.Emit(TokenAtTimeZone)
+			return LexExpression
+		}
 	case "null":
 		l.ConsumeWord(word)
 		l.Emit(TokenNull)
 		return LexExpression
+	case "interval":
+		//  INTERVAL '3' DAY,  INTERVAL 1 MONTH,  INTERVAL '1 month 3 days'
+		if !l.isIntervalLiteral(word) {
+			break
+		}
+		l.ConsumeWord(word)
+		l.Emit(TokenInterval)
+		l.Push("LexExpression", l.clauseState())
+		l.Push("lexIntervalUnit", lexIntervalUnit)
+		return LexValue
+	case "case", "when", "then", "else", "end":
+		//  CASE [<expr>] WHEN <expr> THEN <expr> [ELSE <expr>] END
+		l.ConsumeWord(word)
+		switch word {
+		case "case":
+			l.Emit(TokenCase)
+		case "when":
+			l.Emit(TokenWhen)
+		case "then":
+			l.Emit(TokenThen)
+		case "else":
+			l.Emit(TokenElse)
+		case "end":
+			l.Emit(TokenEnd)
+			return l.clauseState()
+		}
+		return LexExpression
 	case "not":
 		// somewhat weird edge case, not is either word not, or expression
 		//
@@ -2641,6 +2756,36 @@ func LexExpression(l *Lexer) StateFn {
 	return LexExpressionOrIdentity
 }
 
+// isIntervalLiteral is the interval word followed by a quantity, so a
+// column named interval is still an identity.
+func (l *Lexer) isIntervalLiteral(word string) bool {
+	r := l.peekRunePast(len(word))
+	return r == '\'' || r == '"' || unicode.IsDigit(r)
+}
+
+// lexIntervalUnit the optional unit after an INTERVAL quantity
+//
+//     INTERVAL '3' DAY
+//
+func lexIntervalUnit(l *Lexer) StateFn {
+	l.SkipWhiteSpaces()
+	word := l.PeekWord()
+	if isIntervalUnit(word) {
+		l.ConsumeWord(word)
+		l.Emit(TokenIdentity)
+	}
+	return nil
+}
+
+func isIntervalUnit(word string) bool {
+	switch strings.TrimSuffix(strings.ToLower(word), "s") {
+	case "microsecond", "millisecond", "second", "minute", "hour",
+		"day", "week", "month", "quarter", "year":
+		return true
+	}
+	return false
+}
+
 // Handle columnar identies with keyword appendate (ASC, DESC)
 //
 //     [ORDER BY] ( <identity> | <expr> ) [(ASC | DESC)]
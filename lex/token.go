@@ -123,6 +123,23 @@ const (
 	TokenNull             TokenType = 88 // NULL
 	TokenContains         TokenType = 89 // CONTAINS
 	TokenIntersects       TokenType = 90 // INTERSECTS
+	TokenCase             TokenType = 91 // CASE
+	TokenWhen             TokenType = 92 // WHEN
+	TokenThen             TokenType = 93 // THEN
+	TokenElse             TokenType = 94 // ELSE
+	TokenEnd              TokenType = 95 // END
+
+	// null-safe comparison
+	TokenIsDistinctFrom    TokenType = 96 // IS DISTINCT FROM
+	TokenIsNotDistinctFrom TokenType = 97 // IS NOT DISTINCT FROM
+
+	TokenInterval   TokenType = 98 // INTERVAL
+	TokenAtTimeZone TokenType = 99 // AT TIME ZONE
+
+	// pattern matching
+	TokenILike  TokenType = 100 // ILIKE
+	TokenRegexp TokenType = 101 // REGEXP, RLIKE, ~
+	TokenEscape TokenType = 102 // ESCAPE
 
 	// ql top-level keywords, these first keywords determine parser
 	TokenPrepare   TokenType = 200
@@ -142,6 +159,10 @@ const (
 	TokenReplace   TokenType = 214 // Insert/Replace are interchangeable on insert statements
 	TokenRollback  TokenType = 215
 	TokenCommit    TokenType = 216
+	TokenKill      TokenType = 217
+	TokenGrant     TokenType = 218
+	TokenRevoke    TokenType = 219
+	TokenRefresh   TokenType = 220
 
 	// Other QL Keywords, These are clause-level keywords that mark separation between clauses
 	TokenFrom     TokenType = 300 // from
@@ -171,6 +192,7 @@ const (
 	TokenGlobal   TokenType = 324 // GLOBAL
 	TokenSession  TokenType = 325 // SESSION
 	TokenTables   TokenType = 326 // TABLES
+	TokenTo       TokenType = 327 // TO
 
 	// ddl major words
 	TokenSchema         TokenType = 400 // SCHEMA
@@ -180,6 +202,9 @@ const (
 	TokenView           TokenType = 404 // VIEW
 	TokenContinuousView TokenType = 405 // CONTINUOUSVIEW
 	TokenTemp           TokenType = 406 // TEMP or TEMPORARY
+	TokenFunction       TokenType = 407 // FUNCTION
+	TokenPolicy         TokenType = 408 // POLICY
+	TokenUser           TokenType = 409 // USER
 
 	// ddl other
 	TokenChange       TokenType = 410 // change
@@ -195,6 +220,12 @@ const (
 	TokenForeign      TokenType = 420 // foreign
 	TokenReferences   TokenType = 421 // references
 	TokenEngine       TokenType = 422 // engine
+	TokenReturns      TokenType = 423 // returns
+	TokenLanguage     TokenType = 424 // language
+	TokenUsing        TokenType = 425 // using
+	TokenMask         TokenType = 426 // mask
+	TokenRole         TokenType = 427 // role
+	TokenMaterialized TokenType = 428 // materialized
 
 	// Other QL keywords
 	TokenSet  TokenType = 500 // set
@@ -225,6 +256,7 @@ const (
 	TokenTypeTime    TokenType = 991
 	TokenTypeText    TokenType = 990
 	TokenTypeJson    TokenType = 989
+	TokenTypeDecimal TokenType = 988
 
 	// Value types
 	TokenValueType TokenType = 1000 // A generic Identifier of value type
@@ -304,6 +336,23 @@ var (
 		TokenNull:       {Kw: "null", Description: "NULL"},
 		TokenContains:   {Kw: "contains", Description: "contains"},
 		TokenIntersects: {Kw: "intersects", Description: "intersects"},
+		TokenCase:       {Kw: "case", Description: "CASE"},
+		TokenWhen:       {Kw: "when", Description: "WHEN"},
+		TokenThen:       {Kw: "then", Description: "THEN"},
+		TokenElse:       {Kw: "else", Description: "ELSE"},
+		TokenEnd:        {Kw: "end", Description: "END"},
+
+		// null-safe comparison
+		TokenIsDistinctFrom:    {Kw: "is distinct from", Description: "IS DISTINCT FROM"},
+		TokenIsNotDistinctFrom: {Kw: "is not distinct from", Description: "IS NOT DISTINCT FROM"},
+
+		TokenInterval:   {Kw: "interval", Description: "INTERVAL"},
+		TokenAtTimeZone: {Kw: "at time zone", Description: "AT TIME ZONE"},
+
+		// pattern matching
+		TokenILike:  {Kw: "ilike", Description: "ILIKE"},
+		TokenRegexp: {Kw: "regexp", Description: "REGEXP"},
+		TokenEscape: {Kw: "escape", Description: "ESCAPE"},
 
 		// Identity ish bools
 		TokenTrue:  {Kw: "true", Description: "True"},
@@ -334,6 +383,10 @@ var (
 		TokenReplace:   {Description: "replace"},
 		TokenRollback:  {Description: "rollback"},
 		TokenCommit:    {Description: "commit"},
+		TokenKill:      {Description: "kill"},
+		TokenGrant:     {Description: "grant"},
+		TokenRevoke:    {Description: "revoke"},
+		TokenRefresh:   {Description: "refresh"},
 
 		// Top Level dml ql clause keywords
 		TokenInto:    {Description: "into"},
@@ -364,6 +417,7 @@ var (
 		TokenGlobal:   {Description: "global"},
 		TokenSession:  {Description: "session"},
 		TokenTables:   {Description: "tables"},
+		TokenTo:       {Description: "to"},
 
 		// ddl keywords
 		TokenSchema:         {Description: "schema"},
@@ -373,6 +427,9 @@ var (
 		TokenView:           {Description: "view"},
 		TokenContinuousView: {Description: "continuousview"},
 		TokenTemp:           {Description: "temp"},
+		TokenFunction:       {Description: "function"},
+		TokenPolicy:         {Description: "policy"},
+		TokenUser:           {Description: "user"},
 		// ddl other
 		TokenChange:       {Description: "change"},
 		TokenCharacterSet: {Description: "character set"},
@@ -387,6 +444,12 @@ var (
 		TokenForeign:      {Description: "foreign"},
 		TokenReferences:   {Description: "references"},
 		TokenEngine:       {Description: "engine"},
+		TokenReturns:      {Description: "returns"},
+		TokenLanguage:     {Description: "language"},
+		TokenUsing:        {Description: "using"},
+		TokenMask:         {Description: "mask"},
+		TokenRole:         {Description: "role"},
+		TokenMaterialized: {Description: "materialized"},
 
 		// QL Keywords, all lower-case
 		TokenSet:  {Description: "set"},
@@ -414,6 +477,7 @@ var (
 		TokenTypeTime:    {Description: "TimeType"},
 		TokenTypeText:    {Description: "TextType"},
 		TokenTypeJson:    {Description: "JsonType"},
+		TokenTypeDecimal: {Description: "DecimalType"},
 
 		// VALUE TYPES:  ie literal values
 		TokenBool:    {Description: "BoolVal"},
@@ -0,0 +1,141 @@
+package observe
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/araddon/qlbridge/plan"
+)
+
+var (
+	// Ensure we implement the plan.Observer interface
+	_ plan.Observer = (*Metrics)(nil)
+)
+
+// metricDefs the metrics in the order they are written.
+var metricDefs = []struct {
+	name, kind, help, label string
+}{
+	{"queries_total", "counter", "Queries run.", "status"},
+	{"query_duration_seconds", "summary", "Time spent running queries.", ""},
+	{"parse_duration_seconds", "summary", "Time spent parsing statements.", ""},
+	{"plan_duration_seconds", "summary", "Time spent planning statements.", ""},
+	{"task_duration_seconds", "summary", "Time spent running exec tasks.", "task"},
+	{"task_rows_in_total", "counter", "Rows read by exec tasks from the task before them.", "task"},
+	{"task_rows_out_total", "counter", "Rows sent by exec tasks to the task after them.", "task"},
+	{"task_errors_total", "counter", "Exec tasks that failed.", "task"},
+}
+
+// Metrics is a plan.Observer that aggregates the events of queries into
+// counters and summaries, written in the Prometheus text format.
+//
+//   qlbridge_task_duration_seconds_sum{task="Order"} 0.0132
+//   qlbridge_task_duration_seconds_count{task="Order"} 4
+//
+type Metrics struct {
+	Namespace string // prefix of the metric names
+	mu        sync.Mutex
+	values    map[string]map[string]*sample // metric name, label value
+}
+
+// sample a counter, or a summary with both sum and count.
+type sample struct {
+	sum   float64
+	count int64
+}
+
+// NewMetrics creates empty Metrics named qlbridge_*.
+func NewMetrics() *Metrics {
+	return &Metrics{Namespace: "qlbridge", values: make(map[string]map[string]*sample)}
+}
+
+// Observe the events of a query, see plan.Observer.
+func (m *Metrics) Observe(ctx *plan.Context, e *plan.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch e.Type {
+	case plan.EventParse:
+		m.add("parse_duration_seconds", "", e.Duration.Seconds())
+	case plan.EventPlan:
+		m.add("plan_duration_seconds", "", e.Duration.Seconds())
+	case plan.EventJobStop:
+		status := "ok"
+		if e.Err != nil {
+			status = "error"
+		}
+		m.add("queries_total", status, 1)
+		m.add("query_duration_seconds", "", e.Duration.Seconds())
+	case plan.EventTaskStop:
+		m.add("task_duration_seconds", e.Task, e.Duration.Seconds())
+		m.add("task_rows_in_total", e.Task, float64(e.RowsIn))
+		m.add("task_rows_out_total", e.Task, float64(e.RowsOut))
+		if e.Err != nil {
+			m.add("task_errors_total", e.Task, 1)
+		}
+	}
+}
+
+func (m *Metrics) add(name, label string, v float64) {
+	series, ok := m.values[name]
+	if !ok {
+		series = make(map[string]*sample)
+		m.values[name] = series
+	}
+	s, ok := series[label]
+	if !ok {
+		s = &sample{}
+		series[label] = s
+	}
+	s.sum += v
+	s.count++
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	m.mu.Lock()
+	for _, def := range metricDefs {
+		series := m.values[def.name]
+		if len(series) == 0 {
+			continue
+		}
+		name := def.name
+		if m.Namespace != "" {
+			name = m.Namespace + "_" + name
+		}
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", name, def.help, name, def.kind)
+
+		labels := make([]string, 0, len(series))
+		for label := range series {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			s := series[label]
+			lbl := ""
+			if def.label != "" {
+				lbl = fmt.Sprintf(`{%s="%s"}`, def.label, labelEscaper.Replace(label))
+			}
+			if def.kind == "summary" {
+				fmt.Fprintf(&buf, "%s_sum%s %v\n%s_count%s %d\n", name, lbl, s.sum, name, lbl, s.count)
+			} else {
+				fmt.Fprintf(&buf, "%s%s %v\n", name, lbl, s.sum)
+			}
+		}
+	}
+	m.mu.Unlock()
+	return buf.WriteTo(w)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// ServeHTTP serves the metrics to a Prometheus scraper.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteTo(w)
+}
@@ -0,0 +1,179 @@
+package observe_test
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/memdb"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/observe"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/testutil"
+)
+
+var obsSchema *schema.Schema
+
+func init() {
+	testutil.Setup()
+
+	rows := make([][]driver.Value, 0, 20)
+	for i := 0; i < 20; i++ {
+		rows = append(rows, []driver.Value{int64(i), fmt.Sprintf("name%d", i)})
+	}
+	db, err := memdb.NewMemDbData("obs_rows", rows, []string{"id", "name"})
+	if err != nil {
+		panic(err)
+	}
+	if err = schema.RegisterSourceAsSchema("observedb", db); err != nil {
+		panic(err)
+	}
+	obsSchema, _ = schema.DefaultRegistry().Schema("observedb")
+}
+
+func runObserved(t *testing.T, sql string, o plan.Observer) error {
+	ctx := plan.NewContext(sql)
+	ctx.DisableRecover = true
+	ctx.Schema = obsSchema
+	ctx.Session = datasource.NewMySqlSessionVars()
+	ctx.Observer = o
+	job, err := exec.BuildSqlJob(ctx)
+	if err != nil {
+		return err
+	}
+	msgs := make([]schema.Message, 0)
+	job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+	assert.Equal(t, nil, job.Setup())
+	return job.Run()
+}
+
+func TestTracer(t *testing.T) {
+	exporter := observe.NewMemoryExporter()
+	tracer := observe.NewTracer(exporter)
+
+	assert.Equal(t, nil, runObserved(t, `SELECT id FROM obs_rows WHERE id < 5`, tracer))
+
+	spans := exporter.Spans()
+	assert.True(t, len(spans) > 3, "expected spans %v", len(spans))
+	root := spans[0]
+	assert.Equal(t, "query", root.Name)
+	assert.Equal(t, "", root.ParentId)
+	assert.Equal(t, `SELECT id FROM obs_rows WHERE id < 5`, root.Attributes["db.statement"])
+
+	byName := make(map[string]*observe.Span)
+	ids := make(map[string]bool)
+	for _, s := range spans {
+		assert.Equal(t, root.TraceId, s.TraceId)
+		assert.False(t, s.End.Before(s.Start), "span %s ends before it starts", s.Name)
+		byName[s.Name] = s
+		ids[s.SpanId] = true
+	}
+	for _, s := range spans[1:] {
+		assert.True(t, ids[s.ParentId], "span %s has no parent", s.Name)
+	}
+	for _, name := range []string{"parse", "plan", "Source", "Where", "Projection"} {
+		assert.NotNil(t, byName[name], "expected span %s", name)
+	}
+	assert.Equal(t, root.SpanId, byName["parse"].ParentId)
+	assert.Equal(t, int64(5), byName["Projection"].Attributes["rows.in"])
+	assert.Equal(t, int64(5), byName["Projection"].Attributes["rows.out"])
+	// the first and last tasks count the rows they read and hand on
+	assert.Equal(t, int64(20), byName["Source"].Attributes["rows.in"])
+	assert.Equal(t, int64(20), byName["Source"].Attributes["rows.out"])
+	assert.Equal(t, int64(5), byName["ResultBuffer"].Attributes["rows.in"])
+	assert.Equal(t, int64(5), byName["ResultBuffer"].Attributes["rows.out"])
+
+	// the tasks of a join run in parallel and are counted as well
+	exporter.Reset()
+	assert.Equal(t, nil, runObserved(t, `SELECT a.id, b.name FROM obs_rows AS a
+		INNER JOIN obs_rows AS b ON a.id = b.id WHERE a.id < 5`, tracer))
+	for _, s := range exporter.Spans() {
+		switch s.Name {
+		case "TaskParallel", "JoinMerge":
+			assert.Equal(t, int64(40), s.Attributes["rows.in"], s.Name)
+			assert.Equal(t, int64(20), s.Attributes["rows.out"], s.Name)
+		case "TaskSequential", "Source", "JoinKey":
+			assert.Equal(t, int64(20), s.Attributes["rows.in"], s.Name)
+			assert.Equal(t, int64(20), s.Attributes["rows.out"], s.Name)
+		}
+	}
+
+	// a statement that does not parse is a trace of its own
+	exporter.Reset()
+	assert.NotEqual(t, nil, runObserved(t, `SELEKT id FROM obs_rows`, tracer))
+	spans = exporter.Spans()
+	assert.Equal(t, 2, len(spans))
+	assert.NotEqual(t, "", spans[0].Err)
+	assert.Equal(t, "parse", spans[1].Name)
+
+	// a cached plan is still a parse and plan
+	exec.PlanCache = plan.NewPlanCache(10)
+	for i := 0; i < 2; i++ {
+		exporter.Reset()
+		assert.Equal(t, nil, runObserved(t, `SELECT id FROM obs_rows WHERE id < 5`, tracer))
+		names := make(map[string]bool)
+		for _, s := range exporter.Spans() {
+			names[s.Name] = true
+		}
+		assert.True(t, names["parse"] && names["plan"], "run %d %v", i, names)
+	}
+	exec.PlanCache = nil
+
+	// a query that is planned but never run is sent once timed out
+	exporter.Reset()
+	tracer.Timeout = time.Millisecond
+	ctx := plan.NewContext(`SELECT id FROM obs_rows`)
+	ctx.Schema = obsSchema
+	ctx.Session = datasource.NewMySqlSessionVars()
+	ctx.Observer = tracer
+	_, err := exec.BuildSqlJob(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(exporter.Spans()))
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, nil, runObserved(t, `SELECT id FROM obs_rows WHERE id < 5`, tracer))
+	var timedOut *observe.Span
+	for _, s := range exporter.Spans() {
+		if s.Name == "query" && s.Attributes["db.statement"] == `SELECT id FROM obs_rows` {
+			timedOut = s
+		}
+	}
+	assert.NotNil(t, timedOut)
+	if timedOut != nil {
+		assert.NotEqual(t, "", timedOut.Err)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := observe.NewMetrics()
+	unregister := plan.RegisterObserver(metrics)
+
+	assert.Equal(t, nil, runObserved(t, `SELECT id FROM obs_rows WHERE id < 5`, nil))
+	assert.Equal(t, nil, runObserved(t, `SELECT id FROM obs_rows`, nil))
+	unregister()
+	assert.Equal(t, nil, runObserved(t, `SELECT id FROM obs_rows`, nil))
+
+	var buf bytes.Buffer
+	_, err := metrics.WriteTo(&buf)
+	assert.Equal(t, nil, err)
+	text := buf.String()
+	for _, line := range []string{
+		"# TYPE qlbridge_queries_total counter",
+		`qlbridge_queries_total{status="ok"} 2`,
+		"qlbridge_query_duration_seconds_count 2",
+		"qlbridge_parse_duration_seconds_count 2",
+		"qlbridge_plan_duration_seconds_count 2",
+		`qlbridge_task_duration_seconds_count{task="Projection"} 2`,
+		`qlbridge_task_rows_in_total{task="Projection"} 25`,
+		`qlbridge_task_rows_out_total{task="Projection"} 25`,
+	} {
+		assert.True(t, strings.Contains(text, line+"\n"), "expected %q in\n%s", line, text)
+	}
+	assert.False(t, strings.Contains(text, "task_errors_total"), text)
+}
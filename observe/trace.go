@@ -0,0 +1,222 @@
+// Package observe has plan.Observers that export the events of queries,
+// as traces of spans or as metrics in the Prometheus text format.
+//
+//   tracer := observe.NewTracer(observe.NewMemoryExporter())
+//   unregister := plan.RegisterObserver(tracer)
+//
+// A query is a trace whose root span is the job, with the parse, plan
+// and each task of the exec dag as child spans.
+//
+//   query
+//     parse
+//     plan
+//     TaskSequential
+//       Source
+//       Projection
+//
+package observe
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/plan"
+)
+
+var (
+	// Ensure we implement the plan.Observer interface
+	_ plan.Observer = (*Tracer)(nil)
+
+	errTraceTimeout = fmt.Errorf("query was not done within the trace timeout")
+)
+
+// Span is a timed operation of a query, modeled on OpenTelemetry spans.
+// ParentId is empty for the root span of the query.
+type Span struct {
+	TraceId    string
+	SpanId     string
+	ParentId   string
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]interface{}
+	Err        string // empty if the operation succeeded
+}
+
+// Duration of the span.
+func (m *Span) Duration() time.Duration { return m.End.Sub(m.Start) }
+
+// SpanExporter receives the spans of each query once it is done, the
+// root span first.
+type SpanExporter interface {
+	ExportSpans(spans []*Span) error
+}
+
+// DefaultTraceTimeout the default Tracer.Timeout.
+var DefaultTraceTimeout = 10 * time.Minute
+
+// Tracer is a plan.Observer that builds a trace of spans for each query
+// and sends them to its exporter when the query is done.  Queries that
+// are not done within Timeout, ie planned but never run, are sent with
+// an error on the root span.
+type Tracer struct {
+	Timeout  time.Duration
+	exporter SpanExporter
+	mu       sync.Mutex
+	traces   map[*plan.Context]*trace
+	swept    time.Time
+}
+
+// trace the spans of one running query.
+type trace struct {
+	root  *Span
+	spans []*Span
+	tasks map[uint64]*Span
+}
+
+// NewTracer creates a Tracer exporting to exporter.
+func NewTracer(exporter SpanExporter) *Tracer {
+	return &Tracer{
+		Timeout:  DefaultTraceTimeout,
+		exporter: exporter,
+		traces:   make(map[*plan.Context]*trace),
+		swept:    time.Now(),
+	}
+}
+
+// Observe the events of a query, see plan.Observer.
+func (m *Tracer) Observe(ctx *plan.Context, e *plan.Event) {
+	m.mu.Lock()
+	tr, ok := m.traces[ctx]
+	if !ok {
+		tr = newTrace(ctx, e.Start)
+		m.traces[ctx] = tr
+	}
+
+	done := false
+	switch e.Type {
+	case plan.EventParse, plan.EventPlan:
+		tr.add(e.Type.String(), tr.root, e.Start).finish(e.Start.Add(e.Duration), e.Err)
+		// a query that fails to parse or plan is never run
+		done = e.Err != nil
+	case plan.EventTaskStart:
+		parent, ok := tr.tasks[e.ParentId]
+		if !ok {
+			parent = tr.root
+		}
+		s := tr.add(e.Task, parent, e.Start)
+		s.Attributes["task.id"] = e.Id
+		tr.tasks[e.Id] = s
+	case plan.EventTaskStop:
+		if s, ok := tr.tasks[e.Id]; ok {
+			s.Attributes["rows.in"] = e.RowsIn
+			s.Attributes["rows.out"] = e.RowsOut
+			s.finish(e.Start.Add(e.Duration), e.Err)
+		}
+	case plan.EventJobStop:
+		done = true
+	}
+	var finished []*trace
+	if done {
+		tr.root.finish(e.Start.Add(e.Duration), e.Err)
+		delete(m.traces, ctx)
+		finished = append(finished, tr)
+	}
+	finished = append(finished, m.expire(time.Now())...)
+	m.mu.Unlock()
+
+	for _, tr := range finished {
+		if err := m.exporter.ExportSpans(tr.spans); err != nil {
+			u.Warnf("could not export spans: %v", err)
+		}
+	}
+}
+
+// expire removes the traces started more than Timeout ago, checked
+// every tenth of the Timeout.  Must hold the lock.
+func (m *Tracer) expire(now time.Time) []*trace {
+	if m.Timeout <= 0 || now.Sub(m.swept) < m.Timeout/10 {
+		return nil
+	}
+	m.swept = now
+	var expired []*trace
+	for ctx, tr := range m.traces {
+		if now.Sub(tr.root.Start) > m.Timeout {
+			tr.root.finish(now, errTraceTimeout)
+			delete(m.traces, ctx)
+			expired = append(expired, tr)
+		}
+	}
+	return expired
+}
+
+func newTrace(ctx *plan.Context, start time.Time) *trace {
+	root := &Span{
+		TraceId:    fmt.Sprintf("%016x%016x", plan.NextId(), plan.NextId()),
+		SpanId:     fmt.Sprintf("%016x", plan.NextId()),
+		Name:       "query",
+		Start:      start,
+		Attributes: map[string]interface{}{"db.statement": ctx.Raw},
+	}
+	if ctx.Schema != nil {
+		root.Attributes["db.name"] = ctx.Schema.Name
+	}
+	return &trace{root: root, spans: []*Span{root}, tasks: make(map[uint64]*Span)}
+}
+
+func (m *trace) add(name string, parent *Span, start time.Time) *Span {
+	s := &Span{
+		TraceId:    m.root.TraceId,
+		SpanId:     fmt.Sprintf("%016x", plan.NextId()),
+		ParentId:   parent.SpanId,
+		Name:       name,
+		Start:      start,
+		Attributes: make(map[string]interface{}),
+	}
+	m.spans = append(m.spans, s)
+	return s
+}
+
+func (m *Span) finish(end time.Time, err error) {
+	m.End = end
+	if err != nil {
+		m.Err = err.Error()
+	}
+}
+
+// MemoryExporter keeps the exported spans in memory, for tests and
+// debugging.
+type MemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+// NewMemoryExporter creates an empty MemoryExporter.
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+// ExportSpans keeps the spans.
+func (m *MemoryExporter) ExportSpans(spans []*Span) error {
+	m.mu.Lock()
+	m.spans = append(m.spans, spans...)
+	m.mu.Unlock()
+	return nil
+}
+
+// Spans exported so far.
+func (m *MemoryExporter) Spans() []*Span {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Span(nil), m.spans...)
+}
+
+// Reset drops the exported spans.
+func (m *MemoryExporter) Reset() {
+	m.mu.Lock()
+	m.spans = nil
+	m.mu.Unlock()
+}
@@ -0,0 +1,149 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// ErrNoUser a statement without user once there are accounts.
+var ErrNoUser = fmt.Errorf("Access denied, a user is required once there are accounts")
+
+// Authorize check the user of the context has the privileges the statement
+// needs on the tables it reads or changes, granted through its roles.  A
+// context without user is only allowed while there are no accounts, the
+// first accounts are created through the Registry.
+//
+//    SELECT           SELECT on each table read
+//    INSERT           INSERT on the table, and SELECT of an INSERT ... SELECT
+//    UPSERT           INSERT and UPDATE on the table
+//    UPDATE, DELETE   UPDATE, DELETE on the table
+//    DROP             DROP on the table, or on schema.* for other objects
+//    CREATE, ALTER    ALL on schema.*
+//    GRANT, REVOKE    ALL on *.*, as are CREATE/DROP of users and roles
+//    REFRESH          INSERT on the view
+//    KILL             the owner of the query, or ALL on *.*
+//
+// SHOW PROCESSLIST lists only the queries of the user unless it has ALL
+// on *.*, see ProcessAllowed.
+func Authorize(ctx *Context, stmt rel.SqlStatement) error {
+	reg := schema.DefaultRegistry()
+	if ctx.User == "" {
+		if reg != nil && len(reg.Users()) > 0 {
+			return ErrNoUser
+		}
+		return nil
+	}
+	if reg == nil {
+		return fmt.Errorf("no registry to authorize user %q", ctx.User)
+	}
+	if _, ok := reg.User(ctx.User); !ok {
+		return fmt.Errorf("user %q does not exist", ctx.User)
+	}
+	a := &authorizer{reg: reg, user: ctx.User, schema: ctx.SchemaName}
+	if ctx.Schema != nil {
+		a.schema = ctx.Schema.Name
+	}
+
+	switch st := stmt.(type) {
+	case *rel.SqlSelect:
+		return a.selects(st)
+	case *rel.SqlInsert:
+		if err := a.check(lex.TokenInsert, a.schema, st.Table); err != nil {
+			return err
+		}
+		if st.Select != nil {
+			return a.selects(st.Select)
+		}
+	case *rel.SqlUpsert:
+		if err := a.check(lex.TokenInsert, a.schema, st.Table); err != nil {
+			return err
+		}
+		return a.check(lex.TokenUpdate, a.schema, st.Table)
+	case *rel.SqlUpdate:
+		return a.check(lex.TokenUpdate, a.schema, st.Table)
+	case *rel.SqlDelete:
+		return a.check(lex.TokenDelete, a.schema, st.Table)
+	case *rel.SqlDrop:
+		switch st.Tok.T {
+		case lex.TokenTable, lex.TokenView, lex.TokenContinuousView:
+			return a.check(lex.TokenDrop, a.schema, st.Identity)
+		case lex.TokenSchema, lex.TokenSource, lex.TokenDatabase:
+			return a.check(lex.TokenDrop, st.Identity, "*")
+		case lex.TokenUser, lex.TokenRole:
+			return a.check(lex.TokenAll, "*", "*")
+		}
+		return a.check(lex.TokenDrop, a.schema, "*")
+	case *rel.SqlCreate:
+		switch st.Tok.T {
+		case lex.TokenSchema, lex.TokenSource, lex.TokenDatabase, lex.TokenUser, lex.TokenRole:
+			return a.check(lex.TokenAll, "*", "*")
+		}
+		return a.check(lex.TokenAll, a.schema, "*")
+	case *rel.SqlAlter:
+		return a.check(lex.TokenAll, a.schema, "*")
+	case *rel.SqlGrant:
+		return a.check(lex.TokenAll, "*", "*")
+	case *rel.SqlCommand:
+		if st.Keyword() == lex.TokenRefresh {
+			return a.check(lex.TokenInsert, a.schema, st.Identity)
+		}
+	}
+	// SHOW, DESCRIBE, SET need no privileges, KILL is checked against
+	// the owner of the query when run
+	return nil
+}
+
+// ProcessAllowed may the user of ctx see or KILL the process, its own
+// or any if it has ALL on *.*.
+func ProcessAllowed(ctx *Context, p *Process) bool {
+	if p.User == ctx.User {
+		return true
+	}
+	reg := schema.DefaultRegistry()
+	return reg != nil && ctx.User != "" && reg.Privileged(ctx.User, lex.TokenAll, "*", "*")
+}
+
+type authorizer struct {
+	reg    *schema.Registry
+	user   string
+	schema string
+}
+
+func (m *authorizer) check(privilege lex.TokenType, schemaName, table string) error {
+	if m.reg.Privileged(m.user, privilege, schemaName, table) {
+		return nil
+	}
+	return fmt.Errorf("%s denied to user %q on %s.%s", strings.ToUpper(privilege.String()),
+		m.user, schemaName, table)
+}
+
+// selects SELECT on the tables of the select, its sub-queries and joins.
+func (m *authorizer) selects(sel *rel.SqlSelect) error {
+	for _, from := range sel.From {
+		if from.SubQuery != nil {
+			if err := m.selects(from.SubQuery); err != nil {
+				return err
+			}
+			continue
+		}
+		schemaName, table := m.schema, from.Name
+		if from.Schema != "" {
+			schemaName = from.Schema
+		}
+		if left, right, hasLeft := expr.LeftRight(from.Name); hasLeft {
+			schemaName, table = left, right
+		}
+		if err := m.check(lex.TokenSelect, schemaName, table); err != nil {
+			return err
+		}
+	}
+	if sel.Where != nil && sel.Where.Source != nil {
+		return m.selects(sel.Where.Source)
+	}
+	return nil
+}
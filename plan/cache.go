@@ -0,0 +1,534 @@
+package plan
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+)
+
+// PlanCache is an LRU cache of select plans keyed by the fingerprint of
+// the statement, with its literal values normalized out, and the version
+// of the schema it was planned against.
+//
+//   SELECT name FROM users WHERE id = 7 AND state = "ca"
+//   SELECT name FROM users WHERE id = 42 AND state = "ny"
+//
+// share one plan whose parameter slots get the literals of each statement
+// bound into them, skipping parse and plan.  A plan is only reused while
+// the schema keeps the same Version(), ie refreshing the schema or dropping
+// a table invalidates it.  Only single source selects whose tasks all
+// round-trip through protobuf are cached, see Add.
+type PlanCache struct {
+	size   int
+	mu     sync.Mutex
+	lru    *list.List
+	items  map[planKey]*list.Element
+	hits   int64
+	misses int64
+}
+
+type planKey struct {
+	schema      string
+	fingerprint uint64
+}
+
+// planEntry a cached plan, pb is nil for statements that can't be cached
+// so they aren't probed again.
+type planEntry struct {
+	key     planKey
+	schema  *schema.Schema
+	version uint64
+	pb      []byte
+	binds   []planBind
+}
+
+// planBind the literal node of a plan that slot of the statement binds to.
+type planBind struct {
+	node int
+	slot int
+}
+
+// NewPlanCache creates a plan cache holding at most size plans.
+func NewPlanCache(size int) *PlanCache {
+	if size < 1 {
+		size = 1
+	}
+	return &PlanCache{size: size, lru: list.New(), items: make(map[planKey]*list.Element)}
+}
+
+// Get the plan for the statement of ctx, with its literals bound into the
+// cached plan, nil if there is none.  On a hit ctx.Stmt and ctx.Projection
+// are those of the plan.
+func (m *PlanCache) Get(ctx *Context) *Select {
+	if ctx.Schema == nil {
+		return nil
+	}
+	fp, slots, err := statementSlots(ctx.Raw)
+	if err != nil {
+		atomic.AddInt64(&m.misses, 1)
+		return nil
+	}
+	key := planKey{ctx.Schema.Name, fp}
+
+	m.mu.Lock()
+	var pe *planEntry
+	if el, ok := m.items[key]; ok {
+		pe = el.Value.(*planEntry)
+		if pe.schema != ctx.Schema || pe.version != ctx.Schema.Version() {
+			// the schema changed since this was planned
+			m.lru.Remove(el)
+			delete(m.items, key)
+			pe = nil
+		} else {
+			m.lru.MoveToFront(el)
+		}
+	}
+	m.mu.Unlock()
+
+	if pe == nil || pe.pb == nil {
+		atomic.AddInt64(&m.misses, 1)
+		return nil
+	}
+	p, err := pe.plan(ctx, slots)
+	if err != nil {
+		u.Warnf("could not use cached plan for %q: %v", ctx.Raw, err)
+		m.remove(key)
+		atomic.AddInt64(&m.misses, 1)
+		return nil
+	}
+	atomic.AddInt64(&m.hits, 1)
+	return p
+}
+
+// Add the plan p of the statement of ctx to the cache.  The statement
+// is planned once more with probe values in its literal slots to find
+// the nodes of the plan each slot binds to, and the probe plan bound
+// with the literals of ctx must be the same as p, else the statement
+// is remembered as one that can't be cached.
+func (m *PlanCache) Add(ctx *Context, p *Select) {
+	if ctx.Schema == nil || p == nil {
+		return
+	}
+	fp, slots, err := statementSlots(ctx.Raw)
+	if err != nil {
+		return
+	}
+	key := planKey{ctx.Schema.Name, fp}
+	version := ctx.Schema.Version()
+
+	m.mu.Lock()
+	if el, ok := m.items[key]; ok {
+		pe := el.Value.(*planEntry)
+		if pe.schema == ctx.Schema && pe.version == version {
+			m.mu.Unlock()
+			return
+		}
+	}
+	m.mu.Unlock()
+
+	pe := &planEntry{key: key, schema: ctx.Schema, version: version}
+	if err := pe.load(ctx, p, slots); err != nil {
+		u.Debugf("not caching plan for %q: %v", ctx.Raw, err)
+		pe.pb = nil
+		pe.binds = nil
+	}
+
+	m.mu.Lock()
+	if el, ok := m.items[key]; ok {
+		m.lru.Remove(el)
+	}
+	m.items[key] = m.lru.PushFront(pe)
+	for m.lru.Len() > m.size {
+		el := m.lru.Back()
+		m.lru.Remove(el)
+		delete(m.items, el.Value.(*planEntry).key)
+	}
+	m.mu.Unlock()
+}
+
+func (m *PlanCache) remove(key planKey) {
+	m.mu.Lock()
+	if el, ok := m.items[key]; ok {
+		m.lru.Remove(el)
+		delete(m.items, key)
+	}
+	m.mu.Unlock()
+}
+
+// Len number of statements in the cache.
+func (m *PlanCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+// Hits number of Get calls that returned a plan.
+func (m *PlanCache) Hits() int64 { return atomic.LoadInt64(&m.hits) }
+
+// Misses number of Get calls that didn't.
+func (m *PlanCache) Misses() int64 { return atomic.LoadInt64(&m.misses) }
+
+// Purge drops all plans.
+func (m *PlanCache) Purge() {
+	m.mu.Lock()
+	m.lru.Init()
+	m.items = make(map[planKey]*list.Element)
+	m.mu.Unlock()
+}
+
+// plan unmarshals the cached plan and binds the slot values into it.
+func (m *planEntry) plan(ctx *Context, slots []lex.Token) (*Select, error) {
+	pb := &PlanPb{}
+	if err := pb.Unmarshal(m.pb); err != nil {
+		return nil, err
+	}
+	lits := walkPlanLiterals(pb)
+	for _, b := range m.binds {
+		if err := bindLiteral(lits.nodes[b.node], slots[b.slot].V); err != nil {
+			return nil, err
+		}
+	}
+	for _, raw := range lits.raws {
+		raw.SetString(ctx.Raw)
+	}
+	stmt := rel.SqlSelectFromPb(pb.Select.Select)
+	p, err := selectFromPB(pb, ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	// serialized again from ctx if needed, not the probe context
+	p.pbplan = nil
+	ctx.Stmt = stmt
+	if proj, ok := p.tasks[len(p.tasks)-1].(*Projection); ok && proj.Final {
+		proj.P = p
+		ctx.Projection = proj
+	} else if ctx.Projection, err = NewProjectionFinal(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// load the slot bindings of plan p by planning the statement with
+// probe values.
+func (m *planEntry) load(ctx *Context, p *Select, slots []lex.Token) error {
+	if !cacheablePlan(p) {
+		return fmt.Errorf("plan tasks can't be cached")
+	}
+	probes := make([]string, len(slots))
+	var sql bytes.Buffer
+	pos := 0
+	for i, t := range slots {
+		switch t.T {
+		case lex.TokenInteger:
+			probes[i] = strconv.Itoa(7310000 + i)
+		case lex.TokenFloat:
+			probes[i] = strconv.Itoa(7310000+i) + ".5"
+		default:
+			probes[i] = "qlbparam" + strconv.Itoa(i)
+		}
+		sql.WriteString(ctx.Raw[pos : t.Pos-len(t.V)])
+		sql.WriteString(probes[i])
+		pos = t.Pos
+	}
+	sql.WriteString(ctx.Raw[pos:])
+
+	probe, err := planProbe(ctx, sql.String())
+	if err != nil {
+		return err
+	}
+	pb := probe.selectPb()
+	m.pb, err = pb.Marshal()
+	if err != nil {
+		return err
+	}
+
+	// find the literal nodes of the probe plan holding each probe value
+	lits := walkPlanLiterals(pb)
+	for _, name := range lits.funcs {
+		if _, ok := ctx.Schema.Function(name); ok {
+			// user defined functions don't resolve from protobuf
+			return fmt.Errorf("uses user defined function %q", name)
+		}
+	}
+	found := make([]bool, len(slots))
+	for i, n := range lits.nodes {
+		text := ""
+		switch n := n.(type) {
+		case *expr.StringNodePb:
+			text = n.Text
+		case *expr.NumberNodePb:
+			text = n.Text
+		}
+		for slot, probe := range probes {
+			if text == probe {
+				m.binds = append(m.binds, planBind{node: i, slot: slot})
+				found[slot] = true
+			}
+		}
+	}
+	for slot, ok := range found {
+		if !ok {
+			return fmt.Errorf("literal %q is not a value of the plan", slots[slot].V)
+		}
+	}
+
+	// the probe plan bound with the literals of this statement must be
+	// the plan of this statement
+	bound, err := m.bound(slots)
+	if err != nil {
+		return err
+	}
+	want, err := normalizedPlanBytes(p.selectPb())
+	if err != nil {
+		return err
+	}
+	if string(bound) != string(want) {
+		return fmt.Errorf("plan depends on literal values")
+	}
+	return nil
+}
+
+// bound the normalized bytes of the cached plan with slots bound.
+func (m *planEntry) bound(slots []lex.Token) ([]byte, error) {
+	pb := &PlanPb{}
+	if err := pb.Unmarshal(m.pb); err != nil {
+		return nil, err
+	}
+	lits := walkPlanLiterals(pb)
+	for _, b := range m.binds {
+		if err := bindLiteral(lits.nodes[b.node], slots[b.slot].V); err != nil {
+			return nil, err
+		}
+	}
+	return normalizedPlanBytes(pb)
+}
+
+// selectPb the protobuf of the plan including its statement.
+func (m *Select) selectPb() *PlanPb {
+	m.serializeToPb()
+	return m.pbplan
+}
+
+// planProbe parse and plan the probe statement.
+func planProbe(ctx *Context, sql string) (*Select, error) {
+	stmt, err := rel.ParseSqlResolver(sql, ctx.Schema)
+	if err != nil {
+		return nil, err
+	}
+	pctx := NewContext(sql)
+	pctx.Schema = ctx.Schema
+	pctx.Session = ctx.Session
+	pctx.Stmt = stmt
+	task, err := WalkStmt(pctx, stmt, NewPlanner(pctx))
+	if err != nil {
+		return nil, err
+	}
+	p, ok := task.(*Select)
+	if !ok {
+		return nil, fmt.Errorf("expected select plan but got %T", task)
+	}
+	for _, src := range p.From {
+		if src.Conn != nil {
+			src.Conn.Close()
+		}
+	}
+	if !cacheablePlan(p) {
+		return nil, fmt.Errorf("plan tasks can't be cached")
+	}
+	return p, nil
+}
+
+// cacheablePlan is p a single source select whose tasks are rebuilt
+// from protobuf.
+func cacheablePlan(p *Select) bool {
+	if p.Stmt == nil || len(p.Stmt.From) != 1 || len(p.From) != 1 || p.IsSchemaQuery() {
+		return false
+	}
+	return len(p.tasks) > 0 && cacheableTasks(p.tasks)
+}
+
+func cacheableTasks(tasks []Task) bool {
+	for _, t := range tasks {
+		switch t := t.(type) {
+		case *Source:
+			if len(t.Static) > 0 || t.ExecPlan != nil || t.Partition != nil || t.Complete {
+				return false
+			}
+			if _, ok := t.Conn.(SourcePlanner); ok {
+				return false
+			}
+		case *Where, *Having, *Order, *Projection:
+		case *GroupBy:
+			if t.Final {
+				return false
+			}
+		default:
+			return false
+		}
+		if !cacheableTasks(t.Children()) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizedPlanBytes of a plan without the parts that differ per
+// statement, its raw sql and context.
+func normalizedPlanBytes(pb *PlanPb) ([]byte, error) {
+	cp := &PlanPb{}
+	b, err := pb.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if err = cp.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	if cp.Select != nil {
+		cp.Select.Context = nil
+	}
+	lits := walkPlanLiterals(cp)
+	for _, raw := range lits.raws {
+		raw.SetString("")
+	}
+	// column indexes are serialized from maps
+	for _, kvs := range lits.kvs {
+		sort.Slice(kvs, func(i, j int) bool { return kvs[i].K < kvs[j].K })
+	}
+	return cp.Marshal()
+}
+
+// Fingerprint of sql with the values of its literal slots normalized out,
+// and the slot tokens holding those values, see statementSlots.
+func Fingerprint(sql string) (uint64, []lex.Token, error) {
+	return statementSlots(sql)
+}
+
+// statementSlots lexes sql into its fingerprint, with the values of its
+// literal slots normalized out, and the slot tokens.  Literals that change
+// the shape of a plan, LIMIT and OFFSET, durations, escaped strings, are
+// part of the fingerprint instead of slots.
+func statementSlots(sql string) (uint64, []lex.Token, error) {
+	h := fnv.New64a()
+	var slots []lex.Token
+	l := lex.NewSqlLexer(sql)
+	verbatim := false
+	for {
+		t := l.NextToken()
+		switch t.T {
+		case lex.TokenEOF:
+			return h.Sum64(), slots, nil
+		case lex.TokenError:
+			return 0, nil, fmt.Errorf("could not lex %q: %s", sql, t.V)
+		}
+		slot := false
+		switch t.T {
+		case lex.TokenLimit, lex.TokenOffset:
+			verbatim = true
+		case lex.TokenInteger, lex.TokenComma:
+		default:
+			verbatim = false
+		}
+		switch t.T {
+		case lex.TokenInteger, lex.TokenFloat:
+			slot = !verbatim
+		case lex.TokenValue:
+			slot = (t.Quote == '\'' || t.Quote == '"') && !strings.ContainsAny(t.V, "\\'\"`")
+		}
+		fmt.Fprintf(h, "%d:", t.T)
+		if slot {
+			slots = append(slots, t)
+			h.Write([]byte{'?', t.Quote, 0})
+		} else {
+			h.Write([]byte(t.V))
+			h.Write([]byte{0})
+		}
+	}
+}
+
+// bindLiteral sets the value of a literal node of a plan as the parser
+// would for the same text.
+func bindLiteral(node interface{}, text string) error {
+	switch n := node.(type) {
+	case *expr.StringNodePb:
+		n.Text = text
+	case *expr.NumberNodePb:
+		nn, err := expr.NewNumberStr(text)
+		if err != nil {
+			return err
+		}
+		*n = *nn.NodePb().Nn
+	}
+	return nil
+}
+
+// planLiterals the literal nodes of a plan, the functions it calls, its
+// raw sql fields and column indexes, in the order of a walk of its protobuf.
+type planLiterals struct {
+	nodes []interface{}
+	funcs []string
+	raws  []reflect.Value
+	kvs   [][]rel.KvInt
+}
+
+func walkPlanLiterals(pb *PlanPb) *planLiterals {
+	m := &planLiterals{}
+	m.walk(reflect.ValueOf(pb))
+	return m
+}
+
+func (m *planLiterals) walk(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		switch n := v.Interface().(type) {
+		case *expr.StringNodePb, *expr.NumberNodePb:
+			m.nodes = append(m.nodes, n)
+			return
+		case *expr.FuncNodePb:
+			m.funcs = append(m.funcs, n.Name)
+		}
+		m.walk(v.Elem())
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			if f.Name == "Raw" && f.Type.Kind() == reflect.String {
+				if v.Field(i).Len() > 0 {
+					m.raws = append(m.raws, v.Field(i))
+				}
+				continue
+			}
+			m.walk(v.Field(i))
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		if kvs, ok := v.Interface().([]rel.KvInt); ok {
+			m.kvs = append(m.kvs, kvs)
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			m.walk(v.Index(i))
+		}
+	}
+}
@@ -47,9 +47,13 @@ type Context struct {
 	Projection      *Projection      // Projection for this context optional
 
 	// Local in-memory helpers not transported across network
-	Session expr.ContextReadWriter // Session for this connection
-	Schema  *schema.Schema         // this schema for this connection
-	Funcs   expr.FuncResolver      // Local/Dialect specific functions
+	Session  expr.ContextReadWriter // Session for this connection
+	Schema   *schema.Schema         // this schema for this connection
+	Funcs    expr.FuncResolver      // Local/Dialect specific functions
+	Memory   *MemoryAccount         // Memory used by buffered rows, nil is unaccounted
+	Observer Observer               // Receives parse, plan and task events, optional
+	User     string                 // User of this connection, if empty privileges are not checked
+	Identity expr.ContextReader     // Attributes of the user (tenant) set by the application, read by policies, not by SET
 
 	// From configuration
 	DisableRecover bool
@@ -57,6 +61,7 @@ type Context struct {
 	// Local State
 	Errors     []error
 	errRecover interface{}
+	cancel     context.CancelFunc
 }
 
 // NewContext plan context
@@ -67,6 +72,27 @@ func NewContextFromPb(pb *ContextPb) *Context {
 	return &Context{id: pb.Id, fingerprint: pb.Fingerprint, SchemaName: pb.Schema}
 }
 
+// WithTimeout makes the go context of this plan cancelable, with a
+// deadline if timeout > 0.  Cancel stops it.
+func (m *Context) WithTimeout(timeout time.Duration) {
+	parent := m.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	if timeout > 0 {
+		m.Context, m.cancel = context.WithTimeout(parent, timeout)
+	} else {
+		m.Context, m.cancel = context.WithCancel(parent)
+	}
+}
+
+// Cancel the go context of this plan, the running tasks stop.
+func (m *Context) Cancel() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
 // called by go routines/tasks to ensure any recovery panics are captured
 func (m *Context) Recover() {
 	if m == nil {
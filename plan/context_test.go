@@ -30,3 +30,18 @@ func TestContext(t *testing.T) {
 	c1FromPb.fingerprint = 88 //
 	assert.Equal(t, false, c1.Equal(c1FromPb))
 }
+
+func TestMemoryAccountSpill(t *testing.T) {
+	var none *MemoryAccount
+	assert.Equal(t, false, none.Spill(1<<30))
+
+	mem := NewMemoryAccount(1000, 0)
+	// another task of the query holding more than the soft limit
+	// does not make a task with a few rows spill
+	assert.Equal(t, nil, mem.Grow(5000))
+	assert.Equal(t, false, mem.Spill(100))
+	assert.Equal(t, true, mem.Spill(1001))
+	mem.Shrink(5000)
+	assert.Equal(t, int64(0), mem.Used())
+	assert.Equal(t, int64(5000), mem.Peak())
+}
@@ -0,0 +1,123 @@
+package plan
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrMemoryLimit the query buffered more rows than its memory limit allows.
+var ErrMemoryLimit = fmt.Errorf("Query memory limit exceeded, see SET query_memory_limit")
+
+// MemoryAccount tracks the approximate bytes of rows a job holds
+// buffered in its tasks (order by, group by, joins).
+//
+//   SoftLimit   tasks that can spill to disk do so once the rows they
+//               hold themselves are past it
+//   HardLimit   the query fails once past it
+//
+// Both are in bytes, 0 is no limit.  A nil account does no accounting.
+type MemoryAccount struct {
+	SoftLimit int64
+	HardLimit int64
+	used      int64
+	peak      int64
+}
+
+// NewMemoryAccount creates an account with soft and hard limits in bytes.
+func NewMemoryAccount(soft, hard int64) *MemoryAccount {
+	return &MemoryAccount{SoftLimit: soft, HardLimit: hard}
+}
+
+// Grow charges n bytes to the account, ErrMemoryLimit if that would
+// take it past the hard limit in which case nothing is charged.
+func (m *MemoryAccount) Grow(n int64) error {
+	if m == nil {
+		return nil
+	}
+	used := atomic.AddInt64(&m.used, n)
+	if m.HardLimit > 0 && used > m.HardLimit {
+		atomic.AddInt64(&m.used, -n)
+		return ErrMemoryLimit
+	}
+	for {
+		peak := atomic.LoadInt64(&m.peak)
+		if used <= peak || atomic.CompareAndSwapInt64(&m.peak, peak, used) {
+			return nil
+		}
+	}
+}
+
+// Shrink releases n bytes, ie rows that were sent on or spilled.
+func (m *MemoryAccount) Shrink(n int64) {
+	if m == nil {
+		return
+	}
+	atomic.AddInt64(&m.used, -n)
+}
+
+// Used bytes currently charged.
+func (m *MemoryAccount) Used() int64 {
+	if m == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&m.used)
+}
+
+// Peak the most bytes charged at once.
+func (m *MemoryAccount) Peak() int64 {
+	if m == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&m.peak)
+}
+
+// Spill is true once the held bytes of a single task are past the soft
+// limit.  It is not the bytes of the whole query, else a task holding
+// a few rows would spill each of them while another task is large.
+func (m *MemoryAccount) Spill(held int64) bool {
+	if m == nil || m.SoftLimit <= 0 {
+		return false
+	}
+	return held > m.SoftLimit
+}
+
+// RowSize approximate bytes held by a row of values.
+func RowSize(vals []driver.Value) int64 {
+	n := int64(24) // slice header
+	for _, v := range vals {
+		n += ValueSize(v)
+	}
+	return n
+}
+
+// ValueSize approximate bytes held by a value, the interface
+// header plus what it points to.
+func ValueSize(v driver.Value) int64 {
+	switch vt := v.(type) {
+	case nil:
+		return 16
+	case int64, float64, bool:
+		return 24
+	case string:
+		return 32 + int64(len(vt))
+	case []byte:
+		return 40 + int64(len(vt))
+	case time.Time:
+		return 40
+	case []string:
+		n := int64(40)
+		for _, s := range vt {
+			n += 16 + int64(len(s))
+		}
+		return n
+	case map[string]interface{}:
+		n := int64(64)
+		for k, mv := range vt {
+			n += 16 + int64(len(k)) + ValueSize(mv)
+		}
+		return n
+	}
+	return 64
+}
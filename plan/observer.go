@@ -0,0 +1,128 @@
+package plan
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType the kind of an Event a query emits to its Observers.
+type EventType uint8
+
+const (
+	// EventParse the statement was parsed.
+	EventParse EventType = iota + 1
+	// EventPlan the statement was planned and its exec dag built.
+	EventPlan
+	// EventJobStart the job started running.
+	EventJobStart
+	// EventJobStop the job finished running.
+	EventJobStop
+	// EventTaskStart a task of the exec dag started running.
+	EventTaskStart
+	// EventTaskStop a task of the exec dag finished running.
+	EventTaskStop
+)
+
+func (m EventType) String() string {
+	switch m {
+	case EventParse:
+		return "parse"
+	case EventPlan:
+		return "plan"
+	case EventJobStart:
+		return "job_start"
+	case EventJobStop:
+		return "job_stop"
+	case EventTaskStart:
+		return "task_start"
+	case EventTaskStop:
+		return "task_stop"
+	}
+	return "unknown"
+}
+
+// Event is emitted to the Observers of a query as it is parsed, planned
+// and as each of the tasks of its exec dag run.
+//
+// Tasks have a unique Id, ParentId is the id of the sequential or parallel
+// task running them, 0 for the top level tasks of the job.  Rows are counted
+// on the channels between the tasks of a sequence, rows a task reads from
+// outside its sequence are not.
+type Event struct {
+	Type     EventType
+	Task     string // name of the task, ie Order, for task events
+	Id       uint64
+	ParentId uint64
+	Start    time.Time
+	Duration time.Duration // for parse, plan and stop events
+	RowsIn   int64
+	RowsOut  int64
+	Err      error
+}
+
+// Observer receives the events of the queries it is registered for,
+// either on a Context or globally with RegisterObserver.  It is called
+// concurrently by the tasks of a job so must be safe for that.
+type Observer interface {
+	Observe(ctx *Context, e *Event)
+}
+
+// ObserverFunc adapts a func to an Observer.
+type ObserverFunc func(ctx *Context, e *Event)
+
+// Observe calls the func.
+func (m ObserverFunc) Observe(ctx *Context, e *Event) { m(ctx, e) }
+
+var (
+	observerMu sync.RWMutex
+	observers  []*observerReg
+)
+
+type observerReg struct {
+	Observer
+}
+
+// RegisterObserver adds an Observer of every query, the returned
+// func removes it.
+func RegisterObserver(o Observer) (unregister func()) {
+	reg := &observerReg{o}
+	observerMu.Lock()
+	observers = append(observers, reg)
+	observerMu.Unlock()
+	return func() {
+		observerMu.Lock()
+		defer observerMu.Unlock()
+		for i, ob := range observers {
+			if ob == reg {
+				observers = append(observers[:i:i], observers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Observing is true if there is any Observer for this context, so
+// events need not be built when nobody is listening.
+func (m *Context) Observing() bool {
+	if m.Observer != nil {
+		return true
+	}
+	observerMu.RLock()
+	n := len(observers)
+	observerMu.RUnlock()
+	return n > 0
+}
+
+// Observe sends the event to the Observer of this context and the
+// global ones.
+func (m *Context) Observe(e *Event) {
+	if m.Observer != nil {
+		m.Observer.Observe(m, e)
+	}
+	observerMu.RLock()
+	obs := observers
+	observerMu.RUnlock()
+	for _, o := range obs {
+		o.Observe(m, e)
+	}
+}
@@ -33,6 +33,7 @@ var (
 	_ Task = (*Delete)(nil)
 	_ Task = (*Command)(nil)
 	_ Task = (*Create)(nil)
+	_ Task = (*Grant)(nil)
 	_ Task = (*Projection)(nil)
 	_ Task = (*Source)(nil)
 	_ Task = (*Into)(nil)
@@ -42,6 +43,7 @@ var (
 	_ Task = (*Order)(nil)
 	_ Task = (*JoinMerge)(nil)
 	_ Task = (*JoinKey)(nil)
+	_ Task = (*PartitionMerge)(nil)
 
 	// Force any plan that participates in a Select to implement Proto
 	//  which allows us to serialize and distribute to multiple nodes.
@@ -113,6 +115,12 @@ type (
 		WalkAlter(p *Alter) error
 	}
 
+	// GrantPlanner is optionally implemented by a Planner that plans GRANT
+	// and REVOKE, other planners return ErrNotImplemented for them.
+	GrantPlanner interface {
+		WalkGrant(p *Grant) error
+	}
+
 	// SourcePlanner Sources can often do their own planning for sub-select statements
 	// ie mysql can do its own (select, projection) mongo, es can as well
 	// - provide interface to allow passing down select planning to source
@@ -120,6 +128,13 @@ type (
 		// given our request statement, turn that into a plan.Task.
 		WalkSourceSelect(pl Planner, s *Source) (Task, error)
 	}
+	// SourceSelectComplete is an optional interface for a SourcePlanner
+	// whose Complete plans also did the group by, having and order by of
+	// the statement, ie elasticsearch aggregations.  Otherwise the planner
+	// still adds those steps after a Complete source.
+	SourceSelectComplete interface {
+		SelectComplete() bool
+	}
 )
 
 type (
@@ -195,12 +210,13 @@ type (
 		Custom   u.JsonHelper    // Source specific context info
 
 		// Schema and underlying Source provider info, not serialized or transported
-		ctx        *Context       // query context, shared across all parts of this request
-		DataSource schema.Source  // The data source for this From
-		Conn       schema.Conn    // Connection for this source, only for this source/task
-		Schema     *schema.Schema // Schema for this source/from
-		Tbl        *schema.Table  // Table schema for this From
-		Static     []driver.Value // this is static data source
+		ctx        *Context          // query context, shared across all parts of this request
+		DataSource schema.Source     // The data source for this From
+		Conn       schema.Conn       // Connection for this source, only for this source/task
+		Schema     *schema.Schema    // Schema for this source/from
+		Tbl        *schema.Table     // Table schema for this From
+		Partition  *schema.Partition // Partition of the source this scans, nil for all
+		Static     []driver.Value    // this is static data source
 		Cols       []string
 	}
 	// Into Select INTO table
@@ -213,6 +229,7 @@ type (
 		*PlanBase
 		Stmt    *rel.SqlSelect
 		Partial bool
+		Final   bool // merges the partial results of upstream group-bys
 	}
 	// Order By clause
 	Order struct {
@@ -244,6 +261,11 @@ type (
 		*PlanBase
 		Source *Source
 	}
+	// PartitionMerge parallel scans of each partition of a source
+	PartitionMerge struct {
+		*PlanBase
+		Parts []Task
+	}
 
 	// DDL Tasks
 
@@ -265,11 +287,23 @@ type (
 		Ctx  *Context
 		Stmt *rel.SqlAlter
 	}
+	// Grant plan for GRANT and REVOKE
+	Grant struct {
+		*PlanBase
+		Ctx  *Context
+		Stmt *rel.SqlGrant
+	}
 )
 
 // WalkStmt Walk given statement for given Planner to produce a query plan
 // which is a plan.Task and children, ie a DAG of tasks
 func WalkStmt(ctx *Context, stmt rel.SqlStatement, planner Planner) (Task, error) {
+	if err := Authorize(ctx, stmt); err != nil {
+		return nil, err
+	}
+	if err := applyPolicies(ctx, stmt); err != nil {
+		return nil, err
+	}
 	var p Task
 	base := NewPlanBase(false)
 	switch st := stmt.(type) {
@@ -307,6 +341,8 @@ func WalkStmt(ctx *Context, stmt rel.SqlStatement, planner Planner) (Task, error
 		p = &Drop{Stmt: st, PlanBase: base, Ctx: ctx}
 	case *rel.SqlAlter:
 		p = &Alter{Stmt: st, PlanBase: base, Ctx: ctx}
+	case *rel.SqlGrant:
+		p = &Grant{Stmt: st, PlanBase: base, Ctx: ctx}
 	default:
 		panic(fmt.Sprintf("Not implemented for %T", stmt))
 	}
@@ -316,7 +352,7 @@ func WalkStmt(ctx *Context, stmt rel.SqlStatement, planner Planner) (Task, error
 // SelectPlanFromPbBytes Create a sql plan from pb.
 func SelectPlanFromPbBytes(pb []byte, loader SchemaLoader) (*Select, error) {
 	p := &PlanPb{}
-	if err := proto.Unmarshal(pb, p); err != nil {
+	if err := p.Unmarshal(pb); err != nil {
 		u.Errorf("error reading protobuf select: %v  \n%s", err, pb)
 		return nil, err
 	}
@@ -413,6 +449,12 @@ func (m *Source) Walk(p Planner) error            { return p.WalkSourceSelect(m)
 func (m *Create) Walk(p Planner) error            { return p.WalkCreate(m) }
 func (m *Drop) Walk(p Planner) error              { return p.WalkDrop(m) }
 func (m *Alter) Walk(p Planner) error             { return p.WalkAlter(m) }
+func (m *Grant) Walk(p Planner) error {
+	if gp, ok := p.(GrantPlanner); ok {
+		return gp.WalkGrant(m)
+	}
+	return ErrNotImplemented
+}
 
 // NewCreate creates a new Create Task plan.
 func NewCreate(ctx *Context, stmt *rel.SqlCreate) *Create {
@@ -429,6 +471,11 @@ func NewAlter(ctx *Context, stmt *rel.SqlAlter) *Alter {
 	return &Alter{Stmt: stmt, PlanBase: NewPlanBase(false), Ctx: ctx}
 }
 
+// NewGrant create Grant plan task.
+func NewGrant(ctx *Context, stmt *rel.SqlGrant) *Grant {
+	return &Grant{Stmt: stmt, PlanBase: NewPlanBase(false), Ctx: ctx}
+}
+
 func (m *Select) Marshal() ([]byte, error) {
 	err := m.serializeToPb()
 	if err != nil {
@@ -514,7 +561,7 @@ func (m *Select) IsSchemaQuery() bool {
 	if len(m.From) == 1 {
 		//u.Debugf("schema:%q name:%q", m.From[0].Stmt.Schema, m.From[0].Stmt.Name)
 		schemaName := strings.ToLower(m.From[0].Stmt.Schema)
-		if schemaName == "context" || schemaName == "schema" {
+		if schemaName == "context" || schemaName == "schema" || schemaName == "pg_catalog" {
 			return true
 		}
 	}
@@ -522,26 +569,36 @@ func (m *Select) IsSchemaQuery() bool {
 }
 
 func SelectFromPB(pb *PlanPb, loader SchemaLoader) (*Select, error) {
-	m := Select{
-		pbplan:   pb,
-		ChildDag: true,
-	}
-	m.PlanBase = NewPlanBase(pb.Parallel)
+	var ctx *Context
+	var stmt *rel.SqlSelect
 	if pb.Select != nil {
-		m.Stmt = rel.SqlSelectFromPb(pb.Select.Select)
+		stmt = rel.SqlSelectFromPb(pb.Select.Select)
 		if pb.Select.Context != nil {
 			//u.Infof("got context pb %+v", pb.Select.Context)
-			m.Ctx = NewContextFromPb(pb.Select.Context)
-			m.Ctx.Stmt = m.Stmt
-			m.Ctx.Raw = m.Stmt.Raw
-			sch, err := loader(m.Ctx.SchemaName)
+			ctx = NewContextFromPb(pb.Select.Context)
+			ctx.Stmt = stmt
+			ctx.Raw = stmt.Raw
+			sch, err := loader(ctx.SchemaName)
 			if err != nil {
-				u.Errorf("could not load schema: %q  err=%v", m.Ctx.SchemaName, err)
+				u.Errorf("could not load schema: %q  err=%v", ctx.SchemaName, err)
 				return nil, err
 			}
-			m.Ctx.Schema = sch
+			ctx.Schema = sch
 		}
 	}
+	return selectFromPB(pb, ctx, stmt)
+}
+
+// selectFromPB create the select plan of pb for the statement stmt
+// running in ctx.
+func selectFromPB(pb *PlanPb, ctx *Context, stmt *rel.SqlSelect) (*Select, error) {
+	m := Select{
+		pbplan:   pb,
+		ChildDag: true,
+		Stmt:     stmt,
+		Ctx:      ctx,
+	}
+	m.PlanBase = NewPlanBase(pb.Parallel)
 	if len(pb.Children) > 0 {
 		m.tasks = make([]Task, len(pb.Children))
 		for i, pbt := range pb.Children {
@@ -618,6 +675,21 @@ func SourceFromPB(pb *PlanPb, ctx *Context) (*Source, error) {
 		}
 	}
 
+	// A scan of one partition of the source.
+	if id, ok := m.Custom["partition"].(string); ok && m.Conn != nil {
+		pc, ok := m.Conn.(schema.SourcePartitionable)
+		if !ok {
+			return nil, fmt.Errorf("source %q is not partitionable", m.Stmt.SourceName())
+		}
+		m.Partition = &schema.Partition{Id: id}
+		conn, err := pc.PartitionSource(m.Partition)
+		if err != nil {
+			return nil, err
+		}
+		m.Conn.Close()
+		m.Conn = conn
+	}
+
 	return &m, nil
 }
 
@@ -673,7 +745,7 @@ func (m *Source) IsSchemaQuery() bool {
 	if m.Stmt != nil && len(m.Stmt.Schema) > 0 {
 		//u.Debugf("schema:%q name:%q", m.Stmt.Schema, m.Stmt.Name)
 		schemaName := strings.ToLower(m.Stmt.Schema)
-		if schemaName == "context" || schemaName == "schema" {
+		if schemaName == "context" || schemaName == "schema" || schemaName == "pg_catalog" {
 			return true
 		}
 	}
@@ -736,10 +808,19 @@ func (m *Source) serializeToPb() error {
 	if m.SourcePb.SqlSource == nil && m.Stmt != nil {
 		m.SourcePb.SqlSource = m.Stmt.ToPB()
 	}
-	if len(m.Custom) > 0 {
-		by, err := json.Marshal(m.Custom)
+	custom := m.Custom
+	if m.Partition != nil {
+		// Custom may be shared with the other partitions of this source
+		custom = make(u.JsonHelper, len(m.Custom)+1)
+		for k, v := range m.Custom {
+			custom[k] = v
+		}
+		custom["partition"] = m.Partition.Id
+	}
+	if len(custom) > 0 {
+		by, err := json.Marshal(custom)
 		if err != nil {
-			u.Errorf("Could not marshall custom source plan json %v", m.Custom)
+			u.Errorf("Could not marshall custom source plan json %v", custom)
 		} else {
 			m.SourcePb.Custom = by
 		}
@@ -881,6 +962,25 @@ func NewJoinKey(s *Source) *JoinKey {
 	return &JoinKey{Source: s, PlanBase: NewPlanBase(false)}
 }
 
+// NewPartitionMerge A parallel merge of the scans of each partition of
+// a single source, each partition running its own where and partial
+// group by.
+//
+//   partition 1 -> where -> partial group by ->
+//                                              \
+//                                                --  merge  -->
+//                                              /
+//   partition n -> where -> partial group by ->
+//
+func NewPartitionMerge(parts []Task) *PartitionMerge {
+	m := &PartitionMerge{
+		PlanBase: NewPlanBase(false),
+		Parts:    parts,
+	}
+	m.SetParallel()
+	return m
+}
+
 // NewWhere new Where Task from SqlSelect statement.
 func NewWhere(stmt *rel.SqlSelect) *Where {
 	return &Where{Stmt: stmt, PlanBase: NewPlanBase(false)}
@@ -901,6 +1001,17 @@ func NewGroupBy(stmt *rel.SqlSelect) *GroupBy {
 	return &GroupBy{Stmt: stmt, PlanBase: NewPlanBase(false)}
 }
 
+// NewGroupByPartial from SqlSelect statement, aggregates into partial
+// results to be merged by a NewGroupByFinal.
+func NewGroupByPartial(stmt *rel.SqlSelect) *GroupBy {
+	return &GroupBy{Stmt: stmt, Partial: true, PlanBase: NewPlanBase(false)}
+}
+
+// NewGroupByFinal from SqlSelect statement, merges partial results.
+func NewGroupByFinal(stmt *rel.SqlSelect) *GroupBy {
+	return &GroupBy{Stmt: stmt, Final: true, PlanBase: NewPlanBase(false)}
+}
+
 // NewOrder from SqlSelect statement.
 func NewOrder(stmt *rel.SqlSelect) *Order {
 	return &Order{Stmt: stmt, PlanBase: NewPlanBase(false)}
@@ -1005,7 +1116,7 @@ func (m *GroupBy) ToPb() (*PlanPb, error) {
 	if err != nil {
 		return nil, err
 	}
-	pbp.GroupBy = &GroupByPb{Select: m.Stmt.ToPB()}
+	pbp.GroupBy = &GroupByPb{Select: m.Stmt.ToPB(), Partial: m.Partial}
 	return pbp, nil
 }
 func (m *GroupBy) Equal(t Task) bool {
@@ -1030,7 +1141,8 @@ func (m *GroupBy) Equal(t Task) bool {
 }
 func GroupByFromPB(pb *PlanPb) *GroupBy {
 	m := GroupBy{
-		Stmt: rel.SqlSelectFromPb(pb.GroupBy.Select),
+		Stmt:    rel.SqlSelectFromPb(pb.GroupBy.Select),
+		Partial: pb.GroupBy.Partial,
 	}
 	m.PlanBase = NewPlanBase(pb.Parallel)
 	return &m
@@ -1092,6 +1204,29 @@ func (m *JoinMerge) Equal(t Task) bool {
 	}
 	return true
 }
+func (m *PartitionMerge) Equal(t Task) bool {
+	if m == nil && t == nil {
+		return true
+	}
+	if m == nil && t != nil {
+		return false
+	}
+	if m != nil && t == nil {
+		return false
+	}
+	s, ok := t.(*PartitionMerge)
+	if !ok {
+		return false
+	}
+	if len(m.Parts) != len(s.Parts) {
+		return false
+	}
+
+	if !m.PlanBase.EqualBase(s.PlanBase) {
+		return false
+	}
+	return true
+}
 func (m *JoinKey) Equal(t Task) bool {
 	if m == nil && t == nil {
 		return true
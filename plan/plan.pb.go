@@ -125,6 +125,7 @@ func (*WherePb) Descriptor() ([]byte, []int) { return fileDescriptorPlan, []int{
 // Group By Plan
 type GroupByPb struct {
 	Select           *rel.SqlSelectPb `protobuf:"bytes,1,opt,name=select" json:"select,omitempty"`
+	Partial          bool             `protobuf:"varint,2,opt,name=partial" json:"partial"`
 	XXX_unrecognized []byte           `json:"-"`
 }
 
@@ -541,6 +542,14 @@ func (m *GroupByPb) MarshalTo(data []byte) (int, error) {
 		}
 		i += n15
 	}
+	data[i] = 0x10
+	i++
+	if m.Partial {
+		data[i] = 1
+	} else {
+		data[i] = 0
+	}
+	i++
 	if m.XXX_unrecognized != nil {
 		i += copy(data[i:], m.XXX_unrecognized)
 	}
@@ -827,6 +836,7 @@ func (m *GroupByPb) Size() (n int) {
 		l = m.Select.Size()
 		n += 1 + l + sovPlan(uint64(l))
 	}
+	n += 2
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -1995,6 +2005,26 @@ func (m *GroupByPb) Unmarshal(data []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Partial", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPlan
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := data[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Partial = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipPlan(data[iNdEx:])
@@ -2459,43 +2489,44 @@ var (
 )
 
 var fileDescriptorPlan = []byte{
-	// 602 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x94, 0x54, 0x5b, 0x8e, 0xd3, 0x3c,
-	0x18, 0x9d, 0xf4, 0x9a, 0x7c, 0xed, 0xff, 0x53, 0xc2, 0x08, 0x99, 0x79, 0x28, 0x55, 0xb8, 0xa8,
-	0x30, 0x22, 0x11, 0x65, 0x07, 0x45, 0xc0, 0x68, 0x10, 0x43, 0xa5, 0x79, 0x40, 0xe2, 0x2d, 0x17,
-	0x4f, 0x92, 0x91, 0x1b, 0xa7, 0x6e, 0x0a, 0x9d, 0x9d, 0xb0, 0x05, 0x76, 0xd2, 0x47, 0x56, 0x80,
-	0xb8, 0x3c, 0xb0, 0x0d, 0x1c, 0x3b, 0x71, 0x5d, 0x24, 0x46, 0xe5, 0x21, 0x52, 0x72, 0x7c, 0x7c,
-	0xfc, 0xf9, 0x3b, 0xe7, 0x0b, 0x40, 0x4e, 0xfc, 0xcc, 0xcd, 0x19, 0x2d, 0xa8, 0xdd, 0x2a, 0xdf,
-	0x8f, 0x9e, 0xc4, 0x69, 0x91, 0xac, 0x02, 0x37, 0xa4, 0x73, 0x2f, 0xa6, 0x31, 0xf5, 0xc4, 0x62,
-	0xb0, 0xba, 0x10, 0x5f, 0xe2, 0x43, 0xbc, 0xc9, 0x4d, 0x47, 0x8f, 0x34, 0xba, 0xcf, 0xfc, 0x28,
-	0xa2, 0x99, 0xb7, 0x20, 0x01, 0x4b, 0xa3, 0x18, 0x7b, 0x0c, 0x13, 0x6f, 0xb9, 0x20, 0x15, 0xf5,
-	0xf8, 0x3a, 0x2a, 0x5e, 0xe7, 0xcc, 0xcb, 0x68, 0x84, 0x25, 0xd9, 0xf9, 0xdc, 0x84, 0xce, 0x8c,
-	0xd7, 0x33, 0x0b, 0xec, 0xdb, 0x60, 0xe6, 0x9c, 0x4f, 0x08, 0x26, 0xc8, 0x18, 0x35, 0xc6, 0xe6,
-	0xb4, 0xb5, 0xf9, 0x7a, 0xf7, 0xc0, 0xbe, 0x0f, 0x9d, 0x25, 0x26, 0x38, 0x2c, 0x50, 0x73, 0x64,
-	0x8c, 0x7b, 0x93, 0xff, 0x5d, 0x71, 0x99, 0x73, 0x81, 0xcd, 0x02, 0xc1, 0x32, 0x04, 0x8b, 0xae,
-	0x58, 0x88, 0x51, 0x6b, 0x87, 0x25, 0x30, 0xc5, 0x72, 0xa0, 0xfd, 0x31, 0xc1, 0x0c, 0xa3, 0xb6,
-	0x20, 0xfd, 0x27, 0x49, 0xef, 0x4a, 0x48, 0x57, 0x4a, 0xfc, 0x0f, 0x69, 0x16, 0xa3, 0x8e, 0xae,
-	0x74, 0x22, 0x30, 0xc5, 0x7a, 0x08, 0xdd, 0x98, 0xd1, 0x55, 0x3e, 0xbd, 0x42, 0x5d, 0x41, 0xbb,
-	0x21, 0x69, 0xaf, 0x24, 0xa8, 0x9f, 0x48, 0x59, 0x84, 0x19, 0x32, 0xf5, 0x13, 0xdf, 0x96, 0x90,
-	0xe2, 0x3c, 0x06, 0xeb, 0x92, 0xa6, 0xd9, 0x1b, 0xcc, 0x62, 0x8c, 0x2c, 0xc1, 0xbb, 0x29, 0x79,
-	0xa7, 0x35, 0xac, 0x9f, 0x5b, 0x72, 0x5f, 0xe3, 0x2b, 0x04, 0xfa, 0xb9, 0xa7, 0x12, 0x54, 0xbc,
-	0x63, 0xee, 0x39, 0xa3, 0x97, 0xbc, 0x45, 0x29, 0xcd, 0x50, 0xaf, 0x12, 0xe5, 0x4e, 0xb9, 0x33,
-	0x05, 0x6b, 0x57, 0x36, 0xc3, 0x24, 0x25, 0x11, 0xc3, 0x19, 0xea, 0x8f, 0x9a, 0x9c, 0xda, 0x97,
-	0xaa, 0xd2, 0x1a, 0xc9, 0x72, 0xde, 0x83, 0x59, 0x37, 0x9d, 0x97, 0x51, 0x9b, 0x52, 0x5a, 0xd5,
-	0x9b, 0x0c, 0x84, 0xf4, 0xf9, 0x82, 0xfc, 0x61, 0x0b, 0x2f, 0x37, 0xa4, 0x59, 0x81, 0xd7, 0x05,
-	0x6a, 0xe8, 0xe5, 0x3e, 0x97, 0xa0, 0xd2, 0x3e, 0x03, 0x4b, 0x41, 0xf6, 0x21, 0x17, 0x0f, 0x13,
-	0x3c, 0xf7, 0x85, 0xb8, 0x55, 0xe5, 0x60, 0x00, 0x8d, 0x34, 0xe2, 0x2a, 0x8d, 0x71, 0xab, 0x42,
-	0xee, 0x40, 0xef, 0x82, 0x7b, 0x82, 0x59, 0xce, 0xd2, 0xac, 0x8c, 0x87, 0x5a, 0x72, 0x7e, 0x19,
-	0xbc, 0xd8, 0xca, 0x7b, 0x7b, 0x08, 0x83, 0x0c, 0xe3, 0x68, 0x79, 0xe2, 0x2f, 0x13, 0x3f, 0x20,
-	0xb8, 0x6c, 0x5e, 0x43, 0x4b, 0xd8, 0x2d, 0x68, 0x73, 0x1d, 0x9f, 0x08, 0x85, 0x1a, 0xe4, 0x71,
-	0xe4, 0x09, 0xce, 0x09, 0x2e, 0xca, 0x48, 0x6d, 0x71, 0x1b, 0x5a, 0xa5, 0x01, 0x3c, 0x41, 0x5b,
-	0x0c, 0x01, 0xc8, 0xf0, 0xbd, 0x58, 0xe3, 0x90, 0xc7, 0x66, 0xbb, 0xc2, 0xaf, 0x12, 0xae, 0x96,
-	0x05, 0x9d, 0x8b, 0x94, 0xf4, 0xab, 0xae, 0xdc, 0x03, 0x8b, 0xcf, 0x8b, 0xac, 0xaf, 0x0a, 0xc6,
-	0xb6, 0x81, 0x75, 0xd5, 0x0f, 0x76, 0x1c, 0xb4, 0xfe, 0xe2, 0xa0, 0xf3, 0x12, 0xba, 0x55, 0x7e,
-	0x77, 0x4c, 0x31, 0xae, 0x31, 0x45, 0xdd, 0x57, 0x6b, 0x82, 0xf3, 0x0c, 0x2c, 0x95, 0xdd, 0x7d,
-	0x95, 0x9c, 0x09, 0x98, 0xf5, 0x5c, 0xec, 0xbd, 0xe7, 0x29, 0x74, 0xab, 0xf8, 0xff, 0xc3, 0x96,
-	0x9e, 0x36, 0x09, 0x7c, 0xa6, 0xea, 0x09, 0x95, 0xdb, 0xfa, 0x6e, 0xf9, 0x5b, 0x71, 0xcf, 0xf8,
-	0x6f, 0x45, 0x6d, 0xf1, 0xc0, 0x52, 0x23, 0xb1, 0xcf, 0x86, 0xe9, 0xe1, 0xe6, 0xfb, 0xf0, 0x60,
-	0xf3, 0x63, 0x68, 0x7c, 0xe1, 0xcf, 0x37, 0xfe, 0x7c, 0xfa, 0x39, 0x3c, 0xf8, 0x1d, 0x00, 0x00,
-	0xff, 0xff, 0xed, 0x08, 0xa3, 0x5b, 0x39, 0x05, 0x00, 0x00,
+	// 609 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x94, 0xdf, 0x6e, 0xd3, 0x30,
+	0x14, 0xc6, 0x97, 0xfe, 0x4d, 0x4e, 0x0b, 0x8c, 0x30, 0x26, 0xb3, 0x8b, 0x52, 0x05, 0x98, 0x0a,
+	0x13, 0x8d, 0xe8, 0x23, 0x0c, 0x01, 0xd3, 0x10, 0xa3, 0xd2, 0x2e, 0x90, 0xb8, 0x73, 0x93, 0xb3,
+	0x24, 0x93, 0x6b, 0xa7, 0x4e, 0x0a, 0xdb, 0x9b, 0xf0, 0x0a, 0xbc, 0xc9, 0x2e, 0x79, 0x02, 0x04,
+	0xe3, 0x82, 0xd7, 0x40, 0xb1, 0x53, 0xcf, 0x43, 0x62, 0x2a, 0x77, 0xcd, 0xe7, 0x9f, 0x3f, 0xdb,
+	0xe7, 0x3b, 0xa7, 0x00, 0x39, 0xa3, 0x7c, 0x9c, 0x4b, 0x51, 0x0a, 0xbf, 0x55, 0xfd, 0xde, 0x79,
+	0x9e, 0x64, 0x65, 0xba, 0x9c, 0x8d, 0x23, 0x31, 0x0f, 0x13, 0x91, 0x88, 0x50, 0x2d, 0xce, 0x96,
+	0x27, 0xea, 0x4b, 0x7d, 0xa8, 0x5f, 0x7a, 0xd3, 0xce, 0x53, 0x0b, 0xa7, 0x92, 0xc6, 0xb1, 0xe0,
+	0xe1, 0x82, 0xcd, 0x64, 0x16, 0x27, 0x18, 0x4a, 0x64, 0x61, 0xb1, 0x60, 0x35, 0xba, 0x77, 0x13,
+	0x8a, 0x67, 0xb9, 0x0c, 0xb9, 0x88, 0x51, 0xc3, 0xc1, 0xd7, 0x26, 0x74, 0xa6, 0x8c, 0xf2, 0xe9,
+	0xcc, 0xdf, 0x06, 0x37, 0xa7, 0x92, 0x32, 0x86, 0x8c, 0x38, 0xc3, 0xc6, 0xc8, 0xdd, 0x6f, 0x5d,
+	0x7c, 0x7f, 0xb8, 0xe1, 0x3f, 0x86, 0x4e, 0x81, 0x0c, 0xa3, 0x92, 0x34, 0x87, 0xce, 0xa8, 0x37,
+	0xb9, 0x3d, 0x56, 0x8f, 0x39, 0x56, 0xda, 0x74, 0xa6, 0x28, 0x47, 0x51, 0x62, 0x29, 0x23, 0x24,
+	0xad, 0x6b, 0x94, 0xd2, 0x0c, 0x15, 0x40, 0xfb, 0x73, 0x8a, 0x12, 0x49, 0x5b, 0x41, 0xb7, 0x34,
+	0xf4, 0xa1, 0x92, 0x6c, 0xa7, 0x94, 0x7e, 0xca, 0x78, 0x42, 0x3a, 0xb6, 0xd3, 0x81, 0xd2, 0x0c,
+	0xb5, 0x0b, 0xdd, 0x44, 0x8a, 0x65, 0xbe, 0x7f, 0x4e, 0xba, 0x0a, 0xbb, 0xa3, 0xb1, 0x37, 0x5a,
+	0xb4, 0x4f, 0x14, 0x32, 0x46, 0x49, 0x5c, 0xfb, 0xc4, 0xf7, 0x95, 0x64, 0x98, 0x67, 0xe0, 0x9d,
+	0x8a, 0x8c, 0xbf, 0x43, 0x99, 0x20, 0xf1, 0x14, 0x77, 0x57, 0x73, 0x87, 0x2b, 0xd9, 0x3e, 0xb7,
+	0x62, 0xdf, 0xe2, 0x39, 0x01, 0xfb, 0xdc, 0x43, 0x2d, 0x1a, 0x6e, 0x0f, 0x20, 0x97, 0xe2, 0x14,
+	0xa3, 0x32, 0x13, 0x9c, 0xf4, 0x6a, 0x53, 0x89, 0x6c, 0x3c, 0x35, 0xb2, 0xf5, 0x64, 0x37, 0x4a,
+	0x33, 0x16, 0x4b, 0xe4, 0xa4, 0x3f, 0x6c, 0x8e, 0x7a, 0x93, 0xbe, 0x76, 0xd5, 0xd1, 0x68, 0x2a,
+	0xf8, 0x08, 0xee, 0xaa, 0xe8, 0xfe, 0xae, 0x09, 0xa5, 0x8a, 0xaa, 0x37, 0xd9, 0x54, 0xd6, 0xc7,
+	0x0b, 0xf6, 0x57, 0x2c, 0xbb, 0xd0, 0x8d, 0x04, 0x2f, 0xf1, 0xac, 0x24, 0x0d, 0xfb, 0xba, 0x2f,
+	0xb5, 0x68, 0xbc, 0x8f, 0xc0, 0x33, 0x92, 0xbf, 0x05, 0x9d, 0x22, 0x4a, 0x71, 0x4e, 0x95, 0xb9,
+	0x57, 0xf7, 0xc1, 0x26, 0x34, 0xb2, 0x98, 0x34, 0x86, 0x8d, 0x51, 0xab, 0x56, 0x1e, 0x40, 0xef,
+	0x24, 0xe3, 0x09, 0xca, 0x5c, 0x66, 0xbc, 0x6a, 0x0f, 0xb3, 0x14, 0xfc, 0x76, 0xc0, 0x5d, 0x65,
+	0xef, 0x0f, 0x60, 0x93, 0x23, 0xc6, 0xc5, 0x01, 0x2d, 0x52, 0x3a, 0x63, 0x58, 0x15, 0xaf, 0x61,
+	0x75, 0xd8, 0x3d, 0x68, 0x9f, 0x64, 0x9c, 0x32, 0xd2, 0xb4, 0xc4, 0x6d, 0x70, 0x23, 0x31, 0xcf,
+	0x19, 0x96, 0x55, 0x4b, 0x5d, 0xe9, 0x3e, 0xb4, 0xaa, 0x00, 0x48, 0xdb, 0xd2, 0x08, 0x80, 0x6e,
+	0xbe, 0x57, 0x67, 0x18, 0x91, 0x8e, 0xb5, 0xb2, 0x05, 0x9d, 0x68, 0x59, 0x94, 0x62, 0xae, 0xba,
+	0xa4, 0x5f, 0x57, 0xe5, 0x11, 0x78, 0xc5, 0x82, 0xe9, 0xfb, 0xd5, 0x8d, 0x71, 0x55, 0xc0, 0xd5,
+	0xad, 0x9f, 0x5c, 0x4b, 0xd0, 0xfb, 0x47, 0x82, 0xc1, 0x6b, 0xe8, 0xd6, 0xfd, 0x7b, 0x2d, 0x14,
+	0xe7, 0x86, 0x50, 0xcc, 0x7b, 0xad, 0x22, 0x04, 0x87, 0xe0, 0x99, 0xde, 0x5d, 0xdb, 0xe9, 0x3e,
+	0x74, 0x73, 0x2a, 0xcb, 0x4c, 0x79, 0x39, 0xc6, 0x6b, 0x02, 0xee, 0x6a, 0x5c, 0xd6, 0xb5, 0x0a,
+	0x5e, 0x40, 0xb7, 0x9e, 0x8a, 0xff, 0xd8, 0xd2, 0xb3, 0x06, 0xc4, 0x0f, 0xcc, 0xe0, 0xea, 0x6d,
+	0xfd, 0x71, 0xf5, 0x6f, 0x33, 0x3e, 0x12, 0xb1, 0x19, 0x9f, 0x20, 0x04, 0xcf, 0x4c, 0xca, 0x3a,
+	0x1b, 0xf6, 0xb7, 0x2e, 0x7e, 0x0e, 0x36, 0x2e, 0x2e, 0x07, 0xce, 0xb7, 0xcb, 0x81, 0xf3, 0xe3,
+	0x72, 0xe0, 0x7c, 0xf9, 0x35, 0xd8, 0xf8, 0x33, 0x00, 0xd2, 0x20, 0x7d, 0x9a, 0x50, 0x05, 0x00,
+	0x00,
 }
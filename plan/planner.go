@@ -4,11 +4,14 @@ import (
 	"fmt"
 
 	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/lex"
 )
 
 var (
 	// Ensure our default planner meets Planner interface.
-	_ Planner = (*PlannerDefault)(nil)
+	_ Planner      = (*PlannerDefault)(nil)
+	_ GrantPlanner = (*PlannerDefault)(nil)
 )
 
 // PlannerDefault is implementation of Planner that creates a dag of plan.Tasks
@@ -54,6 +57,14 @@ func (m *PlannerDefault) WalkDrop(p *Drop) error {
 // WalkCreate walk a Create Plan to create the dag of tasks for Create.
 func (m *PlannerDefault) WalkCreate(p *Create) error {
 	u.Debugf("WalkCreate %#v", p)
+	switch p.Stmt.Tok.T {
+	case lex.TokenFunction, lex.TokenPolicy, lex.TokenUser, lex.TokenRole:
+		// CREATE FUNCTION, POLICY, USER, ROLE have no WITH
+		return nil
+	case lex.TokenView:
+		// CREATE MATERIALIZED VIEW, WITH is optional
+		return nil
+	}
 	if len(p.Stmt.With) == 0 {
 		return fmt.Errorf("CREATE {SCHEMA|SOURCE|DATABASE}")
 	}
@@ -65,3 +76,9 @@ func (m *PlannerDefault) WalkAlter(p *Alter) error {
 	u.Debugf("WalkAlter %#v", p)
 	return nil
 }
+
+// WalkGrant walk a GRANT or REVOKE Plan.
+func (m *PlannerDefault) WalkGrant(p *Grant) error {
+	u.Debugf("WalkGrant %+v", p.Stmt)
+	return nil
+}
@@ -29,6 +29,7 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 	// u.Debugf("VisitSelect ctx:%p  %+v", p.Ctx, p.Stmt)
 
 	needsFinalProject := true
+	partitioned := false
 
 	if len(p.Stmt.From) == 0 {
 
@@ -43,17 +44,30 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 			return err
 		}
 		p.From = append(p.From, srcPlan)
-		p.Add(srcPlan)
 
 		err = m.Planner.WalkSourceSelect(srcPlan)
 		if err != nil {
 			return err
 		}
 
-		if srcPlan.Complete && !needsFinalProjection(p.Stmt) {
+		// The source pushed down the statement so skip the remaining plan
+		// steps, group by, having and order only if it says it did them.
+		if srcPlan.Complete && (!needsFinalProjection(p.Stmt) || selectComplete(srcPlan)) {
+			p.Add(srcPlan)
 			goto finalProjection
 		}
 
+		partitions, err := m.walkPartitions(p, srcPlan)
+		if err != nil {
+			return err
+		}
+		if partitions != nil {
+			p.Add(partitions)
+			partitioned = true
+		} else {
+			p.Add(srcPlan)
+		}
+
 	} else {
 
 		var prevSource *Source
@@ -90,7 +104,7 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 
 	}
 
-	if p.Stmt.Where != nil {
+	if p.Stmt.Where != nil && !partitioned {
 		switch {
 		case p.Stmt.Where.Source != nil:
 			// SELECT id from article WHERE id in (select article_id from comments where comment_ct > 50);
@@ -106,7 +120,11 @@ func (m *PlannerDefault) WalkSelect(p *Select) error {
 
 	if p.Stmt.IsAggQuery() {
 		//u.Debugf("Adding aggregate/group by? %#v", m.Planner)
-		p.Add(NewGroupBy(p.Stmt))
+		if partitioned {
+			p.Add(NewGroupByFinal(p.Stmt))
+		} else {
+			p.Add(NewGroupBy(p.Stmt))
+		}
 		needsFinalProject = false
 	}
 
@@ -140,6 +158,78 @@ finalProjection:
 	return nil
 }
 
+// selectComplete did the source of a Complete plan also group, filter
+// having and order the statement?
+func selectComplete(p *Source) bool {
+	sc, ok := p.Conn.(SourceSelectComplete)
+	return ok && sc.SelectComplete()
+}
+
+// walkPartitions splits the scan of a single source whose Conn is
+// schema.SourcePartitionable into a parallel scan per partition, each
+// running the where and partial group by.  Returns nil if the source
+// is not partitioned.
+func (m *PlannerDefault) walkPartitions(p *Select, src *Source) (*PartitionMerge, error) {
+
+	pc, ok := src.Conn.(schema.SourcePartitionable)
+	if !ok {
+		return nil, nil
+	}
+	// Sources that plan their own select get the whole statement pushed down
+	// and are not partitioned, ie sqlite, whose conns also each hold the
+	// source lock until closed.
+	if _, ok := src.Conn.(SourcePlanner); ok {
+		return nil, nil
+	}
+	if p.Stmt.Where != nil && p.Stmt.Where.Expr == nil {
+		return nil, nil
+	}
+	partitions := pc.Partitions()
+	if len(partitions) < 2 {
+		return nil, nil
+	}
+
+	parts := make([]Task, 0, len(partitions))
+	for _, partition := range partitions {
+		conn, err := pc.PartitionSource(partition)
+		if err != nil {
+			u.Errorf("could not open partition %v of %q err=%v", partition.Id, src.Stmt.SourceName(), err)
+			for _, part := range parts {
+				part.(*Source).Conn.Close()
+			}
+			return nil, err
+		}
+		part := &Source{
+			PlanBase:   NewPlanBase(false),
+			SourcePb:   &SourcePb{Final: true},
+			Stmt:       src.Stmt,
+			Proj:       src.Proj,
+			Custom:     src.Custom,
+			ctx:        src.ctx,
+			DataSource: src.DataSource,
+			Conn:       conn,
+			Schema:     src.Schema,
+			Tbl:        src.Tbl,
+			Partition:  partition,
+		}
+		if p.Stmt.Where != nil {
+			part.Add(NewWhere(p.Stmt))
+		}
+		if p.Stmt.IsAggQuery() {
+			part.Add(NewGroupByPartial(p.Stmt))
+		}
+		parts = append(parts, part)
+	}
+
+	// The partitions each have their own conn, the source's is not scanned.
+	if err := src.Conn.Close(); err != nil {
+		u.Warnf("could not close %q conn err=%v", src.Stmt.SourceName(), err)
+	}
+	src.Conn = nil
+
+	return NewPartitionMerge(parts), nil
+}
+
 // WalkProjectionFinal walk the select plan to create final projection.
 func (m *PlannerDefault) WalkProjectionFinal(p *Select) error {
 	// Add a Final Projection to choose the columns for results
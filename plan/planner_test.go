@@ -1,12 +1,16 @@
 package plan_test
 
 import (
+	"database/sql/driver"
 	"testing"
 
 	u "github.com/araddon/gou"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/araddon/qlbridge/datasource/memdb"
 	td "github.com/araddon/qlbridge/datasource/mockcsvtestdata"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
 )
 
 type plantest struct {
@@ -37,3 +41,64 @@ func TestPlans(t *testing.T) {
 
 	}
 }
+
+// completeSource plans its own select, always Complete, and says it did
+// the group by and order by if final.
+type completeSource struct {
+	*memdb.MemDb
+	final bool
+}
+type completeConn struct {
+	src *completeSource
+}
+
+func (m *completeSource) Open(table string) (schema.Conn, error) { return &completeConn{m}, nil }
+func (m *completeConn) Close() error                             { return nil }
+func (m *completeConn) SelectComplete() bool                     { return m.src.final }
+func (m *completeConn) WalkSourceSelect(pl plan.Planner, p *plan.Source) (plan.Task, error) {
+	p.Complete = true
+	return nil, nil
+}
+
+func TestPlanSourceComplete(t *testing.T) {
+	db, err := memdb.NewMemDbData("events", [][]driver.Value{{1, "a"}}, []string{"id", "name"})
+	assert.Equal(t, nil, err)
+	src := &completeSource{MemDb: db}
+	assert.Equal(t, nil, schema.RegisterSourceAsSchema("plan_complete", src))
+	s, ok := schema.DefaultRegistry().Schema("plan_complete")
+	assert.True(t, ok)
+
+	tasks := func(sql string) []string {
+		ctx := plan.NewContext(sql)
+		ctx.DisableRecover = true
+		ctx.Schema = s
+		names := make([]string, 0)
+		for _, task := range selectPlan(t, ctx).Children() {
+			switch task.(type) {
+			case *plan.Source:
+				names = append(names, "source")
+			case *plan.Where:
+				names = append(names, "where")
+			case *plan.GroupBy:
+				names = append(names, "groupby")
+			case *plan.Order:
+				names = append(names, "order")
+			case *plan.Projection:
+				names = append(names, "projection")
+			}
+		}
+		return names
+	}
+
+	// the where pushed down is enough to skip the remaining steps
+	assert.Equal(t, []string{"source"}, tasks("SELECT id, name FROM events WHERE id > 0"))
+	// group by and order by still run after the source
+	assert.Equal(t, []string{"source", "where", "groupby", "order"},
+		tasks("SELECT name, count(*) AS ct FROM events WHERE id > 0 GROUP BY name ORDER BY ct"))
+	assert.Equal(t, []string{"source", "order", "projection"}, tasks("SELECT id FROM events ORDER BY id"))
+
+	// unless the source says it did them
+	src.final = true
+	assert.Equal(t, []string{"source"},
+		tasks("SELECT name, count(*) AS ct FROM events GROUP BY name ORDER BY ct"))
+}
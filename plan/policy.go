@@ -0,0 +1,343 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/value"
+)
+
+// applyPolicies rewrite the statement with the policies of the tables it
+// reads or changes, before it is planned.  Row filters are AND'd into the
+// WHERE of SELECT, INSERT ... SELECT, UPDATE and DELETE, masked columns are
+// replaced by their mask in the projected columns of SELECT.  session.<name>
+// is the name attribute of Context.Identity.
+//
+//    CREATE POLICY tenant_only ON events USING tenant_id = session.tenant_id
+//
+//    SELECT * FROM events WHERE action = "click"
+//    SELECT * FROM events WHERE (action = "click") AND (tenant_id = 7)
+//
+func applyPolicies(ctx *Context, stmt rel.SqlStatement) error {
+	if ctx.Schema == nil {
+		return nil
+	}
+	switch st := stmt.(type) {
+	case *rel.SqlSelect:
+		return applySelectPolicies(ctx, st)
+	case *rel.SqlInsert:
+		if st.Select != nil {
+			return applySelectPolicies(ctx, st.Select)
+		}
+	case *rel.SqlUpdate:
+		filter, err := policyFilter(ctx, ctx.Schema.TablePolicies(st.Table), "")
+		if err != nil || filter == nil {
+			return err
+		}
+		st.Where, err = policyWhere(st.Where, filter)
+		return err
+	case *rel.SqlDelete:
+		filter, err := policyFilter(ctx, ctx.Schema.TablePolicies(st.Table), "")
+		if err != nil || filter == nil {
+			return err
+		}
+		st.Where, err = policyWhere(st.Where, filter)
+		return err
+	}
+	return nil
+}
+
+func applySelectPolicies(ctx *Context, sel *rel.SqlSelect) error {
+
+	if sel.Where != nil && sel.Where.Source != nil {
+		if err := applySelectPolicies(ctx, sel.Where.Source); err != nil {
+			return err
+		}
+	}
+
+	// columns of joined tables are qualified by the alias of their source
+	multi := len(sel.From) > 1
+	var filters []expr.Node
+	masks := make(map[string]expr.Node)
+	for _, from := range sel.From {
+		if from.SubQuery != nil {
+			// the planner reads the table of a sub-query directly with the
+			// WHERE of this statement, so filter both
+			if err := applySelectPolicies(ctx, from.SubQuery); err != nil {
+				return err
+			}
+		}
+		table := strings.ToLower(from.SourceName())
+		policies := ctx.Schema.TablePolicies(table)
+		if len(policies) == 0 {
+			continue
+		}
+		alias := strings.ToLower(from.Alias)
+		if alias == "" {
+			alias = strings.ToLower(from.Name)
+		}
+		qualify := ""
+		if multi {
+			qualify = alias
+		}
+		filter, err := policyFilter(ctx, policies, qualify)
+		if err != nil {
+			return err
+		}
+		if filter != nil {
+			filters = append(filters, filter)
+		}
+		for _, p := range policies {
+			if !p.IsMask() {
+				continue
+			}
+			w := &policyWriter{DialectWriter: expr.NewDefaultWriter(), alias: qualify}
+			p.Expr.WriteDialect(w)
+			mask, err := parsePolicyExpr(ctx, w.String())
+			if err != nil {
+				return err
+			}
+			// unqualified columns of a join are masked if any table masks them
+			masks[p.Column] = mask
+			masks[alias+"."+p.Column] = mask
+			if !multi {
+				masks[table+"."+p.Column] = mask
+			}
+		}
+	}
+
+	for _, filter := range filters {
+		where, err := policyWhere(sel.Where, filter)
+		if err != nil {
+			return err
+		}
+		sel.Where = where
+	}
+	if len(masks) > 0 {
+		return maskColumns(ctx, sel, masks)
+	}
+	return nil
+}
+
+// policyFilter the row filters of the policies AND'd together, nil if there
+// are none.
+func policyFilter(ctx *Context, policies []*schema.Policy, alias string) (expr.Node, error) {
+	parts := make([]string, 0, len(policies))
+	for _, p := range policies {
+		if p.IsMask() {
+			continue
+		}
+		w := &policyWriter{DialectWriter: expr.NewDefaultWriter(), alias: alias}
+		p.Expr.WriteDialect(w)
+		parts = append(parts, "("+w.String()+")")
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	return parsePolicyExpr(ctx, strings.Join(parts, " AND "))
+}
+
+// policyWhere AND the filter into where.
+func policyWhere(where *rel.SqlWhere, filter expr.Node) (*rel.SqlWhere, error) {
+	if where == nil {
+		return &rel.SqlWhere{Expr: filter}, nil
+	}
+	if where.Expr == nil {
+		return nil, fmt.Errorf("policies not supported for WHERE %s", where)
+	}
+	if bn, ok := where.Expr.(*expr.BinaryNode); ok {
+		bn.Paren = true
+	}
+	where.Expr = expr.NewBinaryNode(lex.Token{T: lex.TokenLogicAnd, V: "AND"}, where.Expr, filter)
+	return where, nil
+}
+
+// maskColumns replace the masked columns in the projected columns of the
+// select by their masks, keeping the column names.  SELECT * is expanded
+// into the columns of the tables first.
+func maskColumns(ctx *Context, sel *rel.SqlSelect, masks map[string]expr.Node) error {
+
+	if sel.Star {
+		cols := make(rel.Columns, 0, len(sel.Columns))
+		for _, col := range sel.Columns {
+			if !col.Star {
+				cols = append(cols, col)
+				continue
+			}
+			starCols, err := expandStar(ctx, sel)
+			if err != nil {
+				return err
+			}
+			cols = append(cols, starCols...)
+		}
+		for i, col := range cols {
+			col.Index = i
+		}
+		sel.Columns = cols
+		sel.Star = false
+	}
+
+	for _, col := range sel.Columns {
+		if col.Expr == nil {
+			continue
+		}
+		col.Expr = replaceIdentities(col.Expr, func(in *expr.IdentityNode) expr.Node {
+			key := strings.ToLower(in.Text)
+			if left, right, ok := in.LeftRight(); ok {
+				key = strings.ToLower(left) + "." + strings.ToLower(right)
+			}
+			return masks[key]
+		})
+	}
+	return nil
+}
+
+// expandStar the columns of the tables of the select as they are for *.
+func expandStar(ctx *Context, sel *rel.SqlSelect) (rel.Columns, error) {
+	multi := len(sel.From) > 1
+	names := make([]string, 0)
+	for _, from := range sel.From {
+		tbl, err := ctx.Schema.Table(strings.ToLower(from.SourceName()))
+		if err != nil || len(tbl.Columns()) == 0 {
+			return nil, fmt.Errorf("could not expand * of %q to mask its columns", from.SourceName())
+		}
+		alias := from.Alias
+		if alias == "" {
+			alias = from.Name
+		}
+		for _, name := range tbl.Columns() {
+			if multi {
+				names = append(names, fmt.Sprintf("%s.%s AS %s", expr.IdentityMaybeQuote('`', alias),
+					expr.IdentityMaybeQuote('`', name), expr.IdentityMaybeQuote('`', name)))
+			} else {
+				names = append(names, expr.IdentityMaybeQuote('`', name))
+			}
+		}
+	}
+	fr := ctx.Funcs
+	if fr == nil {
+		fr = ctx.Schema
+	}
+	star, err := rel.ParseSqlSelectResolver(fmt.Sprintf("SELECT %s FROM t", strings.Join(names, ", ")), fr)
+	if err != nil {
+		return nil, err
+	}
+	return star.Columns, nil
+}
+
+// parsePolicyExpr parse a rendered policy expression binding its session
+// identities.
+func parsePolicyExpr(ctx *Context, text string) (expr.Node, error) {
+	var fr expr.FuncResolver = ctx.Schema
+	if ctx.Funcs != nil {
+		fr = ctx.Funcs
+	}
+	n, err := expr.ParseExprWithFuncs(expr.NewLexTokenPager(lex.NewLexer(text, lex.LogicalExpressionDialect)), fr)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse policy %q: %v", text, err)
+	}
+	return bindSession(n, ctx.Identity), nil
+}
+
+// policyWriter renders the expression of a policy for a statement, its
+// unqualified columns are qualified by alias if set.
+type policyWriter struct {
+	expr.DialectWriter
+	alias string
+}
+
+func (w *policyWriter) WriteIdentity(i string)              { w.WriteLeftRightIdentity("", i) }
+func (w *policyWriter) WriteIdentityQuote(i string, _ byte) { w.WriteLeftRightIdentity("", i) }
+func (w *policyWriter) WriteLeftRightIdentity(l, r string) {
+	if l == "" && w.alias != "" {
+		switch strings.ToLower(r) {
+		case "true", "false", "null", "*":
+		default:
+			l = w.alias
+		}
+	}
+	w.DialectWriter.WriteLeftRightIdentity(l, r)
+}
+
+// bindSession replace the session.<name> identities of n by the values
+// of the identity of the connection, Context.Identity, not its session
+// variables which any client can SET.  A missing value is NULL, so that a
+// row filter comparing to it matches nothing.
+func bindSession(n expr.Node, identity expr.ContextReader) expr.Node {
+	return replaceIdentities(n, func(in *expr.IdentityNode) expr.Node {
+		if left, right, ok := in.LeftRight(); ok && strings.EqualFold(left, "session") {
+			return sessionNode(identityValue(identity, strings.ToLower(right)))
+		}
+		return nil
+	})
+}
+
+// replaceIdentities walk n replacing its identities by the node fn returns
+// for them, if not nil.
+func replaceIdentities(n expr.Node, fn func(*expr.IdentityNode) expr.Node) expr.Node {
+	switch nt := n.(type) {
+	case *expr.IdentityNode:
+		if rn := fn(nt); rn != nil {
+			return rn
+		}
+	case *expr.BinaryNode:
+		replaceIdentityArgs(nt.Args, fn)
+	case *expr.BooleanNode:
+		replaceIdentityArgs(nt.Args, fn)
+	case *expr.TriNode:
+		replaceIdentityArgs(nt.Args, fn)
+	case *expr.FuncNode:
+		replaceIdentityArgs(nt.Args, fn)
+	case *expr.ArrayNode:
+		replaceIdentityArgs(nt.Args, fn)
+	case *expr.UnaryNode:
+		nt.Arg = replaceIdentities(nt.Arg, fn)
+	case *expr.CaseNode:
+		if nt.Operand != nil {
+			nt.Operand = replaceIdentities(nt.Operand, fn)
+		}
+		replaceIdentityArgs(nt.Whens, fn)
+		replaceIdentityArgs(nt.Thens, fn)
+		if nt.Else != nil {
+			nt.Else = replaceIdentities(nt.Else, fn)
+		}
+	}
+	return n
+}
+
+func replaceIdentityArgs(args []expr.Node, fn func(*expr.IdentityNode) expr.Node) {
+	for i, arg := range args {
+		args[i] = replaceIdentities(arg, fn)
+	}
+}
+
+// identityValue the value of name in the identity, nil if not found.
+func identityValue(identity expr.ContextReader, name string) value.Value {
+	if identity == nil {
+		return nil
+	}
+	if v, ok := identity.Get(name); ok && v != nil && !v.Nil() {
+		return v
+	}
+	return nil
+}
+
+// sessionNode the literal node of an identity value.
+func sessionNode(v value.Value) expr.Node {
+	if v == nil || v.Nil() {
+		return &expr.NullNode{}
+	}
+	switch vt := v.(type) {
+	case value.IntValue, value.NumberValue:
+		if n, err := expr.NewNumberStr(vt.ToString()); err == nil {
+			return n
+		}
+	case value.BoolValue:
+		return expr.NewIdentityNodeVal(vt.ToString())
+	}
+	return expr.NewStringNode(v.ToString())
+}
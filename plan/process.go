@@ -0,0 +1,108 @@
+package plan
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Processes is the process table of the running jobs, as listed
+// by SHOW PROCESSLIST and stopped by KILL.
+var Processes = NewProcessList()
+
+// Process a running job in the process table.
+type Process struct {
+	Id      uint64
+	User    string // user of the context, empty if none
+	Schema  string
+	Info    string // sql statement
+	Started time.Time
+	Killed  bool
+	ctx     *Context
+}
+
+// State of the process, as shown in SHOW PROCESSLIST.
+func (m *Process) State() string {
+	if m.Killed {
+		return "killed"
+	}
+	return "executing"
+}
+
+// ProcessList the table of running processes, ids are assigned
+// in order starting at 1.
+type ProcessList struct {
+	mu     sync.Mutex
+	lastId uint64
+	procs  map[uint64]*Process
+}
+
+// NewProcessList creates an empty process table.
+func NewProcessList() *ProcessList {
+	return &ProcessList{procs: make(map[uint64]*Process)}
+}
+
+// Add the job of this context to the process table, the context
+// should be cancelable (see Context.WithTimeout) for it to be killed.
+func (m *ProcessList) Add(ctx *Context) *Process {
+	p := &Process{
+		User:    ctx.User,
+		Schema:  ctx.SchemaName,
+		Info:    ctx.Raw,
+		Started: time.Now(),
+		ctx:     ctx,
+	}
+	if p.Schema == "" && ctx.Schema != nil {
+		p.Schema = ctx.Schema.Name
+	}
+	m.mu.Lock()
+	m.lastId++
+	p.Id = m.lastId
+	m.procs[p.Id] = p
+	m.mu.Unlock()
+	return p
+}
+
+// Remove a finished process.
+func (m *ProcessList) Remove(id uint64) {
+	m.mu.Lock()
+	delete(m.procs, id)
+	m.mu.Unlock()
+}
+
+// Get a copy of the process.
+func (m *ProcessList) Get(id uint64) (Process, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.procs[id]
+	if !ok {
+		return Process{}, false
+	}
+	return *p, true
+}
+
+// Kill cancels the context of the process, its job stops.
+func (m *ProcessList) Kill(id uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p, ok := m.procs[id]
+	if !ok {
+		return fmt.Errorf("Unknown thread id: %d", id)
+	}
+	p.Killed = true
+	p.ctx.Cancel()
+	return nil
+}
+
+// List copies of the running processes ordered by id.
+func (m *ProcessList) List() []Process {
+	m.mu.Lock()
+	procs := make([]Process, 0, len(m.procs))
+	for _, p := range m.procs {
+		procs = append(procs, *p)
+	}
+	m.mu.Unlock()
+	sort.Slice(procs, func(i, j int) bool { return procs[i].Id < procs[j].Id })
+	return procs
+}
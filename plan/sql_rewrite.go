@@ -199,6 +199,10 @@ func RewriteShowAsSelect(stmt *rel.SqlShow, ctx *Context) (*rel.SqlSelect, error
 		*/
 		sqlStatement = fmt.Sprintf("SELECT Db, Name, Type, Definer, Modified, Created, Security_type, Comment, character_set_client, `collation_connection`, `Database Collation` from `context`.`%ss`;", showType)
 
+	case "processlist":
+		// SHOW [FULL] PROCESSLIST
+		sqlStatement = "select Id, User, Host, db, Command, Time, State, Info from `context`.`processlist`;"
+
 	default:
 		u.Warnf("unhandled sql rewrite statement %s", raw)
 		return nil, fmt.Errorf("Unrecognized:   %s", raw)
@@ -212,16 +216,6 @@ func RewriteShowAsSelect(stmt *rel.SqlShow, ctx *Context) (*rel.SqlSelect, error
 	if stmt.Like != nil {
 		// We are going to ReWrite LIKE clause to WHERE clause
 		sel.Where = &rel.SqlWhere{Expr: stmt.Like}
-		bn, ok := stmt.Like.(*expr.BinaryNode)
-		if ok {
-			rhn, ok := bn.Args[1].(*expr.StringNode)
-			// See if the Like Clause has wildcard matching, if so
-			// our internal vm uses * not %
-			if ok && rhn.Text == "%" {
-				rhn.Text = strings.Replace(rhn.Text, "%", "*", -1)
-			}
-		}
-
 	} else if stmt.Where != nil {
 		//u.Debugf("add where: %s", stmt.Where)
 		sel.Where = &rel.SqlWhere{Expr: stmt.Where}
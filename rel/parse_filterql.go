@@ -406,7 +406,7 @@ func (m *FilterQLParser) parseWhereExpr(req *FilterSelect) error {
 		return err
 	}
 
-	req.Where = n
+	req.Where = globLikePatterns(n)
 	return nil
 }
 
@@ -440,7 +440,35 @@ func (m *FilterQLParser) parseFirstFilters() (expr.Node, error) {
 		return nil, err
 	}
 
-	return n, nil
+	return globLikePatterns(n), nil
+}
+
+// globLikePatterns FilterQL LIKE patterns are globs, * or % match any run
+// of characters, ? a single one, and _ is literal.  The pattern is kept as
+// written and the node marked Glob, evaluators and generators translate it
+// with LikePattern.  A LIKE with an ESCAPE clause is already ANSI.
+//
+//    name LIKE "*da?"   matches as   name LIKE "%da_"
+//
+func globLikePatterns(n expr.Node) expr.Node {
+	switch nt := n.(type) {
+	case *expr.BinaryNode:
+		switch nt.Operator.T {
+		case lex.TokenLike, lex.TokenILike:
+			nt.Glob = true
+		default:
+			for _, arg := range nt.Args {
+				globLikePatterns(arg)
+			}
+		}
+	case *expr.BooleanNode:
+		for _, arg := range nt.Args {
+			globLikePatterns(arg)
+		}
+	case *expr.UnaryNode:
+		globLikePatterns(nt.Arg)
+	}
+	return n
 }
 
 func (m *FilterQLParser) parseLimit() (int, error) {
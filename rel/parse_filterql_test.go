@@ -225,6 +225,43 @@ func TestFilterQlRoundTrip(t *testing.T) {
 	}
 }
 
+func TestFilterQlLikeGlob(t *testing.T) {
+	t.Parallel()
+	// FilterQL LIKE patterns are globs, kept as written and matched as ANSI LIKE
+	for _, tc := range []struct{ fql, pattern string }{
+		{`FILTER name LIKE "*da"`, `%da`},
+		{`FILTER name LIKE "%da"`, `%da`},
+		{`FILTER name ILIKE "Yo?a"`, `Yo_a`},
+		{`FILTER name LIKE "a_b\*"`, `a\_b*`},
+		{`FILTER NOT AND (x > 1, name LIKE "*.com")`, `%.com`},
+		{`FILTER name LIKE "a*!%" ESCAPE "!"`, `a*!%`},
+	} {
+		req, err := rel.ParseFilterQL(tc.fql)
+		assert.Equal(t, nil, err, tc.fql)
+		var pattern string
+		var walk func(n expr.Node)
+		walk = func(n expr.Node) {
+			switch nt := n.(type) {
+			case *expr.BinaryNode:
+				if nt.Operator.T == lex.TokenLike || nt.Operator.T == lex.TokenILike {
+					assert.True(t, nt.Glob, tc.fql)
+					pattern = nt.LikePattern(nt.Args[1].(*expr.StringNode).Text)
+				}
+			case *expr.TriNode:
+				pattern = nt.Args[1].(*expr.StringNode).Text
+			case *expr.BooleanNode:
+				for _, arg := range nt.Args {
+					walk(arg)
+				}
+			case *expr.UnaryNode:
+				walk(nt.Arg)
+			}
+		}
+		walk(req.Filter)
+		assert.Equal(t, tc.pattern, pattern, tc.fql)
+	}
+}
+
 func TestFilterQlFingerPrint(t *testing.T) {
 	t.Parallel()
 
@@ -17,6 +17,16 @@ var (
 	SqlKeywords = []string{"select", "insert", "update", "delete", "from", "where", "as", "into", "limit",
 		"exists", "in", "contains", "include", "not", "and", "having", "or", "null", "group", "order",
 		"offset", "include", "all", "any", "some"}
+
+	// privileges of GRANT and REVOKE
+	grantPrivileges = map[string]lex.TokenType{
+		"select": lex.TokenSelect,
+		"insert": lex.TokenInsert,
+		"update": lex.TokenUpdate,
+		"delete": lex.TokenDelete,
+		"drop":   lex.TokenDrop,
+		"all":    lex.TokenAll,
+	}
 )
 
 // ParseError type
@@ -27,9 +37,12 @@ type ParseError struct {
 // ParseSql Parses SqlStatement and returns a statement or error
 // does not parse more than one statement
 func ParseSql(sqlQuery string) (SqlStatement, error) {
-	return parseSqlResolver(sqlQuery, nil)
+	return ParseSqlResolver(sqlQuery, nil)
 }
-func parseSqlResolver(sqlQuery string, fr expr.FuncResolver) (SqlStatement, error) {
+
+// ParseSqlResolver Parses SqlStatement using function resolver for functions
+// not in the global registry.
+func ParseSqlResolver(sqlQuery string, fr expr.FuncResolver) (SqlStatement, error) {
 	l := lex.NewSqlLexer(sqlQuery)
 	m := Sqlbridge{l: l, SqlTokenPager: NewSqlTokenPager(l), funcs: fr}
 	s, err := m.parse()
@@ -54,7 +67,7 @@ func ParseSqlSelect(sqlQuery string) (*SqlSelect, error) {
 
 // ParseSqlSelectResolver parse as SELECT using function resolver.
 func ParseSqlSelectResolver(sqlQuery string, fr expr.FuncResolver) (*SqlSelect, error) {
-	stmt, err := parseSqlResolver(sqlQuery, fr)
+	stmt, err := ParseSqlResolver(sqlQuery, fr)
 	if err != nil {
 		return nil, err
 	}
@@ -121,10 +134,16 @@ func (m *Sqlbridge) parse() (SqlStatement, error) {
 		return m.parseCommand()
 	case lex.TokenRollback, lex.TokenCommit:
 		return m.parseTransaction()
+	case lex.TokenKill:
+		return m.parseKill()
+	case lex.TokenRefresh:
+		return m.parseRefresh()
 	case lex.TokenCreate:
 		return m.parseCreate()
 	case lex.TokenDrop:
 		return m.parseDrop()
+	case lex.TokenGrant, lex.TokenRevoke:
+		return m.parseGrant()
 	}
 	return nil, fmt.Errorf("Unrecognized request type: %v", m.l.PeekWord())
 }
@@ -558,6 +577,8 @@ func (m *Sqlbridge) parseShow() (*SqlShow, error) {
 		SHOW [STORAGE] ENGINES
 		SHOW INDEX FROM tbl_name [FROM db_name]
 		SHOW [FULL] TABLES [FROM db_name] [like_or_where]
+		SHOW [FULL] PROCESSLIST
+		SHOW FUNCTIONS [like_or_where]
 		SHOW TRIGGERS [FROM db_name] [like_or_where]
 		SHOW [GLOBAL | SESSION] VARIABLES [like_or_where]
 		SHOW [GLOBAL | SESSION | SLAVE] STATUS [like_or_where]
@@ -620,6 +641,16 @@ func (m *Sqlbridge) parseShow() (*SqlShow, error) {
 		req.ShowType = objectType
 		likeLhs = "Name"
 		m.Next()
+	case "functions":
+		// SHOW FUNCTIONS [like_or_where]
+		req.ShowType = "function"
+		likeLhs = "Name"
+		m.Next()
+	case "processlist":
+		// SHOW [FULL] PROCESSLIST
+		req.ShowType = objectType
+		likeLhs = "Info"
+		m.Next()
 	case "columns":
 		m.Next() // consume columns
 		likeLhs = "Field"
@@ -716,7 +747,14 @@ func (m *Sqlbridge) parseCreate() (*SqlCreate, error) {
 		}
 		req.OrReplace = true
 	}
-	// CREATE {DATABASE|SCHEMA|TABLE|VIEW|SOURCE|CONTINUOUSVIEW} <identity>
+	if m.Cur().T == lex.TokenMaterialized {
+		m.Next() // Consume MATERIALIZED
+		if m.Cur().T != lex.TokenView {
+			return nil, m.ErrMsg("Expected CREATE [OR REPLACE] MATERIALIZED VIEW <identity> AS <select_stmt>")
+		}
+		req.Materialized = true
+	}
+	// CREATE {DATABASE|SCHEMA|TABLE|VIEW|SOURCE|CONTINUOUSVIEW|FUNCTION|POLICY|USER|ROLE} <identity>
 	switch m.Cur().T {
 	case lex.TokenTable, lex.TokenSource, lex.TokenDatabase, lex.TokenSchema:
 		req.Tok = m.Next()
@@ -741,10 +779,34 @@ func (m *Sqlbridge) parseCreate() (*SqlCreate, error) {
 		if err != nil {
 			return nil, err
 		}
+		if req.Materialized {
+			// WITH is the storage of the view not part of its select
+			req.With, sel.With = sel.With, nil
+		}
 		req.Select = sel
 		return req, nil
+	case lex.TokenFunction:
+		req.Tok = m.Next()
+		return req, m.parseCreateFunction(req)
+	case lex.TokenPolicy:
+		req.Tok = m.Next()
+		return req, m.parseCreatePolicy(req)
+	case lex.TokenUser, lex.TokenRole:
+		req.Tok = m.Next()
+		if m.Cur().T != lex.TokenIdentity {
+			return nil, m.ErrMsg("Expected CREATE {USER|ROLE} <identity>")
+		}
+		req.Identity = strings.ToLower(m.Next().V)
+		// CREATE USER alice WITH password = "secret"
+		discardComments(m)
+		with, err := ParseWith(m.SqlTokenPager)
+		if err != nil {
+			return nil, err
+		}
+		req.With = with
+		return req, nil
 	default:
-		return nil, m.ErrMsg("Expected view, table, source, schema, database, continuousview for CREATE got")
+		return nil, m.ErrMsg("Expected view, table, source, schema, database, continuousview, function, policy, user, role for CREATE got")
 	}
 
 	// [IF NOT EXISTS]
@@ -811,6 +873,122 @@ func (m *Sqlbridge) parseCreate() (*SqlCreate, error) {
 	return req, nil
 }
 
+// CREATE [OR REPLACE] FUNCTION <identity>(<arg>, ...) AS <expression>
+// CREATE [OR REPLACE] FUNCTION <identity>(<type>, ...) RETURNS <type> LANGUAGE <language> [FROM 'source'] [WITH]
+func (m *Sqlbridge) parseCreateFunction(req *SqlCreate) error {
+
+	errMsg := "Expected CREATE [OR REPLACE] FUNCTION <identity>(<arg>, ...) AS <expression>"
+	if m.Cur().T != lex.TokenUdfExpr {
+		return m.ErrMsg(errMsg)
+	}
+	req.Identity = strings.ToLower(m.Next().V)
+	if m.Next().T != lex.TokenLeftParenthesis {
+		return m.ErrMsg(errMsg)
+	}
+	params := make([]string, 0)
+	for m.Cur().T != lex.TokenRightParenthesis {
+		switch m.Cur().T {
+		case lex.TokenIdentity:
+			params = append(params, m.Next().V)
+		case lex.TokenComma:
+			m.Next()
+		default:
+			return m.ErrMsg(errMsg)
+		}
+	}
+	m.Next() // Consume )
+
+	if m.Cur().T == lex.TokenReturns {
+		return m.parseCreateExternalFunction(req, params)
+	}
+	if m.Next().T != lex.TokenAs {
+		return m.ErrMsg(errMsg)
+	}
+	body, err := expr.ParseExprWithFuncs(m.SqlTokenPager, m.funcs)
+	if err != nil {
+		return err
+	}
+	req.Func = expr.NewUserFunc(req.Identity, params, body)
+	return nil
+}
+
+// RETURNS <type> LANGUAGE <language> [FROM 'source'] [WITH], the params of
+// an external function are its argument types.
+func (m *Sqlbridge) parseCreateExternalFunction(req *SqlCreate, params []string) error {
+
+	errMsg := "Expected CREATE [OR REPLACE] FUNCTION <identity>(<type>, ...) RETURNS <type> LANGUAGE <language>"
+	args := make([]value.ValueType, len(params))
+	for i, p := range params {
+		vt := value.ValueFromString(strings.ToLower(p))
+		if vt == value.UnknownType {
+			return fmt.Errorf("unknown type %q for argument %d of %s", p, i+1, req.Identity)
+		}
+		args[i] = vt
+	}
+	m.Next() // Consume RETURNS
+	if m.Cur().T != lex.TokenIdentity {
+		return m.ErrMsg(errMsg)
+	}
+	returns := value.ValueFromString(strings.ToLower(m.Cur().V))
+	if returns == value.UnknownType {
+		return fmt.Errorf("unknown return type %q of %s", m.Cur().V, req.Identity)
+	}
+	m.Next()
+	if m.Next().T != lex.TokenLanguage || m.Cur().T != lex.TokenIdentity {
+		return m.ErrMsg(errMsg)
+	}
+	language := m.Next().V
+	from := ""
+	if m.Cur().T == lex.TokenFrom {
+		m.Next() // Consume FROM
+		switch m.Cur().T {
+		case lex.TokenValue, lex.TokenValueEscaped:
+			from = m.Next().V
+		default:
+			return m.ErrMsg(errMsg)
+		}
+	}
+	discardComments(m)
+	with, err := ParseWith(m.SqlTokenPager)
+	if err != nil {
+		return err
+	}
+	req.With = with
+	req.Func = expr.NewExternalFunc(req.Identity, args, returns, language, from)
+	req.Func.With = with
+	return nil
+}
+
+// CREATE [OR REPLACE] POLICY <identity> ON <table> [MASK <column>] USING <expression>
+func (m *Sqlbridge) parseCreatePolicy(req *SqlCreate) error {
+
+	errMsg := "Expected CREATE [OR REPLACE] POLICY <identity> ON <table> [MASK <column>] USING <expression>"
+	if m.Cur().T != lex.TokenIdentity {
+		return m.ErrMsg(errMsg)
+	}
+	req.Identity = strings.ToLower(m.Next().V)
+	if m.Next().T != lex.TokenOn || m.Cur().T != lex.TokenIdentity {
+		return m.ErrMsg(errMsg)
+	}
+	req.Table = strings.ToLower(m.Next().V)
+	if m.Cur().T == lex.TokenMask {
+		m.Next() // Consume MASK
+		if m.Cur().T != lex.TokenIdentity {
+			return m.ErrMsg(errMsg)
+		}
+		req.Column = strings.ToLower(m.Next().V)
+	}
+	if m.Next().T != lex.TokenUsing {
+		return m.ErrMsg(errMsg)
+	}
+	using, err := expr.ParseExprWithFuncs(m.SqlTokenPager, m.funcs)
+	if err != nil {
+		return err
+	}
+	req.Using = using
+	return nil
+}
+
 // First keyword was DROP
 func (m *Sqlbridge) parseDrop() (*SqlDrop, error) {
 
@@ -824,10 +1002,19 @@ func (m *Sqlbridge) parseDrop() (*SqlDrop, error) {
 		req.Temp = true
 	}
 
+	// DROP MATERIALIZED VIEW x
+	if m.Cur().T == lex.TokenMaterialized {
+		m.Next()
+		if m.Cur().T != lex.TokenView {
+			return nil, m.ErrMsg("Expected DROP MATERIALIZED VIEW <identity>")
+		}
+	}
+
 	// DROP (TABLE|VIEW|SOURCE|CONTINUOUSVIEW) <identity>
 	switch m.Cur().T {
 	case lex.TokenTable, lex.TokenView, lex.TokenSource, lex.TokenContinuousView,
-		lex.TokenSchema, lex.TokenDatabase:
+		lex.TokenSchema, lex.TokenDatabase, lex.TokenFunction, lex.TokenPolicy,
+		lex.TokenUser, lex.TokenRole:
 		req.Tok = m.Next()
 	case lex.TokenIdentity:
 		// triggers, indexes
@@ -850,6 +1037,8 @@ func (m *Sqlbridge) parseDrop() (*SqlDrop, error) {
 		// schema
 	case lex.TokenContinuousView, lex.TokenView:
 		// view
+	case lex.TokenFunction, lex.TokenPolicy, lex.TokenUser, lex.TokenRole:
+		req.Identity = strings.ToLower(req.Identity)
 	default:
 		// triggers, index, etc
 	}
@@ -873,6 +1062,117 @@ func (m *Sqlbridge) parseTransaction() (*SqlCommand, error) {
 	return req, nil
 }
 
+// First keyword was KILL
+//
+//    KILL [CONNECTION | QUERY] <id>
+//
+func (m *Sqlbridge) parseKill() (*SqlCommand, error) {
+
+	req := &SqlCommand{Columns: make(CommandColumns, 0), Identity: "connection"}
+	req.kw = m.Next().T // kill
+
+	if m.Cur().T == lex.TokenIdentity {
+		req.Identity = strings.ToLower(m.Next().V)
+	}
+	if m.Cur().T != lex.TokenInteger {
+		return nil, m.ErrMsg("Expected KILL [CONNECTION | QUERY] <id>")
+	}
+	id, err := expr.NewNumberStr(m.Next().V)
+	if err != nil {
+		return nil, err
+	}
+	req.Value = id
+	return req, nil
+}
+
+// First keyword was REFRESH
+//
+//    REFRESH MATERIALIZED VIEW <identity>
+//
+func (m *Sqlbridge) parseRefresh() (*SqlCommand, error) {
+
+	req := &SqlCommand{Columns: make(CommandColumns, 0)}
+	req.kw = m.Next().T // refresh
+
+	if m.Next().T != lex.TokenMaterialized || m.Next().T != lex.TokenView {
+		return nil, m.ErrMsg("Expected REFRESH MATERIALIZED VIEW <identity>")
+	}
+	if m.Cur().T != lex.TokenIdentity {
+		return nil, m.ErrMsg("Expected REFRESH MATERIALIZED VIEW <identity>")
+	}
+	req.Identity = m.Next().V
+	return req, nil
+}
+
+// GRANT {SELECT|INSERT|UPDATE|DELETE|DROP|ALL} [, ...] ON <schema>.<table> TO <role>
+// GRANT <role> TO <user>
+// REVOKE {SELECT|INSERT|UPDATE|DELETE|DROP|ALL} [, ...] ON <schema>.<table> FROM <role>
+// REVOKE <role> FROM <user>
+func (m *Sqlbridge) parseGrant() (*SqlGrant, error) {
+
+	req := &SqlGrant{Tok: m.Next()}
+	req.Raw = m.l.RawInput()
+	to := lex.TokenTo
+	if req.Tok.T == lex.TokenRevoke {
+		to = lex.TokenFrom
+	}
+	errMsg := fmt.Sprintf("Expected %s {SELECT|INSERT|UPDATE|DELETE|DROP|ALL} ON <schema>.<table> %s <role>",
+		strings.ToUpper(req.Tok.T.String()), strings.ToUpper(to.String()))
+
+	names := make([]string, 0)
+	for {
+		if m.Cur().T != lex.TokenIdentity {
+			return nil, m.ErrMsg(errMsg)
+		}
+		names = append(names, strings.ToLower(m.Next().V))
+		if m.Cur().T != lex.TokenComma {
+			break
+		}
+		m.Next() // Consume ,
+	}
+
+	switch m.Cur().T {
+	case lex.TokenOn:
+		m.Next() // Consume ON
+		for _, name := range names {
+			priv, ok := grantPrivileges[name]
+			if !ok {
+				return nil, m.ErrMsg(fmt.Sprintf("Unknown privilege %q, %s", name, errMsg))
+			}
+			req.Privileges = append(req.Privileges, priv)
+		}
+		if m.Cur().T != lex.TokenIdentity {
+			return nil, m.ErrMsg(errMsg)
+		}
+		// <table> alone is a table of the current schema
+		parts := strings.Split(m.Next().V, ".")
+		for i, part := range parts {
+			parts[i] = strings.ToLower(strings.Trim(part, "`"))
+		}
+		switch len(parts) {
+		case 1:
+			req.Table = parts[0]
+		case 2:
+			req.Schema, req.Table = parts[0], parts[1]
+		default:
+			return nil, m.ErrMsg(errMsg)
+		}
+	case to:
+		if len(names) != 1 {
+			return nil, m.ErrMsg(errMsg)
+		}
+		req.Role = names[0]
+	default:
+		return nil, m.ErrMsg(errMsg)
+	}
+
+	if m.Next().T != to || m.Cur().T != lex.TokenIdentity {
+		return nil, m.ErrMsg(errMsg)
+	}
+	req.Grantee = strings.ToLower(m.Next().V)
+	return req, nil
+}
+
 func parseColumns(m expr.TokenPager, fr expr.FuncResolver, stmt ColumnsStatement) error {
 
 	var col *Column
@@ -953,6 +1253,16 @@ func parseColumns(m expr.TokenPager, fr expr.FuncResolver, stmt ColumnsStatement
 				return err
 			}
 			col.Expr = exprNode
+		case lex.TokenCase, lex.TokenInterval:
+			// CASE or INTERVAL expression, named after itself unless aliased
+			col = NewColumnValue(m.Cur())
+			exprNode, err := expr.ParseExprWithFuncs(m, fr)
+			if err != nil {
+				return err
+			}
+			col.Expr = exprNode
+			col.As = exprNode.String()
+			col.SourceField = expr.FindFirstIdentity(exprNode)
 		}
 		//u.Debugf("after colstart?:   %v  ", m.Cur())
 		comment += readComment(m)
@@ -1790,6 +2100,11 @@ func (m *Sqlbridge) parseDdlConstraint(col *DdlColumn) error {
 				return m.ErrMsg("expected 'type(integer)'")
 			}
 		}
+	case lex.TokenTypeDecimal:
+		col.DataType = m.Next().V
+		if err := m.parseDdlDecimal(col); err != nil {
+			return err
+		}
 	default:
 		col.Null = true
 	}
@@ -1861,6 +2176,44 @@ func (m *Sqlbridge) parseDdlConstraint(col *DdlColumn) error {
 	return nil
 }
 
+// parseDdlDecimal the optional precision and scale of decimal
+//
+//    DECIMAL(10,2)
+//
+func (m *Sqlbridge) parseDdlDecimal(col *DdlColumn) error {
+	if m.Cur().T != lex.TokenLeftParenthesis {
+		return nil
+	}
+	m.Next()
+	args := make([]int, 0, 2)
+	for {
+		if m.Cur().T != lex.TokenInteger {
+			return m.ErrMsg("expected 'decimal(precision, scale)'")
+		}
+		iv, err := strconv.ParseInt(m.Next().V, 10, 64)
+		if err != nil {
+			return m.ErrMsg("Expected integer")
+		}
+		args = append(args, int(iv))
+		if m.Cur().T != lex.TokenComma || len(args) == 2 {
+			break
+		}
+		m.Next()
+	}
+	if m.Next().T != lex.TokenRightParenthesis {
+		m.Backup()
+		return m.ErrMsg("expected 'decimal(precision, scale)'")
+	}
+	col.DataTypeSize = args[0]
+	if len(args) == 2 {
+		col.DataTypeScale = args[1]
+	}
+	if col.DataTypeScale > col.DataTypeSize {
+		return m.ErrMsg("decimal scale must not be larger than precision")
+	}
+	return nil
+}
+
 func (m *Sqlbridge) parseDdlColumn(col *DdlColumn) error {
 
 	/*
@@ -1909,6 +2262,11 @@ func (m *Sqlbridge) parseDdlColumn(col *DdlColumn) error {
 				return m.ErrMsg("expected 'type(integer)'")
 			}
 		}
+	case lex.TokenTypeDecimal:
+		col.DataType = m.Next().V
+		if err := m.parseDdlDecimal(col); err != nil {
+			return err
+		}
 	default:
 		col.Null = true
 	}
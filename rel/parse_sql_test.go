@@ -11,6 +11,7 @@ import (
 	"github.com/araddon/qlbridge/expr/builtins"
 	"github.com/araddon/qlbridge/lex"
 	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
 )
 
 var (
@@ -553,6 +554,14 @@ func TestSqlShowAst(t *testing.T) {
 	assert.True(t, show.Db == "dbx", "has SHOW db: %q", show.Db)
 	assert.True(t, show.Identity == "tablex", "has identity: %q", show.Identity)
 	assert.True(t, show.Like.String() == "Field LIKE \"%\"", "has Like? %q", show.Like.String())
+
+	sql = "SHOW FULL PROCESSLIST"
+	req, err = rel.ParseSql(sql)
+	assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+	show, ok = req.(*rel.SqlShow)
+	assert.True(t, ok, "is SqlShow: %T", req)
+	assert.True(t, show.Full, "Wanted full")
+	assert.Equal(t, "processlist", show.ShowType)
 }
 
 func TestSqlCommands(t *testing.T) {
@@ -581,6 +590,18 @@ func TestSqlCommands(t *testing.T) {
 	assert.True(t, ok, "is SqlCommand: %T", req)
 	assert.True(t, cmd.Keyword() == lex.TokenUse, "has USE kw: %#v", cmd)
 	assert.True(t, cmd.Identity == "myschema", "has myschema: %#v", cmd.Identity)
+
+	for _, sql := range []string{"KILL 12", "kill connection 12", "KILL QUERY 12;"} {
+		req, err = rel.ParseSql(sql)
+		assert.True(t, err == nil && req != nil, "Must parse: %s  \n\t%v", sql, err)
+		cmd, ok = req.(*rel.SqlCommand)
+		assert.True(t, ok, "is SqlCommand: %T", req)
+		assert.Equal(t, lex.TokenKill, cmd.Keyword(), sql)
+		assert.Equal(t, "12", cmd.Value.String(), sql)
+	}
+	assert.Equal(t, "query", cmd.Identity)
+	_, err = rel.ParseSql("KILL QUERY")
+	assert.NotEqual(t, nil, err)
 }
 
 func TestSqlAlias(t *testing.T) {
@@ -667,6 +688,38 @@ func TestSqlCreate(t *testing.T) {
 	assert.Equal(t, 150, c2.DataTypeSize, "%+v", c2)
 }
 
+func TestSqlCreateDecimal(t *testing.T) {
+	t.Parallel()
+	sql := `
+	CREATE TABLE invoices (
+		  ID int(11) NOT NULL AUTO_INCREMENT,
+		  amount decimal(10,2) NOT NULL,
+		  rate NUMERIC(12),
+		  total decimal
+		) ENGINE=InnoDB;`
+	req, err := rel.ParseSql(sql)
+	assert.Equal(t, nil, err)
+	cs, ok := req.(*rel.SqlCreate)
+	assert.True(t, ok, "wanted SqlCreate got %T", req)
+	assert.Equal(t, 4, len(cs.Cols))
+
+	c := cs.Cols[1]
+	assert.Equal(t, "decimal", c.DataType, "%+v", c)
+	assert.Equal(t, 10, c.DataTypeSize, "%+v", c)
+	assert.Equal(t, 2, c.DataTypeScale, "%+v", c)
+	assert.Equal(t, false, c.Null, "%+v", c)
+	c = cs.Cols[2]
+	assert.Equal(t, "NUMERIC", c.DataType, "%+v", c)
+	assert.Equal(t, 12, c.DataTypeSize, "%+v", c)
+	assert.Equal(t, 0, c.DataTypeScale, "%+v", c)
+	c = cs.Cols[3]
+	assert.Equal(t, "decimal", c.DataType, "%+v", c)
+	assert.Equal(t, 0, c.DataTypeSize, "%+v", c)
+
+	_, err = rel.ParseSql(`CREATE TABLE invoices (amount decimal(2,4)) ENGINE=InnoDB;`)
+	assert.NotEqual(t, nil, err)
+}
+
 func TestSqlDrop(t *testing.T) {
 	t.Parallel()
 	sql := `DROP TABLE articles;`
@@ -680,6 +733,213 @@ func TestSqlDrop(t *testing.T) {
 	assert.Equal(t, "articles", ds.Identity, "has articles: %v", ds.Identity)
 }
 
+func TestSqlCreateFunction(t *testing.T) {
+	t.Parallel()
+	req, err := rel.ParseSql(`CREATE OR REPLACE FUNCTION Domain_Of(url, fallback) AS (ifnull(tolower(domain(url)), fallback));`)
+	assert.Equal(t, nil, err)
+	cs, ok := req.(*rel.SqlCreate)
+	assert.True(t, ok, "wanted SqlCreate got %T", req)
+	assert.Equal(t, lex.TokenFunction, cs.Tok.T)
+	assert.True(t, cs.OrReplace)
+	assert.Equal(t, "domain_of", cs.Identity)
+	assert.Equal(t, []string{"url", "fallback"}, cs.Func.Params)
+	assert.Equal(t, "domain_of(url, fallback) AS ifnull(tolower(domain(url)), fallback)", cs.Func.String())
+
+	_, err = rel.ParseSql(`CREATE FUNCTION domain_of(url) lower(url)`)
+	assert.NotEqual(t, nil, err)
+	_, err = rel.ParseSql(`CREATE FUNCTION domain_of("url") AS lower(url)`)
+	assert.NotEqual(t, nil, err)
+
+	// resolved at parse time through the function resolver
+	fr := expr.NewFuncRegistry()
+	fr.Add(cs.Identity, cs.Func)
+	req, err = rel.ParseSqlResolver(`SELECT domain_of(referer, "none") AS d FROM logs`, fr)
+	assert.Equal(t, nil, err)
+	sel := req.(*rel.SqlSelect)
+	fn, ok := sel.Columns[0].Expr.(*expr.FuncNode)
+	assert.True(t, ok)
+	assert.Equal(t, cs.Func, fn.F.CustomFunc)
+	_, err = rel.ParseSqlResolver(`SELECT domain_of(referer) AS d FROM logs`, fr)
+	assert.NotEqual(t, nil, err, "wrong number of args")
+
+	req, err = rel.ParseSql(`DROP FUNCTION Domain_Of`)
+	assert.Equal(t, nil, err)
+	ds := req.(*rel.SqlDrop)
+	assert.Equal(t, lex.TokenFunction, ds.Tok.T)
+	assert.Equal(t, "domain_of", ds.Identity)
+
+	req, err = rel.ParseSql(`SHOW FUNCTIONS LIKE "domain%"`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "function", req.(*rel.SqlShow).ShowType)
+}
+
+func TestSqlCreateExternalFunction(t *testing.T) {
+	t.Parallel()
+	req, err := rel.ParseSql(`CREATE FUNCTION Clean_Sku(string, int) RETURNS string LANGUAGE WASM FROM '/udfs/sku.wasm' WITH export = "clean", timeout = "50ms"`)
+	assert.Equal(t, nil, err)
+	cs := req.(*rel.SqlCreate)
+	assert.Equal(t, "clean_sku", cs.Identity)
+	assert.True(t, cs.Func.IsExternal())
+	assert.Equal(t, []value.ValueType{value.StringType, value.IntType}, cs.Func.Args)
+	assert.Equal(t, value.StringType, cs.Func.Returns)
+	assert.Equal(t, value.StringType, cs.Func.Type())
+	assert.Equal(t, "wasm", cs.Func.Language)
+	assert.Equal(t, "/udfs/sku.wasm", cs.Func.From)
+	assert.Equal(t, "clean", cs.Func.With.String("export"))
+	assert.Equal(t, `clean_sku(string, int) RETURNS string LANGUAGE wasm FROM "/udfs/sku.wasm" WITH {"export":"clean","timeout":"50ms"}`, cs.Func.String())
+
+	// the declaration round trips
+	req, err = rel.ParseSql("CREATE FUNCTION " + cs.Func.String())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, cs.Func.String(), req.(*rel.SqlCreate).Func.String())
+
+	_, err = rel.ParseSql(`CREATE FUNCTION clean_sku(sku) RETURNS string LANGUAGE wasm FROM 'sku.wasm'`)
+	assert.NotEqual(t, nil, err, "unknown arg type")
+	_, err = rel.ParseSql(`CREATE FUNCTION clean_sku(string) RETURNS text LANGUAGE wasm FROM 'sku.wasm'`)
+	assert.NotEqual(t, nil, err, "unknown return type")
+	_, err = rel.ParseSql(`CREATE FUNCTION clean_sku(string) RETURNS string FROM 'sku.wasm'`)
+	assert.NotEqual(t, nil, err, "missing language")
+
+	// not loaded, so can't be called
+	fr := expr.NewFuncRegistry()
+	fr.Add(cs.Identity, cs.Func)
+	_, err = rel.ParseSqlResolver(`SELECT clean_sku(sku, 1) FROM orders`, fr)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestSqlCreatePolicy(t *testing.T) {
+	t.Parallel()
+	req, err := rel.ParseSql(`CREATE POLICY Tenant_Only ON Events USING (tenant_id = session.tenant_id);`)
+	assert.Equal(t, nil, err)
+	cs, ok := req.(*rel.SqlCreate)
+	assert.True(t, ok, "wanted SqlCreate got %T", req)
+	assert.Equal(t, lex.TokenPolicy, cs.Tok.T)
+	assert.Equal(t, "tenant_only", cs.Identity)
+	assert.Equal(t, "events", cs.Table)
+	assert.Equal(t, "", cs.Column)
+	assert.Equal(t, "(tenant_id = session.tenant_id)", cs.Using.String())
+
+	req, err = rel.ParseSql(`CREATE OR REPLACE POLICY hide_email ON users MASK Email USING hash.sha1(email)`)
+	assert.Equal(t, nil, err)
+	cs = req.(*rel.SqlCreate)
+	assert.True(t, cs.OrReplace)
+	assert.Equal(t, "users", cs.Table)
+	assert.Equal(t, "email", cs.Column)
+	assert.Equal(t, "hash.sha1(email)", cs.Using.String())
+
+	_, err = rel.ParseSql(`CREATE POLICY tenant_only events USING tenant_id = 1`)
+	assert.NotEqual(t, nil, err)
+	_, err = rel.ParseSql(`CREATE POLICY tenant_only ON events`)
+	assert.NotEqual(t, nil, err)
+
+	req, err = rel.ParseSql(`DROP POLICY Tenant_Only`)
+	assert.Equal(t, nil, err)
+	ds := req.(*rel.SqlDrop)
+	assert.Equal(t, lex.TokenPolicy, ds.Tok.T)
+	assert.Equal(t, "tenant_only", ds.Identity)
+}
+
+func TestSqlMaterializedView(t *testing.T) {
+	t.Parallel()
+	req, err := rel.ParseSql(`CREATE MATERIALIZED VIEW hits_by_user AS
+		SELECT user_id, count(*) AS ct FROM hits GROUP BY user_id
+		WITH append = "ts";`)
+	assert.Equal(t, nil, err)
+	cs, ok := req.(*rel.SqlCreate)
+	assert.True(t, ok, "wanted SqlCreate got %T", req)
+	assert.Equal(t, lex.TokenView, cs.Tok.T)
+	assert.True(t, cs.Materialized)
+	assert.Equal(t, "hits_by_user", cs.Identity)
+	assert.Equal(t, "ts", cs.With.String("append"))
+	assert.Equal(t, 0, len(cs.Select.With))
+	assert.Equal(t, 1, len(cs.Select.GroupBy))
+
+	req, err = rel.ParseSql(`CREATE VIEW viewx AS SELECT a FROM tbl`)
+	assert.Equal(t, nil, err)
+	assert.False(t, req.(*rel.SqlCreate).Materialized)
+
+	_, err = rel.ParseSql(`CREATE MATERIALIZED TABLE hits_by_user`)
+	assert.NotEqual(t, nil, err)
+
+	req, err = rel.ParseSql(`REFRESH MATERIALIZED VIEW hits_by_user;`)
+	assert.Equal(t, nil, err)
+	cmd, ok := req.(*rel.SqlCommand)
+	assert.True(t, ok, "wanted SqlCommand got %T", req)
+	assert.Equal(t, lex.TokenRefresh, cmd.Keyword())
+	assert.Equal(t, "hits_by_user", cmd.Identity)
+	assert.Equal(t, "REFRESH MATERIALIZED VIEW hits_by_user", cmd.String())
+
+	_, err = rel.ParseSql(`REFRESH hits_by_user`)
+	assert.NotEqual(t, nil, err)
+
+	req, err = rel.ParseSql(`DROP MATERIALIZED VIEW hits_by_user`)
+	assert.Equal(t, nil, err)
+	ds := req.(*rel.SqlDrop)
+	assert.Equal(t, lex.TokenView, ds.Tok.T)
+	assert.Equal(t, "hits_by_user", ds.Identity)
+}
+
+func TestSqlGrant(t *testing.T) {
+	t.Parallel()
+	req, err := rel.ParseSql(`GRANT select, INSERT ON MyDb.Users TO Analyst;`)
+	assert.Equal(t, nil, err)
+	gs, ok := req.(*rel.SqlGrant)
+	assert.True(t, ok, "wanted SqlGrant got %T", req)
+	assert.Equal(t, lex.TokenGrant, gs.Keyword())
+	assert.Equal(t, []lex.TokenType{lex.TokenSelect, lex.TokenInsert}, gs.Privileges)
+	assert.Equal(t, "mydb", gs.Schema)
+	assert.Equal(t, "users", gs.Table)
+	assert.Equal(t, "analyst", gs.Grantee)
+	assert.Equal(t, "GRANT SELECT, INSERT ON mydb.users TO analyst", gs.String())
+
+	req, err = rel.ParseSql("REVOKE ALL ON `mydb`.* FROM analyst")
+	assert.Equal(t, nil, err)
+	gs = req.(*rel.SqlGrant)
+	assert.Equal(t, lex.TokenRevoke, gs.Keyword())
+	assert.Equal(t, []lex.TokenType{lex.TokenAll}, gs.Privileges)
+	assert.Equal(t, "mydb", gs.Schema)
+	assert.Equal(t, "*", gs.Table)
+
+	req, err = rel.ParseSql(`GRANT DELETE ON users TO analyst`)
+	assert.Equal(t, nil, err)
+	gs = req.(*rel.SqlGrant)
+	assert.Equal(t, "", gs.Schema)
+	assert.Equal(t, "users", gs.Table)
+
+	req, err = rel.ParseSql(`GRANT Analyst TO Alice`)
+	assert.Equal(t, nil, err)
+	gs = req.(*rel.SqlGrant)
+	assert.Equal(t, 0, len(gs.Privileges))
+	assert.Equal(t, "analyst", gs.Role)
+	assert.Equal(t, "alice", gs.Grantee)
+	assert.Equal(t, "GRANT analyst TO alice", gs.String())
+
+	_, err = rel.ParseSql(`GRANT TRUNCATE ON mydb.users TO analyst`)
+	assert.NotEqual(t, nil, err)
+	_, err = rel.ParseSql(`GRANT SELECT ON mydb.users FROM analyst`)
+	assert.NotEqual(t, nil, err)
+	_, err = rel.ParseSql(`GRANT analyst, reader TO alice`)
+	assert.NotEqual(t, nil, err)
+
+	req, err = rel.ParseSql(`CREATE USER Alice`)
+	assert.Equal(t, nil, err)
+	cs := req.(*rel.SqlCreate)
+	assert.Equal(t, lex.TokenUser, cs.Tok.T)
+	assert.Equal(t, "alice", cs.Identity)
+
+	req, err = rel.ParseSql(`CREATE USER alice WITH password = "secret"`)
+	assert.Equal(t, nil, err)
+	cs = req.(*rel.SqlCreate)
+	assert.Equal(t, "alice", cs.Identity)
+	assert.Equal(t, "secret", cs.With.String("password"))
+
+	req, err = rel.ParseSql(`DROP ROLE Analyst`)
+	assert.Equal(t, nil, err)
+	ds := req.(*rel.SqlDrop)
+	assert.Equal(t, lex.TokenRole, ds.Tok.T)
+	assert.Equal(t, "analyst", ds.Identity)
+}
+
 func TestWithNameValue(t *testing.T) {
 	t.Parallel()
 	// some sql dialects support a WITH name=value syntax
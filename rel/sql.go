@@ -30,6 +30,7 @@ var (
 	_ SqlStatement = (*SqlDescribe)(nil)
 	_ SqlStatement = (*SqlCommand)(nil)
 	_ SqlStatement = (*SqlInto)(nil)
+	_ SqlStatement = (*SqlGrant)(nil)
 
 	// sub-query statements
 	_ SqlSourceStatement = (*SqlSource)(nil)
@@ -209,15 +210,20 @@ type (
 	}
 	// SqlCreate SQL CREATE statement
 	SqlCreate struct {
-		Raw         string       // full original raw statement
-		Identity    string       // identity of table, view, etc
-		Tok         lex.Token    // CREATE [TABLE,VIEW,CONTINUOUSVIEW,TRIGGER] etc
-		OrReplace   bool         // OR REPLACE
-		IfNotExists bool         // IF NOT EXISTS
-		Cols        []*DdlColumn // columns
-		Engine      map[string]interface{}
-		With        u.JsonHelper
-		Select      *SqlSelect
+		Raw          string       // full original raw statement
+		Identity     string       // identity of table, view, etc
+		Tok          lex.Token    // CREATE [TABLE,VIEW,CONTINUOUSVIEW,TRIGGER] etc
+		OrReplace    bool         // OR REPLACE
+		Materialized bool         // MATERIALIZED VIEW
+		IfNotExists  bool         // IF NOT EXISTS
+		Cols         []*DdlColumn // columns
+		Engine       map[string]interface{}
+		With         u.JsonHelper
+		Select       *SqlSelect
+		Func         *expr.UserFunc // CREATE FUNCTION name(args) AS expression
+		Table        string         // CREATE POLICY name ON table
+		Column       string         // CREATE POLICY name ON table MASK column
+		Using        expr.Node      // CREATE POLICY name ON table USING expression
 	}
 	// SqlDrop SQL DROP statement
 	SqlDrop struct {
@@ -227,6 +233,17 @@ type (
 		Tok      lex.Token // DROP [TEMP] [TABLE,VIEW,CONTINUOUSVIEW,TRIGGER] etc
 		With     u.JsonHelper
 	}
+	// SqlGrant SQL GRANT and REVOKE statement, of privileges on a table to
+	// a role or of a role to a user.
+	SqlGrant struct {
+		Raw        string          // full original raw statement
+		Tok        lex.Token       // GRANT or REVOKE
+		Privileges []lex.TokenType // SELECT, INSERT, UPDATE, DELETE, DROP, ALL; empty if granting a role
+		Role       string          // GRANT role TO user
+		Schema     string          // ON schema.table, * for all schemas
+		Table      string          // ON schema.table, * for all tables
+		Grantee    string          // TO role, or TO user if granting a role
+	}
 	// SqlAlter SQL ALTER statement
 	SqlAlter struct {
 		Raw      string       // full original raw statement
@@ -274,7 +291,8 @@ type (
 		RefCols       []string      // ref cols
 		Default       expr.Node     // Default value
 		DataType      string        // data type
-		DataTypeSize  int           // Data Type Size:    varchar(2000)
+		DataTypeSize  int           // Data Type Size:    varchar(2000), precision of decimal(10,2)
+		DataTypeScale int           // Data Type Scale:   decimal(10,2)
 		DataTypeArgs  []expr.Node   // data type args
 		Key           lex.TokenType // UNIQUE | PRIMARY
 		Name          string        // name
@@ -1954,8 +1972,16 @@ func (m *CommandColumns) String() string {
 
 func (m *SqlCommand) Keyword() lex.TokenType            { return m.kw }
 func (m *SqlCommand) FingerPrint(r rune) string         { return m.String() }
-func (m *SqlCommand) String() string                    { return fmt.Sprintf("%s %s", m.Keyword(), m.Columns.String()) }
 func (m *SqlCommand) WriteDialect(w expr.DialectWriter) {}
+func (m *SqlCommand) String() string {
+	if m.kw == lex.TokenKill {
+		return fmt.Sprintf("KILL %s %s", strings.ToUpper(m.Identity), m.Value)
+	}
+	if m.kw == lex.TokenRefresh {
+		return fmt.Sprintf("REFRESH MATERIALIZED VIEW %s", m.Identity)
+	}
+	return fmt.Sprintf("%s %s", m.Keyword(), m.Columns.String())
+}
 
 func (m *SqlCreate) Keyword() lex.TokenType            { return lex.TokenCreate }
 func (m *SqlCreate) FingerPrint(r rune) string         { return m.String() }
@@ -1967,6 +1993,25 @@ func (m *SqlDrop) FingerPrint(r rune) string         { return m.String() }
 func (m *SqlDrop) String() string                    { return fmt.Sprintf("DROP %s %v", m.Tok.T, m.Identity) }
 func (m *SqlDrop) WriteDialect(w expr.DialectWriter) {}
 
+func (m *SqlGrant) Keyword() lex.TokenType            { return m.Tok.T }
+func (m *SqlGrant) FingerPrint(r rune) string         { return m.String() }
+func (m *SqlGrant) WriteDialect(w expr.DialectWriter) {}
+func (m *SqlGrant) String() string {
+	to := "TO"
+	if m.Tok.T == lex.TokenRevoke {
+		to = "FROM"
+	}
+	if len(m.Privileges) == 0 {
+		return fmt.Sprintf("%s %s %s %s", strings.ToUpper(m.Tok.T.String()), m.Role, to, m.Grantee)
+	}
+	privs := make([]string, len(m.Privileges))
+	for i, p := range m.Privileges {
+		privs[i] = strings.ToUpper(p.String())
+	}
+	return fmt.Sprintf("%s %s ON %s.%s %s %s", strings.ToUpper(m.Tok.T.String()), strings.Join(privs, ", "),
+		m.Schema, m.Table, to, m.Grantee)
+}
+
 func (m *SqlAlter) Keyword() lex.TokenType            { return lex.TokenAlter }
 func (m *SqlAlter) FingerPrint(r rune) string         { return m.String() }
 func (m *SqlAlter) String() string                    { return fmt.Sprintf("not-implemented") }
@@ -114,7 +114,7 @@ func rewriteIntoProjection(sel *SqlSelect, m Columns) {
 		switch n := c.Expr.(type) {
 		case *expr.IdentityNode:
 			colsToAdd = append(colsToAdd, c.SourceField)
-		case *expr.FuncNode:
+		case *expr.FuncNode, *expr.CaseNode:
 
 			idents := expr.FindAllIdentities(n)
 			for _, in := range idents {
@@ -187,7 +187,7 @@ func rewriteWhere(stmt *SqlSelect, from *SqlSource, node expr.Node, cols Columns
 			n2, cols = rewriteWhere(stmt, from, nt.Args[1], cols)
 
 			if n1 != nil && n2 != nil {
-				return &expr.BinaryNode{Operator: nt.Operator, Args: []expr.Node{n1, n2}}, cols
+				return &expr.BinaryNode{Paren: nt.Paren, Operator: nt.Operator, Args: []expr.Node{n1, n2}}, cols
 			} else if n1 != nil {
 				return n1, cols
 			} else if n2 != nil {
@@ -195,7 +195,8 @@ func rewriteWhere(stmt *SqlSelect, from *SqlSource, node expr.Node, cols Columns
 			} else {
 				//u.Warnf("n1=%#v  n2=%#v    %#v", n1, n2, nt)
 			}
-		case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenGT, lex.TokenGE, lex.TokenLE, lex.TokenNE:
+		case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenGT, lex.TokenGE, lex.TokenLE, lex.TokenNE,
+			lex.TokenIsDistinctFrom, lex.TokenIsNotDistinctFrom:
 			var n1, n2 expr.Node
 			n1, cols = rewriteWhere(stmt, from, nt.Args[0], cols)
 			n2, cols = rewriteWhere(stmt, from, nt.Args[1], cols)
@@ -212,6 +213,30 @@ func rewriteWhere(stmt *SqlSelect, from *SqlSource, node expr.Node, cols Columns
 		default:
 			//u.Warnf("un-implemented op: %#v", nt)
 		}
+	case *expr.CaseNode:
+		// every branch must be re-writeable for this source
+		cn := &expr.CaseNode{Whens: make([]expr.Node, len(nt.Whens)), Thens: make([]expr.Node, len(nt.Thens))}
+		ok := true
+		rewrite := func(n expr.Node) expr.Node {
+			if n == nil {
+				return nil
+			}
+			var rn expr.Node
+			rn, cols = rewriteWhere(stmt, from, n, cols)
+			if rn == nil {
+				ok = false
+			}
+			return rn
+		}
+		cn.Operand = rewrite(nt.Operand)
+		for i := range nt.Whens {
+			cn.Whens[i] = rewrite(nt.Whens[i])
+			cn.Thens[i] = rewrite(nt.Thens[i])
+		}
+		cn.Else = rewrite(nt.Else)
+		if ok {
+			return cn, cols
+		}
 	default:
 		u.Warnf("%T node types are not suppored yet for where rewrite", node)
 	}
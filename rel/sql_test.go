@@ -161,7 +161,7 @@ func TestSqlRewriteTemp(t *testing.T) {
 
 	assert.True(t, sql.String() == `SELECT u.user_id, o.item_id, u.reg_date, u.email, o.price, o.order_date FROM users AS u
 	INNER JOIN (
-		SELECT price, order_date, user_id FROM ORDERS WHERE user_id != NULL AND price > 10
+		SELECT price, order_date, user_id FROM ORDERS WHERE user_id IS NOT NULL AND price > 10
 	) AS o ON u.user_id = o.user_id`, "Wrong Full SQL?: '%v'", sql.String())
 }
 
@@ -291,7 +291,7 @@ func TestSqlRewrite(t *testing.T) {
 	assert.True(t, rw0 != nil, "should not be nil:")
 	assert.True(t, len(rw0.Columns) == 3, "has 3 cols: %v", rw0.String())
 	assert.True(t, len(sql.From[0].Source.Columns) == 3, "has 3 cols? %s", sql.From[0].Source)
-	assert.True(t, rw0.String() == "SELECT title, author, email FROM article WHERE email != NULL", "Wrong SQL 0: %v", rw0.String())
+	assert.True(t, rw0.String() == "SELECT title, author, email FROM article WHERE email IS NOT NULL", "Wrong SQL 0: %v", rw0.String())
 	assert.True(t, rw1 != nil, "should not be nil:")
 	assert.True(t, len(rw1.Columns) == 3, "has 3 cols: %v", rw1.Columns.String())
 	assert.True(t, len(sql.From[1].Source.Columns) == 3, "has 3 cols? %s", sql.From[1].Source)
@@ -303,9 +303,9 @@ func TestSqlRewrite(t *testing.T) {
 		u.Debugf("----%v----", p)
 	}
 	assert.True(t, parts[0] == "SELECT p.actor, p.`repository.name`, a.title FROM article AS a", "Wrong Full SQL?: '%v'", parts[0])
-	assert.True(t, parts[1] == `	INNER JOIN github_push AS p ON p.actor = a.author WHERE p.follow_ct > 20 AND a.email != NULL`, "Wrong Full SQL?: '%v'", parts[1])
+	assert.True(t, parts[1] == `	INNER JOIN github_push AS p ON p.actor = a.author WHERE p.follow_ct > 20 AND a.email IS NOT NULL`, "Wrong Full SQL?: '%v'", parts[1])
 	assert.True(t, sql.String() == `SELECT p.actor, p.`+"`repository.name`"+`, a.title FROM article AS a
-	INNER JOIN github_push AS p ON p.actor = a.author WHERE p.follow_ct > 20 AND a.email != NULL`, "Wrong Full SQL?: '%v'", sql.String())
+	INNER JOIN github_push AS p ON p.actor = a.author WHERE p.follow_ct > 20 AND a.email IS NOT NULL`, "Wrong Full SQL?: '%v'", sql.String())
 
 	s = `SELECT u.user_id, o.item_id, u.reg_date, u.email, o.price, o.order_date FROM users AS u
 	INNER JOIN (
@@ -320,7 +320,7 @@ func TestSqlRewrite(t *testing.T) {
 
 	assert.True(t, sql.String() == `SELECT u.user_id, o.item_id, u.reg_date, u.email, o.price, o.order_date FROM users AS u
 	INNER JOIN (
-		SELECT price, order_date, user_id FROM ORDERS WHERE user_id != NULL AND price > 10
+		SELECT price, order_date, user_id FROM ORDERS WHERE user_id IS NOT NULL AND price > 10
 	) AS o ON u.user_id = o.user_id`, "Wrong Full SQL?: '%v'", sql.String())
 
 	// Rewrite to remove functions, and aliasing to send all fields needed down to source
@@ -0,0 +1,349 @@
+package schema
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/araddon/qlbridge/lex"
+)
+
+type (
+	// User is an account a connection runs its statements as, it has the
+	// privileges granted to its roles.
+	//
+	//    CREATE USER alice WITH password = "secret"
+	//    GRANT analyst TO alice
+	//
+	User struct {
+		Name       string
+		Roles      []string
+		Credential *Credential // verifier of its password, nil if it has none
+	}
+	// Credential is the SCRAM-SHA-256 verifier of a password (RFC 5802,
+	// 7677), the password itself isn't kept.  Frontends that authenticate,
+	// ie pgwire, run SCRAM against it.
+	Credential struct {
+		Salt       []byte
+		Iterations int
+		StoredKey  []byte // H(HMAC(SaltedPassword, "Client Key"))
+		ServerKey  []byte // HMAC(SaltedPassword, "Server Key")
+	}
+	// Role is a named set of privileges granted to users.
+	//
+	//    CREATE ROLE analyst
+	//
+	Role struct {
+		Name   string
+		Grants []*Grant
+	}
+	// Grant is a privilege of a role on a table, Schema or Table "*" grant
+	// it on every schema or table.  ALL is every privilege, and also allows
+	// CREATE, GRANT and REVOKE.
+	//
+	//    GRANT SELECT ON mydb.users TO analyst
+	//    GRANT ALL ON *.* TO admin
+	//
+	Grant struct {
+		Role      string
+		Privilege lex.TokenType // SELECT, INSERT, UPDATE, DELETE, DROP or ALL
+		Schema    string
+		Table     string
+	}
+)
+
+// NewUser create a user without roles.
+func NewUser(name string) *User {
+	return &User{Name: strings.ToLower(name), Roles: make([]string, 0)}
+}
+
+// CredentialIterations the PBKDF2 iterations of new credentials.
+var CredentialIterations = 4096
+
+// NewCredential create the verifier of password with a random salt.
+func NewCredential(password string) (*Credential, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return newCredential(password, salt, CredentialIterations), nil
+}
+
+func newCredential(password string, salt []byte, iterations int) *Credential {
+	salted := pbkdf2SHA256([]byte(password), salt, iterations)
+	clientKey := hmacSHA256(salted, []byte("Client Key"))
+	stored := sha256.Sum256(clientKey)
+	return &Credential{
+		Salt:       salt,
+		Iterations: iterations,
+		StoredKey:  stored[:],
+		ServerKey:  hmacSHA256(salted, []byte("Server Key")),
+	}
+}
+
+// Check is password the one of this credential?
+func (m *Credential) Check(password string) bool {
+	c := newCredential(password, m.Salt, m.Iterations)
+	return hmac.Equal(c.StoredKey, m.StoredKey)
+}
+
+func hmacSHA256(key, msg []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(msg)
+	return h.Sum(nil)
+}
+
+// pbkdf2SHA256 the single block PBKDF2 (RFC 8018) of password, the size
+// of a SHA-256 hash as SCRAM uses.
+func pbkdf2SHA256(password, salt []byte, iterations int) []byte {
+	prf := hmac.New(sha256.New, password)
+	prf.Write(salt)
+	var block [4]byte
+	binary.BigEndian.PutUint32(block[:], 1)
+	prf.Write(block[:])
+	u := prf.Sum(nil)
+	out := make([]byte, len(u))
+	copy(out, u)
+	for i := 1; i < iterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range out {
+			out[j] ^= u[j]
+		}
+	}
+	return out
+}
+
+// NewRole create a role without privileges.
+func NewRole(name string) *Role {
+	return &Role{Name: strings.ToLower(name), Grants: make([]*Grant, 0)}
+}
+
+// NewGrant create a grant of privilege to role on schema.table.
+func NewGrant(role string, privilege lex.TokenType, schema, table string) *Grant {
+	return &Grant{
+		Role:      strings.ToLower(role),
+		Privilege: privilege,
+		Schema:    strings.ToLower(schema),
+		Table:     strings.ToLower(table),
+	}
+}
+
+// HasRole is role one of the roles of this user?
+func (m *User) HasRole(role string) bool {
+	for _, r := range m.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows does this grant give privilege on schema.table?
+func (m *Grant) Allows(privilege lex.TokenType, schema, table string) bool {
+	if m.Privilege != privilege && m.Privilege != lex.TokenAll {
+		return false
+	}
+	return (m.Schema == "*" || m.Schema == schema) && (m.Table == "*" || m.Table == table)
+}
+
+// Equal is this a grant of the same privilege on the same table to the same role.
+func (m *Grant) Equal(g *Grant) bool {
+	return m.Role == g.Role && m.Privilege == g.Privilege && m.Schema == g.Schema && m.Table == g.Table
+}
+
+func (m *Grant) String() string {
+	return fmt.Sprintf("GRANT %s ON %s.%s TO %s", strings.ToUpper(m.Privilege.String()), m.Schema, m.Table, m.Role)
+}
+
+// User get a user by name.
+func (m *Registry) User(name string) (*User, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	user, ok := m.users[strings.ToLower(name)]
+	return user, ok
+}
+
+// Users the sorted names of the users.
+func (m *Registry) Users() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.users))
+	for name := range m.users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Role get a role by name.
+func (m *Registry) Role(name string) (*Role, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	role, ok := m.roles[strings.ToLower(name)]
+	return role, ok
+}
+
+// UserAdd add or replace a user.
+func (m *Registry) UserAdd(user *User) error {
+	return m.applyer.AddOrUpdateOnSchema(nil, user)
+}
+
+// UserPassword set the password of an existing user, the first accounts
+// and their passwords are created through the Registry.
+func (m *Registry) UserPassword(user, password string) error {
+	existing, ok := m.User(user)
+	if !ok {
+		return fmt.Errorf("user %q does not exist", user)
+	}
+	cred, err := NewCredential(password)
+	if err != nil {
+		return err
+	}
+	// users are replaced as a whole by the applyer
+	updated := NewUser(existing.Name)
+	updated.Roles = append(updated.Roles, existing.Roles...)
+	updated.Credential = cred
+	return m.UserAdd(updated)
+}
+
+// RoleAdd add or replace a role.
+func (m *Registry) RoleAdd(role *Role) error {
+	return m.applyer.AddOrUpdateOnSchema(nil, role)
+}
+
+// GrantAdd add a privilege to an existing role.
+func (m *Registry) GrantAdd(g *Grant) error {
+	if _, ok := m.Role(g.Role); !ok {
+		return fmt.Errorf("role %q does not exist", g.Role)
+	}
+	return m.applyer.AddOrUpdateOnSchema(nil, g)
+}
+
+// GrantDrop remove a privilege from a role.
+func (m *Registry) GrantDrop(g *Grant) error {
+	if _, ok := m.Role(g.Role); !ok {
+		return fmt.Errorf("role %q does not exist", g.Role)
+	}
+	return m.applyer.Drop(nil, g)
+}
+
+// RoleGrant add role to the roles of user.
+func (m *Registry) RoleGrant(role, user string) error {
+	return m.userRoles(role, user, true)
+}
+
+// RoleRevoke remove role from the roles of user.
+func (m *Registry) RoleRevoke(role, user string) error {
+	return m.userRoles(role, user, false)
+}
+
+func (m *Registry) userRoles(role, user string, add bool) error {
+	role, user = strings.ToLower(role), strings.ToLower(user)
+	if _, ok := m.Role(role); !ok {
+		return fmt.Errorf("role %q does not exist", role)
+	}
+	existing, ok := m.User(user)
+	if !ok {
+		return fmt.Errorf("user %q does not exist", user)
+	}
+	// users are replaced as a whole by the applyer
+	updated := NewUser(existing.Name)
+	updated.Credential = existing.Credential
+	for _, r := range existing.Roles {
+		if r != role {
+			updated.Roles = append(updated.Roles, r)
+		}
+	}
+	if add {
+		updated.Roles = append(updated.Roles, role)
+	}
+	return m.UserAdd(updated)
+}
+
+// Privileged does user have privilege on schema.table through its roles?
+// Unknown users have none.
+func (m *Registry) Privileged(user string, privilege lex.TokenType, schema, table string) bool {
+	schema, table = strings.ToLower(schema), strings.ToLower(table)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	account, ok := m.users[strings.ToLower(user)]
+	if !ok {
+		return false
+	}
+	for _, name := range account.Roles {
+		role, ok := m.roles[name]
+		if !ok {
+			continue
+		}
+		for _, g := range role.Grants {
+			if g.Allows(privilege, schema, table) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// the in-mem account changes, must hold the registry lock
+
+func (m *Registry) addUser(user *User) {
+	m.users[user.Name] = user
+}
+
+func (m *Registry) dropUser(user *User) {
+	delete(m.users, user.Name)
+}
+
+func (m *Registry) addRole(role *Role) {
+	m.roles[role.Name] = role
+}
+
+func (m *Registry) dropRole(role *Role) {
+	delete(m.roles, role.Name)
+	for name, user := range m.users {
+		if !user.HasRole(role.Name) {
+			continue
+		}
+		updated := NewUser(user.Name)
+		updated.Credential = user.Credential
+		for _, r := range user.Roles {
+			if r != role.Name {
+				updated.Roles = append(updated.Roles, r)
+			}
+		}
+		m.users[name] = updated
+	}
+}
+
+func (m *Registry) addGrant(g *Grant) {
+	role, ok := m.roles[g.Role]
+	if !ok {
+		return
+	}
+	for _, existing := range role.Grants {
+		if existing.Equal(g) {
+			return
+		}
+	}
+	role.Grants = append(role.Grants, g)
+}
+
+func (m *Registry) dropGrant(g *Grant) {
+	role, ok := m.roles[g.Role]
+	if !ok {
+		return
+	}
+	grants := make([]*Grant, 0, len(role.Grants))
+	for _, existing := range role.Grants {
+		if !existing.Equal(g) {
+			grants = append(grants, existing)
+		}
+	}
+	role.Grants = grants
+}
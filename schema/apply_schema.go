@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/expr"
 )
 
 type (
@@ -14,9 +16,10 @@ type (
 	Applyer interface {
 		// Init initialize the applyer with registry.
 		Init(r *Registry)
-		// AddOrUpdateOnSchema Add or Update object (Table, Index)
+		// AddOrUpdateOnSchema Add or Update object (Table, Index, Function, Policy, View),
+		// s is nil for the registry wide User, Role and Grant.
 		AddOrUpdateOnSchema(s *Schema, obj interface{}) error
-		// Drop an object from schema
+		// Drop an object from schema, s is nil for User, Role and Grant.
 		Drop(s *Schema, obj interface{}) error
 	}
 
@@ -50,6 +53,10 @@ func (m *InMemApplyer) Init(r *Registry) {
 // argument which is which schema it is being applied to (ie, add table x to schema y).
 func (m *InMemApplyer) AddOrUpdateOnSchema(s *Schema, v interface{}) error {
 
+	if s == nil {
+		return m.applyAccount(v, false)
+	}
+
 	// All Schemas must also have an info-schema
 	if s.InfoSchema == nil {
 		s.InfoSchema = NewInfoSchema("schema", s)
@@ -96,6 +103,26 @@ func (m *InMemApplyer) AddOrUpdateOnSchema(s *Schema, v interface{}) error {
 		if s.Name != "schema" {
 			s.InfoSchema.refreshSchemaUnlocked()
 		}
+	case *expr.UserFunc:
+		u.Debugf("%p:%s adding function %q", s, s.Name, v.Name)
+		s.mu.Lock()
+		s.addFunction(v)
+		s.mu.Unlock()
+	case *Policy:
+		u.Debugf("%p:%s adding policy %q", s, s.Name, v.Name)
+		s.mu.Lock()
+		s.addPolicy(v)
+		s.mu.Unlock()
+	case *View:
+		u.Debugf("%p:%s adding view %q", s, s.Name, v.Name)
+		s.InfoSchema.DS.Init() // Wipe out cache, it is invalid
+		s.mu.Lock()
+		err := s.addView(v)
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		s.InfoSchema.refreshSchemaUnlocked()
 	default:
 		u.Errorf("invalid type %T", v)
 		return fmt.Errorf("Could not find %T", v)
@@ -107,6 +134,10 @@ func (m *InMemApplyer) AddOrUpdateOnSchema(s *Schema, v interface{}) error {
 // Drop we have a schema change to apply.
 func (m *InMemApplyer) Drop(s *Schema, v interface{}) error {
 
+	if s == nil {
+		return m.applyAccount(v, true)
+	}
+
 	// Find the type of operation being updated.
 	switch v := v.(type) {
 	case *Table:
@@ -139,6 +170,31 @@ func (m *InMemApplyer) Drop(s *Schema, v interface{}) error {
 		s.mu.Unlock()
 		m.reg.mu.Unlock()
 
+	case *expr.UserFunc:
+		u.Debugf("%p:%s dropping function %q", s, s.Name, v.Name)
+		s.mu.Lock()
+		s.dropFunction(v)
+		s.mu.Unlock()
+
+	case *Policy:
+		u.Debugf("%p:%s dropping policy %q", s, s.Name, v.Name)
+		s.mu.Lock()
+		s.dropPolicy(v)
+		s.mu.Unlock()
+
+	case *View:
+		u.Debugf("%p:%s dropping view %q", s, s.Name, v.Name)
+		s.mu.Lock()
+		err := s.dropView(v)
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		if s.InfoSchema != nil && s.InfoSchema.DS != nil {
+			s.InfoSchema.DS.Init()
+			s.InfoSchema.refreshSchemaUnlocked()
+		}
+
 	default:
 		u.Errorf("invalid type %T", v)
 		return fmt.Errorf("Could not find %T", v)
@@ -146,3 +202,36 @@ func (m *InMemApplyer) Drop(s *Schema, v interface{}) error {
 
 	return nil
 }
+
+// applyAccount add or drop the users, roles and grants of the registry.
+func (m *InMemApplyer) applyAccount(v interface{}, drop bool) error {
+	m.reg.mu.Lock()
+	defer m.reg.mu.Unlock()
+	switch v := v.(type) {
+	case *User:
+		u.Debugf("user %q drop?%v", v.Name, drop)
+		if drop {
+			m.reg.dropUser(v)
+		} else {
+			m.reg.addUser(v)
+		}
+	case *Role:
+		u.Debugf("role %q drop?%v", v.Name, drop)
+		if drop {
+			m.reg.dropRole(v)
+		} else {
+			m.reg.addRole(v)
+		}
+	case *Grant:
+		u.Debugf("%s drop?%v", v, drop)
+		if drop {
+			m.reg.dropGrant(v)
+		} else {
+			m.reg.addGrant(v)
+		}
+	default:
+		u.Errorf("invalid type %T", v)
+		return fmt.Errorf("Could not find %T", v)
+	}
+	return nil
+}
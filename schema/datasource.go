@@ -67,6 +67,18 @@ type (
 		// Underlying data type of column
 		Column(col string) (value.ValueType, bool)
 	}
+	// SourceTableVersion is an optional interface for sources that can tell
+	// when the data of a table changed, a version or etag that differs after
+	// any write.  Used to invalidate cached results of queries on the table.
+	SourceTableVersion interface {
+		TableVersion(ctx context.Context, table string) (string, error)
+	}
+	// SourceTableCreator is an optional interface for sources that can create
+	// a table, replacing any of the same name and its rows.  The rows of
+	// materialized views are stored in tables of such sources.
+	SourceTableCreator interface {
+		CreateTable(tbl *Table) error
+	}
 )
 
 type (
@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	u "github.com/araddon/gou"
+	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/lex"
 )
 
@@ -32,6 +33,8 @@ type (
 		sources     map[string]Source
 		schemas     map[string]*Schema
 		schemaNames []string
+		users       map[string]*User // accounts, CREATE USER
+		roles       map[string]*Role // privileges of users, CREATE ROLE, GRANT
 		mu          sync.RWMutex
 	}
 )
@@ -107,6 +110,8 @@ func NewRegistry(applyer Applyer) *Registry {
 		sources:     make(map[string]Source),
 		schemas:     make(map[string]*Schema),
 		schemaNames: make([]string, 0),
+		users:       make(map[string]*User),
+		roles:       make(map[string]*Role),
 	}
 }
 
@@ -149,10 +154,92 @@ func (m *Registry) SchemaDrop(schema, name string, objectType lex.TokenType) err
 			return ErrNotFound
 		}
 		return m.applyer.Drop(s, t)
+	case lex.TokenFunction:
+		m.mu.RLock()
+		s, ok := m.schemas[schema]
+		m.mu.RUnlock()
+		if !ok {
+			return ErrNotFound
+		}
+		fn, ok := s.Function(name)
+		if !ok {
+			return ErrNotFound
+		}
+		return m.applyer.Drop(s, fn)
+	case lex.TokenPolicy:
+		m.mu.RLock()
+		s, ok := m.schemas[schema]
+		m.mu.RUnlock()
+		if !ok {
+			return ErrNotFound
+		}
+		p, ok := s.Policy(name)
+		if !ok {
+			return ErrNotFound
+		}
+		return m.applyer.Drop(s, p)
+	case lex.TokenView:
+		m.mu.RLock()
+		s, ok := m.schemas[schema]
+		m.mu.RUnlock()
+		if !ok {
+			return ErrNotFound
+		}
+		v, ok := s.View(name)
+		if !ok {
+			return ErrNotFound
+		}
+		return m.applyer.Drop(s, v)
+	case lex.TokenUser:
+		user, ok := m.User(name)
+		if !ok {
+			return ErrNotFound
+		}
+		return m.applyer.Drop(nil, user)
+	case lex.TokenRole:
+		role, ok := m.Role(name)
+		if !ok {
+			return ErrNotFound
+		}
+		return m.applyer.Drop(nil, role)
 	}
 	return fmt.Errorf("Object type %s not recognized to DROP", objectType)
 }
 
+// FunctionAdd add or replace a user defined function on a schema.
+func (m *Registry) FunctionAdd(schema string, fn *expr.UserFunc) error {
+	m.mu.RLock()
+	s, ok := m.schemas[strings.ToLower(schema)]
+	m.mu.RUnlock()
+	if !ok {
+		return ErrNotFound
+	}
+	return m.applyer.AddOrUpdateOnSchema(s, fn)
+}
+
+// PolicyAdd add or replace a row filter or column mask on a schema.
+func (m *Registry) PolicyAdd(schema string, p *Policy) error {
+	m.mu.RLock()
+	s, ok := m.schemas[strings.ToLower(schema)]
+	m.mu.RUnlock()
+	if !ok {
+		return ErrNotFound
+	}
+	return m.applyer.AddOrUpdateOnSchema(s, p)
+}
+
+// ViewAdd add or replace a materialized view on a schema, its table must
+// already exist in the source of the view.
+func (m *Registry) ViewAdd(schema string, v *View) error {
+	m.mu.RLock()
+	s, ok := m.schemas[strings.ToLower(schema)]
+	m.mu.RUnlock()
+	if !ok {
+		return ErrNotFound
+	}
+	return m.applyer.AddOrUpdateOnSchema(s, v)
+}
+
 // SchemaRefresh means reload the schema from underlying store.  Possibly
 // requires introspection.
 func (m *Registry) SchemaRefresh(name string) error {
@@ -140,3 +140,53 @@ func didPanic(f func()) (dp bool) {
 	f()
 	return dp
 }
+
+func TestRegistryAccounts(t *testing.T) {
+	applyer := schema.NewApplyer(nil)
+	reg := schema.NewRegistry(applyer)
+	applyer.Init(reg)
+
+	assert.Equal(t, nil, reg.UserAdd(schema.NewUser("Alice")))
+	assert.Equal(t, nil, reg.RoleAdd(schema.NewRole("analyst")))
+	assert.Equal(t, []string{"alice"}, reg.Users())
+
+	// no roles, no privileges
+	assert.False(t, reg.Privileged("alice", lex.TokenSelect, "mydb", "users"))
+
+	assert.Equal(t, nil, reg.GrantAdd(schema.NewGrant("analyst", lex.TokenSelect, "mydb", "users")))
+	assert.Equal(t, nil, reg.GrantAdd(schema.NewGrant("analyst", lex.TokenSelect, "mydb", "users")))
+	assert.Equal(t, nil, reg.GrantAdd(schema.NewGrant("analyst", lex.TokenAll, "other", "*")))
+	role, _ := reg.Role("analyst")
+	assert.Equal(t, 2, len(role.Grants))
+	assert.NotEqual(t, nil, reg.GrantAdd(schema.NewGrant("nobody", lex.TokenSelect, "*", "*")))
+
+	assert.Equal(t, nil, reg.RoleGrant("analyst", "alice"))
+	assert.True(t, reg.Privileged("Alice", lex.TokenSelect, "mydb", "Users"))
+	assert.False(t, reg.Privileged("alice", lex.TokenDelete, "mydb", "users"))
+	assert.False(t, reg.Privileged("alice", lex.TokenSelect, "mydb", "orders"))
+	assert.True(t, reg.Privileged("alice", lex.TokenDrop, "other", "orders"))
+	assert.False(t, reg.Privileged("bob", lex.TokenSelect, "mydb", "users"))
+	assert.NotEqual(t, nil, reg.RoleGrant("analyst", "bob"))
+
+	assert.Equal(t, nil, reg.GrantDrop(schema.NewGrant("analyst", lex.TokenSelect, "mydb", "users")))
+	assert.False(t, reg.Privileged("alice", lex.TokenSelect, "mydb", "users"))
+
+	// only a verifier of the password is kept
+	assert.Equal(t, nil, reg.UserPassword("alice", "s3cret"))
+	assert.NotEqual(t, nil, reg.UserPassword("bob", "s3cret"))
+
+	// dropping a role removes it from its users
+	assert.Equal(t, nil, reg.SchemaDrop("", "analyst", lex.TokenRole))
+	alice, ok := reg.User("alice")
+	assert.True(t, ok)
+	assert.Equal(t, 0, len(alice.Roles))
+	assert.NotEqual(t, nil, alice.Credential)
+	assert.True(t, alice.Credential.Check("s3cret"))
+	assert.False(t, alice.Credential.Check("secret"))
+	assert.False(t, reg.Privileged("alice", lex.TokenDrop, "other", "orders"))
+
+	assert.Equal(t, nil, reg.SchemaDrop("", "alice", lex.TokenUser))
+	_, ok = reg.User("alice")
+	assert.False(t, ok)
+	assert.Equal(t, schema.ErrNotFound, reg.SchemaDrop("", "alice", lex.TokenUser))
+}
@@ -7,9 +7,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"hash/fnv"
+	"io/ioutil"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	u "github.com/araddon/gou"
@@ -25,7 +27,7 @@ var (
 
 	// Static list of common field names for describe header on Show, Describe
 	EngineFullCols       = []string{"Engine", "Support", "Comment", "Transactions", "XA", "Savepoints"}
-	ProdedureFullCols    = []string{"Db", "Name", "Type", "Definer", "Modified", "Created", "Security_type", "Comment", "character_set_client ", "collation_connection", "Database Collation"}
+	ProdedureFullCols    = []string{"Db", "Name", "Type", "Definer", "Modified", "Created", "Security_type", "Comment", "character_set_client", "collation_connection", "Database Collation"}
 	DescribeFullCols     = []string{"Field", "Type", "Collation", "Null", "Key", "Default", "Extra", "Privileges", "Comment"}
 	DescribeFullColMap   = map[string]int{"Field": 0, "Type": 1, "Collation": 2, "Null": 3, "Key": 4, "Default": 5, "Extra": 6, "Privileges": 7, "Comment": 8}
 	DescribeCols         = []string{"Field", "Type", "Null", "Key", "Default", "Extra"}
@@ -35,6 +37,7 @@ var (
 	ShowDatabasesColumns = []string{"Database"}
 	ShowTableColumnMap   = map[string]int{"Table": 0}
 	ShowIndexCols        = []string{"Table", "Non_unique", "Key_name", "Seq_in_index", "Column_name", "Collation", "Cardinality", "Sub_part", "Packed", "Null", "Index_type", "Index_comment"}
+	ShowProcessListCols  = []string{"Id", "User", "Host", "db", "Command", "Time", "State", "Info"}
 	DescribeFullHeaders  = NewDescribeFullHeaders()
 	DescribeHeaders      = NewDescribeHeaders()
 
@@ -83,18 +86,53 @@ type (
 	// - each schema supplies tables to the virtual table pool
 	// - each table name across schemas must be unique (or aliased)
 	Schema struct {
-		Name          string             // Name of schema
-		Conf          *ConfigSource      // source configuration
-		DS            Source             // This datasource Interface
-		InfoSchema    *Schema            // represent this Schema as sql schema like "information_schema"
-		SchemaRef     *Schema            // IF this is infoschema, the schema it refers to
-		parent        *Schema            // parent schema (optional) if nested.
-		schemas       map[string]*Schema // map[schema-name]:Children Schemas
-		tableSchemas  map[string]*Schema // Tables to schema map for parent/child
-		tableMap      map[string]*Table  // Tables and their field info, flattened from all child schemas
-		tableNames    []string           // List Table names, flattened all schemas into one list
-		lastRefreshed time.Time          // Last time we refreshed this schema
-		mu            sync.RWMutex       // lock for schema mods
+		Name          string                    // Name of schema
+		Conf          *ConfigSource             // source configuration
+		DS            Source                    // This datasource Interface
+		InfoSchema    *Schema                   // represent this Schema as sql schema like "information_schema"
+		SchemaRef     *Schema                   // IF this is infoschema, the schema it refers to
+		parent        *Schema                   // parent schema (optional) if nested.
+		schemas       map[string]*Schema        // map[schema-name]:Children Schemas
+		tableSchemas  map[string]*Schema        // Tables to schema map for parent/child
+		tableMap      map[string]*Table         // Tables and their field info, flattened from all child schemas
+		tableNames    []string                  // List Table names, flattened all schemas into one list
+		funcs         map[string]*expr.UserFunc // User defined functions, CREATE FUNCTION
+		policies      map[string]*Policy        // Row filters and column masks, CREATE POLICY
+		views         map[string]*View          // Materialized views, CREATE MATERIALIZED VIEW
+		lastRefreshed time.Time                 // Last time we refreshed this schema
+		mu            sync.RWMutex              // lock for schema mods
+		version       uint64                    // changes with the tables and functions
+	}
+
+	// Policy is a row filter or column mask on a table, the planner applies
+	// it to every statement reading or changing the table.  Identities
+	// session.<name> are bound to the identity of the connection, set by
+	// the application (plan.Context Identity), not to its session variables.
+	//
+	//    CREATE POLICY tenant_only ON events USING tenant_id = session.tenant_id
+	//    CREATE POLICY hide_email ON users MASK email USING hash.sha1(email)
+	//
+	Policy struct {
+		Name   string    // lower-case name of policy
+		Table  string    // lower-case name of table
+		Column string    // masked column, empty for a row filter
+		Expr   expr.Node // row filter, or the value replacing the masked column
+	}
+
+	// View is a materialized view, the rows of its select stored in a table
+	// of the same name in Source.  REFRESH MATERIALIZED VIEW recomputes them,
+	// or if Append is set only folds in the rows of the source whose Append
+	// column is past the last refresh.
+	//
+	//    CREATE MATERIALIZED VIEW hits_by_user AS
+	//       SELECT user_id, count(*) AS ct FROM hits GROUP BY user_id
+	//       WITH append = "ts"
+	//
+	View struct {
+		Name   string // lower-case name of view and its table
+		Sql    string // select statement of the view
+		Append string // ever increasing column of an append-only source, optional
+		Source Source // stores the rows of the view
 	}
 
 	// Table represents traditional definition of Database Table.  It belongs to a Schema
@@ -184,6 +222,9 @@ func NewSchemaSource(schemaName string, ds Source) *Schema {
 		tableMap:     make(map[string]*Table),
 		tableSchemas: make(map[string]*Schema),
 		tableNames:   make([]string, 0),
+		funcs:        make(map[string]*expr.UserFunc),
+		policies:     make(map[string]*Policy),
+		views:        make(map[string]*View),
 		DS:           ds,
 	}
 	return m
@@ -200,6 +241,19 @@ func (m *Schema) Since(dur time.Duration) bool {
 	return false
 }
 
+// Version changes whenever the tables or functions of this schema or
+// its child schemas change, ie refresh, drop table, create function.
+// It only ever increases, plans made against the schema are only valid
+// for the same version.
+func (m *Schema) Version() uint64 { return atomic.LoadUint64(&m.version) }
+
+// changed bumps the version of this schema and of its parents.
+func (m *Schema) changed() {
+	for s := m; s != nil; s = s.parent {
+		atomic.AddUint64(&s.version, 1)
+	}
+}
+
 // Current Is this schema up to date?
 func (m *Schema) Current() bool { return m.Since(SchemaRefreshInterval) }
 
@@ -299,6 +353,7 @@ func (m *Schema) addChildSchema(child *Schema) {
 	defer m.mu.Unlock()
 	m.schemas[child.Name] = child
 	child.parent = m
+	m.changed()
 	child.mu.RLock()
 	defer child.mu.RUnlock()
 	for tableName, tbl := range child.tableMap {
@@ -318,6 +373,7 @@ func (m *Schema) addSchemaForTable(tableName string, ss *Schema) {
 func (m *Schema) refreshSchemaUnlocked() {
 
 	m.lastRefreshed = time.Now()
+	m.changed()
 
 	if m.DS != nil {
 		for _, tableName := range m.DS.Tables() {
@@ -337,6 +393,237 @@ func (m *Schema) refreshSchemaUnlocked() {
 	}
 }
 
+// Function get a user defined function by name.
+func (m *Schema) Function(name string) (*expr.UserFunc, bool) {
+	m.mu.RLock()
+	fn, ok := m.funcs[strings.ToLower(name)]
+	m.mu.RUnlock()
+	if !ok && m.parent != nil {
+		return m.parent.Function(name)
+	}
+	return fn, ok
+}
+
+// Functions list of user defined functions ordered by name.
+func (m *Schema) Functions() []*expr.UserFunc {
+	m.mu.RLock()
+	fns := make([]*expr.UserFunc, 0, len(m.funcs))
+	for _, fn := range m.funcs {
+		fns = append(fns, fn)
+	}
+	m.mu.RUnlock()
+	sort.Slice(fns, func(i, j int) bool { return fns[i].Name < fns[j].Name })
+	return fns
+}
+
+// FuncGet implements expr.FuncResolver so that user defined functions of
+// this schema resolve when parsing statements against it.
+func (m *Schema) FuncGet(name string) (expr.Func, bool) {
+	fn, ok := m.Function(name)
+	if !ok {
+		return expr.Func{}, false
+	}
+	return expr.Func{Name: fn.Name, CustomFunc: fn}, true
+}
+
+// FuncCheck functions of a schema are in addition to the global registry,
+// functions that don't resolve are not an error at parse time.
+func (m *Schema) FuncCheck() bool { return false }
+
+func (m *Schema) addFunction(fn *expr.UserFunc) {
+	if m.funcs == nil {
+		m.funcs = make(map[string]*expr.UserFunc)
+	}
+	if existing, ok := m.funcs[fn.Name]; ok && existing != fn {
+		existing.Close()
+	}
+	fn.SetScope(m)
+	m.funcs[fn.Name] = fn
+	m.changed()
+}
+
+func (m *Schema) dropFunction(fn *expr.UserFunc) {
+	delete(m.funcs, fn.Name)
+	fn.Close()
+	m.changed()
+}
+
+// Policy get a policy by name.
+func (m *Schema) Policy(name string) (*Policy, bool) {
+	m.mu.RLock()
+	p, ok := m.policies[strings.ToLower(name)]
+	m.mu.RUnlock()
+	if !ok && m.parent != nil {
+		return m.parent.Policy(name)
+	}
+	return p, ok
+}
+
+// Policies list of policies of this schema and its parents ordered by name.
+func (m *Schema) Policies() []*Policy {
+	byName := make(map[string]*Policy)
+	for s := m; s != nil; s = s.parent {
+		s.mu.RLock()
+		for name, p := range s.policies {
+			if _, exists := byName[name]; !exists {
+				byName[name] = p
+			}
+		}
+		s.mu.RUnlock()
+	}
+	ps := make([]*Policy, 0, len(byName))
+	for _, p := range byName {
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Name < ps[j].Name })
+	return ps
+}
+
+// TablePolicies list of policies on given table ordered by name.
+func (m *Schema) TablePolicies(table string) []*Policy {
+	table = strings.ToLower(table)
+	ps := make([]*Policy, 0)
+	for _, p := range m.Policies() {
+		if p.Table == table {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+func (m *Schema) addPolicy(p *Policy) {
+	if m.policies == nil {
+		m.policies = make(map[string]*Policy)
+	}
+	m.policies[p.Name] = p
+	m.changed()
+}
+
+func (m *Schema) dropPolicy(p *Policy) {
+	delete(m.policies, p.Name)
+	m.changed()
+}
+
+// View get a materialized view by name.
+func (m *Schema) View(name string) (*View, bool) {
+	m.mu.RLock()
+	v, ok := m.views[strings.ToLower(name)]
+	m.mu.RUnlock()
+	return v, ok
+}
+
+// Views list of materialized views ordered by name.
+func (m *Schema) Views() []*View {
+	m.mu.RLock()
+	vs := make([]*View, 0, len(m.views))
+	for _, v := range m.views {
+		vs = append(vs, v)
+	}
+	m.mu.RUnlock()
+	sort.Slice(vs, func(i, j int) bool { return vs[i].Name < vs[j].Name })
+	return vs
+}
+
+// addView add the table of the view as a child schema of its own, so it
+// reads from the source of the view.  Replaces the table of a view of the
+// same name.
+func (m *Schema) addView(v *View) error {
+	child := NewSchemaSource(v.Name, v.Source)
+	tbl, err := v.Source.Table(v.Name)
+	if err != nil {
+		return err
+	}
+	child.tableMap[v.Name] = tbl
+	child.tableSchemas[v.Name] = child
+	child.tableNames = []string{v.Name}
+	child.parent = m
+
+	if m.views == nil {
+		m.views = make(map[string]*View)
+	}
+	if _, exists := m.views[v.Name]; exists {
+		m.dropViewTable(v.Name)
+	}
+	m.views[v.Name] = v
+	m.schemas[child.Name] = child
+	m.tableMap[v.Name] = tbl
+	m.tableSchemas[v.Name] = child
+	m.tableNames = append(m.tableNames, v.Name)
+	sort.Strings(m.tableNames)
+	m.changed()
+	return nil
+}
+
+// dropView drop the view and its table from the source.
+func (m *Schema) dropView(v *View) error {
+	m.dropViewTable(v.Name)
+	delete(m.views, v.Name)
+	m.changed()
+	if as, ok := v.Source.(Alter); ok {
+		return as.DropTable(v.Name)
+	}
+	return nil
+}
+
+func (m *Schema) dropViewTable(name string) {
+	tl := make([]string, 0, len(m.tableNames))
+	for _, tn := range m.tableNames {
+		if tn != name {
+			tl = append(tl, tn)
+		}
+	}
+	m.tableNames = tl
+	delete(m.schemas, name)
+	delete(m.tableMap, name)
+	delete(m.tableSchemas, name)
+}
+
+// NewView create a materialized view of the select sql stored in source.
+func NewView(name, sql, appendCol string, source Source) *View {
+	return &View{
+		Name:   strings.ToLower(name),
+		Sql:    sql,
+		Append: appendCol,
+		Source: source,
+	}
+}
+
+// NewPolicy create a row filter, or a mask of column if column is not empty.
+func NewPolicy(name, table, column string, filter expr.Node) *Policy {
+	return &Policy{
+		Name:   strings.ToLower(name),
+		Table:  strings.ToLower(table),
+		Column: strings.ToLower(column),
+		Expr:   filter,
+	}
+}
+
+// IsMask is this a column mask instead of a row filter?
+func (m *Policy) IsMask() bool { return m.Column != "" }
+
+// SessionVars lower-case names of the session.<name> identity attributes
+// used by this policy.
+//
+//    tenant_id = session.tenant_id   == {tenant_id}
+//
+func (m *Policy) SessionVars() []string {
+	vars := make([]string, 0)
+	for _, in := range expr.FindAllIdentities(m.Expr) {
+		left, right, hasLeft := in.LeftRight()
+		if hasLeft && strings.EqualFold(left, "session") {
+			vars = append(vars, strings.ToLower(right))
+		}
+	}
+	return vars
+}
+
+func (m *Policy) String() string {
+	if m.IsMask() {
+		return fmt.Sprintf("CREATE POLICY %s ON %s MASK %s USING %s", m.Name, m.Table, m.Column, m.Expr)
+	}
+	return fmt.Sprintf("CREATE POLICY %s ON %s USING %s", m.Name, m.Table, m.Expr)
+}
+
 func (m *Schema) dropTable(tbl *Table) error {
 
 	// u.Warnf("%p drop %s %v", m, m.Name, m.Tables())
@@ -362,6 +649,7 @@ func (m *Schema) dropTable(tbl *Table) error {
 	delete(m.tableMap, tbl.Name)
 	delete(m.tableSchemas, tbl.Name)
 	m.tableNames = tl
+	m.changed()
 
 	if salter, ok := m.InfoSchema.DS.(Alter); ok {
 		err := salter.DropTable(tbl.Name)
@@ -398,6 +686,7 @@ func (m *Schema) addTable(tbl *Table) error {
 	tbl.init(m)
 
 	m.tableMap[tbl.Name] = tbl
+	m.changed()
 
 	m.addschemaForTableUnlocked(tbl.Name, tbl.Schema)
 	return nil
@@ -713,6 +1002,24 @@ func NewSourceConfig(name, sourceType string) *ConfigSource {
 	}
 }
 
+// LoadSourceConfigs reads a json file holding a list of source configs.
+//
+//    [
+//      {"name": "orders", "type": "cloudstore", "settings": {"type": "localfs", "path": "orders/"}}
+//    ]
+//
+func LoadSourceConfigs(path string) ([]*ConfigSource, error) {
+	by, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var confs []*ConfigSource
+	if err := json.Unmarshal(by, &confs); err != nil {
+		return nil, err
+	}
+	return confs, nil
+}
+
 func (m *ConfigSource) String() string {
 	return fmt.Sprintf(`<sourceconfig name=%q type=%q settings=%v/>`, m.Name, m.SourceType, m.Settings)
 }
@@ -208,6 +208,7 @@ type FieldPb struct {
 	Roles       []string `protobuf:"bytes,16,rep,name=roles" json:"roles,omitempty"`
 	Indexes     []*Index `protobuf:"bytes,17,rep,name=indexes" json:"indexes,omitempty"`
 	ContextJson []byte   `protobuf:"bytes,18,opt,name=contextJson,proto3" json:"contextJson,omitempty"`
+	Scale       uint32   `protobuf:"varint,19,opt,name=scale" json:"scale,omitempty"`
 }
 
 func (m *FieldPb) Reset()                    { *m = FieldPb{} }
@@ -327,6 +328,13 @@ func (m *FieldPb) GetContextJson() []byte {
 	return nil
 }
 
+func (m *FieldPb) GetScale() uint32 {
+	if m != nil {
+		return m.Scale
+	}
+	return 0
+}
+
 // Index a description of how field(s) should be indexed for a table.
 type Index struct {
 	Name          string   `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
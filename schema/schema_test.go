@@ -183,6 +183,41 @@ func TestSchema(t *testing.T) {
 	_, err = s.SchemaForTable("not_a_table")
 	assert.NotEqual(t, nil, err)
 }
+
+func TestSchemaVersion(t *testing.T) {
+	a := schema.NewApplyer(func(s *schema.Schema) schema.Source {
+		sdb := datasource.NewSchemaDb(s)
+		s.InfoSchema.DS = sdb
+		return sdb
+	})
+	reg := schema.NewRegistry(a)
+	a.Init(reg)
+
+	db, err := memdb.NewMemDbData("users", [][]driver.Value{{122, "bob"}}, []string{"user_id", "name"})
+	assert.Equal(t, nil, err)
+	vdb, err := memdb.NewMemDbData("user_names", [][]driver.Value{{"bob"}}, []string{"name"})
+	assert.Equal(t, nil, err)
+
+	s := schema.NewSchema("version_test")
+	s.DS = db
+	assert.Equal(t, nil, reg.SchemaAdd(s))
+	s, _ = reg.Schema("version_test")
+
+	// the version only increases, even as child schemas come and go
+	last := s.Version()
+	view := schema.NewView("user_names", "SELECT name FROM users", "", vdb)
+	for _, change := range []func() error{
+		func() error { return reg.ViewAdd("version_test", view) },
+		func() error { return reg.SchemaDrop("version_test", "user_names", lex.TokenView) },
+		func() error { return reg.ViewAdd("version_test", view) },
+		func() error { return reg.SchemaRefresh("version_test") },
+	} {
+		assert.Equal(t, nil, change())
+		v := s.Version()
+		assert.True(t, v > last, "version %d after %d", v, last)
+		last = v
+	}
+}
 func TestTable(t *testing.T) {
 	tbl := schema.NewTable("users")
 
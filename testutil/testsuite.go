@@ -28,6 +28,19 @@ func RunDDLTests(t TestingT) {
 	// DDL
 	TestExec(t, `CREATE SOURCE x WITH { "type":"inmem_testsuite" };`)
 	TestExec(t, `DROP SOURCE x;`)
+
+	// user defined functions
+	TestExec(t, `CREATE FUNCTION domain_of(addr) AS tolower(emaildomain(addr));`)
+	TestSelect(t, `SELECT domain_of(email) AS d FROM users WHERE domain_of(email) = "email.com" ORDER BY d`,
+		[][]driver.Value{{"email.com"}, {"email.com"}},
+	)
+	TestExec(t, `CREATE OR REPLACE FUNCTION domain_of(addr) AS emaildomain(addr);`)
+	TestSelect(t, `show functions like "domain%";`,
+		[][]driver.Value{{"mockcsv", "domain_of", "FUNCTION", "", nil, nil, "DEFINER",
+			"domain_of(addr) AS emaildomain(addr)", "utf8", "utf8_general_ci", "utf8_general_ci"}},
+	)
+	TestExec(t, `DROP FUNCTION domain_of;`)
+	TestSelect(t, `show functions like "domain%";`, nil)
 }
 
 // RunTestSuite run the normal DML SQL test suite.
@@ -57,11 +70,12 @@ func RunTestSuite(t TestingT) {
 	// - ensure we can evaluate against "NULL"
 	// - extra paren in where
 	// - `db`.`col` syntax
-	TestSelect(t, "SELECT user_id FROM users WHERE (`users.user_id` != NULL)",
+	TestSelect(t, "SELECT user_id FROM users WHERE (`users.user_id` IS NOT NULL)",
 		[][]driver.Value{{"hT2impsabc345c"}, {"9Ip1aKbeZe2njCDM"}, {"hT2impsOPUREcVPc"}},
 	)
-	TestSelect(t, "SELECT email FROM users WHERE interests != NULL)",
-		[][]driver.Value{{"aaron@email.com"}, {"bob@email.com"}},
+	// an empty string is a value not NULL
+	TestSelect(t, "SELECT email FROM users WHERE interests IS NOT NULL)",
+		[][]driver.Value{{"not_an_email_2"}, {"aaron@email.com"}, {"bob@email.com"}},
 	)
 	TestSelect(t, "SELECT email FROM users WHERE (`users`.`email` like \"%aaron%\");",
 		[][]driver.Value{{"aaron@email.com"}},
@@ -73,9 +87,9 @@ func RunTestSuite(t TestingT) {
 			`{"name":"aaron"}`, "email.com", true, int64(5)}},
 	)
 
-	// - user_id != NULL (on string column)
+	// - user_id IS NOT NULL (on string column)
 	// - as well as count(*)
-	TestSelect(t, "SELECT COUNT(*) AS count FROM users WHERE (`users.user_id` != NULL)",
+	TestSelect(t, "SELECT COUNT(*) AS count FROM users WHERE (`users.user_id` IS NOT NULL)",
 		[][]driver.Value{{int64(3)}},
 	)
 
@@ -141,20 +155,21 @@ func RunSimpleSuite(t TestingT) {
 	// - ensure we can evaluate against "NULL"
 	// - extra paren in where
 	// - `db`.`col` syntax
-	TestSelect(t, "SELECT user_id FROM users WHERE (`users.user_id` != NULL)",
+	TestSelect(t, "SELECT user_id FROM users WHERE (`users.user_id` IS NOT NULL)",
 		[][]driver.Value{{"hT2impsabc345c"}, {"9Ip1aKbeZe2njCDM"}, {"hT2impsOPUREcVPc"}},
 	)
-	TestSelect(t, "SELECT email FROM users WHERE interests != NULL)",
-		[][]driver.Value{{"aaron@email.com"}, {"bob@email.com"}},
+	// an empty string is a value not NULL
+	TestSelect(t, "SELECT email FROM users WHERE interests IS NOT NULL)",
+		[][]driver.Value{{"not_an_email_2"}, {"aaron@email.com"}, {"bob@email.com"}},
 	)
 
 	TestSelect(t, "SELECT email FROM users WHERE (`users`.`email` like \"%aaron%\");",
 		[][]driver.Value{{"aaron@email.com"}},
 	)
 
-	// - user_id != NULL (on string column)
+	// - user_id IS NOT NULL (on string column)
 	// - as well as count(*)
-	TestSelect(t, "SELECT COUNT(*) AS count FROM users WHERE (`users.user_id` != NULL)",
+	TestSelect(t, "SELECT COUNT(*) AS count FROM users WHERE (`users.user_id` IS NOT NULL)",
 		[][]driver.Value{{int64(3)}},
 	)
 
@@ -180,6 +195,16 @@ func RunSimpleSuite(t TestingT) {
 		[][]driver.Value{{"aaron"}},
 	)
 
+	// CASE expressions in projected columns and where
+	TestSelect(t, `SELECT email, CASE WHEN referral_count > 50 THEN "high" ELSE "low" END AS tier FROM users WHERE CASE interests WHEN "fishing" THEN 1 WHEN "swimming" THEN 2 ELSE 0 END > 0`,
+		[][]driver.Value{{"aaron@email.com", "high"}, {"bob@email.com", "low"}},
+	)
+
+	// null-safe comparison
+	TestSelect(t, `SELECT email FROM users WHERE interests IS DISTINCT FROM "fishing" ORDER BY email ASC`,
+		[][]driver.Value{{"bob@email.com"}, {"not_an_email_2"}},
+	)
+
 	return
 	TestSelect(t, "SELECT email FROM users ORDER BY email DESC",
 		[][]driver.Value{{"not_an_email_2"}, {"bob@email.com"}, {"aaron@email.com"}},
@@ -21,12 +21,15 @@ cd $GOPATH/src/github.com/kr/pretty && git checkout master && git pull
 cd $GOPATH/src/github.com/kr/pty && git checkout master && git pull
 cd $GOPATH/src/github.com/kr/text && git checkout master && git pull
 cd $GOPATH/src/github.com/leekchan/timeutil && git checkout master && git pull
+cd $GOPATH/src/github.com/lib/pq && git checkout master && git pull
 cd $GOPATH/src/github.com/lytics/cloudstorage && git checkout master && git pull
 cd $GOPATH/src/github.com/lytics/confl && git checkout master && git pull
 cd $GOPATH/src/github.com/lytics/datemath && git checkout master && git pull
+cd $GOPATH/src/github.com/mattn/go-runewidth && git checkout master && git pull
 cd $GOPATH/src/github.com/mb0/glob && git checkout master && git pull
 cd $GOPATH/src/github.com/mssola/user_agent && git checkout master && git pull
 cd $GOPATH/src/github.com/pborman/uuid && git checkout master && git pull
+cd $GOPATH/src/github.com/peterh/liner && git checkout master && git pull
 cd $GOPATH/src/github.com/rcrowley/go-metrics && git checkout master && git pull
 cd $GOPATH/src/github.com/stretchr/testify && git checkout master && git pull
 cd $GOPATH/src/github.com/go.opencensus.io && git checkout master && git pull
@@ -91,6 +91,24 @@ func Cast(valType ValueType, val Value) (Value, error) {
 			return NewIntValue(iv), nil
 		}
 		return nil, ErrConversion
+	case DecimalType:
+		dv, ok := ValueToDecimal(val)
+		if ok {
+			return dv, nil
+		}
+		return nil, ErrConversion
+	case DateType:
+		t, ok := ValueToTime(val)
+		if ok {
+			return NewDateValue(t), nil
+		}
+		return nil, ErrConversion
+	case IntervalType:
+		iv, ok := ValueToInterval(val)
+		if ok {
+			return iv, nil
+		}
+		return nil, ErrConversion
 	}
 	return nil, ErrConversionNotSupported
 }
@@ -129,17 +147,32 @@ func Equal(l, r Value) (bool, error) {
 		}
 		return false, nil
 	case IntValue:
+		if rd, isDecimal := r.(DecimalValue); isDecimal {
+			return !rd.Nil() && NewDecimalValueInt(lt.Val()).Cmp(rd) == 0, nil
+		}
 		rhv, _ := ValueToInt64(r)
 		return lt.Val() == rhv, nil
 	case NumberValue:
 		rhv, _ := ValueToFloat64(r)
 		return lt.Val() == rhv, nil
+	case DecimalValue:
+		if lt.Nil() {
+			return false, nil
+		}
+		rhv, ok := ValueToDecimal(r)
+		return ok && lt.Cmp(rhv) == 0, nil
 	case BoolValue:
 		rhv, _ := ValueToBool(r)
 		return lt.Val() == rhv, nil
 	case TimeValue:
 		rhv, _ := ValueToTime(r)
 		return lt.Val() == rhv, nil
+	case DateValue:
+		rhv, ok := ValueToTime(r)
+		return ok && NewDateValue(rhv).Val().Equal(lt.Val()), nil
+	case IntervalValue:
+		rhv, ok := ValueToInterval(r)
+		return ok && rhv == lt, nil
 	case Slice:
 		if rhv, ok := r.(Slice); ok {
 			if lt.Len() != rhv.Len() {
@@ -286,6 +319,37 @@ func ValueToFloat64(val Value) (float64, bool) {
 	return math.NaN(), false
 }
 
+// ValueToDecimal Convert a value type to a decimal if possible.  Ints keep
+// scale 0 and floats get the scale of their shortest string representation.
+//
+//    ValueToDecimal(NewNumberValue(0.1))     => 0.1
+//    ValueToDecimal(NewStringValue("$3.50")) => 3.50
+//
+func ValueToDecimal(val Value) (DecimalValue, bool) {
+	if val == nil || val.Nil() || val.Err() {
+		return NewDecimalNil(), false
+	}
+	switch v := val.(type) {
+	case DecimalValue:
+		return v, true
+	case IntValue:
+		return NewDecimalValueInt(v.Val()), true
+	case NumberValue:
+		if math.IsInf(v.Val(), 0) {
+			return NewDecimalNil(), false
+		}
+		dv, err := ParseDecimal(strconv.FormatFloat(v.Val(), 'f', -1, 64))
+		return dv, err == nil
+	case StringValue:
+		if dv, err := ParseDecimal(v.Val()); err == nil {
+			return dv, true
+		}
+		dv, err := ParseDecimal(intStrReplacer.Replace(v.Val()))
+		return dv, err == nil
+	}
+	return NewDecimalNil(), false
+}
+
 // ValueToInt Convert a value type to a int if possible
 func ValueToInt(val Value) (int, bool) {
 	iv, ok := ValueToInt64(val)
@@ -335,11 +399,14 @@ func ValueToTime(val Value) (time.Time, bool) {
 }
 
 // ValueToTimeAnchor given a value, and a time anchor, conver to time.
-// use "now-3d" anchoring if has prefix "now".
+// use "now-3d" anchoring if has prefix "now".  Strings without a zone
+// are UTC, see ValueToTimeIn to read them in a session time zone.
 func ValueToTimeAnchor(val Value, anchor time.Time) (time.Time, bool) {
 	switch v := val.(type) {
 	case TimeValue:
 		return v.Val(), true
+	case DateValue:
+		return v.Val(), true
 	case StringValue:
 		return StringToTimeAnchor(v.Val(), anchor)
 	case StringsValue:
@@ -363,6 +430,80 @@ func ValueToTimeAnchor(val Value, anchor time.Time) (time.Time, bool) {
 	return time.Time{}, false
 }
 
+// StringToTimeIn convert a string to a time in loc, strings without a
+// zone are read as wall clock time in loc and "now-3d" date math is
+// anchored in loc so rounding ("now/d") is to loc's midnight.
+func StringToTimeIn(val string, anchor time.Time, loc *time.Location) (time.Time, bool) {
+	if loc == nil {
+		return StringToTimeAnchor(val, anchor)
+	}
+	if len(val) > 3 && strings.ToLower(val[:3]) == "now" {
+		t, err := datemath.EvalAnchor(anchor.In(loc), val)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return TimeIn(t, loc), true
+	}
+	var t time.Time
+	var err error
+	if strings.HasSuffix(val, "Z") {
+		// explicitly utc, dateparse.ParseIn drops the Z after fractional seconds
+		t, err = dateparse.ParseAny(val)
+	} else {
+		t, err = dateparse.ParseIn(val, loc)
+	}
+	if err != nil {
+		return time.Time{}, false
+	}
+	return TimeIn(t, loc), true
+}
+
+// ValueToTimeIn convert a value to a time in loc, such as the session
+// time zone. Dates are midnight in loc, see StringToTimeIn for strings
+// and TimeIn for times. A nil loc is the same as ValueToTimeAnchor.
+func ValueToTimeIn(val Value, anchor time.Time, loc *time.Location) (time.Time, bool) {
+	if loc == nil {
+		return ValueToTimeAnchor(val, anchor)
+	}
+	switch v := val.(type) {
+	case DateValue:
+		if v.Nil() {
+			return time.Time{}, false
+		}
+		y, mo, d := v.Val().Date()
+		return time.Date(y, mo, d, 0, 0, 0, 0, loc), true
+	case StringValue:
+		return StringToTimeIn(v.Val(), anchor, loc)
+	case StringsValue:
+		vals := v.Val()
+		if len(vals) < 1 {
+			return time.Time{}, false
+		}
+		return StringToTimeIn(vals[0], anchor, loc)
+	}
+	t, ok := ValueToTimeAnchor(val, anchor)
+	return TimeIn(t, loc), ok
+}
+
+// ValueToInterval convert a value to an interval, strings are quantity
+// unit pairs and ints are milliseconds.
+//
+//    ValueToInterval(NewStringValue("3 days"))  => 3 days
+//    ValueToInterval(NewIntValue(1500))         => 1 seconds 500 milliseconds
+//
+func ValueToInterval(val Value) (IntervalValue, bool) {
+	switch v := val.(type) {
+	case IntervalValue:
+		return v, true
+	case StringValue:
+		iv, err := ParseInterval(v.Val())
+		return iv, err == nil
+	case IntValue:
+		return NewIntervalValue(0, 0, time.Duration(v.Val())*time.Millisecond), true
+	}
+	return IntervalValue{}, false
+}
+
 // StringToFloat64 converts a string to a float
 // includes replacement of $ and other monetary format identifiers.
 // May return math.NaN
@@ -168,11 +168,18 @@ func TestCast(t *testing.T) {
 	iv, _ := ValueToInt(NewIntValue(100))
 	assert.Equal(t, int(100), iv)
 
+	// Convert from ... to DECIMAL
+	good("12.50", DecimalType, NewStringValue("12.50"))
+	good("3.50", DecimalType, NewStringValue("$3.50"))
+	good("100", DecimalType, NewIntValue(100))
+	good("0.1", DecimalType, NewNumberValue(0.1))
+
 	castBad(BoolType, NewIntValue(500))
 	castBad(TimeType, NewStringValue("hello"))
 	castBad(IntType, NewStringValue("hello"))
 	castBad(IntType, NewStringValue(""))
 	castBad(IntType, NewStructValue(struct{ Name string }{Name: "world"}))
+	castBad(DecimalType, NewStringValue("hello"))
 }
 
 func TestEqual(t *testing.T) {
@@ -214,6 +221,13 @@ func TestEqual(t *testing.T) {
 	good(NewNumberValue(500), NewIntValue(500))
 	notEqual(NewNumberValue(500), NewIntValue(89))
 
+	dv, _ := ParseDecimal("1.50")
+	good(dv, NewStringValue("1.5"))
+	good(dv, NewNumberValue(1.5))
+	good(NewIntValue(2), NewDecimalValueInt(2))
+	notEqual(NewIntValue(1), dv)
+	notEqual(dv, NewIntValue(1))
+
 	good(NewBoolValue(true), NewBoolValue(true))
 	good(NewBoolValue(true), NewIntValue(1))
 	good(NewBoolValue(true), NewStringValue("true"))
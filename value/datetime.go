@@ -0,0 +1,282 @@
+package value
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_ NumericValue = DateValue{}
+	_ NumericValue = IntervalValue{}
+
+	// approximate lengths used to compare/order intervals of months and days
+	dayDuration   = 24 * time.Hour
+	monthDuration = 30 * dayDuration
+
+	// UTC a zero offset zone for times explicitly placed in UTC, ie
+	// AT TIME ZONE 'UTC', which TimeIn leaves alone unlike time.UTC.
+	UTC = time.FixedZone("UTC", 0)
+
+	locations = struct {
+		sync.Mutex
+		m map[string]*time.Location
+	}{m: make(map[string]*time.Location)}
+)
+
+// DateValue a calendar date without time of day, held as midnight UTC.
+type DateValue struct {
+	v time.Time
+}
+
+// NewDateValue the calendar date of t in t's own location.
+func NewDateValue(t time.Time) DateValue {
+	if t.IsZero() {
+		return DateValue{}
+	}
+	y, mo, d := t.Date()
+	return DateValue{v: time.Date(y, mo, d, 0, 0, 0, 0, time.UTC)}
+}
+
+func (m DateValue) Nil() bool                    { return m.v.IsZero() }
+func (m DateValue) Err() bool                    { return false }
+func (m DateValue) Type() ValueType              { return DateType }
+func (m DateValue) Value() interface{}           { return m.v }
+func (m DateValue) Val() time.Time               { return m.v }
+func (m DateValue) MarshalJSON() ([]byte, error) { return json.Marshal(m.ToString()) }
+func (m DateValue) ToString() string {
+	if m.v.IsZero() {
+		return ""
+	}
+	return m.v.Format("2006-01-02")
+}
+func (m DateValue) Float() float64  { return float64(m.Int()) }
+func (m DateValue) Int() int64      { return m.v.UnixNano() / 1e6 }
+func (m DateValue) Time() time.Time { return m.v }
+
+// IntervalValue a calendar aware span of time. Months and days are kept
+// apart from the fixed duration as their length depends on the date they
+// are added to, so jan 31 + 1 month is feb 28 (or 29).
+type IntervalValue struct {
+	months int
+	days   int
+	dur    time.Duration
+}
+
+// NewIntervalValue create an interval of months, days and fixed duration.
+func NewIntervalValue(months, days int, dur time.Duration) IntervalValue {
+	return IntervalValue{months: months, days: days, dur: dur}
+}
+
+// ParseInterval parse list of quantity unit pairs, units may be singular
+// or plural and are case-insensitive.
+//
+//    ParseInterval("3 DAY")             => 3 days
+//    ParseInterval("1 year 2 months")   => 14 months
+//    ParseInterval("-90 minutes")       => -1 hours -30 minutes
+//
+func ParseInterval(s string) (IntervalValue, error) {
+	parts := strings.Fields(s)
+	if len(parts) == 0 || len(parts)%2 != 0 {
+		return IntervalValue{}, fmt.Errorf("invalid interval %q", s)
+	}
+	iv := IntervalValue{}
+	for i := 0; i < len(parts); i += 2 {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return IntervalValue{}, fmt.Errorf("invalid interval quantity %q", parts[i])
+		}
+		unit, ok := IntervalUnit(parts[i+1])
+		if !ok {
+			return IntervalValue{}, fmt.Errorf("invalid interval unit %q", parts[i+1])
+		}
+		iv = iv.Add(unit.Mul(int64(n)))
+	}
+	return iv, nil
+}
+
+// IntervalUnit the interval of one of given unit (day, month etc).
+func IntervalUnit(unit string) (IntervalValue, bool) {
+	unit = strings.ToLower(unit)
+	if unit != "ms" {
+		unit = strings.TrimSuffix(unit, "s")
+	}
+	switch unit {
+	case "microsecond":
+		return IntervalValue{dur: time.Microsecond}, true
+	case "millisecond", "ms":
+		return IntervalValue{dur: time.Millisecond}, true
+	case "second", "sec":
+		return IntervalValue{dur: time.Second}, true
+	case "minute", "min":
+		return IntervalValue{dur: time.Minute}, true
+	case "hour":
+		return IntervalValue{dur: time.Hour}, true
+	case "day":
+		return IntervalValue{days: 1}, true
+	case "week":
+		return IntervalValue{days: 7}, true
+	case "month", "mon":
+		return IntervalValue{months: 1}, true
+	case "quarter":
+		return IntervalValue{months: 3}, true
+	case "year":
+		return IntervalValue{months: 12}, true
+	}
+	return IntervalValue{}, false
+}
+
+func (m IntervalValue) Nil() bool          { return false }
+func (m IntervalValue) Err() bool          { return false }
+func (m IntervalValue) Type() ValueType    { return IntervalType }
+func (m IntervalValue) Value() interface{} { return m.ToString() }
+func (m IntervalValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.ToString())
+}
+
+// ToString the interval as quantity unit pairs that ParseInterval reads.
+func (m IntervalValue) ToString() string {
+	parts := make([]string, 0, 4)
+	add := func(n int64, unit string) {
+		if n != 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, unit))
+		}
+	}
+	add(int64(m.months/12), "years")
+	add(int64(m.months%12), "months")
+	add(int64(m.days), "days")
+	d := m.dur
+	add(int64(d/time.Hour), "hours")
+	d = d % time.Hour
+	add(int64(d/time.Minute), "minutes")
+	d = d % time.Minute
+	add(int64(d/time.Second), "seconds")
+	d = d % time.Second
+	if d%time.Millisecond == 0 {
+		add(int64(d/time.Millisecond), "milliseconds")
+	} else {
+		add(int64(d/time.Microsecond), "microseconds")
+	}
+	if len(parts) == 0 {
+		return "0 seconds"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Float approximate milliseconds, see Duration.
+func (m IntervalValue) Float() float64 { return float64(m.Int()) }
+
+// Int approximate milliseconds, see Duration.
+func (m IntervalValue) Int() int64 { return int64(m.Duration() / time.Millisecond) }
+
+// Duration approximate length counting months as 30 days and days as 24 hours,
+// for ordering intervals not for date arithmetic.
+func (m IntervalValue) Duration() time.Duration {
+	return time.Duration(m.months)*monthDuration + time.Duration(m.days)*dayDuration + m.dur
+}
+
+// Months, Days and Fixed the parts of the interval.
+func (m IntervalValue) Months() int          { return m.months }
+func (m IntervalValue) Days() int            { return m.days }
+func (m IntervalValue) Fixed() time.Duration { return m.dur }
+
+// Add m + b
+func (m IntervalValue) Add(b IntervalValue) IntervalValue {
+	return IntervalValue{months: m.months + b.months, days: m.days + b.days, dur: m.dur + b.dur}
+}
+
+// Neg -m
+func (m IntervalValue) Neg() IntervalValue {
+	return IntervalValue{months: -m.months, days: -m.days, dur: -m.dur}
+}
+
+// Mul m * n
+func (m IntervalValue) Mul(n int64) IntervalValue {
+	return IntervalValue{months: m.months * int(n), days: m.days * int(n), dur: m.dur * time.Duration(n)}
+}
+
+// AddTo add interval to t, months then days then fixed duration. Month
+// arithmetic keeps the day of month clamped to the length of the month,
+// days keep the wall clock time across daylight savings changes.
+func (m IntervalValue) AddTo(t time.Time) time.Time {
+	if m.months != 0 {
+		t = AddMonths(t, m.months)
+	}
+	if m.days != 0 {
+		t = t.AddDate(0, 0, m.days)
+	}
+	return t.Add(m.dur)
+}
+
+// AddMonths add n months to t, clamping the day to the end of the month
+// instead of overflowing into the next as time.AddDate does.
+//
+//    AddMonths(2017-01-31, 1) => 2017-02-28
+//
+func AddMonths(t time.Time, n int) time.Time {
+	y, mo, d := t.Date()
+	if last := DaysIn(y, mo+time.Month(n)); d > last {
+		d = last
+	}
+	return time.Date(y, mo+time.Month(n), d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// DaysIn number of days in month of year, month may be out of the 1-12
+// range and is normalized.
+func DaysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// IntervalBetween the interval from a to b as whole days and the
+// remaining fixed duration.
+func IntervalBetween(a, b time.Time) IntervalValue {
+	d := b.Sub(a)
+	return IntervalValue{days: int(d / dayDuration), dur: d % dayDuration}
+}
+
+// LoadLocation cached time.LoadLocation, also accepts mysql style offsets.
+//
+//    LoadLocation("America/Denver")
+//    LoadLocation("+05:30")
+//
+func LoadLocation(name string) (*time.Location, bool) {
+	locations.Lock()
+	defer locations.Unlock()
+	if loc, ok := locations.m[name]; ok {
+		return loc, loc != nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil && len(name) == 6 && (name[0] == '+' || name[0] == '-') && name[3] == ':' {
+		h, herr := strconv.Atoi(name[1:3])
+		m, merr := strconv.Atoi(name[4:])
+		if herr == nil && merr == nil {
+			offset := h*3600 + m*60
+			if name[0] == '-' {
+				offset = -offset
+			}
+			loc, err = time.FixedZone(name, offset), nil
+		}
+	}
+	if err != nil {
+		loc = nil
+	}
+	locations.m[name] = loc
+	return loc, loc != nil
+}
+
+// TimeIn t in loc, unless t carries a zone of its own. Parsing and
+// storage default to UTC or Local so those are moved to loc, while a zone
+// from AT TIME ZONE or an offset in the source string is kept. A nil loc
+// leaves t as is.
+func TimeIn(t time.Time, loc *time.Location) time.Time {
+	if loc == nil || t.IsZero() {
+		return t
+	}
+	if l := t.Location(); l == time.UTC || l == time.Local {
+		return t.In(loc)
+	}
+	return t
+}
@@ -0,0 +1,113 @@
+package value
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInterval(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		out string
+	}{
+		{"3 DAY", "3 days"},
+		{"1 year 2 months", "1 years 2 months"},
+		{"2 quarters", "6 months"},
+		{"1 week 36 hours", "7 days 36 hours"},
+		{"-90 minutes", "-1 hours -30 minutes"},
+		{"1500 ms", "1 seconds 500 milliseconds"},
+		{"0 seconds", "0 seconds"},
+	} {
+		iv, err := ParseInterval(tc.in)
+		assert.Equal(t, nil, err, tc.in)
+		assert.Equal(t, tc.out, iv.ToString(), tc.in)
+		assert.Equal(t, IntervalType, iv.Type())
+		// ToString reads back as the same interval
+		iv2, err := ParseInterval(iv.ToString())
+		assert.Equal(t, nil, err, tc.in)
+		assert.Equal(t, iv, iv2, tc.in)
+	}
+	for _, in := range []string{"", "3", "day 3", "3 fortnights", "1.5 hours"} {
+		_, err := ParseInterval(in)
+		assert.NotEqual(t, nil, err, in)
+	}
+}
+
+func TestIntervalAddTo(t *testing.T) {
+	ts := func(y int, mo time.Month, d, h int) time.Time {
+		return time.Date(y, mo, d, h, 0, 0, 0, time.UTC)
+	}
+	for _, tc := range []struct {
+		t   time.Time
+		iv  IntervalValue
+		out time.Time
+	}{
+		{ts(2017, 1, 31, 10), NewIntervalValue(1, 0, 0), ts(2017, 2, 28, 10)},
+		{ts(2016, 1, 31, 10), NewIntervalValue(1, 0, 0), ts(2016, 2, 29, 10)},
+		{ts(2017, 3, 31, 10), NewIntervalValue(-1, 0, 0), ts(2017, 2, 28, 10)},
+		{ts(2017, 12, 15, 10), NewIntervalValue(14, 0, 0), ts(2019, 2, 15, 10)},
+		{ts(2017, 2, 28, 10), NewIntervalValue(0, 1, time.Hour), ts(2017, 3, 1, 11)},
+		{ts(2017, 1, 31, 10), NewIntervalValue(1, 1, 0), ts(2017, 3, 1, 10)},
+	} {
+		assert.Equal(t, tc.out, tc.iv.AddTo(tc.t), "%v + %v", tc.t, tc.iv.ToString())
+	}
+
+	// days keep the wall clock across daylight savings, fixed hours do not
+	ny, err := time.LoadLocation("America/New_York")
+	assert.Equal(t, nil, err)
+	before := time.Date(2017, 3, 11, 12, 0, 0, 0, ny)
+	assert.Equal(t, 12, NewIntervalValue(0, 1, 0).AddTo(before).Hour())
+	assert.Equal(t, 13, NewIntervalValue(0, 0, 24*time.Hour).AddTo(before).Hour())
+}
+
+func TestDateValue(t *testing.T) {
+	dv := NewDateValue(time.Date(2017, 1, 31, 23, 30, 0, 0, time.FixedZone("pst", -8*3600)))
+	assert.Equal(t, "2017-01-31", dv.ToString())
+	assert.Equal(t, DateType, dv.Type())
+	assert.Equal(t, time.Date(2017, 1, 31, 0, 0, 0, 0, time.UTC), dv.Val())
+	by, err := dv.MarshalJSON()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `"2017-01-31"`, string(by))
+	assert.True(t, NewDateValue(time.Time{}).Nil())
+
+	v, err := Cast(DateType, NewStringValue("2017-02-03 04:05:06"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "2017-02-03", v.ToString())
+	v, err = Cast(IntervalType, NewStringValue("3 days"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, NewIntervalValue(0, 3, 0), v)
+}
+
+func TestValueToTimeIn(t *testing.T) {
+	denver, ok := LoadLocation("America/Denver")
+	assert.True(t, ok)
+	utc := time.Date(2017, 1, 31, 10, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		in  Value
+		out time.Time
+	}{
+		{NewStringValue("2017-01-31 03:30"), utc},
+		{NewStringValue("2017-01-31T10:30:00.000Z"), utc},
+		{NewStringValue("2017-01-31T05:30:00-05:00"), utc},
+		{NewTimeValue(utc), utc},
+		{NewDateValue(utc), time.Date(2017, 1, 31, 0, 0, 0, 0, denver)},
+	} {
+		out, ok := ValueToTimeIn(tc.in, utc, denver)
+		assert.True(t, ok, tc.in.ToString())
+		assert.True(t, tc.out.Equal(out), "%s: %v != %v", tc.in.ToString(), tc.out, out)
+	}
+
+	// times default to utc, those are moved, explicit zones are not
+	assert.Equal(t, denver, TimeIn(utc, denver).Location())
+	assert.Equal(t, UTC, TimeIn(utc.In(UTC), denver).Location())
+	assert.Equal(t, utc, TimeIn(utc, nil))
+
+	loc, ok := LoadLocation("-03:30")
+	assert.True(t, ok)
+	_, offset := utc.In(loc).Zone()
+	assert.Equal(t, -12600, offset)
+	_, ok = LoadLocation("Mars/Olympus_Mons")
+	assert.False(t, ok)
+}
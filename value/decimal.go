@@ -0,0 +1,288 @@
+package value
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	// DecimalDivScale is the number of digits of scale added to the
+	// dividend when dividing decimals, same as mysql div_precision_increment.
+	DecimalDivScale = 4
+
+	// ErrDivideByZero decimal division or modulus by zero
+	ErrDivideByZero = fmt.Errorf("Divide by Zero error")
+	// ErrDecimalNil decimal division or modulus with a NULL operand
+	ErrDecimalNil = fmt.Errorf("NULL decimal operand")
+
+	_ NumericValue = DecimalValue{}
+
+	bigOne = big.NewInt(1)
+	bigTen = big.NewInt(10)
+)
+
+// DecimalValue fixed-point decimal of arbitrary precision, the value is
+// unscaled * 10^-scale so 12.50 is unscaled=1250 scale=2.
+type DecimalValue struct {
+	v     *big.Int
+	scale int
+}
+
+// NewDecimalValue create a decimal from its unscaled value and scale.
+func NewDecimalValue(unscaled *big.Int, scale int) DecimalValue {
+	if unscaled == nil {
+		unscaled = new(big.Int)
+	}
+	if scale < 0 {
+		unscaled = new(big.Int).Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return DecimalValue{v: unscaled, scale: scale}
+}
+
+// NewDecimalValueInt create a decimal of scale 0 from an int.
+func NewDecimalValueInt(v int64) DecimalValue {
+	return DecimalValue{v: big.NewInt(v)}
+}
+
+// NewDecimalNil a NULL decimal.
+func NewDecimalNil() DecimalValue {
+	return DecimalValue{}
+}
+
+// ParseDecimal parse a decimal string keeping all of its digits, the scale
+// is the number of digits after the decimal point.
+//
+//    ParseDecimal("12.50")  => 12.50 (precision 4, scale 2)
+//    ParseDecimal("-1e-3")  => -0.001
+//
+func ParseDecimal(s string) (DecimalValue, error) {
+	s = strings.TrimSpace(s)
+	exp := 0
+	if pos := strings.IndexAny(s, "eE"); pos > 0 {
+		e, err := strconv.Atoi(s[pos+1:])
+		if err != nil {
+			return NewDecimalNil(), fmt.Errorf("invalid decimal %q", s)
+		}
+		exp = e
+		s = s[:pos]
+	}
+	digits := s
+	if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+		digits = digits[1:]
+	}
+	scale := 0
+	if pos := strings.IndexByte(digits, '.'); pos >= 0 {
+		scale = len(digits) - pos - 1
+		digits = digits[:pos] + digits[pos+1:]
+	}
+	if len(digits) == 0 {
+		return NewDecimalNil(), fmt.Errorf("invalid decimal %q", s)
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return NewDecimalNil(), fmt.Errorf("invalid decimal %q", s)
+		}
+	}
+	v, _ := new(big.Int).SetString(digits, 10)
+	if s[0] == '-' {
+		v.Neg(v)
+	}
+	return NewDecimalValue(v, scale-exp), nil
+}
+
+func (m DecimalValue) Nil() bool       { return m.v == nil }
+func (m DecimalValue) Err() bool       { return false }
+func (m DecimalValue) Type() ValueType { return DecimalType }
+
+// Value is the exact decimal string, same as sql drivers return DECIMAL.
+func (m DecimalValue) Value() interface{} { return m.ToString() }
+func (m DecimalValue) MarshalJSON() ([]byte, error) {
+	if m.v == nil {
+		return []byte("null"), nil
+	}
+	return []byte(m.ToString()), nil
+}
+func (m DecimalValue) ToString() string {
+	if m.v == nil {
+		return ""
+	}
+	s := new(big.Int).Abs(m.v).String()
+	if m.scale > 0 {
+		if len(s) <= m.scale {
+			s = strings.Repeat("0", m.scale-len(s)+1) + s
+		}
+		s = s[:len(s)-m.scale] + "." + s[len(s)-m.scale:]
+	}
+	if m.v.Sign() < 0 {
+		return "-" + s
+	}
+	return s
+}
+func (m DecimalValue) Float() float64 {
+	f, _ := strconv.ParseFloat(m.ToString(), 64)
+	return f
+}
+
+// Int truncates the fractional digits.
+func (m DecimalValue) Int() int64 {
+	if m.v == nil {
+		return 0
+	}
+	return new(big.Int).Quo(m.v, pow10(m.scale)).Int64()
+}
+func (m DecimalValue) NumberValue() NumberValue { return NewNumberValue(m.Float()) }
+
+// Unscaled the integer value of the decimal without its decimal point.
+func (m DecimalValue) Unscaled() *big.Int { return m.v }
+
+// Scale number of digits after the decimal point.
+func (m DecimalValue) Scale() int { return m.scale }
+
+// Precision total number of significant digits, at least the scale.
+func (m DecimalValue) Precision() int {
+	if m.v == nil {
+		return 0
+	}
+	p := len(new(big.Int).Abs(m.v).String())
+	if p < m.scale {
+		return m.scale
+	}
+	return p
+}
+
+// Rat the decimal as a big.Rat
+func (m DecimalValue) Rat() *big.Rat {
+	if m.v == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).SetFrac(m.v, pow10(m.scale))
+}
+
+// Sign -1, 0, +1
+func (m DecimalValue) Sign() int {
+	if m.v == nil {
+		return 0
+	}
+	return m.v.Sign()
+}
+
+// Rescale to given scale, digits dropped are rounded half away from zero.
+func (m DecimalValue) Rescale(scale int) DecimalValue {
+	if m.v == nil || scale == m.scale {
+		return m
+	}
+	if scale > m.scale {
+		return DecimalValue{v: new(big.Int).Mul(m.v, pow10(scale-m.scale)), scale: scale}
+	}
+	return DecimalValue{v: quoRound(m.v, pow10(m.scale-scale)), scale: scale}
+}
+
+// Add m + b, scale of result is the larger of the two scales.  NULL if
+// either is NULL, same for Sub and Mul.
+func (m DecimalValue) Add(b DecimalValue) DecimalValue {
+	if m.Nil() || b.Nil() {
+		return NewDecimalNil()
+	}
+	x, y := align(m, b)
+	return DecimalValue{v: new(big.Int).Add(x.v, y.v), scale: x.scale}
+}
+
+// Sub m - b, scale of result is the larger of the two scales.
+func (m DecimalValue) Sub(b DecimalValue) DecimalValue {
+	if m.Nil() || b.Nil() {
+		return NewDecimalNil()
+	}
+	x, y := align(m, b)
+	return DecimalValue{v: new(big.Int).Sub(x.v, y.v), scale: x.scale}
+}
+
+// Mul m * b, scale of result is the sum of the scales.
+func (m DecimalValue) Mul(b DecimalValue) DecimalValue {
+	if m.Nil() || b.Nil() {
+		return NewDecimalNil()
+	}
+	return DecimalValue{v: new(big.Int).Mul(m.v, b.v), scale: m.scale + b.scale}
+}
+
+// Quo m / b rounded to the scale of m plus DecimalDivScale.
+func (m DecimalValue) Quo(b DecimalValue) (DecimalValue, error) {
+	return m.QuoScale(b, m.scale+DecimalDivScale)
+}
+
+// QuoScale m / b rounded to given scale.
+func (m DecimalValue) QuoScale(b DecimalValue, scale int) (DecimalValue, error) {
+	if m.Nil() || b.Nil() {
+		return NewDecimalNil(), ErrDecimalNil
+	}
+	if b.v.Sign() == 0 {
+		return NewDecimalNil(), ErrDivideByZero
+	}
+	// m.v * 10^(scale - m.scale + b.scale) / b.v
+	num, den := new(big.Int).Set(m.v), new(big.Int).Set(b.v)
+	if shift := scale - m.scale + b.scale; shift >= 0 {
+		num.Mul(num, pow10(shift))
+	} else {
+		den.Mul(den, pow10(-shift))
+	}
+	return DecimalValue{v: quoRound(num, den), scale: scale}, nil
+}
+
+// Mod remainder of m / b with the sign of m.
+func (m DecimalValue) Mod(b DecimalValue) (DecimalValue, error) {
+	if m.Nil() || b.Nil() {
+		return NewDecimalNil(), ErrDecimalNil
+	}
+	if b.v.Sign() == 0 {
+		return NewDecimalNil(), ErrDivideByZero
+	}
+	x, y := align(m, b)
+	return DecimalValue{v: new(big.Int).Rem(x.v, y.v), scale: x.scale}, nil
+}
+
+// Cmp compares m and b returning -1, 0, +1, NULL sorts before any value.
+func (m DecimalValue) Cmp(b DecimalValue) int {
+	switch {
+	case m.Nil() && b.Nil():
+		return 0
+	case m.Nil():
+		return -1
+	case b.Nil():
+		return 1
+	}
+	x, y := align(m, b)
+	return x.v.Cmp(y.v)
+}
+
+// align rescales a and b to the larger of their scales, neither may be NULL.
+func align(a, b DecimalValue) (DecimalValue, DecimalValue) {
+	if a.scale < b.scale {
+		return a.Rescale(b.scale), b
+	}
+	return a, b.Rescale(a.scale)
+}
+
+// quoRound a / b rounding half away from zero.
+func quoRound(a, b *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+	// |2r| >= |b| rounds away from zero
+	r2 := new(big.Int).Abs(r)
+	r2.Lsh(r2, 1)
+	if r2.Cmp(new(big.Int).Abs(b)) >= 0 {
+		if (a.Sign() < 0) != (b.Sign() < 0) {
+			q.Sub(q, bigOne)
+		} else {
+			q.Add(q, bigOne)
+		}
+	}
+	return q
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,99 @@
+package value
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustDecimal(t *testing.T, s string) DecimalValue {
+	dv, err := ParseDecimal(s)
+	assert.Equal(t, nil, err, s)
+	return dv
+}
+
+func TestDecimalParse(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		out   string
+		prec  int
+		scale int
+	}{
+		{"12.50", "12.50", 4, 2},
+		{"-0.05", "-0.05", 2, 2},
+		{"+7", "7", 1, 0},
+		{"1.", "1", 1, 0},
+		{".5", "0.5", 1, 1},
+		{"1.5e2", "150", 3, 0},
+		{"-1e-3", "-0.001", 3, 3},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789", 39, 9},
+	} {
+		dv := mustDecimal(t, tc.in)
+		assert.Equal(t, tc.out, dv.ToString(), tc.in)
+		assert.Equal(t, tc.prec, dv.Precision(), tc.in)
+		assert.Equal(t, tc.scale, dv.Scale(), tc.in)
+		assert.Equal(t, DecimalType, dv.Type())
+	}
+	for _, in := range []string{"", "-", ".", "1.2.3", "abc", "1e", "12,50"} {
+		_, err := ParseDecimal(in)
+		assert.NotEqual(t, nil, err, in)
+	}
+
+	assert.True(t, NewDecimalNil().Nil())
+	by, err := json.Marshal(mustDecimal(t, "10.10"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.10", string(by))
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	d := func(s string) DecimalValue { return mustDecimal(t, s) }
+
+	// float64 0.1 + 0.2 != 0.3
+	assert.Equal(t, "0.3", d("0.1").Add(d("0.2")).ToString())
+	assert.Equal(t, 0, d("0.1").Add(d("0.2")).Cmp(d("0.30")))
+	assert.Equal(t, "9.95", d("10").Sub(d("0.05")).ToString())
+	assert.Equal(t, "3.7500", d("1.50").Mul(d("2.50")).ToString())
+	assert.Equal(t, "-1", d("1").Sub(d("2")).ToString())
+
+	q, err := d("10").Quo(d("3"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "3.3333", q.ToString())
+	q, _ = d("2.00").Quo(d("3"))
+	assert.Equal(t, "0.666667", q.ToString())
+	q, _ = d("-2.00").Quo(d("3"))
+	assert.Equal(t, "-0.666667", q.ToString())
+	_, err = d("1").Quo(d("0.00"))
+	assert.Equal(t, ErrDivideByZero, err)
+
+	m, err := d("10.5").Mod(d("3"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "1.5", m.ToString())
+
+	assert.Equal(t, "2.68", d("2.675").Rescale(2).ToString())
+	assert.Equal(t, "-2.68", d("-2.675").Rescale(2).ToString())
+	assert.Equal(t, "2.6750", d("2.675").Rescale(4).ToString())
+
+	assert.Equal(t, 1, d("10.01").Cmp(d("10")))
+	assert.Equal(t, -1, d("-10.01").Cmp(d("-10")))
+	assert.Equal(t, int64(-10), d("-10.99").Int())
+	assert.Equal(t, 10.99, d("10.99").Float())
+
+	// NULL operands give NULL, or an error for division and modulus
+	null := NewDecimalNil()
+	assert.True(t, d("1").Add(null).Nil())
+	assert.True(t, null.Sub(d("1")).Nil())
+	assert.True(t, d("1").Mul(null).Nil())
+	_, err = null.Quo(d("1"))
+	assert.Equal(t, ErrDecimalNil, err)
+	_, err = d("1").Mod(null)
+	assert.Equal(t, ErrDecimalNil, err)
+	assert.Equal(t, 0, null.Cmp(null))
+	assert.Equal(t, -1, null.Cmp(d("-1")))
+	assert.Equal(t, 1, d("-1").Cmp(null))
+	eq, err := Equal(null, NewIntValue(0))
+	assert.Equal(t, nil, err)
+	assert.False(t, eq)
+	eq, _ = Equal(NewIntValue(0), null)
+	assert.False(t, eq)
+}
@@ -60,6 +60,9 @@ const (
 	BoolType           ValueType = 12
 	TimeType           ValueType = 13
 	ByteSliceType      ValueType = 14
+	DecimalType        ValueType = 15
+	DateType           ValueType = 16
+	IntervalType       ValueType = 17
 	StringType         ValueType = 20
 	StringsType        ValueType = 21
 	MapValueType       ValueType = 30
@@ -93,6 +96,12 @@ func (m ValueType) String() string {
 		return "time"
 	case ByteSliceType:
 		return "[]byte"
+	case DecimalType:
+		return "decimal"
+	case DateType:
+		return "date"
+	case IntervalType:
+		return "interval"
 	case StringType:
 		return "string"
 	case StringsType:
@@ -138,7 +147,7 @@ func (m ValueType) IsSlice() bool {
 
 func (m ValueType) IsNumeric() bool {
 	switch m {
-	case NumberType, IntType:
+	case NumberType, IntType, DecimalType:
 		return true
 	}
 	return false
@@ -255,6 +264,12 @@ func ValueFromString(vt string) ValueType {
 		return TimeType
 	case "[]byte":
 		return ByteSliceType
+	case "decimal":
+		return DecimalType
+	case "date":
+		return DateType
+	case "interval":
+		return IntervalType
 	case "string":
 		return StringType
 	case "[]string":
@@ -24,6 +24,10 @@ go tool pprof _bm cpu.prof
 go build && time ./_bm --command=vm --cpuprofile=cpu.prof
 go tool pprof _bm cpu.prof
 
+# compare filter evaluation, walking the ast vs compiled program
+go build && time ./_bm --command=filter
+go build && time ./_bm --command=filtercompiled
+
 
 */
 var (
@@ -32,6 +36,8 @@ var (
 	logging        = "info"
 	command        = "parse"
 
+	filterQl = `FILTER AND (int5 > 2, item_count == "5", user_id IN ("abc", "def", "xyz"), user_id LIKE "a*")`
+
 	msg = datasource.NewContextSimpleTs(
 		map[string]value.Value{
 			"int5":       value.NewIntValue(5),
@@ -47,7 +53,7 @@ func init() {
 	flag.StringVar(&logging, "logging", "info", "logging [ debug,info ]")
 	flag.StringVar(&cpuProfileFile, "cpuprofile", "", "cpuprofile")
 	flag.StringVar(&memProfileFile, "memprofile", "", "memProfileFile")
-	flag.StringVar(&command, "command", "parse", "command to run [parse,vm]")
+	flag.StringVar(&command, "command", "parse", "command to run [parse,vm,filter,filtercompiled]")
 	flag.Parse()
 
 	builtins.LoadAllBuiltins()
@@ -71,6 +77,12 @@ func main() {
 
 	case "vm":
 		runVm(100000, `select user_id, item_count * 2 as itemsx2, yy(reg_date) > 10 as regyy FROM stdio`, msg)
+
+	case "filter":
+		runFilter(1000000, filterQl, msg, false)
+
+	case "filtercompiled":
+		runFilter(1000000, filterQl, msg, true)
 	}
 }
 
@@ -104,3 +116,28 @@ func runVm(repeat int, sql string, readContext expr.ContextReader) {
 		}
 	}
 }
+
+func runFilter(repeat int, ql string, readContext expr.EvalContext, compiled bool) {
+	fs, err := rel.ParseFilterQL(ql)
+	if err != nil {
+		panic(err.Error())
+	}
+	prog, err := vm.Compile(fs.Filter)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	start := time.Now()
+	for i := 0; i < repeat; i++ {
+		var matches, ok bool
+		if compiled {
+			matches, ok = prog.Matches(readContext)
+		} else {
+			matches, ok = vm.Matches(readContext, fs)
+		}
+		if !ok || !matches {
+			panic("expected filter to match")
+		}
+	}
+	log.Printf("compiled=%v %d evaluations in %v", compiled, repeat, time.Since(start))
+}
@@ -0,0 +1,631 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/value"
+)
+
+// Program is an expression compiled once into a tree of closures so that
+// evaluating it per row does not re-walk and type-switch over the AST.
+// Results are the same as Eval/MatchesExpr of the original node.
+type Program interface {
+	// Eval the program against a context, same as vm.Eval(ctx, node)
+	Eval(ctx expr.EvalContext) (value.Value, bool)
+	// Matches evaluates a boolean program, same as vm.MatchesExpr(ctx, node)
+	Matches(ctx expr.EvalContext) (bool, bool)
+	// Node the expression this program was compiled from
+	Node() expr.Node
+}
+
+type evalFunc func(ctx expr.EvalContext) (value.Value, bool)
+
+// compiled is a compiled node, constants are folded at compile time
+// and are available to the parent node for specializing operators.
+type compiled struct {
+	fn    evalFunc
+	konst bool
+	val   value.Value
+	ok    bool
+}
+
+type program struct {
+	node     expr.Node
+	matchAll bool
+	c        *compiled
+}
+
+var _ Program = (*program)(nil)
+
+// Compile an expression into a reusable Program.  Function evaluators
+// are resolved, literals are constant-folded and operators against
+// literals are specialized once instead of per evaluation.
+//
+//    prog, err := vm.Compile(node)
+//    for _, row := range rows {
+//        matches, ok := prog.Matches(row)
+//    }
+func Compile(node expr.Node) (Program, error) {
+	c, err := compileDepth(node, 0)
+	if err != nil {
+		return nil, err
+	}
+	p := &program{node: node, c: c}
+	if in, ok := node.(*expr.IdentityNode); ok {
+		p.matchAll = in.Text == "*" || in.Text == "match_all"
+	}
+	return p, nil
+}
+
+// ProgramCacheSize is the number of compiled expressions Matches and
+// MatchesExpr keep, the cache is emptied when full.
+var ProgramCacheSize = 1000
+
+// programs are the compiled expressions of Matches and MatchesExpr keyed
+// by node, nil if the node doesn't compile.
+var programs = struct {
+	mu    sync.RWMutex
+	progs map[expr.Node]Program
+}{progs: make(map[expr.Node]Program)}
+
+func cachedProgram(node expr.Node) Program {
+	programs.mu.RLock()
+	prog, ok := programs.progs[node]
+	programs.mu.RUnlock()
+	if ok {
+		return prog
+	}
+	prog, err := Compile(node)
+	if err != nil {
+		u.Debugf("could not compile %s, using eval err=%v", node, err)
+		prog = nil
+	}
+	programs.mu.Lock()
+	if len(programs.progs) >= ProgramCacheSize {
+		programs.progs = make(map[expr.Node]Program)
+	}
+	programs.progs[node] = prog
+	programs.mu.Unlock()
+	return prog
+}
+
+func (m *program) Node() expr.Node { return m.node }
+func (m *program) Eval(ctx expr.EvalContext) (value.Value, bool) {
+	return m.c.fn(ctx)
+}
+func (m *program) Matches(ctx expr.EvalContext) (bool, bool) {
+	if m.matchAll {
+		return true, true
+	}
+	val, ok := m.c.fn(ctx)
+	if !ok || val == nil {
+		return false, ok
+	}
+	if bv, isBool := val.(value.BoolValue); isBool {
+		return bv.Val(), ok
+	}
+	return false, true
+}
+
+func konst(v value.Value, ok bool) *compiled {
+	return &compiled{konst: true, val: v, ok: ok, fn: func(expr.EvalContext) (value.Value, bool) {
+		return v, ok
+	}}
+}
+
+func dynamic(fn evalFunc) *compiled {
+	return &compiled{fn: fn}
+}
+
+// fold evaluates an operator on constant arguments at compile time, if the
+// operator panics it is left to panic at runtime same as the interpreter.
+func fold(op func() (value.Value, bool)) (c *compiled) {
+	defer func() {
+		if r := recover(); r != nil {
+			c = nil
+		}
+	}()
+	return konst(op())
+}
+
+func compileDepth(arg expr.Node, depth int) (*compiled, error) {
+	if depth > MaxDepth {
+		return nil, ErrMaxDepth
+	}
+
+	switch n := arg.(type) {
+	case *expr.NumberNode:
+		return konst(numberNodeToValue(n)), nil
+	case *expr.StringNode:
+		return konst(value.NewStringValue(n.Text), true), nil
+	case nil:
+		return konst(nil, false), nil
+	case *expr.NullNode:
+		return konst(value.NewNilValue(), true), nil
+	case *expr.ValueNode:
+		if n.Value == nil {
+			return konst(nil, false), nil
+		}
+		switch val := n.Value.(type) {
+		case *value.NilValue, value.NilValue:
+			return konst(value.NilValueVal, true), nil
+		case value.SliceValue, value.IntervalValue:
+			return konst(val, true), nil
+		}
+		return nil, fmt.Errorf("%v: %T", ErrUnknownNodeType, n.Value)
+	case *expr.IdentityNode:
+		return compileIdentity(n), nil
+	case *expr.BinaryNode:
+		return compileBinary(n, depth)
+	case *expr.BooleanNode:
+		return compileBoolean(n, depth)
+	case *expr.UnaryNode:
+		return compileUnary(n, depth)
+	case *expr.TriNode:
+		return compileTernary(n, depth)
+	case *expr.CaseNode:
+		return compileCase(n, depth)
+	case *expr.ArrayNode:
+		return compileArray(n, depth)
+	case *expr.FuncNode:
+		return compileFunc(n, depth)
+	case *expr.IncludeNode:
+		// includes are resolved against the context at runtime
+		return dynamic(func(ctx expr.EvalContext) (value.Value, bool) {
+			return walkInclude(ctx, n, depth+1)
+		}), nil
+	}
+	return nil, fmt.Errorf("%v: %T", ErrUnknownNodeType, arg)
+}
+
+func compileArgs(args []expr.Node, depth int) ([]*compiled, bool, error) {
+	out := make([]*compiled, len(args))
+	allKonst := true
+	for i, arg := range args {
+		c, err := compileDepth(arg, depth+1)
+		if err != nil {
+			return nil, false, err
+		}
+		allKonst = allKonst && c.konst
+		out[i] = c
+	}
+	return out, allKonst, nil
+}
+
+func compileIdentity(n *expr.IdentityNode) *compiled {
+	if n.IsBooleanIdentity() {
+		return konst(value.NewBoolValue(n.Bool()), true)
+	}
+	key := n.Text
+	if n.HasLeftRight() {
+		key = n.OriginalText()
+	}
+	return dynamic(func(ctx expr.EvalContext) (value.Value, bool) {
+		if ctx == nil {
+			return nil, false
+		}
+		return ctx.Get(key)
+	})
+}
+
+func compileBoolean(n *expr.BooleanNode, depth int) (*compiled, error) {
+	var and bool
+	switch n.Operator.T {
+	case lex.TokenAnd, lex.TokenLogicAnd:
+		and = true
+	case lex.TokenOr, lex.TokenLogicOr:
+		and = false
+	default:
+		u.Warnf("un-recognized operator %v", n.Operator)
+		return konst(value.BoolValueFalse, false), nil
+	}
+	args, _, err := compileArgs(n.Args, depth)
+	if err != nil {
+		return nil, err
+	}
+	fns := make([]evalFunc, len(args))
+	for i, c := range args {
+		fns[i] = c.fn
+	}
+	negated := n.Negated()
+	// the result if no argument short-circuits
+	done := value.NewBoolValue(and != negated)
+
+	return dynamic(func(ctx expr.EvalContext) (value.Value, bool) {
+		unknown := false
+		for _, fn := range fns {
+			val, ok := fn(ctx)
+			if isNull(val, ok) {
+				unknown = true
+				continue
+			}
+			matches, ok := toBool(val, ok)
+			if !ok && and {
+				return nil, false
+			} else if !ok {
+				continue
+			}
+			if matches != and {
+				// OR matched or AND did not match, shortcircuit
+				return value.NewBoolValue(matches != negated), true
+			}
+		}
+		if unknown {
+			return value.NilValueVal, true
+		}
+		return done, true
+	}), nil
+}
+
+func toBool(val value.Value, ok bool) (bool, bool) {
+	if !ok || val == nil {
+		return false, false
+	}
+	if bv, isBool := val.(value.BoolValue); isBool {
+		return bv.Val(), true
+	}
+	return false, false
+}
+
+func compileUnary(n *expr.UnaryNode, depth int) (*compiled, error) {
+	arg, err := compileDepth(n.Arg, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	if arg.konst {
+		if c := fold(func() (value.Value, bool) { return operateUnary(n, arg.val, arg.ok) }); c != nil {
+			return c, nil
+		}
+	}
+	fn := arg.fn
+	return dynamic(func(ctx expr.EvalContext) (value.Value, bool) {
+		a, ok := fn(ctx)
+		return operateUnary(n, a, ok)
+	}), nil
+}
+
+func compileTernary(n *expr.TriNode, depth int) (*compiled, error) {
+	args, allKonst, err := compileArgs(n.Args, depth)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 3 {
+		return nil, fmt.Errorf("vm: ternary expression requires 3 arguments: %s", n)
+	}
+	a, b, c := args[0], args[1], args[2]
+	if allKonst {
+		if f := fold(func() (value.Value, bool) {
+			return operateTernary(n, a.val, a.ok, b.val, b.ok, c.val, c.ok)
+		}); f != nil {
+			return f, nil
+		}
+	}
+	return dynamic(func(ctx expr.EvalContext) (value.Value, bool) {
+		av, aok := a.fn(ctx)
+		bv, bok := b.fn(ctx)
+		cv, cok := c.fn(ctx)
+		return operateTernary(n, av, aok, bv, bok, cv, cok)
+	}), nil
+}
+
+func compileCase(n *expr.CaseNode, depth int) (*compiled, error) {
+	if len(n.Whens) != len(n.Thens) {
+		return nil, fmt.Errorf("vm: case requires a then per when: %s", n)
+	}
+	whens, _, err := compileArgs(n.Whens, depth)
+	if err != nil {
+		return nil, err
+	}
+	thens, _, err := compileArgs(n.Thens, depth)
+	if err != nil {
+		return nil, err
+	}
+	elseC := konst(value.NilValueVal, true)
+	if n.Else != nil {
+		if elseC, err = compileDepth(n.Else, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	if n.Operand == nil {
+		return dynamic(func(ctx expr.EvalContext) (value.Value, bool) {
+			for i, when := range whens {
+				if bv, ok := when.fn(ctx); ok {
+					if b, isBool := bv.(value.BoolValue); isBool && b.Val() {
+						return thens[i].fn(ctx)
+					}
+				}
+			}
+			return elseC.fn(ctx)
+		}), nil
+	}
+	operand, err := compileDepth(n.Operand, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	eqs := make([]*expr.BinaryNode, len(n.Whens))
+	for i, when := range n.Whens {
+		eqs[i] = expr.NewBinaryNode(caseEqual, n.Operand, when)
+	}
+	return dynamic(func(ctx expr.EvalContext) (value.Value, bool) {
+		a, aok := operand.fn(ctx)
+		for i, when := range whens {
+			w, wok := when.fn(ctx)
+			if caseMatches(eqs[i], a, aok, w, wok) {
+				return thens[i].fn(ctx)
+			}
+		}
+		return elseC.fn(ctx)
+	}), nil
+}
+
+func compileArray(n *expr.ArrayNode, depth int) (*compiled, error) {
+	args, allKonst, err := compileArgs(n.Args, depth)
+	if err != nil {
+		return nil, err
+	}
+	if allKonst {
+		vals := make([]value.Value, len(args))
+		for i, c := range args {
+			vals[i] = c.val
+		}
+		return konst(value.NewSliceValues(vals), true), nil
+	}
+	return dynamic(func(ctx expr.EvalContext) (value.Value, bool) {
+		vals := make([]value.Value, len(args))
+		for i, c := range args {
+			vals[i], _ = c.fn(ctx)
+		}
+		return value.NewSliceValues(vals), true
+	}), nil
+}
+
+func compileFunc(n *expr.FuncNode, depth int) (*compiled, error) {
+	if n.F.CustomFunc == nil {
+		return konst(nil, false), nil
+	}
+	if n.Eval == nil {
+		return nil, fmt.Errorf("vm: no evaluator for function %s", n.Name)
+	}
+	args, _, err := compileArgs(n.Args, depth)
+	if err != nil {
+		return nil, err
+	}
+	eval := n.Eval
+	if uf, ok := n.F.CustomFunc.(*expr.UserFunc); ok {
+		var body *compiled
+		if !uf.IsExternal() {
+			if body, err = compileDepth(uf.Body, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		eval = func(ctx expr.EvalContext, vals []value.Value) (value.Value, bool) {
+			// the compiled body is only used while uf is still current
+			if cur, ok := uf.Current(); ok && cur == uf && body != nil {
+				return body.fn(newFuncArgsContext(ctx, uf, vals))
+			}
+			return evalUserFunc(ctx, n, uf, vals, depth)
+		}
+	}
+	return dynamic(func(ctx expr.EvalContext) (value.Value, bool) {
+		vals := make([]value.Value, len(args))
+		for i, c := range args {
+			v, ok := c.fn(ctx)
+			if !ok {
+				v = value.NewNilValue()
+			}
+			vals[i] = v
+		}
+		return eval(ctx, vals)
+	}), nil
+}
+
+func compileBinary(n *expr.BinaryNode, depth int) (*compiled, error) {
+	if len(n.Args) != 2 {
+		return nil, fmt.Errorf("vm: binary expression requires 2 arguments: %s", n)
+	}
+	lhs, err := compileDepth(n.Args[0], depth+1)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := compileDepth(n.Args[1], depth+1)
+	if err != nil {
+		return nil, err
+	}
+	if lhs.konst && rhs.konst {
+		if c := fold(func() (value.Value, bool) {
+			return operateBinary(n, lhs.val, lhs.ok, rhs.val, rhs.ok)
+		}); c != nil {
+			return c, nil
+		}
+	}
+	if rhs.konst && rhs.ok && rhs.val != nil {
+		if fn := specializeBinary(n, lhs.fn, rhs.val); fn != nil {
+			return dynamic(fn), nil
+		}
+	}
+	lf, rf := lhs.fn, rhs.fn
+	return dynamic(func(ctx expr.EvalContext) (value.Value, bool) {
+		ar, aok := lf(ctx)
+		br, bok := rf(ctx)
+		ar, br = sessionTimes(ctx, n.Operator.T, ar, br)
+		return operateBinary(n, ar, aok, br, bok)
+	}), nil
+}
+
+// specializeBinary creates a fast path for the common filter case of
+// comparing a field to a literal.  Any value the fast path does not handle
+// falls through to operateBinary.
+func specializeBinary(n *expr.BinaryNode, lf evalFunc, bv value.Value) evalFunc {
+
+	op := n.Operator.T
+	slow := func(ctx expr.EvalContext, ar value.Value, aok bool) (value.Value, bool) {
+		ar, br := sessionTimes(ctx, op, ar, bv)
+		return operateBinary(n, ar, aok, br, true)
+	}
+
+	switch op {
+	case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenNE,
+		lex.TokenGT, lex.TokenGE, lex.TokenLT, lex.TokenLE:
+
+		switch bt := bv.(type) {
+		case value.IntValue:
+			b, fb := bt.Val(), float64(bt.Val())
+			return func(ctx expr.EvalContext) (value.Value, bool) {
+				ar, aok := lf(ctx)
+				if aok {
+					switch at := ar.(type) {
+					case value.IntValue:
+						return boolValue(compareInts(op, at.Val(), b)), true
+					case value.NumberValue:
+						return boolValue(compareFloats(op, at.Val(), fb)), true
+					}
+				}
+				return slow(ctx, ar, aok)
+			}
+		case value.NumberValue:
+			b := bt.Val()
+			return func(ctx expr.EvalContext) (value.Value, bool) {
+				ar, aok := lf(ctx)
+				if aok {
+					switch at := ar.(type) {
+					case value.IntValue:
+						return boolValue(compareFloats(op, float64(at.Val()), b)), true
+					case value.NumberValue:
+						return boolValue(compareFloats(op, at.Val(), b)), true
+					}
+				}
+				return slow(ctx, ar, aok)
+			}
+		case value.StringValue:
+			b := bt.Val()
+			strOp := op == lex.TokenEqual || op == lex.TokenEqualEqual || op == lex.TokenNE
+			// date-math strings ("now-3d") are relative to evaluation time
+			// so can't be parsed up front
+			var rht time.Time
+			timeOk := false
+			if len(b) <= 3 || strings.ToLower(b[:3]) != "now" {
+				rht, timeOk = value.StringToTimeAnchor(b, time.Now())
+			}
+			if !strOp && !timeOk {
+				return nil
+			}
+			return func(ctx expr.EvalContext) (value.Value, bool) {
+				ar, aok := lf(ctx)
+				if aok {
+					switch at := ar.(type) {
+					case value.StringValue:
+						if strOp {
+							return boolValue((at.Val() == b) != (op == lex.TokenNE)), true
+						}
+					case value.TimeValue:
+						if timeOk && sessionZone(ctx) == nil {
+							return operateTime(op, at.Val(), rht)
+						}
+					}
+				}
+				return slow(ctx, ar, aok)
+			}
+		}
+
+	case lex.TokenIN:
+		sv, ok := bv.(value.SliceValue)
+		if !ok {
+			return nil
+		}
+		strs := make(map[string]struct{}, sv.Len())
+		ints := make(map[int64]struct{}, sv.Len())
+		for _, v := range sv.Val() {
+			strs[v.ToString()] = struct{}{}
+			if iv, ok := value.ValueToInt64(v); ok {
+				ints[iv] = struct{}{}
+			}
+		}
+		return func(ctx expr.EvalContext) (value.Value, bool) {
+			ar, aok := lf(ctx)
+			if aok {
+				switch at := ar.(type) {
+				case value.StringValue:
+					_, found := strs[at.Val()]
+					return boolValue(found), true
+				case value.IntValue:
+					_, found := ints[at.Val()]
+					return boolValue(found), true
+				}
+			}
+			return slow(ctx, ar, aok)
+		}
+
+	case lex.TokenLike, lex.TokenILike, lex.TokenRegexp:
+		bt, ok := bv.(value.StringValue)
+		if !ok {
+			return nil
+		}
+		escape := DefaultLikeEscape
+		if op == lex.TokenRegexp {
+			escape = ""
+		}
+		m, err := compilePattern(op, n.LikePattern(bt.Val()), escape)
+		if err != nil {
+			// left to the slow path to surface per evaluation
+			return nil
+		}
+		return func(ctx expr.EvalContext) (value.Value, bool) {
+			ar, aok := lf(ctx)
+			if at, isStr := ar.(value.StringValue); isStr && aok {
+				return boolValue(m.match(at.Val())), true
+			}
+			return slow(ctx, ar, aok)
+		}
+	}
+	return nil
+}
+
+func boolValue(b bool) value.BoolValue {
+	if b {
+		return value.BoolValueTrue
+	}
+	return value.BoolValueFalse
+}
+
+func compareInts(op lex.TokenType, a, b int64) bool {
+	switch op {
+	case lex.TokenEqual, lex.TokenEqualEqual:
+		return a == b
+	case lex.TokenNE:
+		return a != b
+	case lex.TokenGT:
+		return a > b
+	case lex.TokenGE:
+		return a >= b
+	case lex.TokenLT:
+		return a < b
+	case lex.TokenLE:
+		return a <= b
+	}
+	return false
+}
+
+func compareFloats(op lex.TokenType, a, b float64) bool {
+	switch op {
+	case lex.TokenEqual, lex.TokenEqualEqual:
+		return a == b
+	case lex.TokenNE:
+		return a != b
+	case lex.TokenGT:
+		return a > b
+	case lex.TokenGE:
+		return a >= b
+	case lex.TokenLT:
+		return a < b
+	case lex.TokenLE:
+		return a <= b
+	}
+	return false
+}
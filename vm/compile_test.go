@@ -0,0 +1,89 @@
+package vm_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/expr"
+	"github.com/araddon/qlbridge/rel"
+	"github.com/araddon/qlbridge/value"
+	"github.com/araddon/qlbridge/vm"
+)
+
+func valString(v value.Value, ok bool) string {
+	if v == nil {
+		return fmt.Sprintf("<nil> %v", ok)
+	}
+	return fmt.Sprintf("%T %v %v", v, v.ToString(), ok)
+}
+
+// The compiled program must evaluate exactly the same as the interpreter.
+func TestCompileMatchesEval(t *testing.T) {
+	for _, test := range vmTests {
+		n, err := expr.ParseExpression(test.qlText)
+		if err != nil {
+			continue
+		}
+		prog, err := vm.Compile(n)
+		assert.Equal(t, nil, err, test.qlText)
+
+		expected := valString(vm.Eval(test.context, n))
+		assert.Equal(t, expected, valString(prog.Eval(test.context)), test.qlText)
+	}
+}
+
+func TestCompileFilterQL(t *testing.T) {
+	for _, q := range []string{
+		`FILTER *`,
+		`FILTER AND (name == "Yoda", city == "Peoria", zip == 5, lastvisit > "now-2d")`,
+		`FILTER OR (roles INTERSECTS ("user", "admin"), NOT name IN ("bob", "yoda"))`,
+		`FILTER AND (zip IN (5, 6, 7), zip > 4.5, zip <= 5, name LIKE "Yo*", city != "Chicago")`,
+		`FILTER AND (zip IN (5, 6, 7), zip > 4.5, zip <= 5, name LIKE "Yo%", city != "Chicago")`,
+		`FILTER AND (EXISTS name, NOT EXISTS not_a_field, zip BETWEEN 1 AND 10)`,
+		`FILTER lastvisit BETWEEN "2011-01-01" AND "now"`,
+	} {
+		fs, err := rel.ParseFilterQL(q)
+		assert.Equal(t, nil, err, q)
+		prog, err := vm.Compile(fs.Filter)
+		assert.Equal(t, nil, err, q)
+		for _, row := range compileRows {
+			em, eok := vm.MatchesExpr(row, fs.Filter)
+			cm, cok := prog.Matches(row)
+			assert.Equal(t, fmt.Sprint(em, eok), fmt.Sprint(cm, cok), q)
+		}
+	}
+}
+
+func TestCompileConstantFold(t *testing.T) {
+	n, err := expr.ParseExpression(`(4 + 5) * 2 > 10 AND "abc" IN ("abc", "def")`)
+	assert.Equal(t, nil, err)
+	prog, err := vm.Compile(n)
+	assert.Equal(t, nil, err)
+	// no context needed, it was folded to a constant
+	matches, ok := prog.Matches(nil)
+	assert.True(t, ok)
+	assert.True(t, matches)
+	assert.Equal(t, n, prog.Node())
+}
+
+var compileRows = []expr.EvalContext{
+	datasource.NewContextMap(map[string]interface{}{
+		"name":      "Yoda",
+		"city":      "Peoria",
+		"zip":       5,
+		"lastvisit": t1,
+		"roles":     []string{"user", "api"},
+	}, true),
+	datasource.NewContextMap(map[string]interface{}{
+		"name":      "bob",
+		"city":      "Chicago",
+		"zip":       5.5,
+		"lastvisit": t0,
+	}, true),
+	datasource.NewContextMap(map[string]interface{}{
+		"zip": "6",
+	}, true),
+}
@@ -73,18 +73,22 @@ func EvalFilterSelect(sel *rel.FilterSelect, writeContext expr.ContextWriter, re
 // Matches executes a FilterQL statement against an evaluation context
 // returning true if the context matches.
 func MatchesInc(inc expr.Includer, cr expr.EvalContext, stmt *rel.FilterStatement) (bool, bool) {
-	return matchesExpr(filterql{cr, inc}, stmt.Filter, 0)
+	return MatchesExpr(filterql{cr, inc}, stmt.Filter)
 }
 
 // Matches executes a FilterQL statement against an evaluation context
 // returning true if the context matches.
 func Matches(cr expr.EvalContext, stmt *rel.FilterStatement) (bool, bool) {
-	return matchesExpr(cr, stmt.Filter, 0)
+	return MatchesExpr(cr, stmt.Filter)
 }
 
 // MatchesExpr executes a expr.Node expression against an evaluation context
-// returning true if the context matches.
+// returning true if the context matches.  The node is compiled on first
+// use and the Program reused, nodes that don't compile are walked.
 func MatchesExpr(cr expr.EvalContext, node expr.Node) (bool, bool) {
+	if prog := cachedProgram(node); prog != nil {
+		return prog.Matches(cr)
+	}
 	return matchesExpr(cr, node, 0)
 }
 
@@ -114,7 +114,10 @@ func TestFilterQlVm(t *testing.T) {
 		`FILTER FullName == "Yoda, Jedi"`,                      // use functions on structs in evaluation
 		`FILTER Address.City == "Detroit"`,                     // traverse struct with path.field
 		`FILTER name LIKE "*da"`,                               // LIKE
+		`FILTER name LIKE "%da"`,                               // LIKE
+		`FILTER name LIKE "Yo?a"`,                              // LIKE single character
 		`FILTER name NOT LIKE "*kin"`,                          // LIKE Negation
+		`FILTER name NOT LIKE "Yo_a"`,                          // LIKE _ is literal
 		`FILTER name CONTAINS "od"`,                            // Contains
 		`FILTER name NOT CONTAINS "kin"`,                       // Contains
 		`FILTER roles INTERSECTS ("user", "api")`,              // Intersects
@@ -318,6 +321,40 @@ func TestInclude(t *testing.T) {
 	}
 }
 
+// TestFilterQlLikeRoundTrip a glob LIKE prints as written and means the
+// same when parsed again.
+func TestFilterQlLikeRoundTrip(t *testing.T) {
+	t.Parallel()
+	ctx := datasource.NewContextSimpleNative(map[string]interface{}{"name": "pandax"})
+	for _, tc := range []struct {
+		fql   string
+		match bool
+	}{
+		{`FILTER name LIKE "*da?"`, true},
+		{`FILTER name LIKE "pan%"`, true},
+		{`FILTER name LIKE "pa_da*"`, false},
+	} {
+		q, err := rel.ParseFilterQL(tc.fql)
+		assert.Equal(t, nil, err, tc.fql)
+		assert.Equal(t, tc.fql, q.String())
+		q2, err := rel.ParseFilterQL(q.String())
+		assert.Equal(t, nil, err, tc.fql)
+		assert.Equal(t, q.String(), q2.String())
+		assert.True(t, q.Filter.Equal(q2.Filter), tc.fql)
+
+		for _, stmt := range []*rel.FilterStatement{q, q2} {
+			match, ok := vm.Matches(ctx, stmt)
+			assert.True(t, ok, tc.fql)
+			assert.Equal(t, tc.match, match, tc.fql)
+			prog, err := vm.Compile(stmt.Filter)
+			assert.Equal(t, nil, err, tc.fql)
+			match, ok = prog.Matches(ctx)
+			assert.True(t, ok, tc.fql)
+			assert.Equal(t, tc.match, match, tc.fql)
+		}
+	}
+}
+
 type nilincluder struct{}
 
 func (nilincluder) Include(name string) (expr.Node, error) {
@@ -360,3 +397,24 @@ func TestFilterContexts(t *testing.T) {
 	_, ok = vm.MatchesInc(ctx, readCtx, q)
 	assert.True(t, !ok, "Should be ok")
 }
+
+func TestFilterQlMatchesCompiled(t *testing.T) {
+	ctx := datasource.NewContextSimpleNative(map[string]interface{}{"name": "panda", "ct": 5})
+	q, err := rel.ParseFilterQL(`FILTER AND (name = "panda", ct > 2, ct < 10)`)
+	assert.Equal(t, nil, err)
+	nope, err := rel.ParseFilterQL(`FILTER ct > 5`)
+	assert.Equal(t, nil, err)
+
+	// programs are reused, and recompiled once the cache is emptied
+	size := vm.ProgramCacheSize
+	vm.ProgramCacheSize = 1
+	defer func() { vm.ProgramCacheSize = size }()
+	for i := 0; i < 3; i++ {
+		match, ok := vm.Matches(ctx, q)
+		assert.True(t, ok)
+		assert.True(t, match)
+		match, ok = vm.MatchesExpr(ctx, nope.Filter)
+		assert.True(t, ok)
+		assert.False(t, match)
+	}
+}
@@ -0,0 +1,317 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/lex"
+	"github.com/araddon/qlbridge/value"
+)
+
+// DefaultLikeEscape the escape character of a LIKE pattern without an
+// ESCAPE clause.
+const DefaultLikeEscape = `\`
+
+// maxPatterns cap on the number of compiled patterns cached, past it the
+// cache is emptied and refilled.
+const maxPatterns = 10000
+
+var (
+	patterns = struct {
+		sync.RWMutex
+		m map[patternKey]*patternMatcher
+	}{m: make(map[patternKey]*patternMatcher)}
+)
+
+// patternKey compiled patterns are cached by their text, so a pattern from
+// a column or function is compiled once per distinct value.
+type patternKey struct {
+	op     lex.TokenType
+	src    string
+	escape string
+}
+
+type matchKind uint8
+
+const (
+	matchRegexp matchKind = iota
+	matchExact
+	matchPrefix
+	matchSuffix
+	matchContains
+	matchAll
+)
+
+// patternMatcher a compiled LIKE, ILIKE or REGEXP pattern. Like patterns
+// that are a single literal with leading and or trailing % are matched
+// without a regexp.
+type patternMatcher struct {
+	kind matchKind
+	lit  string
+	fold bool
+	re   *regexp.Regexp
+}
+
+func (m *patternMatcher) match(s string) bool {
+	if m.fold && m.kind != matchRegexp {
+		s = strings.ToLower(s)
+	}
+	switch m.kind {
+	case matchExact:
+		return s == m.lit
+	case matchPrefix:
+		return strings.HasPrefix(s, m.lit)
+	case matchSuffix:
+		return strings.HasSuffix(s, m.lit)
+	case matchContains:
+		return strings.Contains(s, m.lit)
+	case matchAll:
+		return true
+	}
+	return m.re.MatchString(s)
+}
+
+// likeToken a run of literal text, or a % or _ wildcard.
+type likeToken struct {
+	lit  string
+	wild rune
+}
+
+// parseLike split an ANSI LIKE pattern into literal runs and wildcards.
+func parseLike(pattern, escape string) ([]likeToken, error) {
+	esc := rune(-1)
+	if escape != "" {
+		r, size := utf8.DecodeRuneInString(escape)
+		if size != len(escape) {
+			return nil, fmt.Errorf("ESCAPE must be a single character: %q", escape)
+		}
+		esc = r
+	}
+	var toks []likeToken
+	var lit bytes.Buffer
+	flush := func() {
+		if lit.Len() > 0 {
+			toks = append(toks, likeToken{lit: lit.String()})
+			lit.Reset()
+		}
+	}
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+		switch {
+		case r == esc:
+			if i >= len(pattern) {
+				return nil, fmt.Errorf("LIKE pattern must not end with escape character: %q", pattern)
+			}
+			r, size = utf8.DecodeRuneInString(pattern[i:])
+			i += size
+			lit.WriteRune(r)
+		case r == '%', r == '_':
+			flush()
+			toks = append(toks, likeToken{wild: r})
+		default:
+			lit.WriteRune(r)
+		}
+	}
+	flush()
+	return toks, nil
+}
+
+// TranslateLike rewrite an ANSI LIKE pattern into another wildcard syntax,
+// % becomes many, _ becomes one and runs of literal text are passed
+// through quote. An empty escape means the pattern has no escape character.
+//
+//    TranslateLike(`a\_b%`, `\`, ".*", ".", regexp.QuoteMeta)  => `a_b.*`
+//
+func TranslateLike(pattern, escape, many, one string, quote func(string) string) (string, error) {
+	toks, err := parseLike(pattern, escape)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	for _, t := range toks {
+		switch t.wild {
+		case '%':
+			out.WriteString(many)
+		case '_':
+			out.WriteString(one)
+		default:
+			out.WriteString(quote(t.lit))
+		}
+	}
+	return out.String(), nil
+}
+
+// compilePattern compile pattern for op, one of LIKE, ILIKE or REGEXP.
+func compilePattern(op lex.TokenType, pattern, escape string) (*patternMatcher, error) {
+	if op == lex.TokenRegexp {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REGEXP pattern %q: %v", pattern, err)
+		}
+		return &patternMatcher{re: re}, nil
+	}
+	toks, err := parseLike(pattern, escape)
+	if err != nil {
+		return nil, err
+	}
+	fold := op == lex.TokenILike
+	if m := likeFastPath(toks, fold); m != nil {
+		return m, nil
+	}
+	var re bytes.Buffer
+	re.WriteString("^(?s")
+	if fold {
+		re.WriteString("i")
+	}
+	re.WriteString(":")
+	for _, t := range toks {
+		switch t.wild {
+		case '%':
+			re.WriteString(".*")
+		case '_':
+			re.WriteString(".")
+		default:
+			re.WriteString(regexp.QuoteMeta(t.lit))
+		}
+	}
+	re.WriteString(")$")
+	m := &patternMatcher{}
+	if m.re, err = regexp.Compile(re.String()); err != nil {
+		return nil, fmt.Errorf("invalid LIKE pattern %q: %v", pattern, err)
+	}
+	return m, nil
+}
+
+// likeFastPath a matcher for patterns of at most one literal with % on
+// either end, nil if the pattern needs a regexp.
+func likeFastPath(toks []likeToken, fold bool) *patternMatcher {
+	lead, trail := false, false
+	for len(toks) > 0 && toks[0].wild == '%' {
+		toks, lead = toks[1:], true
+	}
+	for len(toks) > 0 && toks[len(toks)-1].wild == '%' {
+		toks, trail = toks[:len(toks)-1], true
+	}
+	m := &patternMatcher{fold: fold}
+	switch {
+	case len(toks) == 0 && (lead || trail):
+		m.kind = matchAll
+		return m
+	case len(toks) == 0:
+		m.kind = matchExact
+		return m
+	case len(toks) > 1 || toks[0].wild != 0:
+		return nil
+	}
+	m.lit = toks[0].lit
+	if fold {
+		m.lit = strings.ToLower(m.lit)
+	}
+	switch {
+	case lead && trail:
+		m.kind = matchContains
+	case lead:
+		m.kind = matchSuffix
+	case trail:
+		m.kind = matchPrefix
+	default:
+		m.kind = matchExact
+	}
+	return m
+}
+
+// cachedPattern the compiled pattern, compiling it only the first time it
+// is seen.
+func cachedPattern(op lex.TokenType, pattern, escape string) (*patternMatcher, error) {
+	key := patternKey{op: op, src: pattern, escape: escape}
+	patterns.RLock()
+	m, ok := patterns.m[key]
+	patterns.RUnlock()
+	if ok {
+		return m, nil
+	}
+	m, err := compilePattern(op, pattern, escape)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Lock()
+	if len(patterns.m) >= maxPatterns {
+		patterns.m = make(map[patternKey]*patternMatcher)
+	}
+	patterns.m[key] = m
+	patterns.Unlock()
+	return m, nil
+}
+
+// isPatternOp is op one of the pattern matching operators.
+func isPatternOp(op lex.TokenType) bool {
+	switch op {
+	case lex.TokenLike, lex.TokenILike, lex.TokenRegexp:
+		return true
+	}
+	return false
+}
+
+// patternStrings the strings of a value matched by, or used as, a pattern.
+// A slice matches if any of its members match.
+func patternStrings(v value.Value) ([]string, bool) {
+	switch vt := v.(type) {
+	case value.StringValue:
+		return []string{vt.Val()}, true
+	case value.StringsValue:
+		return vt.Val(), true
+	case value.SliceValue:
+		strs := make([]string, 0, vt.Len())
+		for _, sv := range vt.Val() {
+			strs = append(strs, sv.ToString())
+		}
+		return strs, true
+	case value.IntValue, value.NumberValue, value.DecimalValue:
+		return []string{vt.ToString()}, true
+	}
+	return nil, false
+}
+
+// operatePattern a LIKE b, a ILIKE b, a REGEXP b with optional escape
+// character for LIKE. Each pattern is compiled once.
+func operatePattern(op lex.TokenType, a, b value.Value, escape string) (value.Value, bool) {
+	pats, ok := patternStrings(b)
+	if !ok {
+		u.Debugf("unsupported pattern type %T for %s", b, op)
+		return nil, false
+	}
+	strs, ok := patternStrings(a)
+	if !ok {
+		return value.BoolValueFalse, true
+	}
+	for _, p := range pats {
+		m, err := cachedPattern(op, p, escape)
+		if err != nil {
+			u.Debugf("invalid pattern: %v", err)
+			return nil, false
+		}
+		for _, s := range strs {
+			if m.match(s) {
+				return value.BoolValueTrue, true
+			}
+		}
+	}
+	return value.BoolValueFalse, true
+}
+
+// LikeCompare takes two strings and evaluates them for like equality,
+// with ANSI % and _ wildcards and \ as escape character.
+func LikeCompare(a, b string) (value.BoolValue, bool) {
+	m, err := cachedPattern(lex.TokenLike, b, DefaultLikeEscape)
+	if err != nil {
+		return value.BoolValueFalse, false
+	}
+	return boolValue(m.match(a)), true
+}
@@ -0,0 +1,61 @@
+package vm_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/vm"
+)
+
+func TestTranslateLike(t *testing.T) {
+	for _, test := range []struct {
+		pattern, escape, re string
+	}{
+		{`abc`, `\`, `abc`},
+		{`a%c_`, `\`, `a.*c.`},
+		{`a\%c\_`, `\`, `a%c_`},
+		{`a\\b`, `\`, `a\\b`},
+		{`a*b?`, `\`, `a\*b\?`},
+		{`50!%%`, `!`, `50%.*`},
+		{`a\%`, ``, `a\\.*`},
+	} {
+		re, err := vm.TranslateLike(test.pattern, test.escape, ".*", ".", regexp.QuoteMeta)
+		assert.Equal(t, nil, err, test.pattern)
+		assert.Equal(t, test.re, re, test.pattern)
+	}
+
+	_, err := vm.TranslateLike(`abc!`, "!", ".*", ".", regexp.QuoteMeta)
+	assert.NotEqual(t, nil, err)
+	_, err = vm.TranslateLike(`abc`, "!!", ".*", ".", regexp.QuoteMeta)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestLikeCompare(t *testing.T) {
+	for _, test := range []struct {
+		val, pattern string
+		match        bool
+	}{
+		{"New York", "New York", true},
+		{"New York", "new york", false},
+		{"New York", "New%", true},
+		{"New York", "%York", true},
+		{"New York", "%w Y%", true},
+		{"New York", "New_York", true},
+		{"New York", "New_", false},
+		{"a*c", "a*c", true},
+		{"abc", "a*c", false},
+		{"a_c", `a\_c`, true},
+		{"abc", `a\_c`, false},
+		{"line\nbreak", "line%", true},
+		{"", "%", true},
+		{"", "", true},
+	} {
+		bv, ok := vm.LikeCompare(test.val, test.pattern)
+		assert.True(t, ok, test.pattern)
+		assert.Equal(t, test.match, bv.Val(), "%q LIKE %q", test.val, test.pattern)
+	}
+	_, ok := vm.LikeCompare("abc", `abc\`)
+	assert.False(t, ok)
+}
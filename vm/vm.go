@@ -11,7 +11,6 @@ import (
 	"time"
 
 	u "github.com/araddon/gou"
-	"github.com/mb0/glob"
 
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/lex"
@@ -110,6 +109,12 @@ func resolveIncludesDepth(ctx expr.Includer, arg expr.Node, depth int) error {
 				return err
 			}
 		}
+	case *expr.CaseNode:
+		for _, narg := range n.ChildrenArgs() {
+			if err := resolveIncludesDepth(ctx, narg, depth+1); err != nil {
+				return err
+			}
+		}
 	case *expr.ArrayNode:
 		for _, narg := range n.Args {
 			if err := resolveIncludesDepth(ctx, narg, depth+1); err != nil {
@@ -158,6 +163,8 @@ func evalDepth(ctx expr.EvalContext, arg expr.Node, depth int) (value.Value, boo
 		return walkUnary(ctx, argVal, depth)
 	case *expr.TriNode:
 		return walkTernary(ctx, argVal, depth)
+	case *expr.CaseNode:
+		return walkCase(ctx, argVal, depth)
 	case *expr.ArrayNode:
 		return walkArray(ctx, argVal, depth)
 	case *expr.FuncNode:
@@ -179,8 +186,8 @@ func evalDepth(ctx expr.EvalContext, arg expr.Node, depth int) (value.Value, boo
 		}
 		switch val := argVal.Value.(type) {
 		case *value.NilValue, value.NilValue:
-			return nil, false
-		case value.SliceValue:
+			return value.NilValueVal, true
+		case value.SliceValue, value.IntervalValue:
 			return val, true
 		}
 		u.Errorf("Unknonwn node type:  %#v", argVal.Value)
@@ -267,9 +274,17 @@ func walkBoolean(ctx expr.EvalContext, n *expr.BooleanNode, depth int) (value.Va
 	}
 
 	//u.Debugf("filters and?%v  filter=%q", and, n)
+	unknown := false
 	for _, bn := range n.Args {
 
-		matches, ok := evalBool(ctx, bn, depth+1)
+		val, ok := evalDepth(ctx, bn, depth+1)
+		if isNull(val, ok) {
+			// NULL is UNKNOWN, the result is UNKNOWN unless another
+			// argument short-circuits
+			unknown = true
+			continue
+		}
+		matches, ok := toBool(val, ok)
 		//u.Debugf("matches filter?%v ok=%v  f=%q", matches, ok, bn)
 		if !ok && and {
 			return nil, false
@@ -294,6 +309,9 @@ func walkBoolean(ctx expr.EvalContext, n *expr.BooleanNode, depth int) (value.Va
 
 	// no shortcircuiting, if and=true this means all expressions returned true...
 	// ...if and=false (OR) this means all expressions returned false.
+	if unknown {
+		return value.NilValueVal, true
+	}
 	if n.Negated() {
 		return value.NewBoolValue(!and), true
 	}
@@ -327,10 +345,71 @@ func walkBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.Value, bool) {
 	ar, aok := evalDepth(ctx, node.Args[0], depth+1)
 	br, bok := evalDepth(ctx, node.Args[1], depth+1)
+	ar, br = sessionTimes(ctx, node.Operator.T, ar, br)
+	return operateBinary(node, ar, aok, br, bok)
+}
+
+// sessionZone the session time zone of ctx, nil if none or UTC which is
+// what strings without a zone are read as anyway.
+func sessionZone(ctx expr.EvalContext) *time.Location {
+	if loc := expr.SessionLocation(ctx); loc != nil && loc != time.UTC {
+		return loc
+	}
+	return nil
+}
+
+// sessionTimes a string compared to a time is read in the session time
+// zone of ctx, instead of the UTC operateBinary would read it in.
+//
+//    SET time_zone = 'America/Denver'
+//    created > "2017-03-13 10:00"   => created > 2017-03-13 16:00 UTC
+//
+func sessionTimes(ctx expr.EvalContext, op lex.TokenType, a, b value.Value) (value.Value, value.Value) {
+	switch op {
+	case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenNE, lex.TokenGT, lex.TokenGE, lex.TokenLT, lex.TokenLE:
+	default:
+		return a, b
+	}
+	switch at := a.(type) {
+	case value.TimeValue:
+		if bs, isStr := b.(value.StringValue); isStr {
+			if loc := sessionZone(ctx); loc != nil {
+				if t, ok := value.StringToTimeIn(bs.Val(), time.Now(), loc); ok {
+					b = value.NewTimeValue(t)
+				}
+			}
+		}
+	case value.StringValue:
+		if _, isTime := b.(value.TimeValue); isTime {
+			if loc := sessionZone(ctx); loc != nil {
+				if t, ok := value.StringToTimeIn(at.Val(), time.Now(), loc); ok {
+					a = value.NewTimeValue(t)
+				}
+			}
+		}
+	}
+	return a, b
+}
+
+// operateBinary applies the binary operator to the already evaluated
+// left (ar) and right (br) arguments.
+func operateBinary(node *expr.BinaryNode, ar value.Value, aok bool, br value.Value, bok bool) (value.Value, bool) {
 
 	// u.Debugf("walkBinary: aok?%v ar:%v %T  node=%s %T", aok, ar, ar, node.Args[0], node.Args[0])
 	// u.Debugf("walkBinary: bok?%v br:%v %T  node=%s %T", bok, br, br, node.Args[1], node.Args[1])
 	// u.Debugf("walkBinary: l:%v  r:%v  %T  %T node=%s", ar, br, ar, br, node)
+	switch node.Operator.T {
+	case lex.TokenIsDistinctFrom, lex.TokenIsNotDistinctFrom:
+		// x IS [NOT] NULL is parsed as x IS [NOT] DISTINCT FROM NULL
+		return operateDistinct(node, ar, aok, br, bok)
+	}
+	if isNull(ar, aok) || isNull(br, bok) {
+		return operateNull(node.Operator.T, ar, br)
+	}
+	if node.Operator.T == lex.TokenAtTimeZone {
+		return operateAtTimeZone(ar, aok, br, bok)
+	}
+
 	// If we could not evaluate either we can shortcut
 	if !aok && !bok {
 		switch node.Operator.T {
@@ -342,7 +421,7 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 			return value.NewBoolValue(false), true
 		case lex.TokenNE:
 			return value.NewBoolValue(false), true
-		case lex.TokenGT, lex.TokenGE, lex.TokenLT, lex.TokenLE, lex.TokenLike:
+		case lex.TokenGT, lex.TokenGE, lex.TokenLT, lex.TokenLE, lex.TokenLike, lex.TokenILike, lex.TokenRegexp:
 			return value.NewBoolValue(false), true
 		}
 		// u.Warnf("walkBinary not ok: op=%s %v  l:%v  r:%v  %T  %T", node.Operator, node, ar, br, ar, br)
@@ -352,7 +431,7 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 	// Else if we can only evaluate right
 	if !aok {
 		switch node.Operator.T {
-		case lex.TokenIntersects, lex.TokenContains, lex.TokenLike:
+		case lex.TokenIntersects, lex.TokenContains, lex.TokenLike, lex.TokenILike, lex.TokenRegexp:
 			return value.NewBoolValue(false), true
 		case lex.TokenIN:
 			return value.NewBoolValue(false), true
@@ -371,13 +450,25 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 			return value.NewBoolValue(true), true
 		case lex.TokenIN:
 			return value.NewBoolValue(false), true
-		case lex.TokenGT, lex.TokenGE, lex.TokenLT, lex.TokenLE, lex.TokenLike:
+		case lex.TokenGT, lex.TokenGE, lex.TokenLT, lex.TokenLE, lex.TokenLike, lex.TokenILike, lex.TokenRegexp:
 			return value.NewBoolValue(false), true
 		}
 		//u.Debugf("walkBinary not ok: op=%s %v  l:%v  r:%v  %T  %T", node.Operator, node, ar, br, ar, br)
 		// need to fall through to below
 	}
 
+	if isPatternOp(node.Operator.T) && aok && bok {
+		// a LIKE b, a ILIKE b, a REGEXP b
+		escape := DefaultLikeEscape
+		if node.Operator.T == lex.TokenRegexp {
+			escape = ""
+		}
+		if bt, isStr := br.(value.StringValue); isStr && node.Glob {
+			br = value.NewStringValue(node.LikePattern(bt.Val()))
+		}
+		return operatePattern(node.Operator.T, ar, br, escape)
+	}
+
 	switch at := ar.(type) {
 	case value.IntValue:
 		switch bt := br.(type) {
@@ -406,6 +497,13 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 			//u.Debugf("doing operate ints/numbers  %v %v  %v", at, node.Operator.V, bt)
 			n := operateNumbers(node.Operator, at.NumberValue(), bt)
 			return n, true
+		case value.DecimalValue:
+			return operateDecimals(node.Operator, value.NewDecimalValueInt(at.Val()), bt)
+		case value.IntervalValue:
+			if node.Operator.T == lex.TokenMultiply {
+				return bt.Mul(at.Val()), true
+			}
+			return nil, false
 		case value.SliceValue:
 			switch node.Operator.T {
 			case lex.TokenIN:
@@ -434,6 +532,11 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 		case value.NumberValue:
 			n := operateNumbers(node.Operator, at, bt)
 			return n, true
+		case value.DecimalValue:
+			if ad, ok := value.ValueToDecimal(at); ok {
+				return operateDecimals(node.Operator, ad, bt)
+			}
+			return operateNumbers(node.Operator, at, bt.NumberValue()), true
 		case value.SliceValue:
 			for _, val := range bt.Val() {
 				switch valt := val.(type) {
@@ -471,6 +574,95 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 		default:
 			u.Errorf("unknown type:  %T %v", bt, bt)
 		}
+	case value.DecimalValue:
+		switch bt := br.(type) {
+		case value.DecimalValue:
+			return operateDecimals(node.Operator, at, bt)
+		case value.IntValue, value.StringValue:
+			if bd, ok := value.ValueToDecimal(bt); ok {
+				return operateDecimals(node.Operator, at, bd)
+			}
+		case value.NumberValue:
+			if bd, ok := value.ValueToDecimal(bt); ok {
+				return operateDecimals(node.Operator, at, bd)
+			}
+			return operateNumbers(node.Operator, at.NumberValue(), bt), true
+		case value.Slice:
+			if node.Operator.T == lex.TokenIN {
+				for _, val := range bt.SliceValue() {
+					if bd, ok := value.ValueToDecimal(val); ok && at.Cmp(bd) == 0 {
+						return value.BoolValueTrue, true
+					}
+				}
+				return value.BoolValueFalse, true
+			}
+			u.Debugf("unsupported op for SliceValue op:%v rhT:%T", node.Operator, br)
+			return nil, false
+		case nil, value.NilValue:
+			return nil, false
+		default:
+			u.Errorf("unknown type:  %T %v", bt, bt)
+		}
+		return nil, false
+	case value.DateValue:
+		switch bt := br.(type) {
+		case value.IntervalValue:
+			// date + interval of whole days stays a date
+			tv, ok := operateInterval(node.Operator.T, at.Val(), bt)
+			if ok && bt.Fixed() == 0 {
+				return value.NewDateValue(tv.Val()), true
+			}
+			return tv, ok
+		case value.IntValue:
+			// date + n days
+			switch node.Operator.T {
+			case lex.TokenPlus:
+				return value.NewDateValue(at.Val().AddDate(0, 0, int(bt.Val()))), true
+			case lex.TokenMinus:
+				return value.NewDateValue(at.Val().AddDate(0, 0, -int(bt.Val()))), true
+			}
+			return nil, false
+		}
+		rht, ok := value.ValueToTime(br)
+		if !ok {
+			return value.BoolValueFalse, false
+		}
+		if _, isDate := br.(value.DateValue); !isDate {
+			if _, isTime := br.(value.TimeValue); !isTime {
+				// "2017-01-31" literals compare as dates
+				rht = value.NewDateValue(rht).Val()
+			}
+		}
+		if node.Operator.T == lex.TokenMinus {
+			return value.IntervalBetween(rht, at.Val()), true
+		}
+		return operateTime(node.Operator.T, at.Val(), rht)
+	case value.IntervalValue:
+		switch bt := br.(type) {
+		case value.IntervalValue:
+			return operateIntervals(node.Operator.T, at, bt)
+		case value.IntValue:
+			if node.Operator.T == lex.TokenMultiply {
+				return at.Mul(bt.Val()), true
+			}
+		case value.TimeValue:
+			if node.Operator.T == lex.TokenPlus {
+				return operateInterval(node.Operator.T, bt.Val(), at)
+			}
+		case value.DateValue:
+			if node.Operator.T == lex.TokenPlus {
+				tv, ok := operateInterval(node.Operator.T, bt.Val(), at)
+				if ok && at.Fixed() == 0 {
+					return value.NewDateValue(tv.Val()), true
+				}
+				return tv, ok
+			}
+		case value.StringValue:
+			if bi, ok := value.ValueToInterval(bt); ok {
+				return operateIntervals(node.Operator.T, at, bi)
+			}
+		}
+		return nil, false
 	case value.BoolValue:
 		switch bt := br.(type) {
 		case value.BoolValue:
@@ -544,14 +736,6 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 					}
 				}
 				return value.NewBoolValue(false), true
-			case lex.TokenLike: // a(value) LIKE b(pattern)
-				for _, val := range bt.SliceValue() {
-					bv, ok := LikeCompare(at.Val(), val.ToString())
-					if ok && bv.Val() {
-						return value.NewBoolValue(true), true
-					}
-				}
-				return value.NewBoolValue(false), true
 			default:
 				u.Debugf("unsupported op for SliceValue op:%v rhT:%T", node.Operator, br)
 				return nil, false
@@ -595,12 +779,23 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 		case value.NumberValue:
 			n := operateNumbers(node.Operator, at.NumberValue(), bt)
 			return n, true
+		case value.DecimalValue:
+			if ad, ok := value.ValueToDecimal(at); ok {
+				return operateDecimals(node.Operator, ad, bt)
+			}
+			return value.BoolValueFalse, false
 		case value.TimeValue:
 			lht, ok := value.ValueToTime(at)
 			if !ok {
 				return value.BoolValueFalse, false
 			}
 			return operateTime(node.Operator.T, lht, bt.Val())
+		case value.IntervalValue:
+			lht, ok := value.ValueToTime(at)
+			if !ok {
+				return nil, false
+			}
+			return operateInterval(node.Operator.T, lht, bt)
 		default:
 			u.Errorf("at?%T  %v bt? %T     %v", at, at.Value(), bt, bt.Value())
 		}
@@ -658,19 +853,6 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 				}
 				return value.BoolValueFalse, true
 			}
-		case lex.TokenLike:
-			switch bv := br.(type) {
-			case value.StringValue:
-				// [x,y,z] LIKE str
-				for _, val := range at.Val() {
-					if boolVal, ok := LikeCompare(val.ToString(), bv.Val()); ok && boolVal.Val() == true {
-						return boolVal, true
-					}
-				}
-				return value.BoolValueFalse, true
-			default:
-				//u.Warnf("un handled right side to Like  T:%T  %v", br, br)
-			}
 		case lex.TokenIntersects:
 			switch bt := br.(type) {
 			case nil, value.NilValue:
@@ -712,20 +894,6 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 				}
 				return value.BoolValueFalse, true
 			}
-		case lex.TokenLike:
-
-			switch bv := br.(type) {
-			case value.StringValue:
-				// [x,y,z] LIKE str
-				for _, val := range at.Val() {
-					boolVal, ok := LikeCompare(val, bv.Val())
-					//u.Debugf("%s like %s ?? ok?%v  result=%v", val, bv.Val(), ok, boolVal)
-					if ok && boolVal.Val() == true {
-						return boolVal, true
-					}
-				}
-				return value.BoolValueFalse, true
-			}
 		case lex.TokenIntersects:
 			switch bt := br.(type) {
 			case nil, value.NilValue:
@@ -757,10 +925,16 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 	case value.TimeValue:
 
 		lht := at.Val()
+		if bt, isInterval := br.(value.IntervalValue); isInterval {
+			return operateInterval(node.Operator.T, lht, bt)
+		}
 		rht, ok := value.ValueToTime(br)
 		if !ok {
 			return value.BoolValueFalse, false
 		}
+		if node.Operator.T == lex.TokenMinus {
+			return value.IntervalBetween(rht, lht), true
+		}
 
 		return operateTime(node.Operator.T, lht, rht)
 
@@ -825,6 +999,63 @@ func evalBinary(ctx expr.EvalContext, node *expr.BinaryNode, depth int) (value.V
 	return value.NewErrorValue(fmt.Errorf("unsupported binary expression: %s", node)), false
 }
 
+// isNull is the value an explicit NULL, as opposed to a missing value
+// or one that could not be evaluated (not ok).
+func isNull(v value.Value, ok bool) bool {
+	return ok && v != nil && v.Type() == value.NilType
+}
+
+// operateNull binary operators with a NULL argument are NULL (UNKNOWN)
+// except where AND/OR are decided by the other argument
+//
+//     false AND NULL => false
+//     true OR NULL   => true
+//
+func operateNull(op lex.TokenType, a, b value.Value) (value.Value, bool) {
+	switch op {
+	case lex.TokenLogicAnd, lex.TokenAnd:
+		if isBool(a, false) || isBool(b, false) {
+			return value.BoolValueFalse, true
+		}
+	case lex.TokenLogicOr, lex.TokenOr:
+		if isBool(a, true) || isBool(b, true) {
+			return value.BoolValueTrue, true
+		}
+	}
+	return value.NilValueVal, true
+}
+
+func isBool(v value.Value, b bool) bool {
+	bv, ok := v.(value.BoolValue)
+	return ok && bv.Val() == b
+}
+
+// distinctEqual the equality used for IS [NOT] DISTINCT FROM
+var distinctEqual = lex.Token{T: lex.TokenEqual, V: "="}
+
+// operateDistinct null-safe comparison, two NULLs (or missing values) are
+// not distinct from each other and a NULL is distinct from any value
+//
+//     x IS DISTINCT FROM y
+//     x IS NOT DISTINCT FROM y
+//
+func operateDistinct(node *expr.BinaryNode, a value.Value, aok bool, b value.Value, bok bool) (value.Value, bool) {
+	aNull := !aok || a == nil || a.Type() == value.NilType
+	bNull := !bok || b == nil || b.Type() == value.NilType
+	var distinct bool
+	switch {
+	case aNull || bNull:
+		distinct = aNull != bNull
+	default:
+		eq, ok := operateBinary(expr.NewBinaryNode(distinctEqual, node.Args[0], node.Args[1]), a, aok, b, bok)
+		distinct = !isBool(eq, true) || !ok
+	}
+	if node.Operator.T == lex.TokenIsNotDistinctFrom {
+		return value.NewBoolValue(!distinct), true
+	}
+	return value.NewBoolValue(distinct), true
+}
+
 func walkIdentity(ctx expr.EvalContext, node *expr.IdentityNode) (value.Value, bool) {
 
 	if node.IsBooleanIdentity() {
@@ -842,6 +1073,11 @@ func walkIdentity(ctx expr.EvalContext, node *expr.IdentityNode) (value.Value, b
 func walkUnary(ctx expr.EvalContext, node *expr.UnaryNode, depth int) (value.Value, bool) {
 
 	a, ok := Eval(ctx, node.Arg)
+	return operateUnary(node, a, ok)
+}
+
+// operateUnary applies the unary operator to the already evaluated argument.
+func operateUnary(node *expr.UnaryNode, a value.Value, ok bool) (value.Value, bool) {
 	//u.Debugf("urnary a:%v ok:%v  %s", a, ok, node)
 	if !ok {
 		switch node.Operator.T {
@@ -860,7 +1096,10 @@ func walkUnary(ctx expr.EvalContext, node *expr.UnaryNode, depth int) (value.Val
 		case value.BoolValue:
 			//u.Debugf("found unary bool:  res=%v   expr=%v", !argVal.Val(), node)
 			return value.NewBoolValue(!argVal.Val()), true
-		case nil, value.NilValue:
+		case value.NilValue:
+			// NOT NULL is NULL
+			return value.NilValueVal, true
+		case nil:
 			return value.NewBoolValue(false), false
 		default:
 			u.LogThrottle(u.WARN, 5, "unary type not implemented. Unknonwn node type: %T:%v node=%s", argVal, argVal, node.String())
@@ -895,6 +1134,20 @@ func walkTernary(ctx expr.EvalContext, node *expr.TriNode, depth int) (value.Val
 	a, aok := Eval(ctx, node.Args[0])
 	b, bok := Eval(ctx, node.Args[1])
 	c, cok := Eval(ctx, node.Args[2])
+	return operateTernary(node, a, aok, b, bok, c, cok)
+}
+
+// operateTernary applies the ternary operator to the already evaluated
+// arguments, NOT BETWEEN and NOT LIKE reverse the result.
+func operateTernary(node *expr.TriNode, a value.Value, aok bool, b value.Value, bok bool, c value.Value, cok bool) (value.Value, bool) {
+	v, ok := evalTernary(node, a, aok, b, bok, c, cok)
+	if bv, isBool := v.(value.BoolValue); isBool && ok && node.Negated() {
+		return value.NewBoolValue(!bv.Val()), true
+	}
+	return v, ok
+}
+
+func evalTernary(node *expr.TriNode, a value.Value, aok bool, b value.Value, bok bool, c value.Value, cok bool) (value.Value, bool) {
 	//u.Infof("tri:  %T:%v  %v  %T:%v   %T:%v", a, a, node.Operator, b, b, c, c)
 	if !aok {
 		return nil, false
@@ -905,7 +1158,13 @@ func walkTernary(ctx expr.EvalContext, node *expr.TriNode, depth int) (value.Val
 	if a == nil || b == nil || c == nil {
 		return nil, false
 	}
+	if isNull(a, aok) || isNull(b, bok) || isNull(c, cok) {
+		return value.NilValueVal, true
+	}
 	switch node.Operator.T {
+	case lex.TokenLike, lex.TokenILike:
+		// a LIKE b ESCAPE c
+		return operatePattern(node.Operator.T, a, b, c.ToString())
 	case lex.TokenBetween:
 		switch at := a.(type) {
 		case value.IntValue:
@@ -939,6 +1198,21 @@ func walkTernary(ctx expr.EvalContext, node *expr.TriNode, depth int) (value.Val
 				return value.NewBoolValue(true), true
 			}
 
+			return value.NewBoolValue(false), true
+		case value.DecimalValue:
+
+			bv, ok := value.ValueToDecimal(b)
+			if !ok {
+				return nil, false
+			}
+			cv, ok := value.ValueToDecimal(c)
+			if !ok {
+				return nil, false
+			}
+			if at.Cmp(bv) > 0 && at.Cmp(cv) < 0 {
+				return value.NewBoolValue(true), true
+			}
+
 			return value.NewBoolValue(false), true
 
 		case value.TimeValue:
@@ -958,6 +1232,24 @@ func walkTernary(ctx expr.EvalContext, node *expr.TriNode, depth int) (value.Val
 
 			return value.NewBoolValue(false), true
 
+		case value.DateValue:
+
+			av := at.Val()
+			bv, ok := value.ValueToTime(b)
+			if !ok {
+				return nil, false
+			}
+			cv, ok := value.ValueToTime(c)
+			if !ok {
+				return nil, false
+			}
+			bd, cd := value.NewDateValue(bv).Val(), value.NewDateValue(cv).Val()
+			if av.After(bd) && av.Before(cd) {
+				return value.NewBoolValue(true), true
+			}
+
+			return value.NewBoolValue(false), true
+
 		default:
 			u.Warnf("between not implemented for type %s %#v", a.Type().String(), node)
 		}
@@ -968,6 +1260,48 @@ func walkTernary(ctx expr.EvalContext, node *expr.TriNode, depth int) (value.Val
 	return nil, false
 }
 
+// walkCase CASE evaluator, returns the THEN of the first WHEN that is true
+// (or equal to the operand) else the ELSE, with no ELSE it is NULL
+//
+//     CASE WHEN a > 5 THEN b ELSE c END
+//     CASE a WHEN 5 THEN b ELSE c END
+//
+func walkCase(ctx expr.EvalContext, node *expr.CaseNode, depth int) (value.Value, bool) {
+	if node.Operand != nil {
+		a, aok := evalDepth(ctx, node.Operand, depth+1)
+		for i, when := range node.Whens {
+			w, wok := evalDepth(ctx, when, depth+1)
+			if caseMatches(expr.NewBinaryNode(caseEqual, node.Operand, when), a, aok, w, wok) {
+				return evalDepth(ctx, node.Thens[i], depth+1)
+			}
+		}
+	} else {
+		for i, when := range node.Whens {
+			if matches, ok := evalBool(ctx, when, depth+1); ok && matches {
+				return evalDepth(ctx, node.Thens[i], depth+1)
+			}
+		}
+	}
+	if node.Else != nil {
+		return evalDepth(ctx, node.Else, depth+1)
+	}
+	return value.NilValueVal, true
+}
+
+// caseEqual is the operator comparing a simple CASE operand to each WHEN
+var caseEqual = lex.Token{T: lex.TokenEqual, V: "="}
+
+// caseMatches applies the operand = when comparison, a nil operand
+// matches nothing same as sql
+func caseMatches(node *expr.BinaryNode, a value.Value, aok bool, w value.Value, wok bool) bool {
+	if !aok || !wok || a == nil || w == nil || a.Nil() || w.Nil() {
+		return false
+	}
+	v, ok := operateBinary(node, a, aok, w, wok)
+	bv, isBool := v.(value.BoolValue)
+	return ok && isBool && bv.Val()
+}
+
 // walkArray Array evaluator:  evaluate multiple values into an array
 //
 //     (b,c,d)
@@ -1005,9 +1339,76 @@ func walkFunc(ctx expr.EvalContext, node *expr.FuncNode, depth int) (value.Value
 		}
 		args[i] = v
 	}
+	if uf, ok := node.F.CustomFunc.(*expr.UserFunc); ok {
+		return evalUserFunc(ctx, node, uf, args, depth)
+	}
 	return node.Eval(ctx, args)
 }
 
+// evalUserFunc call the current declaration of a user defined function,
+// which may have been replaced or dropped since node was parsed.
+func evalUserFunc(ctx expr.EvalContext, node *expr.FuncNode, uf *expr.UserFunc, args []value.Value, depth int) (value.Value, bool) {
+	cur, ok := uf.Current()
+	if !ok {
+		u.LogThrottle(u.WARN, 10, "function %s does not exist", uf.Name)
+		return nil, false
+	}
+	if !cur.IsExternal() {
+		if len(args) != len(cur.Params) {
+			u.LogThrottle(u.WARN, 10, "function %s expects %d args but got %d", cur.Name, len(cur.Params), len(args))
+			return nil, false
+		}
+		return evalDepth(newFuncArgsContext(ctx, cur, args), cur.Body, depth+1)
+	}
+	if cur == uf {
+		return node.Eval(ctx, args)
+	}
+	eval, err := cur.Validate(node)
+	if err != nil {
+		u.LogThrottle(u.WARN, 10, "function %s: %v", cur.Name, err)
+		return nil, false
+	}
+	return eval(ctx, args)
+}
+
+// funcArgsContext the context a user defined function body is evaluated
+// in, its parameters are bound to the arguments of the call, other keys
+// are read from the calling context.
+type funcArgsContext struct {
+	expr.EvalContext
+	args map[string]value.Value
+}
+
+func newFuncArgsContext(ctx expr.EvalContext, uf *expr.UserFunc, args []value.Value) *funcArgsContext {
+	m := &funcArgsContext{EvalContext: ctx, args: make(map[string]value.Value, len(args))}
+	for i, p := range uf.Params {
+		m.args[strings.ToLower(p)] = args[i]
+	}
+	return m
+}
+
+func (m *funcArgsContext) Get(key string) (value.Value, bool) {
+	if v, ok := m.args[strings.ToLower(key)]; ok {
+		return v, true
+	}
+	if m.EvalContext == nil {
+		return nil, false
+	}
+	return m.EvalContext.Get(key)
+}
+func (m *funcArgsContext) Row() map[string]value.Value {
+	if m.EvalContext == nil {
+		return m.args
+	}
+	return m.EvalContext.Row()
+}
+func (m *funcArgsContext) Ts() time.Time {
+	if m.EvalContext == nil {
+		return time.Time{}
+	}
+	return m.EvalContext.Ts()
+}
+
 func operateNumbers(op lex.Token, av, bv value.NumberValue) value.Value {
 	switch op.T {
 	case lex.TokenPlus, lex.TokenStar, lex.TokenMultiply, lex.TokenDivide, lex.TokenMinus,
@@ -1087,9 +1488,57 @@ func operateNumbers(op lex.Token, av, bv value.NumberValue) value.Value {
 	panic(fmt.Errorf("expr: unknown operator %s", op))
 }
 
+// operateDecimals exact arithmetic and comparison of decimals, division
+// is rounded to the scale of the dividend plus value.DecimalDivScale.
+func operateDecimals(op lex.Token, a, b value.DecimalValue) (value.Value, bool) {
+	if a.Nil() || b.Nil() {
+		return value.NilValueVal, true
+	}
+	switch op.T {
+	case lex.TokenPlus: // +
+		return a.Add(b), true
+	case lex.TokenStar, lex.TokenMultiply: // *
+		return a.Mul(b), true
+	case lex.TokenMinus: // -
+		return a.Sub(b), true
+	case lex.TokenDivide: //    /
+		q, err := a.Quo(b)
+		if err != nil {
+			return nil, false
+		}
+		return q, true
+	case lex.TokenModulus: //    %
+		r, err := a.Mod(b)
+		if err != nil {
+			return nil, false
+		}
+		return r, true
+
+	// Below here are Boolean Returns
+	case lex.TokenEqualEqual, lex.TokenEqual: //  ==, =
+		return value.NewBoolValue(a.Cmp(b) == 0), true
+	case lex.TokenNE: //  !=    or <>
+		return value.NewBoolValue(a.Cmp(b) != 0), true
+	case lex.TokenGT: //  >
+		return value.NewBoolValue(a.Cmp(b) > 0), true
+	case lex.TokenGE: // >=
+		return value.NewBoolValue(a.Cmp(b) >= 0), true
+	case lex.TokenLT: // <
+		return value.NewBoolValue(a.Cmp(b) < 0), true
+	case lex.TokenLE: // <=
+		return value.NewBoolValue(a.Cmp(b) <= 0), true
+	case lex.TokenLogicOr, lex.TokenOr: //  ||
+		return value.NewBoolValue(a.Sign() != 0 || b.Sign() != 0), true
+	case lex.TokenLogicAnd: //  &&
+		return value.NewBoolValue(a.Sign() != 0 && b.Sign() != 0), true
+	}
+	u.Debugf("unsupported operator for decimals: %s", op.T)
+	return nil, false
+}
+
 func operateStrings(op lex.Token, av, bv value.StringValue) value.Value {
 
-	//  Any other ops besides =, ==, !=, contains, like?
+	//  Any other ops besides =, ==, !=, contains?
 	a, b := av.Val(), bv.Val()
 	switch op.T {
 	case lex.TokenEqualEqual, lex.TokenEqual: //  ==
@@ -1107,12 +1556,6 @@ func operateStrings(op lex.Token, av, bv value.StringValue) value.Value {
 			return value.BoolValueTrue
 		}
 		return value.BoolValueFalse
-	case lex.TokenLike: // a(value) LIKE b(pattern)
-		bv, ok := LikeCompare(a, b)
-		if !ok {
-			return value.NewErrorValuef("invalid LIKE pattern: %q", a)
-		}
-		return bv
 	case lex.TokenIN:
 		if a == b {
 			return value.BoolValueTrue
@@ -1168,21 +1611,60 @@ func operateTime(op lex.TokenType, lht, rht time.Time) (value.BoolValue, bool) {
 	return value.BoolValueFalse, false
 }
 
-// LikeCompare takes two strings and evaluates them for like equality
-func LikeCompare(a, b string) (value.BoolValue, bool) {
-	// Do we want to always do this replacement?   Or do this at parse time or config?
-	if strings.Contains(b, "%") {
-		b = strings.Replace(b, "%", "*", -1)
+// operateInterval date arithmetic, t + interval or t - interval
+func operateInterval(op lex.TokenType, t time.Time, iv value.IntervalValue) (value.TimeValue, bool) {
+	switch op {
+	case lex.TokenPlus:
+		return value.NewTimeValue(iv.AddTo(t)), true
+	case lex.TokenMinus:
+		return value.NewTimeValue(iv.Neg().AddTo(t)), true
 	}
-	match, err := glob.Match(b, a)
-	if err != nil {
-		return value.BoolValueFalse, false
+	return value.TimeZeroValue, false
+}
+
+// operateAtTimeZone the time of a in the zone named by b, strings without
+// a zone are read as wall clock time in that zone.
+//
+//    "2017-03-13 10:00" AT TIME ZONE 'America/Denver'  => 2017-03-13 10:00 -0600
+//    ts AT TIME ZONE '+05:30'
+//
+func operateAtTimeZone(a value.Value, aok bool, b value.Value, bok bool) (value.Value, bool) {
+	if !aok || !bok {
+		return nil, false
+	}
+	loc, ok := value.LoadLocation(b.ToString())
+	if !ok {
+		return nil, false
+	}
+	if loc == time.UTC {
+		// keep it apart from the default utc so it isn't moved to the
+		// session time zone
+		loc = value.UTC
+	}
+	if at, isTime := a.(value.TimeValue); isTime {
+		return value.NewTimeValue(at.Val().In(loc)), true
+	}
+	t, ok := value.ValueToTimeIn(a, time.Now(), loc)
+	if !ok {
+		return nil, false
 	}
-	if match {
-		return value.BoolValueTrue, true
+	return value.NewTimeValue(t), true
+}
+
+// operateIntervals add, subtract or compare intervals, comparisons use
+// the approximate Duration of each.
+func operateIntervals(op lex.TokenType, a, b value.IntervalValue) (value.Value, bool) {
+	switch op {
+	case lex.TokenPlus:
+		return a.Add(b), true
+	case lex.TokenMinus:
+		return a.Add(b.Neg()), true
+	case lex.TokenEqual, lex.TokenEqualEqual, lex.TokenNE, lex.TokenGT, lex.TokenGE, lex.TokenLT, lex.TokenLE:
+		return boolValue(compareInts(op, int64(a.Duration()), int64(b.Duration()))), true
 	}
-	return value.BoolValueFalse, true
+	return nil, false
 }
+
 func operateInts(op lex.Token, av, bv value.IntValue) value.Value {
 	a, b := av.Val(), bv.Val()
 	v, _ := operateIntVals(op, a, b)
@@ -44,3 +44,52 @@ func BenchmarkVmFuncNew(b *testing.B) {
 		}
 	}
 }
+
+/*
+
+go test -bench="VmFilter" -run=none
+
+Compiled filter vs re-walking the AST each row
+
+BenchmarkVmFilterEval        	  612927	      2101 ns/op
+BenchmarkVmFilterCompiled    	 3071358	       413 ns/op
+
+*/
+
+var benchFilter = `int5 > 2 AND str5 == "5" AND user_id IN ("abc", "def", "xyz") AND email LIKE "%@bob.com" AND created > "2010-01-01"`
+
+func BenchmarkVmFilterEval(b *testing.B) {
+
+	n, err := expr.ParseExpression(benchFilter)
+	if err != nil {
+		b.Fail()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matches, ok := vm.MatchesExpr(msgContext, n)
+		if !ok || !matches {
+			b.Fail()
+		}
+	}
+}
+
+func BenchmarkVmFilterCompiled(b *testing.B) {
+
+	n, err := expr.ParseExpression(benchFilter)
+	if err != nil {
+		b.Fail()
+	}
+	prog, err := vm.Compile(n)
+	if err != nil {
+		b.Fail()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		matches, ok := prog.Matches(msgContext)
+		if !ok || !matches {
+			b.Fail()
+		}
+	}
+}
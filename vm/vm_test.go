@@ -3,6 +3,7 @@ package vm_test
 import (
 	"flag"
 	"log"
+	"math"
 	"os"
 	"testing"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/araddon/qlbridge/datasource"
 	"github.com/araddon/qlbridge/expr"
 	"github.com/araddon/qlbridge/expr/builtins"
+	"github.com/araddon/qlbridge/lex"
 	"github.com/araddon/qlbridge/value"
 	"github.com/araddon/qlbridge/vm"
 )
@@ -44,6 +46,10 @@ func init() {
 var (
 	t0, _ = dateparse.ParseAny("12/18/2015")
 	t1, _ = dateparse.ParseAny("12/18/2019")
+
+	price, _ = value.ParseDecimal("19.99")
+	tax, _   = value.ParseDecimal("0.10")
+	jan31    = time.Date(2017, 1, 31, 10, 30, 0, 0, time.UTC)
 	// This is the message context which will be added to all tests below
 	//  and be available to the VM runtime for evaluation by using
 	//  key's such as "int5" or "user_id"
@@ -58,6 +64,14 @@ var (
 		"hits":    value.NewMapIntValue(map[string]int64{"google.com": 5, "bing.com": 1}),
 		"email":   value.NewStringValue("bob@bob.com"),
 		"mt":      value.NewMapTimeValue(map[string]time.Time{"event0": t0, "event1": t1}),
+		"nullv":   nil,
+		"emptys":  value.NewStringValue(""),
+		"int0":    value.NewIntValue(0),
+		"intmin":  value.NewIntValue(math.MinInt32),
+		"price":   price,
+		"tax":     tax,
+		"jan31":   value.NewTimeValue(jan31),
+		"day31":   value.NewDateValue(jan31),
 	}, true)
 	vmTestsx = []vmTest{
 		vmtall(`"a" IN ["a","b",10, 4.5]`, true, parseOk, evalError),
@@ -93,20 +107,38 @@ var (
 		vmt(`str5 NOT IN ("nope") AND userid NOT IN ("abc") AND email NOT IN ("jane@bob.com")`, true, noError),
 
 		// Native LIKE keyword
-		vmt(`["portland"] LIKE "*land"`, true, noError),
-		vmt(`["chicago"] LIKE "*land"`, false, noError),
+		vmt(`["portland"] LIKE "%land"`, true, noError),
+		vmt(`["chicago"] LIKE "%land"`, false, noError),
 		vmt(`["New York"] LIKE "New York"`, true, noError),
 		vmt(`"New York" LIKE ["Boston","New York"]`, true, noError),
 		vmt(`"New York" LIKE split("Boston,New York", ",")`, true, noError),
 		vmt(`"New York" LIKE split("Boston",",")`, false, noError),
 		vmtall(`user_id LIKE mt`, nil, parseOk, evalError),
-		vmt(`urls LIKE "a*"`, true, noError),
-		vmt(`urls LIKE "d*"`, false, noError),
-		vmt(`split("chicago,portland",",") LIKE "*land"`, true, noError),
-		vmt(`split("chicago,portland",",") LIKE "*sea"`, false, noError),
-		vmt(`email LIKE "bob*"`, true, noError),
+		vmt(`urls LIKE "a%"`, true, noError),
+		vmt(`urls LIKE "d%"`, false, noError),
+		vmt(`split("chicago,portland",",") LIKE "%land"`, true, noError),
+		vmt(`split("chicago,portland",",") LIKE "%sea"`, false, noError),
+		vmt(`email LIKE "bob%"`, true, noError),
 		vmt(`email LIKE "bob"`, false, noError),
-		vmt(`email LIKE "*.com"`, true, noError),
+		vmt(`email LIKE "%.com"`, true, noError),
+		vmt(`email LIKE "bo_@%"`, true, noError),
+		vmt(`email LIKE "b_@%"`, false, noError),
+		vmt(`email LIKE "BOB%"`, false, noError),
+		vmt(`email ILIKE "BOB%"`, true, noError),
+		vmt(`email NOT ILIKE "%BOB.COM"`, false, noError),
+		vmt(`"a*c" LIKE "a*c"`, true, noError),
+		vmt(`"abc" LIKE "a*c"`, false, noError),
+		vmt(`"50%" LIKE "50!%" ESCAPE "!"`, true, noError),
+		vmt(`"501" LIKE "50!%" ESCAPE "!"`, false, noError),
+		vmt(`"501" NOT LIKE "50!%" ESCAPE "!"`, true, noError),
+		vmtall(`"abc" LIKE "a!" ESCAPE "!"`, nil, parseOk, evalError),
+
+		// Native REGEXP, RLIKE and ~
+		vmt(`email REGEXP "^bob@"`, true, noError),
+		vmt(`email RLIKE "b.b"`, true, noError),
+		vmt(`email ~ "^[0-9]+$"`, false, noError),
+		vmt(`split("chicago,portland",",") REGEXP "land$"`, true, noError),
+		vmtall(`email REGEXP "("`, nil, parseOk, evalError),
 
 		// Native Contains keyword
 		vmt(`[1,2,3] contains int5`, false, noError),
@@ -124,6 +156,14 @@ var (
 		// depending on if is array, and we mean equality on array entry or?
 		vmt(`urls contains "ab"`, true, noError),
 
+		// Case
+		vmt(`CASE WHEN int5 > 10 THEN "big" WHEN int5 > 1 THEN "small" ELSE "none" END`, "small", noError),
+		vmt(`CASE WHEN int5 > 10 THEN "big" ELSE "none" END`, "none", noError),
+		vmt(`CASE WHEN not_a_field > 10 THEN "big" WHEN bvalt THEN toint(str5) + 1 END`, int64(6), noError),
+		vmt(`CASE str5 WHEN "4" THEN 4 WHEN "5" THEN 5 ELSE 0 END`, int64(5), noError),
+		vmt(`CASE user_id WHEN "abc" THEN true END`, true, noError),
+		vmt(`(CASE int5 WHEN 5 THEN 10 END) > 9`, true, noError),
+
 		// Between:  Ternary Node Tests
 		vmt(`10 BETWEEN 1 AND 50`, true, noError),
 		vmt(`10 BETWEEN "1" AND 50`, true, noError),
@@ -169,9 +209,9 @@ var (
 		vmt(`user_id == "abcd"`, false, noError),
 		vmt(`user_id != "abc"`, false, noError),
 		vmtall(`user_id > "abc"`, nil, parseOk, evalError),
-		vmt(`user_id LIKE "*bc"`, true, noError),
+		vmt(`user_id LIKE "%bc"`, true, noError),
 		vmt(`user_id LIKE "\*bc"`, false, noError),
-		vmt(`user_id != NULL`, true, noError),
+		vmt(`user_id IS NOT NULL`, true, noError),
 
 		// Binary Bool
 		vmt(`bvalt == true`, true, noError),
@@ -345,6 +385,286 @@ func TestRunExpr(t *testing.T) {
 	}
 }
 
+// SQL three-valued logic, NULL is UNKNOWN and is not the same as a
+// missing field (not_a_field)
+func TestNullSemantics(t *testing.T) {
+	null := value.NilValueVal
+	for _, test := range []struct {
+		qlText string
+		result value.Value
+	}{
+		{`nullv + 5`, null},
+		{`nullv > 5`, null},
+		{`nullv = nullv`, null},
+		{`nullv IN ("a", "b")`, null},
+		{`nullv BETWEEN 1 AND 10`, null},
+		{`NOT (nullv > 5)`, null},
+		{`nullv > 5 AND int5 > 1`, null},
+		{`nullv > 5 AND int5 > 10`, value.BoolValueFalse},
+		{`bvalf AND nullv`, value.BoolValueFalse},
+		{`nullv > 5 OR int5 > 1`, value.BoolValueTrue},
+		{`nullv > 5 OR int5 > 10`, null},
+		{`nullv IS NULL`, value.BoolValueTrue},
+		{`nullv IS NOT NULL`, value.BoolValueFalse},
+		{`not_a_field IS NULL`, value.BoolValueTrue},
+		{`not_a_field IS NOT NULL`, value.BoolValueFalse},
+		{`int5 IS NOT NULL`, value.BoolValueTrue},
+		// empty and zero values are values, not NULL
+		{`emptys IS NULL`, value.BoolValueFalse},
+		{`emptys IS NOT NULL`, value.BoolValueTrue},
+		{`int0 IS NULL`, value.BoolValueFalse},
+		{`intmin IS NULL`, value.BoolValueFalse},
+		{`intmin IS NOT NULL`, value.BoolValueTrue},
+		{`emptys IS DISTINCT FROM NULL`, value.BoolValueTrue},
+		{`intmin IS DISTINCT FROM NULL`, value.BoolValueTrue},
+		{`NOT (emptys IS NULL)`, value.BoolValueTrue},
+		// = and != against NULL are NULL, only IS [NOT] NULL tests for it
+		{`emptys = NULL`, null},
+		{`int0 = NULL`, null},
+		{`intmin != NULL`, null},
+		{`nullv = NULL`, null},
+		{`NULL = int5`, null},
+		{`nullv IS DISTINCT FROM not_a_field`, value.BoolValueFalse},
+		{`nullv IS NOT DISTINCT FROM NULL`, value.BoolValueTrue},
+		{`int5 IS DISTINCT FROM nullv`, value.BoolValueTrue},
+		{`int5 IS DISTINCT FROM 5`, value.BoolValueFalse},
+		{`str5 IS NOT DISTINCT FROM 5`, value.BoolValueTrue},
+		{`NOT (int5 IS DISTINCT FROM 5)`, value.BoolValueTrue},
+		{`coalesce(not_a_field, nullv, "", "x")`, value.NewStringValue("")},
+		{`coalesce(nullv, int5)`, value.NewIntValue(5)},
+		{`coalesce(not_a_field, nullv)`, null},
+		{`nullif(user_id, "abc")`, null},
+		{`nullif(user_id, "xyz")`, value.NewStringValue("abc")},
+		{`nullif(int5, 5) + 1`, null},
+		{`CASE not_a_field WHEN 5 THEN 10 END`, null},
+		{`CASE WHEN int5 > 10 THEN "big" END`, null},
+		{`CASE WHEN nullv > 1 THEN "yes" ELSE "no" END`, value.NewStringValue("no")},
+	} {
+		n, err := expr.ParseExpression(test.qlText)
+		if err != nil {
+			t.Errorf("%s: unexpected parse error %v", test.qlText, err)
+			continue
+		}
+		prog, err := vm.Compile(n)
+		if err != nil {
+			t.Errorf("%s: unexpected compile error %v", test.qlText, err)
+			continue
+		}
+		ctx := &includer{msgContext}
+		for _, eval := range []func(expr.EvalContext) (value.Value, bool){
+			func(ctx expr.EvalContext) (value.Value, bool) { return vm.Eval(ctx, n) },
+			prog.Eval,
+		} {
+			val, ok := eval(ctx)
+			if !ok || val != test.result {
+				t.Errorf("%s: expected %#v but got %#v ok=%v", test.qlText, test.result, val, ok)
+			}
+		}
+	}
+}
+
+// Decimals are exact, mixed with ints, strings and numbers they stay
+// decimal.
+func TestDecimalArithmetic(t *testing.T) {
+	for _, test := range []struct {
+		qlText string
+		result string
+		vt     value.ValueType
+	}{
+		{`tax + 0.2`, "0.30", value.DecimalType},
+		{`tax + 0.2 = 0.3`, "true", value.BoolType},
+		{`price * 3`, "59.97", value.DecimalType},
+		{`price * tax`, "1.9990", value.DecimalType},
+		{`price - "0.99"`, "19.00", value.DecimalType},
+		{`price / 3`, "6.663333", value.DecimalType},
+		{`price % 2`, "1.99", value.DecimalType},
+		{`10 - price`, "-9.99", value.DecimalType},
+		{`price > 19.98`, "true", value.BoolType},
+		{`price <= int5`, "false", value.BoolType},
+		{`price == "19.990"`, "true", value.BoolType},
+		{`price IN (1, 19.99)`, "true", value.BoolType},
+		{`price BETWEEN 19 AND 20`, "true", value.BoolType},
+		{`price + nullv`, "", value.NilType},
+	} {
+		n, err := expr.ParseExpression(test.qlText)
+		if err != nil {
+			t.Errorf("%s: unexpected parse error %v", test.qlText, err)
+			continue
+		}
+		prog, err := vm.Compile(n)
+		if err != nil {
+			t.Errorf("%s: unexpected compile error %v", test.qlText, err)
+			continue
+		}
+		ctx := &includer{msgContext}
+		for _, eval := range []func(expr.EvalContext) (value.Value, bool){
+			func(ctx expr.EvalContext) (value.Value, bool) { return vm.Eval(ctx, n) },
+			prog.Eval,
+		} {
+			val, ok := eval(ctx)
+			if !ok || val.Type() != test.vt || val.ToString() != test.result {
+				t.Errorf("%s: expected %s %q but got %#v ok=%v", test.qlText, test.vt, test.result, val, ok)
+			}
+		}
+	}
+	if val, ok := vm.Eval(&includer{msgContext}, expr.MustParse(`price / 0`)); ok {
+		t.Errorf("price / 0: expected divide by zero error but got %v", val)
+	}
+}
+
+func TestDateIntervalArithmetic(t *testing.T) {
+	for _, test := range []struct {
+		qlText string
+		result string
+		vt     value.ValueType
+	}{
+		{`jan31 + INTERVAL 1 MONTH`, "2017-02-28T10:30:00Z", value.TimeType},
+		{`jan31 + INTERVAL '3' DAY`, "2017-02-03T10:30:00Z", value.TimeType},
+		{`jan31 - INTERVAL '1 month 2 hours'`, "2016-12-31T08:30:00Z", value.TimeType},
+		{`INTERVAL 1 YEAR + jan31`, "2018-01-31T10:30:00Z", value.TimeType},
+		{`"2016-01-31" + INTERVAL 1 MONTH`, "2016-02-29T00:00:00Z", value.TimeType},
+		{`jan31 - todate("2017-01-01")`, "30 days 10 hours 30 minutes", value.IntervalType},
+		{`day31 + INTERVAL 1 MONTH`, "2017-02-28", value.DateType},
+		{`day31 + 1`, "2017-02-01", value.DateType},
+		{`day31 - INTERVAL 2 HOURS`, "2017-01-30T22:00:00Z", value.TimeType},
+		{`day31 - "2017-01-01"`, "30 days", value.IntervalType},
+		{`day31 = "2017-01-31"`, "true", value.BoolType},
+		{`day31 < jan31`, "true", value.BoolType},
+		{`day31 BETWEEN "2017-01-01" AND "2017-02-01"`, "true", value.BoolType},
+		{`INTERVAL 1 DAY * 3`, "3 days", value.IntervalType},
+		{`2 * INTERVAL 1 WEEK + INTERVAL 1 HOUR`, "14 days 1 hours", value.IntervalType},
+		{`INTERVAL 1 DAY > INTERVAL 23 HOURS`, "true", value.BoolType},
+		{`jan31 + INTERVAL 1 DAY > jan31`, "true", value.BoolType},
+	} {
+		n, err := expr.ParseExpression(test.qlText)
+		if err != nil {
+			t.Errorf("%s: unexpected parse error %v", test.qlText, err)
+			continue
+		}
+		prog, err := vm.Compile(n)
+		if err != nil {
+			t.Errorf("%s: unexpected compile error %v", test.qlText, err)
+			continue
+		}
+		ctx := &includer{msgContext}
+		for _, eval := range []func(expr.EvalContext) (value.Value, bool){
+			func(ctx expr.EvalContext) (value.Value, bool) { return vm.Eval(ctx, n) },
+			prog.Eval,
+		} {
+			val, ok := eval(ctx)
+			if !ok || val.Type() != test.vt {
+				t.Errorf("%s: expected %s %q but got %#v ok=%v", test.qlText, test.vt, test.result, val, ok)
+				continue
+			}
+			result := val.ToString()
+			if tv, isTime := val.(value.TimeValue); isTime {
+				result = tv.Val().Format(time.RFC3339)
+			}
+			if result != test.result {
+				t.Errorf("%s: expected %q but got %q", test.qlText, test.result, result)
+			}
+		}
+	}
+}
+
+func TestSessionTimeZone(t *testing.T) {
+	session := datasource.NewContextSimpleNative(map[string]interface{}{
+		"@@time_zone": "America/Denver",
+	})
+	for _, test := range []struct {
+		qlText string
+		utc    string
+		denver string
+	}{
+		// strings compared to times are read in the session time zone
+		{`jan31 = "2017-01-31 03:30"`, "false", "true"},
+		{`"2017-01-31 03:30" = jan31`, "false", "true"},
+		{`jan31 > "2017-01-31 04:00"`, "true", "false"},
+		{`jan31 > "2017-01-31T04:00:00Z"`, "true", "true"},
+		{`jan31 AT TIME ZONE "America/Denver"`, "2017-01-31T03:30:00-07:00", "2017-01-31T03:30:00-07:00"},
+		{`"2017-01-31 03:30" AT TIME ZONE "America/Denver" = jan31`, "true", "true"},
+	} {
+		n, err := expr.ParseExpression(test.qlText)
+		if err != nil {
+			t.Errorf("%s: unexpected parse error %v", test.qlText, err)
+			continue
+		}
+		prog, err := vm.Compile(n)
+		if err != nil {
+			t.Errorf("%s: unexpected compile error %v", test.qlText, err)
+			continue
+		}
+		utc := &includer{msgContext}
+		denver := datasource.NewNestedContextReader([]expr.ContextReader{msgContext, session}, jan31)
+		for _, eval := range []func(expr.EvalContext) (value.Value, bool){
+			func(ctx expr.EvalContext) (value.Value, bool) { return vm.Eval(ctx, n) },
+			prog.Eval,
+		} {
+			for ctx, expected := range map[expr.EvalContext]string{utc: test.utc, denver: test.denver} {
+				val, ok := eval(ctx)
+				if !ok {
+					t.Errorf("%s: expected %q but got %#v ok=%v", test.qlText, expected, val, ok)
+					continue
+				}
+				result := val.ToString()
+				if tv, isTime := val.(value.TimeValue); isTime {
+					result = tv.Val().Format(time.RFC3339)
+				}
+				if result != expected {
+					t.Errorf("%s: expected %q but got %q", test.qlText, expected, result)
+				}
+			}
+		}
+	}
+}
+
+func TestUserFunc(t *testing.T) {
+	parse := func(qlText string, fr expr.FuncResolver) expr.Node {
+		n, err := expr.ParseExprWithFuncs(expr.NewLexTokenPager(lex.NewLexer(qlText, lex.LogicalExpressionDialect)), fr)
+		if err != nil {
+			t.Fatalf("%s: unexpected parse error %v", qlText, err)
+		}
+		return n
+	}
+	fr := expr.NewFuncRegistry()
+	fr.Add("user_domain", expr.NewUserFunc("user_domain", []string{"addr"}, parse(`tolower(emaildomain(addr))`, fr)))
+	fr.Add("is_domain", expr.NewUserFunc("is_domain", []string{"addr", "d"}, parse(`user_domain(addr) = d`, fr)))
+	fr.Add("plus_int5", expr.NewUserFunc("plus_int5", []string{"n"}, parse(`n + int5`, fr)))
+
+	for _, test := range []struct {
+		qlText string
+		result string
+	}{
+		{`user_domain("Bob@Bob.COM")`, "bob.com"},
+		{`user_domain(email)`, "bob.com"},
+		{`is_domain(email, "bob.com")`, "true"},
+		{`is_domain(email, "bob.org")`, "false"},
+		// other keys are read from the calling context
+		{`plus_int5(2)`, "7"},
+		{`plus_int5(int5)`, "10"},
+	} {
+		n := parse(test.qlText, fr)
+		prog, err := vm.Compile(n)
+		if err != nil {
+			t.Errorf("%s: unexpected compile error %v", test.qlText, err)
+			continue
+		}
+		ctx := &includer{msgContext}
+		for _, eval := range []func(expr.EvalContext) (value.Value, bool){
+			func(ctx expr.EvalContext) (value.Value, bool) { return vm.Eval(ctx, n) },
+			prog.Eval,
+		} {
+			val, ok := eval(ctx)
+			if !ok || val.ToString() != test.result {
+				t.Errorf("%s: expected %q but got %#v ok=%v", test.qlText, test.result, val, ok)
+			}
+		}
+	}
+	if _, err := expr.ParseExprWithFuncs(expr.NewLexTokenPager(lex.NewLexer(`user_domain(email, 1)`, lex.LogicalExpressionDialect)), fr); err == nil {
+		t.Errorf("expected error for wrong number of args")
+	}
+}
+
 type vmTest struct {
 	qlText  string
 	parseok bool
@@ -0,0 +1,207 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/rel"
+)
+
+var (
+	// Ensure we implement the exec interfaces
+	_ exec.Executor   = (*Executor)(nil)
+	_ exec.TaskRunner = (*remoteSource)(nil)
+)
+
+// Executor is a JobExecutor that ships the partition scans of a query
+// to workers instead of running them locally.  The rest of the dag,
+// merging partial aggregates, ordering, projection, runs here.
+type Executor struct {
+	*exec.JobExecutor
+	Workers []string
+	Client  *http.Client
+	secret  string
+	next    int
+}
+
+// NewExecutor creates a coordinating Executor for the list of worker
+// addresses and the secret they share, with no workers everything
+// runs locally.
+func NewExecutor(ctx *plan.Context, workers []string, secret string) *Executor {
+	m := &Executor{
+		JobExecutor: exec.NewExecutor(ctx, plan.NewPlanner(ctx)),
+		Workers:     workers,
+		Client:      http.DefaultClient,
+		secret:      secret,
+	}
+	m.JobExecutor.Executor = m
+	return m
+}
+
+// BuildSqlJob given a plan context create a job whose partition scans
+// run on the workers.
+func BuildSqlJob(ctx *plan.Context, workers []string, secret string) (*Executor, error) {
+	job := NewExecutor(ctx, workers, secret)
+	task, err := exec.BuildSqlJobPlanned(job.Planner, job, ctx)
+	if err != nil {
+		return nil, err
+	}
+	taskRunner, ok := task.(exec.TaskRunner)
+	if !ok {
+		return nil, fmt.Errorf("Expected TaskRunner but was %T", task)
+	}
+	job.RootTask = taskRunner
+	return job, nil
+}
+
+// WalkPartitionMerge sends each partition as its own fragment to the
+// next worker, round-robin.
+func (m *Executor) WalkPartitionMerge(p *plan.PartitionMerge) (exec.Task, error) {
+	if len(m.Workers) == 0 {
+		return m.JobExecutor.WalkPartitionMerge(p)
+	}
+	sel, ok := m.Ctx.Stmt.(*rel.SqlSelect)
+	if !ok {
+		return nil, fmt.Errorf("Expected SqlSelect but was %T", m.Ctx.Stmt)
+	}
+
+	execTask := exec.NewTaskParallel(m.Ctx)
+	parts := make([]exec.TaskRunner, 0, len(p.Parts))
+	for _, pp := range p.Parts {
+		fragment := &plan.Select{Stmt: sel, PlanBase: plan.NewPlanBase(false), Ctx: m.Ctx}
+		fragment.Add(pp)
+		pb, err := fragment.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		// the worker opens its own conn to the partition
+		if src, ok := pp.(*plan.Source); ok && src.Conn != nil {
+			src.Conn.Close()
+			src.Conn = nil
+		}
+
+		addr := m.Workers[m.next%len(m.Workers)]
+		m.next++
+
+		part := exec.NewTaskSequential(m.Ctx)
+		if err = part.Add(newRemoteSource(m.Ctx, m.Client, addr, m.secret, pb)); err != nil {
+			return nil, err
+		}
+		if err = execTask.Add(part); err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+
+	pm := exec.NewPartitionMerge(m.Ctx, parts)
+	if err := execTask.Add(pm); err != nil {
+		return nil, err
+	}
+	return execTask, nil
+}
+
+// remoteSource posts a fragment to a worker and emits the rows
+// it streams back.
+type remoteSource struct {
+	*exec.TaskBase
+	client   *http.Client
+	url      string
+	secret   string
+	fragment []byte
+}
+
+func newRemoteSource(ctx *plan.Context, client *http.Client, addr, secret string, fragment []byte) *remoteSource {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return &remoteSource{
+		TaskBase: exec.NewTaskBase(ctx),
+		client:   client,
+		url:      strings.TrimRight(addr, "/") + FragmentPath,
+		secret:   secret,
+		fragment: fragment,
+	}
+}
+
+func (m *remoteSource) Run() error {
+	defer m.Ctx.Recover()
+	defer close(m.MessageOut())
+
+	ctx, cancel := context.WithCancel(m.Ctx.Context)
+	defer cancel()
+	go func() {
+		select {
+		case <-m.SigChan():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	req, err := http.NewRequest("POST", m.url, bytes.NewReader(m.fragment))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(SecretHeader, m.secret)
+	req.Header.Set(UserHeader, m.Ctx.User)
+	resp, err := m.client.Do(req.WithContext(ctx))
+	if err != nil {
+		if m.closed() {
+			return nil
+		}
+		u.Warnf("could not reach worker %s err=%v", m.url, err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("worker %s: %s %s", m.url, resp.Status, bytes.TrimSpace(msg))
+	}
+
+	outCh := m.MessageOut()
+	dec := gob.NewDecoder(resp.Body)
+	var cols map[string]int
+	for {
+		f := &Frame{}
+		if err = dec.Decode(f); err != nil {
+			if m.closed() {
+				return nil
+			}
+			return fmt.Errorf("worker %s: %v", m.url, err)
+		}
+		if f.Done {
+			if f.Err != "" {
+				return fmt.Errorf("worker %s: %s", m.url, f.Err)
+			}
+			return nil
+		}
+		if f.Cols != nil {
+			cols = f.Cols
+		}
+		select {
+		case outCh <- datasource.NewSqlDriverMessageMap(f.Id, f.Vals, cols):
+		case <-m.SigChan():
+			return nil
+		}
+	}
+}
+
+// closed is true once the task has been told to stop, ie a limit
+// was reached, and the request to the worker was hung up.
+func (m *remoteSource) closed() bool {
+	select {
+	case <-m.SigChan():
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,157 @@
+package worker
+
+import (
+	"crypto/subtle"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+
+	u "github.com/araddon/gou"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+)
+
+var (
+	// Ensure we implement http handler
+	_ http.Handler = (*Server)(nil)
+)
+
+// Server is the http handler of a worker, it executes the plan
+// fragments posted to it and streams back the results.
+type Server struct {
+	loader plan.SchemaLoader
+	secret []byte
+}
+
+// NewServer creates a worker Server that uses loader to find the
+// schema of the fragments it is sent, and only runs fragments sent
+// with the shared secret.  An empty secret refuses every fragment.
+func NewServer(loader plan.SchemaLoader, secret string) *Server {
+	return &Server{loader: loader, secret: []byte(secret)}
+}
+
+// ServeHTTP reads a serialized plan.Select fragment from the post body.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "fragments must be POST'd", http.StatusMethodNotAllowed)
+		return
+	}
+	if len(s.secret) == 0 || subtle.ConstantTimeCompare([]byte(r.Header.Get(SecretHeader)), s.secret) != 1 {
+		http.Error(w, "invalid worker secret", http.StatusUnauthorized)
+		return
+	}
+	pb, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p, err := plan.SelectPlanFromPbBytes(pb, s.loader)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if p.Ctx == nil {
+		http.Error(w, "fragment has no context", http.StatusBadRequest)
+		return
+	}
+	// the coordinator checked the user already, a worker may have
+	// grants of its own so check again.
+	p.Ctx.User = r.Header.Get(UserHeader)
+	if err = plan.Authorize(p.Ctx, p.Stmt); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	enc := gob.NewEncoder(w)
+	done := &Frame{Done: true}
+	if err = s.run(p, enc); err != nil {
+		u.Warnf("fragment %q failed err=%v", p.Ctx.Raw, err)
+		done.Err = err.Error()
+	}
+	if err = enc.Encode(done); err != nil {
+		u.Warnf("could not write final frame err=%v", err)
+	}
+}
+
+func (s *Server) run(p *plan.Select, enc *gob.Encoder) error {
+
+	ctx := p.Ctx
+	job := exec.NewExecutor(ctx, plan.NewPlanner(ctx))
+	task, err := job.WalkSelect(p)
+	if err != nil {
+		return err
+	}
+	root, ok := task.(exec.TaskRunner)
+	if !ok {
+		return fmt.Errorf("Expected TaskRunner but was %T", task)
+	}
+	job.RootTask = root
+
+	out := newFrameWriter(ctx, enc)
+	if err = root.Add(out); err != nil {
+		return err
+	}
+	if err = job.Setup(); err != nil {
+		return err
+	}
+	err = job.Run()
+	job.Close()
+	if err != nil {
+		return err
+	}
+	return out.err
+}
+
+// frameWriter is the last task of a fragment, it encodes each
+// message onto the response.
+type frameWriter struct {
+	*exec.TaskBase
+	enc  *gob.Encoder
+	cols map[string]int
+	err  error
+}
+
+func newFrameWriter(ctx *plan.Context, enc *gob.Encoder) *frameWriter {
+	m := &frameWriter{
+		TaskBase: exec.NewTaskBase(ctx),
+		enc:      enc,
+	}
+	m.Handler = func(ctx *plan.Context, msg schema.Message) bool {
+		if m.err != nil {
+			return false
+		}
+		f := &Frame{}
+		switch mt := msg.(type) {
+		case nil:
+			// shutdown signal after a limit
+			return true
+		case *datasource.SqlDriverMessageMap:
+			f.Id = mt.Id()
+			f.Vals = mt.Values()
+			// the column index is shared by all messages of a task
+			if reflect.ValueOf(mt.ColIndex).Pointer() != reflect.ValueOf(m.cols).Pointer() {
+				m.cols = mt.ColIndex
+				f.Cols = mt.ColIndex
+			}
+		case *datasource.SqlDriverMessage:
+			f.Id = mt.Id()
+			f.Vals = mt.Vals
+		default:
+			m.err = fmt.Errorf("unsupported message type %T", msg)
+			return false
+		}
+		if err := m.enc.Encode(f); err != nil {
+			// the coordinator went away
+			m.err = err
+			return false
+		}
+		return true
+	}
+	return m
+}
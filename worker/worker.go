@@ -0,0 +1,44 @@
+// Package worker executes serialized plan fragments on remote processes.
+//
+// A Server accepts a protobuf serialized plan.Select fragment over http,
+// runs it with an exec.JobExecutor and streams the resulting rows back
+// as gob encoded Frames.  The coordinating Executor ships the partition
+// scans of a query to a list of workers and merges their results.
+//
+//   coordinator                              workers
+//
+//   Executor -- POST /fragment (PlanPb) -->  Server -> JobExecutor
+//            <-- Frame, Frame, ... Done ---
+//
+// The coordinator and its workers share a secret, sent on each fragment
+// with the user of the query, whose privileges the worker checks again.
+// The secret is sent in the clear, run workers on a trusted network or
+// behind TLS.
+package worker
+
+import (
+	"database/sql/driver"
+)
+
+const (
+	// FragmentPath is the http path the Server accepts fragments on.
+	FragmentPath = "/fragment"
+	// ContentType of the gob encoded Frame stream.
+	ContentType = "application/x-qlbridge-frames"
+	// SecretHeader carries the secret shared by coordinator and workers.
+	SecretHeader = "X-Qlbridge-Secret"
+	// UserHeader carries the user the fragment runs as.
+	UserHeader = "X-Qlbridge-User"
+)
+
+// Frame is a single message of the result stream of a fragment.  The
+// column index is only sent when it changes, the final frame is marked
+// Done and carries the error of the fragment if any.  The value types
+// of Vals are registered with gob by exec.
+type Frame struct {
+	Id   uint64
+	Vals []driver.Value
+	Cols map[string]int
+	Err  string
+	Done bool
+}
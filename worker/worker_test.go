@@ -0,0 +1,111 @@
+package worker_test
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/araddon/qlbridge/datasource"
+	"github.com/araddon/qlbridge/datasource/memdb"
+	"github.com/araddon/qlbridge/exec"
+	"github.com/araddon/qlbridge/plan"
+	"github.com/araddon/qlbridge/schema"
+	"github.com/araddon/qlbridge/worker"
+)
+
+func loadSchema(name string) (*schema.Schema, error) {
+	s, ok := schema.DefaultRegistry().Schema(name)
+	if !ok {
+		return nil, fmt.Errorf("schema %q not found", name)
+	}
+	return s, nil
+}
+
+func TestWorkers(t *testing.T) {
+
+	// 3 users with 100 orders each, id's hash across partitions
+	rows := make([][]driver.Value, 0, 300)
+	for i := 0; i < 300; i++ {
+		rows = append(rows, []driver.Value{int64(i), fmt.Sprintf("user%d", i%3), int64(i % 10)})
+	}
+	db, err := memdb.NewMemDbData("orders", rows, []string{"order_id", "user_id", "price"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, schema.RegisterSourceAsSchema("workerdb", db))
+	s, err := loadSchema("workerdb")
+	assert.Equal(t, nil, err)
+	s.Conf = &schema.ConfigSource{Name: "workerdb", PartitionCt: 4}
+	assert.Equal(t, nil, db.Setup(s))
+
+	workers := make([]string, 0, 3)
+	for i := 0; i < 3; i++ {
+		ts := httptest.NewServer(worker.NewServer(loadSchema, "sekret"))
+		defer ts.Close()
+		workers = append(workers, ts.URL)
+	}
+
+	run := func(sql string, workers []string, secret string) ([][]driver.Value, error) {
+		ctx := plan.NewContext(sql)
+		ctx.DisableRecover = true
+		ctx.Schema = s
+		ctx.Session = datasource.NewMySqlSessionVars()
+		job, err := worker.BuildSqlJob(ctx, workers, secret)
+		if err != nil {
+			return nil, err
+		}
+		msgs := make([]schema.Message, 0)
+		job.RootTask.Add(exec.NewResultBuffer(ctx, &msgs))
+		if err = job.Setup(); err != nil {
+			return nil, err
+		}
+		err = job.Run()
+		job.Close()
+		vals := make([][]driver.Value, 0, len(msgs))
+		for _, msg := range msgs {
+			// a nil message is the shutdown signal after a limit
+			if msg != nil {
+				vals = append(vals, msg.(*datasource.SqlDriverMessageMap).Values())
+			}
+		}
+		return vals, err
+	}
+
+	for _, sql := range []string{
+		`SELECT count(*) FROM orders`,
+		`SELECT user_id, count(*), sum(price), avg(price) FROM orders
+			WHERE price > 4 GROUP BY user_id ORDER BY user_id ASC`,
+		`SELECT price, count(*) FROM orders WHERE price < 2 GROUP BY price ORDER BY price ASC`,
+		`SELECT order_id, user_id FROM orders WHERE order_id < 5 ORDER BY order_id ASC`,
+	} {
+		remote, err := run(sql, workers, "sekret")
+		assert.Equal(t, nil, err, sql)
+		local, err := run(sql, nil, "")
+		assert.Equal(t, nil, err, sql)
+		assert.NotEqual(t, 0, len(local), sql)
+		assert.Equal(t, local, remote, sql)
+	}
+
+	vals, err := run(`SELECT order_id FROM orders`, workers, "sekret")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 300, len(vals))
+
+	vals, err = run(`SELECT order_id FROM orders LIMIT 10`, workers, "sekret")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 10, len(vals))
+
+	// a worker that is not there fails the query
+	down := httptest.NewServer(worker.NewServer(loadSchema, "sekret"))
+	down.Close()
+	_, err = run(`SELECT count(*) FROM orders`, append(workers, down.URL), "sekret")
+	assert.NotEqual(t, nil, err)
+
+	// fragments without the shared secret are refused
+	_, err = run(`SELECT count(*) FROM orders`, workers, "wrong")
+	assert.NotEqual(t, nil, err)
+	open := httptest.NewServer(worker.NewServer(loadSchema, ""))
+	defer open.Close()
+	_, err = run(`SELECT count(*) FROM orders`, []string{open.URL}, "")
+	assert.NotEqual(t, nil, err)
+}